@@ -0,0 +1,87 @@
+// Command launch starts a network of nodes from a topology file or a
+// named profile, so that multi-node experiments start with one
+// command:
+//
+//	launch -topology small
+//	launch -topology my-network.json -subprocesses
+//
+// The network runs until it is interrupted. With -subprocesses, each
+// node runs in its own copy of launch, started with -plan and -node.
+package main
+
+import (
+	"Coin/pkg/chainparams"
+	"Coin/pkg/launch"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+func main() {
+	topology := flag.String("topology", "small", "a profile ("+strings.Join(launch.ProfileNames(), ", ")+") or a topology file")
+	dataDir := flag.String("data", "", "the directory to keep the network's data in")
+	basePort := flag.Int("port", 0, "the port of the first node, or 0 to pick free ports")
+	subprocesses := flag.Bool("subprocesses", false, "run each node in its own process")
+	planPath := flag.String("plan", "", "run a single node of the network planned in this file")
+	node := flag.Int("node", -1, "the node of -plan to run")
+	chain := flag.String("network", "", "the chain to run ("+strings.Join(chainparams.Names(), ", ")+"), instead of the topology's")
+	flag.Parse()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	if *planPath != "" {
+		p, err := launch.ReadPlan(*planPath)
+		if err != nil {
+			fail(err)
+		}
+		n, err := launch.RunNode(p, *node)
+		if err != nil {
+			fail(err)
+		}
+		<-stop
+		n.Kill()
+		return
+	}
+
+	t, err := launch.Load(*topology)
+	if err != nil {
+		fail(err)
+	}
+	if *dataDir != "" {
+		t.DataDir = *dataDir
+	}
+	if *basePort != 0 {
+		t.BasePort = *basePort
+	}
+	if *chain != "" {
+		t.Network = *chain
+		if err = t.Validate(); err != nil {
+			fail(err)
+		}
+	}
+	t.Subprocesses = t.Subprocesses || *subprocesses
+	executable, err := os.Executable()
+	if err != nil {
+		fail(err)
+	}
+	network, err := launch.Launch(t, executable)
+	if err != nil {
+		fail(err)
+	}
+	for _, spec := range network.Plan.Nodes {
+		fmt.Printf("node %v: %v on port %v, data in %v\n", spec.Index, spec.Role, spec.Port, spec.DataDir)
+	}
+	<-stop
+	network.Kill()
+	network.Wait()
+}
+
+// fail prints err and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
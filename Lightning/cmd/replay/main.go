@@ -0,0 +1,89 @@
+// Command replay feeds the messages a node received, as captured with
+// its CaptureConfig, back into a fresh node, so that a failure seen on
+// a real network can be reproduced locally:
+//
+//	replay -capture capture.log
+//
+// Each replayed message is printed with the error its handler
+// returned, if any. The fresh node keeps its data in -data, which is
+// a temporary directory by default.
+package main
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/capture"
+	"flag"
+	"fmt"
+	"github.com/phayes/freeport"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	capturePath := flag.String("capture", "capture.log", "the file the messages were captured to")
+	port := flag.Int("port", 0, "the port the fresh node runs on, or 0 to pick a free port")
+	dataDir := flag.String("data", "", "the directory to keep the fresh node's data in")
+	flag.Parse()
+
+	records, err := capture.Read(*capturePath)
+	if err != nil {
+		fail(err)
+	}
+	if *dataDir == "" {
+		if *dataDir, err = ioutil.TempDir("", "replay"); err != nil {
+			fail(err)
+		}
+		defer os.RemoveAll(*dataDir)
+	}
+	if *port == 0 {
+		if *port, err = freeport.GetFreePort(); err != nil {
+			fail(err)
+		}
+	}
+	n, err := pkg.New(config(*port, *dataDir))
+	if err != nil {
+		fail(err)
+	}
+	n.Start()
+	defer n.Kill()
+
+	failed := 0
+	for i, r := range capture.Replay(records, n, n.LightningNode) {
+		result := "ok"
+		if r.Err != nil {
+			result = r.Err.Error()
+			failed++
+		}
+		fmt.Printf("%v: %v from %v: %v\n", i, r.Record.Method, r.Record.Peer, result)
+	}
+	fmt.Printf("replayed %v of %v captured messages, %v failed\n", countInbound(records), len(records), failed)
+}
+
+// config returns the Config of a fresh node that keeps its data in dir.
+func config(port int, dir string) *pkg.Config {
+	c := pkg.DefaultConfig(port)
+	c.ChainConfig.BlockInfoDBPath = filepath.Join(dir, "blockinfodata")
+	c.ChainConfig.CoinDBPath = filepath.Join(dir, "coindata")
+	c.ChainConfig.ChainWriterDBPath = filepath.Join(dir, "data")
+	c.JournalConfig.Path = filepath.Join(dir, "journal.log")
+	c.BackupConfig.Path = filepath.Join(dir, "backup.bin")
+	return c
+}
+
+// countInbound returns how many of the records were received.
+func countInbound(records []*capture.Record) int {
+	n := 0
+	for _, r := range records {
+		if r.Direction == capture.Inbound {
+			n++
+		}
+	}
+	return n
+}
+
+// fail prints err and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
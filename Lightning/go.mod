@@ -3,6 +3,7 @@ module Coin
 go 1.16
 
 require (
+	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db
 	github.com/phayes/freeport v0.0.0-20180830031419-95f893ade6f2
 	github.com/syndtr/goleveldb v1.0.0
 	go.uber.org/atomic v1.7.0
@@ -5,10 +5,14 @@ import (
 	"time"
 )
 
+// Address is where a node can be reached.
+// BlocksOnly is whether we and the node have agreed to only exchange
+// blocks, and not loose transactions, so it isn't sent any.
 type Address struct {
-	Addr     string
-	LastSeen uint32
-	SentVer  time.Time
+	Addr       string
+	LastSeen   uint32
+	SentVer    time.Time
+	BlocksOnly bool
 }
 
 func New(addr string, lastSeen uint32) *Address {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"sync"
 	"time"
 )
 
@@ -25,11 +26,34 @@ func clientUnaryInterceptor(
 	return invoker(ctx, method, req, reply, cc, opts...)
 }
 
+// captureInterceptor, if set, sees every rpc sent to a peer, so that
+// it can be captured. It is shared by every node in the process.
+var (
+	captureMutex       sync.RWMutex
+	captureInterceptor grpc.UnaryClientInterceptor
+)
+
+// SetCaptureInterceptor sets the interceptor that every rpc sent to a
+// peer goes through, or clears it if i is nil. Since rpcs are sent
+// from here rather than from a node, it applies to every node in the
+// process.
+func SetCaptureInterceptor(i grpc.UnaryClientInterceptor) {
+	captureMutex.Lock()
+	defer captureMutex.Unlock()
+	captureInterceptor = i
+}
+
 func connectToServer(addr string) (*grpc.ClientConn, error) {
+	interceptors := []grpc.UnaryClientInterceptor{clientUnaryInterceptor}
+	captureMutex.RLock()
+	if captureInterceptor != nil {
+		interceptors = append(interceptors, captureInterceptor)
+	}
+	captureMutex.RUnlock()
 	return grpc.Dial(addr, []grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.FailOnNonTempDialError(true),
-		grpc.WithUnaryInterceptor(clientUnaryInterceptor),
+		grpc.WithChainUnaryInterceptor(interceptors...),
 	}...)
 }
 
@@ -75,6 +99,22 @@ func (a *Address) GetBlocksRPC(request *pro.GetBlocksRequest) (*pro.GetBlocksRes
 	return reply, err2
 }
 
+func (a *Address) GetHeadersRPC(request *pro.GetHeadersRequest) (*pro.GetHeadersResponse, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetHeadersRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetHeaders(context.Background(), request)
+	return reply, err2
+}
+
 func (a *Address) GetDataRPC(request *pro.GetDataRequest) (*pro.GetDataResponse, error) {
 	c, cc, err := a.GetConnection()
 	if err != nil {
@@ -124,6 +164,10 @@ func (a *Address) SendAddressesRPC(request *pro.Addresses) (*pro.Empty, error) {
 }
 
 func (a *Address) ForwardTransactionRPC(request *pro.TransactionWithAddress) (*pro.Empty, error) {
+	// a node that only exchanges blocks doesn't want transactions
+	if a.BlocksOnly {
+		return &pro.Empty{}, nil
+	}
 	c, cc, err := a.GetConnection()
 	if err != nil {
 		return nil, err
@@ -171,6 +215,204 @@ func (a *Address) GetWitnessesRPC(request *pro.Transaction) (*pro.Witnesses, err
 	return reply, err2
 }
 
+func (a *Address) GetBlockTreeRPC(request *pro.Empty) (*pro.BlockTree, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetBlockTreeRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetBlockTree(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) GetUtxoDeltaRPC(request *pro.UtxoDeltaRequest) (*pro.UtxoDelta, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetUtxoDeltaRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetUtxoDelta(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) CaptureProfileRPC(request *pro.ProfileRequest) (*pro.ProfileResponse, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.CaptureProfileRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.CaptureProfile(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) InvalidateBlockRPC(request *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.InvalidateBlockRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.InvalidateBlock(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) ReconsiderBlockRPC(request *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.ReconsiderBlockRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.ReconsiderBlock(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) GetDeploymentsRPC(request *pro.Empty) (*pro.Deployments, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetDeploymentsRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetDeployments(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) LoadBlocksRPC(request *pro.LoadBlocksRequest) (*pro.LoadBlocksResponse, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.LoadBlocksRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.LoadBlocks(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) GetSnapshotsRPC(request *pro.Empty) (*pro.SnapshotList, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetSnapshotsRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetSnapshots(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) GetSnapshotChunkRPC(request *pro.SnapshotChunkRequest) (*pro.SnapshotChunk, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetSnapshotChunkRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetSnapshotChunk(context.Background(), request)
+	return reply, err2
+}
+
+// GetNodeStatusRPC is used to get a node's address, version, tip,
+// peers, and whether its tip has gone stale
+func (a *Address) GetNodeStatusRPC(request *pro.Empty) (*pro.NodeStatus, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetNodeStatusRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetNodeStatus(context.Background(), request)
+	return reply, err2
+}
+
+// GetRefusedReorgsRPC is used to get the heavier branches a node refused to
+// reorg to
+func (a *Address) GetRefusedReorgsRPC(request *pro.Empty) (*pro.RefusedReorgs, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.GetRefusedReorgsRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.GetRefusedReorgs(context.Background(), request)
+	return reply, err2
+}
+
+// ConfirmReorgRPC is used to confirm a reorg a node refused, switching its
+// active chain to the branch
+func (a *Address) ConfirmReorgRPC(request *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	c, cc, err := a.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.ConfirmReorgRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.ConfirmReorg(context.Background(), request)
+	return reply, err2
+}
+
 //-------------------------- Lightning --------------------------//
 
 // GetLightningConnection Returns callback to close connection
@@ -246,3 +488,35 @@ func (a *Address) GetRevocationKeyRPC(request *pro.SignedTransactionWithKey) (*p
 	reply, err2 := c.GetRevocationKey(context.Background(), request)
 	return reply, err2
 }
+
+func (a *Address) ProbeChannelRPC(request *pro.ProbeRequest) (*pro.ProbeResponse, error) {
+	c, cc, err := a.GetLightningConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.ProbeChannelRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.ProbeChannel(context.Background(), request)
+	return reply, err2
+}
+
+func (a *Address) CooperativeCloseRPC(request *pro.CloseChannelRequest) (*pro.CloseChannelResponse, error) {
+	c, cc, err := a.GetLightningConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = cc.Close()
+		if err != nil {
+			fmt.Printf("ERROR {Address.CooperativeCloseRPC}: " +
+				"error when closing connection")
+		}
+	}()
+	reply, err2 := c.CooperativeClose(context.Background(), request)
+	return reply, err2
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"fmt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+)
+
+// adminMethods are the rpcs only the node's operator may call. Peers
+// can't reach them on the node's Port, only on its AdminPort, which
+// the node listens on for the loopback interface alone.
+var adminMethods = map[string]bool{
+	"/Coin/ConfirmReorg":    true,
+	"/Coin/InvalidateBlock": true,
+	"/Coin/ReconsiderBlock": true,
+	"/Coin/LoadBlocks":      true,
+	"/Coin/CaptureProfile":  true,
+}
+
+// peerInterceptor refuses the adminMethods to whoever calls them on
+// the node's Port, since anyone on the network can.
+func peerInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if adminMethods[info.FullMethod] {
+		return nil, status.Errorf(codes.PermissionDenied, "%v is only served on the admin port", info.FullMethod)
+	}
+	return handler(ctx, req)
+}
+
+// StartAdminServer serves every rpc, including the adminMethods, to
+// the node's operator, on the Config's AdminPort of the loopback
+// interface. It does nothing if the node has no AdminPort.
+func (n *Node) StartAdminServer() error {
+	if n.Config.AdminPort == 0 {
+		return nil
+	}
+	lis, err := net.Listen("tcp4", fmt.Sprintf("127.0.0.1:%v", n.Config.AdminPort))
+	if err != nil {
+		return fmt.Errorf("[Node.StartAdminServer] %v", err)
+	}
+	n.AdminServer = grpc.NewServer()
+	pro.RegisterCoinServer(n.AdminServer, n)
+	go func() {
+		if err := n.AdminServer.Serve(lis); err != nil {
+			utils.Debug.Printf("[Node.StartAdminServer] %v", err)
+		}
+	}()
+	return nil
+}
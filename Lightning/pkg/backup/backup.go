@@ -0,0 +1,259 @@
+// Package backup writes encrypted backups of a node: its wallet,
+// its lightning channels, and its identity keys, all in a single
+// archive.
+//
+// The archive is encrypted with a random data key, and the data
+// key is itself encrypted with a key derived from a passphrase.
+// Changing the passphrase only re-encrypts the data key, so a
+// backup can be moved to a new passphrase without being recreated.
+package backup
+
+import (
+	"Coin/pkg/lightning"
+	"Coin/pkg/wallet"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// version is the version of the archive format.
+const version = 1
+
+// keyLength is the length, in bytes, of every key used to encrypt
+// an archive.
+const keyLength = 32
+
+// saltLength is the length, in bytes, of the salt mixed into the
+// passphrase.
+const saltLength = 16
+
+// Keys is a public, private key pair.
+type Keys struct {
+	PublicKey  []byte
+	PrivateKey []byte
+}
+
+// Archive is everything that goes into a backup.
+// CreatedAt is when the backup was made, in Unix seconds.
+// NodeKeys are the node's identity keys.
+// LightningKeys are the lightning node's identity keys.
+// Wallet is the node's wallet, or nil if it doesn't have one.
+// Channels are the lightning node's channels.
+type Archive struct {
+	CreatedAt     int64
+	NodeKeys      *Keys
+	LightningKeys *Keys
+	Wallet        *wallet.Snapshot
+	Channels      []*lightning.ChannelSnapshot
+}
+
+// envelope is how an Archive is laid out on disk.
+// Version is the version of the format.
+// Salt and Iterations are what the passphrase key was derived with.
+// DataKey is the data key, encrypted with the passphrase key.
+// Payload is the Archive, encrypted with the data key.
+type envelope struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	DataKey    []byte `json:"data_key"`
+	Payload    []byte `json:"payload"`
+}
+
+// Write encrypts an Archive under passphrase and writes it to the
+// file at config's Path, replacing any backup already there.
+func Write(config *Config, a *Archive, passphrase string) error {
+	plaintext, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("[backup.Write] failed to encode archive: %v", err)
+	}
+	dataKey, err := randomBytes(keyLength)
+	if err != nil {
+		return fmt.Errorf("[backup.Write] failed to generate data key: %v", err)
+	}
+	payload, err := seal(dataKey, plaintext)
+	if err != nil {
+		return fmt.Errorf("[backup.Write] failed to encrypt archive: %v", err)
+	}
+	env := &envelope{Version: version, Payload: payload}
+	if err = env.wrap(dataKey, passphrase, config.Iterations); err != nil {
+		return fmt.Errorf("[backup.Write] %v", err)
+	}
+	return writeEnvelope(config.Path, env)
+}
+
+// Open reads the backup at path and decrypts it with passphrase.
+func Open(path string, passphrase string) (*Archive, error) {
+	env, err := readEnvelope(path)
+	if err != nil {
+		return nil, fmt.Errorf("[backup.Open] %v", err)
+	}
+	dataKey, err := env.unwrap(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("[backup.Open] %v", err)
+	}
+	plaintext, err := open(dataKey, env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("[backup.Open] archive is corrupted: %v", err)
+	}
+	a := &Archive{}
+	if err = json.Unmarshal(plaintext, a); err != nil {
+		return nil, fmt.Errorf("[backup.Open] failed to decode archive: %v", err)
+	}
+	return a, nil
+}
+
+// Rotate moves the backup at path from oldPassphrase to
+// newPassphrase, deriving the new passphrase key with iterations
+// rounds. The Archive itself is left as it is.
+func Rotate(path string, oldPassphrase string, newPassphrase string, iterations int) error {
+	env, err := readEnvelope(path)
+	if err != nil {
+		return fmt.Errorf("[backup.Rotate] %v", err)
+	}
+	dataKey, err := env.unwrap(oldPassphrase)
+	if err != nil {
+		return fmt.Errorf("[backup.Rotate] %v", err)
+	}
+	if err = env.wrap(dataKey, newPassphrase, iterations); err != nil {
+		return fmt.Errorf("[backup.Rotate] %v", err)
+	}
+	return writeEnvelope(path, env)
+}
+
+// wrap encrypts dataKey with a key derived from passphrase and a
+// fresh salt, and stores it in the envelope.
+func (env *envelope) wrap(dataKey []byte, passphrase string, iterations int) error {
+	if iterations <= 0 {
+		iterations = DefaultConfig().Iterations
+	}
+	salt, err := randomBytes(saltLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	wrapped, err := seal(deriveKey(passphrase, salt, iterations), dataKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt data key: %v", err)
+	}
+	env.Salt = salt
+	env.Iterations = iterations
+	env.DataKey = wrapped
+	return nil
+}
+
+// unwrap decrypts the envelope's data key with passphrase.
+func (env *envelope) unwrap(passphrase string) ([]byte, error) {
+	if env.Version != version {
+		return nil, fmt.Errorf("unknown backup version %v", env.Version)
+	}
+	dataKey, err := open(deriveKey(passphrase, env.Salt, env.Iterations), env.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted backup")
+	}
+	return dataKey, nil
+}
+
+// deriveKey derives a key from passphrase and salt with PBKDF2,
+// using HMAC-SHA256 as the pseudorandom function. The key is
+// exactly one block long, so only the first block is computed.
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	index := make([]byte, 4)
+	binary.BigEndian.PutUint32(index, 1)
+	prf.Write(salt)
+	prf.Write(index)
+	u := prf.Sum(nil)
+	key := append([]byte{}, u...)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// seal encrypts plaintext with key using AES-GCM, and returns the
+// nonce followed by the ciphertext.
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomBytes(gcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts what seal returned.
+func open(key []byte, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// newGCM returns an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// randomBytes returns n cryptographically random bytes.
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// readEnvelope reads the envelope in the file at path.
+func readEnvelope(path string) (*envelope, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup {%v}: %v", path, err)
+	}
+	env := &envelope{}
+	if err = json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("backup {%v} is corrupted: %v", path, err)
+	}
+	return env, nil
+}
+
+// writeEnvelope writes env to the file at path. It writes to a
+// temporary file first, so that a crash never leaves a partially
+// written backup in place of a good one.
+func writeEnvelope(path string, env *envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("[writeEnvelope] failed to encode backup: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("[writeEnvelope] failed to write {%v}: %v", tmp, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("[writeEnvelope] failed to replace {%v}: %v", path, err)
+	}
+	return nil
+}
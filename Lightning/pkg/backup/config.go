@@ -0,0 +1,19 @@
+package backup
+
+// Config is the configuration for backups.
+// Path is the file the backup archive is written to.
+// Iterations is how many rounds of hashing go into deriving
+// a key from a passphrase. More rounds make guessing the
+// passphrase slower, but also make opening a backup slower.
+type Config struct {
+	Path       string
+	Iterations int
+}
+
+// DefaultConfig returns the default Config for backups.
+func DefaultConfig() *Config {
+	return &Config{
+		Path:       "backup.bin",
+		Iterations: 100000,
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"google.golang.org/protobuf/proto"
+	"math/big"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Header provides information about the Block.
@@ -103,6 +105,13 @@ func (b *Block) Size() uint32 {
 	return pro.SizeOfBlock(EncodeBlock(b))
 }
 
+// SerializedSize returns how many bytes the block
+// takes up once marshalled, which is what
+// MaxBlockSize limits.
+func (b *Block) SerializedSize() uint32 {
+	return uint32(proto.Size(EncodeBlock(b)))
+}
+
 func (b *Block) NameTag() string {
 	i, _ := strconv.ParseInt(b.Hash()[:10], 16, 64)
 	return fmt.Sprintf("%v", utils.Colorize(fmt.Sprintf("block-%v", b.Hash()[:8]), int(i)))
@@ -115,12 +124,28 @@ func New(previousHash string, txs []*Transaction, target string) *Block {
 			PreviousHash:     previousHash,
 			MerkleRoot:       CalculateMerkleRoot(txs),
 			DifficultyTarget: target,
+			Timestamp:        uint32(time.Now().Unix()),
 		},
 		Transactions: txs,
 	}
 
 }
 
+// maxWork is 2^256, the size of the hash space.
+var maxWork = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// Work returns the expected number of hashes needed to mine a
+// Block with the Header's DifficultyTarget, 2^256 / (target + 1).
+// Headers without a parsable target (like the genesis Block's) count
+// as a single unit of work, so every Block adds to its chain's work.
+func Work(header *Header) *big.Int {
+	target, ok := new(big.Int).SetString(header.DifficultyTarget, 16)
+	if !ok || target.Sign() < 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Div(maxWork, target.Add(target, big.NewInt(1)))
+}
+
 // CalculateMerkleRoot calculates
 // the merkle root for a list of transactions.
 // Look up merkle trees for further description.
@@ -0,0 +1,115 @@
+package block
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RejectCode is why a Block was rejected, in the words peers use for
+// it, so a rejection can be told apart from any other failure, and the
+// peer that sent the Block penalized if it sent something invalid.
+type RejectCode string
+
+const (
+	// RejectBadHeader is a malformed Header.
+	RejectBadHeader RejectCode = "bad-header"
+	// RejectBadPoW is a Header whose hash doesn't meet its
+	// DifficultyTarget, or whose target is malformed.
+	RejectBadPoW RejectCode = "bad-pow"
+	// RejectTimeTooNew is a Header timestamped too far in the future.
+	RejectTimeTooNew RejectCode = "time-too-new"
+	// RejectTimeTooOld is a Header timestamped no later than the median
+	// time past of the chain it builds on.
+	RejectTimeTooOld RejectCode = "time-too-old"
+	// RejectCheckpoint is a Block that conflicts with a Checkpoint.
+	RejectCheckpoint RejectCode = "checkpoint-mismatch"
+	// RejectDuplicate is a Block that was already stored.
+	RejectDuplicate RejectCode = "duplicate"
+	// RejectPrevInvalid is a Block that builds on an invalid Block.
+	RejectPrevInvalid RejectCode = "bad-prevblk"
+	// RejectBadTransaction is a malformed Transaction.
+	RejectBadTransaction RejectCode = "bad-txns-malformed"
+	// RejectBlockLength is a Block larger than MaxBlockSize.
+	RejectBlockLength RejectCode = "bad-blk-length"
+	// RejectBlockSigOps is a Block that needs more than MaxBlockSigOps
+	// signatures checked.
+	RejectBlockSigOps RejectCode = "bad-blk-sigops"
+	// RejectCoinbaseHeight is a coinbase that doesn't commit to its
+	// Block's height.
+	RejectCoinbaseHeight RejectCode = "bad-cb-height"
+	// RejectCoinbaseAmount is a coinbase that pays out more than the
+	// minting reward and fees.
+	RejectCoinbaseAmount RejectCode = "bad-cb-amount"
+	// RejectNonFinal is a Transaction whose LockTime, or an input's
+	// relative lock, hasn't been reached.
+	RejectNonFinal RejectCode = "bad-txns-nonfinal"
+	// RejectInputsMissing is a Transaction that spends a Coin that
+	// doesn't exist or was already spent.
+	RejectInputsMissing RejectCode = "bad-txns-inputs-missing"
+	// RejectDuplicateCoins is a Transaction whose Coins would overwrite
+	// unspent Coins of a Transaction with the same hash.
+	RejectDuplicateCoins RejectCode = "bad-txns-BIP30"
+	// RejectInBelowOut is a Transaction that pays out more than it
+	// spends.
+	RejectInBelowOut RejectCode = "bad-txns-in-belowout"
+	// RejectFeeOutOfRange is a Block whose fees add up to more than
+	// MaxMoney.
+	RejectFeeOutOfRange RejectCode = "bad-txns-fee-outofrange"
+	// RejectVaultSpend is a Transaction that spends a Coin locked by a
+	// Vault in a way the Vault doesn't allow.
+	RejectVaultSpend RejectCode = "bad-txns-vault"
+	// RejectUTXOCommitment is a coinbase that doesn't commit to the
+	// unspent Coins when it must.
+	RejectUTXOCommitment RejectCode = "bad-utxo-commitment"
+)
+
+// RejectError is returned when a Block is rejected. Code is why, and
+// Err describes the failed check.
+type RejectError struct {
+	Code RejectCode
+	Err  error
+}
+
+// Reject returns a *RejectError with code, whose Err is formatted as
+// fmt.Errorf would. Wrapping another error with %w keeps it reachable
+// through errors.As.
+func Reject(code RejectCode, format string, args ...interface{}) error {
+	return &RejectError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Error returns the code and what failed.
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Code, e.Err)
+}
+
+// Unwrap returns the error describing the failed check.
+func (e *RejectError) Unwrap() error {
+	return e.Err
+}
+
+// Invalid returns whether the rejected Block can never be valid, so
+// whoever sent it sent something invalid. A duplicate, or a Block from
+// too far in the future, may only have come too late or too early.
+func (e *RejectError) Invalid() bool {
+	return e.Code != RejectDuplicate && e.Code != RejectTimeTooNew
+}
+
+// RejectCodeOf returns the code of the outermost *RejectError in err's
+// chain, or nothing if there is none.
+func RejectCodeOf(err error) RejectCode {
+	var re *RejectError
+	if errors.As(err, &re) {
+		return re.Code
+	}
+	return ""
+}
+
+// AsRejectError returns the outermost *RejectError in err's chain, or
+// nil if there is none.
+func AsRejectError(err error) *RejectError {
+	var re *RejectError
+	if errors.As(err, &re) {
+		return re
+	}
+	return nil
+}
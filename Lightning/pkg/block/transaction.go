@@ -16,10 +16,12 @@ import (
 // ReferenceTransactionHash is the hash of the parent TransactionOutput's Transaction.
 // OutputIndex is the index of the parent TransactionOutput's Transaction.
 // Signature verifies that the payer can spend the referenced TransactionOutput.
+// Sequence is a relative lock on the referenced TransactionOutput (see RelativeLock).
 type TransactionInput struct {
 	ReferenceTransactionHash string
 	OutputIndex              uint32
 	UnlockingScript          []byte
+	Sequence                 uint32
 }
 
 // TransactionOutput is an output created from a TransactionInput.
@@ -38,7 +40,8 @@ type TransactionOutput struct {
 // Inputs is a slice of TransactionInputs.
 // Outputs is a slice of TransactionOutputs.
 // Witnesses is the witnesses.
-// LockTime is the future time after which the Transaction is valid.
+// LockTime is the future time after which the Transaction is valid
+// (see IsFinal).
 type Transaction struct {
 	Segwit    bool
 	Version   uint32
@@ -55,6 +58,7 @@ func EncodeTransactionInput(txi *TransactionInput) *pro.TransactionInput {
 		ReferenceTransactionHash: txi.ReferenceTransactionHash,
 		OutputIndex:              txi.OutputIndex,
 		UnlockingScript:          txi.UnlockingScript,
+		Sequence:                 txi.Sequence,
 	}
 }
 
@@ -65,6 +69,7 @@ func DecodeTransactionInput(ptxi *pro.TransactionInput) *TransactionInput {
 		ReferenceTransactionHash: ptxi.GetReferenceTransactionHash(),
 		OutputIndex:              ptxi.GetOutputIndex(),
 		UnlockingScript:          ptxi.GetUnlockingScript(),
+		Sequence:                 ptxi.GetSequence(),
 	}
 }
 
@@ -157,6 +162,12 @@ func (tx *Transaction) Size() uint32 {
 	return pro.SizeOfTransaction(EncodeTransaction(tx))
 }
 
+// SerializedSize returns how many bytes the
+// transaction takes up once marshalled.
+func (tx *Transaction) SerializedSize() uint32 {
+	return uint32(proto.Size(EncodeTransaction(tx)))
+}
+
 // SumOutputs returns the sum of the outputs.
 // Returns:
 // uint32	the sum of the amounts on each
@@ -202,6 +213,20 @@ func (txo *TransactionOutput) MakeSignature(id id.ID) ([]byte, error) {
 	return sig, nil
 }
 
+// VerifySignature returns whether signature, an unlocking script made
+// by MakeSignature, was made by the key whose public key is publicKey.
+func (txo *TransactionOutput) VerifySignature(publicKey []byte, signature []byte) bool {
+	pk, err := utils.Byt2PK(publicKey)
+	if err != nil {
+		return false
+	}
+	bytes, err := proto.Marshal(EncodeTransactionOutput(txo))
+	if err != nil {
+		return false
+	}
+	return utils.Verify(pk, string(bytes), signature)
+}
+
 func (tx *Transaction) Sign(id id.ID) ([]byte, error) {
 	sk := id.GetPrivateKey()
 	sig, err := utils.Sign(sk, []byte(tx.Hash()))
@@ -0,0 +1,236 @@
+package block
+
+import (
+	"Coin/pkg/script"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// HashLength is the length of a hex-encoded sha256 hash, which is
+// how Block and Transaction hashes are represented.
+const HashLength = 2 * 32
+
+// MaxMoney is the most that a Transaction's outputs may add up to.
+const MaxMoney = math.MaxUint32
+
+// MaxBlockSize is the most bytes a Block may take up, serialized (see
+// Block.SerializedSize).
+const MaxBlockSize = 1000000
+
+// MaxBlockSigOps is the most signatures a Block's Transactions may
+// need checked (see SigOps).
+const MaxBlockSigOps = 20000
+
+// ValidateHash returns an error if hash is not a hex-encoded sha256 hash.
+func ValidateHash(hash string) error {
+	if len(hash) != HashLength {
+		return fmt.Errorf("[ValidateHash] hash {%v} has length %v, not %v", hash, len(hash), HashLength)
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return fmt.Errorf("[ValidateHash] hash {%v} is not hex: %v", hash, err)
+	}
+	return nil
+}
+
+// ValidateHeader returns an error if a decoded Header is malformed.
+// The genesis Block has no PreviousHash, and Blocks without
+// Transactions have no MerkleRoot, so those may be empty.
+func ValidateHeader(header *Header) error {
+	if header == nil {
+		return Reject(RejectBadHeader, "[ValidateHeader] header is missing")
+	}
+	if header.PreviousHash != "" {
+		if err := ValidateHash(header.PreviousHash); err != nil {
+			return Reject(RejectBadHeader, "[ValidateHeader] bad previous hash: %v", err)
+		}
+	}
+	if header.MerkleRoot != "" {
+		if err := ValidateHash(header.MerkleRoot); err != nil {
+			return Reject(RejectBadHeader, "[ValidateHeader] bad merkle root: %v", err)
+		}
+	}
+	return nil
+}
+
+// ValidateProofOfWork returns an error if the hash of a Header isn't
+// below its DifficultyTarget, a hex-encoded 256-bit number. Only the
+// genesis Block goes without a target, and it is never validated.
+func ValidateProofOfWork(header *Header) error {
+	if header.DifficultyTarget == "" {
+		return Reject(RejectBadPoW, "[ValidateProofOfWork] header has no difficulty target")
+	}
+	target, ok := new(big.Int).SetString(header.DifficultyTarget, 16)
+	if !ok || target.Sign() < 0 || target.BitLen() > 256 {
+		return Reject(RejectBadPoW, "[ValidateProofOfWork] malformed difficulty target {%v}", header.DifficultyTarget)
+	}
+	hash := (&Block{Header: header}).Hash()
+	value, _ := new(big.Int).SetString(hash, 16)
+	if value.Cmp(target) >= 0 {
+		return Reject(RejectBadPoW, "[ValidateProofOfWork] hash {%v} is not below target {%v}", hash, header.DifficultyTarget)
+	}
+	return nil
+}
+
+// LockTimeThreshold separates the two kinds of LockTime: below it, a
+// LockTime is a Block height, and from it on, a Unix timestamp.
+const LockTimeThreshold = 500000000
+
+// IsFinal returns whether a Transaction's LockTime has been reached by
+// a Block at height, whose chain's median time past is mtp. A LockTime
+// of 0 is always reached. Times are compared with the median time past
+// rather than the Block's own Timestamp, which its miner chooses.
+func IsFinal(tx *Transaction, height uint32, mtp uint32) bool {
+	if tx.LockTime == 0 {
+		return true
+	}
+	if tx.LockTime < LockTimeThreshold {
+		return tx.LockTime < height
+	}
+	return tx.LockTime < mtp
+}
+
+// SequenceLockDisabled is set in a TransactionInput's Sequence when it
+// has no relative lock.
+const SequenceLockDisabled = 1 << 31
+
+// SequenceLockByTime is set in a TransactionInput's Sequence when its
+// relative lock is in units of SequenceGranularity seconds, rather
+// than in Blocks.
+const SequenceLockByTime = 1 << 22
+
+// SequenceLockMask selects the relative lock from a TransactionInput's
+// Sequence.
+const SequenceLockMask = 0xffff
+
+// SequenceGranularity is how many seconds each unit of a time-based
+// relative lock is.
+const SequenceGranularity = 512
+
+// RelativeLock returns how long after the Coin a TransactionInput
+// spends was created it may be spent: a number of Blocks, or, if
+// byTime, a number of seconds that the median time past must advance
+// by. A lock of 0 means the Coin may be spent right away.
+func RelativeLock(txi *TransactionInput) (lock uint32, byTime bool) {
+	if txi.Sequence&SequenceLockDisabled != 0 {
+		return 0, false
+	}
+	lock = txi.Sequence & SequenceLockMask
+	if txi.Sequence&SequenceLockByTime != 0 {
+		return lock * SequenceGranularity, true
+	}
+	return lock, false
+}
+
+// CoinbaseLockTime returns the LockTime a coinbase Transaction in a
+// Block at height must have. Committing to the height makes every
+// coinbase's hash unique, even when the same key earns the same reward
+// twice, and one less than the height keeps it final in its own Block.
+func CoinbaseLockTime(height uint32) uint32 {
+	return height - 1
+}
+
+// ValidateCoinbaseHeight returns an error if any coinbase Transaction in
+// a Block at height doesn't commit to that height (see
+// CoinbaseLockTime).
+func ValidateCoinbaseHeight(b *Block, height uint32) error {
+	for i, tx := range b.Transactions {
+		if tx.IsCoinbase() && tx.LockTime != CoinbaseLockTime(height) {
+			return Reject(RejectCoinbaseHeight, "[ValidateCoinbaseHeight] coinbase %v has lock time %v, not %v", i, tx.LockTime, CoinbaseLockTime(height))
+		}
+	}
+	return nil
+}
+
+// UTXOCommitmentTag starts the LockingScript of a coinbase output that
+// commits to the hash of the unspent Coins before its Block (see
+// UTXOCommitmentScript). It can't be parsed as a locking script, so the
+// output can never be spent.
+var UTXOCommitmentTag = []byte{0x6a, 'u', 't', 'x'}
+
+// UTXOCommitmentScript returns the LockingScript of a coinbase output
+// committing to digest.
+func UTXOCommitmentScript(digest []byte) []byte {
+	return append(append([]byte{}, UTXOCommitmentTag...), digest...)
+}
+
+// UTXOCommitment returns the digest a coinbase Transaction commits to,
+// from its first output whose LockingScript starts with
+// UTXOCommitmentTag, and whether it has one.
+func UTXOCommitment(tx *Transaction) ([]byte, bool) {
+	for _, txo := range tx.Outputs {
+		if bytes.HasPrefix(txo.LockingScript, UTXOCommitmentTag) {
+			return txo.LockingScript[len(UTXOCommitmentTag):], true
+		}
+	}
+	return nil, false
+}
+
+// ValidateTransaction returns an error if a decoded Transaction is
+// malformed: every input must reference a Transaction by its hash,
+// and its outputs must not add up to more than MaxMoney.
+func ValidateTransaction(tx *Transaction) error {
+	if tx == nil {
+		return Reject(RejectBadTransaction, "[ValidateTransaction] transaction is missing")
+	}
+	for i, txi := range tx.Inputs {
+		if txi == nil {
+			return Reject(RejectBadTransaction, "[ValidateTransaction] input %v is missing", i)
+		}
+		if err := ValidateHash(txi.ReferenceTransactionHash); err != nil {
+			return Reject(RejectBadTransaction, "[ValidateTransaction] input %v: %v", i, err)
+		}
+	}
+	total := uint64(0)
+	for i, txo := range tx.Outputs {
+		if txo == nil {
+			return Reject(RejectBadTransaction, "[ValidateTransaction] output %v is missing", i)
+		}
+		total += uint64(txo.Amount)
+		if total > MaxMoney {
+			return Reject(RejectBadTransaction, "[ValidateTransaction] outputs add up to more than %v", uint64(MaxMoney))
+		}
+	}
+	return nil
+}
+
+// SigOps returns how many signatures must be checked for a
+// Transaction: one for each of its inputs, and, for each of its
+// outputs, as many as spending it will need.
+func SigOps(tx *Transaction) uint32 {
+	sigOps := uint32(len(tx.Inputs))
+	for _, txo := range tx.Outputs {
+		sigOps += script.SigOps(txo.LockingScript)
+	}
+	return sigOps
+}
+
+// ValidateBlock returns an error if a decoded Block's Header or
+// any of its Transactions are malformed, or if the Block is larger
+// than MaxBlockSize or needs more than MaxBlockSigOps signatures
+// checked. Like the other checks here, the error is a *RejectError
+// saying which check failed.
+func ValidateBlock(b *Block) error {
+	if b == nil {
+		return Reject(RejectBadHeader, "[ValidateBlock] block is missing")
+	}
+	if err := ValidateHeader(b.Header); err != nil {
+		return err
+	}
+	sigOps := uint32(0)
+	for _, tx := range b.Transactions {
+		if err := ValidateTransaction(tx); err != nil {
+			return err
+		}
+		sigOps += SigOps(tx)
+		if sigOps > MaxBlockSigOps {
+			return Reject(RejectBlockSigOps, "[ValidateBlock] transactions need more than %v signatures checked", MaxBlockSigOps)
+		}
+	}
+	if size := b.SerializedSize(); size > MaxBlockSize {
+		return Reject(RejectBlockLength, "[ValidateBlock] block is %v bytes, more than %v", size, MaxBlockSize)
+	}
+	return nil
+}
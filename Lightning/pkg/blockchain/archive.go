@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+	"fmt"
+)
+
+// ExportChain writes the Blocks of the main chain from fromHeight to
+// toHeight, inclusive, to an archive at path (see
+// chainwriter.ExportChain). toHeight is capped at the tip. It returns
+// how many Blocks it exported.
+func (bc *BlockChain) ExportChain(path string, fromHeight uint32, toHeight uint32) (int, error) {
+	if toHeight > bc.Length {
+		toHeight = bc.Length
+	}
+	var records []*blockinfodatabase.BlockRecord
+	for height := fromHeight; height <= toHeight && height > 0; height++ {
+		hash := bc.BlockInfoDB.GetHashByHeight(height)
+		if hash == "" {
+			return 0, fmt.Errorf("[blockchain.ExportChain] no block at height %v", height)
+		}
+		records = append(records, bc.BlockInfoDB.GetBlockRecord(hash))
+	}
+	return bc.ChainWriter.ExportChain(path, fromHeight, toHeight, records)
+}
+
+// ImportChain handles every Block in an archive written by ExportChain,
+// as if each had come from a peer, so a new node can be bootstrapped
+// from a file rather than the network. Blocks the BlockChain already
+// has are skipped. It stops at the first Block that is rejected, and
+// returns how many Blocks it added.
+func (bc *BlockChain) ImportChain(path string) (int, error) {
+	added := 0
+	_, err := bc.ChainWriter.ImportChain(path, func(b *block.Block) error {
+		hash := b.Hash()
+		if bc.BlockInfoDB.HasBlockRecord(hash) {
+			return nil
+		}
+		if err := bc.HandleBlock(b); err != nil {
+			return fmt.Errorf("[blockchain.ImportChain] block {%v} was rejected: %w", hash, err)
+		}
+		if !bc.BlockInfoDB.HasBlockRecord(hash) ||
+			bc.BlockInfoDB.GetBlockRecord(hash).Status.Has(blockinfodatabase.StatusFailed) {
+			return fmt.Errorf("[blockchain.ImportChain] block {%v} was rejected", hash)
+		}
+		added++
+		return nil
+	})
+	return added, err
+}
+
+// LoadBlocks handles every Block at path, an archive, a block file or
+// another node's block directory (see chainwriter.LoadBlocks), as if
+// each had come from a peer. Unlike ImportChain, it doesn't stop at a
+// rejected Block, since a node's files hold the invalid Blocks it was
+// sent too, and Blocks whose parents come later are kept as orphans
+// until they arrive. handled, if it isn't nil, is called after each
+// Block the BlockChain didn't already have is handled. LoadBlocks only
+// stops if a Block can't be read or stored, and returns how many
+// Blocks it accepted.
+func (bc *BlockChain) LoadBlocks(path string, handled func(*block.Block)) (int, error) {
+	accepted, rejected := 0, 0
+	_, err := bc.ChainWriter.LoadBlocks(path, func(b *block.Block) error {
+		if bc.BlockInfoDB.HasBlockRecord(b.Hash()) {
+			return nil
+		}
+		err := bc.HandleBlock(b)
+		if handled != nil {
+			handled(b)
+		}
+		if block.AsRejectError(err) != nil {
+			rejected++
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("[blockchain.LoadBlocks] %v", err)
+		}
+		accepted++
+		return nil
+	})
+	utils.Debug.Printf("[blockchain.LoadBlocks] accepted %v blocks and rejected %v from {%v}", accepted, rejected, path)
+	return accepted, err
+}
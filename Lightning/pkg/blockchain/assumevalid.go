@@ -0,0 +1,31 @@
+package blockchain
+
+// assumedValid returns whether the Block with hash at height is the
+// assumeValid Block or one of its ancestors, so the scripts and
+// signatures of the Coins it spends needn't be checked. Someone who
+// trusts assumeValid already trusts that they were checked, since it
+// commits to every Block below it. Everything else about the Block is
+// still checked, so it can't spend Coins that don't exist or create
+// money. Nothing is assumed valid until assumeValid's Header arrives,
+// and Blocks on other branches never are.
+func (bc *BlockChain) assumedValid(hash string, height uint32) bool {
+	bc.assumeValidMutex.Lock()
+	defer bc.assumeValidMutex.Unlock()
+	return bc.assumeValidChain[height] == hash
+}
+
+// findAssumeValid fills in assumeValidChain if the Header or Block
+// with hash, which was just accepted, is assumeValid. It's called when
+// the BlockChain opens and whenever a Header or Block is accepted, and
+// only does anything the first time assumeValid is found: a Block's
+// hash commits to its ancestors, so they never change.
+func (bc *BlockChain) findAssumeValid(hash string) {
+	if bc.assumeValid == "" || hash != bc.assumeValid {
+		return
+	}
+	bc.assumeValidMutex.Lock()
+	defer bc.assumeValidMutex.Unlock()
+	if bc.assumeValidChain == nil {
+		bc.assumeValidChain = bc.Headers.ancestors(hash)
+	}
+}
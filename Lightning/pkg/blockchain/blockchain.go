@@ -5,8 +5,13 @@ import (
 	"Coin/pkg/blockchain/blockinfodatabase"
 	"Coin/pkg/blockchain/chainwriter"
 	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/journal"
 	"Coin/pkg/utils"
-	"math"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
 // BlockChain is the main type of this project.
@@ -20,54 +25,363 @@ import (
 // BlockInfoDB is a pointer to a block info database
 // ChainWriter is a pointer to a chain writer.
 // CoinDB is a pointer to a coin database.
+// Journal records blocks connected and disconnected, and reorgs.
+// CumulativeWork is the total work of the active chain.
+// orphanPruneDepth is how deeply a losing branch must be buried
+// before its BlockRecords are marked for pruning.
+// pruneDepth is how deeply a Block must be buried before the files
+// it is stored in may be deleted, or 0 to keep every file.
+// pruneTarget is how many bytes the block and undo files may take up
+// before the oldest are deleted, or 0 to keep them.
+// prunedAtFile is the block file the ChainWriter was writing to the
+// last time files were pruned.
+// maxReorgDepth is how many Blocks deep a reorg may go without an
+// operator confirming it. refusedReorgs are the heavier branches that
+// would have gone deeper, by the hash of their tips (see
+// RefusedReorgs), guarded by refusedMutex.
+// pruneUndo is whether undo files are pruned once their Blocks are
+// buried deeper than maxReorgDepth, and undoPrunedAtFile is the undo
+// file the ChainWriter was writing to the last time they were.
+// locks are the locks on the ChainWriter's and the databases'
+// directories, held until the BlockChain is closed.
+// Orphans holds the Blocks that arrived before their parents.
+// Headers holds the Headers of Blocks that haven't been downloaded yet.
+// checkpoints are the Blocks known to be on the main chain, sorted by
+// height.
+// assumeValid is the hash of the Block whose ancestors' scripts are
+// trusted, and assumeValidChain the hashes of it and its ancestors, by
+// height, once its Header is known (see findAssumeValid), guarded by
+// assumeValidMutex.
+// difficultyTarget is the proof of work target every Block's Header
+// must carry (see ValidateHeader).
+// blockSubsidy, subsidyHalvingRate and maxHalvings are the minting
+// reward (see Subsidy).
+// utxoCommitmentHeight is the height from which coinbases must commit
+// to the unspent Coins, or 0 if they needn't.
+// deployments are the changes to the consensus rules miners signal
+// for, counted over periods of deploymentPeriod Blocks, of which
+// deploymentThreshold must signal. deploymentStates caches the state
+// of each Deployment decided by the last Block of a period, guarded by
+// deploymentMutex.
+// snapshotDirectory is where snapshots of the Coins are written at
+// Checkpoints, or empty to write none, and snapshotChunkSize is how
+// many Coins each of their chunks holds.
+// integrityInterval and integrityBlocks are how often, and how many
+// Blocks at a time, an IntegrityChecker checks the Coins.
+// reorgHandlers are called after every Reorg (see OnReorg), and
+// orphanHandlers whenever an orphan extends the active chain (see
+// OnOrphanConnected).
+// subscriptions are sent every change to the active chain (see
+// Subscribe), and tipConnectedAt is when a Block last became the tip
+// (see TipConnectedAt), both guarded by subscriptionMutex.
 type BlockChain struct {
-	Address      string
-	Length       uint32
-	LastBlock    *block.Block
-	LastHash     string
-	UnsafeHashes []string
-	maxHashes    int
-	ConfirmBlock chan *block.Block
+	Address        string
+	Length         uint32
+	LastBlock      *block.Block
+	LastHash       string
+	UnsafeHashes   []string
+	maxHashes      int
+	ConfirmBlock   chan *block.Block
+	CumulativeWork *big.Int
+
+	orphanPruneDepth uint32
+	pruneDepth       uint32
+	pruneTarget      uint64
+	prunedAtFile     uint32
+	maxReorgDepth    uint32
+	refusedReorgs    map[string]*RefusedReorg
+	refusedMutex     sync.Mutex
+	pruneUndo        bool
+	undoPrunedAtFile uint32
+	checkpoints      []Checkpoint
+
+	assumeValid      string
+	assumeValidChain map[uint32]string
+	assumeValidMutex sync.Mutex
+
+	difficultyTarget string
+
+	blockSubsidy       uint32
+	subsidyHalvingRate uint32
+	maxHalvings        uint32
+
+	utxoCommitmentHeight uint32
+
+	deployments         []Deployment
+	deploymentPeriod    uint32
+	deploymentThreshold uint32
+	deploymentStates    map[deploymentKey]DeploymentState
+	deploymentMutex     sync.Mutex
+
+	snapshotDirectory string
+	snapshotChunkSize int
+
+	integrityInterval time.Duration
+	integrityBlocks   uint32
 
 	BlockInfoDB *blockinfodatabase.BlockInfoDatabase
 	ChainWriter *chainwriter.ChainWriter
 	CoinDB      *coindatabase.CoinDatabase
+	Journal     *journal.Journal
+	Orphans     *OrphanPool
+	Headers     *HeaderTree
+
+	locks          []*utils.DirectoryLock
+	reorgHandlers  []func(*Reorg)
+	orphanHandlers []func(*block.Block)
+
+	subscriptions     map[*Subscription]bool
+	tipConnectedAt    time.Time
+	subscriptionMutex sync.Mutex
 }
 
-// New returns a blockchain given a Config.
-func New(config *Config) *BlockChain {
+// New returns a blockchain given a Config. If the BlockInfoDatabase
+// already has a tip, the BlockChain resumes from it. It returns an
+// error if another node is using any of its directories, which is a
+// *utils.DirectoryLockedError, or if the genesis Block can't be stored.
+func New(config *Config) (*BlockChain, error) {
+	// lock the directories before anything in them is opened, so a
+	// second node can't write to them too
+	locks, err := lockDirectories(config)
+	if err != nil {
+		return nil, err
+	}
 	genBlock := GenesisBlock(config)
 	hash := genBlock.Hash()
 	// set up db paths
 	blockInfoDBConfig := blockinfodatabase.DefaultConfig()
 	blockInfoDBConfig.DatabasePath = config.BlockInfoDBPath
+	blockInfoDBConfig.TxIndex = config.TxIndex
 
 	chainWriterConfig := chainwriter.DefaultConfig()
 	chainWriterConfig.DataDirectory = config.ChainWriterDBPath
+	if config.Magic != 0 {
+		chainWriterConfig.Magic = config.Magic
+	}
+	chainWriterConfig.Compression = config.Compression
+	chainWriterConfig.SyncInterval = config.SyncInterval
+	chainWriterConfig.BlockDirectory = config.BlockDirectory
+	chainWriterConfig.UndoDirectory = config.UndoDirectory
+	chainWriterConfig.FilesPerDirectory = config.FilesPerDirectory
 
 	coinDBConfig := coindatabase.DefaultConfig()
 	coinDBConfig.DatabasePath = config.CoinDBPath
 
 	bc := &BlockChain{
-		Length:       1,
-		LastBlock:    genBlock,
-		LastHash:     hash,
-		UnsafeHashes: []string{hash},
-		maxHashes:    6,
-		BlockInfoDB:  blockinfodatabase.New(blockInfoDBConfig),
-		ChainWriter:  chainwriter.New(chainWriterConfig),
-		CoinDB:       coindatabase.New(coinDBConfig),
+		Length:               1,
+		LastBlock:            genBlock,
+		LastHash:             hash,
+		UnsafeHashes:         []string{hash},
+		maxHashes:            6,
+		CumulativeWork:       BlockWork(genBlock.Header),
+		orphanPruneDepth:     config.OrphanPruneDepth,
+		pruneDepth:           config.PruneDepth,
+		pruneTarget:          uint64(config.PruneTarget) << 20,
+		maxReorgDepth:        config.MaxReorgDepth,
+		refusedReorgs:        make(map[string]*RefusedReorg),
+		pruneUndo:            config.PruneUndo,
+		checkpoints:          sortCheckpoints(config.Checkpoints),
+		assumeValid:          config.AssumeValid,
+		difficultyTarget:     config.DifficultyTarget,
+		blockSubsidy:         config.BlockSubsidy,
+		subsidyHalvingRate:   config.SubsidyHalvingRate,
+		maxHalvings:          config.MaxHalvings,
+		utxoCommitmentHeight: config.UTXOCommitmentHeight,
+		deployments:          append([]Deployment{}, config.Deployments...),
+		deploymentPeriod:     config.DeploymentPeriod,
+		deploymentThreshold:  config.DeploymentThreshold,
+		deploymentStates:     make(map[deploymentKey]DeploymentState),
+		snapshotDirectory:    config.SnapshotDirectory,
+		snapshotChunkSize:    config.SnapshotChunkSize,
+		integrityInterval:    config.IntegrityInterval,
+		integrityBlocks:      config.IntegrityBlocks,
+		BlockInfoDB:          blockinfodatabase.New(blockInfoDBConfig),
+		ChainWriter:          chainwriter.New(chainWriterConfig),
+		CoinDB:               coindatabase.New(coinDBConfig),
+		Orphans:              NewOrphanPool(config.MaxOrphanBlocks, config.OrphanExpiry),
+		subscriptions:        make(map[*Subscription]bool),
+		tipConnectedAt:       time.Now(),
+		locks:                locks,
+	}
+	bc.Headers = newHeaderTree(bc)
+	if bc.deploymentPeriod == 0 {
+		bc.deploymentPeriod = DefaultConfig().DeploymentPeriod
+	}
+	if bc.deploymentThreshold > bc.deploymentPeriod {
+		bc.deploymentThreshold = bc.deploymentPeriod
+	}
+	if bc.snapshotChunkSize <= 0 {
+		bc.snapshotChunkSize = DefaultConfig().SnapshotChunkSize
+	}
+	if !usable(bc.BlockInfoDB.Err()) || !usable(bc.CoinDB.Err()) {
+		utils.Debug.Printf("[blockchain.New] databases could not be opened, the chain must be rebuilt")
+		return bc, nil
+	}
+	// the files may have Blocks written after the last BlockRecord was
+	// stored, which were never recorded
+	if state := bc.BlockInfoDB.GetWriterState(); state != nil {
+		bc.ChainWriter.ResumeAt(state)
+	}
+	bc.prunedAtFile = bc.ChainWriter.State().BlockFileNumber
+	bc.undoPrunedAtFile = bc.ChainWriter.State().UndoFileNumber
+	// assumeValid may have been stored before the BlockChain was closed
+	bc.findAssumeValid(bc.assumeValid)
+	if tip := bc.BlockInfoDB.GetTip(); tip != nil {
+		bc.resumeFromTip(tip)
+		if bc.utxoCommitmentHeight > 0 {
+			// the chain may have been built before the hash was tracked
+			if _, err = bc.utxoState(bc.LastHash); err != nil {
+				utils.Debug.Printf("[blockchain.New] %v", err)
+			}
+		}
+		if config.VerifyDepth > 0 {
+			for _, err := range bc.VerifyChain(config.VerifyDepth, config.VerifyLevel) {
+				utils.Debug.Printf("[blockchain.New] %v", err)
+			}
+		}
+		return bc, nil
 	}
 	// have to store the genesis block
 	bc.CoinDB.StoreBlock(genBlock.Transactions)
 	ub := &chainwriter.UndoBlock{}
-	br := bc.ChainWriter.StoreBlock(genBlock, ub, 1)
-	bc.BlockInfoDB.StoreBlockRecord(hash, br)
-	return bc
+	br, err := bc.ChainWriter.StoreBlock(genBlock, ub, 1)
+	if err != nil {
+		bc.Close()
+		return nil, fmt.Errorf("[blockchain.New] unable to store the genesis block: %v", err)
+	}
+	br.ChainWork = bc.CumulativeWork
+	br.Status = blockinfodatabase.StatusFullyValid
+	if bc.utxoCommitmentHeight > 0 {
+		state := coindatabase.NewUTXOHash()
+		state.ConnectBlock(genBlock, ub)
+		br.UTXOHash = state.Bytes()
+	}
+	if err := bc.BlockInfoDB.StoreBlockRecordAndSetTip(hash, br, bc.tip(), bc.ChainWriter.State()); err != nil {
+		utils.Debug.Printf("[blockchain.New] %v", err)
+	}
+	if err := bc.BlockInfoDB.StoreCreatedTransactions(hash, genBlock.Transactions); err != nil {
+		utils.Debug.Printf("[blockchain.New] %v", err)
+	}
+	bc.indexTransactions(hash, genBlock)
+	return bc, nil
+}
+
+// lockDirectories locks the ChainWriter's and the databases'
+// directories. If any of them is in use, the locks already taken are
+// released, and the error is a *utils.DirectoryLockedError.
+func lockDirectories(config *Config) ([]*utils.DirectoryLock, error) {
+	var locks []*utils.DirectoryLock
+	locked := make(map[string]bool)
+	dirs := []string{config.ChainWriterDBPath, config.BlockDirectory, config.UndoDirectory, config.BlockInfoDBPath, config.CoinDBPath}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		if locked[dir] {
+			continue
+		}
+		lock, err := utils.LockDirectory(dir)
+		if err != nil {
+			releaseLocks(locks)
+			return nil, err
+		}
+		locked[dir] = true
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// releaseLocks releases locks, logging any that fail.
+func releaseLocks(locks []*utils.DirectoryLock) {
+	for _, lock := range locks {
+		if err := lock.Release(); err != nil {
+			utils.Debug.Printf("[blockchain.releaseLocks] Unable to release lock on {%v}: %v", lock.Path, err)
+		}
+	}
+}
+
+// Close syncs and closes the ChainWriter's files, closes the
+// databases, and then releases the locks on their directories, so
+// another BlockChain may use them.
+func (bc *BlockChain) Close() {
+	if err := bc.ChainWriter.Close(); err != nil {
+		utils.Debug.Printf("[blockchain.Close] Unable to flush stored blocks: %v", err)
+	}
+	bc.BlockInfoDB.Close()
+	bc.CoinDB.Close()
+	releaseLocks(bc.locks)
+}
+
+// usable returns whether a database that returned err when it was
+// opened can be used: either it opened cleanly, or it was corrupted
+// but recovered.
+func usable(err error) bool {
+	ce, ok := err.(*utils.CorruptionError)
+	return err == nil || ok && ce.Recovered
+}
+
+// NeedsReindex returns whether either of the BlockChain's databases was
+// found to be corrupted when it was opened. Even if they were recovered,
+// they may have lost records, so the BlockRecords and Coins should be
+// rebuilt from the stored Blocks.
+func (bc *BlockChain) NeedsReindex() bool {
+	return utils.IsCorruption(bc.BlockInfoDB.Err()) || utils.IsCorruption(bc.CoinDB.Err())
+}
+
+// tip returns the ChainTip for the active chain.
+func (bc *BlockChain) tip() *blockinfodatabase.ChainTip {
+	return &blockinfodatabase.ChainTip{
+		Hash:           bc.LastHash,
+		Height:         bc.Length,
+		CumulativeWork: bc.CumulativeWork,
+	}
+}
+
+// resumeFromTip sets the BlockChain's fields from a ChainTip
+// recorded by a previous run.
+func (bc *BlockChain) resumeFromTip(tip *blockinfodatabase.ChainTip) {
+	bc.Length = tip.Height
+	bc.LastHash = tip.Hash
+	bc.LastBlock = bc.GetBlock(tip.Hash)
+	bc.CumulativeWork = tip.CumulativeWork
+	// records stored before ChainWork was tracked don't have it, but the
+	// tip's is needed to compare new Blocks against
+	if br := bc.BlockInfoDB.GetBlockRecord(tip.Hash); br != nil && br.ChainWork.Sign() == 0 {
+		br.ChainWork = tip.CumulativeWork
+		bc.BlockInfoDB.StoreBlockRecord(tip.Hash, br)
+	}
+	bc.loadUnsafeHashes()
+}
+
+// loadUnsafeHashes sets UnsafeHashes to the hashes of the last
+// maxHashes Blocks on the active chain.
+func (bc *BlockChain) loadUnsafeHashes() {
+	var unsafeHashes []string
+	for hash := bc.LastHash; hash != "" && len(unsafeHashes) < bc.maxHashes; {
+		unsafeHashes = append(unsafeHashes, hash)
+		hash = bc.BlockInfoDB.GetBlockRecord(hash).Header.PreviousHash
+	}
+	bc.UnsafeHashes = reverseHashes(unsafeHashes)
+}
+
+// chainWork returns the total work of the chain ending at hash. It
+// walks back only as far as the first BlockRecord with its ChainWork.
+func (bc *BlockChain) chainWork(hash string) *big.Int {
+	work := big.NewInt(0)
+	for hash != "" {
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		if br.ChainWork != nil && br.ChainWork.Sign() > 0 {
+			return work.Add(work, br.ChainWork)
+		}
+		work.Add(work, BlockWork(br.Header))
+		hash = br.Header.PreviousHash
+	}
+	return work
 }
 
 // GenesisBlock creates the genesis Block, using the Config's
-// InitialSubsidy and GenesisPublicKey.
+// InitialSubsidy, GenesisPublicKey and GenesisTimestamp.
 func GenesisBlock(config *Config) *block.Block {
 	txo := &block.TransactionOutput{
 		Amount:        config.InitialSubsidy,
@@ -86,112 +400,274 @@ func GenesisBlock(config *Config) *block.Block {
 			MerkleRoot:       "",
 			DifficultyTarget: "",
 			Nonce:            0,
-			Timestamp:        0,
+			Timestamp:        config.GenesisTimestamp,
 		},
 		Transactions: []*block.Transaction{genTx},
 	}
 }
 
 // HandleBlock handles a new Block. At a high level, it:
-// (1) Validates and stores the Block.
+// (1) Validates the Block, as far as it can be validated without
+// connecting it. Blocks that fail, or that descend from a Block that
+// failed, are stored with StatusFailed and go no further. Blocks that
+// couldn't be checked at all aren't stored, so they can be handled
+// again later (see isInvalid).
 // (2) Stores the Block and resulting Undoblock to Disk.
-// (3) Stores the BlockRecord in the BlockInfoDatabase.
-// (4) Handles a fork, if necessary.
+// (3) Stores the BlockRecord in the BlockInfoDatabase, along with the
+// ChainWriter's state and, when the Block extends the active chain, the
+// new tip.
+// (4) Reorganizes onto the Block's chain, if it has more work than the
+// active chain (see reorganize).
 // (5) Updates the BlockChain's fields.
-func (bc *BlockChain) HandleBlock(b *block.Block) {
-	appends := bc.appendsToActiveChain(b)
-	blockHash := b.Hash()
+// (6) Handles the orphans that were waiting for the Block, and then
+// those waiting for them.
+// Blocks that have already been handled are ignored, so the same Block
+// arriving from several peers is only stored once, and a Block that
+// failed validation is never validated again. Blocks whose Headers
+// fail ValidateHeader aren't stored at all, except that a Block whose
+// parent is unknown is kept in the OrphanPool until its parent arrives.
+// It returns a *block.RejectError saying why the Block was rejected, or
+// why the branch it's on couldn't be connected, so the peer that sent
+// it can be penalized, and nil if it was stored or kept as an orphan.
+// Rejections of the orphans it let through are only logged.
+func (bc *BlockChain) HandleBlock(b *block.Block) error {
+	err := bc.handleBlock(b)
+	bc.handleOrphans(b.Hash())
+	return err
+}
 
-	// 1. Validate Block
-	if appends && !bc.CoinDB.ValidateBlock(b.Transactions) {
-		return
+// handleBlock is HandleBlock, without handling orphans.
+func (bc *BlockChain) handleBlock(b *block.Block) error {
+	if err := block.ValidateBlock(b); err != nil {
+		utils.Debug.Printf("[blockchain.HandleBlock] rejected malformed block: %v", err)
+		return err
 	}
+	blockHash := b.Hash()
+	if bc.BlockInfoDB.HasBlockRecord(blockHash) {
+		utils.Debug.Printf("[blockchain.HandleBlock] already have block {%v}", blockHash)
+		return block.Reject(block.RejectDuplicate, "[blockchain.HandleBlock] already have block {%v}", blockHash)
+	}
+	if err := bc.ValidateHeader(b.Header); IsUnknownParent(err) {
+		if bc.Orphans.Add(b) {
+			utils.Debug.Printf("[blockchain.HandleBlock] keeping orphan block {%v} until its parent arrives", blockHash)
+		}
+		return nil
+	} else if err != nil {
+		utils.Debug.Printf("[blockchain.HandleBlock] rejected header of block {%v}: %v", blockHash, err)
+		return err
+	}
+	appends := bc.appendsToActiveChain(b)
 
-	// 2. Make Undo Block
-	ub := bc.makeUndoBlock(b.Transactions)
-
-	// 4. Get BlockRecord for previous Block
+	// 1. Get BlockRecord for previous Block
 	previousBr := bc.BlockInfoDB.GetBlockRecord(b.Header.PreviousHash)
 
-	// 5. Store UndoBlock and Block to Disk
+	// 2. Validate Block
 	height := previousBr.Height + 1
-	br := bc.ChainWriter.StoreBlock(b, ub, height)
+	contextErr := bc.checkBlockContext(b, height)
+	status := blockinfodatabase.StatusHeaderValid | blockinfodatabase.StatusTreeValid
+	var rejection error
+	switch {
+	case previousBr.Status.Has(blockinfodatabase.StatusFailed):
+		utils.Debug.Printf("[blockchain.HandleBlock] block {%v} descends from an invalid block", blockHash)
+		rejection = block.Reject(block.RejectPrevInvalid, "[blockchain.HandleBlock] block {%v} descends from an invalid block", blockHash)
+		status |= blockinfodatabase.StatusFailed
+	case contextErr != nil:
+		if !isInvalid(contextErr) {
+			utils.Debug.Printf("[blockchain.HandleBlock] unable to check block {%v}: %v", blockHash, contextErr)
+			return contextErr
+		}
+		utils.Debug.Printf("[blockchain.HandleBlock] block {%v} is invalid: %v", blockHash, contextErr)
+		rejection = contextErr
+		status |= blockinfodatabase.StatusFailed
+	case appends:
+		if err := bc.checkCoins(b, height); err == nil {
+			status |= blockinfodatabase.StatusScriptsValid
+		} else if !isInvalid(err) {
+			utils.Debug.Printf("[blockchain.HandleBlock] unable to check block {%v}: %v", blockHash, err)
+			return err
+		} else {
+			utils.Debug.Printf("[blockchain.HandleBlock] block {%v} is invalid: %v", blockHash, err)
+			rejection = err
+			status |= blockinfodatabase.StatusFailed
+		}
+	}
+
+	// 3. Make Undo Block. A Block that failed is never connected, so it
+	// has nothing to undo, and the UndoBlock of a Block on another branch
+	// is made when it's connected, since the Coins it spends aren't known
+	// until then. That's also when its commitment to the Coins is checked.
+	ub := &chainwriter.UndoBlock{}
+	var utxoState []byte
+	if appends && !status.Has(blockinfodatabase.StatusFailed) {
+		ub = bc.makeUndoBlock(b.Transactions)
+		if bc.utxoCommitmentHeight > 0 {
+			var err error
+			if utxoState, err = bc.nextUTXOState(b, height, ub); err != nil && !isInvalid(err) {
+				utils.Debug.Printf("[blockchain.HandleBlock] unable to check block {%v}: %v", blockHash, err)
+				return err
+			} else if err != nil {
+				utils.Debug.Printf("[blockchain.HandleBlock] block {%v} is invalid: %v", blockHash, err)
+				rejection = err
+				status = status&^blockinfodatabase.StatusScriptsValid | blockinfodatabase.StatusFailed
+				ub = &chainwriter.UndoBlock{}
+			}
+		}
+	}
 
-	// 6. Store BlockRecord to BlockInfoDatabase
-	bc.BlockInfoDB.StoreBlockRecord(blockHash, br)
+	// 4. Store UndoBlock and Block to Disk
+	br, err := bc.ChainWriter.StoreBlock(b, ub, height)
+	if err != nil {
+		// the Block isn't recorded, so it can be handled again once
+		// it can be stored
+		utils.Debug.Printf("[blockchain.HandleBlock] unable to store block {%v}: %v", blockHash, err)
+		bc.Journal.Record(journal.BlockStoreFailed, blockHash, err.Error())
+		return fmt.Errorf("[blockchain.HandleBlock] unable to store block {%v}: %v", blockHash, err)
+	}
+	br.ChainWork = new(big.Int).Add(bc.chainWork(b.Header.PreviousHash), BlockWork(b.Header))
+	br.Status = status
+	br.UTXOHash = utxoState
+
+	if status.Has(blockinfodatabase.StatusFailed) {
+		// 5. Remember the Block as invalid, so it's never tried again
+		if err := bc.BlockInfoDB.StoreBlockRecordWithWriterState(blockHash, br, bc.ChainWriter.State()); err != nil {
+			utils.Debug.Printf("[blockchain.HandleBlock] %v", err)
+		}
+		return rejection
+	}
+	if err := bc.BlockInfoDB.StoreCreatedTransactions(blockHash, b.Transactions); err != nil {
+		utils.Debug.Printf("[blockchain.HandleBlock] %v", err)
+	}
 
 	if appends {
-		// 7. Handle appending Block
+		// 6. Handle appending Block
 		bc.CoinDB.StoreBlock(b.Transactions)
 		bc.Length++
 		bc.LastBlock = b
 		bc.LastHash = blockHash
+		bc.CumulativeWork = br.ChainWork
+		// 7. Store BlockRecord to BlockInfoDatabase, along with the new tip
+		if err := bc.BlockInfoDB.StoreBlockRecordAndSetTip(blockHash, br, bc.tip(), bc.ChainWriter.State()); err != nil {
+			utils.Debug.Printf("[blockchain.HandleBlock] %v", err)
+		}
+		bc.findAssumeValid(blockHash)
 		if len(bc.UnsafeHashes) >= 6 {
 			bc.UnsafeHashes = bc.UnsafeHashes[1:]
 		}
 		bc.UnsafeHashes = append(bc.UnsafeHashes, blockHash)
-	} else if height > bc.Length {
-		// 8. Handle fork
-		bc.handleFork(b, height)
+		bc.indexTransactions(blockHash, b)
+		bc.takeSnapshot(blockHash, height)
+		bc.Journal.Record(journal.BlockConnected, blockHash, fmt.Sprintf("height %v", height))
+		bc.notify(&TipEvent{Kind: TipConnected, Hash: blockHash, Height: height, Block: b})
+		// files can only become prunable once the ChainWriter has
+		// moved on from them
+		if (bc.pruneDepth > 0 || bc.pruneTarget > 0) && bc.ChainWriter.State().BlockFileNumber != bc.prunedAtFile {
+			bc.PruneBlockFiles()
+		}
+		if bc.pruneUndo && bc.ChainWriter.State().UndoFileNumber != bc.undoPrunedAtFile {
+			bc.PruneUndoData()
+		}
+		return nil
 	}
-}
-
-// handleFork updates the BlockChain when a fork occurs. First, it
-// finds the Blocks the BlockChain must revert. Once found, it uses
-// those Blocks to update the CoinDatabase. Lastly, it updates the
-// BlockChain's fields to reflect the fork.
-func (bc *BlockChain) handleFork(b *block.Block, height uint32) {
-	// (1) Make sure that this is a valid fork
-	forkLength, ancestorHash := bc.getForkLengthAndAncestor(b.Hash())
-	if forkLength < 0 {
-		utils.Debug.Printf("[blockchain.handleFork] fork was invalid")
-		return
+	// 7. Store BlockRecord to BlockInfoDatabase
+	if err := bc.BlockInfoDB.StoreBlockRecordWithWriterState(blockHash, br, bc.ChainWriter.State()); err != nil {
+		utils.Debug.Printf("[blockchain.HandleBlock] %v", err)
+	}
+	bc.findAssumeValid(blockHash)
+	if CompareChainWork(br, bc.BlockInfoDB.GetBlockRecord(bc.LastHash)) > 0 {
+		// 8. Reorganize, since the Block's chain is now the heaviest,
+		// unless that goes too deep to do without an operator
+		if bc.refuseDeepReorg(blockHash) {
+			return nil
+		}
+		return bc.reorganize(blockHash)
 	}
+	return nil
+}
 
-	// (2) retrieve the blocks on the existing main chain
-	blocks, undoBlocks := bc.getBlocksAndUndoBlocks(forkLength, bc.LastHash)
+// isInvalid returns whether err, from checking a Block, says the Block
+// can never be valid, rather than that it couldn't be checked, like
+// when a Block it needs has been pruned. Only invalid Blocks are
+// marked as failed, since nothing else ever tries them again.
+func isInvalid(err error) bool {
+	re := block.AsRejectError(err)
+	return re != nil && re.Invalid()
+}
 
-	// (3) update unsafe hashes
-	min := int(math.Min(float64(6), float64(forkLength)))
-	// delete unsafe hashes up until the common ancestor
-	for i := min - 1; i > 0; i-- {
-		if bc.UnsafeHashes[i] == ancestorHash {
-			break
-		} else {
-			bc.UnsafeHashes = bc.UnsafeHashes[:len(bc.UnsafeHashes)-1]
+// indexMainChain updates the height index for the Blocks from tipHash
+// back to, but not including, ancestorHash, once they are on the main chain.
+func (bc *BlockChain) indexMainChain(tipHash string, ancestorHash string) {
+	for hash := tipHash; hash != ancestorHash && hash != ""; {
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		bc.BlockInfoDB.IndexHeight(br.Height, hash)
+		bc.BlockInfoDB.UnmarkOrphaned(hash)
+		if bc.BlockInfoDB.HasTxIndex() {
+			bc.indexTransactions(hash, bc.GetBlock(hash))
 		}
+		hash = br.Header.PreviousHash
 	}
-	// add in the new hashes (reverse order because that's how getBlocksAndUndoBlocks
-	// returns them)
-	for i := len(blocks) - 1; i >= 0; i-- {
-		bc.UnsafeHashes = append(bc.UnsafeHashes, blocks[i].Hash())
+}
+
+// indexTransactions adds a main chain Block's Transactions to the
+// transaction index, if there is one.
+func (bc *BlockChain) indexTransactions(hash string, b *block.Block) {
+	if b == nil {
+		return
 	}
+	if err := bc.BlockInfoDB.IndexTransactions(hash, b.Transactions); err != nil {
+		utils.Debug.Printf("[blockchain.indexTransactions] %v", err)
+	}
+}
 
-	// (4) Reflect changes in coinDB
-	bc.CoinDB.UndoCoins(blocks, undoBlocks)
+// GetTransaction looks up a Transaction on the main chain using the
+// transaction index, and returns it with the height of its Block. It
+// returns nil if there is no transaction index, or the Transaction
+// isn't on the main chain. See FindTransaction.
+func (bc *BlockChain) GetTransaction(txHash string) (*block.Transaction, uint32) {
+	info, err := bc.FindTransaction(txHash, "")
+	if err != nil {
+		return nil, 0
+	}
+	return info.Transaction, info.Height
+}
 
-	// (5) Store our new blocks in the coinDB!
-	for _, bl := range blocks {
-		if !bc.CoinDB.ValidateBlock(bl.Transactions) {
-			utils.Debug.Printf("Validation failed for forked block {%v}", b.Hash())
-		}
-		bc.CoinDB.StoreBlock(bl.Transactions)
+// GetConfirmations returns how many Blocks on the main chain, counting
+// its own, confirm the Transaction with the given hash, or 0 if it
+// isn't on the main chain. It needs the transaction index.
+func (bc *BlockChain) GetConfirmations(txHash string) uint32 {
+	tx, height := bc.GetTransaction(txHash)
+	if tx == nil {
+		return 0
 	}
+	return bc.Length - height + 1
+}
 
-	// (5) Update blockchain fields
-	bc.LastBlock = b
-	bc.LastHash = b.Hash()
-	bc.Length = height
+// GetBlockByHeight returns the main chain's Block at height,
+// or nil if the main chain is not that long.
+func (bc *BlockChain) GetBlockByHeight(height uint32) *block.Block {
+	if height == 0 || height > bc.Length {
+		return nil
+	}
+	hash := bc.BlockInfoDB.GetHashByHeight(height)
+	if hash == "" {
+		return nil
+	}
+	return bc.GetBlock(hash)
 }
 
 // makeUndoBlock returns an UndoBlock given a slice of Transactions.
+// Inputs that spend outputs created earlier in the same Block are
+// skipped, since undoing the Block removes those outputs entirely.
 func (bc *BlockChain) makeUndoBlock(txs []*block.Transaction) *chainwriter.UndoBlock {
 	var transactionHashes []string
 	var outputIndexes []uint32
 	var amounts []uint32
 	var lockingScripts [][]byte
+	createdInBlock := make(map[string]bool)
 	for _, tx := range txs {
 		for _, txi := range tx.Inputs {
+			if createdInBlock[txi.ReferenceTransactionHash] {
+				continue
+			}
 			cl := coindatabase.CoinLocator{
 				ReferenceTransactionHash: txi.ReferenceTransactionHash,
 				OutputIndex:              txi.OutputIndex,
@@ -211,6 +687,7 @@ func (bc *BlockChain) makeUndoBlock(txs []*block.Transaction) *chainwriter.UndoB
 			amounts = append(amounts, coin.TransactionOutput.Amount)
 			lockingScripts = append(lockingScripts, coin.TransactionOutput.LockingScript)
 		}
+		createdInBlock[tx.Hash()] = true
 	}
 	return &chainwriter.UndoBlock{
 		TransactionInputHashes: transactionHashes,
@@ -221,9 +698,24 @@ func (bc *BlockChain) makeUndoBlock(txs []*block.Transaction) *chainwriter.UndoB
 }
 
 // GetBlock uses the ChainWriter to retrieve a Block from Disk
-// given that Block's hash
+// given that Block's hash. It returns nil if the Block was pruned,
+// or can't be read because it is corrupted.
 func (bc *BlockChain) GetBlock(blockHash string) *block.Block {
 	br := bc.BlockInfoDB.GetBlockRecord(blockHash)
+	if br == nil || br.Status.Has(blockinfodatabase.StatusPruned) {
+		return nil
+	}
+	b, err := bc.readBlock(br)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.GetBlock] %v", err)
+		return nil
+	}
+	return b
+}
+
+// readBlock uses the ChainWriter to retrieve the Block a BlockRecord
+// points to from Disk.
+func (bc *BlockChain) readBlock(br *blockinfodatabase.BlockRecord) (*block.Block, error) {
 	fi := &chainwriter.FileInfo{
 		FileName:    br.BlockFile,
 		StartOffset: br.BlockStartOffset,
@@ -233,9 +725,21 @@ func (bc *BlockChain) GetBlock(blockHash string) *block.Block {
 }
 
 // getUndoBlock uses the ChainWriter to retrieve an UndoBlock
-// from Disk given the corresponding Block's hash
-func (bc *BlockChain) getUndoBlock(blockHash string) *chainwriter.UndoBlock {
+// from Disk given the corresponding Block's hash. It returns an
+// error if the UndoBlock was pruned or can't be read, since undoing
+// the Block without it would leave the coins wrong.
+func (bc *BlockChain) getUndoBlock(blockHash string) (*chainwriter.UndoBlock, error) {
 	br := bc.BlockInfoDB.GetBlockRecord(blockHash)
+	if br == nil {
+		return nil, fmt.Errorf("[getUndoBlock] no block record for {%v}", blockHash)
+	}
+	if br.Status.Has(blockinfodatabase.StatusPruned) || br.Status.Has(blockinfodatabase.StatusUndoPruned) {
+		return nil, fmt.Errorf("[getUndoBlock] undo block for {%v} was pruned", blockHash)
+	}
+	// Blocks that don't spend any coins have no UndoBlock on Disk
+	if br.UndoFile == "" {
+		return &chainwriter.UndoBlock{}, nil
+	}
 	fi := &chainwriter.FileInfo{
 		FileName:    br.UndoFile,
 		StartOffset: br.UndoStartOffset,
@@ -246,7 +750,8 @@ func (bc *BlockChain) getUndoBlock(blockHash string) *chainwriter.UndoBlock {
 
 // GetBlocks retrieves a slice of blocks from the main chain given a
 // starting and ending height, inclusive. Given a chain of length 50,
-// GetBlocks(10, 20) returns blocks 10 through 20.
+// GetBlocks(10, 20) returns blocks 10 through 20. Blocks that were
+// pruned or can't be read are left out.
 func (bc *BlockChain) GetBlocks(start, end uint32) []*block.Block {
 	if start >= end || end <= 0 || start <= 0 || end > bc.Length {
 		utils.Debug.Printf("cannot get chain blocks with values start: %v end: %v", start, end)
@@ -258,14 +763,12 @@ func (bc *BlockChain) GetBlocks(start, end uint32) []*block.Block {
 
 	for currentHeight >= start {
 		br := bc.BlockInfoDB.GetBlockRecord(nextHash)
-		fi := &chainwriter.FileInfo{
-			FileName:    br.BlockFile,
-			StartOffset: br.BlockStartOffset,
-			EndOffset:   br.BlockEndOffset,
-		}
-		if currentHeight <= end {
-			nextBlock := bc.ChainWriter.ReadBlock(fi)
-			blocks = append(blocks, nextBlock)
+		if currentHeight <= end && !br.Status.Has(blockinfodatabase.StatusPruned) {
+			if nextBlock, err := bc.readBlock(br); err != nil {
+				utils.Debug.Printf("[blockchain.GetBlocks] %v", err)
+			} else {
+				blocks = append(blocks, nextBlock)
+			}
 		}
 		nextHash = br.Header.PreviousHash
 		currentHeight--
@@ -302,47 +805,29 @@ func (bc *BlockChain) appendsToActiveChain(b *block.Block) bool {
 	return bc.LastBlock.Hash() == b.Header.PreviousHash
 }
 
-// getForkLength returns the length of a fork, exclusive of the
-// common ancestor with the main chain. If it returns -1, the fork is
-// invalid
-func (bc *BlockChain) getForkLengthAndAncestor(startHash string) (int, string) {
-	// turn unsafeHashes into map for easier checking
-	unsafeHashes := make(map[string]bool)
-	for _, h := range bc.UnsafeHashes {
-		unsafeHashes[h] = true
-	}
-	forkLength := 0
-	nextHash := startHash
-	// keep going backwards on the forked chain until we find the common ancestor
-	// with the main chain. Update forkLength as we go
-	for i := 0; i < len(bc.UnsafeHashes)+1; i++ {
-		if _, ok := unsafeHashes[nextHash]; ok {
-			return forkLength, nextHash
-		}
-		br := bc.BlockInfoDB.GetBlockRecord(nextHash)
-		nextHash = br.Header.PreviousHash
-		forkLength++
-	}
-	// Couldn't find an ancestor hash, so invalid fork
-	return -1, ""
-}
-
 // getBlocksAndUndoBlocks returns a slice of n Blocks with a
 // corresponding slice of n UndoBlocks. They are returned in reverse order:
 // given block heights of 1, 2, and 3, this function will return the blocks
-// as [3, 2, 1] (to make undoing easier)
-func (bc *BlockChain) getBlocksAndUndoBlocks(n int, hash string) ([]*block.Block, []*chainwriter.UndoBlock) {
+// as [3, 2, 1] (to make undoing easier). It returns an error if any of
+// them can't be read.
+func (bc *BlockChain) getBlocksAndUndoBlocks(n int, hash string) ([]*block.Block, []*chainwriter.UndoBlock, error) {
 	var blocks []*block.Block
 	var undoBlocks []*chainwriter.UndoBlock
 	nextHash := hash
 	for i := 0; i < n; i++ {
 		b := bc.GetBlock(nextHash)
-		ub := bc.getUndoBlock(nextHash)
+		if b == nil {
+			return nil, nil, fmt.Errorf("[getBlocksAndUndoBlocks] unable to read block {%v}", nextHash)
+		}
+		ub, err := bc.getUndoBlock(nextHash)
+		if err != nil {
+			return nil, nil, fmt.Errorf("[getBlocksAndUndoBlocks] %v", err)
+		}
 		blocks = append(blocks, b)
 		undoBlocks = append(undoBlocks, ub)
 		nextHash = b.Header.PreviousHash
 	}
-	return blocks, undoBlocks
+	return blocks, undoBlocks, nil
 }
 
 // reverseBlocks returns a reversed slice of Blocks.
@@ -369,6 +854,33 @@ func (bc *BlockChain) GetBalance(pk []byte) uint32 {
 	return bc.CoinDB.GetBalance(pk)
 }
 
+// GetBalanceForPublicKey returns the total of the unspent outputs
+// locked to a public key, without needing a wallet for that key.
+func (bc *BlockChain) GetBalanceForPublicKey(pk []byte) uint32 {
+	return bc.CoinDB.GetBalanceForPublicKey(pk)
+}
+
+// GetUTXODelta returns the change to the unspent coins made by the
+// main chain's Blocks after startHeight, up to and including endHeight.
+func (bc *BlockChain) GetUTXODelta(startHeight, endHeight uint32) (*coindatabase.UTXODelta, error) {
+	if startHeight >= endHeight || endHeight > bc.Length {
+		return nil, fmt.Errorf("[GetUTXODelta] invalid height range (%v, %v] for chain of length %v",
+			startHeight, endHeight, bc.Length)
+	}
+	var blocks []*block.Block
+	for height := startHeight + 1; height <= endHeight; height++ {
+		b := bc.GetBlockByHeight(height)
+		if b == nil {
+			if bc.IsPruned(bc.BlockInfoDB.GetHashByHeight(height)) {
+				return nil, fmt.Errorf("[GetUTXODelta] block at height %v was pruned", height)
+			}
+			return nil, fmt.Errorf("[GetUTXODelta] no block at height %v", height)
+		}
+		blocks = append(blocks, b)
+	}
+	return coindatabase.MakeUTXODelta(blocks), nil
+}
+
 func (bc *BlockChain) List() []*block.Block {
 	return bc.GetBlocks(1, bc.Length)
 }
@@ -396,3 +908,35 @@ func (bc *BlockChain) GetInputSums(txs []*block.Transaction) []uint32 {
 	}
 	return sums
 }
+
+// BlockInputSums returns the sum of the inputs of each of a connected
+// Block's Transactions, as GetInputSums would have before the Block
+// spent them, reading the amounts from the Block's UndoBlock. Inputs
+// spending outputs of earlier Transactions in the Block are read from
+// those, and the coinbase's sum is 0.
+func (bc *BlockChain) BlockInputSums(b *block.Block) ([]uint32, error) {
+	ub, err := bc.getUndoBlock(b.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if err = coindatabase.CheckUndoBlock(b, ub); err != nil {
+		return nil, err
+	}
+	created := make(map[string]*block.Transaction)
+	sums := make([]uint32, len(b.Transactions))
+	k := 0
+	for i, tx := range b.Transactions {
+		for _, txi := range tx.Inputs {
+			if parent, ok := created[txi.ReferenceTransactionHash]; ok {
+				if int(txi.OutputIndex) < len(parent.Outputs) {
+					sums[i] += parent.Outputs[txi.OutputIndex].Amount
+				}
+				continue
+			}
+			sums[i] += ub.Amounts[k]
+			k++
+		}
+		created[tx.Hash()] = tx
+	}
+	return sums, nil
+}
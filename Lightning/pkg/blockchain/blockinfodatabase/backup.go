@@ -0,0 +1,115 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/utils"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"os"
+)
+
+// backupBatchSize is how many entries Backup copies in each write.
+const backupBatchSize = 1000
+
+// Backup copies everything in the BlockInfoDatabase, including its tip,
+// height index, and schema version, into a new levelDB at path. It reads
+// from a levelDB snapshot, so the copy is consistent even while the node
+// keeps writing. path must not already exist. It returns how many
+// entries were copied.
+func (blockInfoDB *BlockInfoDatabase) Backup(path string) (int, error) {
+	if _, err := os.Stat(path); err == nil {
+		return 0, fmt.Errorf("[Backup] %v already exists", path)
+	}
+	snapshot, err := blockInfoDB.db.GetSnapshot()
+	if err != nil {
+		return 0, fmt.Errorf("[Backup] failed to take a snapshot: %v", err)
+	}
+	defer snapshot.Release()
+	backup, err := leveldb.OpenFile(path, &opt.Options{ErrorIfExist: true})
+	if err != nil {
+		return 0, fmt.Errorf("[Backup] failed to create backup at %v: %v", path, err)
+	}
+	n, err := copyEntries(snapshot, backup)
+	if closeErr := backup.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.RemoveAll(path)
+		return 0, fmt.Errorf("[Backup] failed to copy to %v: %v", path, err)
+	}
+	return n, nil
+}
+
+// copyEntries copies every entry in snapshot to dst, in batches.
+func copyEntries(snapshot *leveldb.Snapshot, dst *leveldb.DB) (int, error) {
+	iterator := snapshot.NewIterator(nil, nil)
+	defer iterator.Release()
+	batch := new(leveldb.Batch)
+	n := 0
+	for iterator.Next() {
+		batch.Put(append([]byte{}, iterator.Key()...), append([]byte{}, iterator.Value()...))
+		n++
+		if batch.Len() >= backupBatchSize {
+			if err := dst.Write(batch, nil); err != nil {
+				return n, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iterator.Error(); err != nil {
+		return n, err
+	}
+	return n, dst.Write(batch, &opt.WriteOptions{Sync: true})
+}
+
+// Restore replaces everything in the BlockInfoDatabase with the backup
+// made by Backup at path, in a single write, and then migrates it if the
+// backup is from an older schema version. It returns how many entries
+// were restored. The BlockChain using the BlockInfoDatabase must be
+// reopened afterwards, so that it resumes from the restored tip.
+func (blockInfoDB *BlockInfoDatabase) Restore(path string) (int, error) {
+	backup, snapshotDir, err := utils.OpenLevelDB(path, true)
+	if err != nil {
+		return 0, fmt.Errorf("[Restore] failed to open backup at %v: %v", path, err)
+	}
+	defer func() {
+		backup.Close()
+		if snapshotDir != "" {
+			os.RemoveAll(snapshotDir)
+		}
+	}()
+	restored := &BlockInfoDatabase{db: backup, cache: newRecordCache(0), stats: newDBStats()}
+	if version, err := restored.GetSchemaVersion(); err != nil {
+		return 0, fmt.Errorf("[Restore] %v", err)
+	} else if version > SchemaVersion {
+		return 0, fmt.Errorf("[Restore] backup has schema version %v, newer than %v", version, SchemaVersion)
+	}
+	if restored.GetTip() == nil {
+		return 0, fmt.Errorf("[Restore] backup at %v has no tip", path)
+	}
+
+	batch := new(leveldb.Batch)
+	current := blockInfoDB.db.NewIterator(nil, nil)
+	for current.Next() {
+		batch.Delete(append([]byte{}, current.Key()...))
+	}
+	current.Release()
+	n := 0
+	iterator := backup.NewIterator(nil, nil)
+	for iterator.Next() {
+		batch.Put(append([]byte{}, iterator.Key()...), append([]byte{}, iterator.Value()...))
+		n++
+	}
+	iterator.Release()
+	if err = iterator.Error(); err != nil {
+		return 0, fmt.Errorf("[Restore] failed to read backup at %v: %v", path, err)
+	}
+	if err = blockInfoDB.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return 0, fmt.Errorf("[Restore] failed to write restored entries: %v", err)
+	}
+	blockInfoDB.cache.clear()
+	if _, err = blockInfoDB.Migrate(); err != nil {
+		return n, fmt.Errorf("[Restore] %v", err)
+	}
+	return n, nil
+}
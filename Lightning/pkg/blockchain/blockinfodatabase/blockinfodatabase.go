@@ -4,21 +4,84 @@ import (
 	"Coin/pkg/pro"
 	"Coin/pkg/utils"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"fmt"
 	"google.golang.org/protobuf/proto"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// heightKeyPrefix starts the keys of the height index, which maps the
+// heights of Blocks on the main chain to their hashes. Block hashes are
+// hex, so they never start with it.
+const heightKeyPrefix = "height:"
+
+// heightKey returns the height index key for a height. Heights are zero
+// padded so that the index is sorted by height.
+func heightKey(height uint32) []byte {
+	return []byte(fmt.Sprintf("%v%010d", heightKeyPrefix, height))
+}
+
+// isMetadataKey returns whether key is one of the keys the
+// BlockInfoDatabase uses for something other than a BlockRecord.
+func isMetadataKey(key string) bool {
+	return key == tipKey || key == schemaKey || key == writerStateKey || strings.HasPrefix(key, heightKeyPrefix) ||
+		strings.HasPrefix(key, orphanKeyPrefix) || strings.HasPrefix(key, txKeyPrefix) || strings.HasPrefix(key, createdKeyPrefix)
+}
+
 // BlockInfoDatabase is a wrapper for a levelDB
+// snapshotDir is where a copy of a locked levelDB was
+// made when opening it read-only, if one was needed.
+// deferSync is whether batches of BlockRecords are
+// written without waiting for them to reach the disk.
+// cache holds recently used BlockRecords, so hot paths
+// like walking back along a chain don't go to the db.
+// txIndex is whether Transactions are indexed by hash.
+// openErr is the error from opening the db, if there was one.
+// path is where the db is on disk.
+// stats are the latencies and cache lookups reported by Metrics.
 type BlockInfoDatabase struct {
-	db *leveldb.DB
+	db          *leveldb.DB
+	path        string
+	snapshotDir string
+	deferSync   bool
+	cache       *recordCache
+	txIndex     bool
+	openErr     error
+	stats       *dbStats
 }
 
 // New returns a BlockInfoDatabase given a Config
 func New(config *Config) *BlockInfoDatabase {
-	db, err := leveldb.OpenFile(config.DatabasePath, nil)
+	db, snapshotDir, err := utils.OpenLevelDB(config.DatabasePath, config.ReadOnly)
 	if err != nil {
-		utils.Debug.Printf("Unable to initialize BlockInfoDatabase with path {%v}", config.DatabasePath)
+		utils.Debug.Printf("Unable to initialize BlockInfoDatabase with path {%v}: %v", config.DatabasePath, err)
 	}
-	return &BlockInfoDatabase{db: db}
+	blockInfoDB := &BlockInfoDatabase{
+		db:          db,
+		path:        config.DatabasePath,
+		snapshotDir: snapshotDir,
+		deferSync:   config.DeferSync,
+		cache:       newRecordCache(config.CacheSize),
+		txIndex:     config.TxIndex,
+		openErr:     err,
+		stats:       newDBStats(),
+	}
+	if db != nil && !config.ReadOnly {
+		blockInfoDB.initSchema()
+	}
+	return blockInfoDB
+}
+
+// Err returns the error from opening the BlockInfoDatabase, or nil if
+// it opened cleanly. If the db was corrupted, it is a
+// *utils.CorruptionError, and the BlockRecords should be rebuilt.
+func (blockInfoDB *BlockInfoDatabase) Err() error {
+	return blockInfoDB.openErr
 }
 
 // StoreBlockRecord stores a BlockRecord in the BlockInfoDatabase.
@@ -36,30 +99,183 @@ func (blockInfoDB *BlockInfoDatabase) StoreBlockRecord(hash string, blockRecord
 	bytes, err := proto.Marshal(protoRecord)
 	// checking that the marshalling process didn't throw an error
 	if err != nil {
-		utils.Debug.Printf("Failed to marshal protoRecord:", err)
+		utils.Debug.Printf("Failed to marshal protoRecord: %v", err)
 	}
 	// attempting to store the bytes in our database AND checking to make
 	// sure that the storing process doesn't fail. The Put(key, value, writeOptions)
 	// function is levelDB's.
-	if err = blockInfoDB.db.Put([]byte(hash), bytes, nil); err != nil {
+	start := time.Now()
+	err = blockInfoDB.db.Put([]byte(hash), bytes, nil)
+	blockInfoDB.stats.write(start)
+	if err != nil {
 		utils.Debug.Printf("Unable to store block protoRecord for hash {%v}", hash)
+		blockInfoDB.cache.remove(hash)
+		return
+	}
+	blockInfoDB.cache.put(hash, blockRecord)
+}
+
+// StoreBlockRecords stores many BlockRecords in a single write, which is
+// much faster than storing them one at a time during initial sync.
+// hashes[i] is the hash of the block that blockRecords[i] is for.
+// Unless the BlockInfoDatabase defers syncing, the write is synced to
+// disk before StoreBlockRecords returns.
+func (blockInfoDB *BlockInfoDatabase) StoreBlockRecords(hashes []string, blockRecords []*BlockRecord) error {
+	if len(hashes) != len(blockRecords) {
+		return fmt.Errorf("[StoreBlockRecords] %v hashes for %v block records", len(hashes), len(blockRecords))
+	}
+	batch := new(leveldb.Batch)
+	for i, br := range blockRecords {
+		bytes, err := proto.Marshal(EncodeBlockRecord(br))
+		if err != nil {
+			return fmt.Errorf("[StoreBlockRecords] failed to marshal record for hash {%v}: %v", hashes[i], err)
+		}
+		batch.Put([]byte(hashes[i]), bytes)
+	}
+	start := time.Now()
+	err := blockInfoDB.db.Write(batch, &opt.WriteOptions{Sync: !blockInfoDB.deferSync})
+	blockInfoDB.stats.write(start)
+	if err != nil {
+		return fmt.Errorf("[StoreBlockRecords] failed to store %v block records: %v", batch.Len(), err)
+	}
+	for i, br := range blockRecords {
+		blockInfoDB.cache.put(hashes[i], br)
+	}
+	return nil
+}
+
+// StoreBlockRecordAndSetTip stores a BlockRecord, makes its Block the
+// tip of the main chain, and indexes it at the tip's height, all in a
+// single LevelDB batch. Either all three reach the database or none
+// do, so a crash can never leave the tip pointing at a Block that
+// wasn't recorded, or the height index disagreeing with the tip.
+// If state isn't nil, it is stored in the same batch.
+func (blockInfoDB *BlockInfoDatabase) StoreBlockRecordAndSetTip(hash string, blockRecord *BlockRecord, tip *ChainTip, state *WriterState) error {
+	if tip.Hash != hash {
+		return fmt.Errorf("[StoreBlockRecordAndSetTip] tip {%v} is not block {%v}", tip.Hash, hash)
+	}
+	recordBytes, err := proto.Marshal(EncodeBlockRecord(blockRecord))
+	if err != nil {
+		return fmt.Errorf("[StoreBlockRecordAndSetTip] failed to marshal record for hash {%v}: %v", hash, err)
+	}
+	tipBytes, err := proto.Marshal(EncodeChainTip(tip))
+	if err != nil {
+		return fmt.Errorf("[StoreBlockRecordAndSetTip] failed to marshal chain tip: %v", err)
+	}
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(hash), recordBytes)
+	batch.Put(heightKey(tip.Height), []byte(hash))
+	batch.Put([]byte(tipKey), tipBytes)
+	if state != nil {
+		batch.Put([]byte(writerStateKey), encodeWriterState(state))
+	}
+	start := time.Now()
+	err = blockInfoDB.db.Write(batch, &opt.WriteOptions{Sync: !blockInfoDB.deferSync})
+	blockInfoDB.stats.write(start)
+	if err != nil {
+		blockInfoDB.cache.remove(hash)
+		return fmt.Errorf("[StoreBlockRecordAndSetTip] failed to store record and tip for hash {%v}: %v", hash, err)
+	}
+	blockInfoDB.cache.put(hash, blockRecord)
+	return nil
+}
+
+// StoreBlockRecordWithWriterState stores a BlockRecord and the
+// WriterState after its Block was written in a single LevelDB batch,
+// for Blocks that don't extend the main chain.
+func (blockInfoDB *BlockInfoDatabase) StoreBlockRecordWithWriterState(hash string, blockRecord *BlockRecord, state *WriterState) error {
+	recordBytes, err := proto.Marshal(EncodeBlockRecord(blockRecord))
+	if err != nil {
+		return fmt.Errorf("[StoreBlockRecordWithWriterState] failed to marshal record for hash {%v}: %v", hash, err)
+	}
+	batch := new(leveldb.Batch)
+	batch.Put([]byte(hash), recordBytes)
+	batch.Put([]byte(writerStateKey), encodeWriterState(state))
+	start := time.Now()
+	err = blockInfoDB.db.Write(batch, &opt.WriteOptions{Sync: !blockInfoDB.deferSync})
+	blockInfoDB.stats.write(start)
+	if err != nil {
+		blockInfoDB.cache.remove(hash)
+		return fmt.Errorf("[StoreBlockRecordWithWriterState] failed to store record for hash {%v}: %v", hash, err)
+	}
+	blockInfoDB.cache.put(hash, blockRecord)
+	return nil
+}
+
+// SetTip records the tip of the main chain.
+func (blockInfoDB *BlockInfoDatabase) SetTip(tip *ChainTip) {
+	bytes, err := proto.Marshal(EncodeChainTip(tip))
+	if err != nil {
+		utils.Debug.Printf("Failed to marshal chain tip: %v", err)
+		return
+	}
+	if err = blockInfoDB.db.Put([]byte(tipKey), bytes, nil); err != nil {
+		utils.Debug.Printf("Unable to store chain tip {%v}", tip.Hash)
 	}
 }
 
+// GetTip returns the tip of the main chain, or nil if none
+// has been recorded.
+func (blockInfoDB *BlockInfoDatabase) GetTip() *ChainTip {
+	data, err := blockInfoDB.db.Get([]byte(tipKey), nil)
+	if err != nil {
+		return nil
+	}
+	ptip := &pro.ChainTip{}
+	if err = proto.Unmarshal(data, ptip); err != nil {
+		utils.Debug.Printf("Failed to unmarshal chain tip: %v", err)
+		return nil
+	}
+	tip := DecodeChainTip(ptip)
+	if err = ValidateChainTip(tip); err != nil {
+		utils.Debug.Printf("Rejected chain tip: %v", err)
+		return nil
+	}
+	return tip
+}
+
+// HasBlockRecord returns whether the BlockInfoDatabase has a BlockRecord
+// for the block with the given hash.
+func (blockInfoDB *BlockInfoDatabase) HasBlockRecord(hash string) bool {
+	if blockInfoDB.cache.get(hash) != nil {
+		blockInfoDB.stats.lookup(true)
+		return true
+	}
+	blockInfoDB.stats.lookup(false)
+	start := time.Now()
+	has, err := blockInfoDB.db.Has([]byte(hash), nil)
+	blockInfoDB.stats.read(start)
+	if err != nil {
+		utils.Debug.Printf("Unable to check for block record with hash {%v}: %v", hash, err)
+		return false
+	}
+	return has
+}
+
 // GetBlockRecord returns a BlockRecord from the BlockInfoDatabase given
-// the relevant block's hash.
+// the relevant block's hash. It returns nil if the stored BlockRecord
+// is malformed. Recently used BlockRecords are returned from the cache.
 // hash is the hash of the block, and the key for the blockRecord.
 //
 // At a high level, here's what this function is doing:
 // (1) retrieving the byte version of the protobuf record.
 // (2) converting the bytes to protobuf
-// (3) converting the protobuf to blockRecord and returning that.
+// (3) converting the protobuf to blockRecord, validating it,
+// and returning that.
 func (blockInfoDB *BlockInfoDatabase) GetBlockRecord(hash string) *BlockRecord {
+	if br := blockInfoDB.cache.get(hash); br != nil {
+		blockInfoDB.stats.lookup(true)
+		return br
+	}
+	blockInfoDB.stats.lookup(false)
 	// attempting to retrieve the byte-version of the protobuf record
 	// from our database AND checking that the value is retrieved successfully.
 	// The Get(key, writeOptions) function is levelDB's.
+	start := time.Now()
 	data, err := blockInfoDB.db.Get([]byte(hash), nil)
-	if err != nil {
+	blockInfoDB.stats.read(start)
+	found := err == nil
+	if !found {
 		utils.Debug.Printf("Unable to get block record for hash {%v}", hash)
 	}
 	// creating a protobuf blockRecord object to fill
@@ -68,13 +284,135 @@ func (blockInfoDB *BlockInfoDatabase) GetBlockRecord(hash string) *BlockRecord {
 	// protobuf object created on line 66. Checking that the conversion process
 	// from bytes to protobuf object succeeds.
 	if err = proto.Unmarshal(data, protoRecord); err != nil {
-		utils.Debug.Printf("Failed to unmarshal record from hash {%v}:", hash, err)
+		utils.Debug.Printf("Failed to unmarshal record from hash {%v}: %v", hash, err)
+	}
+	// convert the protobuf record to a normal blockRecord, and make sure
+	// it's well formed before returning it.
+	br := DecodeBlockRecord(protoRecord)
+	if err = ValidateBlockRecord(br); err != nil {
+		utils.Debug.Printf("Rejected block record for hash {%v}: %v", hash, err)
+		return nil
+	}
+	// only cache records that are actually in the db
+	if found {
+		blockInfoDB.cache.put(hash, br)
+	}
+	return br
+}
+
+// IndexHeight records that the Block with the given hash is the main
+// chain's Block at height, replacing any Block previously at that height.
+func (blockInfoDB *BlockInfoDatabase) IndexHeight(height uint32, hash string) {
+	if err := blockInfoDB.db.Put(heightKey(height), []byte(hash), nil); err != nil {
+		utils.Debug.Printf("Unable to index block {%v} at height %v: %v", hash, height, err)
 	}
-	// convert the protobuf record to a normal blockRecord and returning that.
-	return DecodeBlockRecord(protoRecord)
+}
+
+// TruncateHeightIndex removes every Block above height from the height
+// index, for when the main chain is reorganized onto a shorter one.
+func (blockInfoDB *BlockInfoDatabase) TruncateHeightIndex(height uint32) {
+	if height == math.MaxUint32 {
+		return
+	}
+	limit := util.BytesPrefix([]byte(heightKeyPrefix)).Limit
+	iterator := blockInfoDB.db.NewIterator(&util.Range{Start: heightKey(height + 1), Limit: limit}, nil)
+	defer iterator.Release()
+	batch := new(leveldb.Batch)
+	for iterator.Next() {
+		batch.Delete(append([]byte{}, iterator.Key()...))
+	}
+	if batch.Len() == 0 {
+		return
+	}
+	if err := blockInfoDB.db.Write(batch, nil); err != nil {
+		utils.Debug.Printf("Unable to truncate height index above %v: %v", height, err)
+	}
+}
+
+// GetHashByHeight returns the hash of the main chain's Block at height,
+// or an empty string if no Block is indexed at that height.
+func (blockInfoDB *BlockInfoDatabase) GetHashByHeight(height uint32) string {
+	data, err := blockInfoDB.db.Get(heightKey(height), nil)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// GetBlockRecordByHeight returns the BlockRecord of the main chain's
+// Block at height, or nil if no Block is indexed at that height.
+func (blockInfoDB *BlockInfoDatabase) GetBlockRecordByHeight(height uint32) *BlockRecord {
+	hash := blockInfoDB.GetHashByHeight(height)
+	if hash == "" {
+		utils.Debug.Printf("No block indexed at height %v", height)
+		return nil
+	}
+	return blockInfoDB.GetBlockRecord(hash)
+}
+
+// GetRecordsInRange returns the BlockRecords of the main chain's Blocks
+// from startHeight to endHeight, inclusive, in height order. It walks the
+// height index with a single iterator, and stops at the first height that
+// isn't indexed, so the BlockRecords returned are always a contiguous run.
+func (blockInfoDB *BlockInfoDatabase) GetRecordsInRange(startHeight, endHeight uint32) []*BlockRecord {
+	var records []*BlockRecord
+	if startHeight > endHeight {
+		return records
+	}
+	limit := util.BytesPrefix([]byte(heightKeyPrefix)).Limit
+	if endHeight < math.MaxUint32 {
+		limit = heightKey(endHeight + 1)
+	}
+	iterator := blockInfoDB.db.NewIterator(&util.Range{Start: heightKey(startHeight), Limit: limit}, nil)
+	defer iterator.Release()
+	next := uint64(startHeight)
+	for iterator.Next() {
+		height, err := strconv.ParseUint(strings.TrimPrefix(string(iterator.Key()), heightKeyPrefix), 10, 32)
+		if err != nil || height != next {
+			break
+		}
+		br := blockInfoDB.GetBlockRecord(string(iterator.Value()))
+		if br == nil {
+			break
+		}
+		records = append(records, br)
+		next++
+	}
+	if err := iterator.Error(); err != nil {
+		utils.Debug.Printf("Failed to iterate over heights %v to %v: %v", startHeight, endHeight, err)
+	}
+	return records
+}
+
+// GetAllBlockRecords returns every BlockRecord in the BlockInfoDatabase,
+// keyed by the hash of the relevant block.
+func (blockInfoDB *BlockInfoDatabase) GetAllBlockRecords() map[string]*BlockRecord {
+	records := make(map[string]*BlockRecord)
+	iterator := blockInfoDB.db.NewIterator(nil, nil)
+	for iterator.Next() {
+		if isMetadataKey(string(iterator.Key())) {
+			continue
+		}
+		protoRecord := &pro.BlockRecord{}
+		if err := proto.Unmarshal(iterator.Value(), protoRecord); err != nil {
+			utils.Debug.Printf("Failed to unmarshal record from hash {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		br := DecodeBlockRecord(protoRecord)
+		if err := ValidateBlockRecord(br); err != nil {
+			utils.Debug.Printf("Rejected block record for hash {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		records[string(iterator.Key())] = br
+	}
+	iterator.Release()
+	return records
 }
 
 // Close is used to actually shut down the db (for testing purposes)
 func (blockInfoDB *BlockInfoDatabase) Close() {
 	blockInfoDB.db.Close()
+	if blockInfoDB.snapshotDir != "" {
+		os.RemoveAll(blockInfoDB.snapshotDir)
+	}
 }
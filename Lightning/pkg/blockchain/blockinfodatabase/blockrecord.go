@@ -3,6 +3,8 @@ package blockinfodatabase
 import (
 	"Coin/pkg/block"
 	"Coin/pkg/pro"
+	"fmt"
+	"math/big"
 )
 
 // BlockRecord contains information about where a Block
@@ -20,6 +22,13 @@ import (
 // the UndoFile.
 // UndoEndOffset is the ending offset of the UndoBlock within the
 // UndoFile.
+// ChainWork is the total work of the chain ending at the Block,
+// from the genesis Block on. It is zero for records stored before
+// it was tracked.
+// Status is how far the Block has been validated.
+// UTXOHash is the state of the coindatabase.UTXOHash of the unspent
+// Coins once the Block is connected, or nil if it isn't tracked (see
+// Config.UTXOCommitmentHeight in package blockchain).
 type BlockRecord struct {
 	Header               *block.Header
 	Height               uint32
@@ -32,6 +41,10 @@ type BlockRecord struct {
 	UndoFile        string
 	UndoStartOffset uint32
 	UndoEndOffset   uint32
+
+	ChainWork *big.Int
+	Status    Status
+	UTXOHash  []byte
 }
 
 // EncodeBlockRecord returns a pro.BlockRecord given a BlockRecord.
@@ -46,9 +59,21 @@ func EncodeBlockRecord(br *BlockRecord) *pro.BlockRecord {
 		UndoFile:             br.UndoFile,
 		UndoStartOffset:      br.UndoStartOffset,
 		UndoEndOffset:        br.UndoEndOffset,
+		ChainWork:            chainWorkBytes(br.ChainWork),
+		Status:               uint32(br.Status),
+		UtxoHash:             br.UTXOHash,
 	}
 }
 
+// chainWorkBytes returns the bytes of a BlockRecord's ChainWork,
+// or nil if it has none.
+func chainWorkBytes(work *big.Int) []byte {
+	if work == nil {
+		return nil
+	}
+	return work.Bytes()
+}
+
 // DecodeBlockRecord returns a BlockRecord given a pro.BlockRecord.
 func DecodeBlockRecord(pbr *pro.BlockRecord) *BlockRecord {
 	return &BlockRecord{
@@ -61,5 +86,24 @@ func DecodeBlockRecord(pbr *pro.BlockRecord) *BlockRecord {
 		UndoFile:             pbr.GetUndoFile(),
 		UndoStartOffset:      pbr.GetUndoStartOffset(),
 		UndoEndOffset:        pbr.GetUndoEndOffset(),
+		ChainWork:            new(big.Int).SetBytes(pbr.GetChainWork()),
+		Status:               Status(pbr.GetStatus()),
+		UTXOHash:             pbr.GetUtxoHash(),
+	}
+}
+
+// ValidateBlockRecord returns an error if a decoded BlockRecord is
+// malformed: its Header must be valid, and each of its ending offsets
+// must come after the corresponding starting offset.
+func ValidateBlockRecord(br *BlockRecord) error {
+	if err := block.ValidateHeader(br.Header); err != nil {
+		return fmt.Errorf("[ValidateBlockRecord] %v", err)
+	}
+	if br.BlockStartOffset > br.BlockEndOffset {
+		return fmt.Errorf("[ValidateBlockRecord] block offsets %v to %v are out of order", br.BlockStartOffset, br.BlockEndOffset)
+	}
+	if br.UndoStartOffset > br.UndoEndOffset {
+		return fmt.Errorf("[ValidateBlockRecord] undo offsets %v to %v are out of order", br.UndoStartOffset, br.UndoEndOffset)
 	}
+	return nil
 }
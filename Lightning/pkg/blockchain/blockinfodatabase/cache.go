@@ -0,0 +1,87 @@
+package blockinfodatabase
+
+import (
+	"container/list"
+	"sync"
+)
+
+// recordCache is a bounded cache of recently used BlockRecords,
+// keyed by their Block's hash. Once it holds size BlockRecords,
+// adding another evicts the least recently used one.
+// order has the most recently used entry at the front,
+// entries maps a hash to its element in order.
+type recordCache struct {
+	size int
+
+	mutex   sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// cacheEntry is a BlockRecord in a recordCache.
+type cacheEntry struct {
+	hash string
+	br   *BlockRecord
+}
+
+// newRecordCache returns a recordCache that holds up to size
+// BlockRecords. If size is 0, nothing is cached.
+func newRecordCache(size int) *recordCache {
+	return &recordCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached BlockRecord for hash, or nil if there
+// is none, and marks it as the most recently used.
+func (c *recordCache) get(hash string) *BlockRecord {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[hash]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).br
+}
+
+// put caches the BlockRecord for hash, evicting the least
+// recently used BlockRecord if the cache is full.
+func (c *recordCache) put(hash string, br *BlockRecord) {
+	if c.size <= 0 || br == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if e, ok := c.entries[hash]; ok {
+		e.Value.(*cacheEntry).br = br
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[hash] = c.order.PushFront(&cacheEntry{hash: hash, br: br})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).hash)
+	}
+}
+
+// remove drops the BlockRecord for hash from the cache.
+func (c *recordCache) remove(hash string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if e, ok := c.entries[hash]; ok {
+		c.order.Remove(e)
+		delete(c.entries, hash)
+	}
+}
+
+// clear drops every BlockRecord from the cache.
+func (c *recordCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
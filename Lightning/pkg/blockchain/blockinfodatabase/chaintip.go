@@ -0,0 +1,52 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"fmt"
+	"math/big"
+)
+
+// tipKey is the key the best ChainTip is stored under. Block hashes
+// are hex, so they are never equal to it.
+const tipKey = "tip"
+
+// ChainTip records which Block is the tip of the main chain.
+// Hash is the hash of the tip.
+// Height is the height of the tip.
+// CumulativeWork is the total work of the main chain, from the
+// genesis Block to the tip.
+type ChainTip struct {
+	Hash           string
+	Height         uint32
+	CumulativeWork *big.Int
+}
+
+// EncodeChainTip returns a pro.ChainTip given a ChainTip.
+func EncodeChainTip(tip *ChainTip) *pro.ChainTip {
+	return &pro.ChainTip{
+		Hash:           tip.Hash,
+		Height:         tip.Height,
+		CumulativeWork: tip.CumulativeWork.Bytes(),
+	}
+}
+
+// DecodeChainTip returns a ChainTip given a pro.ChainTip.
+func DecodeChainTip(ptip *pro.ChainTip) *ChainTip {
+	return &ChainTip{
+		Hash:           ptip.GetHash(),
+		Height:         ptip.GetHeight(),
+		CumulativeWork: new(big.Int).SetBytes(ptip.GetCumulativeWork()),
+	}
+}
+
+// ValidateChainTip returns an error if a decoded ChainTip is malformed.
+func ValidateChainTip(tip *ChainTip) error {
+	if err := block.ValidateHash(tip.Hash); err != nil {
+		return fmt.Errorf("[ValidateChainTip] %v", err)
+	}
+	if tip.Height == 0 {
+		return fmt.Errorf("[ValidateChainTip] tip has height 0")
+	}
+	return nil
+}
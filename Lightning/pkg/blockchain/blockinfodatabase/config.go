@@ -1,12 +1,25 @@
 package blockinfodatabase
 
 // Config is the BlockInfoDatabase's configuration options.
+// ReadOnly is whether to open the database so it can't be
+// written to, for tools that inspect a node's data.
+// DeferSync is whether StoreBlockRecords may return before its
+// batch is synced to disk. This speeds up initial sync, but a
+// crash may lose the most recent batch.
+// CacheSize is how many recently used BlockRecords are kept in
+// memory. If it is 0, BlockRecords are always read from the db.
+// TxIndex is whether to keep an index from Transaction hashes
+// to where the Transactions are in Blocks.
 type Config struct {
 	DatabasePath string
+	ReadOnly     bool
+	DeferSync    bool
+	CacheSize    int
+	TxIndex      bool
 }
 
 // DefaultConfig returns the default configuration for the
 // BlockInfoDatabase.
 func DefaultConfig() *Config {
-	return &Config{DatabasePath: "blockinfodata"}
+	return &Config{DatabasePath: "blockinfodata", CacheSize: 1000}
 }
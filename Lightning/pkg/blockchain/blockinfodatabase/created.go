@@ -0,0 +1,48 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/block"
+	"fmt"
+	"strings"
+)
+
+// createdKeyPrefix starts the keys that map the hash of a Block to the
+// hashes of the Transactions it created, which is all that's needed to
+// tell how old a Coin is once the Block's body has been pruned. Block
+// hashes are hex, so they never start with it.
+const createdKeyPrefix = "created:"
+
+// createdKey returns the key of the Transactions created by the Block
+// with hash.
+func createdKey(hash string) []byte {
+	return []byte(createdKeyPrefix + hash)
+}
+
+// StoreCreatedTransactions records the hashes of a Block's
+// Transactions, so GetCreatedTransactions can return them after the
+// Block's body is pruned.
+func (blockInfoDB *BlockInfoDatabase) StoreCreatedTransactions(blockHash string, txs []*block.Transaction) error {
+	var hashes strings.Builder
+	for _, tx := range txs {
+		hashes.WriteString(tx.Hash())
+	}
+	if err := blockInfoDB.db.Put(createdKey(blockHash), []byte(hashes.String()), nil); err != nil {
+		return fmt.Errorf("[StoreCreatedTransactions] failed to store transactions of {%v}: %v", blockHash, err)
+	}
+	return nil
+}
+
+// GetCreatedTransactions returns the hashes of the Transactions in the
+// Block with hash, in order, and whether they were recorded at all. A
+// Block stored before they were recorded has to be read instead.
+func (blockInfoDB *BlockInfoDatabase) GetCreatedTransactions(blockHash string) ([]string, bool) {
+	data, err := blockInfoDB.db.Get(createdKey(blockHash), nil)
+	if err != nil || len(data)%block.HashLength != 0 {
+		return nil, false
+	}
+	var hashes []string
+	for i := 0; i < len(data); i += block.HashLength {
+		hashes = append(hashes, string(data[i:i+block.HashLength]))
+	}
+	return hashes, true
+}
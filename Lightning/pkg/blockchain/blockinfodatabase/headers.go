@@ -0,0 +1,64 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"strconv"
+	"strings"
+)
+
+// MaxHeaders is the most Headers GetHeaders returns at once.
+const MaxHeaders = 2000
+
+// GetHeaders returns the Headers of the main chain's Blocks that follow
+// the last Block a peer has in common with it, for headers-first sync.
+// locator is hashes of the peer's chain, from its tip back toward its
+// genesis Block, and the first of them on our main chain is taken to be
+// the common Block. If none are, the genesis Block is. Headers are
+// returned in height order, up to and including the Header of the Block
+// with stopHash, or MaxHeaders of them, whichever comes first. An empty
+// stopHash sends as many as possible.
+func (blockInfoDB *BlockInfoDatabase) GetHeaders(locator []string, stopHash string) []*block.Header {
+	startHeight := blockInfoDB.forkHeight(locator) + 1
+	var headers []*block.Header
+	limit := util.BytesPrefix([]byte(heightKeyPrefix)).Limit
+	iterator := blockInfoDB.db.NewIterator(&util.Range{Start: heightKey(startHeight), Limit: limit}, nil)
+	defer iterator.Release()
+	next := uint64(startHeight)
+	for len(headers) < MaxHeaders && iterator.Next() {
+		height, err := strconv.ParseUint(strings.TrimPrefix(string(iterator.Key()), heightKeyPrefix), 10, 32)
+		if err != nil || height != next {
+			break
+		}
+		hash := string(iterator.Value())
+		br := blockInfoDB.GetBlockRecord(hash)
+		if br == nil {
+			break
+		}
+		headers = append(headers, br.Header)
+		if hash == stopHash {
+			break
+		}
+		next++
+	}
+	if err := iterator.Error(); err != nil {
+		utils.Debug.Printf("Failed to iterate over heights from %v: %v", startHeight, err)
+	}
+	return headers
+}
+
+// forkHeight returns the height of the first Block in locator that is on
+// the main chain, or the genesis Block's height if none are.
+func (blockInfoDB *BlockInfoDatabase) forkHeight(locator []string) uint32 {
+	for _, hash := range locator {
+		if !blockInfoDB.HasBlockRecord(hash) {
+			continue
+		}
+		br := blockInfoDB.GetBlockRecord(hash)
+		if br != nil && blockInfoDB.GetHashByHeight(br.Height) == hash {
+			return br.Height
+		}
+	}
+	return 1
+}
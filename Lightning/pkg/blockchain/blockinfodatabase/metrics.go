@@ -0,0 +1,190 @@
+package blockinfodatabase
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the buckets that read and
+// write latencies are counted in. Latencies above the last bound are
+// counted in one more bucket.
+var LatencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Histogram counts latencies in LatencyBuckets.
+// Counts[i] is how many latencies were at most LatencyBuckets[i],
+// and above the bound before it. The last count is of latencies
+// above every bound.
+// Count is how many latencies were counted, Total is their sum,
+// and Max is the largest.
+type Histogram struct {
+	Counts []uint64
+	Count  uint64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// newHistogram returns an empty Histogram.
+func newHistogram() Histogram {
+	return Histogram{Counts: make([]uint64, len(LatencyBuckets)+1)}
+}
+
+// observe counts latency d.
+func (h *Histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Total += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// copy returns a copy of the Histogram.
+func (h *Histogram) copy() Histogram {
+	c := *h
+	c.Counts = append([]uint64{}, h.Counts...)
+	return c
+}
+
+// Mean returns the mean latency, or 0 if none were counted.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Total / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket that the latency
+// at quantile q (between 0 and 1) falls in. Latencies above every
+// bound are reported as Max.
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+	seen := uint64(0)
+	for i, n := range h.Counts {
+		seen += n
+		if seen > rank && i < len(LatencyBuckets) {
+			return LatencyBuckets[i]
+		}
+	}
+	return h.Max
+}
+
+// Metrics describe the health of a BlockInfoDatabase.
+// Records is how many BlockRecords it holds.
+// DiskSize is roughly how many bytes its files take up on disk.
+// Reads are the latencies of reading BlockRecords from the db,
+// not counting those found in the cache.
+// Writes are the latencies of writes to the db.
+// CacheHits and CacheMisses count lookups of BlockRecords that
+// were and weren't found in the cache.
+type Metrics struct {
+	Records     int
+	DiskSize    int64
+	Reads       Histogram
+	Writes      Histogram
+	CacheHits   uint64
+	CacheMisses uint64
+}
+
+// dbStats collects the latencies and cache lookups behind Metrics.
+type dbStats struct {
+	mutex       sync.Mutex
+	reads       Histogram
+	writes      Histogram
+	cacheHits   uint64
+	cacheMisses uint64
+}
+
+// newDBStats returns empty dbStats.
+func newDBStats() *dbStats {
+	return &dbStats{reads: newHistogram(), writes: newHistogram()}
+}
+
+// read counts a read from the db that started at start.
+func (s *dbStats) read(start time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reads.observe(time.Since(start))
+}
+
+// write counts a write to the db that started at start.
+func (s *dbStats) write(start time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writes.observe(time.Since(start))
+}
+
+// lookup counts a lookup in the cache.
+func (s *dbStats) lookup(hit bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if hit {
+		s.cacheHits++
+	} else {
+		s.cacheMisses++
+	}
+}
+
+// Metrics returns the BlockInfoDatabase's Metrics. Counting the
+// BlockRecords reads every key, so it shouldn't be called often.
+func (blockInfoDB *BlockInfoDatabase) Metrics() *Metrics {
+	m := &Metrics{
+		Records:  blockInfoDB.countRecords(),
+		DiskSize: blockInfoDB.diskSize(),
+	}
+	blockInfoDB.stats.mutex.Lock()
+	defer blockInfoDB.stats.mutex.Unlock()
+	m.Reads = blockInfoDB.stats.reads.copy()
+	m.Writes = blockInfoDB.stats.writes.copy()
+	m.CacheHits = blockInfoDB.stats.cacheHits
+	m.CacheMisses = blockInfoDB.stats.cacheMisses
+	return m
+}
+
+// countRecords returns how many BlockRecords the db holds.
+func (blockInfoDB *BlockInfoDatabase) countRecords() int {
+	n := 0
+	iterator := blockInfoDB.db.NewIterator(nil, nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		if !isMetadataKey(string(iterator.Key())) {
+			n++
+		}
+	}
+	return n
+}
+
+// diskSize returns how many bytes the files in the db's directory
+// take up.
+func (blockInfoDB *BlockInfoDatabase) diskSize() int64 {
+	dir := blockInfoDB.path
+	if blockInfoDB.snapshotDir != "" {
+		dir = blockInfoDB.snapshotDir
+	}
+	size := int64(0)
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,74 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/utils"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"strings"
+)
+
+// orphanKeyPrefix starts the keys that mark BlockRecords for pruning.
+// Block hashes are hex, so they never start with it.
+const orphanKeyPrefix = "orphan:"
+
+// orphanKey returns the key that marks the BlockRecord for hash.
+func orphanKey(hash string) []byte {
+	return []byte(orphanKeyPrefix + hash)
+}
+
+// MarkOrphaned marks the BlockRecords of Blocks on a branch that lost a
+// reorg, so that they can be deleted later by DeleteBlockRecords.
+func (blockInfoDB *BlockInfoDatabase) MarkOrphaned(hashes []string) error {
+	batch := new(leveldb.Batch)
+	for _, hash := range hashes {
+		batch.Put(orphanKey(hash), []byte{})
+	}
+	if err := blockInfoDB.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("[MarkOrphaned] failed to mark %v block records: %v", len(hashes), err)
+	}
+	return nil
+}
+
+// UnmarkOrphaned removes the mark from the BlockRecord for hash, for
+// when its Block is back on the main chain.
+func (blockInfoDB *BlockInfoDatabase) UnmarkOrphaned(hash string) {
+	if err := blockInfoDB.db.Delete(orphanKey(hash), nil); err != nil {
+		utils.Debug.Printf("Unable to unmark block record for hash {%v}: %v", hash, err)
+	}
+}
+
+// GetOrphaned returns the hashes of every BlockRecord marked by
+// MarkOrphaned.
+func (blockInfoDB *BlockInfoDatabase) GetOrphaned() []string {
+	var hashes []string
+	iterator := blockInfoDB.db.NewIterator(util.BytesPrefix([]byte(orphanKeyPrefix)), nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		hashes = append(hashes, strings.TrimPrefix(string(iterator.Key()), orphanKeyPrefix))
+	}
+	if err := iterator.Error(); err != nil {
+		utils.Debug.Printf("Failed to iterate over orphaned block records: %v", err)
+	}
+	return hashes
+}
+
+// DeleteBlockRecords deletes the BlockRecords for hashes, along with
+// any marks on them and the Transactions they created, in a single
+// write. The Blocks' and UndoBlocks' bytes stay in their files, but
+// nothing refers to them anymore.
+func (blockInfoDB *BlockInfoDatabase) DeleteBlockRecords(hashes []string) error {
+	batch := new(leveldb.Batch)
+	for _, hash := range hashes {
+		batch.Delete([]byte(hash))
+		batch.Delete(orphanKey(hash))
+		batch.Delete(createdKey(hash))
+	}
+	if err := blockInfoDB.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("[DeleteBlockRecords] failed to delete %v block records: %v", len(hashes), err)
+	}
+	for _, hash := range hashes {
+		blockInfoDB.cache.remove(hash)
+	}
+	return nil
+}
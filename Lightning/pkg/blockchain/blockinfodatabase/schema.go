@@ -0,0 +1,179 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"encoding/binary"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"math/big"
+	"sort"
+)
+
+// schemaKey is the key the schema version of the BlockInfoDatabase is
+// stored under. Block hashes are hex, so they never collide with it.
+const schemaKey = "schema"
+
+// SchemaVersion is the schema version of the BlockInfoDatabases this
+// code writes. Older databases are upgraded by running the migrations
+// after their version, in order, when they are opened.
+//
+// Version 0 databases were written before the schema was versioned, and
+// their BlockRecords may have no ChainWork.
+// Version 1 databases have a ChainWork on every BlockRecord.
+// Version 2 databases have a Status on every BlockRecord.
+const SchemaVersion = 2
+
+// migration upgrades a BlockInfoDatabase from the previous schema
+// version to version.
+// description says what the migration does, for the logs.
+// migrate does the upgrade. It must be safe to run again if it was
+// interrupted, since the version is only written once it succeeds.
+type migration struct {
+	version     uint32
+	description string
+	migrate     func(blockInfoDB *BlockInfoDatabase) error
+}
+
+// migrations are every migration, ordered by version. The last one's
+// version must be SchemaVersion.
+var migrations = []*migration{
+	{version: 1, description: "back-fill chain work", migrate: backfillChainWork},
+	{version: 2, description: "back-fill validation status", migrate: backfillStatus},
+}
+
+// GetSchemaVersion returns the schema version of the BlockInfoDatabase.
+// Databases without a version were written before the schema was
+// versioned, and are version 0.
+func (blockInfoDB *BlockInfoDatabase) GetSchemaVersion() (uint32, error) {
+	data, err := blockInfoDB.db.Get([]byte(schemaKey), nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("[GetSchemaVersion] failed to read schema version: %v", err)
+	}
+	if len(data) != 4 {
+		return 0, fmt.Errorf("[GetSchemaVersion] schema version is %v bytes long", len(data))
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// setSchemaVersion stores the schema version of the BlockInfoDatabase.
+func (blockInfoDB *BlockInfoDatabase) setSchemaVersion(version uint32) error {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, version)
+	if err := blockInfoDB.db.Put([]byte(schemaKey), data, nil); err != nil {
+		return fmt.Errorf("[setSchemaVersion] failed to store schema version %v: %v", version, err)
+	}
+	return nil
+}
+
+// isEmpty returns whether the BlockInfoDatabase has nothing in it.
+func (blockInfoDB *BlockInfoDatabase) isEmpty() bool {
+	iterator := blockInfoDB.db.NewIterator(nil, nil)
+	defer iterator.Release()
+	return !iterator.First()
+}
+
+// Migrate upgrades the BlockInfoDatabase to SchemaVersion, running each
+// migration after its current version in order. The version is stored
+// after every migration, so an interrupted upgrade picks up where it
+// left off. It returns how many migrations were run.
+func (blockInfoDB *BlockInfoDatabase) Migrate() (int, error) {
+	current, err := blockInfoDB.GetSchemaVersion()
+	if err != nil {
+		return 0, err
+	}
+	if current > SchemaVersion {
+		return 0, fmt.Errorf("[Migrate] schema version %v is newer than %v", current, SchemaVersion)
+	}
+	n := 0
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		utils.Debug.Printf("[Migrate] upgrading block info database to version %v: %v", m.version, m.description)
+		if err = m.migrate(blockInfoDB); err != nil {
+			return n, fmt.Errorf("[Migrate] failed to upgrade to version %v (%v): %v", m.version, m.description, err)
+		}
+		if err = blockInfoDB.setSchemaVersion(m.version); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// initSchema is called when the BlockInfoDatabase is opened. A new
+// database is marked with the current SchemaVersion, and an old one
+// is migrated to it.
+func (blockInfoDB *BlockInfoDatabase) initSchema() {
+	if blockInfoDB.isEmpty() {
+		if err := blockInfoDB.setSchemaVersion(SchemaVersion); err != nil {
+			utils.Debug.Printf("%v", err)
+		}
+		return
+	}
+	if n, err := blockInfoDB.Migrate(); err != nil {
+		utils.Debug.Printf("%v", err)
+	} else if n > 0 {
+		utils.Debug.Printf("[blockinfodatabase.New] ran %v migrations to schema version %v", n, SchemaVersion)
+	}
+}
+
+// backfillChainWork gives every BlockRecord without a ChainWork one,
+// by adding the work of its Block to its parent's ChainWork. Records
+// are visited from lowest to highest, so parents are always done first.
+func backfillChainWork(blockInfoDB *BlockInfoDatabase) error {
+	records := blockInfoDB.GetAllBlockRecords()
+	hashes := make([]string, 0, len(records))
+	for hash := range records {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return records[hashes[i]].Height < records[hashes[j]].Height
+	})
+	var updatedHashes []string
+	var updated []*BlockRecord
+	for _, hash := range hashes {
+		br := records[hash]
+		if br.ChainWork != nil && br.ChainWork.Sign() > 0 {
+			continue
+		}
+		work := new(big.Int).Set(block.Work(br.Header))
+		if parent, ok := records[br.Header.PreviousHash]; ok && parent.ChainWork != nil {
+			work.Add(work, parent.ChainWork)
+		}
+		br.ChainWork = work
+		updatedHashes = append(updatedHashes, hash)
+		updated = append(updated, br)
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	return blockInfoDB.StoreBlockRecords(updatedHashes, updated)
+}
+
+// backfillStatus gives every BlockRecord without a Status one. Blocks
+// on the main chain were fully validated when they were connected, but
+// Blocks on other branches have only been checked for being well-formed.
+func backfillStatus(blockInfoDB *BlockInfoDatabase) error {
+	var updatedHashes []string
+	var updated []*BlockRecord
+	for hash, br := range blockInfoDB.GetAllBlockRecords() {
+		if br.Status != 0 {
+			continue
+		}
+		br.Status = StatusHeaderValid | StatusTreeValid
+		if blockInfoDB.GetHashByHeight(br.Height) == hash {
+			br.Status = StatusFullyValid
+		}
+		updatedHashes = append(updatedHashes, hash)
+		updated = append(updated, br)
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	return blockInfoDB.StoreBlockRecords(updatedHashes, updated)
+}
@@ -0,0 +1,185 @@
+package blockinfodatabase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is a bitfield of how far a Block has been validated, so
+// that partially validated Blocks can be told apart, Blocks are not
+// validated twice, and invalid branches stay invalid.
+type Status uint32
+
+const (
+	// StatusHeaderValid is set once the Block's Header is well-formed.
+	StatusHeaderValid Status = 1 << iota
+	// StatusTreeValid is set once every Transaction in the Block is
+	// well-formed.
+	StatusTreeValid
+	// StatusScriptsValid is set once the Block's Transactions have been
+	// checked against the Coins they spend.
+	StatusScriptsValid
+	// StatusFailed is set when the Block, or one of its ancestors,
+	// failed validation, or was invalidated by an operator. It is only
+	// cleared when an operator reconsiders the Block (see ClearFailed).
+	StatusFailed
+	// StatusPruned is set once the Block's block file or undo file has
+	// been deleted, so the Block can no longer be read or disconnected.
+	// It says nothing about whether the Block is valid.
+	StatusPruned
+	// StatusUndoPruned is set once only the Block's undo file has been
+	// deleted, so the Block can still be read but not disconnected.
+	StatusUndoPruned
+)
+
+// StatusFullyValid is the Status of a Block that passed every check.
+const StatusFullyValid = StatusHeaderValid | StatusTreeValid | StatusScriptsValid
+
+// Has returns whether every flag in flags is set.
+func (s Status) Has(flags Status) bool {
+	return s&flags == flags
+}
+
+// IsValid returns whether every flag in level is set, and the Block
+// hasn't failed validation.
+func (s Status) IsValid(level Status) bool {
+	return s.Has(level) && !s.Has(StatusFailed)
+}
+
+// String returns the names of the flags that are set.
+func (s Status) String() string {
+	var names []string
+	for _, f := range []struct {
+		flag Status
+		name string
+	}{
+		{StatusHeaderValid, "header"},
+		{StatusTreeValid, "tree"},
+		{StatusScriptsValid, "scripts"},
+		{StatusFailed, "failed"},
+		{StatusPruned, "pruned"},
+		{StatusUndoPruned, "undo-pruned"},
+	} {
+		if s.Has(f.flag) {
+			names = append(names, f.name)
+		}
+	}
+	if len(names) == 0 {
+		return "unvalidated"
+	}
+	return strings.Join(names, "|")
+}
+
+// RaiseStatus sets flags on the BlockRecord for hash, keeping the
+// flags it already has.
+func (blockInfoDB *BlockInfoDatabase) RaiseStatus(hash string, flags Status) error {
+	br := blockInfoDB.GetBlockRecord(hash)
+	if br == nil {
+		return fmt.Errorf("[RaiseStatus] no block record for hash {%v}", hash)
+	}
+	if br.Status.Has(flags) {
+		return nil
+	}
+	br.Status |= flags
+	return blockInfoDB.StoreBlockRecords([]string{hash}, []*BlockRecord{br})
+}
+
+// MarkFailed permanently marks the BlockRecord for hash as having
+// failed validation, along with every BlockRecord descending from it,
+// since no chain through an invalid Block can be valid. It returns the
+// hashes that were marked.
+func (blockInfoDB *BlockInfoDatabase) MarkFailed(hash string) ([]string, error) {
+	records := blockInfoDB.GetAllBlockRecords()
+	if _, ok := records[hash]; !ok {
+		return nil, fmt.Errorf("[MarkFailed] no block record for hash {%v}", hash)
+	}
+	children := make(map[string][]string)
+	for h, br := range records {
+		children[br.Header.PreviousHash] = append(children[br.Header.PreviousHash], h)
+	}
+	var hashes []string
+	var marked []*BlockRecord
+	for queue := []string{hash}; len(queue) > 0; queue = queue[1:] {
+		br := records[queue[0]]
+		if !br.Status.Has(StatusFailed) {
+			br.Status |= StatusFailed
+			hashes = append(hashes, queue[0])
+			marked = append(marked, br)
+		}
+		queue = append(queue, children[queue[0]]...)
+	}
+	if len(marked) == 0 {
+		return nil, nil
+	}
+	return hashes, blockInfoDB.StoreBlockRecords(hashes, marked)
+}
+
+// ClearFailed clears StatusFailed from the BlockRecord for hash, from
+// every BlockRecord descending from it, and from every one it descends
+// from, since the Block can only be valid if they are, undoing
+// MarkFailed. It returns the hashes that were cleared.
+func (blockInfoDB *BlockInfoDatabase) ClearFailed(hash string) ([]string, error) {
+	records := blockInfoDB.GetAllBlockRecords()
+	if _, ok := records[hash]; !ok {
+		return nil, fmt.Errorf("[ClearFailed] no block record for hash {%v}", hash)
+	}
+	children := make(map[string][]string)
+	for h, br := range records {
+		children[br.Header.PreviousHash] = append(children[br.Header.PreviousHash], h)
+	}
+	var affected []string
+	for h := records[hash].Header.PreviousHash; records[h] != nil; h = records[h].Header.PreviousHash {
+		affected = append(affected, h)
+	}
+	for queue := []string{hash}; len(queue) > 0; queue = queue[1:] {
+		affected = append(affected, queue[0])
+		queue = append(queue, children[queue[0]]...)
+	}
+	var hashes []string
+	var cleared []*BlockRecord
+	for _, h := range affected {
+		if br := records[h]; br.Status.Has(StatusFailed) {
+			br.Status &^= StatusFailed
+			hashes = append(hashes, h)
+			cleared = append(cleared, br)
+		}
+	}
+	if len(cleared) == 0 {
+		return nil, nil
+	}
+	return hashes, blockInfoDB.StoreBlockRecords(hashes, cleared)
+}
+
+// MarkPruned marks the BlockRecords for hashes as pruned, once the
+// files their Blocks were stored in have been deleted.
+func (blockInfoDB *BlockInfoDatabase) MarkPruned(hashes []string) error {
+	return blockInfoDB.markAll(hashes, StatusPruned)
+}
+
+// MarkUndoPruned marks the BlockRecords for hashes as undo-pruned, once
+// the undo files their UndoBlocks were stored in have been deleted.
+func (blockInfoDB *BlockInfoDatabase) MarkUndoPruned(hashes []string) error {
+	return blockInfoDB.markAll(hashes, StatusUndoPruned)
+}
+
+// markAll sets flag on the BlockRecords for hashes, in one batch.
+func (blockInfoDB *BlockInfoDatabase) markAll(hashes []string, flag Status) error {
+	var marked []string
+	var records []*BlockRecord
+	for _, hash := range hashes {
+		br := blockInfoDB.GetBlockRecord(hash)
+		if br == nil {
+			return fmt.Errorf("[markAll] no block record for hash {%v}", hash)
+		}
+		if br.Status.Has(flag) {
+			continue
+		}
+		br.Status |= flag
+		marked = append(marked, hash)
+		records = append(records, br)
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+	return blockInfoDB.StoreBlockRecords(marked, records)
+}
@@ -0,0 +1,89 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/protobuf/proto"
+)
+
+// txKeyPrefix starts the keys of the transaction index, which maps
+// Transaction hashes to TxLocations. Block hashes are hex, so they
+// never start with it.
+const txKeyPrefix = "tx:"
+
+// txKey returns the transaction index key for a Transaction hash.
+func txKey(txHash string) []byte {
+	return []byte(txKeyPrefix + txHash)
+}
+
+// TxLocation is where a Transaction is in the Blocks.
+// BlockHash is the hash of the Block the Transaction is in.
+// Index is the Transaction's index among the Block's Transactions.
+type TxLocation struct {
+	BlockHash string
+	Index     uint32
+}
+
+// EncodeTxLocation returns a pro.TxLocation given a TxLocation.
+func EncodeTxLocation(loc *TxLocation) *pro.TxLocation {
+	return &pro.TxLocation{BlockHash: loc.BlockHash, Index: loc.Index}
+}
+
+// DecodeTxLocation returns a TxLocation given a pro.TxLocation.
+func DecodeTxLocation(ploc *pro.TxLocation) *TxLocation {
+	return &TxLocation{BlockHash: ploc.GetBlockHash(), Index: ploc.GetIndex()}
+}
+
+// HasTxIndex returns whether the BlockInfoDatabase keeps a
+// transaction index.
+func (blockInfoDB *BlockInfoDatabase) HasTxIndex() bool {
+	return blockInfoDB.txIndex
+}
+
+// IndexTransactions records the location of every Transaction in the
+// Block with the given hash, replacing any location already recorded
+// for them. It does nothing if there is no transaction index.
+func (blockInfoDB *BlockInfoDatabase) IndexTransactions(blockHash string, txs []*block.Transaction) error {
+	if !blockInfoDB.txIndex || len(txs) == 0 {
+		return nil
+	}
+	batch := new(leveldb.Batch)
+	for i, tx := range txs {
+		bytes, err := proto.Marshal(EncodeTxLocation(&TxLocation{BlockHash: blockHash, Index: uint32(i)}))
+		if err != nil {
+			return fmt.Errorf("[IndexTransactions] failed to marshal location of {%v}: %v", tx.Hash(), err)
+		}
+		batch.Put(txKey(tx.Hash()), bytes)
+	}
+	if err := blockInfoDB.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("[IndexTransactions] failed to index block {%v}: %v", blockHash, err)
+	}
+	return nil
+}
+
+// GetTxLocation returns where the Transaction with the given hash was
+// last indexed, or nil if it hasn't been, or there is no transaction
+// index.
+func (blockInfoDB *BlockInfoDatabase) GetTxLocation(txHash string) *TxLocation {
+	if !blockInfoDB.txIndex {
+		return nil
+	}
+	data, err := blockInfoDB.db.Get(txKey(txHash), nil)
+	if err != nil {
+		return nil
+	}
+	ploc := &pro.TxLocation{}
+	if err = proto.Unmarshal(data, ploc); err != nil {
+		utils.Debug.Printf("Failed to unmarshal location of transaction {%v}: %v", txHash, err)
+		return nil
+	}
+	loc := DecodeTxLocation(ploc)
+	if err = block.ValidateHash(loc.BlockHash); err != nil {
+		utils.Debug.Printf("Rejected location of transaction {%v}: %v", txHash, err)
+		return nil
+	}
+	return loc
+}
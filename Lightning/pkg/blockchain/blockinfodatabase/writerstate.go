@@ -0,0 +1,67 @@
+package blockinfodatabase
+
+import (
+	"Coin/pkg/utils"
+	"encoding/binary"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// writerStateKey is the key the WriterState is stored under. Block
+// hashes are hex, so they are never equal to it.
+const writerStateKey = "writer"
+
+// WriterState records where the ChainWriter will write next, as of the
+// last BlockRecord stored. It is stored in the same batch as the
+// BlockRecord, so it never disagrees with the recorded Blocks.
+// BlockFileNumber and BlockOffset are the current block file and the
+// offset the next Block is written at.
+// UndoFileNumber and UndoOffset are the same for UndoBlocks.
+type WriterState struct {
+	BlockFileNumber uint32
+	BlockOffset     uint32
+	UndoFileNumber  uint32
+	UndoOffset      uint32
+}
+
+// encodeWriterState returns the bytes a WriterState is stored as.
+func encodeWriterState(state *WriterState) []byte {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint32(data[0:], state.BlockFileNumber)
+	binary.BigEndian.PutUint32(data[4:], state.BlockOffset)
+	binary.BigEndian.PutUint32(data[8:], state.UndoFileNumber)
+	binary.BigEndian.PutUint32(data[12:], state.UndoOffset)
+	return data
+}
+
+// decodeWriterState returns the WriterState stored as data.
+func decodeWriterState(data []byte) (*WriterState, error) {
+	if len(data) != 16 {
+		return nil, fmt.Errorf("[decodeWriterState] writer state is %v bytes long", len(data))
+	}
+	return &WriterState{
+		BlockFileNumber: binary.BigEndian.Uint32(data[0:]),
+		BlockOffset:     binary.BigEndian.Uint32(data[4:]),
+		UndoFileNumber:  binary.BigEndian.Uint32(data[8:]),
+		UndoOffset:      binary.BigEndian.Uint32(data[12:]),
+	}, nil
+}
+
+// GetWriterState returns the WriterState stored with the last
+// BlockRecord, or nil if none has been stored.
+func (blockInfoDB *BlockInfoDatabase) GetWriterState() *WriterState {
+	data, err := blockInfoDB.db.Get([]byte(writerStateKey), nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		utils.Debug.Printf("[GetWriterState] failed to read writer state: %v", err)
+		return nil
+	}
+	state, err := decodeWriterState(data)
+	if err != nil {
+		utils.Debug.Printf("Rejected writer state: %v", err)
+		return nil
+	}
+	return state
+}
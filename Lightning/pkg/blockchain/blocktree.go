@@ -0,0 +1,185 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/pro"
+	"math/big"
+	"sort"
+)
+
+// BlockTip is a known Block that no other known Block builds on.
+// Hash is the hash of the tip.
+// Height is the height of the tip.
+// CumulativeWork is the total work from the genesis Block to the tip.
+// Active is whether the tip is the last Block of the active chain.
+// BranchPoint is the hash of the Block where the tip's branch leaves
+// the active chain. For the active tip, it is the tip itself.
+type BlockTip struct {
+	Hash           string
+	Height         uint32
+	CumulativeWork *big.Int
+	Active         bool
+	BranchPoint    string
+}
+
+// BranchPoint is a known Block with more than one child.
+// Hash is the hash of the Block.
+// Height is the height of the Block.
+// Children are the hashes of the Block's children.
+type BranchPoint struct {
+	Hash     string
+	Height   uint32
+	Children []string
+}
+
+// BlockTree is a snapshot of every Block the BlockChain knows about,
+// summarized by its tips and branch points.
+// BestHash is the hash of the last Block of the active chain.
+type BlockTree struct {
+	BestHash     string
+	Tips         []*BlockTip
+	BranchPoints []*BranchPoint
+}
+
+// BlockWork returns the work of a Block with the given Header.
+// See block.Work.
+func BlockWork(header *block.Header) *big.Int {
+	return block.Work(header)
+}
+
+// CompareChainWork compares the chains ending at the Blocks of two
+// BlockRecords by their total work, so the heaviest chain can be chosen.
+// It returns -1 if a's chain has less work than b's, 0 if they have the
+// same work, and +1 if a's chain has more.
+func CompareChainWork(a, b *blockinfodatabase.BlockRecord) int {
+	return chainWorkOf(a).Cmp(chainWorkOf(b))
+}
+
+// chainWorkOf returns a BlockRecord's ChainWork, or zero if it has none.
+func chainWorkOf(br *blockinfodatabase.BlockRecord) *big.Int {
+	if br == nil || br.ChainWork == nil {
+		return big.NewInt(0)
+	}
+	return br.ChainWork
+}
+
+// GetBlockTree returns the BlockChain's current BlockTree. Tips are
+// ordered from highest to lowest, and branch points from lowest to highest.
+func (bc *BlockChain) GetBlockTree() *BlockTree {
+	records := bc.BlockInfoDB.GetAllBlockRecords()
+	// map every block to the blocks that build on it
+	children := make(map[string][]string)
+	for hash, br := range records {
+		if _, ok := records[br.Header.PreviousHash]; ok {
+			children[br.Header.PreviousHash] = append(children[br.Header.PreviousHash], hash)
+		}
+	}
+	// mark the blocks on the active chain
+	active := make(map[string]bool)
+	for hash := bc.LastHash; ; {
+		br, ok := records[hash]
+		if !ok || active[hash] {
+			break
+		}
+		active[hash] = true
+		hash = br.Header.PreviousHash
+	}
+	works := make(map[string]*big.Int)
+	tree := &BlockTree{BestHash: bc.LastHash}
+	for hash, br := range records {
+		if kids := children[hash]; len(kids) > 1 {
+			sort.Strings(kids)
+			tree.BranchPoints = append(tree.BranchPoints, &BranchPoint{
+				Hash:     hash,
+				Height:   br.Height,
+				Children: kids,
+			})
+		}
+		if len(children[hash]) > 0 {
+			continue
+		}
+		// walk back to the active chain to find where this branch leaves it
+		branchPoint := hash
+		for !active[branchPoint] {
+			prev, ok := records[branchPoint]
+			if !ok {
+				branchPoint = ""
+				break
+			}
+			branchPoint = prev.Header.PreviousHash
+		}
+		tree.Tips = append(tree.Tips, &BlockTip{
+			Hash:           hash,
+			Height:         br.Height,
+			CumulativeWork: cumulativeWork(hash, records, works),
+			Active:         hash == bc.LastHash,
+			BranchPoint:    branchPoint,
+		})
+	}
+	sort.Slice(tree.Tips, func(i, j int) bool {
+		if tree.Tips[i].Height != tree.Tips[j].Height {
+			return tree.Tips[i].Height > tree.Tips[j].Height
+		}
+		return tree.Tips[i].Hash < tree.Tips[j].Hash
+	})
+	sort.Slice(tree.BranchPoints, func(i, j int) bool {
+		if tree.BranchPoints[i].Height != tree.BranchPoints[j].Height {
+			return tree.BranchPoints[i].Height < tree.BranchPoints[j].Height
+		}
+		return tree.BranchPoints[i].Hash < tree.BranchPoints[j].Hash
+	})
+	return tree
+}
+
+// cumulativeWork returns the total work of the chain ending at hash,
+// memoizing the work of every Block it visits in works.
+func cumulativeWork(hash string, records map[string]*blockinfodatabase.BlockRecord, works map[string]*big.Int) *big.Int {
+	// walk back until we reach a block we already know the work of
+	var path []string
+	for {
+		if _, ok := works[hash]; ok {
+			break
+		}
+		br, ok := records[hash]
+		if !ok {
+			works[hash] = big.NewInt(0)
+			break
+		}
+		path = append(path, hash)
+		hash = br.Header.PreviousHash
+	}
+	// then add up the work on the way back to the start
+	for i := len(path) - 1; i >= 0; i-- {
+		works[path[i]] = new(big.Int).Add(works[hash], BlockWork(records[path[i]].Header))
+		hash = path[i]
+	}
+	return works[hash]
+}
+
+// EncodeBlockTree returns a pro.BlockTree given a BlockTree.
+func EncodeBlockTree(tree *BlockTree) *pro.BlockTree {
+	var tips []*pro.BlockTip
+	for _, tip := range tree.Tips {
+		tips = append(tips, &pro.BlockTip{
+			Hash:           tip.Hash,
+			Height:         tip.Height,
+			CumulativeWork: tip.CumulativeWork.String(),
+			Active:         tip.Active,
+			BranchPoint:    tip.BranchPoint,
+		})
+	}
+	var branchPoints []*pro.BranchPoint
+	for _, bp := range tree.BranchPoints {
+		branchPoints = append(branchPoints, &pro.BranchPoint{
+			Hash:     bp.Hash,
+			Height:   bp.Height,
+			Children: bp.Children,
+		})
+	}
+	return &pro.BlockTree{
+		BestHash:     tree.BestHash,
+		Tips:         tips,
+		BranchPoints: branchPoints,
+	}
+}
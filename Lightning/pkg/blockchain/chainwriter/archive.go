@@ -0,0 +1,161 @@
+package chainwriter
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// archiveVersion is the version of the archive format ExportChain
+// writes, which ImportChain checks before reading anything else.
+const archiveVersion uint32 = 1
+
+// maxArchiveRecordSize is the largest record ImportChain reads from an
+// archive, so that a corrupted length can't make it allocate an
+// unbounded buffer.
+const maxArchiveRecordSize = 32 << 20
+
+// archiveHeaderSize is the size of an archive's header record: its
+// version, the heights of its first and last Blocks, and how many
+// Blocks it holds, each as 4 big-endian bytes.
+const archiveHeaderSize = 16
+
+// ExportChain writes the Blocks from fromHeight to toHeight, inclusive,
+// to an archive at path, from which ImportChain can bootstrap another
+// node. records are the BlockRecords of the chain to export, which
+// must have exactly one Block at each height in the range; the
+// BlockChain passes those of its main chain. The archive is a header
+// record followed by the Blocks in height order, each in the same frame
+// (see frame.go) they are stored in, so every record is checked when it
+// is imported, and an archive from another network is rejected. The
+// archive is written to a temporary file, and only moved to path once
+// it is complete and synced. ExportChain returns how many Blocks it
+// exported.
+func (cw *ChainWriter) ExportChain(path string, fromHeight uint32, toHeight uint32, records []*blockinfodatabase.BlockRecord) (int, error) {
+	if fromHeight == 0 || toHeight < fromHeight {
+		return 0, fmt.Errorf("[ExportChain] invalid height range %v to %v", fromHeight, toHeight)
+	}
+	var inRange []*blockinfodatabase.BlockRecord
+	for _, br := range records {
+		if br.Height >= fromHeight && br.Height <= toHeight {
+			inRange = append(inRange, br)
+		}
+	}
+	sort.Slice(inRange, func(i, j int) bool { return inRange[i].Height < inRange[j].Height })
+	for i, br := range inRange {
+		if br.Height != fromHeight+uint32(i) {
+			return 0, fmt.Errorf("[ExportChain] expected one block at each height from %v to %v, got height %v", fromHeight, toHeight, br.Height)
+		}
+	}
+	if uint32(len(inRange)) != toHeight-fromHeight+1 {
+		return 0, fmt.Errorf("[ExportChain] missing blocks above height %v", fromHeight+uint32(len(inRange))-1)
+	}
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("[ExportChain] Unable to create {%v}: %v", tmp, err)
+	}
+	defer os.Remove(tmp)
+	w := bufio.NewWriter(file)
+	header := make([]byte, archiveHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], archiveVersion)
+	binary.BigEndian.PutUint32(header[4:8], fromHeight)
+	binary.BigEndian.PutUint32(header[8:12], toHeight)
+	binary.BigEndian.PutUint32(header[12:16], uint32(len(inRange)))
+	w.Write(frame(cw.Magic, header, CompressionNone))
+	for _, br := range inRange {
+		fi := &FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+		data, err2 := cw.readFromDisk(fi)
+		if err2 == nil {
+			_, err2 = unframe(cw.Magic, data)
+		}
+		if err2 != nil {
+			file.Close()
+			return 0, fmt.Errorf("[ExportChain] block at height %v can't be read: %v", br.Height, err2)
+		}
+		w.Write(data)
+	}
+	// a bufio.Writer keeps the first error it hit, so Flush reports
+	// any failed Write
+	if err = w.Flush(); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("[ExportChain] Failed to write {%v}: %v", tmp, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("[ExportChain] %v", err)
+	}
+	return len(inRange), nil
+}
+
+// ImportChain reads an archive written by ExportChain, calling fn with
+// each of its Blocks in height order. The ChainWriter only reads the
+// archive; fn is what stores the Blocks, which the BlockChain does by
+// handling each one as if it came from a peer, so imported Blocks are
+// validated like any other. Unlike IterateBlocks, ImportChain doesn't
+// skip bad records, since an archive comes from somewhere else: if a
+// record is corrupted, the archive is from another network or is
+// truncated, or fn returns an error, ImportChain stops and returns the
+// error. It returns how many Blocks it passed to fn.
+func (cw *ChainWriter) ImportChain(path string, fn func(*block.Block) error) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("[ImportChain] Unable to open {%v}: %v", path, err)
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	header, err := cw.readArchiveRecord(r)
+	if err != nil {
+		return 0, fmt.Errorf("[ImportChain] bad archive header: %v", err)
+	}
+	if len(header) != archiveHeaderSize {
+		return 0, fmt.Errorf("[ImportChain] archive header is %v bytes, expected %v", len(header), archiveHeaderSize)
+	}
+	if version := binary.BigEndian.Uint32(header[0:4]); version != archiveVersion {
+		return 0, fmt.Errorf("[ImportChain] unsupported archive version %v", version)
+	}
+	count := binary.BigEndian.Uint32(header[12:16])
+	for i := uint32(0); i < count; i++ {
+		data, err2 := cw.readArchiveRecord(r)
+		if err2 != nil {
+			return int(i), fmt.Errorf("[ImportChain] block %v of %v: %v", i+1, count, err2)
+		}
+		b, err2 := decodeBlock(data)
+		if err2 != nil {
+			return int(i), fmt.Errorf("[ImportChain] block %v of %v: %v", i+1, count, err2)
+		}
+		if err2 = fn(b); err2 != nil {
+			return int(i), err2
+		}
+	}
+	return int(count), nil
+}
+
+// readArchiveRecord reads the next frame from an archive, and returns
+// the record in it.
+func (cw *ChainWriter) readArchiveRecord(r io.Reader) ([]byte, error) {
+	prefix := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("archive ends early: %v", err)
+	}
+	length := binary.BigEndian.Uint32(prefix[4:8]) &^ compressedFlag
+	if length > maxArchiveRecordSize {
+		return nil, fmt.Errorf("record of %v bytes is too large", length)
+	}
+	buf := make([]byte, frameOverhead+int(length))
+	copy(buf, prefix)
+	if _, err := io.ReadFull(r, buf[frameHeaderSize:]); err != nil {
+		return nil, fmt.Errorf("archive ends early: %v", err)
+	}
+	return unframe(cw.Magic, buf)
+}
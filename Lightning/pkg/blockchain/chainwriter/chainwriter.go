@@ -5,25 +5,67 @@ import (
 	"Coin/pkg/blockchain/blockinfodatabase"
 	"Coin/pkg/pro"
 	"Coin/pkg/utils"
+	"fmt"
 	"google.golang.org/protobuf/proto"
 	"log"
 	"os"
-	"strconv"
+	"sync"
 )
 
+// storedBlocksSize is how many of the Blocks it stored a ChainWriter
+// remembers, so as not to write them again.
+const storedBlocksSize = 1024
+
 // ChainWriter handles all I/O for the BlockChain. It stores and retrieves
 // Blocks and UndoBlocks.
 // See config.go for more information on its fields.
 // Block files are of the format:
-// "DataDirectory/BlockFileName_CurrentBlockFileNumber.FileExtension"
+// "BlockDirectory/BlockFileName_CurrentBlockFileNumber.FileExtension"
 // Ex: "data/block_0.txt"
 // UndoBlock files are of the format:
-// "DataDirectory/UndoFileName_CurrentUndoFileNumber.FileExtension"
+// "UndoDirectory/UndoFileName_CurrentUndoFileNumber.FileExtension"
 // Ex: "data/undo_0.txt"
+// If FilesPerDirectory isn't 0, files are sharded into subdirectories
+// by file number (see filePath).
+// Each Block and UndoBlock is written in a frame (see frame.go), which
+// ReadBlock and ReadUndoBlock check before decoding it. FileInfos span
+// the whole frame. Records are compressed with Compression, and each
+// frame says whether its record is compressed, so records written with
+// and without compression can be read alike.
+// storedBlocks holds the BlockRecords of the last storedBlocksSize
+// Blocks this ChainWriter stored, so a Block stored again soon after,
+// e.g. arriving from several peers at once, is only written once. The
+// BlockChain doesn't store a Block it already has a BlockRecord for,
+// even after a restart (see BlockChain.HandleBlock).
+// files keeps up to MaxOpenFiles files open for reading, so that
+// reading Blocks, which may happen concurrently, doesn't open and
+// close a file each time.
+// unsynced is how many Blocks have been stored since the files were
+// last synced, and createdIn holds the directories files or shards
+// were created in since they were last synced.
+//
+// A ChainWriter is safe for concurrent use. mutex serializes everything
+// that writes or moves the current files and offsets (StoreBlock,
+// WriteBlock, WriteUndoBlock, ResumeAt and PruneBlockFiles), so each
+// record gets its own span of its file and the FileInfo returned for it
+// is exact, and a Block stored from two goroutines at once is only
+// written once. Reads don't take mutex, since a record is never changed
+// once written; they only fail if its file was pruned or rolled back.
+// The exported offsets shouldn't be read directly while other
+// goroutines write; State returns them consistently.
 type ChainWriter struct {
 	// data storage information
+	Magic         uint32
+	Compression   string
 	FileExtension string
 	DataDirectory string
+	SyncInterval  uint32
+	MinFreeSpace  uint64
+
+	// storage layout
+	BlockDirectory    string
+	UndoDirectory     string
+	FilesPerDirectory uint32
 
 	// block information
 	BlockFileName          string
@@ -36,16 +78,33 @@ type ChainWriter struct {
 	CurrentUndoFileNumber uint32
 	CurrentUndoOffset     uint32
 	MaxUndoFileSize       uint32
+
+	mutex        sync.Mutex
+	storedBlocks *storedBlocks
+	files        *filePool
+	unsynced     uint32
+	createdIn    map[string]bool
 }
 
-// New returns a ChainWriter given a Config.
+// New returns a ChainWriter given a Config. It resumes writing
+// after any Blocks and UndoBlocks already in its directories.
 func New(config *Config) *ChainWriter {
-	if err := os.Mkdir(config.DataDirectory, 0700); err != nil {
-		log.Fatalf("Could not create ChainWriter's data directory")
+	blockDirectory, undoDirectory := config.Directories()
+	for _, dir := range []string{config.DataDirectory, blockDirectory, undoDirectory} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			log.Fatalf("Could not create ChainWriter's data directory {%v}", dir)
+		}
 	}
-	return &ChainWriter{
+	cw := &ChainWriter{
+		Magic:                  config.Magic,
+		Compression:            config.Compression,
+		SyncInterval:           config.SyncInterval,
+		MinFreeSpace:           config.MinFreeSpace,
 		FileExtension:          config.FileExtension,
 		DataDirectory:          config.DataDirectory,
+		BlockDirectory:         blockDirectory,
+		UndoDirectory:          undoDirectory,
+		FilesPerDirectory:      config.FilesPerDirectory,
 		BlockFileName:          config.BlockFileName,
 		CurrentBlockFileNumber: 0,
 		CurrentBlockOffset:     0,
@@ -54,12 +113,92 @@ func New(config *Config) *ChainWriter {
 		CurrentUndoFileNumber:  0,
 		CurrentUndoOffset:      0,
 		MaxUndoFileSize:        config.MaxUndoFileSize,
+		storedBlocks:           newStoredBlocks(storedBlocksSize),
+		files:                  newFilePool(config.MaxOpenFiles),
+		createdIn:              make(map[string]bool),
 	}
+	cw.resume()
+	return cw
 }
 
 // StoreBlock stores a Block and its corresponding UndoBlock to Disk,
 // returning a BlockRecord that contains information for later retrieval.
-func (cw *ChainWriter) StoreBlock(bl *block.Block, undoBlock *UndoBlock, height uint32) *blockinfodatabase.BlockRecord {
+// Storing a Block that has already been stored does not write it again,
+// and returns the BlockRecord from the first time it was stored.
+// If either can't be written, it returns the error, which is a
+// *DiskFullError if the disk is full, and leaves the files as they
+// were.
+func (cw *ChainWriter) StoreBlock(bl *block.Block, undoBlock *UndoBlock, height uint32) (*blockinfodatabase.BlockRecord, error) {
+	hash := bl.Hash()
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	if br := cw.storedBlocks.get(hash); br != nil {
+		utils.Debug.Printf("[chainwriter.StoreBlock] block {%v} already stored", hash)
+		return br, nil
+	}
+	serializedBlock, serializedUndoBlock := serialize(bl, undoBlock)
+	// write block to disk
+	before := cw.state()
+	bfi, err := cw.writeBlock(serializedBlock)
+	if err != nil {
+		cw.resumeAt(before)
+		return nil, err
+	}
+	// create an empty file info, which we will update if the function is passed an undo block.
+	ufi := &FileInfo{}
+	if undoBlock.Amounts != nil {
+		if ufi, err = cw.writeUndoBlock(serializedUndoBlock); err != nil {
+			cw.resumeAt(before)
+			return nil, err
+		}
+	}
+	cw.wrote()
+
+	br := newBlockRecord(bl, height, bfi, ufi)
+	cw.storedBlocks.put(hash, br)
+	return br, nil
+}
+
+// StoreUndoBlock stores the UndoBlock of a Block that was stored before
+// it was connected, and so before its UndoBlock could be made, and
+// returns where it was written. An UndoBlock without Amounts isn't
+// written, and an empty FileInfo is returned for it, as StoreBlock does.
+func (cw *ChainWriter) StoreUndoBlock(undoBlock *UndoBlock) (*FileInfo, error) {
+	if undoBlock.Amounts == nil {
+		return &FileInfo{}, nil
+	}
+	serializedUndoBlock, err := proto.Marshal(EncodeUndoBlock(undoBlock))
+	if err != nil {
+		return nil, fmt.Errorf("[StoreUndoBlock] Failed to marshal undo block: %v", err)
+	}
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	before := cw.state()
+	fi, err := cw.writeUndoBlock(serializedUndoBlock)
+	if err != nil {
+		cw.resumeAt(before)
+		return nil, err
+	}
+	cw.wrote()
+	return fi, nil
+}
+
+// OpenFiles returns how many files the ChainWriter holds open for
+// reading.
+func (cw *ChainWriter) OpenFiles() int {
+	return cw.files.len()
+}
+
+// Close syncs the current files to disk, and closes the files the
+// ChainWriter holds open for reading. Later reads open them again.
+func (cw *ChainWriter) Close() error {
+	err := cw.Sync()
+	cw.files.closeAll()
+	return err
+}
+
+// serialize returns the bytes a Block and its UndoBlock are stored as.
+func serialize(bl *block.Block, undoBlock *UndoBlock) ([]byte, []byte) {
 	// serialize block
 	b := block.EncodeBlock(bl)
 	serializedBlock, err := proto.Marshal(b)
@@ -72,14 +211,12 @@ func (cw *ChainWriter) StoreBlock(bl *block.Block, undoBlock *UndoBlock, height
 	if err != nil {
 		utils.Debug.Printf("Failed to marshal undo block")
 	}
-	// write block to disk
-	bfi := cw.WriteBlock(serializedBlock)
-	// create an empty file info, which we will update if the function is passed an undo block.
-	ufi := &FileInfo{}
-	if undoBlock.Amounts != nil {
-		ufi = cw.WriteUndoBlock(serializedUndoBlock)
-	}
+	return serializedBlock, serializedUndoBlock
+}
 
+// newBlockRecord returns the BlockRecord of a Block at height that
+// was written to bfi, with its UndoBlock written to ufi.
+func newBlockRecord(bl *block.Block, height uint32, bfi *FileInfo, ufi *FileInfo) *blockinfodatabase.BlockRecord {
 	return &blockinfodatabase.BlockRecord{
 		Header:               bl.Header,
 		Height:               height,
@@ -93,8 +230,18 @@ func (cw *ChainWriter) StoreBlock(bl *block.Block, undoBlock *UndoBlock, height
 	}
 }
 
+// ForgetBlock drops the ChainWriter's reference to a stored Block, so
+// that storing the Block again writes it again. The Block's bytes stay
+// in its file, since files are only ever appended to.
+func (cw *ChainWriter) ForgetBlock(hash string) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	cw.storedBlocks.remove(hash)
+}
+
 // WriteBlock writes a serialized Block to Disk and returns
-// a FileInfo for storage information.
+// a FileInfo for storage information, or an error if there isn't
+// enough free space for it or it can't be written.
 //
 // At a high level, here's what this function is doing:
 // (1) checking to make sure we still have space for this
@@ -107,38 +254,70 @@ func (cw *ChainWriter) StoreBlock(bl *block.Block, undoBlock *UndoBlock, height
 // (5) updating our offset fo the next write.
 // (6) returning the FileInfo, which will later be used by the
 // BlockInfoDB when filling out a BlockRecord.
-func (cw *ChainWriter) WriteBlock(serializedBlock []byte) *FileInfo {
-	// need to know the length of the block
-	length := uint32(len(serializedBlock))
+func (cw *ChainWriter) WriteBlock(serializedBlock []byte) (*FileInfo, error) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	fi, err := cw.writeBlock(serializedBlock)
+	if err != nil {
+		return nil, err
+	}
+	cw.wrote()
+	return fi, nil
+}
+
+// writeBlock is WriteBlock, for when the caller holds the mutex.
+func (cw *ChainWriter) writeBlock(serializedBlock []byte) (*FileInfo, error) {
+	framed := frame(cw.Magic, serializedBlock, cw.Compression)
+	// need to know the length of the framed block
+	length := uint32(len(framed))
+	// refuse to fill up the disk
+	if err := cw.CheckSpace(uint64(length)); err != nil {
+		return nil, err
+	}
+	// find where the block goes
+	fi := cw.blockSpan(length)
+	// write serialized block to disk
+	if err := writeToDisk(fi.FileName, framed); err != nil {
+		return nil, fmt.Errorf("[WriteBlock] %v", err)
+	}
+	// update offset for next write
+	cw.CurrentBlockOffset = fi.EndOffset
+	// return the file info
+	return fi, nil
+}
+
+// blockSpan returns a FileInfo for where a framed Block of length
+// bytes is written next. It doesn't advance CurrentBlockOffset, which
+// is only done once the Block is written.
+func (cw *ChainWriter) blockSpan(length uint32) *FileInfo {
 	// if we don't have enough space for this block in the current file,
 	// we have to update our file by changing the current file number
 	// and resetting the start offset to zero (so we write at the beginning
 	// of the file again.
 	// (recall format from above: "data/block_0.txt")
 	if cw.CurrentBlockOffset+length >= cw.MaxBlockFileSize {
+		cw.finishFile(cw.filePath(cw.BlockFileName, cw.CurrentBlockFileNumber))
 		cw.CurrentBlockOffset = 0
 		cw.CurrentBlockFileNumber++
 	}
 	// create path to correct file, following format
-	// "DataDirectory/BlockFileName_CurrentBlockFileNumber.FileExtension"
+	// "BlockDirectory/BlockFileName_CurrentBlockFileNumber.FileExtension"
 	// Ex: "data/block_0.txt"
-	fileName := cw.DataDirectory + "/" + cw.BlockFileName + "_" + strconv.Itoa(int(cw.CurrentBlockFileNumber)) + cw.FileExtension
-	// write serialized block to disk
-	writeToDisk(fileName, serializedBlock)
+	fileName := cw.filePath(cw.BlockFileName, cw.CurrentBlockFileNumber)
+	if cw.CurrentBlockOffset == 0 {
+		cw.createFile(fileName)
+	}
 	// create a file info object with the starting and ending offsets of the serialized block
-	fi := &FileInfo{
+	return &FileInfo{
 		FileName:    fileName,
 		StartOffset: cw.CurrentBlockOffset,
 		EndOffset:   cw.CurrentBlockOffset + length,
 	}
-	// update offset for next write
-	cw.CurrentBlockOffset += length
-	// return the file info
-	return fi
 }
 
 // WriteUndoBlock writes a serialized UndoBlock to Disk and returns
-// a FileInfo for storage information.
+// a FileInfo for storage information, or an error if there isn't
+// enough free space for it or it can't be written.
 //
 // The only difference between this and WriteBlock() is the fields
 // we're updating when writing an UndoBlock.
@@ -156,53 +335,116 @@ func (cw *ChainWriter) WriteBlock(serializedBlock []byte) *FileInfo {
 // (5) updating our undo offset fo the next write.
 // (6) returning the FileInfo, which will later be used by the
 // BlockInfoDB when filling out a BlockRecord.
-func (cw *ChainWriter) WriteUndoBlock(serializedUndoBlock []byte) *FileInfo {
-	// need to know the length of the block
-	length := uint32(len(serializedUndoBlock))
+func (cw *ChainWriter) WriteUndoBlock(serializedUndoBlock []byte) (*FileInfo, error) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.writeUndoBlock(serializedUndoBlock)
+}
+
+// writeUndoBlock is WriteUndoBlock, for when the caller holds the
+// mutex.
+func (cw *ChainWriter) writeUndoBlock(serializedUndoBlock []byte) (*FileInfo, error) {
+	framed := frame(cw.Magic, serializedUndoBlock, cw.Compression)
+	// need to know the length of the framed undo block
+	length := uint32(len(framed))
+	// refuse to fill up the disk
+	if err := cw.CheckSpace(uint64(length)); err != nil {
+		return nil, err
+	}
+	// find where the undo block goes
+	fi := cw.undoSpan(length)
+	// write serialized undo block to disk
+	if err := writeToDisk(fi.FileName, framed); err != nil {
+		return nil, fmt.Errorf("[WriteUndoBlock] %v", err)
+	}
+	// update offset for next write
+	cw.CurrentUndoOffset = fi.EndOffset
+	// return the file info
+	return fi, nil
+}
+
+// undoSpan returns a FileInfo for where a framed UndoBlock of length
+// bytes is written next. It doesn't advance CurrentUndoOffset, which
+// is only done once the UndoBlock is written.
+func (cw *ChainWriter) undoSpan(length uint32) *FileInfo {
 	// if we don't have enough space for this undo block in the current undo file,
 	// we have to update our undo file by changing the current undo file number
 	// and resetting the start undo offset to zero (so we write at the beginning
 	// of the undo file again.
 	// (recall format from above: "data/undo_0.txt")
 	if cw.CurrentUndoOffset+length >= cw.MaxUndoFileSize {
+		cw.finishFile(cw.filePath(cw.UndoFileName, cw.CurrentUndoFileNumber))
 		cw.CurrentUndoOffset = 0
 		cw.CurrentUndoFileNumber++
 	}
 	// create path to correct file, following format
-	// "DataDirectory/BlockFileName_CurrentBlockFileNumber.FileExtension"
+	// "UndoDirectory/UndoFileName_CurrentUndoFileNumber.FileExtension"
 	// Ex: "data/undo_0.txt"
-	fileName := cw.DataDirectory + "/" + cw.UndoFileName + "_" + strconv.Itoa(int(cw.CurrentUndoFileNumber)) + cw.FileExtension
-	// write serialized undo block to disk
-	writeToDisk(fileName, serializedUndoBlock)
+	fileName := cw.filePath(cw.UndoFileName, cw.CurrentUndoFileNumber)
+	if cw.CurrentUndoOffset == 0 {
+		cw.createFile(fileName)
+	}
 	// create a file info object with the starting and ending undo offsets of the serialized
 	// undo block
-	fi := &FileInfo{
+	return &FileInfo{
 		FileName:    fileName,
 		StartOffset: cw.CurrentUndoOffset,
 		EndOffset:   cw.CurrentUndoOffset + length,
 	}
-	// update offset for next write
-	cw.CurrentUndoOffset += length
-	// return the file info
-	return fi
 }
 
-// ReadBlock returns a Block given a FileInfo.
-func (cw *ChainWriter) ReadBlock(fi *FileInfo) *block.Block {
-	bytes := readFromDisk(fi)
+// ReadBlock returns a Block given a FileInfo, or an error if the
+// FileInfo doesn't lie within its file, or the Block on Disk is
+// corrupted or malformed.
+func (cw *ChainWriter) ReadBlock(fi *FileInfo) (*block.Block, error) {
+	data, err := cw.readFromDisk(fi)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadBlock] %v", err)
+	}
+	bytes, err := unframe(cw.Magic, data)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadBlock] rejected block from file info {%v}: %v", fi, err)
+	}
+	b, err := decodeBlock(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadBlock] rejected block from file info {%v}: %v", fi, err)
+	}
+	return b, nil
+}
+
+// decodeBlock returns the Block a serialized record holds, or an error
+// if it doesn't unmarshal to a well-formed Block.
+func decodeBlock(bytes []byte) (*block.Block, error) {
 	pb := &pro.Block{}
 	if err := proto.Unmarshal(bytes, pb); err != nil {
-		utils.Debug.Printf("failed to unmarshal block from file info {%v}", fi)
+		return nil, fmt.Errorf("failed to unmarshal block: %v", err)
 	}
-	return block.DecodeBlock(pb)
+	b := block.DecodeBlock(pb)
+	if err := block.ValidateBlock(b); err != nil {
+		return nil, err
+	}
+	return b, nil
 }
 
-// ReadUndoBlock returns an UndoBlock given a FileInfo.
-func (cw *ChainWriter) ReadUndoBlock(fi *FileInfo) *UndoBlock {
-	bytes := readFromDisk(fi)
+// ReadUndoBlock returns an UndoBlock given a FileInfo, or an error if
+// the FileInfo doesn't lie within its file, or the UndoBlock on Disk
+// is corrupted or malformed.
+func (cw *ChainWriter) ReadUndoBlock(fi *FileInfo) (*UndoBlock, error) {
+	data, err := cw.readFromDisk(fi)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadUndoBlock] %v", err)
+	}
+	bytes, err := unframe(cw.Magic, data)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadUndoBlock] rejected undo block from file info {%v}: %v", fi, err)
+	}
 	pub := &pro.UndoBlock{}
-	if err := proto.Unmarshal(bytes, pub); err != nil {
-		utils.Debug.Printf("failed to unmarshal undo block from file info {%v}", fi)
+	if err = proto.Unmarshal(bytes, pub); err != nil {
+		return nil, fmt.Errorf("[ReadUndoBlock] failed to unmarshal undo block from file info {%v}: %v", fi, err)
+	}
+	ub := DecodeUndoBlock(pub)
+	if err = ValidateUndoBlock(ub); err != nil {
+		return nil, fmt.Errorf("[ReadUndoBlock] rejected undo block from file info {%v}: %v", fi, err)
 	}
-	return DecodeUndoBlock(pub)
+	return ub, nil
 }
@@ -1,23 +1,71 @@
 package chainwriter
 
 // Config is the ChainWriter's configuration options.
+// Magic is written at the start of every record, and records that
+// don't start with it are rejected.
+// Compression is the algorithm new records are compressed with, one of
+// CompressionNone or CompressionSnappy.
+// SyncInterval is how many Blocks are stored between syncing the block
+// and undo files to disk: 1 syncs after every Block, and 0 leaves it to
+// the operating system, so a power loss may lose the latest Blocks.
+// Whenever it isn't 0, a file is also synced before moving on from it.
+// MinFreeSpace is how many bytes must be left free on the disk after
+// writing a record. Records that would go below it are refused, so the
+// node stops storing Blocks before the disk actually fills up. 0 never
+// refuses a record.
+// MaxOpenFiles is how many block and undo files are kept open for
+// reading, or 0 to open a file for every read.
+// BlockDirectory and UndoDirectory are where the block and undo files
+// are kept, e.g. on different disks. Either defaults to DataDirectory
+// if it is empty.
+// FilesPerDirectory is how many files of each kind go in one
+// subdirectory of BlockDirectory or UndoDirectory, so no directory
+// ends up with tens of thousands of files, or 0 to keep them all
+// directly in it. It can't be changed once files have been written.
 type Config struct {
+	Magic            uint32
+	Compression      string
 	FileExtension    string
 	DataDirectory    string
 	BlockFileName    string
 	UndoFileName     string
 	MaxBlockFileSize uint32
 	MaxUndoFileSize  uint32
+	MaxOpenFiles     int
+	SyncInterval     uint32
+	MinFreeSpace     uint64
+
+	BlockDirectory    string
+	UndoDirectory     string
+	FilesPerDirectory uint32
 }
 
 // DefaultConfig returns the default Config for the ChainWriter.
 func DefaultConfig() *Config {
 	return &Config{
+		Magic:            DefaultMagic,
+		Compression:      CompressionNone,
 		FileExtension:    ".txt",
 		DataDirectory:    "data",
 		BlockFileName:    "block",
 		UndoFileName:     "undo",
 		MaxBlockFileSize: 1024,
 		MaxUndoFileSize:  1024,
+		MaxOpenFiles:     16,
+		SyncInterval:     0,
+		MinFreeSpace:     16 << 20,
+	}
+}
+
+// Directories returns the directories the block and undo files are
+// kept in.
+func (config *Config) Directories() (string, string) {
+	blockDirectory, undoDirectory := config.BlockDirectory, config.UndoDirectory
+	if blockDirectory == "" {
+		blockDirectory = config.DataDirectory
+	}
+	if undoDirectory == "" {
+		undoDirectory = config.DataDirectory
 	}
+	return blockDirectory, undoDirectory
 }
@@ -0,0 +1,45 @@
+package chainwriter
+
+import "fmt"
+
+// DiskFullError is returned when there isn't enough free space to
+// store a record without going below MinFreeSpace. Path is the
+// directory that is short of space, Free is how many bytes were free in it, and Needed is
+// how many bytes the record would take up.
+type DiskFullError struct {
+	Path   string
+	Free   uint64
+	Needed uint64
+}
+
+// Error returns a description of the shortage.
+func (e *DiskFullError) Error() string {
+	return fmt.Sprintf("disk full: {%v} has %v bytes free, but %v are needed", e.Path, e.Free, e.Needed)
+}
+
+// IsDiskFull returns whether err is a *DiskFullError.
+func IsDiskFull(err error) bool {
+	_, ok := err.(*DiskFullError)
+	return ok
+}
+
+// CheckSpace returns a *DiskFullError if storing n more bytes would
+// leave less than MinFreeSpace free in the BlockDirectory or the
+// UndoDirectory. Since either may get all n bytes, each is checked for
+// all of them. If the free space can't be found out, writes are
+// attempted anyway.
+func (cw *ChainWriter) CheckSpace(n uint64) error {
+	if cw.MinFreeSpace == 0 {
+		return nil
+	}
+	for _, dir := range []string{cw.BlockDirectory, cw.UndoDirectory} {
+		free, err := freeSpace(dir)
+		if err != nil {
+			continue
+		}
+		if free < cw.MinFreeSpace+n {
+			return &DiskFullError{Path: dir, Free: free, Needed: cw.MinFreeSpace + n}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package chainwriter
+
+import "syscall"
+
+// freeSpace returns how many bytes are free for unprivileged users in
+// the file system dir is on.
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,9 @@
+package chainwriter
+
+import "fmt"
+
+// freeSpace isn't supported on Windows, so writes there are never
+// refused for lack of space.
+func freeSpace(dir string) (uint64, error) {
+	return 0, fmt.Errorf("[freeSpace] not supported on windows")
+}
@@ -0,0 +1,145 @@
+package chainwriter
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// filePool keeps up to size block and undo files open for reading, so
+// that reading many Blocks doesn't open and close a file for each one.
+// Once it holds size files, opening another closes the least recently
+// used one that isn't being read.
+// order has the most recently used handle at the front,
+// handles maps a file name to its element in order.
+type filePool struct {
+	size int
+
+	mutex   sync.Mutex
+	order   *list.List
+	handles map[string]*list.Element
+}
+
+// fileHandle is an open file in a filePool.
+// refs is how many reads are using the file, and closed is whether it
+// should be closed once they are done.
+type fileHandle struct {
+	name   string
+	file   *os.File
+	refs   int
+	closed bool
+}
+
+// newFilePool returns a filePool that keeps up to size files open. If
+// size is 0, every read opens and closes its file.
+func newFilePool(size int) *filePool {
+	return &filePool{
+		size:    size,
+		order:   list.New(),
+		handles: make(map[string]*list.Element),
+	}
+}
+
+// acquire returns an open handle to the file named name, which must be
+// given back with release once the read is done.
+func (p *filePool) acquire(name string) (*fileHandle, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e, ok := p.handles[name]; ok {
+		p.order.MoveToFront(e)
+		h := e.Value.(*fileHandle)
+		h.refs++
+		return h, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	h := &fileHandle{name: name, file: file, refs: 1}
+	if p.size <= 0 {
+		h.closed = true
+		return h, nil
+	}
+	p.handles[name] = p.order.PushFront(h)
+	for p.order.Len() > p.size {
+		p.evict(p.order.Back())
+	}
+	return h, nil
+}
+
+// release gives back a handle returned by acquire, closing it if it
+// was evicted while being read.
+func (p *filePool) release(h *fileHandle) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	h.refs--
+	if h.closed && h.refs == 0 {
+		h.file.Close()
+	}
+}
+
+// evict drops a handle from the pool. It is closed now if no read is
+// using it, or else by the last read to release it.
+func (p *filePool) evict(e *list.Element) {
+	h := e.Value.(*fileHandle)
+	p.order.Remove(e)
+	delete(p.handles, h.name)
+	h.closed = true
+	if h.refs == 0 {
+		h.file.Close()
+	}
+}
+
+// forget drops the handle to the file named name, if there is one, so
+// the next read opens it again. It must be called before a file is
+// removed or truncated.
+func (p *filePool) forget(name string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if e, ok := p.handles[name]; ok {
+		p.evict(e)
+	}
+}
+
+// closeAll closes every file in the pool.
+func (p *filePool) closeAll() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for p.order.Len() > 0 {
+		p.evict(p.order.Back())
+	}
+}
+
+// len returns how many files the pool holds open.
+func (p *filePool) len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.order.Len()
+}
+
+// read returns the bytes a FileInfo spans, or an error if they don't
+// lie within the file. Reads of the same file may run concurrently,
+// since they share its handle without seeking.
+func (p *filePool) read(info *FileInfo) ([]byte, error) {
+	if info.EndOffset < info.StartOffset {
+		return nil, fmt.Errorf("[filePool.read] {%v} ends before it starts", info)
+	}
+	h, err := p.acquire(info.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("[filePool.read] Unable to open file {%v}: %v", info.FileName, err)
+	}
+	defer p.release(h)
+	stat, err := h.file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("[filePool.read] Unable to stat file {%v}: %v", info.FileName, err)
+	}
+	if int64(info.EndOffset) > stat.Size() {
+		return nil, fmt.Errorf("[filePool.read] {%v} ends past the end of the file, at %v", info, stat.Size())
+	}
+	buf := make([]byte, info.EndOffset-info.StartOffset)
+	if _, err = h.file.ReadAt(buf, int64(info.StartOffset)); err != nil {
+		return nil, fmt.Errorf("[filePool.read] Failed to read {%v} bytes from file {%v}: %v", len(buf), info.FileName, err)
+	}
+	return buf, nil
+}
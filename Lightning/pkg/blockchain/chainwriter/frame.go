@@ -0,0 +1,119 @@
+package chainwriter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/golang/snappy"
+	"hash/crc32"
+	"io/ioutil"
+)
+
+// DefaultMagic marks the start of every record in a block or undo
+// file, so that records from another network are rejected, and so that
+// a reader can find the next record after a corrupted one.
+const DefaultMagic uint32 = 0xC01DB10C
+
+// frameHeaderSize is the size of a frame's magic and length prefix,
+// and frameOverhead is that plus the size of its checksum.
+const (
+	frameHeaderSize = 8
+	frameOverhead   = frameHeaderSize + 4
+)
+
+// compressedFlag is set in a frame's length when its record is
+// compressed. Records are far smaller than 2GB, so the top bit of the
+// length is never needed for the length itself, and frames written
+// before compression existed never have it set.
+const compressedFlag uint32 = 1 << 31
+
+// Compression algorithms a ChainWriter can store records with.
+// CompressionNone stores records as they are.
+// CompressionSnappy compresses records with snappy.
+const (
+	CompressionNone   = ""
+	CompressionSnappy = "snappy"
+)
+
+// frame wraps a serialized record for writing to disk:
+// (1) 4 bytes of magic
+// (2) the length of the record, as 4 big-endian bytes, with
+// compressedFlag set if the record is compressed
+// (3) the record
+// (4) the CRC32 checksum of the record, as 4 big-endian bytes
+// If compression isn't CompressionNone, the record is compressed,
+// unless that wouldn't make it any smaller.
+func frame(magic uint32, data []byte, compression string) []byte {
+	length := uint32(len(data))
+	if compression == CompressionSnappy {
+		if compressed := snappy.Encode(nil, data); len(compressed) < len(data) {
+			data = compressed
+			length = uint32(len(data)) | compressedFlag
+		}
+	}
+	buf := make([]byte, len(data)+frameOverhead)
+	binary.BigEndian.PutUint32(buf[0:4], magic)
+	binary.BigEndian.PutUint32(buf[4:8], length)
+	copy(buf[frameHeaderSize:], data)
+	binary.BigEndian.PutUint32(buf[frameHeaderSize+len(data):], crc32.ChecksumIEEE(data))
+	return buf
+}
+
+// unframe returns the record in a frame, decompressed if it was
+// compressed, or an error if the frame's magic, length, or checksum
+// is wrong.
+func unframe(magic uint32, buf []byte) ([]byte, error) {
+	if len(buf) < frameOverhead {
+		return nil, fmt.Errorf("[unframe] frame is only %v bytes long", len(buf))
+	}
+	if m := binary.BigEndian.Uint32(buf[0:4]); m != magic {
+		return nil, fmt.Errorf("[unframe] bad magic %x", m)
+	}
+	length := binary.BigEndian.Uint32(buf[4:8])
+	compressed := length&compressedFlag != 0
+	length &^= compressedFlag
+	if uint32(len(buf)-frameOverhead) != length {
+		return nil, fmt.Errorf("[unframe] frame holds %v bytes, but says it holds %v", len(buf)-frameOverhead, length)
+	}
+	data := buf[frameHeaderSize : frameHeaderSize+length]
+	if sum := binary.BigEndian.Uint32(buf[frameHeaderSize+length:]); sum != crc32.ChecksumIEEE(data) {
+		return nil, fmt.Errorf("[unframe] checksum mismatch")
+	}
+	if compressed {
+		decoded, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("[unframe] failed to decompress record: %v", err)
+		}
+		return decoded, nil
+	}
+	return data, nil
+}
+
+// ScanFile returns the FileInfos of the intact records in a block or
+// undo file, in the order they were written. When a record is
+// corrupted, ScanFile looks for the next magic after it, so that one
+// bad record doesn't hide the ones after it.
+func (cw *ChainWriter) ScanFile(fileName string) ([]*FileInfo, error) {
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("[ScanFile] %v", err)
+	}
+	var infos []*FileInfo
+	for offset := 0; offset+frameOverhead <= len(buf); {
+		if binary.BigEndian.Uint32(buf[offset:offset+4]) != cw.Magic {
+			offset++
+			continue
+		}
+		end := offset + frameOverhead + int(binary.BigEndian.Uint32(buf[offset+4:offset+8])&^compressedFlag)
+		if end > len(buf) || end < offset {
+			offset++
+			continue
+		}
+		if _, err = unframe(cw.Magic, buf[offset:end]); err != nil {
+			offset++
+			continue
+		}
+		infos = append(infos, &FileInfo{FileName: fileName, StartOffset: uint32(offset), EndOffset: uint32(end)})
+		offset = end
+	}
+	return infos, nil
+}
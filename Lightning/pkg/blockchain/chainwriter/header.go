@@ -0,0 +1,85 @@
+package chainwriter
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"encoding/binary"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// headerPrefixSize is how many bytes of a record ReadHeader reads at
+// first, which is enough for the Header of almost any Block.
+const headerPrefixSize = 256
+
+// ReadHeader returns the Header of the Block a FileInfo points to,
+// reading only as much of the record as the Header takes up rather than
+// decoding every Transaction. A serialized Block starts with its
+// Header, so that is usually a single small read. The record's checksum
+// covers all of it, so it isn't checked; ReadBlock should be used when
+// the whole Block must be verified. Compressed records can only be
+// decompressed whole, so for them ReadHeader reads the whole Block.
+func (cw *ChainWriter) ReadHeader(fi *FileInfo) (*block.Header, error) {
+	if fi.EndOffset < fi.StartOffset || fi.EndOffset-fi.StartOffset < frameOverhead {
+		return nil, fmt.Errorf("[ReadHeader] {%v} is too short to hold a record", fi)
+	}
+	// the record, without the checksum after it
+	end := fi.EndOffset - 4
+	prefix, err := cw.readFromDisk(&FileInfo{
+		FileName:    fi.FileName,
+		StartOffset: fi.StartOffset,
+		EndOffset:   minOffset(end, fi.StartOffset+frameHeaderSize+headerPrefixSize),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadHeader] %v", err)
+	}
+	if magic := binary.BigEndian.Uint32(prefix[0:4]); magic != cw.Magic {
+		return nil, fmt.Errorf("[ReadHeader] bad magic %x, expected %x", magic, cw.Magic)
+	}
+	if binary.BigEndian.Uint32(prefix[4:8])&compressedFlag != 0 {
+		b, err2 := cw.ReadBlock(fi)
+		if err2 != nil {
+			return nil, fmt.Errorf("[ReadHeader] %v", err2)
+		}
+		return b.Header, nil
+	}
+	// the Header is field 1 of a pro.Block: a tag, a length, and then
+	// the serialized pro.Header
+	data := prefix[frameHeaderSize:]
+	num, typ, tagLen := protowire.ConsumeTag(data)
+	if tagLen < 0 || num != 1 || typ != protowire.BytesType {
+		return nil, fmt.Errorf("[ReadHeader] record in {%v} doesn't start with a header", fi)
+	}
+	length, lengthLen := protowire.ConsumeVarint(data[tagLen:])
+	if lengthLen < 0 {
+		return nil, fmt.Errorf("[ReadHeader] record in {%v} has a malformed header length", fi)
+	}
+	start := fi.StartOffset + frameHeaderSize + uint32(tagLen+lengthLen)
+	if length > uint64(end-start) {
+		return nil, fmt.Errorf("[ReadHeader] header in {%v} runs past the end of its record", fi)
+	}
+	var headerBytes []byte
+	if headerEnd := uint32(frameHeaderSize+tagLen+lengthLen) + uint32(length); headerEnd <= uint32(len(prefix)) {
+		headerBytes = prefix[frameHeaderSize+tagLen+lengthLen : headerEnd]
+	} else if headerBytes, err = cw.readFromDisk(&FileInfo{
+		FileName:    fi.FileName,
+		StartOffset: start,
+		EndOffset:   start + uint32(length),
+	}); err != nil {
+		return nil, fmt.Errorf("[ReadHeader] %v", err)
+	}
+	ph := &pro.Header{}
+	if err = proto.Unmarshal(headerBytes, ph); err != nil {
+		return nil, fmt.Errorf("[ReadHeader] failed to unmarshal header from file info {%v}: %v", fi, err)
+	}
+	return block.DecodeHeader(ph), nil
+}
+
+// minOffset returns the smaller of two offsets.
+func minOffset(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,40 @@
+package chainwriter
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"fmt"
+	"sort"
+)
+
+// IterateBlocks calls fn with every intact Block in the BlockDirectory
+// and its FileInfo, in the order they were written: file by file, and
+// within a file by offset. Records that are corrupted or don't decode
+// to a valid Block are skipped, so replaying the chain, e.g. to reindex
+// it, gets every Block that can still be read. If fn returns an error,
+// IterateBlocks stops and returns it.
+func (cw *ChainWriter) IterateBlocks(fn func(*block.Block, *FileInfo) error) error {
+	files := cw.fileNumbers(cw.BlockFileName)
+	var numbers []uint32
+	for n := range files {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	for _, n := range numbers {
+		infos, err := cw.ScanFile(files[n])
+		if err != nil {
+			return fmt.Errorf("[IterateBlocks] %v", err)
+		}
+		for _, fi := range infos {
+			b, err2 := cw.ReadBlock(fi)
+			if err2 != nil {
+				utils.Debug.Printf("[IterateBlocks] skipping unreadable block: %v", err2)
+				continue
+			}
+			if err = fn(b, fi); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
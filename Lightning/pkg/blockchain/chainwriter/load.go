@@ -0,0 +1,104 @@
+package chainwriter
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadBlocks reads the Blocks at path, which may be kept outside the
+// ChainWriter's directories, calling fn with each of them. path may be:
+// (1) an archive written by ExportChain, read as ImportChain reads it
+// (2) a block file from another node's BlockDirectory, whose intact
+// Blocks are read in the order they were written, skipping corrupted
+// records as IterateBlocks does
+// (3) another node's BlockDirectory, whose block files are read in the
+// order of their numbers, including those in shards
+// Block files must be named the way this ChainWriter names them, and
+// every record must be from the same network. Blocks in a node's files
+// aren't in height order, since side branches and orphans are stored
+// as they arrive, so fn must cope with Blocks whose parents come later.
+// If fn returns an error, LoadBlocks stops and returns it. It returns
+// how many Blocks it passed to fn.
+func (cw *ChainWriter) LoadBlocks(path string, fn func(*block.Block) error) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("[LoadBlocks] %v", err)
+	}
+	if !info.IsDir() {
+		if cw.isArchive(path) {
+			return cw.ImportChain(path, fn)
+		}
+		return cw.loadBlockFile(path, fn)
+	}
+	loaded := 0
+	for _, file := range cw.blockFiles(path) {
+		n, err := cw.loadBlockFile(file, fn)
+		loaded += n
+		if err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// isArchive returns whether the file at path starts with the header
+// record of an archive written by ExportChain. A Block's record is
+// never as small as an archive header, so a block file doesn't.
+func (cw *ChainWriter) isArchive(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header, err := cw.readArchiveRecord(bufio.NewReader(file))
+	return err == nil && len(header) == archiveHeaderSize && binary.BigEndian.Uint32(header[0:4]) == archiveVersion
+}
+
+// loadBlockFile calls fn with every intact Block in the block file at
+// path, in the order they were written.
+func (cw *ChainWriter) loadBlockFile(path string, fn func(*block.Block) error) (int, error) {
+	infos, err := cw.ScanFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("[LoadBlocks] %v", err)
+	}
+	loaded := 0
+	for _, fi := range infos {
+		b, err2 := cw.ReadBlock(fi)
+		if err2 != nil {
+			utils.Debug.Printf("[LoadBlocks] skipping unreadable block: %v", err2)
+			continue
+		}
+		if err = fn(b); err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// blockFiles returns the paths of the block files in dir, and in its
+// shards, sorted by their numbers.
+func (cw *ChainWriter) blockFiles(dir string) []string {
+	name := cw.BlockFileName + "_*" + cw.FileExtension
+	flat, _ := filepath.Glob(filepath.Join(dir, name))
+	sharded, _ := filepath.Glob(filepath.Join(dir, "*", name))
+	numbers := make(map[string]uint64)
+	var paths []string
+	for _, path := range append(flat, sharded...) {
+		base := strings.TrimSuffix(filepath.Base(path), cw.FileExtension)
+		if n, err := strconv.ParseUint(strings.TrimPrefix(base, cw.BlockFileName+"_"), 10, 32); err == nil {
+			numbers[path] = n
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return numbers[paths[i]] < numbers[paths[j]] })
+	return paths
+}
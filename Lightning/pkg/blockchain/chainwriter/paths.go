@@ -0,0 +1,48 @@
+package chainwriter
+
+import (
+	"Coin/pkg/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// directory returns the directory the files named name are kept in,
+// which is the UndoDirectory for undo files and the BlockDirectory for
+// block files.
+func (cw *ChainWriter) directory(name string) string {
+	if name == cw.UndoFileName {
+		return cw.UndoDirectory
+	}
+	return cw.BlockDirectory
+}
+
+// filePath returns the path of file number of the files named name:
+// "Directory/Name_Number.FileExtension", or if FilesPerDirectory isn't
+// 0, "Directory/Shard/Name_Number.FileExtension", where Shard is
+// Number / FilesPerDirectory.
+// Ex: "data/block_0.txt", or "data/3/block_3012.txt" with 1000 files
+// per directory
+func (cw *ChainWriter) filePath(name string, number uint32) string {
+	dir := cw.directory(name)
+	if cw.FilesPerDirectory > 0 {
+		dir += "/" + strconv.Itoa(int(number/cw.FilesPerDirectory))
+	}
+	return dir + "/" + name + "_" + strconv.Itoa(int(number)) + cw.FileExtension
+}
+
+// createFile is called when the file named fileName is about to be
+// written for the first time. It creates the file's shard if it
+// doesn't exist yet, and notes the directories that have new entries,
+// so sync flushes them too.
+func (cw *ChainWriter) createFile(fileName string) {
+	dir := filepath.Dir(fileName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err = os.MkdirAll(dir, 0700); err != nil {
+			// the write will fail, and report it
+			utils.Debug.Printf("[chainwriter.createFile] Unable to create {%v}: %v", dir, err)
+		}
+		cw.createdIn[filepath.Dir(dir)] = true
+	}
+	cw.createdIn[dir] = true
+}
@@ -0,0 +1,219 @@
+package chainwriter
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"fmt"
+)
+
+// BlockWrite is a Block to be stored, along with its UndoBlock and
+// its height.
+type BlockWrite struct {
+	Block     *block.Block
+	UndoBlock *UndoBlock
+	Height    uint32
+}
+
+// pendingWrite is a BlockWrite in a batch that hasn't been written
+// yet, with its Block and UndoBlock framed.
+// index is its index in the batch. framedUndoBlock is nil if the
+// Block has no UndoBlock to store. bfi and ufi are where the Block and
+// UndoBlock are written.
+type pendingWrite struct {
+	index           int
+	write           *BlockWrite
+	framedBlock     []byte
+	framedUndoBlock []byte
+	bfi             *FileInfo
+	ufi             *FileInfo
+}
+
+// StoreBlocks stores a batch of Blocks and their UndoBlocks, returning
+// their BlockRecords in the same order. Instead of a write per record,
+// the records bound for each file are appended to it in one write, and
+// then the files are synced, so the Blocks are durable once StoreBlocks
+// returns. Blocks that were already stored aren't written again. If
+// anything can't be written, it returns the error, which is a
+// *DiskFullError if the disk is full, and leaves the files as they
+// were.
+func (cw *ChainWriter) StoreBlocks(writes []*BlockWrite) ([]*blockinfodatabase.BlockRecord, error) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	records := make([]*blockinfodatabase.BlockRecord, len(writes))
+	var pending []*pendingWrite
+	inBatch := make(map[string]bool)
+	total := uint64(0)
+	for i, w := range writes {
+		hash := w.Block.Hash()
+		if br := cw.storedBlocks.get(hash); br != nil {
+			records[i] = br
+			continue
+		}
+		if inBatch[hash] {
+			continue
+		}
+		inBatch[hash] = true
+		serializedBlock, serializedUndoBlock := serialize(w.Block, w.UndoBlock)
+		p := &pendingWrite{index: i, write: w, framedBlock: frame(cw.Magic, serializedBlock, cw.Compression)}
+		if w.UndoBlock.Amounts != nil {
+			p.framedUndoBlock = frame(cw.Magic, serializedUndoBlock, cw.Compression)
+		}
+		total += uint64(len(p.framedBlock) + len(p.framedUndoBlock))
+		pending = append(pending, p)
+	}
+	if len(pending) == 0 {
+		return fillDuplicates(writes, records), nil
+	}
+	if err := cw.CheckSpace(total); err != nil {
+		return nil, err
+	}
+
+	// lay the records out in the files, collecting what each file gets
+	before := cw.state()
+	var files []string
+	buffers := make(map[string][]byte)
+	add := func(fi *FileInfo, framed []byte) {
+		if _, ok := buffers[fi.FileName]; !ok {
+			files = append(files, fi.FileName)
+		}
+		buffers[fi.FileName] = append(buffers[fi.FileName], framed...)
+	}
+	for _, p := range pending {
+		p.bfi = cw.blockSpan(uint32(len(p.framedBlock)))
+		cw.CurrentBlockOffset = p.bfi.EndOffset
+		add(p.bfi, p.framedBlock)
+		p.ufi = &FileInfo{}
+		if p.framedUndoBlock != nil {
+			p.ufi = cw.undoSpan(uint32(len(p.framedUndoBlock)))
+			cw.CurrentUndoOffset = p.ufi.EndOffset
+			add(p.ufi, p.framedUndoBlock)
+		}
+	}
+	for _, fileName := range files {
+		if err := writeToDisk(fileName, buffers[fileName]); err != nil {
+			cw.resumeAt(before)
+			return nil, fmt.Errorf("[StoreBlocks] %v", err)
+		}
+	}
+	if err := cw.syncFiles(files); err != nil {
+		cw.resumeAt(before)
+		return nil, fmt.Errorf("[StoreBlocks] %v", err)
+	}
+
+	for _, p := range pending {
+		br := newBlockRecord(p.write.Block, p.write.Height, p.bfi, p.ufi)
+		cw.storedBlocks.put(p.write.Block.Hash(), br)
+		records[p.index] = br
+	}
+	return fillDuplicates(writes, records), nil
+}
+
+// fillDuplicates fills in the BlockRecords of Blocks that appeared
+// more than once in a batch from the first time they appeared.
+func fillDuplicates(writes []*BlockWrite, records []*blockinfodatabase.BlockRecord) []*blockinfodatabase.BlockRecord {
+	first := make(map[string]*blockinfodatabase.BlockRecord)
+	for i, br := range records {
+		if br != nil {
+			first[writes[i].Block.Hash()] = br
+		}
+	}
+	for i, br := range records {
+		if br == nil {
+			records[i] = first[writes[i].Block.Hash()]
+		}
+	}
+	return records
+}
+
+// Pipeline stores Blocks in the background, for initial block download,
+// where storing them one at a time is the bottleneck. Blocks submitted
+// while a batch is being written are stored together in the next batch,
+// of at most batchSize Blocks, with StoreBlocks.
+// requests are the submitted Blocks that haven't been stored yet, and
+// stopped is closed once every submitted Block has been stored.
+type Pipeline struct {
+	cw        *ChainWriter
+	batchSize int
+	requests  chan *pipelineRequest
+	stopped   chan struct{}
+}
+
+// pipelineRequest is a Block submitted to a Pipeline, along with the
+// callback to call once it is stored.
+type pipelineRequest struct {
+	write    *BlockWrite
+	callback func(*blockinfodatabase.BlockRecord, error)
+}
+
+// NewPipeline returns a Pipeline that stores Blocks with cw in batches
+// of up to batchSize Blocks.
+func NewPipeline(cw *ChainWriter, batchSize int) *Pipeline {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	p := &Pipeline{
+		cw:        cw,
+		batchSize: batchSize,
+		requests:  make(chan *pipelineRequest, batchSize),
+		stopped:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Submit queues a Block to be stored. callback, if it isn't nil, is
+// called with the Block's BlockRecord once the Block is durable, or
+// with the error if it couldn't be stored. Callbacks are called one at
+// a time, in the order the Blocks were submitted. Submit must not be
+// called after Close.
+func (p *Pipeline) Submit(write *BlockWrite, callback func(*blockinfodatabase.BlockRecord, error)) {
+	p.requests <- &pipelineRequest{write: write, callback: callback}
+}
+
+// Close waits for every submitted Block to be stored and its callback
+// to return, and then stops the Pipeline.
+func (p *Pipeline) Close() {
+	close(p.requests)
+	<-p.stopped
+}
+
+// run stores the submitted Blocks until the Pipeline is closed, taking
+// as many as have been submitted, up to batchSize, for each batch.
+func (p *Pipeline) run() {
+	defer close(p.stopped)
+	for req := range p.requests {
+		batch := []*pipelineRequest{req}
+	collect:
+		for len(batch) < p.batchSize {
+			select {
+			case next, ok := <-p.requests:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, next)
+			default:
+				break collect
+			}
+		}
+		p.store(batch)
+	}
+}
+
+// store stores a batch of submitted Blocks, and calls their callbacks.
+func (p *Pipeline) store(batch []*pipelineRequest) {
+	writes := make([]*BlockWrite, len(batch))
+	for i, req := range batch {
+		writes[i] = req.write
+	}
+	records, err := p.cw.StoreBlocks(writes)
+	for i, req := range batch {
+		if req.callback == nil {
+			continue
+		}
+		if err != nil {
+			req.callback(nil, err)
+		} else {
+			req.callback(records[i], nil)
+		}
+	}
+}
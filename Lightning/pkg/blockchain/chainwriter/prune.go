@@ -0,0 +1,181 @@
+package chainwriter
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+	"os"
+	"sort"
+)
+
+// prunableFile is a block or undo file that may be pruned.
+// maxHeight is the height of the highest Block stored in it.
+// hashes are the hashes of the Blocks stored in it.
+type prunableFile struct {
+	maxHeight uint32
+	hashes    []string
+}
+
+// PruneBlockFiles deletes the block and undo files that only hold
+// Blocks below belowHeight, given the BlockRecords of every Block the
+// ChainWriter has stored, and returns the hashes of the Blocks whose
+// files were deleted. The files currently being written to are never
+// deleted. The returned Blocks can no longer be read, so their
+// BlockRecords should be marked pruned.
+func (cw *ChainWriter) PruneBlockFiles(belowHeight uint32, records map[string]*blockinfodatabase.BlockRecord) []string {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	blockFiles := cw.prunableFiles(records, cw.BlockFileName, cw.CurrentBlockFileNumber,
+		func(br *blockinfodatabase.BlockRecord) string { return br.BlockFile })
+	undoFiles := cw.prunableFiles(records, cw.UndoFileName, cw.CurrentUndoFileNumber,
+		func(br *blockinfodatabase.BlockRecord) string { return br.UndoFile })
+	pruned := make(map[string]bool)
+	for _, files := range []map[string]*prunableFile{blockFiles, undoFiles} {
+		cw.removeFiles(files, belowHeight, records, blockinfodatabase.StatusPruned, pruned)
+	}
+	return hashList(pruned)
+}
+
+// PruneUndoFiles deletes the undo files that only hold the UndoBlocks
+// of Blocks below belowHeight, given the BlockRecords of every Block
+// the ChainWriter has stored, and returns the hashes of the Blocks
+// whose UndoBlocks were deleted. The undo file currently being written
+// to is never deleted. The returned Blocks can still be read, but no
+// longer disconnected, so their BlockRecords should be marked
+// undo-pruned.
+func (cw *ChainWriter) PruneUndoFiles(belowHeight uint32, records map[string]*blockinfodatabase.BlockRecord) []string {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	undoFiles := cw.prunableFiles(records, cw.UndoFileName, cw.CurrentUndoFileNumber,
+		func(br *blockinfodatabase.BlockRecord) string { return br.UndoFile })
+	pruned := make(map[string]bool)
+	cw.removeFiles(undoFiles, belowHeight, records, blockinfodatabase.StatusPruned|blockinfodatabase.StatusUndoPruned, pruned)
+	return hashList(pruned)
+}
+
+// PruneToTarget deletes the oldest block and undo files, as
+// PruneBlockFiles does, until the files take up no more than target
+// bytes (see DiskUsage), given the BlockRecords of every Block the
+// ChainWriter has stored. Files holding Blocks at or above belowHeight
+// are never deleted, even if that leaves the files over target, and
+// neither are the files currently being written to. It returns the
+// hashes of the Blocks whose files were deleted, which should be
+// marked pruned.
+func (cw *ChainWriter) PruneToTarget(target uint64, belowHeight uint32, records map[string]*blockinfodatabase.BlockRecord) []string {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	usage := cw.diskUsage()
+	if usage <= target {
+		return nil
+	}
+	blockFiles := cw.prunableFiles(records, cw.BlockFileName, cw.CurrentBlockFileNumber,
+		func(br *blockinfodatabase.BlockRecord) string { return br.BlockFile })
+	undoFiles := cw.prunableFiles(records, cw.UndoFileName, cw.CurrentUndoFileNumber,
+		func(br *blockinfodatabase.BlockRecord) string { return br.UndoFile })
+	type candidate struct {
+		maxHeight uint32
+		size      uint64
+	}
+	var candidates []candidate
+	for _, files := range []map[string]*prunableFile{blockFiles, undoFiles} {
+		for fileName, f := range files {
+			if info, err := os.Stat(fileName); err == nil {
+				candidates = append(candidates, candidate{maxHeight: f.maxHeight, size: uint64(info.Size())})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].maxHeight < candidates[j].maxHeight })
+	// the oldest files go first, so prune below the height that frees
+	// enough of them
+	pruneBelow := uint32(0)
+	for _, c := range candidates {
+		if usage <= target || c.maxHeight >= belowHeight {
+			break
+		}
+		usage -= c.size
+		pruneBelow = c.maxHeight + 1
+	}
+	if pruneBelow == 0 {
+		return nil
+	}
+	pruned := make(map[string]bool)
+	for _, files := range []map[string]*prunableFile{blockFiles, undoFiles} {
+		cw.removeFiles(files, pruneBelow, records, blockinfodatabase.StatusPruned, pruned)
+	}
+	return hashList(pruned)
+}
+
+// DiskUsage returns how many bytes the block and undo files take up.
+func (cw *ChainWriter) DiskUsage() uint64 {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.diskUsage()
+}
+
+// diskUsage is DiskUsage, for callers that hold the mutex.
+func (cw *ChainWriter) diskUsage() uint64 {
+	usage := uint64(0)
+	for _, name := range []string{cw.BlockFileName, cw.UndoFileName} {
+		for _, path := range cw.fileNumbers(name) {
+			if info, err := os.Stat(path); err == nil {
+				usage += uint64(info.Size())
+			}
+		}
+	}
+	return usage
+}
+
+// prunableFiles groups the BlockRecords by the file that file returns
+// for them, leaving out Blocks with no such file and the file named
+// name that is currently being written to.
+func (cw *ChainWriter) prunableFiles(records map[string]*blockinfodatabase.BlockRecord, name string, current uint32,
+	file func(*blockinfodatabase.BlockRecord) string) map[string]*prunableFile {
+	files := make(map[string]*prunableFile)
+	for hash, br := range records {
+		fileName := file(br)
+		if fileName == "" {
+			continue
+		}
+		f, ok := files[fileName]
+		if !ok {
+			f = &prunableFile{}
+			files[fileName] = f
+		}
+		if br.Height > f.maxHeight {
+			f.maxHeight = br.Height
+		}
+		f.hashes = append(f.hashes, hash)
+	}
+	delete(files, cw.filePath(name, current))
+	return files
+}
+
+// removeFiles deletes the files whose Blocks are all below belowHeight,
+// adding the hashes of their Blocks to pruned, unless their BlockRecords
+// already have any of the flags in done.
+func (cw *ChainWriter) removeFiles(files map[string]*prunableFile, belowHeight uint32,
+	records map[string]*blockinfodatabase.BlockRecord, done blockinfodatabase.Status, pruned map[string]bool) {
+	for fileName, f := range files {
+		if f.maxHeight >= belowHeight {
+			continue
+		}
+		cw.files.forget(fileName)
+		if err := os.Remove(fileName); err != nil && !os.IsNotExist(err) {
+			utils.Debug.Printf("[chainwriter.removeFiles] Unable to remove {%v}: %v", fileName, err)
+			continue
+		}
+		for _, hash := range f.hashes {
+			if records[hash].Status&done == 0 {
+				pruned[hash] = true
+			}
+		}
+	}
+}
+
+// hashList returns the hashes in a set.
+func hashList(set map[string]bool) []string {
+	var hashes []string
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
@@ -1,41 +1,37 @@
 package chainwriter
 
 import (
-	"log"
+	"fmt"
 	"os"
 )
 
-// writeToDisk appends a slice of bytes to a file.
-func writeToDisk(fileName string, data []byte) {
+// writeToDisk appends a slice of bytes to a file. If the bytes can't
+// all be written, the file is truncated back to its old size, so no
+// partial record is left behind.
+func writeToDisk(fileName string, data []byte) error {
 	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		log.Panicf("[readwrite.writeToDisk] Unable to open file {%v}", fileName)
+		return fmt.Errorf("[readwrite.writeToDisk] Unable to open file {%v}: %v", fileName, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("[readwrite.writeToDisk] Unable to stat file {%v}: %v", fileName, err)
 	}
 	if _, err = file.Write(data); err != nil {
-		file.Close() // ignore error; Write error takes precedence
-		log.Panicf("[readwrite.writeToDisk] Failed to write to file {%v}", fileName)
+		file.Truncate(info.Size()) // ignore error; Write error takes precedence
+		file.Close()
+		return fmt.Errorf("[readwrite.writeToDisk] Failed to write to file {%v}: %v", fileName, err)
 	}
 	if err = file.Close(); err != nil {
-		log.Panicf("[readwrite.writeToDisk] Failed to close file {%v}", fileName)
+		return fmt.Errorf("[readwrite.writeToDisk] Failed to close file {%v}: %v", fileName, err)
 	}
+	return nil
 }
 
-// readFromDisk return a slice of bytes from a file, given a FileInfo.
-func readFromDisk(info *FileInfo) []byte {
-	file, err := os.Open(info.FileName)
-	if err != nil {
-		log.Panicf("[readwrite.readFromDisk] Unable to open file {%v}", info.FileName)
-	}
-	if _, err = file.Seek(int64(info.StartOffset), 0); err != nil {
-		log.Panicf("[readwrite.readFromDisk] Failed to seek to {%v} in file {%v}", info.StartOffset, info.FileName)
-	}
-	numBytes := info.EndOffset - info.StartOffset
-	buf := make([]byte, numBytes)
-	if n, err2 := file.Read(buf); uint32(n) != info.EndOffset-info.StartOffset || err2 != nil {
-		log.Panicf("[readwrite.readFromDisk] Failed to read {%v} bytes from file {%v}", numBytes, info.FileName)
-	}
-	if err = file.Close(); err != nil {
-		log.Panicf("[readwrite.readFromDisk] Failed to close file {%v}", info.FileName)
-	}
-	return buf
+// readFromDisk return a slice of bytes from a file, given a FileInfo,
+// or an error if the FileInfo doesn't lie within the file. The file is
+// read through the ChainWriter's pool of open files.
+func (cw *ChainWriter) readFromDisk(info *FileInfo) ([]byte, error) {
+	return cw.files.read(info)
 }
@@ -0,0 +1,139 @@
+package chainwriter
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resume sets the ChainWriter's current files and offsets to where the
+// files already in its directories end, so that a restarted node
+// keeps appending to them instead of starting over at file 0.
+func (cw *ChainWriter) resume() {
+	cw.CurrentBlockFileNumber, cw.CurrentBlockOffset = cw.resumeFiles(cw.BlockFileName)
+	cw.CurrentUndoFileNumber, cw.CurrentUndoOffset = cw.resumeFiles(cw.UndoFileName)
+}
+
+// fileNumbers maps the numbers of the files named name in their
+// directory, or its shards, to their paths.
+func (cw *ChainWriter) fileNumbers(name string) map[uint32]string {
+	numbers := make(map[uint32]string)
+	pattern := filepath.Join(cw.directory(name), name+"_*"+cw.FileExtension)
+	if cw.FilesPerDirectory > 0 {
+		pattern = filepath.Join(cw.directory(name), "*", name+"_*"+cw.FileExtension)
+	}
+	paths, _ := filepath.Glob(pattern)
+	for _, path := range paths {
+		base := strings.TrimSuffix(filepath.Base(path), cw.FileExtension)
+		n, err := strconv.ParseUint(strings.TrimPrefix(base, name+"_"), 10, 32)
+		if err == nil {
+			numbers[uint32(n)] = path
+		}
+	}
+	return numbers
+}
+
+// resumeFiles returns the number of the last file named name in its
+// directory, and the offset its last intact record ends at. If the
+// node crashed partway through writing a record, the file is truncated
+// to drop the torn record, since records are only ever appended.
+func (cw *ChainWriter) resumeFiles(name string) (uint32, uint32) {
+	last, found := uint32(0), false
+	for n := range cw.fileNumbers(name) {
+		if !found || n > last {
+			last, found = n, true
+		}
+	}
+	if !found {
+		return 0, 0
+	}
+	fileName := cw.filePath(name, last)
+	infos, err := cw.ScanFile(fileName)
+	if err != nil {
+		utils.Debug.Printf("[chainwriter.resume] %v", err)
+		return last, 0
+	}
+	end := uint32(0)
+	if len(infos) > 0 {
+		end = infos[len(infos)-1].EndOffset
+	}
+	if info, err2 := os.Stat(fileName); err2 == nil && info.Size() > int64(end) {
+		utils.Debug.Printf("[chainwriter.resume] dropping %v bytes of a torn record from {%v}", info.Size()-int64(end), fileName)
+		cw.files.forget(fileName)
+		if err = os.Truncate(fileName, int64(end)); err != nil {
+			utils.Debug.Printf("[chainwriter.resume] Unable to truncate {%v}: %v", fileName, err)
+		}
+	}
+	return last, end
+}
+
+// State returns where the ChainWriter will write next, to be stored
+// along with the BlockRecord of the Block it last wrote.
+func (cw *ChainWriter) State() *blockinfodatabase.WriterState {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.state()
+}
+
+// state is State, for when the caller holds the mutex.
+func (cw *ChainWriter) state() *blockinfodatabase.WriterState {
+	return &blockinfodatabase.WriterState{
+		BlockFileNumber: cw.CurrentBlockFileNumber,
+		BlockOffset:     cw.CurrentBlockOffset,
+		UndoFileNumber:  cw.CurrentUndoFileNumber,
+		UndoOffset:      cw.CurrentUndoOffset,
+	}
+}
+
+// ResumeAt sets the ChainWriter's current files and offsets to a
+// WriterState stored by a previous run. Anything written after it was
+// stored belongs to no BlockRecord, since the node crashed before
+// recording the Block, so it is dropped: the current files are
+// truncated to the state's offsets, and later files are removed.
+func (cw *ChainWriter) ResumeAt(state *blockinfodatabase.WriterState) {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	cw.resumeAt(state)
+}
+
+// resumeAt is ResumeAt, for when the caller holds the mutex.
+func (cw *ChainWriter) resumeAt(state *blockinfodatabase.WriterState) {
+	cw.CurrentBlockFileNumber, cw.CurrentBlockOffset = state.BlockFileNumber, state.BlockOffset
+	cw.CurrentUndoFileNumber, cw.CurrentUndoOffset = state.UndoFileNumber, state.UndoOffset
+	cw.rollBackFiles(cw.BlockFileName, state.BlockFileNumber, state.BlockOffset)
+	cw.rollBackFiles(cw.UndoFileName, state.UndoFileNumber, state.UndoOffset)
+}
+
+// rollBackFiles truncates file number of the files named name to
+// offset, and removes the files numbered after it.
+func (cw *ChainWriter) rollBackFiles(name string, number uint32, offset uint32) {
+	for n, path := range cw.fileNumbers(name) {
+		if n <= number {
+			continue
+		}
+		utils.Debug.Printf("[chainwriter.ResumeAt] removing unrecorded file {%v}", path)
+		cw.files.forget(path)
+		if err := os.Remove(path); err != nil {
+			utils.Debug.Printf("[chainwriter.ResumeAt] Unable to remove {%v}: %v", path, err)
+		}
+	}
+	fileName := cw.filePath(name, number)
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return
+	}
+	if info.Size() < int64(offset) {
+		utils.Debug.Printf("[chainwriter.ResumeAt] {%v} is shorter than its recorded offset %v", fileName, offset)
+		return
+	}
+	if info.Size() > int64(offset) {
+		utils.Debug.Printf("[chainwriter.ResumeAt] dropping %v unrecorded bytes from {%v}", info.Size()-int64(offset), fileName)
+		cw.files.forget(fileName)
+		if err = os.Truncate(fileName, int64(offset)); err != nil {
+			utils.Debug.Printf("[chainwriter.ResumeAt] Unable to truncate {%v}: %v", fileName, err)
+		}
+	}
+}
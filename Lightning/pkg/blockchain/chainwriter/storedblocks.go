@@ -0,0 +1,80 @@
+package chainwriter
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"container/list"
+	"sync"
+)
+
+// storedBlocks remembers the BlockRecords of the Blocks a ChainWriter
+// stored most recently, keyed by their Block's hash. Once it holds size
+// BlockRecords, adding another forgets the least recently used one.
+// order has the most recently used entry at the front,
+// entries maps a hash to its element in order.
+type storedBlocks struct {
+	size int
+
+	mutex   sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// storedBlock is a BlockRecord in storedBlocks.
+type storedBlock struct {
+	hash string
+	br   *blockinfodatabase.BlockRecord
+}
+
+// newStoredBlocks returns a storedBlocks that remembers up to size
+// BlockRecords.
+func newStoredBlocks(size int) *storedBlocks {
+	return &storedBlocks{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the BlockRecord of the stored Block with hash, or nil if
+// there is none, and marks it as the most recently used.
+func (s *storedBlocks) get(hash string) *blockinfodatabase.BlockRecord {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.entries[hash]
+	if !ok {
+		return nil
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*storedBlock).br
+}
+
+// put remembers the BlockRecord of the stored Block with hash,
+// forgetting the least recently used one if storedBlocks is full.
+func (s *storedBlocks) put(hash string, br *blockinfodatabase.BlockRecord) {
+	if s.size <= 0 || br == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.entries[hash]; ok {
+		e.Value.(*storedBlock).br = br
+		s.order.MoveToFront(e)
+		return
+	}
+	s.entries[hash] = s.order.PushFront(&storedBlock{hash: hash, br: br})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*storedBlock).hash)
+	}
+}
+
+// remove forgets the BlockRecord of the Block with hash.
+func (s *storedBlocks) remove(hash string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if e, ok := s.entries[hash]; ok {
+		s.order.Remove(e)
+		delete(s.entries, hash)
+	}
+}
@@ -0,0 +1,86 @@
+package chainwriter
+
+import (
+	"Coin/pkg/utils"
+	"fmt"
+	"os"
+)
+
+// Sync flushes the current block and undo files, and their
+// directories if files were created in them, to disk, so the Blocks
+// stored so far survive a power loss.
+func (cw *ChainWriter) Sync() error {
+	cw.mutex.Lock()
+	defer cw.mutex.Unlock()
+	return cw.sync()
+}
+
+// sync is Sync, for when the caller holds the mutex.
+func (cw *ChainWriter) sync() error {
+	blockFile := cw.filePath(cw.BlockFileName, cw.CurrentBlockFileNumber)
+	undoFile := cw.filePath(cw.UndoFileName, cw.CurrentUndoFileNumber)
+	return cw.syncFiles([]string{blockFile, undoFile})
+}
+
+// syncFiles syncs files, and the directories files were created in
+// since they were last synced.
+func (cw *ChainWriter) syncFiles(files []string) error {
+	cw.unsynced = 0
+	for _, fileName := range files {
+		if err := syncFile(fileName); err != nil {
+			return err
+		}
+	}
+	for dir := range cw.createdIn {
+		if err := syncFile(dir); err != nil {
+			return err
+		}
+		delete(cw.createdIn, dir)
+	}
+	return nil
+}
+
+// wrote counts a stored Block, syncing once SyncInterval Blocks have
+// been stored since the last sync.
+func (cw *ChainWriter) wrote() {
+	if cw.SyncInterval == 0 {
+		return
+	}
+	cw.unsynced++
+	if cw.unsynced < cw.SyncInterval {
+		return
+	}
+	if err := cw.sync(); err != nil {
+		utils.Debug.Printf("[chainwriter.sync] %v", err)
+	}
+}
+
+// finishFile is called before the ChainWriter moves on from the file
+// named fileName to a new one. Unless syncing is left to the operating
+// system, the file is synced now, since sync only syncs the current
+// files.
+func (cw *ChainWriter) finishFile(fileName string) {
+	if cw.SyncInterval == 0 {
+		return
+	}
+	if err := syncFile(fileName); err != nil {
+		utils.Debug.Printf("[chainwriter.finishFile] %v", err)
+	}
+}
+
+// syncFile flushes the file or directory named fileName to disk. A
+// file that doesn't exist has nothing to flush.
+func syncFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("[syncFile] Unable to open {%v}: %v", fileName, err)
+	}
+	defer file.Close()
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("[syncFile] Failed to sync {%v}: %v", fileName, err)
+	}
+	return nil
+}
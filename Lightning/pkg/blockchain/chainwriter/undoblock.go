@@ -1,6 +1,10 @@
 package chainwriter
 
-import "Coin/pkg/pro"
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"fmt"
+)
 
 // UndoBlock is used to reverse the side effects causes by a Block.
 // When the chain reverts a block's Transactions, it must both (1)
@@ -39,18 +43,14 @@ func EncodeUndoBlock(ub *UndoBlock) *pro.UndoBlock {
 	}
 }
 
-// DecodeUndoBlock returns an UndoBlock given a pro.UndoBlock
+// DecodeUndoBlock returns an UndoBlock given a pro.UndoBlock.
+// The slices are copied as they are, even if their lengths don't
+// match, so that ValidateUndoBlock can reject them.
 func DecodeUndoBlock(pub *pro.UndoBlock) *UndoBlock {
-	var transactionInputHashes []string
-	var outputIndexes []uint32
-	var amounts []uint32
-	var lockingScripts [][]byte
-	for i := 0; i < len(pub.GetTransactionInputHashes()); i++ {
-		transactionInputHashes = append(transactionInputHashes, pub.GetTransactionInputHashes()[i])
-		outputIndexes = append(outputIndexes, pub.GetOutputIndexes()[i])
-		amounts = append(amounts, pub.GetAmounts()[i])
-		lockingScripts = append(lockingScripts, pub.GetLockingScripts()[i])
-	}
+	transactionInputHashes := append([]string(nil), pub.GetTransactionInputHashes()...)
+	outputIndexes := append([]uint32(nil), pub.GetOutputIndexes()...)
+	amounts := append([]uint32(nil), pub.GetAmounts()...)
+	lockingScripts := append([][]byte(nil), pub.GetLockingScripts()...)
 	return &UndoBlock{
 		TransactionInputHashes: transactionInputHashes,
 		OutputIndexes:          outputIndexes,
@@ -58,3 +58,21 @@ func DecodeUndoBlock(pub *pro.UndoBlock) *UndoBlock {
 		LockingScripts:         lockingScripts,
 	}
 }
+
+// ValidateUndoBlock returns an error if a decoded UndoBlock is
+// malformed: it must have exactly one hash, OutputIndex, Amount,
+// and LockingScript for each coin it restores, and every hash
+// must be a Transaction hash.
+func ValidateUndoBlock(ub *UndoBlock) error {
+	n := len(ub.TransactionInputHashes)
+	if len(ub.OutputIndexes) != n || len(ub.Amounts) != n || len(ub.LockingScripts) != n {
+		return fmt.Errorf("[ValidateUndoBlock] %v hashes, %v output indexes, %v amounts, and %v locking scripts",
+			n, len(ub.OutputIndexes), len(ub.Amounts), len(ub.LockingScripts))
+	}
+	for _, hash := range ub.TransactionInputHashes {
+		if err := block.ValidateHash(hash); err != nil {
+			return fmt.Errorf("[ValidateUndoBlock] %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"sort"
+)
+
+// Checkpoint is a Block that is known to be on the main chain: the
+// Block at Height must have Hash.
+// UTXOHash is the hex-encoded digest of the Coins the Block leaves
+// (see UTXOHash.Digest), so a snapshot of them can be loaded instead
+// of the Blocks below it (see LoadSnapshot), or empty if it isn't
+// known.
+type Checkpoint struct {
+	Height   uint32
+	Hash     string
+	UTXOHash string
+}
+
+// sortCheckpoints returns a copy of checkpoints, sorted by height.
+func sortCheckpoints(checkpoints []Checkpoint) []Checkpoint {
+	sorted := append([]Checkpoint{}, checkpoints...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Height < sorted[j].Height })
+	return sorted
+}
+
+// LastCheckpoint returns the highest Checkpoint, or nil if there are
+// none.
+func (bc *BlockChain) LastCheckpoint() *Checkpoint {
+	if len(bc.checkpoints) == 0 {
+		return nil
+	}
+	return &bc.checkpoints[len(bc.checkpoints)-1]
+}
+
+// lastReachedCheckpoint returns the highest Checkpoint the active
+// chain has reached, or nil if it hasn't reached any.
+func (bc *BlockChain) lastReachedCheckpoint() *Checkpoint {
+	for i := len(bc.checkpoints) - 1; i >= 0; i-- {
+		cp := bc.checkpoints[i]
+		if cp.Height <= bc.Length && bc.BlockInfoDB.GetHashByHeight(cp.Height) == cp.Hash {
+			return &cp
+		}
+	}
+	return nil
+}
+
+// checkCheckpoints returns an error if a Header at height conflicts
+// with a Checkpoint: either a Checkpoint at its height has a different
+// hash, or it forks from the active chain below the last Checkpoint
+// the active chain has reached, so its branch can never include that
+// Checkpoint.
+func (bc *BlockChain) checkCheckpoints(header *block.Header, height uint32) error {
+	hash := (&block.Block{Header: header}).Hash()
+	for _, cp := range bc.checkpoints {
+		if cp.Height == height && cp.Hash != hash {
+			return block.Reject(block.RejectCheckpoint, "[blockchain.checkCheckpoints] block {%v} at height %v conflicts with checkpoint {%v}", hash, height, cp.Hash)
+		}
+	}
+	if cp := bc.lastReachedCheckpoint(); cp != nil && height <= cp.Height {
+		return block.Reject(block.RejectCheckpoint, "[blockchain.checkCheckpoints] block {%v} at height %v forks below checkpoint %v", hash, height, cp.Height)
+	}
+	return nil
+}
+
+// CoveredByCheckpoint returns whether a Block extends the active chain
+// at or below the last Checkpoint. The Checkpoint commits to the
+// Block's hash, and so to its Transactions, so the scripts and
+// signatures of the Coins they spend needn't be checked, which is the
+// most expensive part of connecting a Block during sync. The Coins
+// must still exist and be unspent, and the Block must not create
+// money, as for an assumed valid Block (see assumedValid). This trusts
+// that a Block extending the active chain below the Checkpoint is one
+// of its ancestors; Blocks on other branches are always checked.
+func (bc *BlockChain) CoveredByCheckpoint(b *block.Block) bool {
+	cp := bc.LastCheckpoint()
+	return cp != nil && bc.appendsToActiveChain(b) && bc.Length+1 <= cp.Height
+}
@@ -4,11 +4,14 @@ import (
 	"Coin/pkg/block"
 	"Coin/pkg/blockchain/chainwriter"
 	"Coin/pkg/pro"
+	"Coin/pkg/script"
 	"Coin/pkg/utils"
 	"bytes"
 	"fmt"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"google.golang.org/protobuf/proto"
+	"os"
 )
 
 // CoinDatabase keeps track of Coins.
@@ -17,64 +20,294 @@ import (
 // mainCacheSize is how many Coins are currently in the mainCache.
 // mainCacheCapacity is the maximum number of Coins that the mainCache
 // can store before it must flush.
+// mainCacheBytes is roughly how much memory the Coins in the mainCache use.
+// mainCacheByteBudget is how much memory the mainCache may use before it
+// must flush, and evict unspent Coins if flushing is not enough.
+// pruneInterval is how many Blocks are stored between calls to PruneSpent.
+// blocksSincePrune is how many Blocks have been stored since the last prune.
+// readOnly is whether the db was opened read-only.
+// snapshotDir is where a copy of a locked db was made when opening it
+// read-only, if one was needed.
+// openErr is the error from opening the db, if there was one.
 type CoinDatabase struct {
-	db                *leveldb.DB
-	mainCache         map[CoinLocator]*Coin
-	mainCacheSize     uint32
-	mainCacheCapacity uint32
+	db                  *leveldb.DB
+	mainCache           map[CoinLocator]*Coin
+	mainCacheSize       uint32
+	mainCacheCapacity   uint32
+	mainCacheBytes      uint64
+	mainCacheByteBudget uint64
+	pruneInterval       uint32
+	blocksSincePrune    uint32
+	readOnly            bool
+	snapshotDir         string
+	openErr             error
 }
 
 // New returns a CoinDatabase given a Config.
 func New(config *Config) *CoinDatabase {
-	db, err := leveldb.OpenFile(config.DatabasePath, nil)
+	db, snapshotDir, err := utils.OpenLevelDB(config.DatabasePath, config.ReadOnly)
 	if err != nil {
-		utils.Debug.Printf("Unable to initialize BlockInfoDatabase with path {%v}", config.DatabasePath)
+		utils.Debug.Printf("Unable to initialize CoinDatabase with path {%v}: %v", config.DatabasePath, err)
 	}
-	return &CoinDatabase{
-		db:                db,
-		mainCache:         make(map[CoinLocator]*Coin),
-		mainCacheSize:     0,
-		mainCacheCapacity: config.MainCacheCapacity,
+	coinDB := &CoinDatabase{
+		db:                  db,
+		mainCache:           make(map[CoinLocator]*Coin),
+		mainCacheSize:       0,
+		mainCacheCapacity:   config.MainCacheCapacity,
+		mainCacheByteBudget: config.MainCacheByteBudget,
+		pruneInterval:       config.PruneInterval,
+		readOnly:            config.ReadOnly,
+		snapshotDir:         snapshotDir,
+		openErr:             err,
 	}
+	if db == nil {
+		return coinDB
+	}
+	if config.MigrateOnOpen && !config.ReadOnly {
+		if n, err := coinDB.MigrateRecords(); err != nil {
+			utils.Debug.Printf("%v", err)
+		} else if n > 0 {
+			utils.Debug.Printf("[coindatabase.New] migrated %v coin records to version %v", n, CoinRecordVersion)
+		}
+	}
+	return coinDB
+}
+
+// Err returns the error from opening the CoinDatabase, or nil if it
+// opened cleanly. If the db was corrupted, it is a
+// *utils.CorruptionError, and the Coins should be rebuilt.
+func (coinDB *CoinDatabase) Err() error {
+	return coinDB.openErr
+}
+
+// MigrateRecords upgrades every CoinRecord in the db that was written
+// with an older schema version, returning how many were upgraded.
+// Records are otherwise upgraded lazily, the first time they are read.
+func (coinDB *CoinDatabase) MigrateRecords() (int, error) {
+	if coinDB.readOnly {
+		return 0, fmt.Errorf("[MigrateRecords] coin database is read-only")
+	}
+	batch := new(leveldb.Batch)
+	iterator := coinDB.db.NewIterator(nil, nil)
+	for iterator.Next() {
+		if isMetadataKey(string(iterator.Key())) {
+			continue
+		}
+		pcr := &pro.CoinRecord{}
+		if err := proto.Unmarshal(iterator.Value(), pcr); err != nil {
+			utils.Debug.Printf("[MigrateRecords] Failed to unmarshal record {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		if pcr.GetVersion() == CoinRecordVersion {
+			continue
+		}
+		if err := ValidateCoinRecord(pcr); err != nil {
+			utils.Debug.Printf("[MigrateRecords] Skipping record {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		cr, err := MigrateCoinRecord(DecodeCoinRecord(pcr))
+		if err != nil {
+			utils.Debug.Printf("[MigrateRecords] Unable to migrate record {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		data, err := proto.Marshal(EncodeCoinRecord(cr))
+		if err != nil {
+			utils.Debug.Printf("[MigrateRecords] Unable to marshal record {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		batch.Put(append([]byte{}, iterator.Key()...), data)
+	}
+	iterator.Release()
+	if err := iterator.Error(); err != nil {
+		return 0, fmt.Errorf("[MigrateRecords] failed to iterate over coin records: %v", err)
+	}
+	if err := coinDB.db.Write(batch, nil); err != nil {
+		return 0, fmt.Errorf("[MigrateRecords] failed to write migrated coin records: %v", err)
+	}
+	return batch.Len(), nil
+}
+
+// decodeRecord returns the CoinRecord stored under key, given the bytes
+// stored in the db. The record is validated before it is decoded, and
+// migrated to the current schema version after.
+func (coinDB *CoinDatabase) decodeRecord(key string, data []byte) (*CoinRecord, error) {
+	pcr := &pro.CoinRecord{}
+	if err := proto.Unmarshal(data, pcr); err != nil {
+		return nil, fmt.Errorf("[decodeRecord] failed to unmarshal record {%v}: %v", key, err)
+	}
+	if err := ValidateCoinRecord(pcr); err != nil {
+		return nil, fmt.Errorf("[decodeRecord] rejected record {%v}: %v", key, err)
+	}
+	return coinDB.upgradeRecord(key, DecodeCoinRecord(pcr)), nil
+}
+
+// upgradeRecord returns a CoinRecord migrated to the current schema
+// version, writing the migrated CoinRecord back to the db under key.
+// CoinRecords that cannot be migrated are returned unchanged.
+func (coinDB *CoinDatabase) upgradeRecord(key string, cr *CoinRecord) *CoinRecord {
+	if cr.Version == CoinRecordVersion {
+		return cr
+	}
+	migrated, err := MigrateCoinRecord(cr)
+	if err != nil {
+		utils.Debug.Printf("[upgradeRecord] Unable to migrate record {%v}: %v", key, err)
+		return cr
+	}
+	if !coinDB.readOnly {
+		coinDB.putRecordInDB(key, migrated)
+	}
+	return migrated
 }
 
 // ValidateBlock returns whether a Block's Transactions are valid.
+// Transactions are validated in order against a temporary view of the
+// Block, so a Transaction may spend an output created by an earlier
+// Transaction in the same Block, but no output may be spent twice.
 func (coinDB *CoinDatabase) ValidateBlock(transactions []*block.Transaction) bool {
-	for _, tx := range transactions {
-		if err := coinDB.ValidateTransaction(tx); err != nil {
-			utils.Debug.Printf("%v", err)
-			return false
-		}
+	if err := coinDB.CheckBlock(transactions); err != nil {
+		utils.Debug.Printf("%v", err)
+		return false
 	}
 	return true
 }
 
+// CheckBlock is ValidateBlock, but returns why the first invalid
+// Transaction was rejected instead of just whether it was. Unless the
+// CoinDatabase itself failed, that's a *block.RejectError.
+func (coinDB *CoinDatabase) CheckBlock(transactions []*block.Transaction) error {
+	_, err := coinDB.CheckBlockFees(transactions)
+	return err
+}
+
+// CheckBlockFees is CheckBlock, but also returns the fees that a valid
+// Block's Transactions pay, which its coinbase may claim.
+func (coinDB *CoinDatabase) CheckBlockFees(transactions []*block.Transaction) (uint32, error) {
+	view := make(map[CoinLocator]*Coin)
+	fees := uint64(0)
+	for i, tx := range transactions {
+		fee, err := coinDB.validateTransactionWithView(tx, view)
+		if err != nil {
+			return 0, fmt.Errorf("[CheckBlock] transaction %v: %w", i, err)
+		}
+		if fees += uint64(fee); fees > block.MaxMoney {
+			return 0, block.Reject(block.RejectFeeOutOfRange, "[CheckBlock] fees add up to more than %v", uint64(block.MaxMoney))
+		}
+		addOutputsToView(tx, view)
+	}
+	return uint32(fees), nil
+}
+
 // ValidateTransaction checks whether a Transaction's inputs are valid Coins.
 // If the Coins have already been spent or do not exist, validateTransaction
 // returns an error.
 func (coinDB *CoinDatabase) ValidateTransaction(transaction *block.Transaction) error {
+	_, err := coinDB.validateTransactionWithView(transaction, make(map[CoinLocator]*Coin))
+	return err
+}
+
+// validateTransactionWithView checks a Transaction's inputs against a view
+// of the Block being validated before falling back to the mainCache and db,
+// and returns the fee it pays. The view maps CoinLocators to the Coins
+// created earlier in the Block. Inputs that pass validation are marked as
+// spent in the view. A Transaction with the same hash as one whose Coins
+// aren't all spent is rejected, since its Coins would overwrite them, and
+// so is one whose outputs add up to more than its inputs, unless it's a
+// coinbase, which pays no fee.
+func (coinDB *CoinDatabase) validateTransactionWithView(transaction *block.Transaction, view map[CoinLocator]*Coin) (uint32, error) {
+	if coinDB.hasUnspentCoins(transaction, view) {
+		return 0, block.Reject(block.RejectDuplicateCoins, "[validateTransaction] transaction {%v} already has unspent coins", transaction.Hash())
+	}
+	inputs := uint64(0)
 	for _, txi := range transaction.Inputs {
 		key := makeCoinLocator(txi)
+		if coin, ok := view[key]; ok {
+			if coin.IsSpent {
+				return 0, block.Reject(block.RejectInputsMissing, "[validateTransaction] coin already spent in block")
+			}
+			inputs += uint64(coin.TransactionOutput.Amount)
+			view[key] = &Coin{TransactionOutput: coin.TransactionOutput, IsSpent: true}
+			continue
+		}
 		if coin, ok := coinDB.mainCache[key]; ok {
 			if coin.IsSpent {
-				return fmt.Errorf("[validateTransaction] coin already spent")
+				return 0, block.Reject(block.RejectInputsMissing, "[validateTransaction] coin already spent")
 			}
+			inputs += uint64(coin.TransactionOutput.Amount)
+			view[key] = &Coin{TransactionOutput: coin.TransactionOutput, IsSpent: true}
 			continue
 		}
 		if data, err := coinDB.db.Get([]byte(txi.ReferenceTransactionHash), nil); err != nil {
-			return fmt.Errorf("[validateTransaction] coin not in leveldb")
+			return 0, block.Reject(block.RejectInputsMissing, "[validateTransaction] coin not in leveldb")
 		} else {
-			pcr := &pro.CoinRecord{}
-			if err2 := proto.Unmarshal(data, pcr); err2 != nil {
-				utils.Debug.Printf("Failed to unmarshal record from hash {%v}:", txi.ReferenceTransactionHash, err)
+			cr, err2 := coinDB.decodeRecord(txi.ReferenceTransactionHash, data)
+			if err2 != nil {
+				return 0, fmt.Errorf("[validateTransaction] %v", err2)
 			}
-			cr := DecodeCoinRecord(pcr)
-			if !contains(cr.OutputIndexes, txi.OutputIndex) {
-				return fmt.Errorf("[validateTransaction] coinRecord did not contain Coin")
+			index := indexOf(cr.OutputIndexes, txi.OutputIndex)
+			if index < 0 {
+				return 0, block.Reject(block.RejectInputsMissing, "[validateTransaction] coinRecord did not contain Coin")
 			}
+			txo := &block.TransactionOutput{Amount: cr.Amounts[index], LockingScript: cr.LockingScripts[index]}
+			inputs += uint64(txo.Amount)
+			view[key] = &Coin{TransactionOutput: txo, IsSpent: true}
 		}
 	}
-	return nil
+	if transaction.IsCoinbase() {
+		return 0, nil
+	}
+	outputs := uint64(0)
+	for _, txo := range transaction.Outputs {
+		outputs += uint64(txo.Amount)
+	}
+	if outputs > inputs {
+		return 0, block.Reject(block.RejectInBelowOut, "[validateTransaction] outputs add up to %v, more than the inputs' %v", outputs, inputs)
+	}
+	return uint32(inputs - outputs), nil
+}
+
+// hasUnspentCoins returns whether any Coin that a Transaction would create
+// already exists and is unspent, in the view of the Block being validated,
+// the mainCache, or the db.
+func (coinDB *CoinDatabase) hasUnspentCoins(tx *block.Transaction, view map[CoinLocator]*Coin) bool {
+	txHash := tx.Hash()
+	var cr *CoinRecord
+	for i := range tx.Outputs {
+		cl := CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}
+		if coin, ok := view[cl]; ok {
+			if !coin.IsSpent {
+				return true
+			}
+			continue
+		}
+		if coin, ok := coinDB.mainCache[cl]; ok {
+			if !coin.IsSpent {
+				return true
+			}
+			continue
+		}
+		if cr == nil {
+			data, err := coinDB.db.Get([]byte(txHash), nil)
+			if err != nil {
+				return false
+			}
+			if cr, err = coinDB.decodeRecord(txHash, data); err != nil {
+				return false
+			}
+		}
+		if contains(cr.OutputIndexes, uint32(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// addOutputsToView adds a Transaction's outputs to a Block view as
+// unspent Coins, so later Transactions in the Block can spend them.
+func addOutputsToView(tx *block.Transaction, view map[CoinLocator]*Coin) {
+	txHash := tx.Hash()
+	for i, txo := range tx.Outputs {
+		view[CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}] = &Coin{TransactionOutput: txo}
+	}
 }
 
 // UndoCoins handles reverting a Block.
@@ -84,14 +317,24 @@ func (coinDB *CoinDatabase) ValidateTransaction(transaction *block.Transaction)
 // reestablish the "used" coins (for inputs) as usable.
 //
 // At a high level, this function:
-// (1) loops through all the block/undoBlock pairings
-// (2) erases the coins and coin records created by the block's transaction.
-// (3) re-establishes the inputs as usable.
+// (1) checks that every undoBlock lines up with its block, returning an
+// error without touching the coinDB if any of them do not.
+// (2) loops through all the block/undoBlock pairings
+// (3) erases the coins and coin records created by the block's transaction.
+// (4) re-establishes the inputs as usable.
 // Note: Students must fill out this function for their project.
-func (coinDB *CoinDatabase) UndoCoins(blocks []*block.Block, undoBlocks []*chainwriter.UndoBlock) {
-	// loop through all the block/undoBlock pairings || len(blocks) = len(undoBlocks)
+func (coinDB *CoinDatabase) UndoCoins(blocks []*block.Block, undoBlocks []*chainwriter.UndoBlock) error {
+	// (1) make sure we can undo everything before we undo anything
+	if len(blocks) != len(undoBlocks) {
+		return fmt.Errorf("[UndoCoins] got %v blocks but %v undo blocks", len(blocks), len(undoBlocks))
+	}
+	for i := range blocks {
+		if err := CheckUndoBlock(blocks[i], undoBlocks[i]); err != nil {
+			return err
+		}
+	}
 	for i := 0; i < len(blocks); i++ {
-		// (1) deal with Blocks: erase the coins and the coin record
+		// (2) deal with Blocks: erase the coins and the coin record
 		for _, tx := range blocks[i].Transactions {
 			// delete all the coins created by this block
 			for j := 0; j < len(tx.Outputs); j++ {
@@ -99,20 +342,22 @@ func (coinDB *CoinDatabase) UndoCoins(blocks []*block.Block, undoBlocks []*chain
 					ReferenceTransactionHash: tx.Hash(),
 					OutputIndex:              uint32(j),
 				}
-				delete(coinDB.mainCache, cl)
-				coinDB.mainCacheSize--
+				if _, ok := coinDB.mainCache[cl]; ok {
+					coinDB.removeFromMainCache(cl)
+					coinDB.mainCacheSize--
+				}
 			}
 			// delete the coin record
 			if err := coinDB.db.Delete([]byte(tx.Hash()), nil); err != nil {
 				utils.Debug.Printf("[coinDb.UndoCoins] Error while deleting coin record for hash: %v", tx.Hash())
 			}
 		}
-		// (2) deal with UndoBlocks: re-establish inputs as usable
+		// (3) deal with UndoBlocks: re-establish inputs as usable
 		for j := 0; j < len(undoBlocks[i].TransactionInputHashes); j++ {
 			txHash := undoBlocks[i].TransactionInputHashes[j]
 			// mark coin in mainCache as usable once again
 			cl := CoinLocator{
-				ReferenceTransactionHash: undoBlocks[i].TransactionInputHashes[j],
+				ReferenceTransactionHash: txHash,
 				OutputIndex:              undoBlocks[i].OutputIndexes[j],
 			}
 			if coin, ok := coinDB.mainCache[cl]; ok {
@@ -120,25 +365,62 @@ func (coinDB *CoinDatabase) UndoCoins(blocks []*block.Block, undoBlocks []*chain
 			}
 			// retrieve coin record from db
 			cr := coinDB.getCoinRecordFromDB(txHash)
-			//
 			if cr != nil {
-				// Add coins to record. This is the reestablishing part.
-				cr = coinDB.addCoinToRecord(cr, undoBlocks[i], j)
+				// a coin that was spent in the mainCache was never
+				// removed from its record, so only add it if it's missing.
+				// This is the reestablishing part.
+				if !contains(cr.OutputIndexes, cl.OutputIndex) {
+					cr = coinDB.addCoinToRecord(cr, undoBlocks[i], j)
+				}
 			} else {
 				// if there was no coin record to get from the db, we
-				// need to make a new one with all the coins from
-				// the undoBlock
+				// need to make a new one with this coin from the undoBlock
 				cr = &CoinRecord{
-					Version:        0,
-					OutputIndexes:  undoBlocks[i].OutputIndexes,
-					Amounts:        undoBlocks[i].Amounts,
-					LockingScripts: undoBlocks[i].LockingScripts,
+					Version:        CoinRecordVersion,
+					OutputIndexes:  []uint32{undoBlocks[i].OutputIndexes[j]},
+					Amounts:        []uint32{undoBlocks[i].Amounts[j]},
+					LockingScripts: [][]byte{undoBlocks[i].LockingScripts[j]},
 				}
 			}
 			// put the updated record back in the db.
 			coinDB.putRecordInDB(txHash, cr)
 		}
 	}
+	return nil
+}
+
+// CheckUndoBlock returns an error if an UndoBlock does not describe
+// exactly the inputs of its Block, in order. Inputs that spend outputs
+// created earlier in the same Block have no entry in the UndoBlock.
+func CheckUndoBlock(b *block.Block, ub *chainwriter.UndoBlock) error {
+	if ub == nil {
+		return fmt.Errorf("[CheckUndoBlock] missing undo block for block {%v}", b.Hash())
+	}
+	n := len(ub.TransactionInputHashes)
+	if len(ub.OutputIndexes) != n || len(ub.Amounts) != n || len(ub.LockingScripts) != n {
+		return fmt.Errorf("[CheckUndoBlock] undo block for block {%v} has mismatched lengths", b.Hash())
+	}
+	k := 0
+	createdInBlock := make(map[string]bool)
+	for _, tx := range b.Transactions {
+		for _, txi := range tx.Inputs {
+			if createdInBlock[txi.ReferenceTransactionHash] {
+				continue
+			}
+			if k >= n {
+				return fmt.Errorf("[CheckUndoBlock] undo block for block {%v} is missing inputs", b.Hash())
+			}
+			if ub.TransactionInputHashes[k] != txi.ReferenceTransactionHash || ub.OutputIndexes[k] != txi.OutputIndex {
+				return fmt.Errorf("[CheckUndoBlock] undo block for block {%v} does not match input %v", b.Hash(), k)
+			}
+			k++
+		}
+		createdInBlock[tx.Hash()] = true
+	}
+	if k != n {
+		return fmt.Errorf("[CheckUndoBlock] undo block for block {%v} has %v extra inputs", b.Hash(), n-k)
+	}
+	return nil
 }
 
 // addCoinToRecord adds a Coin to a CoinRecord given an UndoBlock and index,
@@ -173,19 +455,21 @@ func (coinDB *CoinDatabase) FlushMainCache() {
 			if err != nil {
 				utils.Debug.Printf("[FlushMainCache] coin record not in leveldb")
 			}
-			pcr := &pro.CoinRecord{}
-			if err = proto.Unmarshal(data, pcr); err != nil {
-				utils.Debug.Printf("Failed to unmarshal record from hash {%v}:%v", cl.ReferenceTransactionHash, err)
+			if cr, err = coinDB.decodeRecord(cl.ReferenceTransactionHash, data); err != nil {
+				// leave a malformed record alone rather than overwrite it
+				utils.Debug.Printf("[FlushMainCache] %v", err)
+				coinDB.removeFromMainCache(cl)
+				continue
 			}
-			cr = DecodeCoinRecord(pcr)
 		}
 		// (2) we know that the coin is spent given our first check, so we should remove it from the record
 		cr = coinDB.removeCoinFromRecord(cr, cl.OutputIndex)
 		// add the updated coin record and remove the coin from the cache
 		updatedCoinRecords[cl.ReferenceTransactionHash] = cr
-		delete(coinDB.mainCache, cl)
+		coinDB.removeFromMainCache(cl)
 	}
 	coinDB.mainCacheSize = 0
+	coinDB.evictUnspentCoins(0)
 	// write the new records
 	for key, cr := range updatedCoinRecords {
 		if len(cr.OutputIndexes) == 0 {
@@ -199,18 +483,102 @@ func (coinDB *CoinDatabase) FlushMainCache() {
 	}
 }
 
-// StoreBlock handles storing a newly minted Block. It:
+// StoreBlock handles storing a newly minted Block. For each Transaction,
+// in Block order, it:
 // (1) removes spent TransactionOutputs
 // (2) stores new TransactionOutputs as Coins in the mainCache
 // (3) stores CoinRecords for the Transactions in the db.
+// Handling Transactions one at a time means a Transaction that spends an
+// output created earlier in the same Block finds that Coin in the mainCache.
+// A Block whose first Transaction already has Coins in the CoinDatabase has
+// already been stored, so storing it again does nothing.
 //
 // Important note: students do NOT have these helper functions. We created them to
 // make our lives easier. You should PUSH students to do the same, but they don't
 // have to.
 func (coinDB *CoinDatabase) StoreBlock(transactions []*block.Transaction) {
-	coinDB.updateSpentCoins(transactions)
-	coinDB.storeTransactionsInMainCache(transactions)
-	coinDB.storeTransactionsInDB(transactions)
+	if coinDB.alreadyStored(transactions) {
+		utils.Debug.Printf("[coindatabase.StoreBlock] block already stored")
+		return
+	}
+	for _, tx := range transactions {
+		txs := []*block.Transaction{tx}
+		coinDB.updateSpentCoins(txs)
+		coinDB.storeTransactionsInMainCache(txs)
+		coinDB.storeTransactionsInDB(txs)
+	}
+	if coinDB.pruneInterval == 0 || coinDB.readOnly {
+		return
+	}
+	coinDB.blocksSincePrune++
+	if coinDB.blocksSincePrune >= coinDB.pruneInterval {
+		if _, err := coinDB.PruneSpent(); err != nil {
+			utils.Debug.Printf("%v", err)
+		}
+	}
+}
+
+// PruneSpent removes spent Coins from the CoinDatabase and reclaims the
+// space they took up on disk. It returns how many CoinRecords were deleted.
+//
+// At a high level, this function:
+// (1) flushes the mainCache, so spent Coins are removed from their CoinRecords
+// (2) deletes any CoinRecords that no longer have any Coins
+// (3) compacts the db, so deleted entries are actually dropped from disk.
+func (coinDB *CoinDatabase) PruneSpent() (int, error) {
+	if coinDB.readOnly {
+		return 0, fmt.Errorf("[PruneSpent] coin database is read-only")
+	}
+	coinDB.blocksSincePrune = 0
+	// (1) flush spent coins out of the cache and into their records
+	coinDB.FlushMainCache()
+	// (2) find and delete the records that are now empty
+	batch := new(leveldb.Batch)
+	iterator := coinDB.db.NewIterator(nil, nil)
+	for iterator.Next() {
+		if isMetadataKey(string(iterator.Key())) {
+			continue
+		}
+		pcr := &pro.CoinRecord{}
+		if err := proto.Unmarshal(iterator.Value(), pcr); err != nil {
+			utils.Debug.Printf("[PruneSpent] Failed to unmarshal record {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		if len(pcr.GetOutputIndexes()) == 0 {
+			batch.Delete(append([]byte{}, iterator.Key()...))
+		}
+	}
+	iterator.Release()
+	if err := iterator.Error(); err != nil {
+		return 0, fmt.Errorf("[PruneSpent] failed to iterate over coin records: %v", err)
+	}
+	if err := coinDB.db.Write(batch, nil); err != nil {
+		return 0, fmt.Errorf("[PruneSpent] failed to delete spent coin records: %v", err)
+	}
+	// (3) compact the whole key range
+	if err := coinDB.db.CompactRange(util.Range{}); err != nil {
+		return batch.Len(), fmt.Errorf("[PruneSpent] failed to compact db: %v", err)
+	}
+	return batch.Len(), nil
+}
+
+// alreadyStored returns whether a Block's Transactions have already been
+// stored, by checking for Coins created by its first Transaction.
+func (coinDB *CoinDatabase) alreadyStored(transactions []*block.Transaction) bool {
+	if len(transactions) == 0 || len(transactions[0].Outputs) == 0 {
+		return false
+	}
+	txHash := transactions[0].Hash()
+	for i := range transactions[0].Outputs {
+		if _, ok := coinDB.mainCache[CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}]; ok {
+			return true
+		}
+	}
+	has, err := coinDB.db.Has([]byte(txHash), nil)
+	if err != nil {
+		utils.Debug.Printf("[alreadyStored] Unable to check for coin record {%v}: %v", txHash, err)
+	}
+	return has
 }
 
 // updateSpentCoins marks Coins in the mainCache as spent and removes
@@ -232,9 +600,7 @@ func (coinDB *CoinDatabase) updateSpentCoins(transactions []*block.Transaction)
 				// if the coin is not in the cache,
 				// we have to remove the coin from the
 				// database.
-				txHash := tx.Hash()
-				// remove the spent coin from the db
-				coinDB.removeCoinFromDB(txHash, cl)
+				coinDB.removeCoinFromDB(cl.ReferenceTransactionHash, cl)
 			}
 		}
 	}
@@ -301,8 +667,11 @@ func (coinDB *CoinDatabase) storeTransactionsInMainCache(transactions []*block.T
 		txHash := tx.Hash()
 		for i, txo := range tx.Outputs {
 			// check whether we're approaching our capacity and flush if we are
-			if coinDB.mainCacheSize+uint32(len(tx.Outputs)) >= coinDB.mainCacheCapacity {
+			if coinDB.mainCacheSize+uint32(len(tx.Outputs)) >= coinDB.mainCacheCapacity ||
+				coinDB.overByteBudget(coinBytes(txo)) {
 				coinDB.FlushMainCache()
+				// make room for the new coin too
+				coinDB.evictUnspentCoins(coinBytes(txo))
 			}
 			// actually create the coin
 			coin := &Coin{
@@ -317,6 +686,52 @@ func (coinDB *CoinDatabase) storeTransactionsInMainCache(transactions []*block.T
 			// add the coin to main cach and increment the size of the main cache.
 			coinDB.mainCache[cl] = coin
 			coinDB.mainCacheSize++
+			coinDB.mainCacheBytes += coinBytes(txo)
+		}
+	}
+}
+
+// coinOverheadBytes approximates the memory a Coin uses apart from its
+// locking script: the map entry, the CoinLocator (including a hex-encoded
+// hash), and the Coin and TransactionOutput structs.
+const coinOverheadBytes = 192
+
+// coinBytes approximates how much memory a Coin for a TransactionOutput
+// uses in the mainCache.
+func coinBytes(txo *block.TransactionOutput) uint64 {
+	return coinOverheadBytes + uint64(len(txo.LockingScript))
+}
+
+// MainCacheBytes returns roughly how much memory the mainCache is using.
+func (coinDB *CoinDatabase) MainCacheBytes() uint64 {
+	return coinDB.mainCacheBytes
+}
+
+// overByteBudget returns whether adding extra bytes to the mainCache
+// would put it over its byte budget, if it has one.
+func (coinDB *CoinDatabase) overByteBudget(extra uint64) bool {
+	return coinDB.mainCacheByteBudget > 0 && coinDB.mainCacheBytes+extra > coinDB.mainCacheByteBudget
+}
+
+// removeFromMainCache deletes a Coin from the mainCache, updating
+// mainCacheBytes. It does not change mainCacheSize.
+func (coinDB *CoinDatabase) removeFromMainCache(cl CoinLocator) {
+	if coin, ok := coinDB.mainCache[cl]; ok {
+		coinDB.mainCacheBytes -= coinBytes(coin.TransactionOutput)
+		delete(coinDB.mainCache, cl)
+	}
+}
+
+// evictUnspentCoins drops unspent Coins from the mainCache until extra
+// more bytes would fit in its byte budget. Unspent Coins are safe to drop
+// because their CoinRecords are already in the db.
+func (coinDB *CoinDatabase) evictUnspentCoins(extra uint64) {
+	for cl, coin := range coinDB.mainCache {
+		if !coinDB.overByteBudget(extra) {
+			return
+		}
+		if !coin.IsSpent {
+			coinDB.removeFromMainCache(cl)
 		}
 	}
 }
@@ -348,7 +763,7 @@ func (coinDB *CoinDatabase) createCoinRecord(tx *block.Transaction) *CoinRecord
 		LockingScripts = append(LockingScripts, txo.LockingScript)
 	}
 	cr := &CoinRecord{
-		Version:        0,
+		Version:        CoinRecordVersion,
 		OutputIndexes:  outputIndexes,
 		Amounts:        amounts,
 		LockingScripts: LockingScripts,
@@ -362,11 +777,11 @@ func (coinDB *CoinDatabase) getCoinRecordFromDB(txHash string) *CoinRecord {
 		utils.Debug.Printf("[getCoinRecordFromDB] coin not in leveldb")
 		return nil
 	} else {
-		pcr := &pro.CoinRecord{}
-		if err = proto.Unmarshal(data, pcr); err != nil {
-			utils.Debug.Printf("Failed to unmarshal record from hash {%v}:", txHash, err)
+		cr, err := coinDB.decodeRecord(txHash, data)
+		if err != nil {
+			utils.Debug.Printf("[getCoinRecordFromDB] %v", err)
+			return nil
 		}
-		cr := DecodeCoinRecord(pcr)
 		return cr
 	}
 }
@@ -395,18 +810,20 @@ func (coinDB *CoinDatabase) GetCoin(cl CoinLocator) *Coin {
 	}
 }
 
-//GetBalance returns the current balance of the publicKey
+// GetBalance returns the current balance of the publicKey
 func (coinDB *CoinDatabase) GetBalance(publicKey []byte) uint32 {
 	coinDB.FlushMainCache()
 	balance := uint32(0)
 	iterator := coinDB.db.NewIterator(nil, nil)
 	for iterator.Next() {
-		value := iterator.Value()
-		pcr := &pro.CoinRecord{}
-		if err := proto.Unmarshal(value, pcr); err != nil {
-			utils.Debug.Printf("[GetBalance] Failed to unmarshal record from coinDB iterator:")
+		if isMetadataKey(string(iterator.Key())) {
+			continue
+		}
+		cr, err := coinDB.decodeRecord(string(iterator.Key()), iterator.Value())
+		if err != nil {
+			utils.Debug.Printf("[GetBalance] %v", err)
+			continue
 		}
-		cr := DecodeCoinRecord(pcr)
 		for i, pK := range cr.LockingScripts {
 			if bytes.Equal(pK, publicKey) {
 				balance += cr.Amounts[i]
@@ -417,6 +834,55 @@ func (coinDB *CoinDatabase) GetBalance(publicKey []byte) uint32 {
 	return balance
 }
 
+// GetBalanceForPublicKey returns the sum of every unspent Coin locked to a
+// public key, either directly or by a PayToPublicKey script. Unlike
+// GetBalance, it does not flush the mainCache, so it is cheap enough to
+// answer balance queries for watch-only addresses.
+func (coinDB *CoinDatabase) GetBalanceForPublicKey(publicKey []byte) uint32 {
+	balance := uint32(0)
+	iterator := coinDB.db.NewIterator(nil, nil)
+	for iterator.Next() {
+		if isMetadataKey(string(iterator.Key())) {
+			continue
+		}
+		txHash := string(iterator.Key())
+		cr, err := coinDB.decodeRecord(txHash, iterator.Value())
+		if err != nil {
+			utils.Debug.Printf("[GetBalanceForPublicKey] %v", err)
+			continue
+		}
+		for i, lockingScript := range cr.LockingScripts {
+			if !isLockedTo(lockingScript, publicKey) {
+				continue
+			}
+			// coins spent since the last flush are still in their records
+			cl := CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: cr.OutputIndexes[i]}
+			if coin, ok := coinDB.mainCache[cl]; ok && coin.IsSpent {
+				continue
+			}
+			balance += cr.Amounts[i]
+		}
+	}
+	iterator.Release()
+	return balance
+}
+
+// isLockedTo returns whether a locking script can be spent by the owner
+// of publicKey alone.
+func isLockedTo(lockingScript []byte, publicKey []byte) bool {
+	if bytes.Equal(lockingScript, publicKey) {
+		return true
+	}
+	if t, err := script.DetermineScriptType(lockingScript); err != nil || t != script.P2PK {
+		return false
+	}
+	p2pk := &pro.PayToPublicKey{}
+	if err := proto.Unmarshal(lockingScript, p2pk); err != nil {
+		return false
+	}
+	return bytes.Equal(p2pk.GetPublicKey(), publicKey)
+}
+
 // contains returns true if an int slice s contains element e, false if it does not.
 func contains(s []uint32, e uint32) bool {
 	for _, a := range s {
@@ -427,7 +893,7 @@ func contains(s []uint32, e uint32) bool {
 	return false
 }
 
-//indexOf returns the index of element e in int slice s, -1 if the element does not exist.
+// indexOf returns the index of element e in int slice s, -1 if the element does not exist.
 func indexOf(s []uint32, e uint32) int {
 	for i, a := range s {
 		if a == e {
@@ -440,4 +906,7 @@ func indexOf(s []uint32, e uint32) int {
 // Close is used to actually shut down the db (for testing purposes)
 func (coinDB *CoinDatabase) Close() {
 	coinDB.db.Close()
+	if coinDB.snapshotDir != "" {
+		os.RemoveAll(coinDB.snapshotDir)
+	}
 }
@@ -1,6 +1,25 @@
 package coindatabase
 
-import "Coin/pkg/pro"
+import (
+	"Coin/pkg/pro"
+	"fmt"
+)
+
+// CoinRecordVersion is the schema version of the CoinRecords that the
+// CoinDatabase writes. Records with an older Version are migrated with
+// the coinRecordMigrations when they are read.
+//
+// Version 0 records may have duplicate OutputIndexes, or fewer Amounts
+// or LockingScripts than OutputIndexes.
+// Version 1 records have exactly one Amount and LockingScript for each
+// OutputIndex, and no OutputIndex appears twice.
+const CoinRecordVersion = 1
+
+// coinRecordMigrations maps a schema version to the function that
+// upgrades a CoinRecord from that version to the next one.
+var coinRecordMigrations = map[uint32]func(*CoinRecord) *CoinRecord{
+	0: migrateCoinRecordV0,
+}
 
 // CoinRecord is a record of which coins created by a Transaction
 // have been spent. It is stored in the CoinDatabase's db.
@@ -34,7 +53,16 @@ func DecodeCoinRecord(pcr *pro.CoinRecord) *CoinRecord {
 	var outputIndexes []uint32
 	var amounts []uint32
 	var lockingScripts [][]byte
-	for i := 0; i < len(pcr.GetOutputIndexes()); i++ {
+	// version 0 records may be missing amounts or locking scripts,
+	// so only decode the coins that are complete
+	n := len(pcr.GetOutputIndexes())
+	if len(pcr.GetAmounts()) < n {
+		n = len(pcr.GetAmounts())
+	}
+	if len(pcr.GetLockingScripts()) < n {
+		n = len(pcr.GetLockingScripts())
+	}
+	for i := 0; i < n; i++ {
 		outputIndexes = append(outputIndexes, pcr.GetOutputIndexes()[i])
 		amounts = append(amounts, pcr.GetAmounts()[i])
 		lockingScripts = append(lockingScripts, pcr.GetLockingScripts()[i])
@@ -46,3 +74,62 @@ func DecodeCoinRecord(pcr *pro.CoinRecord) *CoinRecord {
 		LockingScripts: lockingScripts,
 	}
 }
+
+// ValidateCoinRecord returns an error if a pro.CoinRecord is malformed.
+// Records written with the current schema version must have exactly one
+// Amount and LockingScript for each OutputIndex, with no OutputIndex
+// repeated. Older records are fixed up by migration instead, and records
+// from newer versions are rejected.
+func ValidateCoinRecord(pcr *pro.CoinRecord) error {
+	if pcr.GetVersion() > CoinRecordVersion {
+		return fmt.Errorf("[ValidateCoinRecord] record version %v is newer than %v", pcr.GetVersion(), CoinRecordVersion)
+	}
+	if pcr.GetVersion() < CoinRecordVersion {
+		return nil
+	}
+	n := len(pcr.GetOutputIndexes())
+	if len(pcr.GetAmounts()) != n || len(pcr.GetLockingScripts()) != n {
+		return fmt.Errorf("[ValidateCoinRecord] %v output indexes, %v amounts, and %v locking scripts",
+			n, len(pcr.GetAmounts()), len(pcr.GetLockingScripts()))
+	}
+	seen := make(map[uint32]bool)
+	for _, outputIndex := range pcr.GetOutputIndexes() {
+		if seen[outputIndex] {
+			return fmt.Errorf("[ValidateCoinRecord] output index %v is repeated", outputIndex)
+		}
+		seen[outputIndex] = true
+	}
+	return nil
+}
+
+// MigrateCoinRecord returns a CoinRecord upgraded to CoinRecordVersion,
+// applying each migration in turn. It returns an error if the CoinRecord
+// was written by a newer version of the software.
+func MigrateCoinRecord(cr *CoinRecord) (*CoinRecord, error) {
+	if cr.Version > CoinRecordVersion {
+		return nil, fmt.Errorf("[MigrateCoinRecord] record version %v is newer than %v", cr.Version, CoinRecordVersion)
+	}
+	for cr.Version < CoinRecordVersion {
+		migrate, ok := coinRecordMigrations[cr.Version]
+		if !ok {
+			return nil, fmt.Errorf("[MigrateCoinRecord] no migration from version %v", cr.Version)
+		}
+		cr = migrate(cr)
+	}
+	return cr, nil
+}
+
+// migrateCoinRecordV0 upgrades a version 0 CoinRecord to version 1 by
+// dropping repeated OutputIndexes, keeping the first of each.
+func migrateCoinRecordV0(cr *CoinRecord) *CoinRecord {
+	migrated := &CoinRecord{Version: 1}
+	for i, outputIndex := range cr.OutputIndexes {
+		if contains(migrated.OutputIndexes, outputIndex) {
+			continue
+		}
+		migrated.OutputIndexes = append(migrated.OutputIndexes, outputIndex)
+		migrated.Amounts = append(migrated.Amounts, cr.Amounts[i])
+		migrated.LockingScripts = append(migrated.LockingScripts, cr.LockingScripts[i])
+	}
+	return migrated
+}
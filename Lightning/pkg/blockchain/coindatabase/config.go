@@ -1,15 +1,32 @@
 package coindatabase
 
 // Config is the CoinDatabase's configuration options.
+// MainCacheByteBudget is roughly how many bytes of memory the mainCache
+// may use before it is flushed. Zero means only MainCacheCapacity applies.
+// PruneInterval is how many Blocks are stored between
+// automatic calls to PruneSpent. Zero disables automatic pruning.
+// MigrateOnOpen is whether to upgrade every CoinRecord written with an
+// older schema version when the CoinDatabase is opened. Otherwise, they
+// are upgraded as they are read.
+// ReadOnly is whether to open the database so it can't be written to,
+// for tools that inspect a node's data. Records are not migrated, and
+// nothing is pruned, in a read-only CoinDatabase.
 type Config struct {
-	DatabasePath      string
-	MainCacheCapacity uint32
+	DatabasePath        string
+	MainCacheCapacity   uint32
+	MainCacheByteBudget uint64
+	PruneInterval       uint32
+	MigrateOnOpen       bool
+	ReadOnly            bool
 }
 
 // DefaultConfig returns the CoinDatabase's default Config.
 func DefaultConfig() *Config {
 	return &Config{
-		DatabasePath:      "coindata",
-		MainCacheCapacity: 30,
+		DatabasePath:        "coindata",
+		MainCacheCapacity:   30,
+		MainCacheByteBudget: 0,
+		PruneInterval:       100,
+		MigrateOnOpen:       false,
 	}
 }
@@ -0,0 +1,74 @@
+package coindatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// flushMarkerKey is the key the flush marker is stored under.
+// Transaction hashes are hex, so they are never equal to it.
+const flushMarkerKey = "flushed"
+
+// isMetadataKey returns whether key is one of the keys the CoinDatabase
+// uses for something other than a CoinRecord.
+func isMetadataKey(key string) bool {
+	return key == flushMarkerKey
+}
+
+// Flush flushes the mainCache to the db and then records hash, the
+// hash of the last Block stored, as the flush marker. Spent Coins only
+// leave their CoinRecords when the mainCache is flushed, so the db
+// matches the chain exactly as of the flush marker, and no later.
+func (coinDB *CoinDatabase) Flush(hash string) error {
+	if coinDB.readOnly {
+		return fmt.Errorf("[Flush] coin database is read-only")
+	}
+	coinDB.FlushMainCache()
+	if err := coinDB.db.Put([]byte(flushMarkerKey), []byte(hash), &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("[Flush] failed to store flush marker {%v}: %v", hash, err)
+	}
+	return nil
+}
+
+// FlushMarker returns the hash recorded by the last Flush, or "" if
+// the CoinDatabase has never been flushed with one.
+func (coinDB *CoinDatabase) FlushMarker() string {
+	data, err := coinDB.db.Get([]byte(flushMarkerKey), nil)
+	if err != nil {
+		if err != leveldb.ErrNotFound {
+			utils.Debug.Printf("[FlushMarker] failed to read flush marker: %v", err)
+		}
+		return ""
+	}
+	return string(data)
+}
+
+// ReplayBlock applies a Block's Transactions straight to the db, for
+// Blocks stored after the last flush that a crash may have partly lost.
+// Unlike StoreBlock, it can be run on a Block that was already stored:
+// Coins that were already removed are skipped, and CoinRecords are
+// rewritten. Replaying every Block after the flush marker, in order,
+// brings the db back in line with the chain. It should only be used
+// while the mainCache is empty, before any Block is stored.
+func (coinDB *CoinDatabase) ReplayBlock(transactions []*block.Transaction) {
+	for _, tx := range transactions {
+		for _, txi := range tx.Inputs {
+			cr := coinDB.getCoinRecordFromDB(txi.ReferenceTransactionHash)
+			if cr == nil || indexOf(cr.OutputIndexes, txi.OutputIndex) < 0 {
+				continue
+			}
+			cr = coinDB.removeCoinFromRecord(cr, txi.OutputIndex)
+			if len(cr.OutputIndexes) == 0 {
+				if err := coinDB.db.Delete([]byte(txi.ReferenceTransactionHash), nil); err != nil {
+					utils.Debug.Printf("[ReplayBlock] failed to remove {%v} from db", txi.ReferenceTransactionHash)
+				}
+				continue
+			}
+			coinDB.putRecordInDB(txi.ReferenceTransactionHash, cr)
+		}
+		coinDB.storeTransactionsInDB([]*block.Transaction{tx})
+	}
+}
@@ -0,0 +1,137 @@
+package coindatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"crypto/sha256"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"google.golang.org/protobuf/proto"
+	"sort"
+)
+
+// SnapshotCoins returns every unspent Coin in the CoinDatabase, split
+// into chunks of at most chunkSize Coins, along with their UTXOHash.
+// Coins are ordered by their CoinLocators, so two CoinDatabases holding
+// the same Coins split them into the same chunks, whatever order the
+// Coins were stored in. It flushes the mainCache first, so spent Coins
+// are gone from their CoinRecords.
+func (coinDB *CoinDatabase) SnapshotCoins(chunkSize int) ([][]*DeltaCoin, *UTXOHash, error) {
+	if chunkSize <= 0 {
+		return nil, nil, fmt.Errorf("[SnapshotCoins] chunk size must be positive")
+	}
+	coinDB.FlushMainCache()
+	h := NewUTXOHash()
+	var chunks [][]*DeltaCoin
+	var chunk []*DeltaCoin
+	iterator := coinDB.db.NewIterator(nil, nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		key := string(iterator.Key())
+		if isMetadataKey(key) {
+			continue
+		}
+		cr, err := coinDB.decodeRecord(key, iterator.Value())
+		if err != nil {
+			return nil, nil, fmt.Errorf("[SnapshotCoins] %v", err)
+		}
+		var coins []*DeltaCoin
+		for i, index := range cr.OutputIndexes {
+			coins = append(coins, &DeltaCoin{
+				Locator:           CoinLocator{ReferenceTransactionHash: key, OutputIndex: index},
+				TransactionOutput: &block.TransactionOutput{Amount: cr.Amounts[i], LockingScript: cr.LockingScripts[i]},
+			})
+		}
+		sort.Slice(coins, func(i, j int) bool { return coins[i].Locator.OutputIndex < coins[j].Locator.OutputIndex })
+		for _, dc := range coins {
+			h.Add(dc.Locator, dc.TransactionOutput)
+			chunk = append(chunk, dc)
+			if len(chunk) == chunkSize {
+				chunks = append(chunks, chunk)
+				chunk = nil
+			}
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, h, iterator.Error()
+}
+
+// EncodeSnapshotChunk returns a pro.SnapshotChunk given a chunk of
+// Coins.
+func EncodeSnapshotChunk(coins []*DeltaCoin) *pro.SnapshotChunk {
+	return &pro.SnapshotChunk{Coins: EncodeUTXODelta(&UTXODelta{Created: coins}).GetCreated()}
+}
+
+// DecodeSnapshotChunk returns the Coins in a pro.SnapshotChunk.
+func DecodeSnapshotChunk(pchunk *pro.SnapshotChunk) []*DeltaCoin {
+	return DecodeUTXODelta(&pro.UtxoDelta{Created: pchunk.GetCoins()}).Created
+}
+
+// HashSnapshotChunk returns the sha256 of a pro.SnapshotChunk's
+// deterministic encoding, which is what a snapshot's chunks are
+// advertised by, so each chunk can be checked as it arrives.
+func HashSnapshotChunk(pchunk *pro.SnapshotChunk) ([]byte, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(pchunk)
+	if err != nil {
+		return nil, fmt.Errorf("[HashSnapshotChunk] unable to marshal chunk: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// ReplaceCoins replaces every Coin in the CoinDatabase with coins, in a
+// single write, and records hash, the hash of the Block the Coins are
+// as of, as the flush marker. The mainCache is emptied, since the Coins
+// it holds are no longer in the set.
+func (coinDB *CoinDatabase) ReplaceCoins(coins []*DeltaCoin, hash string) error {
+	if coinDB.readOnly {
+		return fmt.Errorf("[ReplaceCoins] coin database is read-only")
+	}
+	if err := ValidateUTXODelta(&UTXODelta{Created: coins}); err != nil {
+		return err
+	}
+	records := make(map[string]*CoinRecord)
+	for _, dc := range coins {
+		cr, ok := records[dc.Locator.ReferenceTransactionHash]
+		if !ok {
+			cr = &CoinRecord{Version: CoinRecordVersion}
+			records[dc.Locator.ReferenceTransactionHash] = cr
+		}
+		if contains(cr.OutputIndexes, dc.Locator.OutputIndex) {
+			return fmt.Errorf("[ReplaceCoins] coin {%v:%v} appears twice",
+				dc.Locator.ReferenceTransactionHash, dc.Locator.OutputIndex)
+		}
+		cr.OutputIndexes = append(cr.OutputIndexes, dc.Locator.OutputIndex)
+		cr.Amounts = append(cr.Amounts, dc.TransactionOutput.Amount)
+		cr.LockingScripts = append(cr.LockingScripts, dc.TransactionOutput.LockingScript)
+	}
+	batch := new(leveldb.Batch)
+	iterator := coinDB.db.NewIterator(nil, nil)
+	for iterator.Next() {
+		if _, ok := records[string(iterator.Key())]; !ok {
+			batch.Delete(append([]byte{}, iterator.Key()...))
+		}
+	}
+	iterator.Release()
+	if err := iterator.Error(); err != nil {
+		return fmt.Errorf("[ReplaceCoins] failed to read coin records: %v", err)
+	}
+	for txHash, cr := range records {
+		data, err := proto.Marshal(EncodeCoinRecord(cr))
+		if err != nil {
+			return fmt.Errorf("[ReplaceCoins] unable to marshal coin record {%v}: %v", txHash, err)
+		}
+		batch.Put([]byte(txHash), data)
+	}
+	batch.Put([]byte(flushMarkerKey), []byte(hash))
+	if err := coinDB.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return fmt.Errorf("[ReplaceCoins] failed to write coin records: %v", err)
+	}
+	coinDB.mainCache = make(map[CoinLocator]*Coin)
+	coinDB.mainCacheSize = 0
+	coinDB.mainCacheBytes = 0
+	return nil
+}
@@ -0,0 +1,202 @@
+package coindatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/protobuf/proto"
+)
+
+// UTXODelta is the change to the set of unspent Coins made by a run
+// of consecutive Blocks.
+// Spent are the Coins that existed before the first Block, and were
+// spent by the Blocks,
+// Created are the Coins that were created by the Blocks, and are
+// still unspent after the last one.
+type UTXODelta struct {
+	Spent   []CoinLocator
+	Created []*DeltaCoin
+}
+
+// DeltaCoin is a Coin created in a UTXODelta.
+// Locator is where the Coin was created,
+// TransactionOutput is the Coin's TransactionOutput.
+type DeltaCoin struct {
+	Locator           CoinLocator
+	TransactionOutput *block.TransactionOutput
+}
+
+// MakeUTXODelta returns the UTXODelta for a run of consecutive Blocks,
+// given in order. Coins that are both created and spent within the
+// run appear in neither Spent nor Created.
+func MakeUTXODelta(blocks []*block.Block) *UTXODelta {
+	created := make(map[CoinLocator]*DeltaCoin)
+	var order []CoinLocator
+	delta := &UTXODelta{}
+	for _, b := range blocks {
+		for _, tx := range b.Transactions {
+			for _, txi := range tx.Inputs {
+				cl := makeCoinLocator(txi)
+				if _, ok := created[cl]; ok {
+					delete(created, cl)
+				} else {
+					delta.Spent = append(delta.Spent, cl)
+				}
+			}
+			txHash := tx.Hash()
+			for i, txo := range tx.Outputs {
+				cl := CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}
+				created[cl] = &DeltaCoin{Locator: cl, TransactionOutput: txo}
+				order = append(order, cl)
+			}
+		}
+	}
+	for _, cl := range order {
+		if dc, ok := created[cl]; ok {
+			delta.Created = append(delta.Created, dc)
+		}
+	}
+	return delta
+}
+
+// EncodeUTXODelta returns a pro.UtxoDelta given a UTXODelta.
+func EncodeUTXODelta(delta *UTXODelta) *pro.UtxoDelta {
+	var spent []*pro.CoinLocator
+	for _, cl := range delta.Spent {
+		spent = append(spent, encodeCoinLocator(cl))
+	}
+	var created []*pro.DeltaCoin
+	for _, dc := range delta.Created {
+		created = append(created, &pro.DeltaCoin{
+			Locator: encodeCoinLocator(dc.Locator),
+			Output:  block.EncodeTransactionOutput(dc.TransactionOutput),
+		})
+	}
+	return &pro.UtxoDelta{Spent: spent, Created: created}
+}
+
+// DecodeUTXODelta returns a UTXODelta given a pro.UtxoDelta.
+func DecodeUTXODelta(pdelta *pro.UtxoDelta) *UTXODelta {
+	delta := &UTXODelta{}
+	for _, pcl := range pdelta.GetSpent() {
+		delta.Spent = append(delta.Spent, decodeCoinLocator(pcl))
+	}
+	for _, pdc := range pdelta.GetCreated() {
+		delta.Created = append(delta.Created, &DeltaCoin{
+			Locator:           decodeCoinLocator(pdc.GetLocator()),
+			TransactionOutput: block.DecodeTransactionOutput(pdc.GetOutput()),
+		})
+	}
+	return delta
+}
+
+func encodeCoinLocator(cl CoinLocator) *pro.CoinLocator {
+	return &pro.CoinLocator{
+		ReferenceTransactionHash: cl.ReferenceTransactionHash,
+		OutputIndex:              cl.OutputIndex,
+	}
+}
+
+func decodeCoinLocator(pcl *pro.CoinLocator) CoinLocator {
+	return CoinLocator{
+		ReferenceTransactionHash: pcl.GetReferenceTransactionHash(),
+		OutputIndex:              pcl.GetOutputIndex(),
+	}
+}
+
+// ValidateUTXODelta returns an error if a decoded UTXODelta is
+// malformed: every Coin must be located by a Transaction hash, and
+// every created Coin must have a TransactionOutput.
+func ValidateUTXODelta(delta *UTXODelta) error {
+	for _, cl := range delta.Spent {
+		if err := block.ValidateHash(cl.ReferenceTransactionHash); err != nil {
+			return fmt.Errorf("[ValidateUTXODelta] spent coin: %v", err)
+		}
+	}
+	for _, dc := range delta.Created {
+		if err := block.ValidateHash(dc.Locator.ReferenceTransactionHash); err != nil {
+			return fmt.Errorf("[ValidateUTXODelta] created coin: %v", err)
+		}
+		if dc.TransactionOutput == nil {
+			return fmt.Errorf("[ValidateUTXODelta] created coin {%v:%v} has no output",
+				dc.Locator.ReferenceTransactionHash, dc.Locator.OutputIndex)
+		}
+	}
+	return nil
+}
+
+// ApplyUTXODelta applies a UTXODelta to the CoinDatabase in a single
+// write, so either all of it is applied or none of it is. It returns
+// an error, and changes nothing, if the UTXODelta is malformed or a
+// spent Coin is not unspent in the CoinDatabase.
+//
+// At a high level, this function:
+// (1) flushes the mainCache, so the db has every CoinRecord up to date
+// (2) removes spent Coins from, and adds created Coins to, their CoinRecords
+// (3) writes the updated CoinRecords in one batch
+// (4) drops the spent Coins from the mainCache.
+func (coinDB *CoinDatabase) ApplyUTXODelta(delta *UTXODelta) error {
+	if coinDB.readOnly {
+		return fmt.Errorf("[ApplyUTXODelta] coin database is read-only")
+	}
+	if err := ValidateUTXODelta(delta); err != nil {
+		return err
+	}
+	// (1) flush the cache
+	coinDB.FlushMainCache()
+	// (2) update the records in memory first
+	records := make(map[string]*CoinRecord)
+	getRecord := func(txHash string) *CoinRecord {
+		if cr, ok := records[txHash]; ok {
+			return cr
+		}
+		if has, _ := coinDB.db.Has([]byte(txHash), nil); !has {
+			return nil
+		}
+		cr := coinDB.getCoinRecordFromDB(txHash)
+		records[txHash] = cr
+		return cr
+	}
+	for _, cl := range delta.Spent {
+		cr := getRecord(cl.ReferenceTransactionHash)
+		if cr == nil || !contains(cr.OutputIndexes, cl.OutputIndex) {
+			return fmt.Errorf("[ApplyUTXODelta] coin {%v:%v} is not unspent",
+				cl.ReferenceTransactionHash, cl.OutputIndex)
+		}
+		records[cl.ReferenceTransactionHash] = coinDB.removeCoinFromRecord(cr, cl.OutputIndex)
+	}
+	for _, dc := range delta.Created {
+		cr := getRecord(dc.Locator.ReferenceTransactionHash)
+		if cr == nil {
+			cr = &CoinRecord{Version: CoinRecordVersion}
+		}
+		if !contains(cr.OutputIndexes, dc.Locator.OutputIndex) {
+			cr.OutputIndexes = append(cr.OutputIndexes, dc.Locator.OutputIndex)
+			cr.Amounts = append(cr.Amounts, dc.TransactionOutput.Amount)
+			cr.LockingScripts = append(cr.LockingScripts, dc.TransactionOutput.LockingScript)
+		}
+		records[dc.Locator.ReferenceTransactionHash] = cr
+	}
+	// (3) write every record at once
+	batch := new(leveldb.Batch)
+	for txHash, cr := range records {
+		if len(cr.OutputIndexes) == 0 {
+			batch.Delete([]byte(txHash))
+			continue
+		}
+		data, err := proto.Marshal(EncodeCoinRecord(cr))
+		if err != nil {
+			return fmt.Errorf("[ApplyUTXODelta] unable to marshal coin record {%v}: %v", txHash, err)
+		}
+		batch.Put([]byte(txHash), data)
+	}
+	if err := coinDB.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("[ApplyUTXODelta] failed to write coin records: %v", err)
+	}
+	// (4) the cache may still hold coins that are now spent
+	for _, cl := range delta.Spent {
+		coinDB.removeFromMainCache(cl)
+	}
+	return nil
+}
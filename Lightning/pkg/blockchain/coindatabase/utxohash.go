@@ -0,0 +1,148 @@
+package coindatabase
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/chainwriter"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// utxoHashBytes is the size of an encoded UTXOHash.
+const utxoHashBytes = 384
+
+// utxoHashPrime is the prime 2^3072 - 1103717, the largest below
+// 2^3072, which UTXOHashes are computed modulo.
+var utxoHashPrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 8*utxoHashBytes), big.NewInt(1103717))
+
+// UTXOHash is a rolling hash of a set of Coins: the product, modulo a
+// large prime, of a hash of each Coin. Since multiplication commutes,
+// the hash doesn't depend on the order Coins were added in, so it can
+// be kept up to date as Blocks are connected, one Coin at a time, and
+// still equal the hash of the whole set computed from scratch, as a
+// snapshot of the Coins is (see CoinDatabase.UTXOHash).
+// numerator is the product of the Coins added, and denominator of the
+// Coins removed, so removing a Coin doesn't need a modular inverse
+// until the hash is encoded.
+type UTXOHash struct {
+	numerator   *big.Int
+	denominator *big.Int
+}
+
+// NewUTXOHash returns the UTXOHash of an empty set of Coins.
+func NewUTXOHash() *UTXOHash {
+	return &UTXOHash{numerator: big.NewInt(1), denominator: big.NewInt(1)}
+}
+
+// DecodeUTXOHash returns the UTXOHash encoded by Bytes.
+func DecodeUTXOHash(b []byte) (*UTXOHash, error) {
+	if len(b) != utxoHashBytes {
+		return nil, fmt.Errorf("[DecodeUTXOHash] utxo hash has %v bytes, not %v", len(b), utxoHashBytes)
+	}
+	n := new(big.Int).SetBytes(b)
+	if n.Sign() == 0 || n.Cmp(utxoHashPrime) >= 0 {
+		return nil, fmt.Errorf("[DecodeUTXOHash] utxo hash is out of range")
+	}
+	return &UTXOHash{numerator: n, denominator: big.NewInt(1)}, nil
+}
+
+// Bytes returns the UTXOHash's state, which DecodeUTXOHash decodes, so
+// Coins can be added to and removed from it later.
+func (h *UTXOHash) Bytes() []byte {
+	n := new(big.Int).ModInverse(h.denominator, utxoHashPrime)
+	n.Mul(n, h.numerator).Mod(n, utxoHashPrime)
+	h.numerator, h.denominator = n, big.NewInt(1)
+	return n.FillBytes(make([]byte, utxoHashBytes))
+}
+
+// Digest returns the 32 byte hash of the set of Coins, which is what
+// Blocks commit to.
+func (h *UTXOHash) Digest() []byte {
+	digest := sha256.Sum256(h.Bytes())
+	return digest[:]
+}
+
+// Copy returns a copy of the UTXOHash.
+func (h *UTXOHash) Copy() *UTXOHash {
+	return &UTXOHash{numerator: new(big.Int).Set(h.numerator), denominator: new(big.Int).Set(h.denominator)}
+}
+
+// Add adds the Coin for txo at cl to the set.
+func (h *UTXOHash) Add(cl CoinLocator, txo *block.TransactionOutput) {
+	h.numerator.Mul(h.numerator, coinElement(cl, txo)).Mod(h.numerator, utxoHashPrime)
+}
+
+// Remove removes the Coin for txo at cl from the set.
+func (h *UTXOHash) Remove(cl CoinLocator, txo *block.TransactionOutput) {
+	h.denominator.Mul(h.denominator, coinElement(cl, txo)).Mod(h.denominator, utxoHashPrime)
+}
+
+// ConnectBlock updates the set for a Block being connected, given its
+// UndoBlock: the Coins it spends are removed, and the Coins it creates
+// are added. Outputs spent later in the same Block never become Coins.
+func (h *UTXOHash) ConnectBlock(b *block.Block, ub *chainwriter.UndoBlock) {
+	spentInBlock := make(map[CoinLocator]bool)
+	for _, tx := range b.Transactions {
+		for _, txi := range tx.Inputs {
+			spentInBlock[makeCoinLocator(txi)] = true
+		}
+	}
+	for _, tx := range b.Transactions {
+		txHash := tx.Hash()
+		for i, txo := range tx.Outputs {
+			if cl := (CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}); !spentInBlock[cl] {
+				h.Add(cl, txo)
+			}
+		}
+	}
+	for i, txHash := range ub.TransactionInputHashes {
+		cl := CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: ub.OutputIndexes[i]}
+		h.Remove(cl, &block.TransactionOutput{Amount: ub.Amounts[i], LockingScript: ub.LockingScripts[i]})
+	}
+}
+
+// coinElement hashes a Coin to a number below utxoHashPrime, by
+// hashing it along with a counter enough times to fill utxoHashBytes.
+func coinElement(cl CoinLocator, txo *block.TransactionOutput) *big.Int {
+	numbers := make([]byte, 8)
+	binary.BigEndian.PutUint32(numbers, cl.OutputIndex)
+	binary.BigEndian.PutUint32(numbers[4:], txo.Amount)
+	coin := append([]byte(cl.ReferenceTransactionHash), numbers...)
+	coin = append(coin, txo.LockingScript...)
+	seed := sha256.Sum256(coin)
+	expanded := make([]byte, 0, utxoHashBytes)
+	for i := byte(0); len(expanded) < utxoHashBytes; i++ {
+		chunk := sha256.Sum256(append(seed[:], i))
+		expanded = append(expanded, chunk[:]...)
+	}
+	e := new(big.Int).SetBytes(expanded)
+	if e.Cmp(utxoHashPrime) >= 0 {
+		e.Sub(e, utxoHashPrime)
+	}
+	return e
+}
+
+// UTXOHash returns the UTXOHash of every unspent Coin in the
+// CoinDatabase, flushing the mainCache first so spent Coins are gone
+// from their CoinRecords.
+func (coinDB *CoinDatabase) UTXOHash() (*UTXOHash, error) {
+	coinDB.FlushMainCache()
+	h := NewUTXOHash()
+	iterator := coinDB.db.NewIterator(nil, nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		key := string(iterator.Key())
+		if isMetadataKey(key) {
+			continue
+		}
+		cr, err := coinDB.decodeRecord(key, iterator.Value())
+		if err != nil {
+			return nil, fmt.Errorf("[UTXOHash] %v", err)
+		}
+		for i, index := range cr.OutputIndexes {
+			h.Add(CoinLocator{ReferenceTransactionHash: key, OutputIndex: index}, &block.TransactionOutput{Amount: cr.Amounts[i], LockingScript: cr.LockingScripts[i]})
+		}
+	}
+	return h, iterator.Error()
+}
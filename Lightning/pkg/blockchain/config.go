@@ -4,17 +4,121 @@ import (
 	"Coin/pkg/blockchain/blockinfodatabase"
 	"Coin/pkg/blockchain/chainwriter"
 	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/utils"
 	"encoding/hex"
+	"time"
 )
 
 // Config is the BlockChain's configuration options.
+// GenesisPublicKey, InitialSubsidy and GenesisTimestamp make up the
+// genesis Block (see GenesisBlock).
+// BlockSubsidy, SubsidyHalvingRate and MaxHalvings are the minting
+// reward a coinbase may claim along with its Block's fees (see
+// Subsidy).
+// DifficultyTarget is the hex-encoded proof of work target every
+// Block's Header must carry, the same one miners mine to (see
+// miner.Config).
+// Magic marks every record the ChainWriter stores (see
+// chainwriter.Config).
+// OrphanPruneDepth is how far below the tip of the main chain a
+// branch that lost a reorg must be buried before its BlockRecords
+// are marked for pruning.
+// PruneDepth is how far below the tip of the main chain a Block must
+// be buried before the files it is stored in may be deleted, or 0 to
+// keep every file. It is never less than the number of unsafe hashes,
+// so Blocks that may still be reverted are kept.
+// PruneTarget is how many megabytes the block and undo files may take
+// up before the oldest are deleted, or 0 to keep them. Files holding
+// Blocks within MaxReorgDepth, or the unsafe hashes, of the tip are
+// always kept, so the node can still reorg and serve recent Blocks to
+// peers.
+// MaxReorgDepth is how many Blocks deep a reorg may go, so UndoBlocks
+// are only needed for that many Blocks below the tip. It is never less
+// than the number of unsafe hashes. A heavier branch that would
+// disconnect more Blocks is refused until an operator confirms it (see
+// ConfirmReorg), since in a small network it may be a long-range
+// attack.
+// PruneUndo is whether to delete the undo files that only hold the
+// UndoBlocks of Blocks buried deeper than MaxReorgDepth.
+// Compression is the algorithm the ChainWriter compresses the Blocks
+// and UndoBlocks it stores with (see chainwriter.Config).
+// SyncInterval is how many Blocks the ChainWriter stores between
+// syncing its files to disk, or 0 to leave it to the operating system
+// (see chainwriter.Config).
+// BlockDirectory and UndoDirectory are where the ChainWriter keeps the
+// block and undo files if not in ChainWriterDBPath, and
+// FilesPerDirectory is how many files it puts in each subdirectory of
+// them (see chainwriter.Config).
+// TxIndex is whether to index Transactions by hash, so they can be
+// looked up without scanning Blocks.
+// MaxOrphanBlocks is how many Blocks that arrived before their parents
+// are kept until their parents arrive, and OrphanExpiry is how long
+// each is kept for (see OrphanPool).
+// Checkpoints are Blocks known to be on the main chain. Branches that
+// conflict with them are rejected, and the scripts and signatures of
+// the Coins spent by Blocks below the last one aren't checked.
+// AssumeValid is the hash of a Block trusted to be on the main chain,
+// or empty to trust none. The scripts and signatures of the Coins it
+// and its ancestors spend aren't checked, though everything else is
+// (see assumedValid).
+// VerifyDepth is how many Blocks below the tip VerifyChain checks when
+// the BlockChain is opened, or 0 to skip it, and VerifyLevel is how
+// thoroughly it checks them.
+// UTXOCommitmentHeight is the height from which every Block's coinbase
+// must commit to the hash of the unspent Coins its parent leaves, so
+// clients can check a snapshot of the Coins against the chain, or 0 to
+// not require it. While it's set, that hash is tracked for every Block
+// connected, even below the height.
+// Deployments are the changes to the consensus rules miners signal
+// for. Signals are counted over periods of DeploymentPeriod Blocks, and
+// a Deployment locks in once DeploymentThreshold Blocks of a period
+// signal for it. DeploymentPeriod is never 0, and DeploymentThreshold
+// never more than it.
+// SnapshotDirectory is where a snapshot of the Coins is written when
+// the active chain reaches a Checkpoint, to serve to peers, or empty to
+// write none, and SnapshotChunkSize is how many Coins each of its
+// chunks holds.
+// IntegrityInterval is how often an IntegrityChecker checks the next
+// IntegrityBlocks Blocks of the active chain against the Coins, or 0
+// to not check them in the background.
 type Config struct {
-	GenesisPublicKey  []byte
-	InitialSubsidy    uint32
-	HasChain          bool
-	BlockInfoDBPath   string
-	ChainWriterDBPath string
-	CoinDBPath        string
+	GenesisPublicKey     []byte
+	InitialSubsidy       uint32
+	GenesisTimestamp     uint32
+	BlockSubsidy         uint32
+	SubsidyHalvingRate   uint32
+	MaxHalvings          uint32
+	DifficultyTarget     string
+	Magic                uint32
+	HasChain             bool
+	BlockInfoDBPath      string
+	ChainWriterDBPath    string
+	CoinDBPath           string
+	OrphanPruneDepth     uint32
+	PruneDepth           uint32
+	PruneTarget          uint32
+	MaxReorgDepth        uint32
+	PruneUndo            bool
+	Compression          string
+	SyncInterval         uint32
+	BlockDirectory       string
+	UndoDirectory        string
+	FilesPerDirectory    uint32
+	TxIndex              bool
+	MaxOrphanBlocks      int
+	OrphanExpiry         time.Duration
+	Checkpoints          []Checkpoint
+	AssumeValid          string
+	VerifyDepth          uint32
+	VerifyLevel          VerifyLevel
+	UTXOCommitmentHeight uint32
+	Deployments          []Deployment
+	DeploymentPeriod     uint32
+	DeploymentThreshold  uint32
+	SnapshotDirectory    string
+	SnapshotChunkSize    int
+	IntegrityInterval    time.Duration
+	IntegrityBlocks      uint32
 }
 
 // GENPK is the public key that was used
@@ -31,11 +135,28 @@ var GENPVK = "307702010104202456b0e8bed5c27dcadb044df1af8eaf714084b61a23d17359fb
 func DefaultConfig() *Config {
 	pkB, _ := hex.DecodeString(GENPK)
 	return &Config{
-		GenesisPublicKey:  pkB,
-		InitialSubsidy:    0,
-		HasChain:          true,
-		BlockInfoDBPath:   blockinfodatabase.DefaultConfig().DatabasePath,
-		ChainWriterDBPath: chainwriter.DefaultConfig().DataDirectory,
-		CoinDBPath:        coindatabase.DefaultConfig().DatabasePath,
+		GenesisPublicKey:    pkB,
+		InitialSubsidy:      0,
+		BlockSubsidy:        50,
+		SubsidyHalvingRate:  10,
+		MaxHalvings:         10,
+		DifficultyTarget:    string(utils.CalcPOWD(-1)),
+		Magic:               chainwriter.DefaultMagic,
+		HasChain:            true,
+		BlockInfoDBPath:     blockinfodatabase.DefaultConfig().DatabasePath,
+		ChainWriterDBPath:   chainwriter.DefaultConfig().DataDirectory,
+		CoinDBPath:          coindatabase.DefaultConfig().DatabasePath,
+		OrphanPruneDepth:    100,
+		MaxReorgDepth:       6,
+		Compression:         chainwriter.DefaultConfig().Compression,
+		SyncInterval:        chainwriter.DefaultConfig().SyncInterval,
+		MaxOrphanBlocks:     100,
+		OrphanExpiry:        20 * time.Minute,
+		VerifyDepth:         6,
+		VerifyLevel:         VerifyCoins,
+		DeploymentPeriod:    144,
+		DeploymentThreshold: 108,
+		SnapshotChunkSize:   1000,
+		IntegrityBlocks:     10,
 	}
 }
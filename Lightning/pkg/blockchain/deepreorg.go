@@ -0,0 +1,117 @@
+package blockchain
+
+import (
+	"Coin/pkg/journal"
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"fmt"
+	"sort"
+)
+
+// RefusedReorg is a branch with more work than the active chain that
+// the BlockChain didn't switch to, because that would have disconnected
+// more Blocks than the Config's MaxReorgDepth. In a small network, a
+// branch like that is more likely to be a long-range attack, mined in
+// private, than an honest fork, so it waits for an operator to confirm
+// it (see ConfirmReorg).
+// Tip is the hash of the branch's last Block, and Height its height.
+// Ancestor is the hash of the last Block the branch shares with the
+// active chain.
+// Depth is how many Blocks of the active chain switching to the branch
+// would have disconnected.
+type RefusedReorg struct {
+	Tip      string
+	Height   uint32
+	Ancestor string
+	Depth    uint32
+}
+
+// reorgDepthLimit returns how many Blocks deep a reorg may go without
+// being confirmed, which is never less than the number of unsafe
+// hashes.
+func (bc *BlockChain) reorgDepthLimit() uint32 {
+	if bc.maxReorgDepth < uint32(bc.maxHashes) {
+		return uint32(bc.maxHashes)
+	}
+	return bc.maxReorgDepth
+}
+
+// refuseDeepReorg returns whether switching the active chain to the
+// branch ending at tipHash would go deeper than the reorgDepthLimit,
+// and if so raises an alert and remembers the branch as a RefusedReorg
+// in place of its parent, which the branch has grown past.
+func (bc *BlockChain) refuseDeepReorg(tipHash string) bool {
+	branch, ancestorHash, err := bc.branchFrom(tipHash)
+	if err != nil {
+		// reorganize reports it
+		return false
+	}
+	depth := bc.Length - bc.BlockInfoDB.GetBlockRecord(ancestorHash).Height
+	if depth <= bc.reorgDepthLimit() {
+		return false
+	}
+	tipBr := bc.BlockInfoDB.GetBlockRecord(tipHash)
+	bc.refusedMutex.Lock()
+	_, seen := bc.refusedReorgs[tipBr.Header.PreviousHash]
+	delete(bc.refusedReorgs, tipBr.Header.PreviousHash)
+	bc.refusedReorgs[tipHash] = &RefusedReorg{Tip: tipHash, Height: tipBr.Height, Ancestor: ancestorHash, Depth: depth}
+	bc.refusedMutex.Unlock()
+	if !seen {
+		utils.Debug.Printf("[blockchain.HandleBlock] ALERT: refused to reorg %v blocks deep to branch {%v} of %v blocks from {%v}, "+
+			"which must be confirmed by an operator", depth, tipHash, len(branch), ancestorHash)
+	}
+	bc.Journal.Record(journal.ReorgRefused, tipHash, fmt.Sprintf("depth %v, ancestor %v", depth, ancestorHash))
+	return true
+}
+
+// RefusedReorgs returns the RefusedReorgs that still have more work
+// than the active chain, in height order.
+func (bc *BlockChain) RefusedReorgs() []*RefusedReorg {
+	bc.refusedMutex.Lock()
+	defer bc.refusedMutex.Unlock()
+	var refused []*RefusedReorg
+	for hash, r := range bc.refusedReorgs {
+		if bc.chainWork(hash).Cmp(bc.CumulativeWork) > 0 {
+			refused = append(refused, r)
+		}
+	}
+	sort.Slice(refused, func(i, j int) bool {
+		if refused[i].Height != refused[j].Height {
+			return refused[i].Height < refused[j].Height
+		}
+		return refused[i].Tip < refused[j].Tip
+	})
+	return refused
+}
+
+// ConfirmReorg switches the active chain to the RefusedReorg ending at
+// hash, however deep that goes, for an operator who has decided the
+// branch is honest. Blocks that extend it later are handled as usual.
+func (bc *BlockChain) ConfirmReorg(hash string) error {
+	bc.refusedMutex.Lock()
+	r, ok := bc.refusedReorgs[hash]
+	delete(bc.refusedReorgs, hash)
+	bc.refusedMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("[ConfirmReorg] no refused reorg to {%v}", hash)
+	}
+	if bc.chainWork(hash).Cmp(bc.CumulativeWork) <= 0 {
+		return fmt.Errorf("[ConfirmReorg] branch {%v} no longer has more work than the active chain", hash)
+	}
+	bc.Journal.Record(journal.ReorgConfirmed, hash, fmt.Sprintf("depth %v, ancestor %v", r.Depth, r.Ancestor))
+	return bc.reorganize(hash)
+}
+
+// EncodeRefusedReorgs returns a pro.RefusedReorgs given RefusedReorgs.
+func EncodeRefusedReorgs(refused []*RefusedReorg) *pro.RefusedReorgs {
+	p := &pro.RefusedReorgs{}
+	for _, r := range refused {
+		p.Reorgs = append(p.Reorgs, &pro.RefusedReorg{
+			Tip:      r.Tip,
+			Height:   r.Height,
+			Ancestor: r.Ancestor,
+			Depth:    r.Depth,
+		})
+	}
+	return p
+}
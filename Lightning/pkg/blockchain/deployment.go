@@ -0,0 +1,297 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+)
+
+// VersionBitsTopBits are the top bits of a Header.Version that signals
+// for Deployments with its low bits (see Deployment.Signals). A Version
+// without them, like that of every Block mined before Deployments,
+// signals for none.
+const VersionBitsTopBits = 0x20000000
+
+// VersionBitsTopMask masks the top bits of a Header.Version, which must
+// equal VersionBitsTopBits for it to signal.
+const VersionBitsTopMask = 0xe0000000
+
+// VersionBitsNumBits is how many low bits of a Header.Version
+// Deployments may signal with.
+const VersionBitsNumBits = 29
+
+// DeploymentState is how far a Deployment is from being enforced. It
+// only changes at the first Block of a period of
+// Config.DeploymentPeriod Blocks, so every Block in a period has the
+// same state.
+type DeploymentState int
+
+const (
+	// DeploymentDefined is the state until the median time past reaches
+	// the Deployment's StartTime.
+	DeploymentDefined DeploymentState = iota
+	// DeploymentStarted is the state while Blocks signal for the
+	// Deployment, until enough of a period's Blocks do.
+	DeploymentStarted
+	// DeploymentLockedIn is the state for the period after the one in
+	// which enough Blocks signaled, so nodes have time to upgrade.
+	DeploymentLockedIn
+	// DeploymentActive is the state from then on, when the new rules
+	// are enforced.
+	DeploymentActive
+	// DeploymentFailed is the state from the first period that starts
+	// after the Deployment's Timeout without it having locked in.
+	DeploymentFailed
+)
+
+// String returns the name of a DeploymentState.
+func (s DeploymentState) String() string {
+	switch s {
+	case DeploymentDefined:
+		return "defined"
+	case DeploymentStarted:
+		return "started"
+	case DeploymentLockedIn:
+		return "locked_in"
+	case DeploymentActive:
+		return "active"
+	case DeploymentFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Deployment is a change to the consensus rules that miners roll out
+// by signaling for it in their Blocks' Header.Versions, so it's only
+// enforced once most of them are ready to enforce it.
+// Name identifies the Deployment.
+// Bit is the bit of the Header.Version that signals for it, below
+// VersionBitsNumBits.
+// StartTime is the median time past from which Blocks' signals count,
+// and Timeout the one from which the Deployment fails if it hasn't
+// locked in.
+type Deployment struct {
+	Name      string
+	Bit       uint8
+	StartTime uint32
+	Timeout   uint32
+}
+
+// Signals returns whether a Header signals for the Deployment.
+func (d *Deployment) Signals(header *block.Header) bool {
+	return header.Version&VersionBitsTopMask == VersionBitsTopBits && header.Version&(1<<d.Bit) != 0
+}
+
+// DeploymentStatus is where a Deployment stands for the next Block of
+// the active chain.
+// Deployment is the Deployment itself.
+// State is its state, and Since is the height of the first Block in
+// that state.
+// Elapsed is how many Blocks of the current period are on the active
+// chain, and Count how many of them signal for it.
+type DeploymentStatus struct {
+	Deployment Deployment
+	State      DeploymentState
+	Since      uint32
+	Elapsed    uint32
+	Count      uint32
+}
+
+// deploymentKey identifies the state of a Deployment in the period
+// after the Block with Hash.
+type deploymentKey struct {
+	Name string
+	Hash string
+}
+
+// DeploymentState returns the state of the Deployment called name for
+// a Block building on the Block with prevHash, which is
+// DeploymentDefined for a Deployment the BlockChain doesn't know.
+func (bc *BlockChain) DeploymentState(name string, prevHash string) DeploymentState {
+	for i := range bc.deployments {
+		if bc.deployments[i].Name == name {
+			return bc.deploymentState(&bc.deployments[i], prevHash)
+		}
+	}
+	return DeploymentDefined
+}
+
+// IsDeploymentActive returns whether the rules of the Deployment called
+// name are enforced for a Block building on the Block with prevHash.
+func (bc *BlockChain) IsDeploymentActive(name string, prevHash string) bool {
+	return bc.DeploymentState(name, prevHash) == DeploymentActive
+}
+
+// deploymentState returns the state of d for a Block building on the
+// Block with prevHash. That's decided by the last Block of the previous
+// period, so the states of the periods before are found first, walking
+// back until one was found before or the Deployment hadn't started,
+// and then worked forward. Every state found is cached by the hash of
+// the Block that decided it, since it never changes.
+func (bc *BlockChain) deploymentState(d *Deployment, prevHash string) DeploymentState {
+	bc.deploymentMutex.Lock()
+	defer bc.deploymentMutex.Unlock()
+	state := DeploymentDefined
+	var periodEnds []string
+	hash := bc.periodEnd(prevHash)
+	for hash != "" {
+		key := deploymentKey{Name: d.Name, Hash: hash}
+		if cached, ok := bc.deploymentStates[key]; ok {
+			state = cached
+			break
+		}
+		if bc.MedianTimePast(hash) < d.StartTime {
+			bc.deploymentStates[key] = DeploymentDefined
+			break
+		}
+		periodEnds = append(periodEnds, hash)
+		hash = bc.ancestor(hash, bc.BlockInfoDB.GetBlockRecord(hash).Height-bc.deploymentPeriod)
+	}
+	for i := len(periodEnds) - 1; i >= 0; i-- {
+		mtp := bc.MedianTimePast(periodEnds[i])
+		switch state {
+		case DeploymentDefined:
+			if mtp >= d.Timeout {
+				state = DeploymentFailed
+			} else if mtp >= d.StartTime {
+				state = DeploymentStarted
+			}
+		case DeploymentStarted:
+			if mtp >= d.Timeout {
+				state = DeploymentFailed
+			} else if count, _ := bc.countSignals(d, periodEnds[i]); count >= bc.deploymentThreshold {
+				state = DeploymentLockedIn
+			}
+		case DeploymentLockedIn:
+			state = DeploymentActive
+		}
+		bc.deploymentStates[deploymentKey{Name: d.Name, Hash: periodEnds[i]}] = state
+	}
+	return state
+}
+
+// periodEnd returns the hash of the last Block of the period before the
+// one a Block building on the Block with prevHash is in, or nothing if
+// that Block is in the first period.
+func (bc *BlockChain) periodEnd(prevHash string) string {
+	if !bc.BlockInfoDB.HasBlockRecord(prevHash) {
+		return ""
+	}
+	height := bc.BlockInfoDB.GetBlockRecord(prevHash).Height
+	return bc.ancestor(prevHash, height-height%bc.deploymentPeriod)
+}
+
+// ancestor returns the hash of the Block at height on the chain ending
+// at hash, or nothing if there is none.
+func (bc *BlockChain) ancestor(hash string, height uint32) string {
+	if height == 0 || !bc.BlockInfoDB.HasBlockRecord(hash) {
+		return ""
+	}
+	br := bc.BlockInfoDB.GetBlockRecord(hash)
+	if height > br.Height {
+		return ""
+	}
+	if bc.onMainChain(hash, br) {
+		return bc.BlockInfoDB.GetHashByHeight(height)
+	}
+	for br.Height > height {
+		hash = br.Header.PreviousHash
+		if !bc.BlockInfoDB.HasBlockRecord(hash) {
+			return ""
+		}
+		br = bc.BlockInfoDB.GetBlockRecord(hash)
+	}
+	return hash
+}
+
+// countSignals returns how many Blocks signal for d in the period
+// ending at the Block with hash, or so far if it hasn't ended, and how
+// many Blocks of it that is.
+func (bc *BlockChain) countSignals(d *Deployment, hash string) (uint32, uint32) {
+	count, elapsed := uint32(0), uint32(0)
+	for hash != "" && bc.BlockInfoDB.HasBlockRecord(hash) {
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		if elapsed > 0 && br.Height%bc.deploymentPeriod == 0 {
+			break
+		}
+		if d.Signals(br.Header) {
+			count++
+		}
+		elapsed++
+		hash = br.Header.PreviousHash
+	}
+	return count, elapsed
+}
+
+// DeploymentStatuses returns where every Deployment stands for the next
+// Block of the active chain.
+func (bc *BlockChain) DeploymentStatuses() []*DeploymentStatus {
+	var statuses []*DeploymentStatus
+	for i := range bc.deployments {
+		d := &bc.deployments[i]
+		status := &DeploymentStatus{Deployment: *d, State: bc.deploymentState(d, bc.LastHash)}
+		// the state began at the first period whose previous period
+		// ended in another state
+		status.Since = bc.Length - bc.Length%bc.deploymentPeriod + 1
+		for status.Since > bc.deploymentPeriod {
+			prevEnd := bc.ancestor(bc.LastHash, status.Since-1-bc.deploymentPeriod)
+			if bc.deploymentState(d, prevEnd) != status.State {
+				break
+			}
+			status.Since -= bc.deploymentPeriod
+		}
+		if bc.Length%bc.deploymentPeriod != 0 {
+			status.Count, status.Elapsed = bc.countSignals(d, bc.LastHash)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// DeploymentPeriod returns how many Blocks signals are counted over,
+// and how many of them must signal for a Deployment to lock in.
+func (bc *BlockChain) DeploymentPeriod() (uint32, uint32) {
+	return bc.deploymentPeriod, bc.deploymentThreshold
+}
+
+// BlockVersion returns the Header.Version the next Block of the active
+// chain should have, signaling for every Deployment that has started
+// or locked in. Without any Deployments, it's 0, like every Block's
+// before them.
+func (bc *BlockChain) BlockVersion() uint32 {
+	if len(bc.deployments) == 0 {
+		return 0
+	}
+	version := uint32(VersionBitsTopBits)
+	for i := range bc.deployments {
+		switch bc.deploymentState(&bc.deployments[i], bc.LastHash) {
+		case DeploymentStarted, DeploymentLockedIn:
+			version |= 1 << bc.deployments[i].Bit
+		}
+	}
+	return version
+}
+
+// EncodeDeploymentStatuses returns a pro.Deployments given the
+// DeploymentStatuses, and the period and threshold they're counted
+// over.
+func EncodeDeploymentStatuses(statuses []*DeploymentStatus, period uint32, threshold uint32) *pro.Deployments {
+	var deployments []*pro.Deployment
+	for _, s := range statuses {
+		deployments = append(deployments, &pro.Deployment{
+			Name:      s.Deployment.Name,
+			Bit:       uint32(s.Deployment.Bit),
+			StartTime: s.Deployment.StartTime,
+			Timeout:   s.Deployment.Timeout,
+			State:     s.State.String(),
+			Since:     s.Since,
+			Elapsed:   s.Elapsed,
+			Count:     s.Count,
+		})
+	}
+	return &pro.Deployments{
+		Period:      period,
+		Threshold:   threshold,
+		Deployments: deployments,
+	}
+}
@@ -0,0 +1,221 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxFutureBlockTime is how far ahead of the local clock a Block's
+// Timestamp may be, in seconds. Clocks drift, so a little is allowed,
+// but a Block from further in the future was timestamped to game
+// time-based rules.
+const MaxFutureBlockTime = 2 * 60 * 60
+
+// MedianTimeSpan is how many Blocks the median time past is taken
+// over.
+const MedianTimeSpan = 11
+
+// ValidateHeader is the first stage of validating a Block, which only
+// needs its Header, so a bad Block can be rejected before its body is
+// fetched or stored. It returns an error if:
+// (1) the Header is malformed
+// (2) its DifficultyTarget isn't the BlockChain's, or its hash doesn't
+// meet it
+// (3) its Timestamp is more than MaxFutureBlockTime ahead of the clock
+// (4) its PreviousHash isn't a Block the BlockChain knows about, in
+// which case the error is an *UnknownParentError
+// (5) its Timestamp isn't after the median time past of the chain it
+// builds on
+// (6) it conflicts with a Checkpoint (see checkCheckpoints)
+// Together, (3) and (5) keep a miner from moving a Block's time far
+// from the real time in either direction.
+func (bc *BlockChain) ValidateHeader(header *block.Header) error {
+	if err := bc.checkHeader(header); err != nil {
+		return err
+	}
+	if !bc.BlockInfoDB.HasBlockRecord(header.PreviousHash) {
+		return &UnknownParentError{PreviousHash: header.PreviousHash}
+	}
+	if mtp := bc.MedianTimePast(header.PreviousHash); header.Timestamp <= mtp {
+		return block.Reject(block.RejectTimeTooOld, "[blockchain.ValidateHeader] timestamp %v is not after the median time past %v", header.Timestamp, mtp)
+	}
+	if err := bc.checkCheckpoints(header, bc.BlockInfoDB.GetBlockRecord(header.PreviousHash).Height+1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkHeader runs checks (1) to (3) of ValidateHeader, which don't
+// depend on the chain the Header builds on. A Header's own target is
+// only trusted once it's the one expected, or a miner could claim an
+// easy one.
+func (bc *BlockChain) checkHeader(header *block.Header) error {
+	if err := block.ValidateHeader(header); err != nil {
+		return err
+	}
+	if header.DifficultyTarget != bc.difficultyTarget {
+		return block.Reject(block.RejectBadPoW, "[blockchain.ValidateHeader] difficulty target {%v} is not {%v}", header.DifficultyTarget, bc.difficultyTarget)
+	}
+	if err := block.ValidateProofOfWork(header); err != nil {
+		return err
+	}
+	if limit := time.Now().Unix() + MaxFutureBlockTime; int64(header.Timestamp) > limit {
+		return block.Reject(block.RejectTimeTooNew, "[blockchain.ValidateHeader] timestamp %v is more than %v seconds in the future", header.Timestamp, MaxFutureBlockTime)
+	}
+	return nil
+}
+
+// UnknownParentError is returned by ValidateHeader when a Header's
+// PreviousHash isn't a Block the BlockChain knows about, which may
+// only mean that its parent hasn't arrived yet. Every other check
+// that doesn't need the parent passed.
+type UnknownParentError struct {
+	PreviousHash string
+}
+
+// Error returns a description of the missing parent.
+func (e *UnknownParentError) Error() string {
+	return fmt.Sprintf("[blockchain.ValidateHeader] previous block {%v} is unknown", e.PreviousHash)
+}
+
+// IsUnknownParent returns whether err is an *UnknownParentError.
+func IsUnknownParent(err error) bool {
+	_, ok := err.(*UnknownParentError)
+	return ok
+}
+
+// MedianTimePast returns the median Timestamp of the last
+// MedianTimeSpan Blocks of the chain ending at hash, or of all of them
+// if the chain is shorter. Unlike the Timestamp of any one Block, it
+// only ever moves forward along a chain, and no single miner can move
+// it, so it is what time-based rules, like LockTimes, are checked
+// against.
+func (bc *BlockChain) MedianTimePast(hash string) uint32 {
+	return medianTime(bc.appendTimestamps(nil, hash))
+}
+
+// appendTimestamps appends the Timestamps of the Blocks of the chain
+// ending at hash, newest first, until there are MedianTimeSpan of them.
+func (bc *BlockChain) appendTimestamps(timestamps []uint32, hash string) []uint32 {
+	for hash != "" && len(timestamps) < MedianTimeSpan && bc.BlockInfoDB.HasBlockRecord(hash) {
+		header := bc.BlockInfoDB.GetBlockRecord(hash).Header
+		timestamps = append(timestamps, header.Timestamp)
+		hash = header.PreviousHash
+	}
+	return timestamps
+}
+
+// medianTime returns the median of timestamps, or 0 if there are none.
+func medianTime(timestamps []uint32) uint32 {
+	if len(timestamps) == 0 {
+		return 0
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2]
+}
+
+// IsFinal returns whether a Transaction's LockTime, and the relative
+// locks of its inputs, allow it into the next Block of the active chain
+// (see block.IsFinal and block.RelativeLock). Time LockTimes are checked
+// against the active chain's median time past, so a miner can't get a
+// Transaction in early by timestamping its Block ahead.
+func (bc *BlockChain) IsFinal(tx *block.Transaction) bool {
+	return block.IsFinal(tx, bc.Length+1, bc.MedianTimePast(bc.LastHash)) &&
+		bc.checkSequenceLocks([]*block.Transaction{tx}, bc.LastHash, bc.Length+1) == nil
+}
+
+// checkBlockContext returns an error if a Block at height breaks a rule
+// that depends on where it is in the chain: every coinbase must commit
+// to its height (see block.ValidateCoinbaseHeight), and the LockTime of
+// every Transaction must have been reached, judged by its height and
+// its parent's median time past.
+func (bc *BlockChain) checkBlockContext(b *block.Block, height uint32) error {
+	if err := block.ValidateCoinbaseHeight(b, height); err != nil {
+		return err
+	}
+	mtp := bc.MedianTimePast(b.Header.PreviousHash)
+	for i, tx := range b.Transactions {
+		if !block.IsFinal(tx, height, mtp) {
+			return block.Reject(block.RejectNonFinal, "[blockchain.checkBlockContext] transaction %v has lock time %v, not reached at height %v with median time past %v", i, tx.LockTime, height, mtp)
+		}
+	}
+	return bc.checkSequenceLocks(b.Transactions, b.Header.PreviousHash, height)
+}
+
+// checkSequenceLocks returns an error if any of the Transactions, in a
+// Block at height building on the Block with prevHash, spends a Coin
+// before its relative lock (see block.RelativeLock) is reached. A
+// Coin's age is found by walking back from prevHash until every
+// locked input is either old enough or spends a Coin created by the
+// Block walked back to, so only as many Blocks as the longest lock
+// needs are read.
+func (bc *BlockChain) checkSequenceLocks(txs []*block.Transaction, prevHash string, height uint32) error {
+	// Coins created in the same Block have no age at all
+	var pending []*block.TransactionInput
+	created := make(map[string]bool)
+	for i, tx := range txs {
+		for _, txi := range tx.Inputs {
+			if lock, _ := block.RelativeLock(txi); lock == 0 {
+				continue
+			}
+			if created[txi.ReferenceTransactionHash] {
+				return block.Reject(block.RejectNonFinal, "[blockchain.checkSequenceLocks] transaction %v spends a coin from the same block before its relative lock", i)
+			}
+			pending = append(pending, txi)
+		}
+		created[tx.Hash()] = true
+	}
+	mtp := bc.MedianTimePast(prevHash)
+	hash, h := prevHash, height-1
+	for len(pending) > 0 && bc.BlockInfoDB.HasBlockRecord(hash) {
+		header := bc.BlockInfoDB.GetBlockRecord(hash).Header
+		// a Coin's time is the median time past when it was created
+		createdAt := bc.MedianTimePast(header.PreviousHash)
+		var locked []*block.TransactionInput
+		for _, txi := range pending {
+			lock, byTime := block.RelativeLock(txi)
+			if (!byTime && height-h < lock) || (byTime && mtp-createdAt < lock) {
+				locked = append(locked, txi)
+			}
+		}
+		if len(locked) > 0 {
+			txHashes, err := bc.createdTransactions(hash)
+			if err != nil {
+				return fmt.Errorf("[blockchain.checkSequenceLocks] %v", err)
+			}
+			for _, txHash := range txHashes {
+				for _, txi := range locked {
+					if txi.ReferenceTransactionHash == txHash {
+						return block.Reject(block.RejectNonFinal, "[blockchain.checkSequenceLocks] coin {%v} created at height %v is still locked at height %v", txHash, h, height)
+					}
+				}
+			}
+		}
+		pending = locked
+		hash, h = header.PreviousHash, h-1
+	}
+	return nil
+}
+
+// createdTransactions returns the hashes of the Transactions in the
+// Block with hash, which is all checkSequenceLocks and checkVaults need
+// from the Blocks they walk back over. They're recorded as the Block
+// is stored, so they outlive its body when it's pruned, and only a
+// Block stored before they were is read. It returns an error if
+// neither has them.
+func (bc *BlockChain) createdTransactions(hash string) ([]string, error) {
+	if txHashes, ok := bc.BlockInfoDB.GetCreatedTransactions(hash); ok {
+		return txHashes, nil
+	}
+	b := bc.GetBlock(hash)
+	if b == nil {
+		return nil, fmt.Errorf("unable to read the transactions of block {%v}", hash)
+	}
+	var txHashes []string
+	for _, tx := range b.Transactions {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	return txHashes, nil
+}
@@ -0,0 +1,243 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// HeaderTree holds the Headers of Blocks that haven't been downloaded
+// yet, so that the chain with the most work can be found from Headers
+// alone, which are cheap to fetch and validate, before any Block is
+// fetched. Headers are validated against the Headers and Blocks they
+// build on as they're added, so only Blocks whose Headers are valid are
+// ever asked for.
+// entries maps the hash of each Header to it, and best is the hash of
+// the Header that ends the chain with the most work.
+type HeaderTree struct {
+	bc      *BlockChain
+	entries map[string]*headerEntry
+	best    string
+	mutex   sync.Mutex
+}
+
+// headerEntry is a Header in the HeaderTree, along with its height and
+// the work of the chain it ends.
+type headerEntry struct {
+	header    *block.Header
+	height    uint32
+	chainWork *big.Int
+}
+
+// newHeaderTree returns an empty HeaderTree for bc.
+func newHeaderTree(bc *BlockChain) *HeaderTree {
+	return &HeaderTree{
+		bc:      bc,
+		entries: make(map[string]*headerEntry),
+	}
+}
+
+// Add validates a Header as ValidateHeader does, except that the
+// Header may build on another Header in the tree rather than on a
+// stored Block, and adds it to the tree. Headers the tree already
+// holds, or whose Blocks are already stored, are ignored.
+func (ht *HeaderTree) Add(header *block.Header) error {
+	if err := ht.add(header); err != nil {
+		return err
+	}
+	ht.bc.findAssumeValid((&block.Block{Header: header}).Hash())
+	return nil
+}
+
+// add is Add, without looking for the BlockChain's assumeValid Block,
+// whose ancestors can only be found once the tree is unlocked.
+func (ht *HeaderTree) add(header *block.Header) error {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	if header == nil {
+		return fmt.Errorf("[HeaderTree.Add] header is missing")
+	}
+	hash := (&block.Block{Header: header}).Hash()
+	if _, ok := ht.entries[hash]; ok || ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+		return nil
+	}
+	if err := ht.bc.checkHeader(header); err != nil {
+		return err
+	}
+	parent, err := ht.lookup(header.PreviousHash)
+	if err != nil {
+		return err
+	}
+	if mtp := medianTime(ht.timestamps(header.PreviousHash)); header.Timestamp <= mtp {
+		return fmt.Errorf("[HeaderTree.Add] timestamp %v is not after the median time past %v", header.Timestamp, mtp)
+	}
+	if err = ht.bc.checkCheckpoints(header, parent.height+1); err != nil {
+		return err
+	}
+	entry := &headerEntry{
+		header:    header,
+		height:    parent.height + 1,
+		chainWork: new(big.Int).Add(parent.chainWork, BlockWork(header)),
+	}
+	ht.entries[hash] = entry
+	if best, ok := ht.entries[ht.best]; !ok || entry.chainWork.Cmp(best.chainWork) > 0 {
+		ht.best = hash
+	}
+	return nil
+}
+
+// lookup returns the entry for the Header with hash, whether it's in
+// the tree or its Block is stored. It returns an *UnknownParentError if
+// it's neither, and an error if its Block failed validation.
+func (ht *HeaderTree) lookup(hash string) (*headerEntry, error) {
+	if entry, ok := ht.entries[hash]; ok {
+		return entry, nil
+	}
+	if !ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+		return nil, &UnknownParentError{PreviousHash: hash}
+	}
+	br := ht.bc.BlockInfoDB.GetBlockRecord(hash)
+	if br.Status.Has(blockinfodatabase.StatusFailed) {
+		return nil, fmt.Errorf("[HeaderTree.Add] previous block {%v} is invalid", hash)
+	}
+	return &headerEntry{header: br.Header, height: br.Height, chainWork: ht.bc.chainWork(hash)}, nil
+}
+
+// timestamps returns the Timestamps of the last MedianTimeSpan Headers
+// of the chain ending at hash, following the tree and then the stored
+// Blocks.
+func (ht *HeaderTree) timestamps(hash string) []uint32 {
+	var timestamps []uint32
+	for len(timestamps) < MedianTimeSpan {
+		entry, ok := ht.entries[hash]
+		if !ok {
+			break
+		}
+		timestamps = append(timestamps, entry.header.Timestamp)
+		hash = entry.header.PreviousHash
+	}
+	return ht.bc.appendTimestamps(timestamps, hash)
+}
+
+// Len returns how many Headers are in the tree.
+func (ht *HeaderTree) Len() int {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	return len(ht.entries)
+}
+
+// Missing returns the hashes of the Blocks that haven't been stored
+// on the chain of Headers with the most work, in height order, or
+// nothing if that chain doesn't have more work than the active chain.
+func (ht *HeaderTree) Missing() []string {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	best, ok := ht.entries[ht.best]
+	if !ok || best.chainWork.Cmp(ht.bc.CumulativeWork) <= 0 {
+		return nil
+	}
+	var missing []string
+	for hash := ht.best; ; {
+		entry, ok := ht.entries[hash]
+		if !ok || ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+			break
+		}
+		missing = append(missing, hash)
+		hash = entry.header.PreviousHash
+	}
+	return reverseHashes(missing)
+}
+
+// chain returns the entries of the Headers from the one that builds on
+// the active chain's tip up to hash, in height order. It returns an
+// error if the Header with hash isn't in the tree, or its chain doesn't
+// build on the tip.
+func (ht *HeaderTree) chain(hash string) ([]*headerEntry, error) {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	var entries []*headerEntry
+	for hash != ht.bc.LastHash {
+		entry, ok := ht.entries[hash]
+		if !ok {
+			return nil, fmt.Errorf("[HeaderTree.chain] header {%v} doesn't build on the tip {%v}", hash, ht.bc.LastHash)
+		}
+		entries = append(entries, entry)
+		hash = entry.header.PreviousHash
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ancestors returns the hashes of the Header with hash and every
+// Header below it, by height, following the tree and then the stored
+// Blocks. It returns nil if the Header isn't known.
+func (ht *HeaderTree) ancestors(hash string) map[uint32]string {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	hashes := make(map[uint32]string)
+	for hash != "" {
+		entry, err := ht.lookup(hash)
+		if err != nil {
+			return nil
+		}
+		hashes[entry.height] = hash
+		hash = entry.header.PreviousHash
+	}
+	return hashes
+}
+
+// Prune removes the Headers whose Blocks have been stored, since the
+// BlockChain knows about them now.
+func (ht *HeaderTree) Prune() {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	for hash := range ht.entries {
+		if ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+			delete(ht.entries, hash)
+		}
+	}
+}
+
+// Locator returns a block locator for the chain ending at hash, or at
+// the Header with the most work if hash is empty: the hashes of its
+// last 10 Blocks, newest first, and then of Blocks further and further
+// apart, doubling the step each time, down to the genesis Block. A peer
+// finds the newest of them on its own main chain, and sends the Headers
+// after it (see BlockInfoDatabase.GetHeaders), so the locator finds
+// where the chains fork in a single request however far back that is.
+func (ht *HeaderTree) Locator(hash string) []string {
+	ht.mutex.Lock()
+	defer ht.mutex.Unlock()
+	if hash == "" {
+		hash = ht.best
+	}
+	if _, ok := ht.entries[hash]; !ok && !ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+		hash = ht.bc.LastHash
+	}
+	var locator []string
+	step, sinceLast := 1, 0
+	for hash != "" {
+		var header *block.Header
+		if entry, ok := ht.entries[hash]; ok {
+			header = entry.header
+		} else if ht.bc.BlockInfoDB.HasBlockRecord(hash) {
+			header = ht.bc.BlockInfoDB.GetBlockRecord(hash).Header
+		} else {
+			break
+		}
+		if sinceLast == 0 || header.PreviousHash == "" {
+			locator = append(locator, hash)
+			if len(locator) >= 10 {
+				step *= 2
+			}
+			sinceLast = step
+		}
+		sinceLast--
+		hash = header.PreviousHash
+	}
+	return locator
+}
@@ -0,0 +1,200 @@
+package blockchain
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/utils"
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IntegrityChecker cross-checks the CoinDatabase against the Blocks
+// and UndoBlocks of the active chain in the background, a few Blocks
+// at a time, so corruption is caught long before it's stumbled on.
+// Each step checks the next Blocks down from where the last one
+// stopped, starting again from the tip once it reaches the genesis
+// Block or a pruned Block. For each Block, it checks that:
+// (1) the Block can be read, and has the hash it's stored under
+// (2) its UndoBlock can be read, and matches the Block's inputs
+// (3) none of the Coins its UndoBlock restores are unspent, since the
+// Block spent them
+// (4) every Coin it created that's still unspent has the amount and
+// locking script of the output it was created from.
+// Unlike VerifyChain, it never holds more than a step's Blocks in
+// mind, so it can run forever without slowing the node down.
+// Alerts receives every inconsistency it finds.
+// lock is held during each step, so Blocks aren't handled while a
+// step reads the CoinDatabase.
+// interval is how long it waits between steps, and blocksPerStep how
+// many Blocks each step checks.
+// next and nextHeight are the Block the next step starts from, or
+// empty to start from the tip.
+// passes is how many times it has checked the whole chain.
+// mutex guards next, nextHeight, passes and stop.
+type IntegrityChecker struct {
+	Alerts chan *ChainError
+
+	bc            *BlockChain
+	lock          sync.Locker
+	interval      time.Duration
+	blocksPerStep uint32
+
+	mutex      sync.Mutex
+	next       string
+	nextHeight uint32
+	passes     uint32
+	stop       chan bool
+}
+
+// NewIntegrityChecker returns an IntegrityChecker for the BlockChain,
+// using the Config's IntegrityInterval and IntegrityBlocks, that holds
+// lock while it checks.
+func (bc *BlockChain) NewIntegrityChecker(lock sync.Locker) *IntegrityChecker {
+	blocksPerStep := bc.integrityBlocks
+	if blocksPerStep == 0 {
+		blocksPerStep = 1
+	}
+	return &IntegrityChecker{
+		Alerts:        make(chan *ChainError),
+		bc:            bc,
+		lock:          lock,
+		interval:      bc.integrityInterval,
+		blocksPerStep: blocksPerStep,
+	}
+}
+
+// Passes returns how many times the IntegrityChecker has checked
+// every Block it can, from the tip down.
+func (ic *IntegrityChecker) Passes() uint32 {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+	return ic.passes
+}
+
+// Step checks the next Blocks, stopping early if it gets to the end of
+// a pass, and returns the inconsistencies it found, which are sent to
+// Alerts too.
+func (ic *IntegrityChecker) Step() []*ChainError {
+	ic.lock.Lock()
+	ic.mutex.Lock()
+	var errs []*ChainError
+	passes := ic.passes
+	for i := uint32(0); i < ic.blocksPerStep && ic.passes == passes; i++ {
+		errs = append(errs, ic.checkNext()...)
+	}
+	ic.mutex.Unlock()
+	ic.lock.Unlock()
+	for _, err := range errs {
+		utils.Debug.Printf("[blockchain.IntegrityChecker] %v", err)
+		go func(err *ChainError) {
+			ic.Alerts <- err
+		}(err)
+	}
+	return errs
+}
+
+// checkNext checks the Block the IntegrityChecker is at, and moves it
+// on to its parent, or back to the tip.
+func (ic *IntegrityChecker) checkNext() []*ChainError {
+	bc := ic.bc
+	// a reorg may have taken the Block off the active chain
+	if ic.next == "" || ic.nextHeight > bc.Length || bc.BlockInfoDB.GetHashByHeight(ic.nextHeight) != ic.next {
+		ic.next, ic.nextHeight = bc.LastHash, bc.Length
+	}
+	hash, height := ic.next, ic.nextHeight
+	var errs []*ChainError
+	report := func(err error) {
+		errs = append(errs, &ChainError{Hash: hash, Height: height, Err: err})
+	}
+	restart := func() []*ChainError {
+		ic.next, ic.nextHeight = "", 0
+		ic.passes++
+		return errs
+	}
+	if !bc.BlockInfoDB.HasBlockRecord(hash) {
+		report(fmt.Errorf("no block record"))
+		return restart()
+	}
+	br := bc.BlockInfoDB.GetBlockRecord(hash)
+	if br.Status.Has(blockinfodatabase.StatusPruned) {
+		return restart()
+	}
+	// (1) read the block
+	b, err := bc.readBlock(br)
+	if err != nil {
+		report(err)
+		return restart()
+	}
+	if b.Hash() != hash {
+		report(fmt.Errorf("stored block has hash {%v}", b.Hash()))
+		return restart()
+	}
+	if height <= 1 {
+		return restart()
+	}
+	// (2) read the undo block
+	if !br.Status.Has(blockinfodatabase.StatusUndoPruned) {
+		ub, err := bc.getUndoBlock(hash)
+		if err == nil {
+			err = coindatabase.CheckUndoBlock(b, ub)
+		}
+		if err != nil {
+			report(err)
+		} else {
+			// (3) the coins it spent are gone
+			for i, txHash := range ub.TransactionInputHashes {
+				cl := coindatabase.CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: ub.OutputIndexes[i]}
+				if coin := bc.CoinDB.GetCoin(cl); coin != nil && !coin.IsSpent {
+					report(fmt.Errorf("spent output %v of transaction {%v} is unspent in the coins", cl.OutputIndex, txHash))
+				}
+			}
+		}
+	}
+	// (4) the coins it created that are left match its outputs
+	for _, tx := range b.Transactions {
+		txHash := tx.Hash()
+		for i, txo := range tx.Outputs {
+			cl := coindatabase.CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: uint32(i)}
+			coin := bc.CoinDB.GetCoin(cl)
+			if coin == nil || coin.IsSpent {
+				continue
+			}
+			if coin.TransactionOutput.Amount != txo.Amount || !bytes.Equal(coin.TransactionOutput.LockingScript, txo.LockingScript) {
+				report(fmt.Errorf("output %v of transaction {%v} differs from its coin", i, txHash))
+			}
+		}
+	}
+	ic.next, ic.nextHeight = b.Header.PreviousHash, height-1
+	return errs
+}
+
+// Start calls Step every interval until Stop is called.
+func (ic *IntegrityChecker) Start() {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+	ic.stop = make(chan bool)
+	go func(stop chan bool) {
+		ticker := time.NewTicker(ic.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ic.Step()
+			case <-stop:
+				return
+			}
+		}
+	}(ic.stop)
+}
+
+// Stop stops the IntegrityChecker started by Start.
+func (ic *IntegrityChecker) Stop() {
+	ic.mutex.Lock()
+	defer ic.mutex.Unlock()
+	if ic.stop != nil {
+		close(ic.stop)
+		ic.stop = nil
+	}
+}
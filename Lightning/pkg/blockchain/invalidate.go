@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/journal"
+	"fmt"
+	"math/big"
+)
+
+// InvalidateBlock marks the Block with hash, and every Block descending
+// from it, as invalid, as though it had failed validation, and switches
+// the active chain to the valid chain with the most work if it included
+// the Block, even if that chain has less work. The genesis Block can't
+// be invalidated. This is for operators recovering from consensus bugs,
+// so a Block that was wrongly accepted can be rejected without
+// rebuilding the chain; ReconsiderBlock undoes it.
+func (bc *BlockChain) InvalidateBlock(hash string) error {
+	if !bc.BlockInfoDB.HasBlockRecord(hash) {
+		return fmt.Errorf("[InvalidateBlock] no block record for {%v}", hash)
+	}
+	if bc.BlockInfoDB.GetBlockRecord(hash).Header.PreviousHash == "" {
+		return fmt.Errorf("[InvalidateBlock] the genesis block can't be invalidated")
+	}
+	marked, err := bc.BlockInfoDB.MarkFailed(hash)
+	if err != nil {
+		return err
+	}
+	bc.Journal.Record(journal.BlockInvalidated, hash, fmt.Sprintf("%v blocks", len(marked)))
+	return bc.activateBestChain()
+}
+
+// ReconsiderBlock clears the invalid marking from the Block with hash,
+// from every Block descending from it, and from every Block it descends
+// from (see ClearFailed), and switches the active chain to the chain
+// with the most work if that is now one of theirs. Blocks that really
+// are invalid fail again when they are connected, so reconsidering a
+// Block is always safe.
+func (bc *BlockChain) ReconsiderBlock(hash string) error {
+	cleared, err := bc.BlockInfoDB.ClearFailed(hash)
+	if err != nil {
+		return err
+	}
+	bc.Journal.Record(journal.BlockReconsidered, hash, fmt.Sprintf("%v blocks", len(cleared)))
+	return bc.activateBestChain()
+}
+
+// activateBestChain reorganizes onto the valid chain with the most
+// work, until the active chain is that chain. Each time a Block on it
+// turns out to be invalid, it's marked as failed, and the next best
+// chain is tried.
+func (bc *BlockChain) activateBestChain() error {
+	for {
+		best := bc.bestValidTip()
+		if best == "" {
+			return fmt.Errorf("[activateBestChain] no valid chain")
+		}
+		tipFailed := bc.BlockInfoDB.GetBlockRecord(bc.LastHash).Status.Has(blockinfodatabase.StatusFailed)
+		if best == bc.LastHash || !tipFailed && bc.chainWork(best).Cmp(bc.CumulativeWork) <= 0 {
+			return nil
+		}
+		lastHash := bc.LastHash
+		bc.reorganize(best)
+		if bc.LastHash == lastHash && !bc.BlockInfoDB.GetBlockRecord(best).Status.Has(blockinfodatabase.StatusFailed) {
+			// nothing was marked as failed, so trying again would fail
+			// the same way
+			return fmt.Errorf("[activateBestChain] unable to switch to block {%v}", best)
+		}
+	}
+}
+
+// bestValidTip returns the hash of the Block with the most chain work
+// that hasn't failed validation and can still be read, preferring the
+// active chain's tip, and then the lowest hash, among Blocks with the
+// same work.
+func (bc *BlockChain) bestValidTip() string {
+	var bestHash string
+	var bestWork *big.Int
+	for hash, br := range bc.BlockInfoDB.GetAllBlockRecords() {
+		if br.Status.Has(blockinfodatabase.StatusFailed) || br.Status.Has(blockinfodatabase.StatusPruned) {
+			continue
+		}
+		work := chainWorkOf(br)
+		if bestHash == "" {
+			bestHash, bestWork = hash, work
+			continue
+		}
+		switch cmp := work.Cmp(bestWork); {
+		case cmp > 0, cmp == 0 && hash == bc.LastHash, cmp == 0 && bestHash != bc.LastHash && hash < bestHash:
+			bestHash, bestWork = hash, work
+		}
+	}
+	return bestHash
+}
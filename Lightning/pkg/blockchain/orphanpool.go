@@ -0,0 +1,189 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"sync"
+	"time"
+)
+
+// OrphanPool holds Blocks that arrived before their parents, so they
+// can be handled once their parents are, instead of being dropped and
+// fetched again. Blocks are kept by their PreviousHash, so all of a
+// parent's children are found at once. The pool is bounded: Blocks
+// older than expiry are evicted, and once it holds maxBlocks Blocks,
+// the oldest is evicted to make room for a new one.
+// orphans maps each Block's hash to it, and children maps each
+// PreviousHash to the hashes of the Blocks waiting for it.
+// added counts the Blocks ever added, to order them by.
+type OrphanPool struct {
+	maxBlocks int
+	expiry    time.Duration
+	orphans   map[string]*orphan
+	children  map[string][]string
+	added     uint64
+	mutex     sync.Mutex
+}
+
+// orphan is a Block in the OrphanPool, along with when it was added,
+// and how many Blocks were added before it.
+type orphan struct {
+	block *block.Block
+	added time.Time
+	seq   uint64
+}
+
+// NewOrphanPool returns an empty OrphanPool that holds at most
+// maxBlocks Blocks, for at most expiry each.
+func NewOrphanPool(maxBlocks int, expiry time.Duration) *OrphanPool {
+	return &OrphanPool{
+		maxBlocks: maxBlocks,
+		expiry:    expiry,
+		orphans:   make(map[string]*orphan),
+		children:  make(map[string][]string),
+	}
+}
+
+// Add adds a Block whose parent is unknown to the pool, first evicting
+// the Blocks that have expired and, if the pool is still full, the
+// oldest Block. It returns false if the Block was already in the pool,
+// or the pool can't hold any Blocks.
+func (op *OrphanPool) Add(b *block.Block) bool {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	hash := b.Hash()
+	if _, ok := op.orphans[hash]; ok || op.maxBlocks <= 0 {
+		return false
+	}
+	now := time.Now()
+	op.expire(now)
+	for len(op.orphans) >= op.maxBlocks {
+		op.remove(op.oldest())
+	}
+	op.orphans[hash] = &orphan{block: b, added: now, seq: op.added}
+	op.added++
+	op.children[b.Header.PreviousHash] = append(op.children[b.Header.PreviousHash], hash)
+	return true
+}
+
+// Has returns whether the Block with hash is in the pool.
+func (op *OrphanPool) Has(hash string) bool {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	_, ok := op.orphans[hash]
+	return ok
+}
+
+// Len returns how many Blocks are in the pool.
+func (op *OrphanPool) Len() int {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	return len(op.orphans)
+}
+
+// Take removes the Blocks waiting for the Block with parentHash from
+// the pool, and returns them in the order they were added.
+func (op *OrphanPool) Take(parentHash string) []*block.Block {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	var blocks []*block.Block
+	for _, hash := range op.children[parentHash] {
+		blocks = append(blocks, op.orphans[hash].block)
+		delete(op.orphans, hash)
+	}
+	delete(op.children, parentHash)
+	return blocks
+}
+
+// Expire evicts the Blocks that have been in the pool for longer than
+// its expiry, and returns how many it evicted.
+func (op *OrphanPool) Expire() int {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	return op.expire(time.Now())
+}
+
+// expire is Expire, for when the caller holds the mutex.
+func (op *OrphanPool) expire(now time.Time) int {
+	if op.expiry <= 0 {
+		return 0
+	}
+	evicted := 0
+	for hash, o := range op.orphans {
+		if now.Sub(o.added) > op.expiry {
+			op.remove(hash)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// oldest returns the hash of the Block that has been in the pool the
+// longest.
+func (op *OrphanPool) oldest() string {
+	var oldestHash string
+	var oldestSeq uint64
+	for hash, o := range op.orphans {
+		if oldestHash == "" || o.seq < oldestSeq {
+			oldestHash, oldestSeq = hash, o.seq
+		}
+	}
+	return oldestHash
+}
+
+// remove removes the Block with hash from the pool.
+func (op *OrphanPool) remove(hash string) {
+	o, ok := op.orphans[hash]
+	if !ok {
+		return
+	}
+	delete(op.orphans, hash)
+	parentHash := o.block.Header.PreviousHash
+	siblings := op.children[parentHash]
+	for i, h := range siblings {
+		if h == hash {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(op.children, parentHash)
+	} else {
+		op.children[parentHash] = siblings
+	}
+}
+
+// OnOrphanConnected registers fn to be called with every orphan that
+// extends the active chain once its parent arrives, since whatever
+// handled the parent only knows about the parent. Orphans that cause a
+// Reorg are reported to the OnReorg handlers instead.
+func (bc *BlockChain) OnOrphanConnected(fn func(*block.Block)) {
+	bc.orphanHandlers = append(bc.orphanHandlers, fn)
+}
+
+// handleOrphans handles the orphans waiting for the Block with hash,
+// once it has been stored, and then the orphans waiting for them, in
+// the order they arrived.
+func (bc *BlockChain) handleOrphans(hash string) {
+	if !bc.BlockInfoDB.HasBlockRecord(hash) {
+		return
+	}
+	parents := []string{hash}
+	for len(parents) > 0 {
+		parentHash := parents[0]
+		parents = parents[1:]
+		for _, b := range bc.Orphans.Take(parentHash) {
+			lastHash := bc.LastHash
+			bc.handleBlock(b)
+			orphanHash := b.Hash()
+			if !bc.BlockInfoDB.HasBlockRecord(orphanHash) {
+				continue
+			}
+			if bc.LastHash == orphanHash && lastHash == parentHash {
+				for _, fn := range bc.orphanHandlers {
+					fn(b)
+				}
+			}
+			parents = append(parents, orphanHash)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package blockchain
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+)
+
+// onMainChain returns whether the Block with the given hash and
+// BlockRecord is on the main chain.
+func (bc *BlockChain) onMainChain(hash string, br *blockinfodatabase.BlockRecord) bool {
+	return br.Height <= bc.Length && bc.BlockInfoDB.GetHashByHeight(br.Height) == hash
+}
+
+// MarkOrphanedBranches marks the BlockRecords of branches that lost a
+// reorg and are now buried more than orphanPruneDepth below the tip of
+// the main chain, and returns their hashes. How deeply a branch is
+// buried is measured from its highest Block, so a Block is never marked
+// before the Blocks built on it.
+func (bc *BlockChain) MarkOrphanedBranches() []string {
+	records := bc.BlockInfoDB.GetAllBlockRecords()
+	// maps each orphaned Block to the height of the highest Block on its branch
+	branchHeights := make(map[string]uint32)
+	for hash, br := range records {
+		if bc.onMainChain(hash, br) {
+			continue
+		}
+		for h, r := hash, br; r != nil && !bc.onMainChain(h, r); r = records[h] {
+			if branchHeights[h] >= br.Height {
+				break
+			}
+			branchHeights[h] = br.Height
+			h = r.Header.PreviousHash
+		}
+	}
+	var hashes []string
+	for hash, height := range branchHeights {
+		if bc.Length > height && bc.Length-height > bc.orphanPruneDepth {
+			hashes = append(hashes, hash)
+		}
+	}
+	if err := bc.BlockInfoDB.MarkOrphaned(hashes); err != nil {
+		utils.Debug.Printf("[blockchain.MarkOrphanedBranches] %v", err)
+		return nil
+	}
+	return hashes
+}
+
+// PruneOrphanedRecords deletes the BlockRecords marked by
+// MarkOrphanedBranches, and the ChainWriter's references to their
+// Blocks, returning how many were deleted. A marked Block that has
+// since returned to the main chain is unmarked instead.
+func (bc *BlockChain) PruneOrphanedRecords() (int, error) {
+	var hashes []string
+	for _, hash := range bc.BlockInfoDB.GetOrphaned() {
+		if br := bc.BlockInfoDB.GetBlockRecord(hash); br != nil && bc.onMainChain(hash, br) {
+			bc.BlockInfoDB.UnmarkOrphaned(hash)
+			continue
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := bc.BlockInfoDB.DeleteBlockRecords(hashes); err != nil {
+		return 0, err
+	}
+	for _, hash := range hashes {
+		bc.ChainWriter.ForgetBlock(hash)
+	}
+	return len(hashes), nil
+}
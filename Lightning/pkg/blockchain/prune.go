@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+)
+
+// PruneBlockFiles deletes the block and undo files that only hold
+// Blocks buried more than the Config's PruneDepth below the tip of the
+// main chain, and then, if there's a PruneTarget, the oldest files left
+// while they take up more than it, and marks the BlockRecords of those
+// Blocks pruned. It returns the hashes of the Blocks that were pruned.
+// Blocks within the unsafe hashes are never pruned, since they may
+// still be reverted, and neither are Blocks within MaxReorgDepth
+// because of the PruneTarget.
+func (bc *BlockChain) PruneBlockFiles() []string {
+	bc.prunedAtFile = bc.ChainWriter.State().BlockFileNumber
+	var hashes []string
+	if bc.pruneDepth > 0 {
+		depth := bc.pruneDepth
+		if depth < uint32(bc.maxHashes) {
+			depth = uint32(bc.maxHashes)
+		}
+		if bc.Length > depth {
+			hashes = bc.ChainWriter.PruneBlockFiles(bc.Length-depth, bc.BlockInfoDB.GetAllBlockRecords())
+			bc.markPruned(hashes)
+		}
+	}
+	if bc.pruneTarget > 0 {
+		depth := bc.maxReorgDepth
+		if depth < uint32(bc.maxHashes) {
+			depth = uint32(bc.maxHashes)
+		}
+		if bc.Length > depth {
+			pruned := bc.ChainWriter.PruneToTarget(bc.pruneTarget, bc.Length-depth, bc.BlockInfoDB.GetAllBlockRecords())
+			bc.markPruned(pruned)
+			hashes = append(hashes, pruned...)
+		}
+	}
+	return hashes
+}
+
+// markPruned marks the BlockRecords of Blocks whose files were deleted
+// pruned.
+func (bc *BlockChain) markPruned(hashes []string) {
+	if err := bc.BlockInfoDB.MarkPruned(hashes); err != nil {
+		utils.Debug.Printf("[blockchain.PruneBlockFiles] %v", err)
+	}
+}
+
+// IsPruned returns whether the Block with hash was stored, but its
+// files have since been pruned, so it can't be read or sent to peers.
+func (bc *BlockChain) IsPruned(hash string) bool {
+	return bc.BlockInfoDB.HasBlockRecord(hash) && bc.BlockInfoDB.GetBlockRecord(hash).Status.Has(blockinfodatabase.StatusPruned)
+}
+
+// PruneUndoData deletes the undo files that only hold the UndoBlocks
+// of Blocks buried deeper than the Config's MaxReorgDepth below the tip
+// of the main chain, since no reorg can disconnect those Blocks, and
+// marks their BlockRecords undo-pruned. The Blocks themselves are kept.
+// It returns the hashes of the Blocks whose UndoBlocks were pruned.
+func (bc *BlockChain) PruneUndoData() []string {
+	bc.undoPrunedAtFile = bc.ChainWriter.State().UndoFileNumber
+	depth := bc.maxReorgDepth
+	if depth < uint32(bc.maxHashes) {
+		depth = uint32(bc.maxHashes)
+	}
+	if bc.Length <= depth {
+		return nil
+	}
+	hashes := bc.ChainWriter.PruneUndoFiles(bc.Length-depth, bc.BlockInfoDB.GetAllBlockRecords())
+	if err := bc.BlockInfoDB.MarkUndoPruned(hashes); err != nil {
+		utils.Debug.Printf("[blockchain.PruneUndoData] %v", err)
+	}
+	return hashes
+}
@@ -0,0 +1,128 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"fmt"
+	"math/big"
+)
+
+// BlockInfo is what the BlockChain knows about a Block.
+// Hash, Header and Height identify the Block.
+// Block is the Block itself, or nil if it was pruned or can't be read.
+// Confirmations is how many Blocks on the main chain confirm the
+// Block, counting itself, or 0 if it isn't on the main chain.
+// ChainWork is the total work of the chain ending at the Block.
+// Status is how far the Block has been validated.
+type BlockInfo struct {
+	Hash          string
+	Header        *block.Header
+	Height        uint32
+	Block         *block.Block
+	Confirmations uint32
+	ChainWork     *big.Int
+	Status        blockinfodatabase.Status
+}
+
+// TxInfo is what the BlockChain knows about a Transaction in a Block.
+// Transaction is the Transaction itself.
+// BlockHash and Height identify the Block it's in, and Index is where
+// it is in that Block.
+// Confirmations is how many Blocks on the main chain confirm the
+// Transaction, counting its own, or 0 if its Block isn't on the main
+// chain.
+type TxInfo struct {
+	Transaction   *block.Transaction
+	BlockHash     string
+	Height        uint32
+	Index         uint32
+	Confirmations uint32
+}
+
+// HasBlock returns whether the BlockChain has stored the Block with
+// hash, whether or not it's on the main chain or valid.
+func (bc *BlockChain) HasBlock(hash string) bool {
+	return bc.BlockInfoDB.HasBlockRecord(hash)
+}
+
+// GetBlockInfo returns what the BlockChain knows about the Block with
+// hash, or an error if it hasn't stored it.
+func (bc *BlockChain) GetBlockInfo(hash string) (*BlockInfo, error) {
+	if !bc.BlockInfoDB.HasBlockRecord(hash) {
+		return nil, fmt.Errorf("[GetBlockInfo] unknown block {%v}", hash)
+	}
+	br := bc.BlockInfoDB.GetBlockRecord(hash)
+	info := &BlockInfo{
+		Hash:      hash,
+		Header:    br.Header,
+		Height:    br.Height,
+		Block:     bc.GetBlock(hash),
+		ChainWork: bc.chainWork(hash),
+		Status:    br.Status,
+	}
+	if bc.onMainChain(hash, br) {
+		info.Confirmations = bc.Length - br.Height + 1
+	}
+	return info, nil
+}
+
+// GetBlockInfoByHeight returns what the BlockChain knows about the
+// main chain's Block at height, or an error if the main chain isn't
+// that long.
+func (bc *BlockChain) GetBlockInfoByHeight(height uint32) (*BlockInfo, error) {
+	if height == 0 || height > bc.Length {
+		return nil, fmt.Errorf("[GetBlockInfoByHeight] no block at height %v, the main chain has %v", height, bc.Length)
+	}
+	return bc.GetBlockInfo(bc.BlockInfoDB.GetHashByHeight(height))
+}
+
+// FindTransaction returns the Transaction with txHash, and where it is.
+// If blockHash is given, the Transaction is looked for in that Block,
+// whether or not it's on the main chain. Otherwise it's looked up in
+// the transaction index, which only finds Transactions on the main
+// chain, and returns an error if there is no transaction index.
+func (bc *BlockChain) FindTransaction(txHash string, blockHash string) (*TxInfo, error) {
+	index := -1
+	if blockHash == "" {
+		if !bc.BlockInfoDB.HasTxIndex() {
+			return nil, fmt.Errorf("[FindTransaction] no transaction index, a block hash is needed")
+		}
+		loc := bc.BlockInfoDB.GetTxLocation(txHash)
+		if loc == nil {
+			return nil, fmt.Errorf("[FindTransaction] transaction {%v} is not indexed", txHash)
+		}
+		blockHash, index = loc.BlockHash, int(loc.Index)
+	}
+	info, err := bc.GetBlockInfo(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	// the index isn't updated when Blocks are disconnected
+	if index >= 0 && info.Confirmations == 0 {
+		return nil, fmt.Errorf("[FindTransaction] transaction {%v} is not on the main chain", txHash)
+	}
+	if info.Block == nil && info.Status.Has(blockinfodatabase.StatusPruned) {
+		return nil, fmt.Errorf("[FindTransaction] block {%v} was pruned", blockHash)
+	}
+	if info.Block == nil {
+		return nil, fmt.Errorf("[FindTransaction] unable to read block {%v}", blockHash)
+	}
+	if index < 0 {
+		for i, tx := range info.Block.Transactions {
+			if tx.Hash() == txHash {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 || index >= len(info.Block.Transactions) || info.Block.Transactions[index].Hash() != txHash {
+		return nil, fmt.Errorf("[FindTransaction] transaction {%v} is not in block {%v}", txHash, blockHash)
+	}
+	return &TxInfo{
+		Transaction:   info.Block.Transactions[index],
+		BlockHash:     blockHash,
+		Height:        info.Height,
+		Index:         uint32(index),
+		Confirmations: info.Confirmations,
+	}, nil
+}
@@ -0,0 +1,260 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/journal"
+	"Coin/pkg/utils"
+	"bytes"
+	"fmt"
+)
+
+// Reorg describes the active chain switching to a heavier branch.
+// Ancestor is the hash of the last Block both branches share.
+// Disconnected are the Blocks taken off the active chain, from the old
+// tip back to Ancestor.
+// Connected are the Blocks of the new branch, from Ancestor up to the
+// new tip.
+// Returned are the Transactions of the Disconnected Blocks that aren't
+// in the Connected Blocks and still only spend unspent Coins, so they
+// can be mined again.
+// Length is the length of the active chain after the Reorg.
+type Reorg struct {
+	Ancestor     string
+	Disconnected []*block.Block
+	Connected    []*block.Block
+	Returned     []*block.Transaction
+	Length       uint32
+}
+
+// Tip returns the hash of the active chain's new tip, which is
+// Ancestor if no Blocks were connected.
+func (r *Reorg) Tip() string {
+	if len(r.Connected) == 0 {
+		return r.Ancestor
+	}
+	return r.Connected[len(r.Connected)-1].Hash()
+}
+
+// OnReorg registers fn to be called after every Reorg, once the
+// BlockChain's fields describe the new active chain. fn is called by
+// whatever handled the Block that caused the Reorg, so it must not
+// handle Blocks itself.
+func (bc *BlockChain) OnReorg(fn func(*Reorg)) {
+	bc.reorgHandlers = append(bc.reorgHandlers, fn)
+}
+
+// reorganize switches the active chain to the branch ending at
+// tipHash, which has more work than it, unless the active chain has
+// been invalidated (see InvalidateBlock), in which case tipHash may
+// even be one of its ancestors. At a high level, it:
+// (1) walks back from tipHash to the last Block on the active chain.
+// (2) reads the Blocks on the active chain above that ancestor, with
+// their UndoBlocks, and the Blocks on the new branch.
+// (3) disconnects the active chain's Blocks, newest first, with their
+// UndoBlocks.
+// (4) connects the new branch's Blocks, oldest first, validating each
+// against the Coins its ancestors leave, and storing its UndoBlock.
+// (5) updates the BlockChain's fields, and tells the Subscriptions and
+// the handlers registered with OnReorg.
+// If any Block on the new branch is invalid, it and its descendants are
+// marked as failed, and the old branch is connected again. Nothing is
+// changed if any Block or UndoBlock can't be read. It returns why the
+// active chain wasn't switched, if it wasn't.
+func (bc *BlockChain) reorganize(tipHash string) error {
+	// (1) find the fork point
+	branch, ancestorHash, err := bc.branchFrom(tipHash)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.reorganize] %v", err)
+		return err
+	}
+
+	// (2) read both branches
+	ancestorBr := bc.BlockInfoDB.GetBlockRecord(ancestorHash)
+	disconnected, undoBlocks, err := bc.getBlocksAndUndoBlocks(int(bc.Length-ancestorBr.Height), bc.LastHash)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.reorganize] unable to read main chain: %v", err)
+		return err
+	}
+	connected := make([]*block.Block, len(branch))
+	for i, hash := range branch {
+		if connected[i] = bc.GetBlock(hash); connected[i] == nil {
+			utils.Debug.Printf("[blockchain.reorganize] unable to read block {%v}", hash)
+			return fmt.Errorf("[blockchain.reorganize] unable to read block {%v}", hash)
+		}
+	}
+
+	// (3) disconnect the active chain back to the ancestor
+	if err = bc.CoinDB.UndoCoins(disconnected, undoBlocks); err != nil {
+		utils.Debug.Printf("[blockchain.reorganize] unable to undo main chain: %v", err)
+		return err
+	}
+
+	// (4) connect the new branch, putting the old one back if it can't be
+	connectedUndoBlocks, err := bc.connectBranch(branch, connected)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.reorganize] %v", err)
+		n := len(connectedUndoBlocks)
+		undone := reverseBlocks(append([]*block.Block{}, connected[:n]...))
+		if err2 := bc.CoinDB.UndoCoins(undone, reverseUndoBlocks(connectedUndoBlocks)); err2 != nil {
+			utils.Debug.Printf("[blockchain.reorganize] unable to undo new branch: %v", err2)
+		}
+		for i := len(disconnected) - 1; i >= 0; i-- {
+			bc.CoinDB.StoreBlock(disconnected[i].Transactions)
+		}
+		return err
+	}
+
+	// (5) update blockchain fields
+	tipBr := bc.BlockInfoDB.GetBlockRecord(tipHash)
+	if len(connected) > 0 {
+		bc.LastBlock = connected[len(connected)-1]
+	} else if bc.LastBlock = bc.GetBlock(tipHash); bc.LastBlock == nil {
+		utils.Debug.Printf("[blockchain.reorganize] unable to read block {%v}", tipHash)
+	}
+	bc.LastHash = tipHash
+	bc.Length = tipBr.Height
+	bc.CumulativeWork = bc.chainWork(tipHash)
+	bc.loadUnsafeHashes()
+	bc.BlockInfoDB.SetTip(bc.tip())
+	bc.indexMainChain(tipHash, ancestorHash)
+	bc.BlockInfoDB.TruncateHeightIndex(bc.Length)
+	for _, b := range disconnected {
+		bc.Journal.Record(journal.BlockDisconnected, b.Hash(), "")
+	}
+	bc.Journal.Record(journal.Reorg, tipHash, fmt.Sprintf("depth %v, ancestor %v", len(disconnected), ancestorHash))
+	for i, hash := range branch {
+		bc.Journal.Record(journal.BlockConnected, hash, fmt.Sprintf("height %v", ancestorBr.Height+uint32(i)+1))
+	}
+	r := &Reorg{
+		Ancestor:     ancestorHash,
+		Disconnected: disconnected,
+		Connected:    connected,
+		Returned:     bc.returnedTransactions(disconnected, connected),
+		Length:       bc.Length,
+	}
+	for i, b := range disconnected {
+		bc.notify(&TipEvent{Kind: TipDisconnected, Hash: b.Hash(), Height: ancestorBr.Height + uint32(len(disconnected)-i), Block: b})
+	}
+	for i, b := range connected {
+		bc.notify(&TipEvent{Kind: TipConnected, Hash: branch[i], Height: ancestorBr.Height + uint32(i) + 1, Block: b})
+	}
+	bc.notify(&TipEvent{Kind: ReorgCompleted, Hash: tipHash, Height: bc.Length, Block: bc.LastBlock, Reorg: r})
+	for _, fn := range bc.reorgHandlers {
+		fn(r)
+	}
+	return nil
+}
+
+// branchFrom walks back from tipHash until it reaches a Block on the
+// active chain, returning that Block's hash and the hashes of the Blocks
+// above it, in height order.
+func (bc *BlockChain) branchFrom(tipHash string) ([]string, string, error) {
+	var branch []string
+	hash := tipHash
+	for {
+		if !bc.BlockInfoDB.HasBlockRecord(hash) {
+			return nil, "", fmt.Errorf("[branchFrom] no block record for {%v}", hash)
+		}
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		if bc.onMainChain(hash, br) {
+			return reverseHashes(branch), hash, nil
+		}
+		branch = append(branch, hash)
+		hash = br.Header.PreviousHash
+	}
+}
+
+// connectBranch connects the Blocks on a branch, in height order,
+// returning the UndoBlocks of the Blocks it connected. A Block that was
+// never connected before has its UndoBlock made and stored now, since
+// which Coins it spends couldn't be known while it wasn't on the active
+// chain, and is checked against its ancestors again, in case it was
+// reconsidered after failing that check (see ReconsiderBlock). Its
+// commitment to the Coins is checked too, if it must have one. It stops
+// at the first Block that can't be connected, marking it as failed if
+// it's invalid (see isInvalid), and returns the error along with the UndoBlocks of the
+// Blocks it connected before it.
+func (bc *BlockChain) connectBranch(branch []string, blocks []*block.Block) ([]*chainwriter.UndoBlock, error) {
+	var undoBlocks []*chainwriter.UndoBlock
+	for i, b := range blocks {
+		hash := branch[i]
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		err := bc.checkCoins(b, br.Height)
+		if err == nil && !br.Status.Has(blockinfodatabase.StatusScriptsValid) {
+			err = bc.checkBlockContext(b, br.Height)
+		}
+		ub := bc.makeUndoBlock(b.Transactions)
+		var utxoState []byte
+		if err == nil && bc.utxoCommitmentHeight > 0 {
+			utxoState, err = bc.nextUTXOState(b, br.Height, ub)
+		}
+		if err != nil && !isInvalid(err) {
+			return undoBlocks, fmt.Errorf("[connectBranch] unable to check block {%v}: %w", hash, err)
+		} else if err != nil {
+			if _, err2 := bc.BlockInfoDB.MarkFailed(hash); err2 != nil {
+				utils.Debug.Printf("[blockchain.connectBranch] %v", err2)
+			}
+			return undoBlocks, fmt.Errorf("[connectBranch] block {%v} is invalid: %w", hash, err)
+		}
+		changed := false
+		if !br.Status.Has(blockinfodatabase.StatusScriptsValid) || br.Status.Has(blockinfodatabase.StatusUndoPruned) {
+			fi, err := bc.ChainWriter.StoreUndoBlock(ub)
+			if err != nil {
+				return undoBlocks, fmt.Errorf("[connectBranch] unable to store undo block for {%v}: %v", hash, err)
+			}
+			br.UndoFile = fi.FileName
+			br.UndoStartOffset = fi.StartOffset
+			br.UndoEndOffset = fi.EndOffset
+			br.Status = (br.Status &^ blockinfodatabase.StatusUndoPruned) | blockinfodatabase.StatusScriptsValid
+			changed = true
+		}
+		if utxoState != nil && !bytes.Equal(utxoState, br.UTXOHash) {
+			br.UTXOHash = utxoState
+			changed = true
+		}
+		if changed {
+			if err = bc.BlockInfoDB.StoreBlockRecordWithWriterState(hash, br, bc.ChainWriter.State()); err != nil {
+				utils.Debug.Printf("[blockchain.connectBranch] %v", err)
+			}
+		}
+		bc.CoinDB.StoreBlock(b.Transactions)
+		bc.takeSnapshot(hash, br.Height)
+		undoBlocks = append(undoBlocks, ub)
+	}
+	return undoBlocks, nil
+}
+
+// returnedTransactions returns the Transactions of the disconnected
+// Blocks that aren't in the connected Blocks, and only spend Coins that
+// are still unspent, oldest first. Coinbase Transactions are never
+// returned, since they can only be mined in their own Block.
+func (bc *BlockChain) returnedTransactions(disconnected []*block.Block, connected []*block.Block) []*block.Transaction {
+	inBranch := make(map[string]bool)
+	for _, b := range connected {
+		for _, tx := range b.Transactions {
+			inBranch[tx.Hash()] = true
+		}
+	}
+	var returned []*block.Transaction
+	for i := len(disconnected) - 1; i >= 0; i-- {
+		for _, tx := range disconnected[i].Transactions {
+			if tx.IsCoinbase() || inBranch[tx.Hash()] {
+				continue
+			}
+			if bc.CoinDB.ValidateTransaction(tx) == nil {
+				returned = append(returned, tx)
+			}
+		}
+	}
+	return returned
+}
+
+// reverseUndoBlocks returns a reversed slice of UndoBlocks.
+func reverseUndoBlocks(s []*chainwriter.UndoBlock) []*chainwriter.UndoBlock {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	return s
+}
@@ -0,0 +1,329 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/journal"
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// snapshotInfoFile is the name of the file a snapshot's Snapshot is
+// stored in, within the snapshot's directory.
+const snapshotInfoFile = "info.dat"
+
+// Snapshot describes a snapshot of the unspent Coins as of a Block at
+// a Checkpoint's height, which peers can download instead of every
+// Block below it (see LoadSnapshot).
+// Height and Hash are the Block the Coins are as of.
+// UTXOHash is the state of the Coins' UTXOHash (see UTXOHash.Bytes),
+// whose digest a Checkpoint commits to.
+// Coins is how many Coins the snapshot holds.
+// ChunkHashes are the hashes of the snapshot's chunks, in order (see
+// coindatabase.HashSnapshotChunk), so each can be checked as it
+// arrives, long before the whole snapshot can be.
+type Snapshot struct {
+	Height      uint32
+	Hash        string
+	UTXOHash    []byte
+	Coins       uint32
+	ChunkHashes [][]byte
+}
+
+// EncodeSnapshot returns a pro.SnapshotInfo given a Snapshot.
+func EncodeSnapshot(s *Snapshot) *pro.SnapshotInfo {
+	return &pro.SnapshotInfo{
+		Height:      s.Height,
+		Hash:        s.Hash,
+		UtxoHash:    s.UTXOHash,
+		Coins:       s.Coins,
+		ChunkHashes: s.ChunkHashes,
+	}
+}
+
+// DecodeSnapshot returns a Snapshot given a pro.SnapshotInfo.
+func DecodeSnapshot(ps *pro.SnapshotInfo) *Snapshot {
+	return &Snapshot{
+		Height:      ps.GetHeight(),
+		Hash:        ps.GetHash(),
+		UTXOHash:    ps.GetUtxoHash(),
+		Coins:       ps.GetCoins(),
+		ChunkHashes: ps.GetChunkHashes(),
+	}
+}
+
+// Digest returns the digest of the snapshot's Coins, hex-encoded as
+// Checkpoints have it, or an error if its UTXOHash is malformed.
+func (s *Snapshot) Digest() (string, error) {
+	state, err := coindatabase.DecodeUTXOHash(s.UTXOHash)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(state.Digest()), nil
+}
+
+// Matches returns whether the snapshot claims to be of the Block a
+// Checkpoint vouches for, and to hold the Coins it commits to. Only
+// the Coins themselves can prove that (see LoadSnapshot), but a peer
+// advertising a snapshot that doesn't match needn't be downloaded from.
+func (s *Snapshot) Matches(cp *Checkpoint) bool {
+	digest, err := s.Digest()
+	return err == nil && cp.UTXOHash != "" && s.Height == cp.Height && s.Hash == cp.Hash && digest == cp.UTXOHash
+}
+
+// SnapshotCheckpoint returns the highest Checkpoint that commits to
+// the Coins as of its Block, which a snapshot can be loaded at, or nil
+// if there is none.
+func (bc *BlockChain) SnapshotCheckpoint() *Checkpoint {
+	for i := len(bc.checkpoints) - 1; i >= 0; i-- {
+		if bc.checkpoints[i].UTXOHash != "" {
+			cp := bc.checkpoints[i]
+			return &cp
+		}
+	}
+	return nil
+}
+
+// snapshotPath returns the directory the snapshot at height is kept
+// in.
+func (bc *BlockChain) snapshotPath(height uint32) string {
+	return filepath.Join(bc.snapshotDirectory, fmt.Sprintf("snapshot_%v", height))
+}
+
+// takeSnapshot writes a snapshot of the Coins to the Config's
+// SnapshotDirectory if the Block with hash, which the CoinDatabase
+// must be at, is a Checkpoint's, so it can be served to peers. The
+// snapshot's digest is logged, since that is what a Checkpoint must
+// commit to for peers to load it.
+func (bc *BlockChain) takeSnapshot(hash string, height uint32) {
+	if bc.snapshotDirectory == "" {
+		return
+	}
+	atCheckpoint := false
+	for _, cp := range bc.checkpoints {
+		atCheckpoint = atCheckpoint || cp.Height == height && cp.Hash == hash
+	}
+	if !atCheckpoint {
+		return
+	}
+	s, err := bc.writeSnapshot(hash, height)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.takeSnapshot] %v", err)
+		return
+	}
+	digest, _ := s.Digest()
+	utils.Debug.Printf("[blockchain.takeSnapshot] wrote snapshot of %v coins at height %v with digest %v", s.Coins, height, digest)
+}
+
+// writeSnapshot writes the CoinDatabase's Coins, in chunks, and then
+// the Snapshot describing them, so a snapshot without its info file was
+// never finished.
+func (bc *BlockChain) writeSnapshot(hash string, height uint32) (*Snapshot, error) {
+	chunks, state, err := bc.CoinDB.SnapshotCoins(bc.snapshotChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	dir := bc.snapshotPath(height)
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("[writeSnapshot] %v", err)
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("[writeSnapshot] %v", err)
+	}
+	s := &Snapshot{Height: height, Hash: hash, UTXOHash: state.Bytes()}
+	for i, chunk := range chunks {
+		pchunk := coindatabase.EncodeSnapshotChunk(chunk)
+		chunkHash, err := coindatabase.HashSnapshotChunk(pchunk)
+		if err != nil {
+			return nil, err
+		}
+		if err = writeMessage(filepath.Join(dir, fmt.Sprintf("chunk_%v.dat", i)), pchunk); err != nil {
+			return nil, err
+		}
+		s.Coins += uint32(len(chunk))
+		s.ChunkHashes = append(s.ChunkHashes, chunkHash)
+	}
+	if err = writeMessage(filepath.Join(dir, snapshotInfoFile), EncodeSnapshot(s)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeMessage marshals m to the file at path.
+func writeMessage(path string, m proto.Message) error {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("[writeMessage] unable to marshal {%v}: %v", path, err)
+	}
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("[writeMessage] %v", err)
+	}
+	return nil
+}
+
+// readMessage unmarshals the file at path into m.
+func readMessage(path string, m proto.Message) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("[readMessage] %v", err)
+	}
+	if err = proto.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("[readMessage] unable to unmarshal {%v}: %v", path, err)
+	}
+	return nil
+}
+
+// Snapshots returns the snapshots in the Config's SnapshotDirectory
+// that were finished, sorted by height.
+func (bc *BlockChain) Snapshots() []*Snapshot {
+	if bc.snapshotDirectory == "" {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(bc.snapshotDirectory, "snapshot_*", snapshotInfoFile))
+	var snapshots []*Snapshot
+	for _, path := range paths {
+		ps := &pro.SnapshotInfo{}
+		if err := readMessage(path, ps); err != nil {
+			utils.Debug.Printf("[blockchain.Snapshots] %v", err)
+			continue
+		}
+		snapshots = append(snapshots, DecodeSnapshot(ps))
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Height < snapshots[j].Height })
+	return snapshots
+}
+
+// SnapshotChunk returns the chunk at index of the snapshot at height.
+func (bc *BlockChain) SnapshotChunk(height uint32, index uint32) (*pro.SnapshotChunk, error) {
+	for _, s := range bc.Snapshots() {
+		if s.Height != height {
+			continue
+		}
+		if int(index) >= len(s.ChunkHashes) {
+			return nil, fmt.Errorf("[SnapshotChunk] snapshot at height %v has %v chunks, not %v", height, len(s.ChunkHashes), index+1)
+		}
+		pchunk := &pro.SnapshotChunk{}
+		if err := readMessage(filepath.Join(bc.snapshotPath(height), fmt.Sprintf("chunk_%v.dat", index)), pchunk); err != nil {
+			return nil, err
+		}
+		return pchunk, nil
+	}
+	return nil, fmt.Errorf("[SnapshotChunk] no snapshot at height %v", height)
+}
+
+// LoadSnapshot starts the active chain at base, the Block at the
+// height of the SnapshotCheckpoint, with coins as the unspent Coins
+// it leaves, instead of connecting every Block below it. The Coins
+// must hash to the digest the Checkpoint commits to, so they're
+// trusted as much as the Checkpoint is. The Headers of base and every
+// Block below it must be in the HeaderTree (see HeaderTree.Add), and
+// the active chain must hold only the genesis Block. At a high level,
+// it:
+// (1) checks the Coins against the Checkpoint.
+// (2) adds base's Header to the HeaderTree, and finds the chain of
+// Headers down to the genesis Block.
+// (3) stores BlockRecords for the Blocks below base, marked pruned,
+// since they were never downloaded.
+// (4) stores base, marked undo-pruned, since it can't be disconnected.
+// (5) replaces the Coins, and moves the tip to base.
+// Blocks above base are then validated as usual. A snapshot of the
+// Coins is written too, so they can be served to other peers.
+func (bc *BlockChain) LoadSnapshot(base *block.Block, coins []*coindatabase.DeltaCoin) error {
+	hash := base.Hash()
+	cp := bc.SnapshotCheckpoint()
+	if cp == nil || cp.Hash != hash {
+		return fmt.Errorf("[LoadSnapshot] no checkpoint commits to the coins of block {%v}", hash)
+	}
+	if bc.Length != 1 {
+		return fmt.Errorf("[LoadSnapshot] the active chain already has %v blocks", bc.Length)
+	}
+	if err := block.ValidateBlock(base); err != nil {
+		return err
+	}
+	// (1) check the coins
+	state := coindatabase.NewUTXOHash()
+	for _, dc := range coins {
+		if dc.TransactionOutput == nil {
+			return fmt.Errorf("[LoadSnapshot] coin {%v:%v} has no output", dc.Locator.ReferenceTransactionHash, dc.Locator.OutputIndex)
+		}
+		state.Add(dc.Locator, dc.TransactionOutput)
+	}
+	if digest := hex.EncodeToString(state.Digest()); digest != cp.UTXOHash {
+		return fmt.Errorf("[LoadSnapshot] coins hash to %v, not %v", digest, cp.UTXOHash)
+	}
+	// (2) find the headers below the snapshot
+	if err := bc.Headers.Add(base.Header); err != nil {
+		return err
+	}
+	entries, err := bc.Headers.chain(hash)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("[LoadSnapshot] block {%v} is already the tip", hash)
+	}
+	baseEntry := entries[len(entries)-1]
+	if baseEntry.height != cp.Height {
+		return fmt.Errorf("[LoadSnapshot] block {%v} is at height %v, not %v", hash, baseEntry.height, cp.Height)
+	}
+	// (3) record the blocks below it
+	var hashes []string
+	var records []*blockinfodatabase.BlockRecord
+	for _, entry := range entries[:len(entries)-1] {
+		hashes = append(hashes, (&block.Block{Header: entry.header}).Hash())
+		records = append(records, &blockinfodatabase.BlockRecord{
+			Header:    entry.header,
+			Height:    entry.height,
+			ChainWork: new(big.Int).Set(entry.chainWork),
+			Status:    blockinfodatabase.StatusFullyValid | blockinfodatabase.StatusPruned,
+		})
+	}
+	if err = bc.BlockInfoDB.StoreBlockRecords(hashes, records); err != nil {
+		return err
+	}
+	for i, h := range hashes {
+		bc.BlockInfoDB.IndexHeight(records[i].Height, h)
+	}
+	// (4) store the base block
+	br, err := bc.ChainWriter.StoreBlock(base, &chainwriter.UndoBlock{}, cp.Height)
+	if err != nil {
+		return fmt.Errorf("[LoadSnapshot] unable to store block {%v}: %v", hash, err)
+	}
+	br.ChainWork = new(big.Int).Set(baseEntry.chainWork)
+	br.Status = blockinfodatabase.StatusFullyValid | blockinfodatabase.StatusUndoPruned
+	if bc.utxoCommitmentHeight > 0 {
+		br.UTXOHash = state.Bytes()
+	}
+	// (5) replace the coins and move the tip
+	if err = bc.CoinDB.ReplaceCoins(coins, hash); err != nil {
+		return err
+	}
+	bc.Length = cp.Height
+	bc.LastBlock = base
+	bc.LastHash = hash
+	bc.CumulativeWork = br.ChainWork
+	if err = bc.BlockInfoDB.StoreBlockRecordAndSetTip(hash, br, bc.tip(), bc.ChainWriter.State()); err != nil {
+		utils.Debug.Printf("[blockchain.LoadSnapshot] %v", err)
+	}
+	if err = bc.BlockInfoDB.StoreCreatedTransactions(hash, base.Transactions); err != nil {
+		utils.Debug.Printf("[blockchain.LoadSnapshot] %v", err)
+	}
+	bc.BlockInfoDB.IndexHeight(cp.Height, hash)
+	bc.loadUnsafeHashes()
+	bc.indexTransactions(hash, base)
+	bc.Headers.Prune()
+	bc.Journal.Record(journal.SnapshotLoaded, hash, fmt.Sprintf("height %v, %v coins", cp.Height, len(coins)))
+	bc.notify(&TipEvent{Kind: TipConnected, Hash: hash, Height: cp.Height, Block: base})
+	bc.takeSnapshot(hash, cp.Height)
+	return nil
+}
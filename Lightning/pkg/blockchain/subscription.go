@@ -0,0 +1,122 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"time"
+)
+
+// TipEventKind is what happened to the active chain in a TipEvent.
+type TipEventKind int
+
+const (
+	// TipConnected is a Block becoming the tip of the active chain.
+	TipConnected TipEventKind = iota
+	// TipDisconnected is the tip being taken off the active chain by a
+	// Reorg.
+	TipDisconnected
+	// ReorgCompleted is a Reorg finishing, after the TipDisconnected and
+	// TipConnected events for its Blocks.
+	ReorgCompleted
+)
+
+// String returns the name of a TipEventKind.
+func (k TipEventKind) String() string {
+	switch k {
+	case TipConnected:
+		return "connected"
+	case TipDisconnected:
+		return "disconnected"
+	case ReorgCompleted:
+		return "reorg"
+	default:
+		return "unknown"
+	}
+}
+
+// TipEvent is a change to the active chain.
+// Kind is what happened.
+// Hash, Height and Block are the Block that was connected or
+// disconnected, or the new tip for ReorgCompleted.
+// Reorg is the Reorg that completed, for ReorgCompleted, or nil.
+type TipEvent struct {
+	Kind   TipEventKind
+	Hash   string
+	Height uint32
+	Block  *block.Block
+	Reorg  *Reorg
+}
+
+// Subscription delivers TipEvents to a subscriber, in the order they
+// happened (see Subscribe).
+// Events receives the TipEvents. It's closed when the subscriber
+// unsubscribes, or when the subscriber falls so far behind that its
+// buffer is full, since the BlockChain never waits for a subscriber.
+// overflowed is whether it was closed for falling behind.
+type Subscription struct {
+	Events <-chan *TipEvent
+
+	bc         *BlockChain
+	events     chan *TipEvent
+	overflowed bool
+}
+
+// Subscribe returns a Subscription to the changes to the active chain
+// from now on, buffering up to buffer TipEvents the subscriber hasn't
+// received yet. The wallet, miner and watchtower can react to Blocks
+// this way without polling the chain.
+func (bc *BlockChain) Subscribe(buffer int) *Subscription {
+	events := make(chan *TipEvent, buffer)
+	s := &Subscription{Events: events, bc: bc, events: events}
+	bc.subscriptionMutex.Lock()
+	defer bc.subscriptionMutex.Unlock()
+	bc.subscriptions[s] = true
+	return s
+}
+
+// Unsubscribe stops the Subscription, closing Events. Unsubscribing
+// again does nothing.
+func (s *Subscription) Unsubscribe() {
+	s.bc.subscriptionMutex.Lock()
+	defer s.bc.subscriptionMutex.Unlock()
+	if s.bc.subscriptions[s] {
+		delete(s.bc.subscriptions, s)
+		close(s.events)
+	}
+}
+
+// Overflowed returns whether Events was closed because the subscriber
+// fell behind. It missed TipEvents, so it must catch up from the chain
+// itself before subscribing again.
+func (s *Subscription) Overflowed() bool {
+	s.bc.subscriptionMutex.Lock()
+	defer s.bc.subscriptionMutex.Unlock()
+	return s.overflowed
+}
+
+// notify sends a TipEvent to every Subscription, closing those whose
+// buffers are full rather than waiting for them.
+func (bc *BlockChain) notify(e *TipEvent) {
+	bc.subscriptionMutex.Lock()
+	defer bc.subscriptionMutex.Unlock()
+	if e.Kind == TipConnected {
+		bc.tipConnectedAt = time.Now()
+	}
+	for s := range bc.subscriptions {
+		select {
+		case s.events <- e:
+		default:
+			s.overflowed = true
+			delete(bc.subscriptions, s)
+			close(s.events)
+		}
+	}
+}
+
+// TipConnectedAt returns when a Block last became the tip of the
+// active chain, or when the BlockChain was opened if none has since.
+// A node uses it to tell when its tip has gone stale.
+func (bc *BlockChain) TipConnectedAt() time.Time {
+	bc.subscriptionMutex.Lock()
+	defer bc.subscriptionMutex.Unlock()
+	return bc.tipConnectedAt
+}
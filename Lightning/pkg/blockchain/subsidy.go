@@ -0,0 +1,55 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+)
+
+// Subsidy returns the minting reward for a Block extending a chain of
+// length chainLength: initial, halved every halvingRate Blocks, and
+// nothing once it has been halved maxHalvings times.
+func Subsidy(chainLength uint32, initial uint32, halvingRate uint32, maxHalvings uint32) uint32 {
+	if chainLength >= halvingRate*maxHalvings {
+		return 0
+	}
+	halvings := chainLength / halvingRate
+	if halvings >= 32 {
+		return 0
+	}
+	return initial >> halvings
+}
+
+// subsidy returns the minting reward for the Block at height.
+func (bc *BlockChain) subsidy(height uint32) uint32 {
+	return Subsidy(height-1, bc.blockSubsidy, bc.subsidyHalvingRate, bc.maxHalvings)
+}
+
+// checkCoins returns an error if a Block at height can't be connected
+// to the Coins its ancestors leave: its Transactions must only spend
+// unspent Coins, only as their Vaults allow (see checkVaults), and not
+// pay out more than they spend, and its coinbase Transactions may only
+// pay out the minting reward plus the fees the rest pay. The Vaults
+// aren't checked if the Block is assumed valid (see assumedValid) or
+// covered by a Checkpoint (see CoveredByCheckpoint).
+func (bc *BlockChain) checkCoins(b *block.Block, height uint32) error {
+	fees, err := bc.CoinDB.CheckBlockFees(b.Transactions)
+	if err != nil {
+		return err
+	}
+	if !bc.assumedValid(b.Hash(), height) && !bc.CoveredByCheckpoint(b) {
+		if err = bc.checkVaults(b.Transactions, b.Header.PreviousHash, height); err != nil {
+			return err
+		}
+	}
+	claimed := uint64(0)
+	for _, tx := range b.Transactions {
+		if tx.IsCoinbase() {
+			for _, txo := range tx.Outputs {
+				claimed += uint64(txo.Amount)
+			}
+		}
+	}
+	if allowed := uint64(bc.subsidy(height)) + uint64(fees); claimed > allowed {
+		return block.Reject(block.RejectCoinbaseAmount, "[blockchain.checkCoins] coinbase pays %v, more than the subsidy and fees of %v", claimed, allowed)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/utils"
+	"bytes"
+	"fmt"
+)
+
+// UTXOCommitment returns the digest the coinbase of the next Block of
+// the active chain must commit to (see block.UTXOCommitmentScript), or
+// nil if it needn't commit to one. It only reads the tip's BlockRecord,
+// so it's safe to call while Blocks are being handled.
+func (bc *BlockChain) UTXOCommitment() []byte {
+	if bc.utxoCommitmentHeight == 0 || bc.Length+1 < bc.utxoCommitmentHeight {
+		return nil
+	}
+	state, err := coindatabase.DecodeUTXOHash(bc.BlockInfoDB.GetBlockRecord(bc.LastHash).UTXOHash)
+	if err != nil {
+		utils.Debug.Printf("[blockchain.UTXOCommitment] %v", err)
+		return nil
+	}
+	return state.Digest()
+}
+
+// utxoState returns the UTXOHash of the Coins once the Block with hash
+// is connected. It's read from the Block's BlockRecord if it was
+// tracked, and otherwise computed from the CoinDatabase, which must be
+// at that Block, and stored in the BlockRecord.
+func (bc *BlockChain) utxoState(hash string) (*coindatabase.UTXOHash, error) {
+	br := bc.BlockInfoDB.GetBlockRecord(hash)
+	if br.UTXOHash != nil {
+		state, err := coindatabase.DecodeUTXOHash(br.UTXOHash)
+		if err == nil {
+			return state, nil
+		}
+		utils.Debug.Printf("[blockchain.utxoState] block {%v}: %v", hash, err)
+	}
+	state, err := bc.CoinDB.UTXOHash()
+	if err != nil {
+		return nil, fmt.Errorf("[utxoState] %v", err)
+	}
+	br.UTXOHash = state.Bytes()
+	bc.BlockInfoDB.StoreBlockRecord(hash, br)
+	return state, nil
+}
+
+// nextUTXOState returns the state of the UTXOHash once a Block at
+// height is connected, with its UndoBlock, on top of its parent, which
+// the CoinDatabase must be at. It returns an error if the Block is at
+// or above Config.UTXOCommitmentHeight and its coinbase doesn't commit
+// to the Coins its parent leaves.
+func (bc *BlockChain) nextUTXOState(b *block.Block, height uint32, ub *chainwriter.UndoBlock) ([]byte, error) {
+	state, err := bc.utxoState(b.Header.PreviousHash)
+	if err != nil {
+		return nil, err
+	}
+	if height >= bc.utxoCommitmentHeight {
+		if err = checkUTXOCommitment(b, state.Digest()); err != nil {
+			return nil, err
+		}
+	}
+	state.ConnectBlock(b, ub)
+	return state.Bytes(), nil
+}
+
+// checkUTXOCommitment returns an error if the Block's coinbase doesn't
+// commit to digest.
+func checkUTXOCommitment(b *block.Block, digest []byte) error {
+	for _, tx := range b.Transactions {
+		if !tx.IsCoinbase() {
+			continue
+		}
+		commitment, ok := block.UTXOCommitment(tx)
+		if !ok {
+			return block.Reject(block.RejectUTXOCommitment, "[checkUTXOCommitment] coinbase doesn't commit to the unspent coins")
+		}
+		if !bytes.Equal(commitment, digest) {
+			return block.Reject(block.RejectUTXOCommitment, "[checkUTXOCommitment] coinbase commits to %x, not %x", commitment, digest)
+		}
+		return nil
+	}
+	return block.Reject(block.RejectUTXOCommitment, "[checkUTXOCommitment] block has no coinbase")
+}
@@ -0,0 +1,106 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/script"
+	"fmt"
+	"math"
+)
+
+// vaultWithdrawal is an input withdrawing from an Unvaulting Vault with
+// its owner key, which is only allowed once the Coin it spends has been
+// confirmed for the Vault's DelayBlocks.
+type vaultWithdrawal struct {
+	txi   *block.TransactionInput
+	delay uint32
+}
+
+// CheckVaultSpends returns an error if a Transaction spends a Coin
+// locked by a Vault in a way the Vault doesn't allow in the next Block
+// of the active chain (see checkVaults).
+func (bc *BlockChain) CheckVaultSpends(tx *block.Transaction) error {
+	return bc.checkVaults([]*block.Transaction{tx}, bc.LastHash, bc.Length+1)
+}
+
+// checkVaults returns an error if any of the Transactions, in a Block
+// at height building on the Block with prevHash, spends a Coin locked
+// by a Vault in a way the Vault doesn't allow (see
+// script.CheckVaultSpend). The key that spent it is whichever of the
+// Vault's keys its signature verifies against. A Coin's confirmations
+// are found by walking back from prevHash, as for relative locks (see
+// checkSequenceLocks). The CoinDB must hold the Coins the Block's
+// ancestors leave.
+func (bc *BlockChain) checkVaults(txs []*block.Transaction, prevHash string, height uint32) error {
+	// Coins created in the same Block have no confirmations at all
+	created := make(map[coindatabase.CoinLocator]*block.TransactionOutput)
+	var pending []*vaultWithdrawal
+	for i, tx := range txs {
+		var outputScripts [][]byte
+		for _, txo := range tx.Outputs {
+			outputScripts = append(outputScripts, txo.LockingScript)
+		}
+		for _, txi := range tx.Inputs {
+			cl := coindatabase.CoinLocator{ReferenceTransactionHash: txi.ReferenceTransactionHash, OutputIndex: txi.OutputIndex}
+			txo, sameBlock := created[cl]
+			if !sameBlock {
+				coin := bc.CoinDB.GetCoin(cl)
+				if coin == nil {
+					// checkCoins reports the missing Coin
+					continue
+				}
+				txo = coin.TransactionOutput
+			}
+			v, err := script.ParseVault(txo.LockingScript)
+			if err != nil {
+				continue
+			}
+			signer, byOwner := v.RecoveryPublicKey, false
+			if !txo.VerifySignature(v.RecoveryPublicKey, txi.UnlockingScript) {
+				if !txo.VerifySignature(v.OwnerPublicKey, txi.UnlockingScript) {
+					return block.Reject(block.RejectVaultSpend, "[blockchain.checkVaults] transaction %v is signed by neither the owner nor the recovery key of the vault it spends", i)
+				}
+				signer, byOwner = v.OwnerPublicKey, true
+			}
+			// the delay is checked once the Coin's confirmations are known
+			confirmations := uint32(math.MaxUint32)
+			if sameBlock {
+				confirmations = 0
+			}
+			if err = script.CheckVaultSpend(v, signer, confirmations, outputScripts); err != nil {
+				return block.Reject(block.RejectVaultSpend, "[blockchain.checkVaults] transaction %v: %v", i, err)
+			}
+			if byOwner && !sameBlock && v.Unvaulting && v.DelayBlocks > 0 {
+				pending = append(pending, &vaultWithdrawal{txi: txi, delay: v.DelayBlocks})
+			}
+		}
+		for j, txo := range tx.Outputs {
+			created[coindatabase.CoinLocator{ReferenceTransactionHash: tx.Hash(), OutputIndex: uint32(j)}] = txo
+		}
+	}
+	hash, h := prevHash, height-1
+	for len(pending) > 0 && bc.BlockInfoDB.HasBlockRecord(hash) {
+		var locked []*vaultWithdrawal
+		for _, w := range pending {
+			if height-h < w.delay {
+				locked = append(locked, w)
+			}
+		}
+		if len(locked) > 0 {
+			txHashes, err := bc.createdTransactions(hash)
+			if err != nil {
+				return fmt.Errorf("[blockchain.checkVaults] %v", err)
+			}
+			for _, txHash := range txHashes {
+				for _, w := range locked {
+					if w.txi.ReferenceTransactionHash == txHash {
+						return block.Reject(block.RejectVaultSpend, "[blockchain.checkVaults] vault {%v} created at height %v can't be withdrawn from until height %v", txHash, h, h+w.delay)
+					}
+				}
+			}
+		}
+		pending = locked
+		hash, h = bc.BlockInfoDB.GetBlockRecord(hash).Header.PreviousHash, h-1
+	}
+	return nil
+}
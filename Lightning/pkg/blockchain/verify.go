@@ -0,0 +1,166 @@
+package blockchain
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/blockchain/coindatabase"
+	"fmt"
+)
+
+// VerifyLevel is how thoroughly VerifyChain checks each Block. Every
+// level also runs the checks of the levels below it.
+type VerifyLevel int
+
+const (
+	// VerifyRead reads each Block, and checks that it has the hash its
+	// BlockRecord is stored under and is indexed at its height.
+	VerifyRead VerifyLevel = iota
+	// VerifyBlocks validates each Block again, as far as it can be
+	// validated against its ancestors.
+	VerifyBlocks
+	// VerifyUndo reads each Block's UndoBlock, and checks that it
+	// matches the Block's inputs.
+	VerifyUndo
+	// VerifyCoins disconnects the Blocks from the Coins in memory,
+	// newest first, checking that the Coins each one created are
+	// unspent and the Coins its UndoBlock restores are spent.
+	VerifyCoins
+)
+
+// ChainError is an inconsistency VerifyChain or an IntegrityChecker
+// found in a Block on the active chain.
+// Hash and Height identify the Block, and Err is what is wrong with it.
+type ChainError struct {
+	Hash   string
+	Height uint32
+	Err    error
+}
+
+// Error returns a description of the inconsistency.
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("block {%v} at height %v: %v", e.Hash, e.Height, e.Err)
+}
+
+// VerifyChain checks the last depth Blocks of the active chain, or the
+// whole chain if depth is 0, at level, and returns the inconsistencies
+// it found, newest first. It stops at the first Block it can't read,
+// and checks nothing below it, and it stops checking UndoBlocks and
+// Coins at the first Block whose UndoBlock was pruned. The genesis
+// Block is only read, since it has no UndoBlock. Nothing is changed,
+// so it can run at startup (see Config.VerifyDepth) or on demand.
+func (bc *BlockChain) VerifyChain(depth uint32, level VerifyLevel) []*ChainError {
+	var errs []*ChainError
+	report := func(hash string, height uint32, err error) {
+		errs = append(errs, &ChainError{Hash: hash, Height: height, Err: err})
+	}
+	// view holds the Coins as they were before the Blocks checked so
+	// far, over the CoinDatabase: nil for a Coin that didn't exist yet
+	view := make(map[coindatabase.CoinLocator]*block.TransactionOutput)
+	unspent := func(cl coindatabase.CoinLocator) bool {
+		if txo, ok := view[cl]; ok {
+			return txo != nil
+		}
+		coin := bc.CoinDB.GetCoin(cl)
+		return coin != nil && !coin.IsSpent
+	}
+	checkUndo := level >= VerifyUndo
+	hash := bc.LastHash
+	for height := bc.Length; height >= 1 && (depth == 0 || bc.Length-height < depth); height-- {
+		if !bc.BlockInfoDB.HasBlockRecord(hash) {
+			report(hash, height, fmt.Errorf("no block record"))
+			break
+		}
+		br := bc.BlockInfoDB.GetBlockRecord(hash)
+		if br.Height != height {
+			report(hash, height, fmt.Errorf("block record has height %v", br.Height))
+		}
+		if indexed := bc.BlockInfoDB.GetHashByHeight(height); indexed != hash {
+			report(hash, height, fmt.Errorf("height index has block {%v}", indexed))
+		}
+		if br.Status.Has(blockinfodatabase.StatusPruned) {
+			break
+		}
+		b, err := bc.readBlock(br)
+		if err != nil {
+			report(hash, height, err)
+			break
+		}
+		if b.Hash() != hash {
+			report(hash, height, fmt.Errorf("stored block has hash {%v}", b.Hash()))
+			break
+		}
+		if height == 1 {
+			break
+		}
+
+		if level >= VerifyBlocks {
+			if br.Status.Has(blockinfodatabase.StatusFailed) || !br.Status.Has(blockinfodatabase.StatusScriptsValid) {
+				report(hash, height, fmt.Errorf("block record has status %v", br.Status))
+			}
+			if err = block.ValidateBlock(b); err == nil {
+				if err = bc.checkHeader(b.Header); err == nil {
+					err = bc.checkBlockContext(b, height)
+				}
+			}
+			if err != nil {
+				report(hash, height, err)
+			}
+		}
+
+		if checkUndo && br.Status.Has(blockinfodatabase.StatusUndoPruned) {
+			checkUndo = false
+		}
+		if checkUndo {
+			ub, err := bc.getUndoBlock(hash)
+			if err == nil {
+				err = coindatabase.CheckUndoBlock(b, ub)
+			}
+			if err != nil {
+				report(hash, height, err)
+				// the Coins can't be disconnected without it
+				checkUndo = false
+			} else if level >= VerifyCoins {
+				for _, err = range disconnectInView(b, ub, view, unspent) {
+					report(hash, height, err)
+				}
+			}
+		}
+		hash = b.Header.PreviousHash
+	}
+	return errs
+}
+
+// disconnectInView disconnects a Block from the Coins in view, given
+// its UndoBlock, returning an error for every Coin it created that
+// isn't unspent, and every Coin its UndoBlock restores that isn't
+// spent. Outputs spent later in the same Block were never Coins.
+func disconnectInView(b *block.Block, ub *chainwriter.UndoBlock, view map[coindatabase.CoinLocator]*block.TransactionOutput, unspent func(coindatabase.CoinLocator) bool) []error {
+	var errs []error
+	spentInBlock := make(map[coindatabase.CoinLocator]bool)
+	for _, tx := range b.Transactions {
+		for _, txi := range tx.Inputs {
+			spentInBlock[coindatabase.CoinLocator{ReferenceTransactionHash: txi.ReferenceTransactionHash, OutputIndex: txi.OutputIndex}] = true
+		}
+	}
+	for _, tx := range b.Transactions {
+		for i := range tx.Outputs {
+			cl := coindatabase.CoinLocator{ReferenceTransactionHash: tx.Hash(), OutputIndex: uint32(i)}
+			if spentInBlock[cl] {
+				continue
+			}
+			if !unspent(cl) {
+				errs = append(errs, fmt.Errorf("output %v of transaction {%v} is missing from the coins", i, tx.Hash()))
+			}
+			view[cl] = nil
+		}
+	}
+	for i, txHash := range ub.TransactionInputHashes {
+		cl := coindatabase.CoinLocator{ReferenceTransactionHash: txHash, OutputIndex: ub.OutputIndexes[i]}
+		if unspent(cl) {
+			errs = append(errs, fmt.Errorf("spent output %v of transaction {%v} is unspent in the coins", cl.OutputIndex, txHash))
+		}
+		view[cl] = &block.TransactionOutput{Amount: ub.Amounts[i], LockingScript: ub.LockingScripts[i]}
+	}
+	return errs
+}
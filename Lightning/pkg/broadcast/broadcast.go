@@ -0,0 +1,227 @@
+// Package broadcast relays our own Transactions to the network until
+// they are confirmed. Instead of sending a Transaction once and
+// forgetting it, the Broadcaster watches for peers relaying it back
+// to us, sends it again to peers it hasn't tried yet while it remains
+// unconfirmed, and reports it once it gives up.
+package broadcast
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Sender sends a Transaction to the peer at addr.
+type Sender func(addr string, tx *block.Transaction) error
+
+// Status is how the broadcast of a Transaction is going.
+// Attempts is how many times it has been sent.
+// SentTo is how many different peers it has been sent to.
+// Echoes is how many different peers have relayed it back to us.
+// Accepted is whether enough peers have relayed it back to us
+// to count it as accepted by the network.
+type Status struct {
+	Attempts int
+	SentTo   int
+	Echoes   int
+	Accepted bool
+}
+
+// pending is a Transaction that hasn't been confirmed yet.
+// sentTo and echoedBy are the addresses of the peers it was sent
+// to, and that relayed it back to us.
+// lastSent is when it was last sent.
+type pending struct {
+	tx       *block.Transaction
+	attempts int
+	sentTo   map[string]bool
+	echoedBy map[string]bool
+	lastSent time.Time
+}
+
+// Broadcaster tracks our Transactions until they are confirmed.
+// Config is the Broadcaster's configuration,
+// Send is how a Transaction is sent to a peer,
+// Peers returns the addresses of the peers Transactions may be sent to,
+// Failed receives every Transaction the Broadcaster gives up on.
+type Broadcaster struct {
+	Config *Config
+	Send   Sender
+	Peers  func() []string
+	Failed chan *block.Transaction
+
+	mutex   sync.Mutex
+	pending map[string]*pending
+	stop    chan bool
+}
+
+// New returns a Broadcaster given a Config, a Sender, and a way to
+// list peers.
+func New(config *Config, send Sender, peers func() []string) *Broadcaster {
+	return &Broadcaster{
+		Config:  config,
+		Send:    send,
+		Peers:   peers,
+		Failed:  make(chan *block.Transaction),
+		pending: make(map[string]*pending),
+	}
+}
+
+// Track starts broadcasting a Transaction, and sends it right away.
+func (b *Broadcaster) Track(tx *block.Transaction) {
+	b.mutex.Lock()
+	p, ok := b.pending[tx.Hash()]
+	if !ok {
+		p = &pending{
+			tx:       tx,
+			sentTo:   make(map[string]bool),
+			echoedBy: make(map[string]bool),
+		}
+		b.pending[tx.Hash()] = p
+	}
+	peers := b.attempt(p, time.Now())
+	b.mutex.Unlock()
+	b.sendTo(peers, tx)
+}
+
+// Echo records that the peer at addr relayed the Transaction with
+// hash back to us. It returns whether the Transaction is one the
+// Broadcaster is tracking.
+func (b *Broadcaster) Echo(hash string, addr string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p, ok := b.pending[hash]
+	if !ok {
+		return false
+	}
+	p.echoedBy[addr] = true
+	return true
+}
+
+// Confirm stops broadcasting the Transaction with hash, since it has
+// been seen in a Block, or replaced by one paying a higher fee.
+func (b *Broadcaster) Confirm(hash string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.pending, hash)
+}
+
+// GetStatus returns the Status of the Transaction with hash, or nil
+// if the Broadcaster isn't tracking it.
+func (b *Broadcaster) GetStatus(hash string) *Status {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	p, ok := b.pending[hash]
+	if !ok {
+		return nil
+	}
+	return &Status{
+		Attempts: p.attempts,
+		SentTo:   len(p.sentTo),
+		Echoes:   len(p.echoedBy),
+		Accepted: len(p.echoedBy) >= b.Config.EchoesNeeded,
+	}
+}
+
+// Tick sends every Transaction that hasn't been sent in the last
+// RebroadcastInterval again, and gives up on those that have already
+// been sent MaxAttempts times, sending them to Failed.
+func (b *Broadcaster) Tick(now time.Time) {
+	type send struct {
+		peers []string
+		tx    *block.Transaction
+	}
+	var sends []send
+	var failed []*block.Transaction
+	b.mutex.Lock()
+	for hash, p := range b.pending {
+		if now.Sub(p.lastSent) < b.Config.RebroadcastInterval {
+			continue
+		}
+		if p.attempts >= b.Config.MaxAttempts {
+			delete(b.pending, hash)
+			failed = append(failed, p.tx)
+			continue
+		}
+		sends = append(sends, send{b.attempt(p, now), p.tx})
+	}
+	b.mutex.Unlock()
+	for _, s := range sends {
+		b.sendTo(s.peers, s.tx)
+	}
+	for _, tx := range failed {
+		utils.Debug.Printf("[broadcast.Tick] giving up on %v after %v attempts", tx.NameTag(), b.Config.MaxAttempts)
+		go func(tx *block.Transaction) {
+			b.Failed <- tx
+		}(tx)
+	}
+}
+
+// Start calls Tick periodically until Stop is called.
+func (b *Broadcaster) Start() {
+	interval := b.Config.RebroadcastInterval / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	b.stop = make(chan bool)
+	go func(stop chan bool) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				b.Tick(now)
+			case <-stop:
+				return
+			}
+		}
+	}(b.stop)
+}
+
+// Stop stops calling Tick.
+func (b *Broadcaster) Stop() {
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+}
+
+// attempt records another attempt at sending p, and returns the peers
+// to send it to. Peers it hasn't been sent to are picked first, so that
+// each attempt reaches a different part of the network. The caller must
+// hold the mutex.
+func (b *Broadcaster) attempt(p *pending, now time.Time) []string {
+	var fresh, tried []string
+	for _, addr := range b.Peers() {
+		if p.sentTo[addr] {
+			tried = append(tried, addr)
+		} else {
+			fresh = append(fresh, addr)
+		}
+	}
+	rand.Shuffle(len(fresh), func(i, j int) { fresh[i], fresh[j] = fresh[j], fresh[i] })
+	rand.Shuffle(len(tried), func(i, j int) { tried[i], tried[j] = tried[j], tried[i] })
+	peers := append(fresh, tried...)
+	if len(peers) > b.Config.PeersPerAttempt {
+		peers = peers[:b.Config.PeersPerAttempt]
+	}
+	for _, addr := range peers {
+		p.sentTo[addr] = true
+	}
+	p.attempts++
+	p.lastSent = now
+	return peers
+}
+
+// sendTo sends tx to each of peers.
+func (b *Broadcaster) sendTo(peers []string, tx *block.Transaction) {
+	for _, addr := range peers {
+		go func(addr string) {
+			if err := b.Send(addr, tx); err != nil {
+				utils.Debug.Printf("[broadcast] %v was not sent to %v: %v", tx.NameTag(), utils.FmtAddr(addr), err)
+			}
+		}(addr)
+	}
+}
@@ -0,0 +1,29 @@
+package broadcast
+
+import "time"
+
+// Config is the Broadcaster's configuration options.
+// RebroadcastInterval is how long the Broadcaster waits for a
+// Transaction to be confirmed before sending it again.
+// PeersPerAttempt is how many peers a Transaction is sent to
+// each time it is sent.
+// MaxAttempts is how many times a Transaction is sent before
+// the Broadcaster gives up on it.
+// EchoesNeeded is how many peers must relay a Transaction back
+// to us before it counts as accepted by the network.
+type Config struct {
+	RebroadcastInterval time.Duration
+	PeersPerAttempt     int
+	MaxAttempts         int
+	EchoesNeeded        int
+}
+
+// DefaultConfig returns the Broadcaster's default Config.
+func DefaultConfig() *Config {
+	return &Config{
+		RebroadcastInterval: time.Second * 30,
+		PeersPerAttempt:     4,
+		MaxAttempts:         10,
+		EchoesNeeded:        1,
+	}
+}
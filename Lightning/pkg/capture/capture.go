@@ -0,0 +1,158 @@
+package capture
+
+import (
+	"Coin/pkg/utils"
+	"bufio"
+	"encoding/json"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"sync"
+	"time"
+)
+
+// The directions a message can travel in.
+const (
+	Inbound  = "in"
+	Outbound = "out"
+)
+
+// Record is one captured RPC.
+// Time is when the RPC was made, in unix nanoseconds.
+// Direction is Inbound or Outbound.
+// Method is the full gRPC method, such as "/Coin/ForwardBlock".
+// Peer is the address of the other end of the RPC.
+// Request is the request, marshalled as protobuf.
+// Error is the error the RPC failed with, if any.
+type Record struct {
+	Time      int64  `json:"time"`
+	Direction string `json:"direction"`
+	Method    string `json:"method"`
+	Peer      string `json:"peer"`
+	Request   []byte `json:"request"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Recorder captures RPCs to a file, one JSON object per line, so that
+// a trace of a failure can be replayed into a fresh node later.
+// A nil Recorder captures nothing.
+type Recorder struct {
+	mutex sync.Mutex
+	file  *os.File
+	w     *bufio.Writer
+}
+
+// New returns a Recorder that appends to the file in the Config.
+func New(config *Config) (*Recorder, error) {
+	file, err := os.OpenFile(config.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+// Record captures an RPC. Requests that aren't protobuf messages
+// can't be replayed, so they're skipped.
+func (r *Recorder) Record(direction string, method string, addr string, req interface{}, rpcErr error) {
+	if r == nil {
+		return
+	}
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		utils.Debug.Printf("[capture.Record] Unable to marshal request to %v: %v", method, err)
+		return
+	}
+	rec := &Record{
+		Time:      time.Now().UnixNano(),
+		Direction: direction,
+		Method:    method,
+		Peer:      addr,
+		Request:   data,
+	}
+	if rpcErr != nil {
+		rec.Error = rpcErr.Error()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		utils.Debug.Printf("[capture.Record] Unable to encode record of %v: %v", method, err)
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return
+	}
+	r.w.Write(append(line, '\n'))
+	// flush every record, so that a crash doesn't lose the trace
+	// leading up to it
+	if err = r.w.Flush(); err != nil {
+		utils.Debug.Printf("[capture.Record] Unable to write to {%v}: %v", r.file.Name(), err)
+	}
+}
+
+// Close stops capturing and closes the file.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// ServerInterceptor returns an interceptor that captures the RPCs a
+// server receives.
+func (r *Recorder) ServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		reply, err := handler(ctx, req)
+		addr := ""
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+		}
+		r.Record(Inbound, info.FullMethod, addr, req, err)
+		return reply, err
+	}
+}
+
+// ClientInterceptor returns an interceptor that captures the RPCs a
+// client sends.
+func (r *Recorder) ClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		r.Record(Outbound, method, cc.Target(), req, err)
+		return err
+	}
+}
+
+// Read returns the Records captured in the file at path, oldest first.
+func Read(path string) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []*Record
+	scanner := bufio.NewScanner(file)
+	// blocks can be much larger than the default line limit
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		rec := &Record{}
+		// a crash mid-write can leave a partial last line, which we skip
+		if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
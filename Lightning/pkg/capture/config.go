@@ -0,0 +1,16 @@
+package capture
+
+// Config is the capture's configuration options.
+// Path is the file that messages are captured to. If it is empty,
+// nothing is captured.
+type Config struct {
+	Path string
+}
+
+// DefaultConfig returns the capture's default Config, which captures
+// nothing.
+func DefaultConfig() *Config {
+	return &Config{
+		Path: "",
+	}
+}
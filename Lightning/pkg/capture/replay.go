@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"Coin/pkg/pro"
+	"fmt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"strings"
+)
+
+// Result is the outcome of replaying a Record.
+// Record is the Record that was replayed.
+// Err is the error the handler returned, if any.
+type Result struct {
+	Record *Record
+	Err    error
+}
+
+// Replay feeds the inbound Records to coin and ln in order, as if
+// they had just been received, and returns a Result for each.
+// Outbound Records are skipped, since they are what the captured node
+// sent. Records for a server that is nil are skipped too.
+func Replay(records []*Record, coin pro.CoinServer, ln pro.LightningServer) []*Result {
+	var results []*Result
+	for _, rec := range records {
+		if rec.Direction != Inbound {
+			continue
+		}
+		var err error
+		switch service(rec.Method) {
+		case pro.Coin_ServiceDesc.ServiceName:
+			if coin == nil {
+				continue
+			}
+			err = replay(rec, &pro.Coin_ServiceDesc, coin)
+		case pro.Lightning_ServiceDesc.ServiceName:
+			if ln == nil {
+				continue
+			}
+			err = replay(rec, &pro.Lightning_ServiceDesc, ln)
+		default:
+			err = fmt.Errorf("[capture.Replay] unknown method %v", rec.Method)
+		}
+		results = append(results, &Result{Record: rec, Err: err})
+	}
+	return results
+}
+
+// service returns the service of a full gRPC method.
+func service(method string) string {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	return parts[0]
+}
+
+// replay calls the handler for a Record's method on srv, decoding the
+// Record's request as the handler's request type.
+func replay(rec *Record, desc *grpc.ServiceDesc, srv interface{}) error {
+	name := rec.Method[strings.LastIndex(rec.Method, "/")+1:]
+	for _, m := range desc.Methods {
+		if m.MethodName != name {
+			continue
+		}
+		dec := func(v interface{}) error {
+			return proto.Unmarshal(rec.Request, v.(proto.Message))
+		}
+		_, err := m.Handler(srv, context.Background(), dec, nil)
+		return err
+	}
+	return fmt.Errorf("[capture.replay] %v has no method %v", desc.ServiceName, name)
+}
@@ -0,0 +1,183 @@
+package chainparams
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/miner"
+	"Coin/pkg/utils"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Params are the rules and settings that set one network apart from
+// another, so that test networks and local development don't collide
+// with the main chain: each has its own genesis Block, so their chains
+// share no Blocks, its own Magic, so their files can't be mixed up, and
+// its own port and address prefix.
+// Name is what the network is selected by.
+// Magic marks every record the ChainWriter stores (see
+// chainwriter.Config).
+// DefaultPort is the port a node listens on unless it is given one.
+// AddressPrefix starts every address shown for the network (see
+// FormatAddress).
+// GenesisPublicKey, GenesisSubsidy and GenesisTimestamp make up the
+// genesis Block (see blockchain.GenesisBlock).
+// InitialSubsidy, SubsidyHalvingRate and MaxHalvings are the subsidy
+// schedule miners are paid by (see miner.Config).
+// POWDifficultyZeros is the number of leading zeros of the proof of
+// work difficulty target, which miners mine to and every Block must
+// carry (see utils.CalcPOWD).
+// Checkpoints are Blocks known to be on the network's main chain, and
+// AssumeValid a Block whose ancestors' scripts are trusted by default
+// (see blockchain.Config).
+type Params struct {
+	Name          string
+	Magic         uint32
+	DefaultPort   int
+	AddressPrefix string
+
+	GenesisPublicKey []byte
+	GenesisSubsidy   uint32
+	GenesisTimestamp uint32
+
+	InitialSubsidy     uint32
+	SubsidyHalvingRate uint32
+	MaxHalvings        uint32
+	POWDifficultyZeros int
+
+	Checkpoints []blockchain.Checkpoint
+	AssumeValid string
+}
+
+// genesisPublicKey is the public key the genesis Blocks pay to.
+func genesisPublicKey() []byte {
+	pk, _ := hex.DecodeString(blockchain.GENPK)
+	return pk
+}
+
+// Main is the main network, whose settings are the defaults of every
+// package.
+var Main = &Params{
+	Name:               "mainnet",
+	Magic:              chainwriter.DefaultMagic,
+	DefaultPort:        8333,
+	AddressPrefix:      "coin",
+	GenesisPublicKey:   genesisPublicKey(),
+	GenesisSubsidy:     0,
+	GenesisTimestamp:   0,
+	InitialSubsidy:     50,
+	SubsidyHalvingRate: 10,
+	MaxHalvings:        10,
+	POWDifficultyZeros: -1,
+}
+
+// TestNet is a public network for testing, with an easier difficulty
+// than the main network.
+var TestNet = &Params{
+	Name:               "testnet",
+	Magic:              0x7E57B10C,
+	DefaultPort:        18333,
+	AddressPrefix:      "tcoin",
+	GenesisPublicKey:   genesisPublicKey(),
+	GenesisSubsidy:     0,
+	GenesisTimestamp:   1,
+	InitialSubsidy:     50,
+	SubsidyHalvingRate: 10,
+	MaxHalvings:        10,
+	POWDifficultyZeros: 2,
+}
+
+// RegTest is a private network for local development and tests, where
+// Blocks are mined almost instantly and the subsidy lasts much longer.
+var RegTest = &Params{
+	Name:               "regtest",
+	Magic:              0x5E67B10C,
+	DefaultPort:        18444,
+	AddressPrefix:      "rcoin",
+	GenesisPublicKey:   genesisPublicKey(),
+	GenesisSubsidy:     0,
+	GenesisTimestamp:   2,
+	InitialSubsidy:     50,
+	SubsidyHalvingRate: 150,
+	MaxHalvings:        10,
+	POWDifficultyZeros: 0,
+}
+
+// registry maps each network's Name to its Params.
+var registry = map[string]*Params{
+	Main.Name:    Main,
+	TestNet.Name: TestNet,
+	RegTest.Name: RegTest,
+}
+
+// Get returns the Params of the network called name.
+func Get(name string) (*Params, error) {
+	if p, ok := registry[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("[chainparams.Get] unknown network %q, expected one of %v", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of every network, sorted.
+func Names() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChainConfig sets the parts of a blockchain.Config that the network
+// decides.
+func (p *Params) ChainConfig(config *blockchain.Config) {
+	config.GenesisPublicKey = p.GenesisPublicKey
+	config.InitialSubsidy = p.GenesisSubsidy
+	config.GenesisTimestamp = p.GenesisTimestamp
+	config.BlockSubsidy = p.InitialSubsidy
+	config.SubsidyHalvingRate = p.SubsidyHalvingRate
+	config.MaxHalvings = p.MaxHalvings
+	config.Magic = p.Magic
+	config.Checkpoints = p.Checkpoints
+	config.AssumeValid = p.AssumeValid
+	config.DifficultyTarget = string(utils.CalcPOWD(p.POWDifficultyZeros))
+}
+
+// MinerConfig sets the parts of a miner.Config that the network
+// decides.
+func (p *Params) MinerConfig(config *miner.Config) {
+	config.InitialSubsidy = p.InitialSubsidy
+	config.SubsidyHalvingRate = p.SubsidyHalvingRate
+	config.MaxHalvings = p.MaxHalvings
+	config.InitialPOWDifficulty = utils.CalcPOWD(p.POWDifficultyZeros)
+}
+
+// GenesisBlock returns the network's genesis Block.
+func (p *Params) GenesisBlock() *block.Block {
+	config := blockchain.DefaultConfig()
+	p.ChainConfig(config)
+	return blockchain.GenesisBlock(config)
+}
+
+// FormatAddress returns the address of a public key on the network:
+// its AddressPrefix, a colon, and the public key in hex.
+func (p *Params) FormatAddress(publicKey []byte) string {
+	return p.AddressPrefix + ":" + hex.EncodeToString(publicKey)
+}
+
+// ParseAddress returns the public key of an address made by
+// FormatAddress, or an error if the address is for another network.
+func (p *Params) ParseAddress(address string) ([]byte, error) {
+	i := strings.LastIndex(address, ":")
+	if i < 0 || address[:i] != p.AddressPrefix {
+		return nil, fmt.Errorf("[chainparams.ParseAddress] address {%v} is not a %v address", address, p.Name)
+	}
+	publicKey, err := hex.DecodeString(address[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("[chainparams.ParseAddress] address {%v} is malformed: %v", address, err)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,199 @@
+// Package checkpoint records consistent points across a node's
+// databases, so that a restarted node can trust its state as of the
+// last checkpoint and only replay the Blocks after it.
+//
+// The BlockInfoDatabase and ChainWriter are always consistent with
+// each other, but the CoinDatabase keeps spent Coins in memory until
+// its cache is flushed, so after a crash its db may still hold Coins
+// that were spent. A checkpoint flushes the CoinDatabase, marks the
+// flush with the tip's hash, and records it alongside the
+// ChainWriter's offsets and the wallet's state version.
+package checkpoint
+
+import (
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Checkpoint is a consistent point across the node's databases.
+// Hash and Height are the tip of the main chain.
+// FlushMarker is the flush marker the CoinDatabase was flushed with,
+// which is Hash.
+// Writer is where the ChainWriter would write next.
+// WalletVersion is the wallet's state version once it had handled
+// the Block with Hash.
+// CreatedAt is when the Checkpoint was made, in Unix seconds.
+type Checkpoint struct {
+	Hash          string
+	Height        uint32
+	FlushMarker   string
+	Writer        *blockinfodatabase.WriterState
+	WalletVersion uint32
+	CreatedAt     int64
+}
+
+// Manager makes Checkpoints every Config.Interval Blocks.
+// Last is the last Checkpoint made, or read back from the Config's
+// Path, or nil if there isn't one.
+// blocksSince is how many Blocks have been handled since Last.
+type Manager struct {
+	Config *Config
+	Last   *Checkpoint
+
+	blocksSince uint32
+}
+
+// New returns a Manager given a Config, with the Checkpoint already
+// at the Config's Path, if there is one.
+func New(config *Config) *Manager {
+	m := &Manager{Config: config}
+	if config.Path == "" {
+		return m
+	}
+	cp, err := Read(config.Path)
+	if err != nil && !os.IsNotExist(err) {
+		utils.Debug.Printf("[checkpoint.New] %v", err)
+	}
+	m.Last = cp
+	return m
+}
+
+// Read returns the Checkpoint written to path.
+func Read(path string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cp := &Checkpoint{}
+	if err = json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("[checkpoint.Read] failed to decode {%v}: %v", path, err)
+	}
+	return cp, nil
+}
+
+// write writes cp to path, replacing the Checkpoint there all at once,
+// so a crash never leaves half of one behind.
+func write(path string, cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("[checkpoint.write] failed to encode checkpoint: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("[checkpoint.write] failed to write {%v}: %v", tmp, err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("[checkpoint.write] failed to replace {%v}: %v", path, err)
+	}
+	return nil
+}
+
+// HandleBlock counts a Block handled by bc, and makes a Checkpoint
+// if Config.Interval Blocks have been handled since the last one. It's
+// called after the wallet has handled the Block too, with the node's
+// mutex held, so walletVersion is the wallet's state as of bc's tip.
+func (m *Manager) HandleBlock(bc *blockchain.BlockChain, walletVersion uint32) {
+	if m == nil || m.Config.Path == "" {
+		return
+	}
+	m.blocksSince++
+	if m.blocksSince < m.Config.Interval {
+		return
+	}
+	if _, err := m.Checkpoint(bc, walletVersion); err != nil {
+		utils.Debug.Printf("%v", err)
+	}
+}
+
+// Checkpoint flushes bc's CoinDatabase, and records a Checkpoint of
+// bc's tip, its ChainWriter, and the wallet's state version.
+func (m *Manager) Checkpoint(bc *blockchain.BlockChain, walletVersion uint32) (*Checkpoint, error) {
+	m.blocksSince = 0
+	if err := bc.CoinDB.Flush(bc.LastHash); err != nil {
+		return nil, fmt.Errorf("[Checkpoint] %v", err)
+	}
+	cp := &Checkpoint{
+		Hash:          bc.LastHash,
+		Height:        bc.Length,
+		FlushMarker:   bc.LastHash,
+		Writer:        bc.ChainWriter.State(),
+		WalletVersion: walletVersion,
+		CreatedAt:     time.Now().Unix(),
+	}
+	if err := write(m.Config.Path, cp); err != nil {
+		return nil, err
+	}
+	m.Last = cp
+	return cp, nil
+}
+
+// Recover brings bc's CoinDatabase up to date after a restart by
+// replaying the main chain's Blocks after the last Checkpoint, and
+// returns how many were replayed. It returns an error, without
+// replaying anything, if the Checkpoint doesn't agree with the
+// databases, in which case nothing after the genesis Block can be
+// trusted without revalidating it, or if the wallet, at walletVersion,
+// is behind it, in which case the wallet has lost Blocks and must be
+// rescanned (see Node.RestoreWallet). The wallet saves its state after
+// every Block, so it is never behind unless its state was lost.
+func (m *Manager) Recover(bc *blockchain.BlockChain, walletVersion uint32) (int, error) {
+	cp := m.Last
+	if cp == nil {
+		return 0, fmt.Errorf("[Recover] there is no checkpoint")
+	}
+	if err := m.check(bc, cp); err != nil {
+		return 0, err
+	}
+	if walletVersion < cp.WalletVersion {
+		return 0, fmt.Errorf("[Recover] wallet at state version %v is behind checkpoint {%v} at state version %v", walletVersion, cp.Hash, cp.WalletVersion)
+	}
+	replayed := 0
+	for height := cp.Height + 1; height <= bc.Length; height++ {
+		b := bc.GetBlockByHeight(height)
+		if b == nil {
+			return replayed, fmt.Errorf("[Recover] no block at height %v to replay", height)
+		}
+		bc.CoinDB.ReplayBlock(b.Transactions)
+		replayed++
+	}
+	return replayed, nil
+}
+
+// check returns an error if cp doesn't agree with bc's databases: its
+// Block must still be on the main chain, the CoinDatabase must have
+// been flushed with its marker, and the ChainWriter must not be behind
+// it.
+func (m *Manager) check(bc *blockchain.BlockChain, cp *Checkpoint) error {
+	if cp.Height > bc.Length || bc.BlockInfoDB.GetHashByHeight(cp.Height) != cp.Hash {
+		return fmt.Errorf("[Recover] checkpoint {%v} at height %v is not on the main chain", cp.Hash, cp.Height)
+	}
+	if marker := bc.CoinDB.FlushMarker(); marker != cp.FlushMarker {
+		return fmt.Errorf("[Recover] coin database was flushed at {%v}, not at checkpoint {%v}", marker, cp.FlushMarker)
+	}
+	if cp.Writer != nil && behind(bc.ChainWriter.State(), cp.Writer) {
+		return fmt.Errorf("[Recover] chain writer at %+v is behind checkpoint %+v", bc.ChainWriter.State(), cp.Writer)
+	}
+	return nil
+}
+
+// behind returns whether the ChainWriter state a is behind b, in
+// either its block files or its undo files.
+func behind(a, b *blockinfodatabase.WriterState) bool {
+	return before(a.BlockFileNumber, a.BlockOffset, b.BlockFileNumber, b.BlockOffset) ||
+		before(a.UndoFileNumber, a.UndoOffset, b.UndoFileNumber, b.UndoOffset)
+}
+
+// before returns whether offset a in file number aFile comes before
+// offset b in file number bFile.
+func before(aFile, a, bFile, b uint32) bool {
+	if aFile != bFile {
+		return aFile < bFile
+	}
+	return a < b
+}
@@ -0,0 +1,18 @@
+package checkpoint
+
+// Config is the configuration for checkpoints.
+// Path is the file the last checkpoint is written to. If it is empty,
+// no checkpoints are made.
+// Interval is how many Blocks are handled between checkpoints.
+type Config struct {
+	Path     string
+	Interval uint32
+}
+
+// DefaultConfig returns the default Config for checkpoints.
+func DefaultConfig() *Config {
+	return &Config{
+		Path:     "checkpoint.json",
+		Interval: 10,
+	}
+}
@@ -1,19 +1,40 @@
 package pkg
 
 import (
+	"Coin/pkg/backup"
 	"Coin/pkg/blockchain"
+	"Coin/pkg/broadcast"
+	"Coin/pkg/capture"
+	"Coin/pkg/chainparams"
+	"Coin/pkg/checkpoint"
+	"Coin/pkg/download"
 	"Coin/pkg/id"
+	"Coin/pkg/journal"
 	"Coin/pkg/lightning"
 	"Coin/pkg/miner"
+	"Coin/pkg/profiling"
 	"Coin/pkg/wallet"
 	"time"
 )
 
 // Config is the configuration for the node.
+// Params are the settings of the network the node is on
+// (see UseParams),
 // IdConf is the configuration for the id,
 // MinerConfig is the configuration for the miner,
 // WalletConfig is the configuration for the wallet,
 // ChainConfig is the configuration for the blockchain,
+// JournalConfig is the configuration for the event journal,
+// ProfilingConfig is the configuration for the profiler,
+// DownloadConfig is the configuration for downloading blocks
+// from peers while bootstrapping,
+// BackupConfig is the configuration for encrypted backups,
+// BroadcastConfig is the configuration for broadcasting our
+// own transactions until they are confirmed,
+// CaptureConfig is the configuration for capturing the
+// messages the node sends and receives,
+// CheckpointConfig is the configuration for checkpointing
+// the node's databases, so restarts only replay recent blocks,
 // Version is the version that the node is (used for
 // software updates),
 // PeerLimit is the maximum amount of peers the node
@@ -21,13 +42,54 @@ import (
 // AddressLimit is the maximum amount of addresses the
 // node is allowed to keep track of.
 // Port is the port that the node should run on,
+// AdminPort is the port the node serves its operator's rpcs on,
+// such as ConfirmReorg, listening on the loopback interface
+// only, or 0 to not serve them at all. Peers can't call them
+// on Port,
 // MaxBlockSize is the maximum allowed block size,
+// BlocksOnly is whether the node should only exchange
+// blocks, and not loose transactions, with all peers,
+// BlocksOnlyPeers are the addresses of peers the node
+// should only exchange blocks with,
+// TrustedPeers are the addresses of nodes that the node
+// exchanges unvalidated UTXO deltas with,
+// BlockAnnounceDelay is how long the node waits before
+// announcing a block to its peers. It is only meant for
+// experiments that simulate slow propagation, such as
+// measuring orphan rates, and is zero by default,
+// LoadBlocks are archives, block files, or other nodes' block
+// directories whose blocks the node loads when it starts, as
+// if they had come from peers (see Node.LoadBlockFiles),
+// UseSnapshots is whether a new node bootstraps from a peer's
+// snapshot of the unspent coins at the last checkpoint that
+// commits to them, instead of every block below it (see
+// Node.SyncSnapshot),
+// HaltOnCorruption is whether the node stops exchanging blocks
+// and transactions, and stops mining, once its integrity checker
+// finds the coins have diverged from the blocks (see
+// blockchain.IntegrityChecker), rather than only raising alerts,
+// TargetBlockInterval is how long the network expects a block to
+// take to be found,
+// StaleTipMultiple is how many TargetBlockIntervals can pass without
+// a new tip before the node considers its tip stale, warns, and asks
+// more peers for headers (see Node.CheckStaleTip), or 0 to never,
+// StaleTipPeers is how many more known addresses the node connects to
+// each time it finds its tip stale.
 type Config struct {
-	IdConfig        *id.Config
-	MinerConfig     *miner.Config
-	WalletConfig    *wallet.Config
-	ChainConfig     *blockchain.Config
-	LightningConfig *lightning.Config
+	Params *chainparams.Params
+
+	IdConfig         *id.Config
+	MinerConfig      *miner.Config
+	WalletConfig     *wallet.Config
+	ChainConfig      *blockchain.Config
+	LightningConfig  *lightning.Config
+	JournalConfig    *journal.Config
+	ProfilingConfig  *profiling.Config
+	DownloadConfig   *download.Config
+	BackupConfig     *backup.Config
+	BroadcastConfig  *broadcast.Config
+	CaptureConfig    *capture.Config
+	CheckpointConfig *checkpoint.Config
 
 	HasCustomId bool
 	CustomID    id.ID
@@ -36,9 +98,27 @@ type Config struct {
 	PeerLimit      int
 	AddressLimit   int
 	Port           int
+	AdminPort      int
 	VersionTimeout time.Duration
 
 	MaxBlockSize uint32
+
+	BlocksOnly      bool
+	BlocksOnlyPeers map[string]bool
+
+	TrustedPeers map[string]bool
+
+	BlockAnnounceDelay time.Duration
+
+	LoadBlocks []string
+
+	UseSnapshots bool
+
+	HaltOnCorruption bool
+
+	TargetBlockInterval time.Duration
+	StaleTipMultiple    uint32
+	StaleTipPeers       int
 }
 
 // DefaultConfig creates a Config object that
@@ -52,33 +132,69 @@ type Config struct {
 // on
 func DefaultConfig(port int) *Config {
 	c := &Config{
-		IdConfig:        id.DefaultConfig(),
-		MinerConfig:     miner.DefaultConfig(-1),
-		WalletConfig:    wallet.DefaultConfig(),
-		ChainConfig:     blockchain.DefaultConfig(),
-		LightningConfig: lightning.DefaultConfig(port + 40),
-		Version:         0,
-		PeerLimit:       20,
-		AddressLimit:    1000,
-		Port:            port,
-		VersionTimeout:  time.Second * 2,
-		MaxBlockSize:    10000000,
+		IdConfig:         id.DefaultConfig(),
+		MinerConfig:      miner.DefaultConfig(-1),
+		WalletConfig:     wallet.DefaultConfig(),
+		ChainConfig:      blockchain.DefaultConfig(),
+		LightningConfig:  lightning.DefaultConfig(port + 40),
+		JournalConfig:    journal.DefaultConfig(),
+		ProfilingConfig:  profiling.DefaultConfig(),
+		DownloadConfig:   download.DefaultConfig(),
+		BackupConfig:     backup.DefaultConfig(),
+		BroadcastConfig:  broadcast.DefaultConfig(),
+		CaptureConfig:    capture.DefaultConfig(),
+		CheckpointConfig: checkpoint.DefaultConfig(),
+		Version:          0,
+		PeerLimit:        20,
+		AddressLimit:     1000,
+		Port:             port,
+		VersionTimeout:   time.Second * 2,
+		MaxBlockSize:     10000000,
+
+		TargetBlockInterval: time.Minute * 10,
+		StaleTipMultiple:    3,
+		StaleTipPeers:       2,
 	}
+	c.UseParams(chainparams.Main)
 	return c
 }
 
 func TestingConfig(port int) *Config {
 	c := &Config{
-		IdConfig:       id.DefaultConfig(),
-		MinerConfig:    miner.DefaultConfig(-1),
-		WalletConfig:   wallet.DefaultConfig(),
-		ChainConfig:    blockchain.DefaultConfig(),
-		Version:        0,
-		PeerLimit:      20,
-		AddressLimit:   1000,
-		Port:           port,
-		VersionTimeout: time.Second * 2,
-		MaxBlockSize:   10000000,
+		IdConfig:         id.DefaultConfig(),
+		MinerConfig:      miner.DefaultConfig(-1),
+		WalletConfig:     wallet.DefaultConfig(),
+		ChainConfig:      blockchain.DefaultConfig(),
+		JournalConfig:    journal.DefaultConfig(),
+		ProfilingConfig:  profiling.DefaultConfig(),
+		DownloadConfig:   download.DefaultConfig(),
+		BackupConfig:     backup.DefaultConfig(),
+		BroadcastConfig:  broadcast.DefaultConfig(),
+		CaptureConfig:    capture.DefaultConfig(),
+		CheckpointConfig: checkpoint.DefaultConfig(),
+		Version:          0,
+		PeerLimit:        20,
+		AddressLimit:     1000,
+		Port:             port,
+		VersionTimeout:   time.Second * 2,
+		MaxBlockSize:     10000000,
+
+		TargetBlockInterval: time.Minute * 10,
+		StaleTipMultiple:    3,
+		StaleTipPeers:       2,
 	}
+	c.UseParams(chainparams.Main)
 	return c
 }
+
+// UseParams puts the node on the network p describes, by setting the
+// parts of its Config that the network decides. The node listens on
+// the network's default port, unless it was given another one.
+func (c *Config) UseParams(p *chainparams.Params) {
+	if c.Port == 0 || (c.Params != nil && c.Port == c.Params.DefaultPort) {
+		c.Port = p.DefaultPort
+	}
+	c.Params = p
+	p.ChainConfig(c.ChainConfig)
+	p.MinerConfig(c.MinerConfig)
+}
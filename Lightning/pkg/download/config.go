@@ -0,0 +1,28 @@
+package download
+
+import "time"
+
+// Config is the Downloader's configuration options.
+// RangeSize is how many consecutive Blocks are handed to
+// a peer at a time.
+// StallTimeout is how long a peer may take to send a single
+// Block before it is considered stalled, and its range is
+// handed to another peer.
+// Window is how far past the next Block to be handed over
+// Blocks may be downloaded, so that a slow peer can't make
+// the others download the whole chain into memory while
+// waiting on it. 0 means there is no limit.
+type Config struct {
+	RangeSize    int
+	StallTimeout time.Duration
+	Window       int
+}
+
+// DefaultConfig returns the Downloader's default Config.
+func DefaultConfig() *Config {
+	return &Config{
+		RangeSize:    16,
+		StallTimeout: time.Second * 5,
+		Window:       1024,
+	}
+}
@@ -0,0 +1,250 @@
+package download
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Fetcher asks the peer at addr for the Block with the given hash.
+type Fetcher func(addr string, hash string) (*block.Block, error)
+
+// PeerStats is how a peer has performed during a download.
+// Blocks is the number of Blocks the peer has sent,
+// Elapsed is the total time spent waiting on those Blocks,
+// Stalled is whether the peer stalled or failed, and was
+// dropped from the download.
+type PeerStats struct {
+	Blocks  int
+	Elapsed time.Duration
+	Stalled bool
+}
+
+// Throughput returns the Blocks per second the peer has sent.
+func (s *PeerStats) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Blocks) / s.Elapsed.Seconds()
+}
+
+// blockRange is a run of consecutive Blocks to download.
+// start is the index of the first Block in the download.
+type blockRange struct {
+	start  int
+	hashes []string
+}
+
+// Downloader fetches Blocks from several peers in parallel.
+// Config is the Downloader's configuration,
+// Fetch is how a Block is fetched from a peer,
+// stats tracks how each peer has performed.
+type Downloader struct {
+	Config *Config
+	Fetch  Fetcher
+
+	mutex sync.Mutex
+	stats map[string]*PeerStats
+}
+
+// New returns a Downloader given a Config and a Fetcher.
+func New(config *Config, fetch Fetcher) *Downloader {
+	return &Downloader{
+		Config: config,
+		Fetch:  fetch,
+		stats:  make(map[string]*PeerStats),
+	}
+}
+
+// Stats returns a copy of how each peer has performed so far.
+func (d *Downloader) Stats() map[string]PeerStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	stats := make(map[string]PeerStats)
+	for addr, s := range d.stats {
+		stats[addr] = *s
+	}
+	return stats
+}
+
+// Download fetches the Blocks with the given hashes from peers, and
+// returns them in the same order as the hashes. It returns an error
+// once every peer has been dropped, along with the Blocks before the
+// first one that wasn't downloaded (see Stream).
+func (d *Downloader) Download(peers []string, hashes []string) ([]*block.Block, error) {
+	out := make(chan *block.Block)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- d.Stream(peers, hashes, out)
+	}()
+	blocks := make([]*block.Block, 0, len(hashes))
+	for b := range out {
+		blocks = append(blocks, b)
+	}
+	return blocks, <-errs
+}
+
+// Stream fetches the Blocks with the given hashes from peers, and
+// sends them on out in the same order as the hashes, as soon as each
+// one and every one before it have been downloaded, so the caller can
+// connect Blocks while later ones are still being fetched. out is
+// closed once Stream returns.
+//
+// At a high level, this function:
+// (1) hands the hashes out in ranges of Config.RangeSize Blocks, in
+// order, to whichever peer asks for one next, so faster peers end up
+// downloading more of them
+// (2) hands out no range starting Config.Window or more Blocks past
+// the next one to send, so a slow peer holding up that Block can't
+// make the others download the whole chain into memory
+// (3) drops a peer that takes longer than Config.StallTimeout to
+// send a Block, or fails to, handing the rest of its range to the
+// next peer that asks for one, before any other range
+// (4) sends the Blocks on out in order, and returns an error once
+// every peer has been dropped with Blocks left to download.
+func (d *Downloader) Stream(peers []string, hashes []string, out chan<- *block.Block) error {
+	defer close(out)
+	if len(hashes) == 0 {
+		return nil
+	}
+	if len(peers) == 0 {
+		return fmt.Errorf("[Stream] no peers to download from")
+	}
+	var mutex sync.Mutex
+	cond := sync.NewCond(&mutex)
+	// fetched holds the Blocks downloaded but not yet sent, next is
+	// the index of the next Block to send, nextStart is the index of
+	// the first Block never handed out, retry holds the ranges put
+	// back by dropped peers, inFlight is how many ranges peers are
+	// downloading, and active is how many peers haven't been dropped.
+	fetched := make([]*block.Block, len(hashes))
+	next, nextStart := 0, 0
+	var retry []*blockRange
+	inFlight, active := 0, len(peers)
+	// take waits for a range to download, and returns nil once there
+	// are none left.
+	take := func() *blockRange {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for {
+			if len(retry) > 0 {
+				r := retry[0]
+				retry = retry[1:]
+				inFlight++
+				return r
+			}
+			if nextStart < len(hashes) && (d.Config.Window <= 0 || nextStart < next+d.Config.Window) {
+				end := nextStart + d.Config.RangeSize
+				if end > len(hashes) {
+					end = len(hashes)
+				}
+				r := &blockRange{start: nextStart, hashes: hashes[nextStart:end]}
+				nextStart = end
+				inFlight++
+				return r
+			}
+			if nextStart >= len(hashes) && inFlight == 0 {
+				return nil
+			}
+			cond.Wait()
+		}
+	}
+	store := func(i int, b *block.Block) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fetched[i] = b
+		cond.Broadcast()
+	}
+	// (1) one worker per peer
+	for _, addr := range peers {
+		go func(addr string) {
+			for r := take(); r != nil; r = take() {
+				n := d.downloadRange(addr, r, store)
+				mutex.Lock()
+				inFlight--
+				if n < len(r.hashes) {
+					// (3) hand the rest of the range to another peer
+					retry = append(retry, &blockRange{start: r.start + n, hashes: r.hashes[n:]})
+					active--
+				}
+				cond.Broadcast()
+				mutex.Unlock()
+				if n < len(r.hashes) {
+					return
+				}
+			}
+		}(addr)
+	}
+	// (4) send the Blocks in order, moving the window (2) along
+	for next < len(hashes) {
+		mutex.Lock()
+		for fetched[next] == nil && active > 0 {
+			cond.Wait()
+		}
+		b := fetched[next]
+		if b == nil {
+			mutex.Unlock()
+			return fmt.Errorf("[Stream] every peer stalled with %v of %v blocks left", len(hashes)-next, len(hashes))
+		}
+		fetched[next] = nil
+		next++
+		cond.Broadcast()
+		mutex.Unlock()
+		out <- b
+	}
+	return nil
+}
+
+// downloadRange fetches the Blocks in r from the peer at addr,
+// storing each one with its index in the download, and returns how
+// many it fetched before the peer stalled or failed.
+func (d *Downloader) downloadRange(addr string, r *blockRange, store func(int, *block.Block)) int {
+	type result struct {
+		b   *block.Block
+		err error
+	}
+	for i, hash := range r.hashes {
+		started := time.Now()
+		results := make(chan result, 1)
+		go func() {
+			b, err := d.Fetch(addr, hash)
+			results <- result{b, err}
+		}()
+		var res result
+		select {
+		case res = <-results:
+		case <-time.After(d.Config.StallTimeout):
+			res.err = fmt.Errorf("stalled for %v", d.Config.StallTimeout)
+		}
+		if res.err == nil && (res.b == nil || res.b.Hash() != hash) {
+			res.err = fmt.Errorf("sent the wrong block")
+		}
+		d.record(addr, time.Since(started), res.err == nil)
+		if res.err != nil {
+			utils.Debug.Printf("[download.Stream] dropping %v at block {%v}: %v", addr, hash, res.err)
+			return i
+		}
+		store(r.start+i, res.b)
+	}
+	return len(r.hashes)
+}
+
+// record updates the peer at addr's stats after it was waited
+// on for elapsed, and either sent a Block or stalled.
+func (d *Downloader) record(addr string, elapsed time.Duration, ok bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	s, found := d.stats[addr]
+	if !found {
+		s = &PeerStats{}
+		d.stats[addr] = s
+	}
+	s.Elapsed += elapsed
+	if ok {
+		s.Blocks++
+	} else {
+		s.Stalled = true
+	}
+}
@@ -0,0 +1,102 @@
+package id
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// HardenedOffset is added to a child's index to derive a hardened
+// child, which can't be derived from its parent's public key alone.
+const HardenedOffset uint32 = 0x80000000
+
+// masterKeySalt is the HMAC key a master key is derived from a seed
+// with.
+var masterKeySalt = []byte("Coin seed")
+
+// ExtendedKey is a private key that more keys can be derived from, in
+// the manner of BIP32, so a wallet can use a fresh key for every
+// payment while only ever backing up the seed its master key came
+// from. Keys are on the P256 curve, like every other key, and only
+// hardened children are derived, since nothing needs to derive public
+// keys without the private keys.
+// Key is the private key.
+// ChainCode is the extra entropy its children are derived with.
+type ExtendedKey struct {
+	Key       *ecdsa.PrivateKey
+	ChainCode []byte
+}
+
+// NewMasterKey returns the ExtendedKey at the root of the tree of
+// keys a seed derives.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 {
+		return nil, fmt.Errorf("[NewMasterKey] seed must be at least 16 bytes")
+	}
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	d := new(big.Int).SetBytes(sum[:32])
+	if d.Sign() == 0 || d.Cmp(elliptic.P256().Params().N) >= 0 {
+		return nil, fmt.Errorf("[NewMasterKey] seed derives an invalid key")
+	}
+	return &ExtendedKey{Key: privateKeyFromScalar(d), ChainCode: sum[32:]}, nil
+}
+
+// Child returns the hardened child of the ExtendedKey at index, which
+// must be below HardenedOffset. In the rare case that index derives an
+// invalid key, an error is returned, and the next index should be used.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if index >= HardenedOffset {
+		return nil, fmt.Errorf("[ExtendedKey.Child] index %v is too large", index)
+	}
+	n := elliptic.P256().Params().N
+	data := make([]byte, 37)
+	k.Key.D.FillBytes(data[1:33])
+	binary.BigEndian.PutUint32(data[33:], index+HardenedOffset)
+	mac := hmac.New(sha512.New, k.ChainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("[ExtendedKey.Child] index %v derives an invalid key", index)
+	}
+	d := tweak.Add(tweak, k.Key.D)
+	d.Mod(d, n)
+	if d.Sign() == 0 {
+		return nil, fmt.Errorf("[ExtendedKey.Child] index %v derives an invalid key", index)
+	}
+	return &ExtendedKey{Key: privateKeyFromScalar(d), ChainCode: sum[32:]}, nil
+}
+
+// Derive returns the descendant of the ExtendedKey at path, a list of
+// child indexes from the top down.
+func (k *ExtendedKey) Derive(path ...uint32) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		child, err := key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// ID returns the ExtendedKey's private key as a SimpleID.
+func (k *ExtendedKey) ID() (*SimpleID, error) {
+	return newSimpleID(k.Key)
+}
+
+// privateKeyFromScalar returns the P256 private key with scalar d.
+func privateKeyFromScalar(d *big.Int) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	return key
+}
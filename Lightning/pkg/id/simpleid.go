@@ -36,6 +36,11 @@ func CreateSimpleID() (*SimpleID, error) {
 	if err != nil {
 		return nil, err
 	}
+	return newSimpleID(privKey)
+}
+
+// newSimpleID returns a SimpleID for a private key.
+func newSimpleID(privKey *ecdsa.PrivateKey) (*SimpleID, error) {
 	id := &SimpleID{
 		PrivateKey: privKey,
 		PublicKey:  &privKey.PublicKey,
@@ -0,0 +1,18 @@
+package journal
+
+// Config is the Journal's configuration options.
+// Path is the file the Journal is persisted to. If it is empty,
+// the Journal is only kept in memory.
+// Capacity is the number of most recent Events the Journal keeps.
+type Config struct {
+	Path     string
+	Capacity int
+}
+
+// DefaultConfig returns the Journal's default Config.
+func DefaultConfig() *Config {
+	return &Config{
+		Path:     "journal.log",
+		Capacity: 1000,
+	}
+}
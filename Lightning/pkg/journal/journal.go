@@ -0,0 +1,222 @@
+package journal
+
+import (
+	"Coin/pkg/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Kinds of Events recorded in the Journal.
+const (
+	BlockConnected      = "block-connected"
+	BlockDisconnected   = "block-disconnected"
+	Reorg               = "reorg"
+	BlockStoreFailed    = "block-store-failed"
+	MempoolEviction     = "mempool-eviction"
+	ChannelOpened       = "channel-opened"
+	ChannelStateUpdated = "channel-state-updated"
+	ChannelClosed       = "channel-closed"
+	SwapSettled         = "swap-settled"
+	BlockInvalidated    = "block-invalidated"
+	BlockReconsidered   = "block-reconsidered"
+	SnapshotLoaded      = "snapshot-loaded"
+	IntegrityAlert      = "integrity-alert"
+	StaleTip            = "stale-tip"
+	ReorgRefused        = "reorg-refused"
+	ReorgConfirmed      = "reorg-confirmed"
+)
+
+// Event is a single significant thing the node did.
+// Timestamp is when the Event happened, in Unix nanoseconds.
+// Kind is what type of Event it was.
+// Hash is the hash of the Block or Transaction involved.
+// Detail is any additional human-readable information.
+type Event struct {
+	Timestamp int64  `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Hash      string `json:"hash"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// String returns a one-line, human-readable version of the Event.
+func (e *Event) String() string {
+	return fmt.Sprintf("%v %v %v %v", time.Unix(0, e.Timestamp).Format(time.RFC3339Nano), e.Kind, e.Hash, e.Detail)
+}
+
+// Journal is an append-only record of the most recent Events, kept
+// in a ring buffer and persisted to disk so it survives a crash.
+// events is the ring buffer, and next is where the next Event goes.
+// onDisk is how many Events are currently in the file. Once it
+// reaches twice the capacity, the file is rewritten from the ring.
+//
+// All methods are safe to call on a nil Journal, which records nothing.
+type Journal struct {
+	path   string
+	events []*Event
+	next   int
+	full   bool
+	onDisk int
+	mutex  sync.Mutex
+}
+
+// New returns a Journal given a Config, loading any Events that were
+// persisted by a previous run.
+func New(config *Config) *Journal {
+	capacity := config.Capacity
+	if capacity <= 0 {
+		capacity = DefaultConfig().Capacity
+	}
+	j := &Journal{
+		path:   config.Path,
+		events: make([]*Event, capacity),
+	}
+	if j.path == "" {
+		return j
+	}
+	events, err := ReadEvents(j.path)
+	if err != nil && !os.IsNotExist(err) {
+		utils.Debug.Printf("[journal.New] Unable to read journal {%v}: %v", j.path, err)
+	}
+	for _, e := range events {
+		j.push(e)
+	}
+	j.compact()
+	return j
+}
+
+// Record adds an Event of the given kind to the Journal.
+func (j *Journal) Record(kind string, hash string, detail string) {
+	if j == nil {
+		return
+	}
+	e := &Event{
+		Timestamp: time.Now().UnixNano(),
+		Kind:      kind,
+		Hash:      hash,
+		Detail:    detail,
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.push(e)
+	if j.path == "" {
+		return
+	}
+	if j.onDisk+1 >= 2*len(j.events) {
+		j.compact()
+		return
+	}
+	if err := appendEvents(j.path, []*Event{e}); err != nil {
+		utils.Debug.Printf("[journal.Record] Unable to write to journal {%v}: %v", j.path, err)
+		return
+	}
+	j.onDisk++
+}
+
+// Events returns the Events in the Journal, oldest first.
+func (j *Journal) Events() []*Event {
+	if j == nil {
+		return nil
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.ordered()
+}
+
+// Dump writes every Event in the Journal to w, one per line, oldest first.
+func (j *Journal) Dump(w io.Writer) error {
+	for _, e := range j.Events() {
+		if _, err := fmt.Fprintln(w, e.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadEvents returns the Events persisted in the journal file at path,
+// oldest first. It is meant for inspecting a node after a failure.
+func ReadEvents(path string) ([]*Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var events []*Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		e := &Event{}
+		// a crash mid-write can leave a partial last line, which we skip
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// push adds an Event to the ring buffer, overwriting the oldest
+// Event if the buffer is full.
+func (j *Journal) push(e *Event) {
+	j.events[j.next] = e
+	j.next = (j.next + 1) % len(j.events)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// ordered returns the Events in the ring buffer, oldest first.
+func (j *Journal) ordered() []*Event {
+	if !j.full {
+		return append([]*Event{}, j.events[:j.next]...)
+	}
+	return append(append([]*Event{}, j.events[j.next:]...), j.events[:j.next]...)
+}
+
+// compact rewrites the journal file so that it only contains the
+// Events currently in the ring buffer.
+func (j *Journal) compact() {
+	if j.path == "" {
+		return
+	}
+	events := j.ordered()
+	tmp := j.path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		utils.Debug.Printf("[journal.compact] Unable to remove {%v}: %v", tmp, err)
+	}
+	if err := appendEvents(tmp, events); err != nil {
+		utils.Debug.Printf("[journal.compact] Unable to write {%v}: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		utils.Debug.Printf("[journal.compact] Unable to replace {%v}: %v", j.path, err)
+		return
+	}
+	j.onDisk = len(events)
+}
+
+// appendEvents appends Events to a file, one JSON object per line.
+func appendEvents(path string, events []*Event) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, e := range events {
+		data, err2 := json.Marshal(e)
+		if err2 != nil {
+			file.Close()
+			return err2
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err = w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,173 @@
+package launch
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/utils"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// connectAttempts is how many times a node tries to connect to a
+// peer that may not have started yet, waiting connectDelay in between.
+const (
+	connectAttempts = 20
+	connectDelay    = 250 * time.Millisecond
+)
+
+// Network is a network of nodes launched from a Plan.
+// Nodes are the nodes running in this process, by index.
+// Processes are the nodes running in their own processes.
+type Network struct {
+	Plan      *Plan
+	Nodes     []*pkg.Node
+	Processes []*exec.Cmd
+}
+
+// PlanPath returns where the Plan for a network is written.
+func PlanPath(t *Topology) string {
+	return filepath.Join(t.DataDir, t.Name, "plan.json")
+}
+
+// Launch plans the Topology and starts its nodes: as goroutines in
+// this process, or, if the Topology says so, by running executable
+// once per node with the flags RunNode expects.
+func Launch(t *Topology, executable string) (*Network, error) {
+	p, err := t.Plan()
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Join(t.DataDir, t.Name), 0755); err != nil {
+		return nil, fmt.Errorf("[Launch] failed to make the network's directory: %v", err)
+	}
+	if err = p.Write(PlanPath(t)); err != nil {
+		return nil, err
+	}
+	if t.Subprocesses {
+		return startProcesses(p, PlanPath(t), executable)
+	}
+	return Start(p)
+}
+
+// Start starts every node in the Plan in this process, and then
+// connects them.
+func Start(p *Plan) (*Network, error) {
+	network := &Network{Plan: p}
+	for _, spec := range p.Nodes {
+		n, err := newNode(spec)
+		if err != nil {
+			network.Kill()
+			return nil, err
+		}
+		n.Start()
+		network.Nodes = append(network.Nodes, n)
+	}
+	for _, spec := range p.Nodes {
+		connect(network.Nodes[spec.Index], p, spec)
+	}
+	for _, n := range network.Nodes {
+		if n.Config.MinerConfig.HasMiner {
+			n.StartMiner()
+		}
+	}
+	return network, nil
+}
+
+// RunNode starts node i of the Plan in this process, and connects it
+// to its peers once they are up. It is what each subprocess runs.
+func RunNode(p *Plan, i int) (*pkg.Node, error) {
+	if i < 0 || i >= len(p.Nodes) {
+		return nil, fmt.Errorf("[RunNode] the plan has no node %v", i)
+	}
+	n, err := newNode(p.Nodes[i])
+	if err != nil {
+		return nil, err
+	}
+	n.Start()
+	connect(n, p, p.Nodes[i])
+	if n.Config.MinerConfig.HasMiner {
+		n.StartMiner()
+	}
+	return n, nil
+}
+
+// Kill stops every node in the Network.
+func (network *Network) Kill() {
+	for _, n := range network.Nodes {
+		n.Kill()
+	}
+	for _, cmd := range network.Processes {
+		if cmd.Process != nil {
+			if err := cmd.Process.Kill(); err != nil {
+				utils.Debug.Printf("[launch.Kill] failed to kill process %v: %v", cmd.Process.Pid, err)
+			}
+		}
+	}
+}
+
+// Wait waits for the Network's processes to exit.
+func (network *Network) Wait() {
+	for _, cmd := range network.Processes {
+		if err := cmd.Wait(); err != nil {
+			utils.Debug.Printf("[launch.Wait] %v exited: %v", cmd.Args, err)
+		}
+	}
+}
+
+// newNode makes the node for spec, and its directory.
+func newNode(spec *NodeSpec) (*pkg.Node, error) {
+	if err := os.MkdirAll(spec.DataDir, 0755); err != nil {
+		return nil, fmt.Errorf("[launch] failed to make the directory for node %v: %v", spec.Index, err)
+	}
+	return pkg.New(spec.Config())
+}
+
+// startProcesses runs executable once per node in the Plan.
+func startProcesses(p *Plan, planPath string, executable string) (*Network, error) {
+	network := &Network{Plan: p}
+	for _, spec := range p.Nodes {
+		cmd := exec.Command(executable, "-plan", planPath, "-node", strconv.Itoa(spec.Index))
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			network.Kill()
+			return nil, fmt.Errorf("[launch] failed to start node %v: %v", spec.Index, err)
+		}
+		network.Processes = append(network.Processes, cmd)
+	}
+	return network, nil
+}
+
+// connect connects n to the peers in spec with a higher index, and,
+// if both are lightning nodes, their lightning nodes too. Peering goes
+// both ways, so each connection only needs to be made once. Peers may
+// still be starting, so each is tried a few times.
+func connect(n *pkg.Node, p *Plan, spec *NodeSpec) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		utils.Debug.Printf("[launch] failed to get hostname: %v", err)
+		return
+	}
+	for _, j := range spec.Peers {
+		if j < spec.Index {
+			continue
+		}
+		peer := p.Nodes[j]
+		addr := fmt.Sprintf("%v:%v", hostname, peer.Port)
+		for attempt := 0; attempt < connectAttempts && !n.PeerDb.In(addr); attempt++ {
+			if attempt > 0 {
+				time.Sleep(connectDelay)
+			}
+			n.ConnectToPeer(addr)
+		}
+		if !n.PeerDb.In(addr) {
+			utils.Debug.Printf("[launch] node %v could not connect to node %v", spec.Index, j)
+		}
+		if spec.Role == RoleLightning && peer.Role == RoleLightning {
+			n.LightningNode.ConnectToPeer(fmt.Sprintf("%v:%v", hostname, peer.Config().LightningConfig.Port))
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package launch
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/chainparams"
+	"Coin/pkg/id"
+	"encoding/json"
+	"fmt"
+	"github.com/phayes/freeport"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// NodeSpec is a node of a Topology, with everything needed to
+// start it.
+// Index is the node's number in the Topology.
+// Role is the node's role.
+// Port is the port the node listens on. Its lightning server
+// listens 40 ports above it.
+// DataDir is where the node keeps its databases and files.
+// Peers are the nodes it is connected to.
+// AnnounceDelayMs is the Topology's AnnounceDelayMs.
+// Network is the Topology's Network.
+type NodeSpec struct {
+	Index           int    `json:"index"`
+	Role            string `json:"role"`
+	Port            int    `json:"port"`
+	DataDir         string `json:"data_dir"`
+	Peers           []int  `json:"peers"`
+	AnnounceDelayMs int    `json:"announce_delay_ms,omitempty"`
+	Network         string `json:"network,omitempty"`
+}
+
+// Plan is a Topology with ports and directories assigned to its
+// nodes. It is written to the network's directory, so that nodes
+// running as subprocesses all start from the same one.
+type Plan struct {
+	Name  string      `json:"name"`
+	Nodes []*NodeSpec `json:"nodes"`
+	Edges [][2]int    `json:"edges"`
+}
+
+// Plan assigns ports and directories to the Topology's nodes.
+func (t *Topology) Plan() (*Plan, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	p := &Plan{Name: t.Name, Edges: t.Edges()}
+	for i := 0; i < t.Size(); i++ {
+		port := t.BasePort + i
+		if t.BasePort == 0 {
+			var err error
+			if port, err = freeport.GetFreePort(); err != nil {
+				return nil, fmt.Errorf("[Plan] failed to find a free port for node %v: %v", i, err)
+			}
+		}
+		p.Nodes = append(p.Nodes, &NodeSpec{
+			Index:           i,
+			Role:            t.Role(i),
+			Port:            port,
+			DataDir:         filepath.Join(t.DataDir, t.Name, fmt.Sprintf("node%v", i)),
+			AnnounceDelayMs: t.AnnounceDelayMs,
+			Network:         t.Network,
+		})
+	}
+	for _, edge := range p.Edges {
+		p.Nodes[edge[0]].Peers = append(p.Nodes[edge[0]].Peers, edge[1])
+		p.Nodes[edge[1]].Peers = append(p.Nodes[edge[1]].Peers, edge[0])
+	}
+	return p, nil
+}
+
+// Write writes the Plan to path as JSON.
+func (p *Plan) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("[Plan.Write] failed to marshal plan: %v", err)
+	}
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("[Plan.Write] failed to write plan to %v: %v", path, err)
+	}
+	return nil
+}
+
+// ReadPlan reads a Plan written by Write.
+func ReadPlan(path string) (*Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadPlan] failed to read plan from %v: %v", path, err)
+	}
+	p := &Plan{}
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("[ReadPlan] failed to parse plan in %v: %v", path, err)
+	}
+	return p, nil
+}
+
+// Config returns the node's Config. Only miners mine, but every
+// node has a wallet, so that it can be paid.
+func (spec *NodeSpec) Config() *pkg.Config {
+	c := pkg.DefaultConfig(spec.Port)
+	// the Topology was validated, so the network is known
+	if params, err := chainparams.Get(spec.Network); err == nil {
+		c.UseParams(params)
+	}
+	if spec.Index == 0 {
+		c.HasCustomId = true
+		c.CustomID, _ = id.LoadInSmplID(blockchain.GENPK, blockchain.GENPVK)
+	}
+	c.MinerConfig.HasMiner = spec.Role == RoleMiner
+	c.ChainConfig.BlockInfoDBPath = filepath.Join(spec.DataDir, "blockinfodata")
+	c.ChainConfig.CoinDBPath = filepath.Join(spec.DataDir, "coindata")
+	c.ChainConfig.ChainWriterDBPath = filepath.Join(spec.DataDir, "data")
+	c.JournalConfig.Path = filepath.Join(spec.DataDir, "journal.log")
+	c.BackupConfig.Path = filepath.Join(spec.DataDir, "backup.bin")
+	c.CheckpointConfig.Path = filepath.Join(spec.DataDir, "checkpoint.json")
+	c.BlockAnnounceDelay = time.Duration(spec.AnnounceDelayMs) * time.Millisecond
+	return c
+}
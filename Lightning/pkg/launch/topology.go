@@ -0,0 +1,194 @@
+// Package launch starts a whole network of nodes from a description
+// of its topology, so that experiments with several miners, wallets,
+// and lightning nodes can be started with one command. The nodes run
+// either as goroutines in one process, or as one process each.
+package launch
+
+import (
+	"Coin/pkg/chainparams"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// Roles a node can have in a Topology.
+const (
+	RoleMiner     = "miner"
+	RoleWallet    = "wallet"
+	RoleLightning = "lightning"
+)
+
+// Layouts for connecting the nodes of a Topology without Links.
+const (
+	LayoutMesh = "mesh"
+	LayoutRing = "ring"
+	LayoutLine = "line"
+	LayoutStar = "star"
+)
+
+// maxNodes is the most nodes a Topology may have. Each node's
+// lightning server listens 40 ports above the node's, so with
+// consecutive ports, more nodes than this would collide.
+const maxNodes = 40
+
+// Topology describes a network of nodes.
+// Name names the network, and the directory its data is kept in.
+// Miners, Wallets, and Lightning are how many nodes of each role
+// the network has. Nodes are numbered in that order, and node 0 is
+// always the genesis node, which owns the genesis coins.
+// Links are the pairs of nodes that are connected to each other.
+// If there are none, the nodes are connected according to Layout.
+// Layout is one of mesh (the default), ring, line, or star.
+// BasePort is the port of node 0, and node i gets BasePort + i.
+// If it is 0, free ports are picked instead.
+// DataDir is where the directory for the network's data is made.
+// Subprocesses is whether each node runs in its own process.
+// AnnounceDelayMs is how many milliseconds each node waits before
+// announcing a block, to simulate slow propagation.
+// Network is the name of the chain the nodes run (see chainparams),
+// or empty for the main chain.
+type Topology struct {
+	Name            string   `json:"name"`
+	Miners          int      `json:"miners"`
+	Wallets         int      `json:"wallets"`
+	Lightning       int      `json:"lightning"`
+	Links           [][2]int `json:"links,omitempty"`
+	Layout          string   `json:"layout,omitempty"`
+	BasePort        int      `json:"base_port,omitempty"`
+	DataDir         string   `json:"data_dir,omitempty"`
+	Subprocesses    bool     `json:"subprocesses,omitempty"`
+	AnnounceDelayMs int      `json:"announce_delay_ms,omitempty"`
+	Network         string   `json:"network,omitempty"`
+}
+
+// Profiles are the named Topologies that can be launched without a
+// topology file.
+var Profiles = map[string]*Topology{
+	"solo":      {Name: "solo", Miners: 1},
+	"small":     {Name: "small", Miners: 2, Wallets: 2},
+	"ring":      {Name: "ring", Miners: 4, Wallets: 4, Layout: LayoutRing},
+	"lightning": {Name: "lightning", Miners: 1, Lightning: 3, Layout: LayoutLine},
+	"star":      {Name: "star", Miners: 1, Wallets: 6, Layout: LayoutStar},
+}
+
+// ProfileNames returns the names of the Profiles, sorted.
+func ProfileNames() []string {
+	var names []string
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the Profile named nameOrPath, or else the Topology in
+// the JSON file at nameOrPath.
+func Load(nameOrPath string) (*Topology, error) {
+	if profile, ok := Profiles[nameOrPath]; ok {
+		t := *profile
+		return &t, t.Validate()
+	}
+	data, err := ioutil.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("[launch.Load] %v is neither a profile nor a readable topology file: %v", nameOrPath, err)
+	}
+	t := &Topology{}
+	if err = json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("[launch.Load] failed to parse topology file %v: %v", nameOrPath, err)
+	}
+	return t, t.Validate()
+}
+
+// Size returns how many nodes the Topology has.
+func (t *Topology) Size() int {
+	return t.Miners + t.Wallets + t.Lightning
+}
+
+// Role returns the role of node i.
+func (t *Topology) Role(i int) string {
+	switch {
+	case i < t.Miners:
+		return RoleMiner
+	case i < t.Miners+t.Wallets:
+		return RoleWallet
+	default:
+		return RoleLightning
+	}
+}
+
+// Validate returns an error if the Topology can't be launched.
+func (t *Topology) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("[Validate] the topology has no name")
+	}
+	if t.Miners < 0 || t.Wallets < 0 || t.Lightning < 0 {
+		return fmt.Errorf("[Validate] node counts can't be negative")
+	}
+	if t.Size() == 0 || t.Size() > maxNodes {
+		return fmt.Errorf("[Validate] the topology has %v nodes, but must have between 1 and %v", t.Size(), maxNodes)
+	}
+	if t.BasePort < 0 || t.BasePort+t.Size()+40 > 65535 {
+		return fmt.Errorf("[Validate] base port %v leaves no room for %v nodes", t.BasePort, t.Size())
+	}
+	for _, link := range t.Links {
+		if link[0] == link[1] || link[0] < 0 || link[1] < 0 || link[0] >= t.Size() || link[1] >= t.Size() {
+			return fmt.Errorf("[Validate] link %v is not between two of the %v nodes", link, t.Size())
+		}
+	}
+	switch t.Layout {
+	case "", LayoutMesh, LayoutRing, LayoutLine, LayoutStar:
+	default:
+		return fmt.Errorf("[Validate] unknown layout %v", t.Layout)
+	}
+	if t.Network != "" {
+		if _, err := chainparams.Get(t.Network); err != nil {
+			return fmt.Errorf("[Validate] %v", err)
+		}
+	}
+	return nil
+}
+
+// Edges returns the pairs of nodes that are connected, each once,
+// with the lower index first.
+func (t *Topology) Edges() [][2]int {
+	seen := make(map[[2]int]bool)
+	var edges [][2]int
+	add := func(i, j int) {
+		if i > j {
+			i, j = j, i
+		}
+		if i != j && !seen[[2]int{i, j}] {
+			seen[[2]int{i, j}] = true
+			edges = append(edges, [2]int{i, j})
+		}
+	}
+	if len(t.Links) > 0 {
+		for _, link := range t.Links {
+			add(link[0], link[1])
+		}
+		return edges
+	}
+	n := t.Size()
+	switch t.Layout {
+	case LayoutRing:
+		for i := 0; i < n; i++ {
+			add(i, (i+1)%n)
+		}
+	case LayoutLine:
+		for i := 0; i+1 < n; i++ {
+			add(i, i+1)
+		}
+	case LayoutStar:
+		for i := 1; i < n; i++ {
+			add(0, i)
+		}
+	default:
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				add(i, j)
+			}
+		}
+	}
+	return edges
+}
@@ -3,9 +3,11 @@ package lightning
 import (
 	"Coin/pkg/block"
 	"Coin/pkg/id"
+	"Coin/pkg/journal"
 	"Coin/pkg/peer"
 	"Coin/pkg/pro"
 	"Coin/pkg/script"
+	"fmt"
 )
 
 // Channel is our node's view of a channel
@@ -17,6 +19,10 @@ import (
 // Transactions is the slice of transactions, indexed by state
 // MyRevocationKeys is a mapping of my private revocation keys
 // TheirRevocationKeys is a mapping of their private revocation keys
+// MyShutdownScript is the script we committed to being paid to when
+// the channel is closed, if we committed to one
+// TheirShutdownScript is the script the other node committed to being
+// paid to when the channel is closed, if they committed to one
 type Channel struct {
 	Funder             bool
 	FundingTransaction *block.Transaction
@@ -28,6 +34,9 @@ type Channel struct {
 
 	MyRevocationKeys    map[string][]byte
 	TheirRevocationKeys map[string]*RevocationInfo
+
+	MyShutdownScript    []byte
+	TheirShutdownScript []byte
 }
 
 type RevocationInfo struct {
@@ -59,6 +68,8 @@ func (ln *LightningNode) CreateChannel(peer *peer.Peer, theirPubKey []byte, amou
 	
 		MyRevocationKeys: make(map[string][]byte), // create a new map, the key is a string and the value is []byte 
 		TheirRevocationKeys: make(map[string]*RevocationInfo),
+
+		MyShutdownScript: ln.Config.UpfrontShutdownScript,
 	}
 	ln.Channels[peer] = cha
 
@@ -84,11 +95,13 @@ func (ln *LightningNode) CreateChannel(peer *peer.Peer, theirPubKey []byte, amou
 		PublicKey: ln.Id.GetPublicKeyBytes(),
 		FundingTransaction: block.EncodeTransaction(receive_trans),
 		RefundTransaction: block.EncodeTransaction(refund_trans),
+		UpfrontShutdownScript: cha.MyShutdownScript,
 	}
 
 	res, _ := peer.Addr.OpenChannelRPC(open_cha) // peer is a struct 
 
 	cha.FundingTransaction = block.DecodeTransaction(res.SignedFundingTransaction)
+	cha.TheirShutdownScript = res.GetUpfrontShutdownScript()
 	trans1 := block.DecodeTransaction(res.SignedRefundTransaction)
 	tmp1 := []*block.Transaction{trans1}
 	cha.MyTransactions = append(tmp1, cha.MyTransactions...) // ...:  passing its elements as separate arguments
@@ -98,6 +111,7 @@ func (ln *LightningNode) CreateChannel(peer *peer.Peer, theirPubKey []byte, amou
 
 	ln.ValidateAndSign(receive_trans)
 	ln.BroadcastTransaction <- receive_trans
+	ln.Journal.Record(journal.ChannelOpened, receive_trans.Hash(), fmt.Sprintf("funder with %v", peer.Addr.Addr))
 
 }
 
@@ -149,4 +163,5 @@ func (ln *LightningNode) UpdateState(peer *peer.Peer, tx *block.Transaction) {
 	}
 
 	cha.TheirRevocationKeys[trans_hash] = revo
+	ln.Journal.Record(journal.ChannelStateUpdated, trans_hash, fmt.Sprintf("state %v with %v", cha.State, peer.Addr.Addr))
 }
@@ -2,9 +2,19 @@ package lightning
 
 import (
 	"Coin/pkg/id"
+	"Coin/pkg/lightning/invoicedatabase"
 	"time"
 )
 
+// Config is the configuration for the lightning node.
+// ProbeTTL is how long the result of a probe is reused
+// before the route is probed again.
+// UpfrontShutdownScript is the script that our funds must be paid to
+// whenever a channel is cooperatively closed. It is committed to when
+// the channel is opened, so that a compromised node can't later redirect
+// closing funds. If it is empty, no script is committed to.
+// InvoiceConfig is the configuration for the database of invoices we
+// create and pay.
 type Config struct {
 	IdConfig         *id.Config
 	LockTime         uint32
@@ -13,6 +23,12 @@ type Config struct {
 
 	Port           int
 	VersionTimeout time.Duration
+
+	ProbeTTL time.Duration
+
+	UpfrontShutdownScript []byte
+
+	InvoiceConfig *invoicedatabase.Config
 }
 
 func DefaultConfig(port int) *Config {
@@ -22,5 +38,7 @@ func DefaultConfig(port int) *Config {
 		Version:        0,
 		Port:           port,
 		VersionTimeout: time.Second * 2,
+		ProbeTTL:       time.Minute * 10,
+		InvoiceConfig:  invoicedatabase.DefaultConfig(),
 	}
 }
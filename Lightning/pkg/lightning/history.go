@@ -0,0 +1,116 @@
+package lightning
+
+import (
+	"Coin/pkg/peer"
+	"fmt"
+	"strings"
+)
+
+// CommitmentRecord describes one state of a channel.
+// State is the state's number, starting at 0 with the refund
+// transaction.
+// MyTransaction and TheirTransaction are the hashes of our and the
+// other node's commitment transactions for the state.
+// MyBalance and TheirBalance are what each side is paid in the state.
+// Revoked is whether the state has been replaced by a later one, so
+// broadcasting it would let the other side take every coin.
+// TheirRevocationKey is whether we hold a revocation key from the other
+// node for one of the state's transactions.
+type CommitmentRecord struct {
+	State              int    `json:"state"`
+	MyTransaction      string `json:"my_transaction"`
+	TheirTransaction   string `json:"their_transaction"`
+	MyBalance          uint32 `json:"my_balance"`
+	TheirBalance       uint32 `json:"their_balance"`
+	Revoked            bool   `json:"revoked"`
+	TheirRevocationKey bool   `json:"their_revocation_key"`
+}
+
+// ChannelHistory is every state a channel has been through, for
+// auditing how it evolved before a dispute.
+// ChannelID is the hash of the channel's funding transaction.
+// Peer is the address of the other node in the channel.
+// Funder is whether we funded the channel.
+// State is the channel's current state.
+// Commitments are the channel's states, in order.
+type ChannelHistory struct {
+	ChannelID   string              `json:"channel_id"`
+	Peer        string              `json:"peer"`
+	Funder      bool                `json:"funder"`
+	State       int                 `json:"state"`
+	Commitments []*CommitmentRecord `json:"commitments"`
+}
+
+// ChannelID returns the ID of the channel: the hash of its funding
+// transaction, or "" if it hasn't been funded yet.
+func (c *Channel) ChannelID() string {
+	if c.FundingTransaction == nil {
+		return ""
+	}
+	return c.FundingTransaction.Hash()
+}
+
+// ExportChannelHistory returns the ChannelHistory of the channel with
+// channelID, which is the hash of its funding transaction.
+func (ln *LightningNode) ExportChannelHistory(channelID string) (*ChannelHistory, error) {
+	for p, cha := range ln.Channels {
+		if cha.ChannelID() == channelID {
+			return cha.history(p), nil
+		}
+	}
+	return nil, fmt.Errorf("[ExportChannelHistory] there is no channel {%v}", channelID)
+}
+
+// history returns the ChannelHistory of the channel we have with p.
+func (c *Channel) history(p *peer.Peer) *ChannelHistory {
+	h := &ChannelHistory{
+		ChannelID: c.ChannelID(),
+		Funder:    c.Funder,
+		State:     c.State,
+	}
+	if p != nil && p.Addr != nil {
+		h.Peer = p.Addr.Addr
+	}
+	for i := range c.MyTransactions {
+		r := &CommitmentRecord{
+			State:         i,
+			MyTransaction: c.MyTransactions[i].Hash(),
+			Revoked:       i < c.State,
+		}
+		r.MyBalance, r.TheirBalance = c.balancesAt(i)
+		_, r.TheirRevocationKey = c.TheirRevocationKeys[r.MyTransaction]
+		if i < len(c.TheirTransactions) {
+			r.TheirTransaction = c.TheirTransactions[i].Hash()
+			if _, ok := c.TheirRevocationKeys[r.TheirTransaction]; ok {
+				r.TheirRevocationKey = true
+			}
+		}
+		h.Commitments = append(h.Commitments, r)
+	}
+	return h
+}
+
+// String returns the ChannelHistory as a table, one state per line.
+func (h *ChannelHistory) String() string {
+	var b strings.Builder
+	role := "fundee"
+	if h.Funder {
+		role = "funder"
+	}
+	fmt.Fprintf(&b, "channel %v with %v (we are the %v), at state %v\n", h.ChannelID, h.Peer, role, h.State)
+	fmt.Fprintf(&b, "%-6v %-12v %-12v %-8v %-10v %-16v %v\n", "state", "mine", "theirs", "revoked", "their key", "my tx", "their tx")
+	for _, r := range h.Commitments {
+		fmt.Fprintf(&b, "%-6v %-12v %-12v %-8v %-10v %-16v %v\n", r.State, r.MyBalance, r.TheirBalance, r.Revoked,
+			r.TheirRevocationKey, shortHash(r.MyTransaction), shortHash(r.TheirTransaction))
+	}
+	return b.String()
+}
+
+// shortHash returns the start of a hash, which is enough to tell
+// transactions apart in a table.
+func shortHash(hash string) string {
+	if len(hash) > 16 {
+		return hash[:16]
+	}
+	return hash
+}
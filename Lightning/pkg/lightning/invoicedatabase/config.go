@@ -0,0 +1,14 @@
+package invoicedatabase
+
+// Config is the InvoiceDatabase's configuration options.
+// DatabasePath is where the InvoiceDatabase's levelDB is
+// stored. If it is empty, invoices are only kept in memory.
+type Config struct {
+	DatabasePath string
+}
+
+// DefaultConfig returns the default configuration for the
+// InvoiceDatabase.
+func DefaultConfig() *Config {
+	return &Config{DatabasePath: ""}
+}
@@ -0,0 +1,109 @@
+package invoicedatabase
+
+import (
+	"Coin/pkg/pro"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Invoice is a request for a lightning payment, either one we
+// created to be paid, or one we paid.
+// PaymentHash is the SHA-256 hash of the Preimage, and identifies the
+// Invoice.
+// Preimage is the secret that the payment is settled with. We know it
+// from the start for Invoices we create, and learn it once an
+// Invoice we paid is settled.
+// Amount is how much the Invoice is for.
+// Memo is a human-readable description of what the Invoice is for.
+// Incoming is whether we created the Invoice, as opposed to paid it.
+// CreatedAt is when the Invoice was added, in Unix seconds.
+// SettledAt is when the Invoice was settled, in Unix seconds, or 0 if
+// it hasn't been.
+type Invoice struct {
+	PaymentHash []byte
+	Preimage    []byte
+	Amount      uint32
+	Memo        string
+	Incoming    bool
+	CreatedAt   int64
+	SettledAt   int64
+}
+
+// Settled returns whether the Invoice has been settled.
+func (inv *Invoice) Settled() bool {
+	return inv.SettledAt != 0
+}
+
+// EncodeInvoice returns a pro.Invoice given an Invoice.
+func EncodeInvoice(inv *Invoice) *pro.Invoice {
+	return &pro.Invoice{
+		PaymentHash: inv.PaymentHash,
+		Preimage:    inv.Preimage,
+		Amount:      inv.Amount,
+		Memo:        inv.Memo,
+		Incoming:    inv.Incoming,
+		CreatedAt:   inv.CreatedAt,
+		SettledAt:   inv.SettledAt,
+	}
+}
+
+// DecodeInvoice returns an Invoice given a pro.Invoice.
+func DecodeInvoice(pinv *pro.Invoice) *Invoice {
+	return &Invoice{
+		PaymentHash: pinv.GetPaymentHash(),
+		Preimage:    pinv.GetPreimage(),
+		Amount:      pinv.GetAmount(),
+		Memo:        pinv.GetMemo(),
+		Incoming:    pinv.GetIncoming(),
+		CreatedAt:   pinv.GetCreatedAt(),
+		SettledAt:   pinv.GetSettledAt(),
+	}
+}
+
+// VerifySettlement returns whether preimage settles the payment with
+// paymentHash. Since only whoever created an Invoice knows its
+// preimage until it is paid, a payer holding the preimage can prove
+// that the payment was settled.
+func VerifySettlement(paymentHash []byte, preimage []byte) bool {
+	hash := sha256.Sum256(preimage)
+	return len(preimage) > 0 && bytes.Equal(hash[:], paymentHash)
+}
+
+// SettlementProof is an Invoice in the form it is exported in, so that
+// a settled payment can be proven without the node. Byte fields are hex.
+// Preimage is only filled in once the Invoice is settled, so that
+// exporting never gives away the secret of an unpaid Invoice.
+type SettlementProof struct {
+	PaymentHash string `json:"payment_hash"`
+	Preimage    string `json:"preimage,omitempty"`
+	Amount      uint32 `json:"amount"`
+	Memo        string `json:"memo,omitempty"`
+	Incoming    bool   `json:"incoming"`
+	CreatedAt   int64  `json:"created_at"`
+	SettledAt   int64  `json:"settled_at,omitempty"`
+}
+
+// Proof returns the SettlementProof for the Invoice.
+func (inv *Invoice) Proof() *SettlementProof {
+	proof := &SettlementProof{
+		PaymentHash: hex.EncodeToString(inv.PaymentHash),
+		Amount:      inv.Amount,
+		Memo:        inv.Memo,
+		Incoming:    inv.Incoming,
+		CreatedAt:   inv.CreatedAt,
+		SettledAt:   inv.SettledAt,
+	}
+	if inv.Settled() {
+		proof.Preimage = hex.EncodeToString(inv.Preimage)
+	}
+	return proof
+}
+
+// Verify returns whether the SettlementProof proves that its payment
+// was settled.
+func (proof *SettlementProof) Verify() bool {
+	paymentHash, err1 := hex.DecodeString(proof.PaymentHash)
+	preimage, err2 := hex.DecodeString(proof.Preimage)
+	return err1 == nil && err2 == nil && VerifySettlement(paymentHash, preimage)
+}
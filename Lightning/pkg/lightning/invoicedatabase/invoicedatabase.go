@@ -0,0 +1,224 @@
+package invoicedatabase
+
+import (
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InvoiceDatabase is a wrapper for a levelDB that stores Invoices,
+// keyed by their hex-encoded PaymentHash.
+// mutex keeps Settle from racing with other writes to an Invoice.
+type InvoiceDatabase struct {
+	db    *leveldb.DB
+	mutex sync.Mutex
+}
+
+// New returns an InvoiceDatabase given a Config.
+func New(config *Config) *InvoiceDatabase {
+	var db *leveldb.DB
+	var err error
+	if config.DatabasePath == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, _, err = utils.OpenLevelDB(config.DatabasePath, false)
+	}
+	if err != nil {
+		utils.Debug.Printf("Unable to initialize InvoiceDatabase with path {%v}: %v", config.DatabasePath, err)
+	}
+	return &InvoiceDatabase{db: db}
+}
+
+// Query selects which Invoices ListInvoices and Export return.
+// SettledOnly is whether to only select settled Invoices.
+// IncomingOnly is whether to only select Invoices we created.
+// OutgoingOnly is whether to only select Invoices we paid.
+// Since and Until bound when the Invoices were created, in Unix
+// seconds. A bound of 0 is no bound.
+type Query struct {
+	SettledOnly  bool
+	IncomingOnly bool
+	OutgoingOnly bool
+	Since        int64
+	Until        int64
+}
+
+// Matches returns whether the Query selects inv. A nil Query selects
+// every Invoice.
+func (q *Query) Matches(inv *Invoice) bool {
+	if q == nil {
+		return true
+	}
+	switch {
+	case q.SettledOnly && !inv.Settled():
+		return false
+	case q.IncomingOnly && !inv.Incoming:
+		return false
+	case q.OutgoingOnly && inv.Incoming:
+		return false
+	case q.Since != 0 && inv.CreatedAt < q.Since:
+		return false
+	case q.Until != 0 && inv.CreatedAt > q.Until:
+		return false
+	}
+	return true
+}
+
+// AddInvoice creates an Invoice for amount for others to pay us,
+// with a fresh random Preimage, and stores it.
+func (invoiceDB *InvoiceDatabase) AddInvoice(amount uint32, memo string) (*Invoice, error) {
+	preimage := make([]byte, 32)
+	if _, err := rand.Read(preimage); err != nil {
+		return nil, fmt.Errorf("[AddInvoice] failed to generate preimage: %v", err)
+	}
+	paymentHash := sha256.Sum256(preimage)
+	inv := &Invoice{
+		PaymentHash: paymentHash[:],
+		Preimage:    preimage,
+		Amount:      amount,
+		Memo:        memo,
+		Incoming:    true,
+		CreatedAt:   time.Now().Unix(),
+	}
+	invoiceDB.mutex.Lock()
+	defer invoiceDB.mutex.Unlock()
+	if err := invoiceDB.put(inv); err != nil {
+		return nil, fmt.Errorf("[AddInvoice] %v", err)
+	}
+	return inv, nil
+}
+
+// AddPayment stores an Invoice created by someone else that we are
+// paying. Its Preimage is filled in when it is settled.
+func (invoiceDB *InvoiceDatabase) AddPayment(paymentHash []byte, amount uint32, memo string) (*Invoice, error) {
+	if len(paymentHash) != sha256.Size {
+		return nil, fmt.Errorf("[AddPayment] payment hash is %v bytes long", len(paymentHash))
+	}
+	invoiceDB.mutex.Lock()
+	defer invoiceDB.mutex.Unlock()
+	if invoiceDB.get(paymentHash) != nil {
+		return nil, fmt.Errorf("[AddPayment] there is already an invoice for payment hash %v", hex.EncodeToString(paymentHash))
+	}
+	inv := &Invoice{
+		PaymentHash: paymentHash,
+		Amount:      amount,
+		Memo:        memo,
+		Incoming:    false,
+		CreatedAt:   time.Now().Unix(),
+	}
+	if err := invoiceDB.put(inv); err != nil {
+		return nil, fmt.Errorf("[AddPayment] %v", err)
+	}
+	return inv, nil
+}
+
+// Settle marks the Invoice for paymentHash as settled by preimage,
+// which is kept as proof of the settlement. Settling an Invoice that
+// is already settled does nothing.
+func (invoiceDB *InvoiceDatabase) Settle(paymentHash []byte, preimage []byte) (*Invoice, error) {
+	if !VerifySettlement(paymentHash, preimage) {
+		return nil, fmt.Errorf("[Settle] preimage doesn't hash to payment hash %v", hex.EncodeToString(paymentHash))
+	}
+	invoiceDB.mutex.Lock()
+	defer invoiceDB.mutex.Unlock()
+	inv := invoiceDB.get(paymentHash)
+	if inv == nil {
+		return nil, fmt.Errorf("[Settle] there is no invoice for payment hash %v", hex.EncodeToString(paymentHash))
+	}
+	if inv.Settled() {
+		return inv, nil
+	}
+	inv.Preimage = preimage
+	inv.SettledAt = time.Now().Unix()
+	if err := invoiceDB.put(inv); err != nil {
+		return nil, fmt.Errorf("[Settle] %v", err)
+	}
+	return inv, nil
+}
+
+// GetInvoice returns the Invoice for paymentHash, or nil if there is
+// none.
+func (invoiceDB *InvoiceDatabase) GetInvoice(paymentHash []byte) *Invoice {
+	return invoiceDB.get(paymentHash)
+}
+
+// ListInvoices returns every Invoice that q selects, oldest first.
+func (invoiceDB *InvoiceDatabase) ListInvoices(q *Query) []*Invoice {
+	var invoices []*Invoice
+	iterator := invoiceDB.db.NewIterator(nil, nil)
+	defer iterator.Release()
+	for iterator.Next() {
+		pinv := &pro.Invoice{}
+		if err := proto.Unmarshal(iterator.Value(), pinv); err != nil {
+			utils.Debug.Printf("[ListInvoices] Failed to unmarshal invoice {%v}: %v", string(iterator.Key()), err)
+			continue
+		}
+		if inv := DecodeInvoice(pinv); q.Matches(inv) {
+			invoices = append(invoices, inv)
+		}
+	}
+	if err := iterator.Error(); err != nil {
+		utils.Debug.Printf("[ListInvoices] Failed to iterate over invoices: %v", err)
+	}
+	sort.SliceStable(invoices, func(i, j int) bool {
+		return invoices[i].CreatedAt < invoices[j].CreatedAt
+	})
+	return invoices
+}
+
+// Export writes the SettlementProof of every Invoice that q selects
+// to out as a JSON array, oldest first.
+func (invoiceDB *InvoiceDatabase) Export(out io.Writer, q *Query) error {
+	proofs := []*SettlementProof{}
+	for _, inv := range invoiceDB.ListInvoices(q) {
+		proofs = append(proofs, inv.Proof())
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(proofs)
+}
+
+// Close closes the InvoiceDatabase's levelDB.
+func (invoiceDB *InvoiceDatabase) Close() {
+	invoiceDB.db.Close()
+}
+
+// get returns the Invoice for paymentHash, or nil if there is none.
+func (invoiceDB *InvoiceDatabase) get(paymentHash []byte) *Invoice {
+	data, err := invoiceDB.db.Get([]byte(hex.EncodeToString(paymentHash)), nil)
+	if err != nil {
+		if err != leveldb.ErrNotFound {
+			utils.Debug.Printf("[GetInvoice] Unable to read invoice {%v}: %v", hex.EncodeToString(paymentHash), err)
+		}
+		return nil
+	}
+	pinv := &pro.Invoice{}
+	if err = proto.Unmarshal(data, pinv); err != nil {
+		utils.Debug.Printf("[GetInvoice] Failed to unmarshal invoice {%v}: %v", hex.EncodeToString(paymentHash), err)
+		return nil
+	}
+	return DecodeInvoice(pinv)
+}
+
+// put stores inv, replacing any Invoice with the same PaymentHash.
+func (invoiceDB *InvoiceDatabase) put(inv *Invoice) error {
+	data, err := proto.Marshal(EncodeInvoice(inv))
+	if err != nil {
+		return fmt.Errorf("failed to marshal invoice: %v", err)
+	}
+	if err = invoiceDB.db.Put([]byte(hex.EncodeToString(inv.PaymentHash)), data, nil); err != nil {
+		return fmt.Errorf("failed to store invoice: %v", err)
+	}
+	return nil
+}
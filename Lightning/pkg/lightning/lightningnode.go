@@ -5,6 +5,8 @@ import (
 	"Coin/pkg/address/addressdb"
 	"Coin/pkg/block"
 	"Coin/pkg/id"
+	"Coin/pkg/journal"
+	"Coin/pkg/lightning/invoicedatabase"
 	"Coin/pkg/peer"
 	"Coin/pkg/pro"
 	"Coin/pkg/utils"
@@ -30,9 +32,14 @@ type WalletRequest struct {
 // GetTransactionFromWallet: a channel to get a transaction from the wallet
 // ReceiveTransactionFromWallet: a channel to receive a transaction from the wallet
 // RevocationKeys: channel to send revocationKeys to watchtower
+// Journal: records channels opening and changing state
+// Router: caches the results of probing routes
+// Invoices: the invoices we have created and paid
+// ServerOptions: extra options for the server, such as interceptors
 type LightningNode struct {
 	*pro.UnimplementedLightningServer
-	Server *grpc.Server
+	Server        *grpc.Server
+	ServerOptions []grpc.ServerOption
 
 	Config  *Config
 	Address string
@@ -48,12 +55,21 @@ type LightningNode struct {
 
 	RevocationKeys chan *RevocationInfo
 
+	Journal *journal.Journal
+	Router  *Router
+
+	Invoices *invoicedatabase.InvoiceDatabase
+
 	AddressDB addressdb.AddressDb
 	PeerDb    peer.PeerDb
 }
 
 func New(config *Config) *LightningNode {
 	i, _ := id.New(config.IdConfig)
+	var invoices *invoicedatabase.InvoiceDatabase
+	if config.InvoiceConfig != nil {
+		invoices = invoicedatabase.New(config.InvoiceConfig)
+	}
 	return &LightningNode{
 		Config:                       config,
 		Id:                           i,
@@ -64,6 +80,8 @@ func New(config *Config) *LightningNode {
 		ReceiveTransactionFromWallet: make(chan *block.Transaction),
 		RevocationKeys:               make(chan *RevocationInfo),
 		Channels:                     make(map[*peer.Peer]*Channel),
+		Router:                       NewRouter(config.ProbeTTL),
+		Invoices:                     invoices,
 	}
 }
 
@@ -103,7 +121,7 @@ func (ln *LightningNode) StartServer(address string) {
 		panic(err)
 	}
 	// Open node to connections
-	ln.Server = grpc.NewServer()
+	ln.Server = grpc.NewServer(ln.ServerOptions...)
 	pro.RegisterLightningServer(ln.Server, ln)
 	go func() {
 		err = ln.Server.Serve(lis)
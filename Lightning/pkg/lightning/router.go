@@ -0,0 +1,179 @@
+package lightning
+
+import (
+	"Coin/pkg/peer"
+	"Coin/pkg/pro"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ProbeUnknownPaymentHash is the failure returned by a node that
+	// could have carried a probe, but doesn't know its payment hash.
+	ProbeUnknownPaymentHash = "unknown payment hash"
+	// ProbeInsufficientLiquidity is the failure returned by a node when
+	// the channel can't carry the probe's amount.
+	ProbeInsufficientLiquidity = "insufficient liquidity"
+)
+
+// ProbeResult is the outcome of probing for a route.
+// Destination is the address of the node that was probed,
+// Amount is the amount that was probed,
+// Route is the addresses of the hops along the route that was
+// found (or last tried), ending with the destination,
+// Sufficient is whether the route can carry Amount,
+// Failure is the failure reported by the route,
+// ProbedAt is when the probe was made.
+type ProbeResult struct {
+	Destination string
+	Amount      uint32
+	Route       []string
+	Sufficient  bool
+	Failure     string
+	ProbedAt    time.Time
+}
+
+type probeKey struct {
+	destination string
+	amount      uint32
+}
+
+// Router keeps the results of recent probes, so that
+// a payment can reuse them instead of probing again.
+// TTL is how long a result is kept,
+// results maps a destination and amount to its result.
+type Router struct {
+	TTL time.Duration
+
+	mutex   sync.Mutex
+	results map[probeKey]*ProbeResult
+}
+
+// NewRouter returns a Router that keeps probe results for ttl.
+func NewRouter(ttl time.Duration) *Router {
+	return &Router{
+		TTL:     ttl,
+		results: make(map[probeKey]*ProbeResult),
+	}
+}
+
+// Get returns the cached result of probing destination for amount,
+// or nil if there is none or it has expired.
+func (r *Router) Get(destination string, amount uint32) *ProbeResult {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	key := probeKey{destination, amount}
+	result, ok := r.results[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(result.ProbedAt) > r.TTL {
+		delete(r.results, key)
+		return nil
+	}
+	return result
+}
+
+// Put caches a probe result.
+func (r *Router) Put(result *ProbeResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.results[probeKey{result.Destination, result.Amount}] = result
+}
+
+// Probe finds out whether we can pay amount to the node at
+// destination, without committing to a payment. It offers each
+// candidate route an HTLC whose payment hash is random, so that the
+// destination can never fulfill it. A route that fails with
+// ProbeUnknownPaymentHash could have carried the payment.
+// Results are cached in the Router for its TTL.
+// Channels are only ever opened between peers, so the candidate
+// routes are our direct channels to the destination.
+func (ln *LightningNode) Probe(destination string, amount uint32) (*ProbeResult, error) {
+	if result := ln.Router.Get(destination, amount); result != nil {
+		return result, nil
+	}
+	routes := ln.candidateRoutes(destination)
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("[Probe] no route to %v", destination)
+	}
+	result := &ProbeResult{
+		Destination: destination,
+		Amount:      amount,
+		ProbedAt:    time.Now(),
+	}
+	for _, p := range routes {
+		result.Route = []string{p.Addr.Addr}
+		result.Failure = ln.probeRoute(p, amount)
+		if result.Failure == ProbeUnknownPaymentHash {
+			result.Sufficient = true
+			break
+		}
+	}
+	ln.Router.Put(result)
+	return result, nil
+}
+
+// candidateRoutes returns the peers we have a channel with
+// that can reach destination.
+func (ln *LightningNode) candidateRoutes(destination string) []*peer.Peer {
+	var routes []*peer.Peer
+	for p := range ln.Channels {
+		if p.Addr.Addr == destination {
+			routes = append(routes, p)
+		}
+	}
+	return routes
+}
+
+// probeRoute sends a probe for amount to p, and returns the failure
+// it reports. Our own side of the channel is checked first, so that
+// we don't ask p about a payment we couldn't make.
+func (ln *LightningNode) probeRoute(p *peer.Peer, amount uint32) string {
+	mine, _ := ln.Channels[p].Balances()
+	if mine < amount {
+		return ProbeInsufficientLiquidity
+	}
+	paymentHash := make([]byte, 32)
+	if _, err := rand.Read(paymentHash); err != nil {
+		return err.Error()
+	}
+	res, err := p.Addr.ProbeChannelRPC(&pro.ProbeRequest{
+		Address:     ln.Address,
+		Amount:      amount,
+		PaymentHash: paymentHash,
+	})
+	if err != nil {
+		return err.Error()
+	}
+	return res.GetFailure()
+}
+
+// Balances returns our and our counterparty's balance in the
+// channel's current state. The funder's coin is output 0 and
+// the other party's is output 1.
+func (c *Channel) Balances() (uint32, uint32) {
+	return c.balancesAt(c.State)
+}
+
+// balancesAt returns our balance and the other node's balance in
+// state, according to our transaction for that state.
+func (c *Channel) balancesAt(state int) (uint32, uint32) {
+	if state < 0 || state >= len(c.MyTransactions) {
+		return 0, 0
+	}
+	tx := c.MyTransactions[state]
+	var funder, fundee uint32
+	if len(tx.Outputs) > 0 {
+		funder = tx.Outputs[0].Amount
+	}
+	if len(tx.Outputs) > 1 {
+		fundee = tx.Outputs[1].Amount
+	}
+	if c.Funder {
+		return funder, fundee
+	}
+	return fundee, funder
+}
@@ -10,6 +10,7 @@ import (
 	"Coin/pkg/block"
 	"Coin/pkg/utils"
 	"Coin/pkg/script"
+	"Coin/pkg/journal"
 )
 
 // Version was copied directly from pkg/server.go. Only changed the function receiver and types
@@ -89,6 +90,9 @@ func (ln *LightningNode) OpenChannel(ctx context.Context, in *pro.OpenChannelReq
 	
 		MyRevocationKeys: make(map[string][]byte), // create a new map, the key is a string and the value is []byte 
 		TheirRevocationKeys: make(map[string]*RevocationInfo),
+
+		MyShutdownScript: ln.Config.UpfrontShutdownScript,
+		TheirShutdownScript: in.GetUpfrontShutdownScript(),
 	}
 
 	ln.Channels[p] = cha
@@ -98,10 +102,13 @@ func (ln *LightningNode) OpenChannel(ctx context.Context, in *pro.OpenChannelReq
 	// MyRevocationKeys    map[string][]byte
 	ln.Channels[p].MyRevocationKeys[tx_r_decode.Hash()] = re_key
 
+	ln.Journal.Record(journal.ChannelOpened, tx_f_decode.Hash(), fmt.Sprintf("fundee with %v", in.GetAddress()))
+
 	cha_response := &pro.OpenChannelResponse{
 		PublicKey: ln.Id.GetPublicKeyBytes(),
 		SignedFundingTransaction: block.EncodeTransaction(tx_f_decode),
 		SignedRefundTransaction: block.EncodeTransaction(tx_r_decode),
+		UpfrontShutdownScript: cha.MyShutdownScript,
 	}
 
 
@@ -182,6 +189,7 @@ func (ln *LightningNode) GetRevocationKey(ctx context.Context, in *pro.SignedTra
 	revo_key := cha.MyRevocationKeys[de_trans2.Hash()]
 
 	cha.State ++ 
+	ln.Journal.Record(journal.ChannelStateUpdated, de_trans2.Hash(), fmt.Sprintf("state %v with %v", cha.State, in.Address))
 
 	revo_fin := &pro.RevocationKey{
 		Key: revo_key,
@@ -189,3 +197,48 @@ func (ln *LightningNode) GetRevocationKey(ctx context.Context, in *pro.SignedTra
 
 	return revo_fin, nil
 }
+
+// ProbeChannel is called by a peer that wants to know whether our channel could
+// carry a payment to us. We never know the preimage of a probe's payment
+// hash, so the HTLC always fails, and the failure tells the peer whether
+// the payment would otherwise have gone through.
+func (ln *LightningNode) ProbeChannel(ctx context.Context, in *pro.ProbeRequest) (*pro.ProbeResponse, error) {
+	p := ln.PeerDb.Get(in.GetAddress())
+	if p == nil {
+		return nil, fmt.Errorf("the peer is unknown!")
+	}
+	cha, ok := ln.Channels[p]
+	if !ok {
+		return nil, fmt.Errorf("there is no channel with the peer!")
+	}
+	_, theirs := cha.Balances()
+	if theirs < in.GetAmount() {
+		return &pro.ProbeResponse{Failure: ProbeInsufficientLiquidity}, nil
+	}
+	return &pro.ProbeResponse{Sufficient: true, Failure: ProbeUnknownPaymentHash}, nil
+}
+
+// CooperativeClose is called by a peer that wants to close our channel. The
+// closing transaction must pay us our full balance to our shutdown script, and
+// must pay the peer to the shutdown script it committed to when the channel was
+// opened, if it committed to one. Otherwise the close is refused.
+func (ln *LightningNode) CooperativeClose(ctx context.Context, in *pro.CloseChannelRequest) (*pro.CloseChannelResponse, error) {
+	p := ln.PeerDb.Get(in.GetAddress())
+	if p == nil {
+		return nil, fmt.Errorf("the peer is unknown!")
+	}
+	cha, ok := ln.Channels[p]
+	if !ok {
+		return nil, fmt.Errorf("there is no channel with the peer!")
+	}
+	tx := block.DecodeTransaction(in.GetClosingTransaction())
+	if err := ln.checkClosingTransaction(cha, tx); err != nil {
+		return nil, err
+	}
+	if err := ln.ValidateAndSign(tx); err != nil {
+		return nil, err
+	}
+	delete(ln.Channels, p)
+	ln.Journal.Record(journal.ChannelClosed, tx.Hash(), fmt.Sprintf("cooperatively with %v", in.GetAddress()))
+	return &pro.CloseChannelResponse{SignedClosingTransaction: block.EncodeTransaction(tx)}, nil
+}
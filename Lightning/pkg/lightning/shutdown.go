@@ -0,0 +1,142 @@
+package lightning
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/journal"
+	"Coin/pkg/peer"
+	"Coin/pkg/pro"
+	"bytes"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// payToPublicKey returns a script that pays to publicKey.
+func payToPublicKey(publicKey []byte) []byte {
+	p2pk := &pro.PayToPublicKey{
+		ScriptType: pro.ScriptType_P2PK,
+		PublicKey:  publicKey,
+	}
+	scriptB, err := proto.Marshal(p2pk)
+	if err != nil {
+		fmt.Printf("[payToPublicKey] Failed to marshal pay-to-public-key script")
+	}
+	return scriptB
+}
+
+// myShutdownScript returns the script our funds are paid to when the
+// channel is closed: the one we committed to, or else our public key.
+func (ln *LightningNode) myShutdownScript(cha *Channel) []byte {
+	if len(cha.MyShutdownScript) > 0 {
+		return cha.MyShutdownScript
+	}
+	return payToPublicKey(ln.Id.GetPublicKeyBytes())
+}
+
+// theirShutdownScript returns the script the other node's funds are paid
+// to when the channel is closed: the one they committed to, or else
+// their public key.
+func theirShutdownScript(cha *Channel) []byte {
+	if len(cha.TheirShutdownScript) > 0 {
+		return cha.TheirShutdownScript
+	}
+	return payToPublicKey(cha.CounterPartyPubKey)
+}
+
+// ClosingTransaction returns a transaction that closes the channel in its
+// current state, paying our balance to myScript and the other node's
+// balance to theirScript. Like the channel's other transactions, the
+// funder's output comes first, and the funder pays the fee.
+func (c *Channel) ClosingTransaction(fee uint32, myScript []byte, theirScript []byte) (*block.Transaction, error) {
+	if c.FundingTransaction == nil {
+		return nil, fmt.Errorf("[ClosingTransaction] the channel has no funding transaction")
+	}
+	mine, theirs := c.Balances()
+	funderScript, fundeeScript := myScript, theirScript
+	funder, fundee := mine, theirs
+	if !c.Funder {
+		funderScript, fundeeScript = theirScript, myScript
+		funder, fundee = theirs, mine
+	}
+	if funder < fee {
+		return nil, fmt.Errorf("[ClosingTransaction] the funder's balance %v can't pay the fee %v", funder, fee)
+	}
+	fundingHash := c.FundingTransaction.Hash()
+	inputs := []*block.TransactionInput{{ReferenceTransactionHash: fundingHash, OutputIndex: 0}}
+	if len(c.FundingTransaction.Outputs) > 2 {
+		inputs = append(inputs, &block.TransactionInput{ReferenceTransactionHash: fundingHash, OutputIndex: 2})
+	}
+	outputs := []*block.TransactionOutput{
+		{Amount: funder - fee, LockingScript: funderScript},
+		{Amount: fundee, LockingScript: fundeeScript},
+	}
+	// any change the funder has yet to get back is carried over as-is
+	if c.State < len(c.MyTransactions) && len(c.MyTransactions[c.State].Outputs) > 2 {
+		outputs = append(outputs, c.MyTransactions[c.State].Outputs[2])
+	}
+	return &block.Transaction{
+		Segwit:    true,
+		Version:   c.FundingTransaction.Version,
+		Inputs:    inputs,
+		Outputs:   outputs,
+		Witnesses: [][]byte{},
+	}, nil
+}
+
+// checkClosingTransaction returns an error if a closing transaction
+// proposed by the other node doesn't pay out the channel's current
+// balances, doesn't pay us to our shutdown script, or doesn't pay them
+// to the shutdown script they committed to when the channel was opened.
+func (ln *LightningNode) checkClosingTransaction(cha *Channel, tx *block.Transaction) error {
+	if len(tx.Outputs) < 2 {
+		return fmt.Errorf("[checkClosingTransaction] closing transaction has %v outputs", len(tx.Outputs))
+	}
+	// the funder pays the fee, so the fundee is owed its whole balance
+	funderOutput, fundeeOutput := tx.Outputs[0], tx.Outputs[1]
+	mine, theirs := cha.Balances()
+	myOutput, theirOutput, funder, fundee := funderOutput, fundeeOutput, mine, theirs
+	if !cha.Funder {
+		myOutput, theirOutput, funder, fundee = fundeeOutput, funderOutput, theirs, mine
+	}
+	if fundeeOutput.Amount != fundee || funderOutput.Amount > funder {
+		return fmt.Errorf("[checkClosingTransaction] closing transaction pays %v and %v, but the balances are %v and %v",
+			funderOutput.Amount, fundeeOutput.Amount, funder, fundee)
+	}
+	if !bytes.Equal(myOutput.LockingScript, ln.myShutdownScript(cha)) {
+		return fmt.Errorf("[checkClosingTransaction] closing transaction doesn't pay us to our shutdown script")
+	}
+	if len(cha.TheirShutdownScript) > 0 && !bytes.Equal(theirOutput.LockingScript, cha.TheirShutdownScript) {
+		return fmt.Errorf("[checkClosingTransaction] closing transaction doesn't pay them to their upfront shutdown script")
+	}
+	return nil
+}
+
+// CloseChannel cooperatively closes our channel with peer. We propose a
+// closing transaction paying each side its current balance to its
+// shutdown script, the other node checks and signs it, and then we
+// broadcast it. The funder pays fee.
+func (ln *LightningNode) CloseChannel(peer *peer.Peer, fee uint32) error {
+	cha, ok := ln.Channels[peer]
+	if !ok {
+		return fmt.Errorf("[CloseChannel] there is no channel with %v", peer.Addr.Addr)
+	}
+	tx, err := cha.ClosingTransaction(fee, ln.myShutdownScript(cha), theirShutdownScript(cha))
+	if err != nil {
+		return err
+	}
+	ln.SignTransaction(tx)
+	res, err := peer.Addr.CooperativeCloseRPC(&pro.CloseChannelRequest{
+		Address:            ln.Address,
+		ClosingTransaction: block.EncodeTransaction(tx),
+	})
+	if err != nil {
+		return fmt.Errorf("[CloseChannel] %v refused to close the channel: %v", peer.Addr.Addr, err)
+	}
+	signed := block.DecodeTransaction(res.GetSignedClosingTransaction())
+	if signed.Hash() != tx.Hash() {
+		return fmt.Errorf("[CloseChannel] %v signed a different closing transaction", peer.Addr.Addr)
+	}
+	delete(ln.Channels, peer)
+	ln.BroadcastTransaction <- signed
+	ln.Journal.Record(journal.ChannelClosed, signed.Hash(), fmt.Sprintf("cooperatively with %v", peer.Addr.Addr))
+	return nil
+}
@@ -0,0 +1,20 @@
+package lightning
+
+// ChannelSnapshot is a copy of one of our channels that can
+// be serialized, for backing the lightning node up.
+// Peer is the address of the other node in the channel.
+// Channel is the channel itself.
+type ChannelSnapshot struct {
+	Peer    string
+	Channel *Channel
+}
+
+// ChannelSnapshots returns a ChannelSnapshot of each of our
+// channels.
+func (ln *LightningNode) ChannelSnapshots() []*ChannelSnapshot {
+	var snapshots []*ChannelSnapshot
+	for p, cha := range ln.Channels {
+		snapshots = append(snapshots, &ChannelSnapshot{Peer: p.Addr.Addr, Channel: cha})
+	}
+	return snapshots
+}
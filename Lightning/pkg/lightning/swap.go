@@ -0,0 +1,65 @@
+package lightning
+
+import (
+	"Coin/pkg/journal"
+	"Coin/pkg/utils"
+	"encoding/hex"
+	"fmt"
+)
+
+// RequestSwap creates an invoice for amount that is to be paid on-chain,
+// by a swap locked to us with the invoice's payment hash. The wallet
+// claims the swap by revealing the invoice's preimage.
+func (ln *LightningNode) RequestSwap(amount uint32, memo string) ([]byte, error) {
+	if ln.Invoices == nil {
+		return nil, fmt.Errorf("[RequestSwap] the node has no invoice database")
+	}
+	inv, err := ln.Invoices.AddInvoice(amount, memo)
+	if err != nil {
+		return nil, err
+	}
+	return inv.PaymentHash, nil
+}
+
+// PayWithSwap records that we are paying the invoice for paymentHash
+// with on-chain coins. The payment is settled once the recipient claims
+// the swap, revealing the invoice's preimage.
+func (ln *LightningNode) PayWithSwap(paymentHash []byte, amount uint32, memo string) error {
+	if ln.Invoices == nil {
+		return fmt.Errorf("[PayWithSwap] the node has no invoice database")
+	}
+	_, err := ln.Invoices.AddPayment(paymentHash, amount, memo)
+	return err
+}
+
+// SwapPreimage returns the preimage of paymentHash if it is one of our
+// invoices that hasn't been settled, so that the wallet can claim swaps
+// that pay it. Otherwise, it returns nil.
+func (ln *LightningNode) SwapPreimage(paymentHash []byte) []byte {
+	if ln.Invoices == nil {
+		return nil
+	}
+	inv := ln.Invoices.GetInvoice(paymentHash)
+	if inv == nil || !inv.Incoming || inv.Settled() {
+		return nil
+	}
+	return inv.Preimage
+}
+
+// SettleSwap settles the invoice for paymentHash with the preimage that
+// claiming a swap revealed.
+func (ln *LightningNode) SettleSwap(paymentHash []byte, preimage []byte) {
+	if ln.Invoices == nil {
+		return
+	}
+	inv, err := ln.Invoices.Settle(paymentHash, preimage)
+	if err != nil {
+		utils.Debug.Printf("%v", err)
+		return
+	}
+	direction := "received"
+	if !inv.Incoming {
+		direction = "paid"
+	}
+	ln.Journal.Record(journal.SwapSettled, hex.EncodeToString(paymentHash), fmt.Sprintf("%v %v on-chain", direction, inv.Amount))
+}
@@ -19,6 +19,8 @@ import (
 // must be met for the miner to start mining a
 // group of transactions
 // BlockSize defines the maximum size a block can be.
+// Blocks are never made larger than block.MaxBlockSize,
+// whatever it is set to.
 // NonceLimit defines the maximum nonce that miners
 // are willing to mine to.
 // InitialSubsidy defines the initial subsidy given
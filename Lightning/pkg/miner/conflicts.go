@@ -0,0 +1,125 @@
+package miner
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/journal"
+	"fmt"
+)
+
+// trackSpends records the outpoints spent by t, which was just
+// added to the pool. The caller must hold the mutex.
+func (tp *TxPool) trackSpends(t *block.Transaction) {
+	for _, txi := range t.Inputs {
+		tp.spends[spentBy(txi)] = t
+	}
+}
+
+// untrackSpends forgets the outpoints spent by t, which was just
+// removed from the pool. The caller must hold the mutex.
+func (tp *TxPool) untrackSpends(t *block.Transaction) {
+	for _, txi := range t.Inputs {
+		cl := spentBy(txi)
+		if other, ok := tp.spends[cl]; ok && other.Hash() == t.Hash() {
+			delete(tp.spends, cl)
+		}
+	}
+}
+
+// conflicts returns the transactions in the pool that spend an
+// outpoint also spent by one of txs, without being one of txs.
+// The caller must hold the mutex.
+func (tp *TxPool) conflicts(txs []*block.Transaction) []*block.Transaction {
+	confirmed := make(map[string]bool)
+	for _, t := range txs {
+		confirmed[t.Hash()] = true
+	}
+	var conflicting []*block.Transaction
+	for _, t := range txs {
+		for _, txi := range t.Inputs {
+			other, ok := tp.spends[spentBy(txi)]
+			if ok && !confirmed[other.Hash()] {
+				conflicting = append(conflicting, other)
+			}
+		}
+	}
+	return conflicting
+}
+
+// descendants returns txs along with every transaction in the pool
+// that spends one of their outputs, directly or not. The caller must
+// hold the mutex.
+func (tp *TxPool) descendants(txs []*block.Transaction) []*block.Transaction {
+	seen := make(map[string]bool)
+	var all []*block.Transaction
+	for len(txs) > 0 {
+		t := txs[0]
+		txs = txs[1:]
+		if seen[t.Hash()] {
+			continue
+		}
+		seen[t.Hash()] = true
+		all = append(all, t)
+		for i := range t.Outputs {
+			cl := coindatabase.CoinLocator{ReferenceTransactionHash: t.Hash(), OutputIndex: uint32(i)}
+			if child, ok := tp.spends[cl]; ok {
+				txs = append(txs, child)
+			}
+		}
+	}
+	return all
+}
+
+// evictConflicts removes the transactions in the pool that conflict
+// with the spends of txs, which were just confirmed, along with their
+// descendants, since none of them can be mined anymore. It returns the
+// transactions that were evicted.
+// The caller must hold the mutex.
+func (tp *TxPool) evictConflicts(txs []*block.Transaction) []*block.Transaction {
+	conflicting := tp.conflicts(txs)
+	if len(conflicting) == 0 {
+		return nil
+	}
+	evicted := tp.descendants(conflicting)
+	removed, totalPriority := tp.TxQ.Remove(evicted)
+	for _, t := range removed {
+		tp.untrackSpends(t)
+		tp.Journal.Record(journal.MempoolEviction, t.Hash(), "conflicts with a confirmed spend")
+	}
+	tp.Count.Sub(uint32(len(removed)))
+	tp.CurrentPriority.Sub(totalPriority)
+	return removed
+}
+
+// replaceConflicts makes way for t, which has priority pri, by
+// evicting the transactions in the pool that spend the same outpoints,
+// along with their descendants, as long as pri is higher than each of
+// their priorities. Otherwise it evicts nothing, and returns false,
+// since t shouldn't take their place. The caller must hold the mutex.
+func (tp *TxPool) replaceConflicts(t *block.Transaction, pri uint32) bool {
+	conflicting := tp.conflicts([]*block.Transaction{t})
+	if len(conflicting) == 0 {
+		return true
+	}
+	for _, other := range conflicting {
+		if i, ok := tp.TxQ.GetIndex(other); ok && (*tp.TxQ)[i].Priority >= pri {
+			return false
+		}
+	}
+	removed, totalPriority := tp.TxQ.Remove(tp.descendants(conflicting))
+	for _, r := range removed {
+		tp.untrackSpends(r)
+		tp.Journal.Record(journal.MempoolEviction, r.Hash(), fmt.Sprintf("replaced by %v", t.Hash()))
+	}
+	tp.Count.Sub(uint32(len(removed)))
+	tp.CurrentPriority.Sub(totalPriority)
+	return true
+}
+
+// spentBy returns the outpoint a TransactionInput spends.
+func spentBy(txi *block.TransactionInput) coindatabase.CoinLocator {
+	return coindatabase.CoinLocator{
+		ReferenceTransactionHash: txi.ReferenceTransactionHash,
+		OutputIndex:              txi.OutputIndex,
+	}
+}
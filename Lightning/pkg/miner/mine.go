@@ -2,11 +2,11 @@ package miner
 
 import (
 	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
 	"Coin/pkg/utils"
 	"bytes"
 	"context"
 	"fmt"
-	"math"
 	"time"
 )
 
@@ -73,6 +73,9 @@ func (m *Miner) Mine() *block.Block {
 	txs := append([]*block.Transaction{m.GenerateCoinbaseTransaction(m.MiningPool)}, m.MiningPool...)
 	// this is the block that we're going to mine!
 	b := block.New(m.PreviousHash, txs, string(m.DifficultyTarget))
+	if m.BlockVersion != nil {
+		b.Header.Version = m.BlockVersion()
+	}
 	// if results is true, we found a winning nonce! Otherwise, we failed (which won't ever actually happen
 	// for us)
 	// Change this to something else
@@ -119,19 +122,34 @@ func (m *Miner) GenerateCoinbaseTransaction(txs []*block.Transaction) *block.Tra
 	feeRwd := m.CalculateFees(txs)
 	// find out what the minting reward is
 	mntRwd := m.CalculateMintingReward()
+	return m.coinbase(feeRwd + mntRwd)
+}
+
+// coinbase returns a coinbase transaction that pays reward
+// to the miner, and commits to the unspent coins if it must.
+func (m *Miner) coinbase(reward uint32) *block.Transaction {
 	// get our public key, so that we can send the txo to ourselves
 	pubK := m.Id.GetPublicKeyBytes()
 	// Output with fee reward and minting reward to ourselves
 	txo := &block.TransactionOutput{
-		Amount:        feeRwd + mntRwd,
+		Amount:        reward,
 		LockingScript: pubK,
 	}
 	// the actual transaction. Note: no inputs since Coinbase!
+	// It commits to the height of the block it's mined in.
 	tx := &block.Transaction{
 		Version:  0,
 		Inputs:   []*block.TransactionInput{},
 		Outputs:  []*block.TransactionOutput{txo},
-		LockTime: 0,
+		LockTime: block.CoinbaseLockTime(m.ChainLength.Load() + 1),
+	}
+	if m.UTXOCommitment != nil {
+		if digest := m.UTXOCommitment(); digest != nil {
+			tx.Outputs = append(tx.Outputs, &block.TransactionOutput{
+				Amount:        0,
+				LockingScript: block.UTXOCommitmentScript(digest),
+			})
+		}
 	}
 	return tx
 }
@@ -168,7 +186,11 @@ func (m *Miner) getInputSums(txs []*block.Transaction) ([]uint32, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
 	// ask the node to sum the inputs for our transactions
-	m.GetInputSums <- txs
+	select {
+	case <-ctx.Done():
+		return []uint32{0}, fmt.Errorf("[miner.sumInputs] Error: timed out")
+	case m.GetInputSums <- txs:
+	}
 	// wait until we get a response from the node in our SumInputs channel
 	for {
 		select {
@@ -193,12 +215,5 @@ func (m *Miner) getInputSums(txs []*block.Transaction) ([]uint32, error) {
 // has minted
 func (m *Miner) CalculateMintingReward() uint32 {
 	c := m.Config
-	chainLength := m.ChainLength.Load()
-	if chainLength >= c.SubsidyHalvingRate*c.MaxHalvings {
-		return 0
-	}
-	halvings := chainLength / c.SubsidyHalvingRate
-	rwd := c.InitialSubsidy
-	rwd /= uint32(math.Pow(2, float64(halvings)))
-	return rwd
+	return blockchain.Subsidy(m.ChainLength.Load(), c.InitialSubsidy, c.SubsidyHalvingRate, c.MaxHalvings)
 }
@@ -23,6 +23,10 @@ import (
 // GetInputCoins is used by the miner to ask the node for the coins used for the inputs on
 // a block
 // InputCoins is the channel by which the node sends the requested coins back to the miner
+// UTXOCommitment returns the digest of the unspent coins the coinbase must commit to, or nil
+// if it needn't commit to any (see blockchain.Config.UTXOCommitmentHeight)
+// BlockVersion returns the version the block should have, which signals for soft fork
+// deployments (see blockchain.Deployment)
 type Miner struct {
 	Config *Config
 	Id     id.ID
@@ -44,6 +48,10 @@ type Miner struct {
 	GetInputSums chan []*block.Transaction
 	InputSums    chan []uint32
 
+	IsFinal        func(*block.Transaction) bool
+	UTXOCommitment func() []byte
+	BlockVersion   func() uint32
+
 	mutex sync.Mutex
 }
 
@@ -98,6 +106,30 @@ func (m *Miner) HandleBlock(b *block.Block) {
 	m.UpdateTXPool(b.Transactions)
 }
 
+// HandleReorg handles the main chain switching to a heavier branch. The transactions of the disconnected
+// blocks that can be mined again go back in the transaction pool, the transactions of the connected blocks
+// are taken out of it, and the miner's perspective of the main chain is reset to the new tip.
+// Inputs:
+// r - the reorg, as the blockchain describes it
+// sums - the sums of the inputs of each of r's returned transactions
+func (m *Miner) HandleReorg(r *blockchain.Reorg, sums []uint32) {
+	if r == nil || len(sums) != len(r.Returned) {
+		return
+	}
+	for i, t := range r.Returned {
+		m.TxPool.Add(t, sums[i])
+	}
+	var txs []*block.Transaction
+	for _, b := range r.Connected {
+		txs = append(txs, b.Transactions...)
+	}
+	m.mutex.Lock()
+	m.PreviousHash = r.Tip()
+	m.mutex.Unlock()
+	m.SetChainLength(r.Length)
+	m.UpdateTXPool(txs)
+}
+
 // UpdateTXPool handles updating
 // the transaction pool based
 // on the new transactions in the block.
@@ -7,14 +7,28 @@ import "Coin/pkg/block"
 type MiningPool []*block.Transaction
 
 // NewMiningPool selects the highest priority
-// transactions from the transaction pool.
+// transactions from the transaction pool, as
+// many as fit in a block of the configured size
+// without breaking the consensus limits on a
+// block's size and signature checks. Transactions
+// whose lock times haven't been reached are left
+// in the pool for a later block.
 func (m *Miner) NewMiningPool() MiningPool {
 	var txs []*block.Transaction
 	var blkSz uint32 = 100 // assume coinbase
+	var sigOps uint32 = 1  // the coinbase pays one key
+	maxSz := m.Config.BlockSize
+	if maxSz > block.MaxBlockSize {
+		maxSz = block.MaxBlockSize
+	}
 	var rankings = *m.TxPool.TxQ
 	for i := 0; i < len(rankings); i++ {
-		blkSz += rankings[i].Transaction.Size()
-		if blkSz < m.Config.BlockSize {
+		if m.IsFinal != nil && !m.IsFinal(rankings[i].Transaction) {
+			continue
+		}
+		blkSz += rankings[i].Transaction.SerializedSize()
+		sigOps += block.SigOps(rankings[i].Transaction)
+		if blkSz < maxSz && sigOps <= block.MaxBlockSigOps {
 			txs = append(txs, rankings[i].Transaction)
 		} else {
 			break
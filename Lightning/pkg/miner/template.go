@@ -0,0 +1,45 @@
+package miner
+
+import "Coin/pkg/block"
+
+// Template describes the block the miner would build right now.
+// Transactions are the transactions it would select from the
+// TxPool, highest priority first, not counting the coinbase.
+// Fees is the sum of their fees, and Subsidy is the minting reward
+// at the current chain length. The coinbase pays Fees plus Subsidy.
+// Size is the size of the block, coinbase included.
+// PriorityMet is whether the TxPool has enough priority for the
+// miner to start mining it.
+type Template struct {
+	Transactions []*block.Transaction
+	Fees         uint32
+	Subsidy      uint32
+	Size         uint32
+	PriorityMet  bool
+}
+
+// Reward returns what the Template's coinbase would pay the miner.
+func (t *Template) Reward() uint32 {
+	return t.Fees + t.Subsidy
+}
+
+// PreviewTemplate returns the Template of the block the miner would
+// build from its TxPool right now, without mining it or changing the
+// MiningPool. Working out the fees asks the node for the sums of the
+// Transactions' inputs.
+func (m *Miner) PreviewTemplate() *Template {
+	m.TxPool.Mutex.Lock()
+	txs := m.NewMiningPool()
+	m.TxPool.Mutex.Unlock()
+	t := &Template{
+		Transactions: txs,
+		Subsidy:      m.CalculateMintingReward(),
+		PriorityMet:  m.TxPool.PriorityMet(),
+	}
+	if len(txs) > 0 {
+		t.Fees = m.CalculateFees(txs)
+	}
+	b := block.New(m.PreviousHash, append([]*block.Transaction{m.coinbase(t.Reward())}, txs...), string(m.DifficultyTarget))
+	t.Size = b.Size()
+	return t
+}
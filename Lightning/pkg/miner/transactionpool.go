@@ -2,6 +2,8 @@ package miner
 
 import (
 	"Coin/pkg/block"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/journal"
 	"fmt"
 	"go.uber.org/atomic"
 	"sync"
@@ -19,6 +21,10 @@ import (
 // in the pool.
 // Cap is the maximum amount of allowed
 // transactions to store in the pool.
+// Journal records transactions turned away from the pool.
+// spends maps each outpoint spent by a transaction in the pool
+// to that transaction, so that transactions conflicting with a
+// new block can be found.
 type TxPool struct {
 	CurrentPriority *atomic.Uint32
 	PriorityLimit   uint32
@@ -27,7 +33,10 @@ type TxPool struct {
 	Count    *atomic.Uint32
 	Capacity uint32
 
-	Mutex sync.Mutex
+	Journal *journal.Journal
+
+	Mutex  sync.Mutex
+	spends map[coindatabase.CoinLocator]*block.Transaction
 }
 
 // Length returns the count of transactions
@@ -38,6 +47,18 @@ func (tp *TxPool) Length() uint32 {
 	return tp.Count.Load()
 }
 
+// Priorities returns the priority of each
+// transaction in the pool, in no order.
+func (tp *TxPool) Priorities() []uint32 {
+	tp.Mutex.Lock()
+	defer tp.Mutex.Unlock()
+	priorities := make([]uint32, 0, tp.TxQ.Len())
+	for _, n := range *tp.TxQ {
+		priorities = append(priorities, n.Priority)
+	}
+	return priorities
+}
+
 // NewTxPool constructs a transaction pool.
 func NewTxPool(c *Config) *TxPool {
 	return &TxPool{
@@ -46,6 +67,7 @@ func NewTxPool(c *Config) *TxPool {
 		TxQ:             block.NewTransactionHeap(),
 		Count:           atomic.NewUint32(0),
 		Capacity:        c.TransactionPoolCapacity,
+		spends:          make(map[coindatabase.CoinLocator]*block.Transaction),
 	}
 }
 
@@ -79,8 +101,11 @@ func CalculatePriority(t *block.Transaction, sumInputs uint32) uint32 {
 
 // Add adds a transaction to the transaction pool.
 // If the transaction pool is full, the transaction
-// will not be added. Otherwise, the cumulative
-// priority level is updated, the counter is
+// will not be added. A transaction spending the same
+// outpoints as transactions already in the pool
+// replaces them if its priority is higher than each
+// of theirs, and is not added otherwise. Then the
+// cumulative priority level is updated, the counter is
 // incremented, and the transaction is added to the
 // heap.
 func (tp *TxPool) Add(t *block.Transaction, sumInputs uint32) {
@@ -90,21 +115,35 @@ func (tp *TxPool) Add(t *block.Transaction, sumInputs uint32) {
 		return
 	}
 	if tp.Count.Load() >= tp.Capacity {
+		tp.Journal.Record(journal.MempoolEviction, t.Hash(), "pool at capacity")
 		return
 	}
 	pri := CalculatePriority(t, sumInputs)
-	tp.CurrentPriority.Add(pri)
 	tp.Mutex.Lock()
+	if !tp.replaceConflicts(t, pri) {
+		tp.Mutex.Unlock()
+		tp.Journal.Record(journal.MempoolEviction, t.Hash(), "conflicts with a transaction of at least its priority")
+		return
+	}
+	tp.CurrentPriority.Add(pri)
 	tp.TxQ.Add(pri, t)
+	tp.trackSpends(t)
 	tp.Mutex.Unlock()
 	tp.Count.Inc()
 }
 
 // CheckTransactions checks for any duplicate
 // transactions in the heap and removes them.
+// Transactions that spend the same outpoints
+// as txs can never be mined, so they are
+// evicted too, along with their descendants.
 func (tp *TxPool) CheckTransactions(txs []*block.Transaction) {
 	tp.Mutex.Lock()
 	amtRem, totalPriority := tp.TxQ.Remove(txs)
+	for _, t := range amtRem {
+		tp.untrackSpends(t)
+	}
+	tp.evictConflicts(txs)
 	tp.Mutex.Unlock()
 	tp.Count.Sub(uint32(len(amtRem)))
 	tp.CurrentPriority.Sub(totalPriority)
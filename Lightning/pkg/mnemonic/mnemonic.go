@@ -0,0 +1,99 @@
+// Package mnemonic writes seeds as lists of words, in the manner of
+// BIP39, so a seed can be backed up by hand and typed back in.
+//
+// Every 11 bits of the seed, followed by a checksum of one bit for
+// every 32 bits of seed, picks a word from the English wordlist, so a
+// 32 byte seed takes 24 words. The checksum catches most mistyped or
+// misordered words.
+package mnemonic
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//go:embed english.txt
+var english string
+
+// words is the wordlist, and indexes the index of each word in it.
+var (
+	words   = strings.Fields(english)
+	indexes = func() map[string]int {
+		m := make(map[string]int, len(words))
+		for i, word := range words {
+			m[word] = i
+		}
+		return m
+	}()
+)
+
+// bitsPerWord is how many bits each word stands for.
+const bitsPerWord = 11
+
+// checkLength returns an error unless a seed of length bytes can be
+// written as a mnemonic, which takes between 16 and 32 bytes, in steps
+// of 4.
+func checkLength(length int) error {
+	if length < 16 || length > 32 || length%4 != 0 {
+		return fmt.Errorf("seed must be 16 to 32 bytes, in steps of 4, not %v", length)
+	}
+	return nil
+}
+
+// checksum returns the first n bits of the SHA256 hash of seed.
+func checksum(seed []byte, n int) *big.Int {
+	sum := sha256.Sum256(seed)
+	c := new(big.Int).SetBytes(sum[:])
+	return c.Rsh(c, uint(256-n))
+}
+
+// Encode returns the mnemonic for seed, its words separated by spaces.
+func Encode(seed []byte) (string, error) {
+	if err := checkLength(len(seed)); err != nil {
+		return "", fmt.Errorf("[mnemonic.Encode] %v", err)
+	}
+	checksumBits := len(seed) * 8 / 32
+	n := new(big.Int).SetBytes(seed)
+	n.Lsh(n, uint(checksumBits))
+	n.Or(n, checksum(seed, checksumBits))
+	count := (len(seed)*8 + checksumBits) / bitsPerWord
+	mask := big.NewInt(1<<bitsPerWord - 1)
+	out := make([]string, count)
+	index := new(big.Int)
+	for i := count - 1; i >= 0; i-- {
+		out[i] = words[index.And(n, mask).Int64()]
+		n.Rsh(n, bitsPerWord)
+	}
+	return strings.Join(out, " "), nil
+}
+
+// Decode returns the seed a mnemonic was encoded from. Words may be
+// separated by any whitespace, and in any case. An error is returned if
+// a word isn't in the wordlist, or the checksum doesn't match.
+func Decode(mnemonic string) ([]byte, error) {
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	totalBits := len(fields) * bitsPerWord
+	checksumBits := totalBits / 33
+	length := (totalBits - checksumBits) / 8
+	if len(fields)%3 != 0 || checkLength(length) != nil {
+		return nil, fmt.Errorf("[mnemonic.Decode] a mnemonic must be 12 to 24 words, in steps of 3, not %v", len(fields))
+	}
+	n := new(big.Int)
+	for i, word := range fields {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, fmt.Errorf("[mnemonic.Decode] word %v, {%v}, is not in the wordlist", i+1, word)
+		}
+		n.Lsh(n, bitsPerWord)
+		n.Or(n, big.NewInt(int64(index)))
+	}
+	sum := new(big.Int).And(n, big.NewInt(1<<uint(checksumBits)-1))
+	seed := n.Rsh(n, uint(checksumBits)).FillBytes(make([]byte, length))
+	if sum.Cmp(checksum(seed, checksumBits)) != 0 {
+		return nil, fmt.Errorf("[mnemonic.Decode] checksum does not match, so a word is wrong or out of place")
+	}
+	return seed, nil
+}
@@ -3,15 +3,25 @@ package pkg
 import (
 	"Coin/pkg/address"
 	"Coin/pkg/address/addressdb"
+	"Coin/pkg/backup"
 	"Coin/pkg/block"
 	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/broadcast"
+	"Coin/pkg/capture"
+	"Coin/pkg/checkpoint"
+	"Coin/pkg/download"
 	"Coin/pkg/id"
+	"Coin/pkg/journal"
 	"Coin/pkg/lightning"
 	"Coin/pkg/miner"
 	"Coin/pkg/peer"
 	"Coin/pkg/pro"
+	"Coin/pkg/profiling"
 	"Coin/pkg/utils"
 	"Coin/pkg/wallet"
+	"bytes"
 	"errors"
 	"fmt"
 	"google.golang.org/grpc"
@@ -39,6 +49,8 @@ type TransactionWithCount struct {
 // on the node object.
 // *pro.UnimplementedCoinServer
 // Server *grpc.Server
+// AdminServer *grpc.Server serves the node's operator, on the
+// loopback interface, if the node has an AdminPort
 // Config *Config the settings for the node
 // Address string the address that the node is listening
 // to traffic on
@@ -58,9 +70,26 @@ type TransactionWithCount struct {
 // of whether a block has been seen on the network
 // before or not
 // Paused bool
+// Journal *journal.Journal a record of significant
+// events, shared with the chain, miner, and lightning node
+// Profiler *profiling.Profiler serves pprof endpoints and
+// captures profiles on demand
+// Broadcaster *broadcast.Broadcaster relays our own
+// transactions until they are confirmed
+// Capture *capture.Recorder records the messages the node
+// sends and receives, if capturing is on
+// Checkpoints *checkpoint.Manager periodically records a
+// consistent point across the node's databases, if
+// checkpointing is on
+// Integrity *blockchain.IntegrityChecker cross-checks the
+// chain's coins against its blocks in the background, if
+// the chain's IntegrityInterval is set
+// tipStale is whether the tip was stale when CheckStaleTip last
+// looked, guarded by mutex, and stopTipWatch stops watchTip
 type Node struct {
 	*pro.UnimplementedCoinServer
-	Server *grpc.Server
+	Server      *grpc.Server
+	AdminServer *grpc.Server
 
 	Config  *Config
 	Address string
@@ -83,6 +112,17 @@ type Node struct {
 
 	Paused bool
 
+	Journal     *journal.Journal
+	Profiler    *profiling.Profiler
+	Broadcaster *broadcast.Broadcaster
+	Capture     *capture.Recorder
+	Checkpoints *checkpoint.Manager
+	Integrity   *blockchain.IntegrityChecker
+
+	tipStale     bool
+	stopTipWatch chan bool
+	stopFeeWatch chan bool
+
 	mutex sync.RWMutex
 }
 
@@ -93,25 +133,107 @@ type Node struct {
 // of the Node
 // Returns:
 // *Node a pointer to the new node object
-func New(conf *Config) *Node {
+// error if the node's chain can't be opened, e.g. because another
+// node is using its directories
+func New(conf *Config) (*Node, error) {
 	i, _ := id.New(conf.IdConfig)
-	return &Node{
+	var j *journal.Journal
+	if conf.JournalConfig != nil {
+		j = journal.New(conf.JournalConfig)
+	}
+	bc, err := blockchain.New(conf.ChainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("[pkg.New] %v", err)
+	}
+	bc.Journal = j
+	if bc.NeedsReindex() {
+		utils.Debug.Printf("[pkg.New] the chain's databases were corrupted, so the chain must be reindexed")
+	}
+	w := wallet.New(conf.WalletConfig, i)
+	var cps *checkpoint.Manager
+	if conf.CheckpointConfig != nil && conf.CheckpointConfig.Path != "" {
+		cps = checkpoint.New(conf.CheckpointConfig)
+		if cps.Last != nil && !bc.NeedsReindex() {
+			if replayed, err := cps.Recover(bc, w.StateVersion()); err != nil {
+				utils.Debug.Printf("[pkg.New] %v", err)
+			} else {
+				utils.Debug.Printf("[pkg.New] replayed %v blocks after checkpoint {%v}", replayed, cps.Last.Hash)
+			}
+		}
+	}
+	m := miner.New(conf.MinerConfig, i)
+	if m != nil {
+		m.TxPool.Journal = j
+		// the miner builds on the chain's tip, whichever network's
+		// genesis block it starts from
+		m.PreviousHash = bc.LastHash
+		m.SetChainLength(bc.Length)
+		m.IsFinal = bc.IsFinal
+		m.UTXOCommitment = bc.UTXOCommitment
+		m.BlockVersion = bc.BlockVersion
+	}
+	ln := lightning.New(conf.LightningConfig)
+	ln.Journal = j
+	var prof *profiling.Profiler
+	if conf.ProfilingConfig != nil {
+		prof = profiling.New(conf.ProfilingConfig, conf.ChainConfig.ChainWriterDBPath)
+	}
+	var rec *capture.Recorder
+	if conf.CaptureConfig != nil && conf.CaptureConfig.Path != "" {
+		var err error
+		if rec, err = capture.New(conf.CaptureConfig); err != nil {
+			utils.Debug.Printf("[pkg.New] Unable to capture messages to {%v}: %v", conf.CaptureConfig.Path, err)
+		} else {
+			ln.ServerOptions = append(ln.ServerOptions, grpc.UnaryInterceptor(rec.ServerInterceptor()))
+			address.SetCaptureInterceptor(rec.ClientInterceptor())
+		}
+	}
+	n := &Node{
 		Config:           conf,
 		Address:          "",
 		Id:               i,
-		BlockChain:       blockchain.New(conf.ChainConfig),
-		Wallet:           wallet.New(conf.WalletConfig, i),
-		Miner:            miner.New(conf.MinerConfig, i),
-		LightningNode:    lightning.New(conf.LightningConfig),
-		WatchTower:       &lightning.WatchTower{Id: i},
+		BlockChain:       bc,
+		Wallet:           w,
+		Miner:            m,
+		LightningNode:    ln,
+		WatchTower: &lightning.WatchTower{
+			Id:                  i,
+			RevocationKeys:      make(map[string]*lightning.RevocationInfo),
+			RevokedTransactions: make(chan *lightning.RevocationInfo),
+		},
 		SeenTransactions: make(map[string]*TransactionWithCount),
 		SeenBlocks:       make(map[string]uint32),
 		fGetAddr:         false,
 		AddressDB:        addressdb.New(true, 1000),
 		PeerDb:           peer.NewDb(true, 200, ""),
 		Paused:           false,
+		Journal:          j,
+		Profiler:         prof,
+		Capture:          rec,
+		Checkpoints:      cps,
 		mutex:            sync.RWMutex{},
 	}
+	broadcastConfig := conf.BroadcastConfig
+	if broadcastConfig == nil {
+		broadcastConfig = broadcast.DefaultConfig()
+	}
+	n.Broadcaster = broadcast.New(broadcastConfig, n.sendTransaction, n.relayPeers)
+	if conf.ChainConfig.IntegrityInterval > 0 {
+		// blocks aren't handled while a step reads the coins
+		n.Integrity = bc.NewIntegrityChecker(&n.mutex)
+	}
+	bc.OnReorg(n.handleReorg)
+	bc.OnOrphanConnected(n.handleOrphanConnected)
+	if n.Wallet != nil {
+		// swaps are settled with the preimages of lightning invoices
+		n.Wallet.LookupPreimage = ln.SwapPreimage
+		n.Wallet.OnSwapPreimage = ln.SettleSwap
+		// fees are estimated from the transactions waiting to be mined
+		if m != nil {
+			n.Wallet.Fees.Mempool = m.TxPool.Priorities
+		}
+	}
+	return n, nil
 }
 
 // BroadcastTransaction broadcasts transactions created by the wallet
@@ -125,24 +247,79 @@ func (n *Node) BroadcastTransaction(tx *block.Transaction) {
 	if n.Config.MinerConfig.HasMiner {
 		go n.Miner.HandleTransaction(tx)
 	}
+	n.Broadcaster.Track(tx)
+}
+
+// sendTransaction sends one of our transactions to the peer
+// at addr. It is how the Broadcaster relays transactions.
+func (n *Node) sendTransaction(addr string, tx *block.Transaction) error {
+	d := block.EncodeTransaction(tx)
+	// peers ask for the witnesses of segwit transactions separately
+	d.Witnesses = nil
+	_, err := address.New(addr, 0).ForwardTransactionRPC(&pro.TransactionWithAddress{
+		Transaction: d,
+		Address:     n.Address,
+	})
+	return err
+}
+
+// relayPeers returns the addresses of the peers that
+// transactions may be relayed to.
+func (n *Node) relayPeers() []string {
+	var addrs []string
 	for _, p := range n.PeerDb.List() {
-		d := block.EncodeTransaction(tx) // this method is defined in package block 
-		//TODO: remove the proto transaction's witnesses before you send it off to your peers
-		d.Witnesses = nil 
-		go func(addr *address.Address) {
-			txWithAddr := &pro.TransactionWithAddress{
-				Transaction: d,
-				Address:     n.Address,
-			}
-			_, err := addr.ForwardTransactionRPC(txWithAddr)
-			if err != nil {
-				utils.Debug.Printf("%v received no response from ForwardTransactionRPC to %v",
-					utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr))
-			}
-		}(p.Addr)
+		if !p.Addr.BlocksOnly {
+			addrs = append(addrs, p.Addr.Addr)
+		}
+	}
+	return addrs
+}
+
+// confirmTransactions tells the Broadcaster that the
+// transactions in a block on the main chain are confirmed.
+func (n *Node) confirmTransactions(b *block.Block) {
+	for _, tx := range b.Transactions {
+		n.Broadcaster.Confirm(tx.Hash())
+	}
+}
+
+// handleReorg brings the broadcaster, the wallet, and the miner in
+// line with the main chain after the chain switches to a heavier
+// branch. The wallet takes back the coins spent in the disconnected
+// blocks before it sees the connected ones.
+func (n *Node) handleReorg(r *blockchain.Reorg) {
+	for _, b := range r.Connected {
+		n.confirmTransactions(b)
+	}
+	if n.Config.WalletConfig.HasWallet && n.Wallet != nil {
+		n.Wallet.HandleFork(r.Disconnected)
+		for _, b := range r.Connected {
+			n.Wallet.HandleBlock(b.Transactions)
+		}
+	}
+	if n.Config.MinerConfig.HasMiner && n.Miner != nil {
+		// the sums are read now, while the chain still matches r
+		go n.Miner.HandleReorg(r, n.BlockChain.GetInputSums(r.Returned))
+	}
+}
+
+// handleOrphanConnected tells the broadcaster, the wallet, and the
+// miner about a block that arrived before its parent, once the chain
+// extends the main chain with it.
+func (n *Node) handleOrphanConnected(b *block.Block) {
+	n.confirmTransactions(b)
+	if n.Config.MinerConfig.HasMiner && n.Miner != nil {
+		go n.Miner.HandleBlock(b)
+	}
+	if n.Config.WalletConfig.HasWallet && n.Wallet != nil {
+		n.Wallet.HandleBlock(b.Transactions)
 	}
 }
 
+// watchTowerBuffer is how many blocks the watchtower may fall behind
+// the chain before it misses any.
+const watchTowerBuffer = 64
+
 // Start starts a node on the network. At first, the node is
 // not technically connected to the network, since it has no
 // one to connect to. So, this method opens up a listener and
@@ -166,17 +343,58 @@ func (n *Node) Start() {
 	}
 	if n.Config.WalletConfig.HasWallet {
 		n.Wallet.SetAddress(addr)
+		n.Wallet.StartConsolidationScheduler()
 	}
 	// Added for Project 3: Lightning
 	n.LightningNode.SetAddress(addr)
 	n.LightningNode.Start()
 	n.StartServer(addr)
+	if err := n.StartAdminServer(); err != nil {
+		utils.Debug.Printf("%v", err)
+	}
+	n.Broadcaster.Start()
+	if err := n.Profiler.Start(); err != nil {
+		utils.Debug.Printf("%v", err)
+	}
+	if len(n.Config.LoadBlocks) > 0 {
+		go n.loadStartupBlocks()
+	}
+	if n.Integrity != nil {
+		n.Integrity.Start()
+		go n.watchIntegrity()
+	}
+	if n.Config.StaleTipMultiple > 0 && n.Config.TargetBlockInterval > 0 {
+		n.stopTipWatch = make(chan bool)
+		go n.watchTip(n.stopTipWatch)
+	}
+	if n.Wallet != nil {
+		n.stopFeeWatch = make(chan bool)
+		go n.watchFees(n.BlockChain.Subscribe(feeWatchBuffer), n.stopFeeWatch)
+	}
 	go func() {
 		if n.Config.MinerConfig.HasMiner {
+			// the watchtower checks every block the main chain gains
+			tips := n.BlockChain.Subscribe(watchTowerBuffer)
 			for {
 				select {
+				case e, ok := <-tips.Events:
+					if !ok {
+						utils.Debug.Printf("%v watchtower fell behind the chain", utils.FmtAddr(n.Address))
+						tips = n.BlockChain.Subscribe(watchTowerBuffer)
+						continue
+					}
+					if e.Kind == blockchain.TipConnected {
+						n.WatchTower.HandleBlock(e.Block)
+					}
 				case t := <-n.Wallet.TransactionRequests:
 					n.BroadcastTransaction(t)
+				case r := <-n.Wallet.Replacements:
+					n.Broadcaster.Confirm(r.Original)
+					n.BroadcastTransaction(r.Transaction)
+				case t := <-n.Wallet.ConsolidationDue:
+					n.Wallet.MaybeConsolidate(t)
+				case tx := <-n.Broadcaster.Failed:
+					n.Wallet.HandleBroadcastFailure(tx)
 				case b := <-n.Miner.SendBlock:
 					n.HandleMinerBlock(b)
 				case b := <-n.BlockChain.ConfirmBlock:
@@ -205,42 +423,210 @@ func (n *Node) Start() {
 				select {
 				case t := <-n.Wallet.TransactionRequests:
 					n.BroadcastTransaction(t)
+				case r := <-n.Wallet.Replacements:
+					n.Broadcaster.Confirm(r.Original)
+					n.BroadcastTransaction(r.Transaction)
+				case t := <-n.Wallet.ConsolidationDue:
+					n.Wallet.MaybeConsolidate(t)
+				case tx := <-n.Broadcaster.Failed:
+					n.Wallet.HandleBroadcastFailure(tx)
 				}
 			}
 		}
 	}()
 }
 
+// watchIntegrity raises an alert for every inconsistency the
+// integrity checker finds between the chain's coins and its
+// blocks. If the Config's HaltOnCorruption is set, the node
+// halts at the first one, since a node whose coins have diverged
+// may accept invalid blocks or reject valid ones, and relay or
+// mine on top of them.
+func (n *Node) watchIntegrity() {
+	for err := range n.Integrity.Alerts {
+		utils.Debug.Printf("%v ALERT: the chain state is corrupted: %v", utils.FmtAddr(n.Address), err)
+		n.Journal.Record(journal.IntegrityAlert, err.Hash, err.Err.Error())
+		if !n.Config.HaltOnCorruption {
+			continue
+		}
+		n.Integrity.Stop()
+		if n.Miner != nil {
+			n.Miner.Active.Store(false)
+		}
+		n.PauseNetwork()
+		utils.Debug.Printf("%v halted until the chain is reindexed", utils.FmtAddr(n.Address))
+		return
+	}
+}
+
+// watchTip checks whether the tip has gone stale every
+// TargetBlockInterval, until stop is closed.
+func (n *Node) watchTip(stop chan bool) {
+	ticker := time.NewTicker(n.Config.TargetBlockInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.CheckStaleTip()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// feeWatchBuffer is how many blocks the wallet's fee estimates
+// may fall behind the chain before some are missed.
+const feeWatchBuffer = 64
+
+// watchFees shows the wallet's fee estimator the feerates of the
+// transactions in every block the main chain gains, until stop is
+// closed. Blocks missed by falling behind only leave the
+// estimates a little staler.
+func (n *Node) watchFees(tips *blockchain.Subscription, stop chan bool) {
+	defer func() { tips.Unsubscribe() }()
+	for {
+		select {
+		case e, ok := <-tips.Events:
+			if !ok {
+				tips = n.BlockChain.Subscribe(feeWatchBuffer)
+				continue
+			}
+			if e.Kind == blockchain.TipConnected {
+				n.observeFees(e.Block)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// observeFees shows the wallet's fee estimator the feerates of
+// the transactions in b, which must be connected.
+func (n *Node) observeFees(b *block.Block) {
+	n.mutex.RLock()
+	sums, err := n.BlockChain.BlockInputSums(b)
+	n.mutex.RUnlock()
+	if err != nil {
+		utils.Debug.Printf("%v could not read the fees of %v: %v", utils.FmtAddr(n.Address), b.NameTag(), err)
+		return
+	}
+	var feerates []uint32
+	for i, tx := range b.Transactions {
+		if !tx.IsCoinbase() {
+			feerates = append(feerates, miner.CalculatePriority(tx, sums[i]))
+		}
+	}
+	n.Wallet.Fees.ObserveBlock(feerates)
+}
+
+// TipHash returns the hash of the active chain's tip. It's read under
+// the node's mutex, so it can be polled while blocks are handled.
+func (n *Node) TipHash() string {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.BlockChain.LastHash
+}
+
+// TipAge returns how long it has been since a block last became
+// the tip of the active chain, and whether that's long enough for
+// the tip to be stale: more than the Config's StaleTipMultiple
+// TargetBlockIntervals.
+func (n *Node) TipAge() (time.Duration, bool) {
+	age := time.Since(n.BlockChain.TipConnectedAt())
+	if n.Config.StaleTipMultiple == 0 {
+		return age, false
+	}
+	return age, age > time.Duration(n.Config.StaleTipMultiple)*n.Config.TargetBlockInterval
+}
+
+// CheckStaleTip handles the tip going stale, which usually means
+// the node's peers have stopped telling it about new blocks, or
+// are on a chain of their own. It warns once each time the tip
+// goes stale, and, for as long as it stays stale, connects to up
+// to StaleTipPeers more of the addresses it knows and bootstraps
+// from every peer, so any blocks they have are downloaded.
+// It returns whether the tip was stale.
+func (n *Node) CheckStaleTip() bool {
+	age, stale := n.TipAge()
+	n.mutex.Lock()
+	warn := stale && !n.tipStale
+	n.tipStale = stale
+	n.mutex.Unlock()
+	if !stale {
+		return false
+	}
+	if warn {
+		utils.Debug.Printf("%v WARNING: no block has been connected for %v, tip %v may be stale",
+			utils.FmtAddr(n.Address), age.Round(time.Second), n.BlockChain.LastBlock.NameTag())
+		n.Journal.Record(journal.StaleTip, n.BlockChain.LastHash, fmt.Sprintf("no block for %v", age.Round(time.Second)))
+	}
+	n.connectMorePeers(n.Config.StaleTipPeers)
+	if err := n.Bootstrap(); err != nil {
+		utils.Debug.Printf("%v unable to sync from peers for a stale tip: %v", utils.FmtAddr(n.Address), err)
+	}
+	return true
+}
+
+// connectMorePeers connects to up to count known addresses the
+// node isn't already peered with, without going over its
+// PeerLimit.
+func (n *Node) connectMorePeers(count int) {
+	for _, a := range n.AddressDB.List() {
+		if count <= 0 || len(n.PeerDb.List()) >= n.Config.PeerLimit {
+			return
+		}
+		if a.Addr == n.Address || n.PeerDb.In(a.Addr) {
+			continue
+		}
+		n.ConnectToPeer(a.Addr)
+		count--
+	}
+}
+
 // HandleMinerBlock handles a block
 // that was just made by the miner. It does this
 // by sending the block to the chain so that it can be
 // added, to the wallet, and to the network to be
-// broadcast.
+// broadcast. Blocks mined while the disk is full are dropped, since
+// they can't be stored.
 func (n *Node) HandleMinerBlock(b *block.Block) {
+	if err := n.BlockChain.ChainWriter.CheckSpace(0); err != nil {
+		utils.Debug.Printf("%v dropped mined %v: %v", utils.FmtAddr(n.Address), b.NameTag(), err)
+		return
+	}
+	// blocks are handled one at a time, as in ForwardBlock
+	n.mutex.Lock()
 	n.SeenBlocks[b.Hash()] = 1
 	// (1) send to chain
 	n.BlockChain.HandleBlock(b)
+	n.confirmTransactions(b)
 	// (2) send a newly safe block to the wallet, appending
 	// the new block to unsafe blocks
 	if n.Config.WalletConfig.HasWallet {
 		n.Wallet.HandleBlock(b.Transactions)
 	}
-	// (3) send to network to broadcast
+	// (3) count it towards a checkpoint, now that the wallet has it
+	n.Checkpoints.HandleBlock(n.BlockChain, n.Wallet.StateVersion())
+	n.mutex.Unlock()
+	// (4) send to network to broadcast
+	n.announceBlock(b)
+}
+
+// announceBlock sends a block to every peer, after waiting
+// the Config's BlockAnnounceDelay.
+func (n *Node) announceBlock(b *block.Block) {
 	for _, p := range n.PeerDb.List() {
-		//_, err := p.Addr.ForwardBlockRPC(block.EncodeBlock(b))
-		//if err != nil {
-		//	utils.Debug.Printf("%v received no response from ForwardBlockRPC to %v",
-		//		utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr))
-		//}
 		go func(addr *address.Address) {
+			if n.Config.BlockAnnounceDelay > 0 {
+				time.Sleep(n.Config.BlockAnnounceDelay)
+			}
 			_, err := addr.ForwardBlockRPC(block.EncodeBlock(b))
 			if err != nil {
 				utils.Debug.Printf("%v received no response from ForwardBlockRPC to %v",
-					utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr))
+					utils.FmtAddr(n.Address), utils.FmtAddr(addr.Addr))
 			}
 		}(p.Addr)
 	}
-
 }
 
 // GetBalance returns the balance (amount of money)
@@ -255,6 +641,117 @@ func (n *Node) GetBalance(pk []byte) uint32 {
 	return n.BlockChain.GetBalance(pk)
 }
 
+// GetBalanceForPublicKey returns the balance of any
+// public key, including watch-only ones that the node's
+// wallet does not hold.
+// Inputs:
+// pk []byte the public key to look up
+// Returns:
+// uint32 the sum of the unspent outputs locked to pk
+func (n *Node) GetBalanceForPublicKey(pk []byte) uint32 {
+	return n.BlockChain.GetBalanceForPublicKey(pk)
+}
+
+// SyncUTXOs catches the node's coins up from startHeight to
+// endHeight by applying the UTXO delta between those heights
+// from a trusted peer, instead of replaying every block.
+// The delta is not validated, so the peer must be trusted.
+// Inputs:
+// addr string the address of the trusted peer
+// startHeight uint32 the height the node's coins are at
+// endHeight uint32 the height to catch up to
+func (n *Node) SyncUTXOs(addr string, startHeight, endHeight uint32) error {
+	if !n.Config.TrustedPeers[addr] {
+		return fmt.Errorf("[SyncUTXOs] %v is not a trusted peer", addr)
+	}
+	a := address.New(addr, 0)
+	res, err := a.GetUtxoDeltaRPC(&pro.UtxoDeltaRequest{
+		AddrMe:      n.Address,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	})
+	if err != nil {
+		return fmt.Errorf("[SyncUTXOs] failed to get delta from %v: %v", addr, err)
+	}
+	return n.BlockChain.CoinDB.ApplyUTXODelta(coindatabase.DecodeUTXODelta(res))
+}
+
+// BackupAll writes the node's wallet, lightning channels,
+// and identity keys to a single backup, encrypted with a key
+// derived from passphrase. The backup goes to the Path in
+// the node's BackupConfig.
+// Inputs:
+// passphrase string the passphrase the backup is encrypted
+// under, which is needed to open or rotate it later
+func (n *Node) BackupAll(passphrase string) error {
+	if n.Config.BackupConfig == nil {
+		return fmt.Errorf("[BackupAll] the node has no backup config")
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	a := &backup.Archive{
+		CreatedAt: time.Now().Unix(),
+		NodeKeys:  &backup.Keys{PublicKey: n.Id.GetPublicKeyBytes(), PrivateKey: n.Id.GetPrivateKeyBytes()},
+	}
+	if n.Wallet != nil {
+		a.Wallet = n.Wallet.Snapshot()
+	}
+	if n.LightningNode != nil {
+		ln := n.LightningNode
+		a.LightningKeys = &backup.Keys{PublicKey: ln.Id.GetPublicKeyBytes(), PrivateKey: ln.Id.GetPrivateKeyBytes()}
+		a.Channels = ln.ChannelSnapshots()
+	}
+	return backup.Write(n.Config.BackupConfig, a, passphrase)
+}
+
+// RestoreWallet restores the node's wallet from a mnemonic written by
+// its Mnemonic, for when the wallet's data has been lost. The wallet
+// re-derives its keys from the mnemonic, and is handed every Block of
+// the active chain after the genesis Block again, in order, so it
+// finds the coins paid to them. None of those Blocks can have been
+// pruned.
+// Inputs:
+// words string the mnemonic
+func (n *Node) RestoreWallet(words string) error {
+	if n.Wallet == nil {
+		return fmt.Errorf("[RestoreWallet] the node has no wallet")
+	}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.Wallet.RestoreFromMnemonic(words); err != nil {
+		return err
+	}
+	bc := n.BlockChain
+	for height := uint32(2); height <= bc.Length; height++ {
+		b := bc.GetBlock(bc.BlockInfoDB.GetHashByHeight(height))
+		if b == nil {
+			return fmt.Errorf("[RestoreWallet] block at height %v is pruned or unreadable, so the rescan stopped", height)
+		}
+		n.Wallet.HandleBlock(b.Transactions)
+	}
+	utils.Debug.Printf("%v restored its wallet, rescanning %v blocks", utils.FmtAddr(n.Address), bc.Length-1)
+	return nil
+}
+
+// PayInvoiceWithSwap pays the lightning invoice for paymentHash with
+// on-chain coins, by locking amount into a swap that recipientPK can
+// claim with the invoice's preimage. The invoice is settled once the
+// swap is claimed, and the coins are refunded if it isn't claimed
+// within timeout blocks.
+func (n *Node) PayInvoiceWithSwap(paymentHash []byte, amount uint32, fee uint32, recipientPK []byte, timeout uint32) (*block.Transaction, error) {
+	if n.Wallet == nil {
+		return nil, fmt.Errorf("[PayInvoiceWithSwap] the node has no wallet")
+	}
+	if err := n.LightningNode.PayWithSwap(paymentHash, amount, "swap"); err != nil {
+		return nil, err
+	}
+	tx := n.Wallet.LockSwap(amount, fee, recipientPK, paymentHash, timeout)
+	if tx == nil {
+		return nil, fmt.Errorf("[PayInvoiceWithSwap] the wallet could not lock the swap")
+	}
+	return tx, nil
+}
+
 // StartMiner starts the miner, which means the miner
 // is now actively waiting for enough transactions
 // to mine.
@@ -270,18 +767,31 @@ func (n *Node) StartMiner() {
 // to connect to.
 func (n *Node) ConnectToPeer(addr string) {
 	a := address.New(addr, 0)
-	_, err := a.VersionRPC(&pro.VersionRequest{
-		Version:    uint32(n.Config.Version),
-		AddrYou:    addr,
-		AddrMe:     n.Address,
-		BestHeight: n.BlockChain.Length,
-	})
+	_, err := a.VersionRPC(n.makeVersionRequest(addr))
 	if err != nil {
 		utils.Debug.Printf("%v received no response from VersionRPC to %v",
 			utils.FmtAddr(n.Address), utils.FmtAddr(addr))
 	}
 }
 
+// makeVersionRequest returns the VersionRequest the node
+// sends to the node at addrYou to become its peer.
+func (n *Node) makeVersionRequest(addrYou string) *pro.VersionRequest {
+	return &pro.VersionRequest{
+		Version:    uint32(n.Config.Version),
+		AddrYou:    addrYou,
+		AddrMe:     n.Address,
+		BestHeight: n.BlockChain.Length,
+		BlocksOnly: n.wantsBlocksOnly(addrYou),
+	}
+}
+
+// wantsBlocksOnly returns whether the node's configuration
+// says to only exchange blocks with the node at addr.
+func (n *Node) wantsBlocksOnly(addr string) bool {
+	return n.Config.BlocksOnly || n.Config.BlocksOnlyPeers[addr]
+}
+
 // BroadcastAddress broadcasts the node's address
 func (n *Node) BroadcastAddress() {
 	myAddr := pro.Address{Addr: n.Address, LastSeen: uint32(time.Now().UnixNano())}
@@ -290,7 +800,7 @@ func (n *Node) BroadcastAddress() {
 			_, err := addr.SendAddressesRPC(&pro.Addresses{Addrs: []*pro.Address{&myAddr}})
 			if err != nil {
 				utils.Debug.Printf("%v received no response from SendAddressesRPC to %v",
-					utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr))
+					utils.FmtAddr(n.Address), utils.FmtAddr(addr.Addr))
 			}
 		}(p.Addr)
 	}
@@ -300,50 +810,260 @@ func (n *Node) BroadcastAddress() {
 // pre-existing one that other nodes have. This may happen
 // when a node first joins the network, or if the node left
 // the network for a while (paused), then rejoined.
+// Headers are synced first, since they are cheap to fetch and
+// validate, so the chain with the most work is known before any
+// Block is fetched. At a high level, it:
+// (1) syncs headers from every peer in parallel (see syncHeaders).
+// (2) finds the Blocks missing from the chain of headers with the
+// most work.
+// (3) downloads them in parallel from every peer whose headers end
+// that chain, so a single slow peer can't hold up the sync, and
+// (4) handles each one as soon as every Block before it has arrived,
+// while the later ones are still being downloaded, so fetching and
+// decoding Blocks overlaps with connecting them.
 func (n *Node) Bootstrap() error {
 	utils.Debug.Printf("%v bootstrapping from %v peers with top block %v", utils.FmtAddr(n.Address), len(n.PeerDb.List()), n.BlockChain.LastBlock.NameTag())
-	topBlockHash := n.BlockChain.LastHash
-	var wg sync.WaitGroup
-	var longestRes *pro.GetBlocksResponse
-	var addr *address.Address
 	if len(n.PeerDb.List()) == 0 {
 		return errors.New("no peers to bootstrap from")
 	}
+	// (1) sync headers
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	lastHeaders := make(map[string]string)
 	for _, p := range n.PeerDb.List() {
 		wg.Add(1)
 		go func(p *peer.Peer) {
-			res, err := p.Addr.GetBlocksRPC(&pro.GetBlocksRequest{TopBlockHash: topBlockHash})
+			defer wg.Done()
+			lastHash, err := n.syncHeaders(p)
 			if err != nil {
-				wg.Done()
-				return
-			}
-			if longestRes == nil || len(res.BlockHashes) > len(longestRes.BlockHashes) {
-				longestRes = res
-				addr = p.Addr
+				utils.Debug.Printf("%v unable to sync headers from %v: %v", utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr), err)
 			}
-			wg.Done()
+			mutex.Lock()
+			defer mutex.Unlock()
+			lastHeaders[p.Addr.Addr] = lastHash
 		}(p)
 	}
 	wg.Wait()
-	if longestRes == nil {
-		return errors.New("no peers gave responses")
+
+	// (2) start from a snapshot of the coins, if the chain is new
+	if n.Config.UseSnapshots && n.BlockChain.Length == 1 && n.BlockChain.SnapshotCheckpoint() != nil {
+		for _, p := range n.PeerDb.List() {
+			err := n.SyncSnapshot(p.Addr.Addr)
+			if err == nil {
+				break
+			}
+			utils.Debug.Printf("%v unable to load a snapshot from %v: %v", utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr), err)
+		}
+	}
+
+	// (3) find the missing blocks
+	hashes := n.BlockChain.Headers.Missing()
+	if len(hashes) == 0 {
+		return nil
+	}
+	var peers []string
+	for addr, lastHash := range lastHeaders {
+		if lastHash == hashes[len(hashes)-1] {
+			peers = append(peers, addr)
+		}
 	}
-	for _, h := range longestRes.BlockHashes {
-		pb, _ := addr.GetDataRPC(&pro.GetDataRequest{BlockHash: h})
-		b := block.DecodeBlock(pb.Block)
+
+	// (4) download them
+	d := download.New(n.Config.DownloadConfig, n.fetchBlock)
+	blocks := make(chan *block.Block, n.Config.DownloadConfig.RangeSize)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- d.Stream(peers, hashes, blocks)
+	}()
+
+	// (5) handle them as they arrive
+	defer n.BlockChain.Headers.Prune()
+	var err error
+	for b := range blocks {
+		if err != nil {
+			// the stream is drained, so the download can finish
+			continue
+		}
+		if err = n.BlockChain.ChainWriter.CheckSpace(0); err != nil {
+			continue
+		}
+		n.mutex.Lock()
 		n.SeenBlocks[b.Hash()] = 1
 		n.BlockChain.HandleBlock(b)
+		n.Checkpoints.HandleBlock(n.BlockChain, n.Wallet.StateVersion())
+		n.mutex.Unlock()
+	}
+	for addr, stats := range d.Stats() {
+		utils.Debug.Printf("%v downloaded %v blocks from %v at %.1f blocks/s (stalled: %v)",
+			utils.FmtAddr(n.Address), stats.Blocks, utils.FmtAddr(addr), stats.Throughput(), stats.Stalled)
+	}
+	if err2 := <-errs; err == nil {
+		err = err2
+	}
+	return err
+}
+
+// SyncSnapshot starts a new node's chain at the last checkpoint
+// that commits to the unspent coins, by downloading a snapshot of
+// them from the peer at addr, instead of every block below it. The
+// node then validates the blocks above the checkpoint as usual. At a
+// high level, it:
+// (1) finds the peer's snapshot at the checkpoint, skipping peers
+// whose snapshot doesn't claim to match it
+// (2) syncs the peer's headers, since the chain below the snapshot
+// is only kept as headers
+// (3) downloads each chunk, checking it against the hash the peer
+// advertised for it
+// (4) fetches the checkpoint's block, and loads the snapshot on top
+// of it, which checks the coins against the checkpoint.
+// Inputs:
+// addr string the address of the peer to download from
+func (n *Node) SyncSnapshot(addr string) error {
+	cp := n.BlockChain.SnapshotCheckpoint()
+	if cp == nil {
+		return fmt.Errorf("[SyncSnapshot] no checkpoint commits to the coins")
+	}
+	p := n.PeerDb.Get(addr)
+	if p == nil {
+		return fmt.Errorf("[SyncSnapshot] %v is not a peer", addr)
+	}
+	// (1) find the snapshot
+	res, err := p.Addr.GetSnapshotsRPC(&pro.Empty{})
+	if err != nil {
+		return err
+	}
+	var snapshot *blockchain.Snapshot
+	for _, ps := range res.GetSnapshots() {
+		if s := blockchain.DecodeSnapshot(ps); s.Matches(cp) {
+			snapshot = s
+		}
+	}
+	if snapshot == nil {
+		return fmt.Errorf("[SyncSnapshot] %v has no snapshot at checkpoint %v", addr, cp.Height)
+	}
+	// (2) sync headers
+	if _, err = n.syncHeaders(p); err != nil {
+		return err
+	}
+	// (3) download the chunks
+	var coins []*coindatabase.DeltaCoin
+	for i, chunkHash := range snapshot.ChunkHashes {
+		pchunk, err := p.Addr.GetSnapshotChunkRPC(&pro.SnapshotChunkRequest{Height: snapshot.Height, Index: uint32(i)})
+		if err != nil {
+			return err
+		}
+		hash, err := coindatabase.HashSnapshotChunk(pchunk)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hash, chunkHash) {
+			return fmt.Errorf("[SyncSnapshot] chunk %v from %v doesn't match its advertised hash", i, addr)
+		}
+		coins = append(coins, coindatabase.DecodeSnapshotChunk(pchunk)...)
 	}
+	// (4) load it
+	base, err := n.fetchBlock(addr, cp.Hash)
+	if err != nil {
+		return err
+	}
+	if err = n.BlockChain.LoadSnapshot(base, coins); err != nil {
+		return err
+	}
+	n.mutex.Lock()
+	n.SeenBlocks[cp.Hash] = 1
+	n.mutex.Unlock()
+	utils.Debug.Printf("%v loaded a snapshot of %v coins at height %v from %v", utils.FmtAddr(n.Address), len(coins), cp.Height, utils.FmtAddr(addr))
 	return nil
 }
 
+// LoadBlockFiles loads the blocks in an archive, a block file, or
+// another node's block directory at path (see BlockChain.LoadBlocks),
+// so a node can be bootstrapped from files instead of its peers. Like
+// blocks downloaded while bootstrapping, they aren't announced to
+// peers. It returns how many blocks were accepted.
+func (n *Node) LoadBlockFiles(path string) (int, error) {
+	if err := n.BlockChain.ChainWriter.CheckSpace(0); err != nil {
+		return 0, err
+	}
+	return n.BlockChain.LoadBlocks(path, func(b *block.Block) {
+		n.mutex.Lock()
+		n.SeenBlocks[b.Hash()] = 1
+		n.Checkpoints.HandleBlock(n.BlockChain, n.Wallet.StateVersion())
+		n.mutex.Unlock()
+	})
+}
+
+// loadStartupBlocks loads the blocks at each of the Config's
+// LoadBlocks paths, in order.
+func (n *Node) loadStartupBlocks() {
+	for _, path := range n.Config.LoadBlocks {
+		accepted, err := n.LoadBlockFiles(path)
+		if err != nil {
+			utils.Debug.Printf("%v unable to load blocks from {%v}: %v", utils.FmtAddr(n.Address), path, err)
+			continue
+		}
+		utils.Debug.Printf("%v loaded %v blocks from {%v}", utils.FmtAddr(n.Address), accepted, path)
+	}
+}
+
+// syncHeaders asks a peer for the headers past the active chain's
+// tip, adding them to the BlockChain's HeaderTree, until the peer
+// sends fewer than MaxHeaders. It returns the hash of the last header
+// the peer sent, or an empty string if it sent none.
+func (n *Node) syncHeaders(p *peer.Peer) (string, error) {
+	var lastHash string
+	locator := n.BlockChain.Headers.Locator(n.BlockChain.LastHash)
+	for {
+		res, err := p.Addr.GetHeadersRPC(&pro.GetHeadersRequest{Locator: locator, AddrMe: n.Address})
+		if err != nil {
+			return lastHash, err
+		}
+		for _, ph := range res.GetHeaders() {
+			header := block.DecodeHeader(ph)
+			if err = n.BlockChain.Headers.Add(header); err != nil {
+				return lastHash, err
+			}
+			lastHash = (&block.Block{Header: header}).Hash()
+		}
+		if len(res.GetHeaders()) < blockinfodatabase.MaxHeaders {
+			return lastHash, nil
+		}
+		locator = n.BlockChain.Headers.Locator(lastHash)
+	}
+}
+
+// fetchBlock asks the peer at addr for the Block with the given hash,
+// and checks that it is well formed.
+func (n *Node) fetchBlock(addr string, hash string) (*block.Block, error) {
+	p := n.PeerDb.Get(addr)
+	if p == nil {
+		return nil, fmt.Errorf("[fetchBlock] %v is not a peer", addr)
+	}
+	pb, err := p.Addr.GetDataRPC(&pro.GetDataRequest{BlockHash: hash})
+	if err != nil {
+		return nil, err
+	}
+	if pb.GetBlock() == nil {
+		return nil, fmt.Errorf("[fetchBlock] %v does not have block {%v}", addr, hash)
+	}
+	b := block.DecodeBlock(pb.Block)
+	if err = block.ValidateBlock(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
 func (n *Node) StartServer(addr string) {
 	lis, err := net.Listen("tcp4", addr)
 	if err != nil {
 		panic(err)
 	}
-	// Open node to connections
-	n.Server = grpc.NewServer()
+	// Open node to connections, other than the operator's rpcs
+	interceptors := []grpc.UnaryServerInterceptor{peerInterceptor}
+	if n.Capture != nil {
+		interceptors = append(interceptors, n.Capture.ServerInterceptor())
+	}
+	n.Server = grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
 	pro.RegisterCoinServer(n.Server, n)
 	go func() {
 		err = n.Server.Serve(lis)
@@ -372,5 +1092,30 @@ func (n *Node) ResumeNetwork() {
 // Kill kills any threads currently managed by the Node or that
 // it previously started. It also does any necessary clean up.
 func (n *Node) Kill() {
+	n.Broadcaster.Stop()
+	if n.Integrity != nil {
+		n.Integrity.Stop()
+	}
+	if n.stopTipWatch != nil {
+		close(n.stopTipWatch)
+		n.stopTipWatch = nil
+	}
+	if n.stopFeeWatch != nil {
+		close(n.stopFeeWatch)
+		n.stopFeeWatch = nil
+	}
 	n.Server.GracefulStop()
+	if n.AdminServer != nil {
+		n.AdminServer.GracefulStop()
+	}
+	n.Profiler.Stop()
+	if err := n.BlockChain.ChainWriter.Close(); err != nil {
+		utils.Debug.Printf("[Node.Kill] Unable to flush stored blocks: %v", err)
+	}
+	if n.Capture != nil {
+		address.SetCaptureInterceptor(nil)
+		if err := n.Capture.Close(); err != nil {
+			utils.Debug.Printf("[Node.Kill] Unable to close capture: %v", err)
+		}
+	}
 }
@@ -4,6 +4,9 @@ import (
 	"Coin/pkg/address"
 )
 
+// Peer is a node that we are connected to.
+// Addr says whether we and the peer have agreed to
+// only exchange blocks (see address.Address).
 type Peer struct {
 	Addr       *address.Address
 	Version    uint32
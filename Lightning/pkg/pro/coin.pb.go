@@ -26,6 +26,8 @@ const (
 	ScriptType_P2PK  ScriptType = 0
 	ScriptType_MULTI ScriptType = 1
 	ScriptType_HTLC  ScriptType = 2
+	ScriptType_VAULT ScriptType = 3
+	ScriptType_SWAP  ScriptType = 4
 )
 
 // Enum value maps for ScriptType.
@@ -34,11 +36,15 @@ var (
 		0: "P2PK",
 		1: "MULTI",
 		2: "HTLC",
+		3: "VAULT",
+		4: "SWAP",
 	}
 	ScriptType_value = map[string]int32{
 		"P2PK":  0,
 		"MULTI": 1,
 		"HTLC":  2,
+		"VAULT": 3,
+		"SWAP":  4,
 	}
 )
 
@@ -164,6 +170,7 @@ type TransactionInput struct {
 	ReferenceTransactionHash string `protobuf:"bytes,1,opt,name=reference_transaction_hash,json=referenceTransactionHash,proto3" json:"reference_transaction_hash,omitempty"`
 	OutputIndex              uint32 `protobuf:"varint,2,opt,name=output_index,json=outputIndex,proto3" json:"output_index,omitempty"`
 	UnlockingScript          []byte `protobuf:"bytes,3,opt,name=unlocking_script,json=unlockingScript,proto3" json:"unlocking_script,omitempty"`
+	Sequence                 uint32 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"` // relative lock on the spent coin, see block.RelativeLock
 }
 
 func (x *TransactionInput) Reset() {
@@ -219,6 +226,13 @@ func (x *TransactionInput) GetUnlockingScript() []byte {
 	return nil
 }
 
+func (x *TransactionInput) GetSequence() uint32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
 type TransactionOutput struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -417,6 +431,61 @@ func (x *Block) GetTransactions() []*Transaction {
 	return nil
 }
 
+type TxLocation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BlockHash string `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	Index     uint32 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+}
+
+func (x *TxLocation) Reset() {
+	*x = TxLocation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TxLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxLocation) ProtoMessage() {}
+
+func (x *TxLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxLocation.ProtoReflect.Descriptor instead.
+func (*TxLocation) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TxLocation) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *TxLocation) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
 type BlockRecord struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -431,12 +500,15 @@ type BlockRecord struct {
 	UndoFile             string  `protobuf:"bytes,7,opt,name=undo_file,json=undoFile,proto3" json:"undo_file,omitempty"`
 	UndoStartOffset      uint32  `protobuf:"varint,8,opt,name=undo_start_offset,json=undoStartOffset,proto3" json:"undo_start_offset,omitempty"`
 	UndoEndOffset        uint32  `protobuf:"varint,9,opt,name=undo_end_offset,json=undoEndOffset,proto3" json:"undo_end_offset,omitempty"`
+	ChainWork            []byte  `protobuf:"bytes,10,opt,name=chain_work,json=chainWork,proto3" json:"chain_work,omitempty"`
+	Status               uint32  `protobuf:"varint,11,opt,name=status,proto3" json:"status,omitempty"`
+	UtxoHash             []byte  `protobuf:"bytes,12,opt,name=utxo_hash,json=utxoHash,proto3" json:"utxo_hash,omitempty"` // state of the unspent coins after the block, if tracked
 }
 
 func (x *BlockRecord) Reset() {
 	*x = BlockRecord{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[5]
+		mi := &file_coin_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -449,7 +521,7 @@ func (x *BlockRecord) String() string {
 func (*BlockRecord) ProtoMessage() {}
 
 func (x *BlockRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[5]
+	mi := &file_coin_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -462,7 +534,7 @@ func (x *BlockRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockRecord.ProtoReflect.Descriptor instead.
 func (*BlockRecord) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{5}
+	return file_coin_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *BlockRecord) GetHeader() *Header {
@@ -528,6 +600,90 @@ func (x *BlockRecord) GetUndoEndOffset() uint32 {
 	return 0
 }
 
+func (x *BlockRecord) GetChainWork() []byte {
+	if x != nil {
+		return x.ChainWork
+	}
+	return nil
+}
+
+func (x *BlockRecord) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *BlockRecord) GetUtxoHash() []byte {
+	if x != nil {
+		return x.UtxoHash
+	}
+	return nil
+}
+
+type ChainTip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash           string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Height         uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	CumulativeWork []byte `protobuf:"bytes,3,opt,name=cumulative_work,json=cumulativeWork,proto3" json:"cumulative_work,omitempty"` // big-endian
+}
+
+func (x *ChainTip) Reset() {
+	*x = ChainTip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChainTip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainTip) ProtoMessage() {}
+
+func (x *ChainTip) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainTip.ProtoReflect.Descriptor instead.
+func (*ChainTip) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ChainTip) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *ChainTip) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ChainTip) GetCumulativeWork() []byte {
+	if x != nil {
+		return x.CumulativeWork
+	}
+	return nil
+}
+
 type CoinRecord struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -542,7 +698,7 @@ type CoinRecord struct {
 func (x *CoinRecord) Reset() {
 	*x = CoinRecord{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[6]
+		mi := &file_coin_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -555,7 +711,7 @@ func (x *CoinRecord) String() string {
 func (*CoinRecord) ProtoMessage() {}
 
 func (x *CoinRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[6]
+	mi := &file_coin_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -568,7 +724,7 @@ func (x *CoinRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CoinRecord.ProtoReflect.Descriptor instead.
 func (*CoinRecord) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{6}
+	return file_coin_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CoinRecord) GetVersion() uint32 {
@@ -613,7 +769,7 @@ type UndoBlock struct {
 func (x *UndoBlock) Reset() {
 	*x = UndoBlock{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[7]
+		mi := &file_coin_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -626,7 +782,7 @@ func (x *UndoBlock) String() string {
 func (*UndoBlock) ProtoMessage() {}
 
 func (x *UndoBlock) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[7]
+	mi := &file_coin_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -639,7 +795,7 @@ func (x *UndoBlock) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UndoBlock.ProtoReflect.Descriptor instead.
 func (*UndoBlock) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{7}
+	return file_coin_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *UndoBlock) GetTransactionInputHashes() []string {
@@ -679,7 +835,7 @@ type Empty struct {
 func (x *Empty) Reset() {
 	*x = Empty{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[8]
+		mi := &file_coin_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -692,7 +848,7 @@ func (x *Empty) String() string {
 func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[8]
+	mi := &file_coin_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -705,7 +861,7 @@ func (x *Empty) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Empty.ProtoReflect.Descriptor instead.
 func (*Empty) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{8}
+	return file_coin_proto_rawDescGZIP(), []int{10}
 }
 
 type VersionRequest struct {
@@ -717,12 +873,13 @@ type VersionRequest struct {
 	AddrYou    string `protobuf:"bytes,2,opt,name=addr_you,json=addrYou,proto3" json:"addr_you,omitempty"`           // the IP address of the remote node as seen from this node
 	AddrMe     string `protobuf:"bytes,3,opt,name=addr_me,json=addrMe,proto3" json:"addr_me,omitempty"`              // the IP address of the local node, as discovered by the local node
 	BestHeight uint32 `protobuf:"varint,4,opt,name=best_height,json=bestHeight,proto3" json:"best_height,omitempty"` // the block height of this node’s blockchain
+	BlocksOnly bool   `protobuf:"varint,5,opt,name=blocks_only,json=blocksOnly,proto3" json:"blocks_only,omitempty"` // whether this node wants to neither send nor receive loose transactions
 }
 
 func (x *VersionRequest) Reset() {
 	*x = VersionRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[9]
+		mi := &file_coin_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -735,7 +892,7 @@ func (x *VersionRequest) String() string {
 func (*VersionRequest) ProtoMessage() {}
 
 func (x *VersionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[9]
+	mi := &file_coin_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -748,7 +905,7 @@ func (x *VersionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VersionRequest.ProtoReflect.Descriptor instead.
 func (*VersionRequest) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{9}
+	return file_coin_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *VersionRequest) GetVersion() uint32 {
@@ -779,6 +936,13 @@ func (x *VersionRequest) GetBestHeight() uint32 {
 	return 0
 }
 
+func (x *VersionRequest) GetBlocksOnly() bool {
+	if x != nil {
+		return x.BlocksOnly
+	}
+	return false
+}
+
 type GetBlocksRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -791,7 +955,7 @@ type GetBlocksRequest struct {
 func (x *GetBlocksRequest) Reset() {
 	*x = GetBlocksRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[10]
+		mi := &file_coin_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -804,7 +968,7 @@ func (x *GetBlocksRequest) String() string {
 func (*GetBlocksRequest) ProtoMessage() {}
 
 func (x *GetBlocksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[10]
+	mi := &file_coin_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -817,7 +981,7 @@ func (x *GetBlocksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlocksRequest.ProtoReflect.Descriptor instead.
 func (*GetBlocksRequest) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{10}
+	return file_coin_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *GetBlocksRequest) GetTopBlockHash() string {
@@ -846,7 +1010,7 @@ type GetBlocksResponse struct {
 func (x *GetBlocksResponse) Reset() {
 	*x = GetBlocksResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[11]
+		mi := &file_coin_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -859,7 +1023,7 @@ func (x *GetBlocksResponse) String() string {
 func (*GetBlocksResponse) ProtoMessage() {}
 
 func (x *GetBlocksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[11]
+	mi := &file_coin_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -872,7 +1036,7 @@ func (x *GetBlocksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBlocksResponse.ProtoReflect.Descriptor instead.
 func (*GetBlocksResponse) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{11}
+	return file_coin_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *GetBlocksResponse) GetBlockHashes() []string {
@@ -882,31 +1046,32 @@ func (x *GetBlocksResponse) GetBlockHashes() []string {
 	return nil
 }
 
-type GetDataRequest struct {
+type GetHeadersRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	BlockHash string `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"` // the hash of the requested block
+	Locator []string `protobuf:"bytes,1,rep,name=locator,proto3" json:"locator,omitempty"`             // hashes of blocks the local node has, newest first, thinning out exponentially
+	AddrMe  string   `protobuf:"bytes,2,opt,name=addr_me,json=addrMe,proto3" json:"addr_me,omitempty"` // the IP address of the local node
 }
 
-func (x *GetDataRequest) Reset() {
-	*x = GetDataRequest{}
+func (x *GetHeadersRequest) Reset() {
+	*x = GetHeadersRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[12]
+		mi := &file_coin_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetDataRequest) String() string {
+func (x *GetHeadersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetDataRequest) ProtoMessage() {}
+func (*GetHeadersRequest) ProtoMessage() {}
 
-func (x *GetDataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[12]
+func (x *GetHeadersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -917,43 +1082,50 @@ func (x *GetDataRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetDataRequest.ProtoReflect.Descriptor instead.
-func (*GetDataRequest) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use GetHeadersRequest.ProtoReflect.Descriptor instead.
+func (*GetHeadersRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *GetDataRequest) GetBlockHash() string {
+func (x *GetHeadersRequest) GetLocator() []string {
 	if x != nil {
-		return x.BlockHash
+		return x.Locator
+	}
+	return nil
+}
+
+func (x *GetHeadersRequest) GetAddrMe() string {
+	if x != nil {
+		return x.AddrMe
 	}
 	return ""
 }
 
-type GetDataResponse struct {
+type GetHeadersResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Block *Block `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"` // requested block
+	Headers []*Header `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty"` // the headers of the blocks after the first locator hash on the main chain
 }
 
-func (x *GetDataResponse) Reset() {
-	*x = GetDataResponse{}
+func (x *GetHeadersResponse) Reset() {
+	*x = GetHeadersResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[13]
+		mi := &file_coin_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetDataResponse) String() string {
+func (x *GetHeadersResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetDataResponse) ProtoMessage() {}
+func (*GetHeadersResponse) ProtoMessage() {}
 
-func (x *GetDataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[13]
+func (x *GetHeadersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -964,44 +1136,43 @@ func (x *GetDataResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetDataResponse.ProtoReflect.Descriptor instead.
-func (*GetDataResponse) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use GetHeadersResponse.ProtoReflect.Descriptor instead.
+func (*GetHeadersResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *GetDataResponse) GetBlock() *Block {
+func (x *GetHeadersResponse) GetHeaders() []*Header {
 	if x != nil {
-		return x.Block
+		return x.Headers
 	}
 	return nil
 }
 
-type Address struct {
+type GetDataRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Addr     string `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`                          // actual address
-	LastSeen uint32 `protobuf:"varint,2,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"` // A unix timestamp or block number (pg 114)
+	BlockHash string `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"` // the hash of the requested block
 }
 
-func (x *Address) Reset() {
-	*x = Address{}
+func (x *GetDataRequest) Reset() {
+	*x = GetDataRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[14]
+		mi := &file_coin_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Address) String() string {
+func (x *GetDataRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Address) ProtoMessage() {}
+func (*GetDataRequest) ProtoMessage() {}
 
-func (x *Address) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[14]
+func (x *GetDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1012,50 +1183,43 @@ func (x *Address) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Address.ProtoReflect.Descriptor instead.
-func (*Address) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use GetDataRequest.ProtoReflect.Descriptor instead.
+func (*GetDataRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *Address) GetAddr() string {
+func (x *GetDataRequest) GetBlockHash() string {
 	if x != nil {
-		return x.Addr
+		return x.BlockHash
 	}
 	return ""
 }
 
-func (x *Address) GetLastSeen() uint32 {
-	if x != nil {
-		return x.LastSeen
-	}
-	return 0
-}
-
-type Addresses struct {
+type GetDataResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Addrs []*Address `protobuf:"bytes,1,rep,name=addrs,proto3" json:"addrs,omitempty"` // array of known neighbor addresses
+	Block *Block `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"` // requested block
 }
 
-func (x *Addresses) Reset() {
-	*x = Addresses{}
+func (x *GetDataResponse) Reset() {
+	*x = GetDataResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[15]
+		mi := &file_coin_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Addresses) String() string {
+func (x *GetDataResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Addresses) ProtoMessage() {}
+func (*GetDataResponse) ProtoMessage() {}
 
-func (x *Addresses) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[15]
+func (x *GetDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1066,44 +1230,45 @@ func (x *Addresses) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Addresses.ProtoReflect.Descriptor instead.
-func (*Addresses) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use GetDataResponse.ProtoReflect.Descriptor instead.
+func (*GetDataResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *Addresses) GetAddrs() []*Address {
+func (x *GetDataResponse) GetBlock() *Block {
 	if x != nil {
-		return x.Addrs
+		return x.Block
 	}
 	return nil
 }
 
-//------------------------ Project 3: Lightning ------------------------//
-type Witnesses struct {
+type UtxoDeltaRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Witnesses [][]byte `protobuf:"bytes,1,rep,name=witnesses,proto3" json:"witnesses,omitempty"`
+	AddrMe      string `protobuf:"bytes,1,opt,name=addr_me,json=addrMe,proto3" json:"addr_me,omitempty"`                 // the IP address of the local node
+	StartHeight uint32 `protobuf:"varint,2,opt,name=start_height,json=startHeight,proto3" json:"start_height,omitempty"` // the height the local node's coins are at
+	EndHeight   uint32 `protobuf:"varint,3,opt,name=end_height,json=endHeight,proto3" json:"end_height,omitempty"`       // the height to catch up to
 }
 
-func (x *Witnesses) Reset() {
-	*x = Witnesses{}
+func (x *UtxoDeltaRequest) Reset() {
+	*x = UtxoDeltaRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[16]
+		mi := &file_coin_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Witnesses) String() string {
+func (x *UtxoDeltaRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Witnesses) ProtoMessage() {}
+func (*UtxoDeltaRequest) ProtoMessage() {}
 
-func (x *Witnesses) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[16]
+func (x *UtxoDeltaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1114,43 +1279,58 @@ func (x *Witnesses) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Witnesses.ProtoReflect.Descriptor instead.
-func (*Witnesses) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use UtxoDeltaRequest.ProtoReflect.Descriptor instead.
+func (*UtxoDeltaRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *Witnesses) GetWitnesses() [][]byte {
+func (x *UtxoDeltaRequest) GetAddrMe() string {
 	if x != nil {
-		return x.Witnesses
+		return x.AddrMe
 	}
-	return nil
+	return ""
 }
 
-type RevocationKey struct {
+func (x *UtxoDeltaRequest) GetStartHeight() uint32 {
+	if x != nil {
+		return x.StartHeight
+	}
+	return 0
+}
+
+func (x *UtxoDeltaRequest) GetEndHeight() uint32 {
+	if x != nil {
+		return x.EndHeight
+	}
+	return 0
+}
+
+type ProfileRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Kind    string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`        // cpu, heap, or goroutine
+	Seconds uint32 `protobuf:"varint,2,opt,name=seconds,proto3" json:"seconds,omitempty"` // how long to sample a cpu profile for
 }
 
-func (x *RevocationKey) Reset() {
-	*x = RevocationKey{}
+func (x *ProfileRequest) Reset() {
+	*x = ProfileRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[17]
+		mi := &file_coin_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *RevocationKey) String() string {
+func (x *ProfileRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RevocationKey) ProtoMessage() {}
+func (*ProfileRequest) ProtoMessage() {}
 
-func (x *RevocationKey) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[17]
+func (x *ProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1161,45 +1341,50 @@ func (x *RevocationKey) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RevocationKey.ProtoReflect.Descriptor instead.
-func (*RevocationKey) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use ProfileRequest.ProtoReflect.Descriptor instead.
+func (*ProfileRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *RevocationKey) GetKey() []byte {
+func (x *ProfileRequest) GetKind() string {
 	if x != nil {
-		return x.Key
+		return x.Kind
 	}
-	return nil
+	return ""
 }
 
-type SignedTransactionWithKey struct {
+func (x *ProfileRequest) GetSeconds() uint32 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+type ProfileResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SignedTransaction *Transaction `protobuf:"bytes,1,opt,name=signed_transaction,json=signedTransaction,proto3" json:"signed_transaction,omitempty"`
-	RevocationKey     []byte       `protobuf:"bytes,2,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
-	Address           string       `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // where the profile was written
 }
 
-func (x *SignedTransactionWithKey) Reset() {
-	*x = SignedTransactionWithKey{}
+func (x *ProfileResponse) Reset() {
+	*x = ProfileResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[18]
+		mi := &file_coin_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SignedTransactionWithKey) String() string {
+func (x *ProfileResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SignedTransactionWithKey) ProtoMessage() {}
+func (*ProfileResponse) ProtoMessage() {}
 
-func (x *SignedTransactionWithKey) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[18]
+func (x *ProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1210,58 +1395,43 @@ func (x *SignedTransactionWithKey) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SignedTransactionWithKey.ProtoReflect.Descriptor instead.
-func (*SignedTransactionWithKey) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{18}
-}
-
-func (x *SignedTransactionWithKey) GetSignedTransaction() *Transaction {
-	if x != nil {
-		return x.SignedTransaction
-	}
-	return nil
-}
-
-func (x *SignedTransactionWithKey) GetRevocationKey() []byte {
-	if x != nil {
-		return x.RevocationKey
-	}
-	return nil
+// Deprecated: Use ProfileResponse.ProtoReflect.Descriptor instead.
+func (*ProfileResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *SignedTransactionWithKey) GetAddress() string {
+func (x *ProfileResponse) GetPath() string {
 	if x != nil {
-		return x.Address
+		return x.Path
 	}
 	return ""
 }
 
-type TransactionWithAddress struct {
+type BlockHashRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Transaction *Transaction `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
-	Address     string       `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	BlockHash string `protobuf:"bytes,1,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"` // the hash of the block to act on
 }
 
-func (x *TransactionWithAddress) Reset() {
-	*x = TransactionWithAddress{}
+func (x *BlockHashRequest) Reset() {
+	*x = BlockHashRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[19]
+		mi := &file_coin_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TransactionWithAddress) String() string {
+func (x *BlockHashRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TransactionWithAddress) ProtoMessage() {}
+func (*BlockHashRequest) ProtoMessage() {}
 
-func (x *TransactionWithAddress) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[19]
+func (x *BlockHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1272,51 +1442,44 @@ func (x *TransactionWithAddress) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TransactionWithAddress.ProtoReflect.Descriptor instead.
-func (*TransactionWithAddress) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{19}
-}
-
-func (x *TransactionWithAddress) GetTransaction() *Transaction {
-	if x != nil {
-		return x.Transaction
-	}
-	return nil
+// Deprecated: Use BlockHashRequest.ProtoReflect.Descriptor instead.
+func (*BlockHashRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *TransactionWithAddress) GetAddress() string {
+func (x *BlockHashRequest) GetBlockHash() string {
 	if x != nil {
-		return x.Address
+		return x.BlockHash
 	}
 	return ""
 }
 
-type UpdatedTransactions struct {
+type CoinLocator struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SignedTransaction   *Transaction `protobuf:"bytes,1,opt,name=signed_transaction,json=signedTransaction,proto3" json:"signed_transaction,omitempty"`
-	UnsignedTransaction *Transaction `protobuf:"bytes,2,opt,name=unsigned_transaction,json=unsignedTransaction,proto3" json:"unsigned_transaction,omitempty"`
+	ReferenceTransactionHash string `protobuf:"bytes,1,opt,name=reference_transaction_hash,json=referenceTransactionHash,proto3" json:"reference_transaction_hash,omitempty"`
+	OutputIndex              uint32 `protobuf:"varint,2,opt,name=output_index,json=outputIndex,proto3" json:"output_index,omitempty"`
 }
 
-func (x *UpdatedTransactions) Reset() {
-	*x = UpdatedTransactions{}
+func (x *CoinLocator) Reset() {
+	*x = CoinLocator{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[20]
+		mi := &file_coin_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UpdatedTransactions) String() string {
+func (x *CoinLocator) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdatedTransactions) ProtoMessage() {}
+func (*CoinLocator) ProtoMessage() {}
 
-func (x *UpdatedTransactions) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[20]
+func (x *CoinLocator) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1327,53 +1490,51 @@ func (x *UpdatedTransactions) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdatedTransactions.ProtoReflect.Descriptor instead.
-func (*UpdatedTransactions) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use CoinLocator.ProtoReflect.Descriptor instead.
+func (*CoinLocator) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *UpdatedTransactions) GetSignedTransaction() *Transaction {
+func (x *CoinLocator) GetReferenceTransactionHash() string {
 	if x != nil {
-		return x.SignedTransaction
+		return x.ReferenceTransactionHash
 	}
-	return nil
+	return ""
 }
 
-func (x *UpdatedTransactions) GetUnsignedTransaction() *Transaction {
+func (x *CoinLocator) GetOutputIndex() uint32 {
 	if x != nil {
-		return x.UnsignedTransaction
+		return x.OutputIndex
 	}
-	return nil
+	return 0
 }
 
-type OpenChannelRequest struct {
+type DeltaCoin struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Address            string       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	PublicKey          []byte       `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	FundingTransaction *Transaction `protobuf:"bytes,3,opt,name=funding_transaction,json=fundingTransaction,proto3" json:"funding_transaction,omitempty"`
-	RefundTransaction  *Transaction `protobuf:"bytes,4,opt,name=refund_transaction,json=refundTransaction,proto3" json:"refund_transaction,omitempty"`
+	Locator *CoinLocator       `protobuf:"bytes,1,opt,name=locator,proto3" json:"locator,omitempty"`
+	Output  *TransactionOutput `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
 }
 
-func (x *OpenChannelRequest) Reset() {
-	*x = OpenChannelRequest{}
+func (x *DeltaCoin) Reset() {
+	*x = DeltaCoin{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[21]
+		mi := &file_coin_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *OpenChannelRequest) String() string {
+func (x *DeltaCoin) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OpenChannelRequest) ProtoMessage() {}
+func (*DeltaCoin) ProtoMessage() {}
 
-func (x *OpenChannelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[21]
+func (x *DeltaCoin) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1384,66 +1545,51 @@ func (x *OpenChannelRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OpenChannelRequest.ProtoReflect.Descriptor instead.
-func (*OpenChannelRequest) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{21}
-}
-
-func (x *OpenChannelRequest) GetAddress() string {
-	if x != nil {
-		return x.Address
-	}
-	return ""
-}
-
-func (x *OpenChannelRequest) GetPublicKey() []byte {
-	if x != nil {
-		return x.PublicKey
-	}
-	return nil
+// Deprecated: Use DeltaCoin.ProtoReflect.Descriptor instead.
+func (*DeltaCoin) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *OpenChannelRequest) GetFundingTransaction() *Transaction {
+func (x *DeltaCoin) GetLocator() *CoinLocator {
 	if x != nil {
-		return x.FundingTransaction
+		return x.Locator
 	}
 	return nil
 }
 
-func (x *OpenChannelRequest) GetRefundTransaction() *Transaction {
+func (x *DeltaCoin) GetOutput() *TransactionOutput {
 	if x != nil {
-		return x.RefundTransaction
+		return x.Output
 	}
 	return nil
 }
 
-type OpenChannelResponse struct {
+type UtxoDelta struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	PublicKey                []byte       `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
-	SignedFundingTransaction *Transaction `protobuf:"bytes,2,opt,name=signed_funding_transaction,json=signedFundingTransaction,proto3" json:"signed_funding_transaction,omitempty"`
-	SignedRefundTransaction  *Transaction `protobuf:"bytes,3,opt,name=signed_refund_transaction,json=signedRefundTransaction,proto3" json:"signed_refund_transaction,omitempty"`
+	Spent   []*CoinLocator `protobuf:"bytes,1,rep,name=spent,proto3" json:"spent,omitempty"`     // coins that existed at start_height and were spent
+	Created []*DeltaCoin   `protobuf:"bytes,2,rep,name=created,proto3" json:"created,omitempty"` // coins created after start_height that are unspent at end_height
 }
 
-func (x *OpenChannelResponse) Reset() {
-	*x = OpenChannelResponse{}
+func (x *UtxoDelta) Reset() {
+	*x = UtxoDelta{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[22]
+		mi := &file_coin_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *OpenChannelResponse) String() string {
+func (x *UtxoDelta) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OpenChannelResponse) ProtoMessage() {}
+func (*UtxoDelta) ProtoMessage() {}
 
-func (x *OpenChannelResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[22]
+func (x *UtxoDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1454,59 +1600,51 @@ func (x *OpenChannelResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OpenChannelResponse.ProtoReflect.Descriptor instead.
-func (*OpenChannelResponse) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{22}
-}
-
-func (x *OpenChannelResponse) GetPublicKey() []byte {
-	if x != nil {
-		return x.PublicKey
-	}
-	return nil
+// Deprecated: Use UtxoDelta.ProtoReflect.Descriptor instead.
+func (*UtxoDelta) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *OpenChannelResponse) GetSignedFundingTransaction() *Transaction {
+func (x *UtxoDelta) GetSpent() []*CoinLocator {
 	if x != nil {
-		return x.SignedFundingTransaction
+		return x.Spent
 	}
 	return nil
 }
 
-func (x *OpenChannelResponse) GetSignedRefundTransaction() *Transaction {
+func (x *UtxoDelta) GetCreated() []*DeltaCoin {
 	if x != nil {
-		return x.SignedRefundTransaction
+		return x.Created
 	}
 	return nil
 }
 
-// our 3 different Locking Scripts
-type PayToPublicKey struct {
+type Address struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ScriptType ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
-	PublicKey  []byte     `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Addr     string `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`                          // actual address
+	LastSeen uint32 `protobuf:"varint,2,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"` // A unix timestamp or block number (pg 114)
 }
 
-func (x *PayToPublicKey) Reset() {
-	*x = PayToPublicKey{}
+func (x *Address) Reset() {
+	*x = Address{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[23]
+		mi := &file_coin_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *PayToPublicKey) String() string {
+func (x *Address) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PayToPublicKey) ProtoMessage() {}
+func (*Address) ProtoMessage() {}
 
-func (x *PayToPublicKey) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[23]
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1517,54 +1655,1970 @@ func (x *PayToPublicKey) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PayToPublicKey.ProtoReflect.Descriptor instead.
-func (*PayToPublicKey) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *PayToPublicKey) GetScriptType() ScriptType {
+func (x *Address) GetAddr() string {
 	if x != nil {
-		return x.ScriptType
+		return x.Addr
 	}
-	return ScriptType_P2PK
+	return ""
 }
 
-func (x *PayToPublicKey) GetPublicKey() []byte {
+func (x *Address) GetLastSeen() uint32 {
 	if x != nil {
-		return x.PublicKey
+		return x.LastSeen
 	}
-	return nil
+	return 0
 }
 
-type MultiParty struct {
+type Addresses struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ScriptType       ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
-	MyPublicKey      []byte     `protobuf:"bytes,2,opt,name=my_public_key,json=myPublicKey,proto3" json:"my_public_key,omitempty"`
-	TheirPublicKey   []byte     `protobuf:"bytes,3,opt,name=their_public_key,json=theirPublicKey,proto3,oneof" json:"their_public_key,omitempty"`
-	RevocationKey    []byte     `protobuf:"bytes,4,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
-	AdditionalBlocks uint32     `protobuf:"varint,5,opt,name=additional_blocks,json=additionalBlocks,proto3" json:"additional_blocks,omitempty"`
+	Addrs []*Address `protobuf:"bytes,1,rep,name=addrs,proto3" json:"addrs,omitempty"` // array of known neighbor addresses
 }
 
-func (x *MultiParty) Reset() {
-	*x = MultiParty{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[24]
+func (x *Addresses) Reset() {
+	*x = Addresses{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Addresses) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Addresses) ProtoMessage() {}
+
+func (x *Addresses) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Addresses.ProtoReflect.Descriptor instead.
+func (*Addresses) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Addresses) GetAddrs() []*Address {
+	if x != nil {
+		return x.Addrs
+	}
+	return nil
+}
+
+type BlockTip struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash           string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`                                           // the hash of the tip block
+	Height         uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`                                      // the height of the tip block
+	CumulativeWork string `protobuf:"bytes,3,opt,name=cumulative_work,json=cumulativeWork,proto3" json:"cumulative_work,omitempty"` // total work from genesis to the tip, in decimal
+	Active         bool   `protobuf:"varint,4,opt,name=active,proto3" json:"active,omitempty"`                                      // whether the tip is the end of the active chain
+	BranchPoint    string `protobuf:"bytes,5,opt,name=branch_point,json=branchPoint,proto3" json:"branch_point,omitempty"`          // the hash where this tip's branch leaves the active chain
+}
+
+func (x *BlockTip) Reset() {
+	*x = BlockTip{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockTip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockTip) ProtoMessage() {}
+
+func (x *BlockTip) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockTip.ProtoReflect.Descriptor instead.
+func (*BlockTip) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *BlockTip) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *BlockTip) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *BlockTip) GetCumulativeWork() string {
+	if x != nil {
+		return x.CumulativeWork
+	}
+	return ""
+}
+
+func (x *BlockTip) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *BlockTip) GetBranchPoint() string {
+	if x != nil {
+		return x.BranchPoint
+	}
+	return ""
+}
+
+type BranchPoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash     string   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`         // the hash of the block with more than one child
+	Height   uint32   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`    // the height of that block
+	Children []string `protobuf:"bytes,3,rep,name=children,proto3" json:"children,omitempty"` // the hashes of the block's children
+}
+
+func (x *BranchPoint) Reset() {
+	*x = BranchPoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BranchPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BranchPoint) ProtoMessage() {}
+
+func (x *BranchPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BranchPoint.ProtoReflect.Descriptor instead.
+func (*BranchPoint) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *BranchPoint) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *BranchPoint) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *BranchPoint) GetChildren() []string {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type BlockTree struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BestHash     string         `protobuf:"bytes,1,opt,name=best_hash,json=bestHash,proto3" json:"best_hash,omitempty"`             // the hash of the last block on the active chain
+	Tips         []*BlockTip    `protobuf:"bytes,2,rep,name=tips,proto3" json:"tips,omitempty"`                                     // every known block without children
+	BranchPoints []*BranchPoint `protobuf:"bytes,3,rep,name=branch_points,json=branchPoints,proto3" json:"branch_points,omitempty"` // every known block with multiple children
+}
+
+func (x *BlockTree) Reset() {
+	*x = BlockTree{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockTree) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockTree) ProtoMessage() {}
+
+func (x *BlockTree) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockTree.ProtoReflect.Descriptor instead.
+func (*BlockTree) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *BlockTree) GetBestHash() string {
+	if x != nil {
+		return x.BestHash
+	}
+	return ""
+}
+
+func (x *BlockTree) GetTips() []*BlockTip {
+	if x != nil {
+		return x.Tips
+	}
+	return nil
+}
+
+func (x *BlockTree) GetBranchPoints() []*BranchPoint {
+	if x != nil {
+		return x.BranchPoints
+	}
+	return nil
+}
+
+type LoadBlocksRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // an archive, block file, or block directory on the node's disk
+}
+
+func (x *LoadBlocksRequest) Reset() {
+	*x = LoadBlocksRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadBlocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadBlocksRequest) ProtoMessage() {}
+
+func (x *LoadBlocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadBlocksRequest.ProtoReflect.Descriptor instead.
+func (*LoadBlocksRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *LoadBlocksRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type LoadBlocksResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Accepted uint32    `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"` // how many blocks were accepted
+	Tip      *BlockTip `protobuf:"bytes,2,opt,name=tip,proto3" json:"tip,omitempty"`            // the tip of the active chain afterwards
+}
+
+func (x *LoadBlocksResponse) Reset() {
+	*x = LoadBlocksResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoadBlocksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoadBlocksResponse) ProtoMessage() {}
+
+func (x *LoadBlocksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoadBlocksResponse.ProtoReflect.Descriptor instead.
+func (*LoadBlocksResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *LoadBlocksResponse) GetAccepted() uint32 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *LoadBlocksResponse) GetTip() *BlockTip {
+	if x != nil {
+		return x.Tip
+	}
+	return nil
+}
+
+type Deployment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                             // identifies the soft fork
+	Bit       uint32 `protobuf:"varint,2,opt,name=bit,proto3" json:"bit,omitempty"`                              // the header version bit blocks signal for it with
+	StartTime uint32 `protobuf:"varint,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // the median time past from which signals count
+	Timeout   uint32 `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`                      // the median time past from which it fails unless locked in
+	State     string `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`                           // defined, started, locked_in, active or failed
+	Since     uint32 `protobuf:"varint,6,opt,name=since,proto3" json:"since,omitempty"`                          // the height of the first block in that state
+	Elapsed   uint32 `protobuf:"varint,7,opt,name=elapsed,proto3" json:"elapsed,omitempty"`                      // how many blocks of the current period there are
+	Count     uint32 `protobuf:"varint,8,opt,name=count,proto3" json:"count,omitempty"`                          // how many of them signal
+}
+
+func (x *Deployment) Reset() {
+	*x = Deployment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Deployment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deployment) ProtoMessage() {}
+
+func (x *Deployment) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deployment.ProtoReflect.Descriptor instead.
+func (*Deployment) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *Deployment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Deployment) GetBit() uint32 {
+	if x != nil {
+		return x.Bit
+	}
+	return 0
+}
+
+func (x *Deployment) GetStartTime() uint32 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Deployment) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *Deployment) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Deployment) GetSince() uint32 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *Deployment) GetElapsed() uint32 {
+	if x != nil {
+		return x.Elapsed
+	}
+	return 0
+}
+
+func (x *Deployment) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Deployments struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Period      uint32        `protobuf:"varint,1,opt,name=period,proto3" json:"period,omitempty"`          // how many blocks signals are counted over
+	Threshold   uint32        `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`    // how many of a period's blocks must signal to lock in
+	Deployments []*Deployment `protobuf:"bytes,3,rep,name=deployments,proto3" json:"deployments,omitempty"` // where each deployment stands for the next block
+}
+
+func (x *Deployments) Reset() {
+	*x = Deployments{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Deployments) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deployments) ProtoMessage() {}
+
+func (x *Deployments) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deployments.ProtoReflect.Descriptor instead.
+func (*Deployments) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Deployments) GetPeriod() uint32 {
+	if x != nil {
+		return x.Period
+	}
+	return 0
+}
+
+func (x *Deployments) GetThreshold() uint32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Deployments) GetDeployments() []*Deployment {
+	if x != nil {
+		return x.Deployments
+	}
+	return nil
+}
+
+type SnapshotInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height      uint32   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`                             // the height of the block the snapshot is of
+	Hash        string   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`                                  // the hash of that block
+	UtxoHash    []byte   `protobuf:"bytes,3,opt,name=utxo_hash,json=utxoHash,proto3" json:"utxo_hash,omitempty"`          // the rolling hash of the snapshot's coins, whose digest checkpoints commit to
+	Coins       uint32   `protobuf:"varint,4,opt,name=coins,proto3" json:"coins,omitempty"`                               // how many coins the snapshot holds
+	ChunkHashes [][]byte `protobuf:"bytes,5,rep,name=chunk_hashes,json=chunkHashes,proto3" json:"chunk_hashes,omitempty"` // the sha256 of each encoded chunk, in order
+}
+
+func (x *SnapshotInfo) Reset() {
+	*x = SnapshotInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotInfo) ProtoMessage() {}
+
+func (x *SnapshotInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotInfo.ProtoReflect.Descriptor instead.
+func (*SnapshotInfo) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SnapshotInfo) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *SnapshotInfo) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *SnapshotInfo) GetUtxoHash() []byte {
+	if x != nil {
+		return x.UtxoHash
+	}
+	return nil
+}
+
+func (x *SnapshotInfo) GetCoins() uint32 {
+	if x != nil {
+		return x.Coins
+	}
+	return 0
+}
+
+func (x *SnapshotInfo) GetChunkHashes() [][]byte {
+	if x != nil {
+		return x.ChunkHashes
+	}
+	return nil
+}
+
+type SnapshotList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Snapshots []*SnapshotInfo `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"` // every snapshot the node serves
+}
+
+func (x *SnapshotList) Reset() {
+	*x = SnapshotList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotList) ProtoMessage() {}
+
+func (x *SnapshotList) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotList.ProtoReflect.Descriptor instead.
+func (*SnapshotList) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *SnapshotList) GetSnapshots() []*SnapshotInfo {
+	if x != nil {
+		return x.Snapshots
+	}
+	return nil
+}
+
+type SnapshotChunkRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height uint32 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"` // the height of the snapshot
+	Index  uint32 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`   // which of its chunks to send
+}
+
+func (x *SnapshotChunkRequest) Reset() {
+	*x = SnapshotChunkRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotChunkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotChunkRequest) ProtoMessage() {}
+
+func (x *SnapshotChunkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotChunkRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotChunkRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *SnapshotChunkRequest) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *SnapshotChunkRequest) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+type SnapshotChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Coins []*DeltaCoin `protobuf:"bytes,1,rep,name=coins,proto3" json:"coins,omitempty"` // the chunk's coins, in locator order
+}
+
+func (x *SnapshotChunk) Reset() {
+	*x = SnapshotChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotChunk) ProtoMessage() {}
+
+func (x *SnapshotChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotChunk.ProtoReflect.Descriptor instead.
+func (*SnapshotChunk) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SnapshotChunk) GetCoins() []*DeltaCoin {
+	if x != nil {
+		return x.Coins
+	}
+	return nil
+}
+
+type RefusedReorg struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tip      string `protobuf:"bytes,1,opt,name=tip,proto3" json:"tip,omitempty"`           // the hash of the last block of the branch
+	Height   uint32 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`    // the height of that block
+	Ancestor string `protobuf:"bytes,3,opt,name=ancestor,proto3" json:"ancestor,omitempty"` // the hash of the last block the branch shares with the active chain
+	Depth    uint32 `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`      // how many blocks of the active chain switching to it would disconnect
+}
+
+func (x *RefusedReorg) Reset() {
+	*x = RefusedReorg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RefusedReorg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefusedReorg) ProtoMessage() {}
+
+func (x *RefusedReorg) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefusedReorg.ProtoReflect.Descriptor instead.
+func (*RefusedReorg) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *RefusedReorg) GetTip() string {
+	if x != nil {
+		return x.Tip
+	}
+	return ""
+}
+
+func (x *RefusedReorg) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *RefusedReorg) GetAncestor() string {
+	if x != nil {
+		return x.Ancestor
+	}
+	return ""
+}
+
+func (x *RefusedReorg) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+type RefusedReorgs struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reorgs []*RefusedReorg `protobuf:"bytes,1,rep,name=reorgs,proto3" json:"reorgs,omitempty"` // heavier branches waiting for an operator to confirm them
+}
+
+func (x *RefusedReorgs) Reset() {
+	*x = RefusedReorgs{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RefusedReorgs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefusedReorgs) ProtoMessage() {}
+
+func (x *RefusedReorgs) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefusedReorgs.ProtoReflect.Descriptor instead.
+func (*RefusedReorgs) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *RefusedReorgs) GetReorgs() []*RefusedReorg {
+	if x != nil {
+		return x.Reorgs
+	}
+	return nil
+}
+
+type NodeStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address string    `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`              // the address the node listens on
+	Version uint32    `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`             // the version the node is running
+	Tip     *BlockTip `protobuf:"bytes,3,opt,name=tip,proto3" json:"tip,omitempty"`                      // the tip of the active chain
+	Peers   uint32    `protobuf:"varint,4,opt,name=peers,proto3" json:"peers,omitempty"`                 // how many peers the node has
+	TipAge  uint64    `protobuf:"varint,5,opt,name=tip_age,json=tipAge,proto3" json:"tip_age,omitempty"` // seconds since a block last became the tip
+	Stale   bool      `protobuf:"varint,6,opt,name=stale,proto3" json:"stale,omitempty"`                 // whether the tip is older than the node expects a block to take
+}
+
+func (x *NodeStatus) Reset() {
+	*x = NodeStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeStatus) ProtoMessage() {}
+
+func (x *NodeStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeStatus.ProtoReflect.Descriptor instead.
+func (*NodeStatus) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *NodeStatus) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *NodeStatus) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetTip() *BlockTip {
+	if x != nil {
+		return x.Tip
+	}
+	return nil
+}
+
+func (x *NodeStatus) GetPeers() uint32 {
+	if x != nil {
+		return x.Peers
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetTipAge() uint64 {
+	if x != nil {
+		return x.TipAge
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+//------------------------ Project 3: Lightning ------------------------//
+type Witnesses struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Witnesses [][]byte `protobuf:"bytes,1,rep,name=witnesses,proto3" json:"witnesses,omitempty"`
+}
+
+func (x *Witnesses) Reset() {
+	*x = Witnesses{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Witnesses) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Witnesses) ProtoMessage() {}
+
+func (x *Witnesses) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Witnesses.ProtoReflect.Descriptor instead.
+func (*Witnesses) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *Witnesses) GetWitnesses() [][]byte {
+	if x != nil {
+		return x.Witnesses
+	}
+	return nil
+}
+
+type RevocationKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *RevocationKey) Reset() {
+	*x = RevocationKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[42]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RevocationKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevocationKey) ProtoMessage() {}
+
+func (x *RevocationKey) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[42]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevocationKey.ProtoReflect.Descriptor instead.
+func (*RevocationKey) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RevocationKey) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type SignedTransactionWithKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SignedTransaction *Transaction `protobuf:"bytes,1,opt,name=signed_transaction,json=signedTransaction,proto3" json:"signed_transaction,omitempty"`
+	RevocationKey     []byte       `protobuf:"bytes,2,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
+	Address           string       `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *SignedTransactionWithKey) Reset() {
+	*x = SignedTransactionWithKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[43]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignedTransactionWithKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedTransactionWithKey) ProtoMessage() {}
+
+func (x *SignedTransactionWithKey) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[43]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedTransactionWithKey.ProtoReflect.Descriptor instead.
+func (*SignedTransactionWithKey) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *SignedTransactionWithKey) GetSignedTransaction() *Transaction {
+	if x != nil {
+		return x.SignedTransaction
+	}
+	return nil
+}
+
+func (x *SignedTransactionWithKey) GetRevocationKey() []byte {
+	if x != nil {
+		return x.RevocationKey
+	}
+	return nil
+}
+
+func (x *SignedTransactionWithKey) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type TransactionWithAddress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Transaction *Transaction `protobuf:"bytes,1,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	Address     string       `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *TransactionWithAddress) Reset() {
+	*x = TransactionWithAddress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[44]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TransactionWithAddress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionWithAddress) ProtoMessage() {}
+
+func (x *TransactionWithAddress) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[44]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionWithAddress.ProtoReflect.Descriptor instead.
+func (*TransactionWithAddress) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *TransactionWithAddress) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *TransactionWithAddress) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type UpdatedTransactions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SignedTransaction   *Transaction `protobuf:"bytes,1,opt,name=signed_transaction,json=signedTransaction,proto3" json:"signed_transaction,omitempty"`
+	UnsignedTransaction *Transaction `protobuf:"bytes,2,opt,name=unsigned_transaction,json=unsignedTransaction,proto3" json:"unsigned_transaction,omitempty"`
+}
+
+func (x *UpdatedTransactions) Reset() {
+	*x = UpdatedTransactions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[45]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdatedTransactions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatedTransactions) ProtoMessage() {}
+
+func (x *UpdatedTransactions) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[45]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatedTransactions.ProtoReflect.Descriptor instead.
+func (*UpdatedTransactions) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *UpdatedTransactions) GetSignedTransaction() *Transaction {
+	if x != nil {
+		return x.SignedTransaction
+	}
+	return nil
+}
+
+func (x *UpdatedTransactions) GetUnsignedTransaction() *Transaction {
+	if x != nil {
+		return x.UnsignedTransaction
+	}
+	return nil
+}
+
+type OpenChannelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address               string       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	PublicKey             []byte       `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	FundingTransaction    *Transaction `protobuf:"bytes,3,opt,name=funding_transaction,json=fundingTransaction,proto3" json:"funding_transaction,omitempty"`
+	RefundTransaction     *Transaction `protobuf:"bytes,4,opt,name=refund_transaction,json=refundTransaction,proto3" json:"refund_transaction,omitempty"`
+	UpfrontShutdownScript []byte       `protobuf:"bytes,5,opt,name=upfront_shutdown_script,json=upfrontShutdownScript,proto3" json:"upfront_shutdown_script,omitempty"`
+}
+
+func (x *OpenChannelRequest) Reset() {
+	*x = OpenChannelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OpenChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenChannelRequest) ProtoMessage() {}
+
+func (x *OpenChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenChannelRequest.ProtoReflect.Descriptor instead.
+func (*OpenChannelRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *OpenChannelRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *OpenChannelRequest) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *OpenChannelRequest) GetFundingTransaction() *Transaction {
+	if x != nil {
+		return x.FundingTransaction
+	}
+	return nil
+}
+
+func (x *OpenChannelRequest) GetRefundTransaction() *Transaction {
+	if x != nil {
+		return x.RefundTransaction
+	}
+	return nil
+}
+
+func (x *OpenChannelRequest) GetUpfrontShutdownScript() []byte {
+	if x != nil {
+		return x.UpfrontShutdownScript
+	}
+	return nil
+}
+
+type OpenChannelResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PublicKey                []byte       `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	SignedFundingTransaction *Transaction `protobuf:"bytes,2,opt,name=signed_funding_transaction,json=signedFundingTransaction,proto3" json:"signed_funding_transaction,omitempty"`
+	SignedRefundTransaction  *Transaction `protobuf:"bytes,3,opt,name=signed_refund_transaction,json=signedRefundTransaction,proto3" json:"signed_refund_transaction,omitempty"`
+	UpfrontShutdownScript    []byte       `protobuf:"bytes,4,opt,name=upfront_shutdown_script,json=upfrontShutdownScript,proto3" json:"upfront_shutdown_script,omitempty"`
+}
+
+func (x *OpenChannelResponse) Reset() {
+	*x = OpenChannelResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[47]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OpenChannelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenChannelResponse) ProtoMessage() {}
+
+func (x *OpenChannelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[47]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenChannelResponse.ProtoReflect.Descriptor instead.
+func (*OpenChannelResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *OpenChannelResponse) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *OpenChannelResponse) GetSignedFundingTransaction() *Transaction {
+	if x != nil {
+		return x.SignedFundingTransaction
+	}
+	return nil
+}
+
+func (x *OpenChannelResponse) GetSignedRefundTransaction() *Transaction {
+	if x != nil {
+		return x.SignedRefundTransaction
+	}
+	return nil
+}
+
+func (x *OpenChannelResponse) GetUpfrontShutdownScript() []byte {
+	if x != nil {
+		return x.UpfrontShutdownScript
+	}
+	return nil
+}
+
+type CloseChannelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address            string       `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	ClosingTransaction *Transaction `protobuf:"bytes,2,opt,name=closing_transaction,json=closingTransaction,proto3" json:"closing_transaction,omitempty"`
+}
+
+func (x *CloseChannelRequest) Reset() {
+	*x = CloseChannelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CloseChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseChannelRequest) ProtoMessage() {}
+
+func (x *CloseChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseChannelRequest.ProtoReflect.Descriptor instead.
+func (*CloseChannelRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *CloseChannelRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *CloseChannelRequest) GetClosingTransaction() *Transaction {
+	if x != nil {
+		return x.ClosingTransaction
+	}
+	return nil
+}
+
+type CloseChannelResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SignedClosingTransaction *Transaction `protobuf:"bytes,1,opt,name=signed_closing_transaction,json=signedClosingTransaction,proto3" json:"signed_closing_transaction,omitempty"`
+}
+
+func (x *CloseChannelResponse) Reset() {
+	*x = CloseChannelResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[49]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CloseChannelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseChannelResponse) ProtoMessage() {}
+
+func (x *CloseChannelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[49]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseChannelResponse.ProtoReflect.Descriptor instead.
+func (*CloseChannelResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *CloseChannelResponse) GetSignedClosingTransaction() *Transaction {
+	if x != nil {
+		return x.SignedClosingTransaction
+	}
+	return nil
+}
+
+type ProbeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address     string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Amount      uint32 `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	PaymentHash []byte `protobuf:"bytes,3,opt,name=payment_hash,json=paymentHash,proto3" json:"payment_hash,omitempty"`
+}
+
+func (x *ProbeRequest) Reset() {
+	*x = ProbeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeRequest) ProtoMessage() {}
+
+func (x *ProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeRequest.ProtoReflect.Descriptor instead.
+func (*ProbeRequest) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ProbeRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ProbeRequest) GetAmount() uint32 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ProbeRequest) GetPaymentHash() []byte {
+	if x != nil {
+		return x.PaymentHash
+	}
+	return nil
+}
+
+type ProbeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sufficient bool   `protobuf:"varint,1,opt,name=sufficient,proto3" json:"sufficient,omitempty"`
+	Failure    string `protobuf:"bytes,2,opt,name=failure,proto3" json:"failure,omitempty"`
+}
+
+func (x *ProbeResponse) Reset() {
+	*x = ProbeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[51]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProbeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeResponse) ProtoMessage() {}
+
+func (x *ProbeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[51]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeResponse.ProtoReflect.Descriptor instead.
+func (*ProbeResponse) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ProbeResponse) GetSufficient() bool {
+	if x != nil {
+		return x.Sufficient
+	}
+	return false
+}
+
+func (x *ProbeResponse) GetFailure() string {
+	if x != nil {
+		return x.Failure
+	}
+	return ""
+}
+
+type Invoice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PaymentHash []byte `protobuf:"bytes,1,opt,name=payment_hash,json=paymentHash,proto3" json:"payment_hash,omitempty"`
+	Preimage    []byte `protobuf:"bytes,2,opt,name=preimage,proto3" json:"preimage,omitempty"`
+	Amount      uint32 `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Memo        string `protobuf:"bytes,4,opt,name=memo,proto3" json:"memo,omitempty"`
+	Incoming    bool   `protobuf:"varint,5,opt,name=incoming,proto3" json:"incoming,omitempty"`
+	CreatedAt   int64  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	SettledAt   int64  `protobuf:"varint,7,opt,name=settled_at,json=settledAt,proto3" json:"settled_at,omitempty"`
+}
+
+func (x *Invoice) Reset() {
+	*x = Invoice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[52]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Invoice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invoice) ProtoMessage() {}
+
+func (x *Invoice) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[52]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invoice.ProtoReflect.Descriptor instead.
+func (*Invoice) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *Invoice) GetPaymentHash() []byte {
+	if x != nil {
+		return x.PaymentHash
+	}
+	return nil
+}
+
+func (x *Invoice) GetPreimage() []byte {
+	if x != nil {
+		return x.Preimage
+	}
+	return nil
+}
+
+func (x *Invoice) GetAmount() uint32 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Invoice) GetMemo() string {
+	if x != nil {
+		return x.Memo
+	}
+	return ""
+}
+
+func (x *Invoice) GetIncoming() bool {
+	if x != nil {
+		return x.Incoming
+	}
+	return false
+}
+
+func (x *Invoice) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Invoice) GetSettledAt() int64 {
+	if x != nil {
+		return x.SettledAt
+	}
+	return 0
+}
+
+// our 4 different Locking Scripts
+type PayToPublicKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ScriptType ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
+	PublicKey  []byte     `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+}
+
+func (x *PayToPublicKey) Reset() {
+	*x = PayToPublicKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[53]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PayToPublicKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayToPublicKey) ProtoMessage() {}
+
+func (x *PayToPublicKey) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[53]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayToPublicKey.ProtoReflect.Descriptor instead.
+func (*PayToPublicKey) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *PayToPublicKey) GetScriptType() ScriptType {
+	if x != nil {
+		return x.ScriptType
+	}
+	return ScriptType_P2PK
+}
+
+func (x *PayToPublicKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+type MultiParty struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ScriptType       ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
+	MyPublicKey      []byte     `protobuf:"bytes,2,opt,name=my_public_key,json=myPublicKey,proto3" json:"my_public_key,omitempty"`
+	TheirPublicKey   []byte     `protobuf:"bytes,3,opt,name=their_public_key,json=theirPublicKey,proto3,oneof" json:"their_public_key,omitempty"`
+	RevocationKey    []byte     `protobuf:"bytes,4,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
+	AdditionalBlocks uint32     `protobuf:"varint,5,opt,name=additional_blocks,json=additionalBlocks,proto3" json:"additional_blocks,omitempty"`
+}
+
+func (x *MultiParty) Reset() {
+	*x = MultiParty{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[54]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiParty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiParty) ProtoMessage() {}
+
+func (x *MultiParty) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[54]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiParty.ProtoReflect.Descriptor instead.
+func (*MultiParty) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *MultiParty) GetScriptType() ScriptType {
+	if x != nil {
+		return x.ScriptType
+	}
+	return ScriptType_P2PK
+}
+
+func (x *MultiParty) GetMyPublicKey() []byte {
+	if x != nil {
+		return x.MyPublicKey
+	}
+	return nil
+}
+
+func (x *MultiParty) GetTheirPublicKey() []byte {
+	if x != nil {
+		return x.TheirPublicKey
+	}
+	return nil
+}
+
+func (x *MultiParty) GetRevocationKey() []byte {
+	if x != nil {
+		return x.RevocationKey
+	}
+	return nil
+}
+
+func (x *MultiParty) GetAdditionalBlocks() uint32 {
+	if x != nil {
+		return x.AdditionalBlocks
+	}
+	return 0
+}
+
+type HashedTimeLock struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ScriptType       ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
+	MyPublicKey      []byte     `protobuf:"bytes,2,opt,name=my_public_key,json=myPublicKey,proto3" json:"my_public_key,omitempty"`
+	TheirPublicKey   []byte     `protobuf:"bytes,3,opt,name=their_public_key,json=theirPublicKey,proto3" json:"their_public_key,omitempty"`
+	RevocationKey    []byte     `protobuf:"bytes,4,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
+	HashLock         string     `protobuf:"bytes,5,opt,name=hash_lock,json=hashLock,proto3" json:"hash_lock,omitempty"`
+	AdditionalBlocks uint32     `protobuf:"varint,6,opt,name=additional_blocks,json=additionalBlocks,proto3" json:"additional_blocks,omitempty"`
+	Fee              uint32     `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`
+}
+
+func (x *HashedTimeLock) Reset() {
+	*x = HashedTimeLock{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[55]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HashedTimeLock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashedTimeLock) ProtoMessage() {}
+
+func (x *HashedTimeLock) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[55]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashedTimeLock.ProtoReflect.Descriptor instead.
+func (*HashedTimeLock) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *HashedTimeLock) GetScriptType() ScriptType {
+	if x != nil {
+		return x.ScriptType
+	}
+	return ScriptType_P2PK
+}
+
+func (x *HashedTimeLock) GetMyPublicKey() []byte {
+	if x != nil {
+		return x.MyPublicKey
+	}
+	return nil
+}
+
+func (x *HashedTimeLock) GetTheirPublicKey() []byte {
+	if x != nil {
+		return x.TheirPublicKey
+	}
+	return nil
+}
+
+func (x *HashedTimeLock) GetRevocationKey() []byte {
+	if x != nil {
+		return x.RevocationKey
+	}
+	return nil
+}
+
+func (x *HashedTimeLock) GetHashLock() string {
+	if x != nil {
+		return x.HashLock
+	}
+	return ""
+}
+
+func (x *HashedTimeLock) GetAdditionalBlocks() uint32 {
+	if x != nil {
+		return x.AdditionalBlocks
+	}
+	return 0
+}
+
+func (x *HashedTimeLock) GetFee() uint32 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+type Vault struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ScriptType        ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
+	OwnerPublicKey    []byte     `protobuf:"bytes,2,opt,name=owner_public_key,json=ownerPublicKey,proto3" json:"owner_public_key,omitempty"`
+	RecoveryPublicKey []byte     `protobuf:"bytes,3,opt,name=recovery_public_key,json=recoveryPublicKey,proto3" json:"recovery_public_key,omitempty"`
+	DelayBlocks       uint32     `protobuf:"varint,4,opt,name=delay_blocks,json=delayBlocks,proto3" json:"delay_blocks,omitempty"`
+	Unvaulting        bool       `protobuf:"varint,5,opt,name=unvaulting,proto3" json:"unvaulting,omitempty"`
+}
+
+func (x *Vault) Reset() {
+	*x = Vault{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[56]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *MultiParty) String() string {
+func (x *Vault) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MultiParty) ProtoMessage() {}
+func (*Vault) ProtoMessage() {}
 
-func (x *MultiParty) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[24]
+func (x *Vault) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[56]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1575,77 +3629,75 @@ func (x *MultiParty) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MultiParty.ProtoReflect.Descriptor instead.
-func (*MultiParty) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{24}
+// Deprecated: Use Vault.ProtoReflect.Descriptor instead.
+func (*Vault) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *MultiParty) GetScriptType() ScriptType {
+func (x *Vault) GetScriptType() ScriptType {
 	if x != nil {
 		return x.ScriptType
 	}
 	return ScriptType_P2PK
 }
 
-func (x *MultiParty) GetMyPublicKey() []byte {
+func (x *Vault) GetOwnerPublicKey() []byte {
 	if x != nil {
-		return x.MyPublicKey
+		return x.OwnerPublicKey
 	}
 	return nil
 }
 
-func (x *MultiParty) GetTheirPublicKey() []byte {
+func (x *Vault) GetRecoveryPublicKey() []byte {
 	if x != nil {
-		return x.TheirPublicKey
+		return x.RecoveryPublicKey
 	}
 	return nil
 }
 
-func (x *MultiParty) GetRevocationKey() []byte {
+func (x *Vault) GetDelayBlocks() uint32 {
 	if x != nil {
-		return x.RevocationKey
+		return x.DelayBlocks
 	}
-	return nil
+	return 0
 }
 
-func (x *MultiParty) GetAdditionalBlocks() uint32 {
+func (x *Vault) GetUnvaulting() bool {
 	if x != nil {
-		return x.AdditionalBlocks
+		return x.Unvaulting
 	}
-	return 0
+	return false
 }
 
-type HashedTimeLock struct {
+type Swap struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ScriptType       ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
-	MyPublicKey      []byte     `protobuf:"bytes,2,opt,name=my_public_key,json=myPublicKey,proto3" json:"my_public_key,omitempty"`
-	TheirPublicKey   []byte     `protobuf:"bytes,3,opt,name=their_public_key,json=theirPublicKey,proto3" json:"their_public_key,omitempty"`
-	RevocationKey    []byte     `protobuf:"bytes,4,opt,name=revocation_key,json=revocationKey,proto3" json:"revocation_key,omitempty"`
-	HashLock         string     `protobuf:"bytes,5,opt,name=hash_lock,json=hashLock,proto3" json:"hash_lock,omitempty"`
-	AdditionalBlocks uint32     `protobuf:"varint,6,opt,name=additional_blocks,json=additionalBlocks,proto3" json:"additional_blocks,omitempty"`
-	Fee              uint32     `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`
+	ScriptType         ScriptType `protobuf:"varint,1,opt,name=script_type,json=scriptType,proto3,enum=ScriptType" json:"script_type,omitempty"`
+	SenderPublicKey    []byte     `protobuf:"bytes,2,opt,name=sender_public_key,json=senderPublicKey,proto3" json:"sender_public_key,omitempty"`
+	RecipientPublicKey []byte     `protobuf:"bytes,3,opt,name=recipient_public_key,json=recipientPublicKey,proto3" json:"recipient_public_key,omitempty"`
+	PaymentHash        []byte     `protobuf:"bytes,4,opt,name=payment_hash,json=paymentHash,proto3" json:"payment_hash,omitempty"`
+	TimeoutBlocks      uint32     `protobuf:"varint,5,opt,name=timeout_blocks,json=timeoutBlocks,proto3" json:"timeout_blocks,omitempty"`
 }
 
-func (x *HashedTimeLock) Reset() {
-	*x = HashedTimeLock{}
+func (x *Swap) Reset() {
+	*x = Swap{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_coin_proto_msgTypes[25]
+		mi := &file_coin_proto_msgTypes[57]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *HashedTimeLock) String() string {
+func (x *Swap) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HashedTimeLock) ProtoMessage() {}
+func (*Swap) ProtoMessage() {}
 
-func (x *HashedTimeLock) ProtoReflect() protoreflect.Message {
-	mi := &file_coin_proto_msgTypes[25]
+func (x *Swap) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[57]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1656,58 +3708,99 @@ func (x *HashedTimeLock) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HashedTimeLock.ProtoReflect.Descriptor instead.
-func (*HashedTimeLock) Descriptor() ([]byte, []int) {
-	return file_coin_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use Swap.ProtoReflect.Descriptor instead.
+func (*Swap) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *HashedTimeLock) GetScriptType() ScriptType {
+func (x *Swap) GetScriptType() ScriptType {
 	if x != nil {
 		return x.ScriptType
 	}
 	return ScriptType_P2PK
 }
 
-func (x *HashedTimeLock) GetMyPublicKey() []byte {
+func (x *Swap) GetSenderPublicKey() []byte {
 	if x != nil {
-		return x.MyPublicKey
+		return x.SenderPublicKey
 	}
 	return nil
 }
 
-func (x *HashedTimeLock) GetTheirPublicKey() []byte {
+func (x *Swap) GetRecipientPublicKey() []byte {
 	if x != nil {
-		return x.TheirPublicKey
+		return x.RecipientPublicKey
 	}
 	return nil
 }
 
-func (x *HashedTimeLock) GetRevocationKey() []byte {
+func (x *Swap) GetPaymentHash() []byte {
 	if x != nil {
-		return x.RevocationKey
+		return x.PaymentHash
 	}
 	return nil
 }
 
-func (x *HashedTimeLock) GetHashLock() string {
+func (x *Swap) GetTimeoutBlocks() uint32 {
 	if x != nil {
-		return x.HashLock
+		return x.TimeoutBlocks
 	}
-	return ""
+	return 0
 }
 
-func (x *HashedTimeLock) GetAdditionalBlocks() uint32 {
+type SwapUnlock struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signature []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+	Preimage  []byte `protobuf:"bytes,2,opt,name=preimage,proto3" json:"preimage,omitempty"`
+}
+
+func (x *SwapUnlock) Reset() {
+	*x = SwapUnlock{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_coin_proto_msgTypes[58]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwapUnlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwapUnlock) ProtoMessage() {}
+
+func (x *SwapUnlock) ProtoReflect() protoreflect.Message {
+	mi := &file_coin_proto_msgTypes[58]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwapUnlock.ProtoReflect.Descriptor instead.
+func (*SwapUnlock) Descriptor() ([]byte, []int) {
+	return file_coin_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *SwapUnlock) GetSignature() []byte {
 	if x != nil {
-		return x.AdditionalBlocks
+		return x.Signature
 	}
-	return 0
+	return nil
 }
 
-func (x *HashedTimeLock) GetFee() uint32 {
+func (x *SwapUnlock) GetPreimage() []byte {
 	if x != nil {
-		return x.Fee
+		return x.Preimage
 	}
-	return 0
+	return nil
 }
 
 var File_coin_proto protoreflect.FileDescriptor
@@ -1726,7 +3819,7 @@ var file_coin_proto_rawDesc = []byte{
 	0x72, 0x67, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20,
 	0x01, 0x28, 0x0d, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
 	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x74,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x9e, 0x01, 0x0a, 0x10, 0x54, 0x72, 0x61,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0xba, 0x01, 0x0a, 0x10, 0x54, 0x72, 0x61,
 	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x3c, 0x0a,
 	0x1a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73,
 	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
@@ -1736,104 +3829,258 @@ var file_coin_proto_rawDesc = []byte{
 	0x0d, 0x52, 0x0b, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x29,
 	0x0a, 0x10, 0x75, 0x6e, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x63, 0x72, 0x69,
 	0x70, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x75, 0x6e, 0x6c, 0x6f, 0x63, 0x6b,
-	0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x22, 0x52, 0x0a, 0x11, 0x54, 0x72, 0x61,
-	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x16,
-	0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06,
-	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e,
-	0x67, 0x5f, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d,
-	0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x22, 0xd3, 0x01,
-	0x0a, 0x0b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a,
-	0x06, 0x73, 0x65, 0x67, 0x77, 0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73,
-	0x65, 0x67, 0x77, 0x69, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
-	0x29, 0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x11, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70,
-	0x75, 0x74, 0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x07, 0x6f, 0x75,
-	0x74, 0x70, 0x75, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x54, 0x72,
-	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52,
-	0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x77, 0x69, 0x74, 0x6e,
-	0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x77, 0x69, 0x74,
-	0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x74,
-	0x69, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x6b, 0x54,
-	0x69, 0x6d, 0x65, 0x22, 0x5a, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1f, 0x0a, 0x06,
-	0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x48,
-	0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x30, 0x0a,
-	0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x52, 0x0c, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22,
-	0xe4, 0x02, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12,
-	0x1f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x07, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x34, 0x0a, 0x16, 0x6e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x5f, 0x6f, 0x66, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x14, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
-	0x4f, 0x66, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d,
-	0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x2c, 0x0a,
-	0x12, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6f, 0x66, 0x66,
-	0x73, 0x65, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
-	0x53, 0x74, 0x61, 0x72, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x62,
-	0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x65, 0x6e, 0x64, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18,
-	0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x45, 0x6e, 0x64, 0x4f,
-	0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x66, 0x69,
-	0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x6e, 0x64, 0x6f, 0x46, 0x69,
-	0x6c, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74,
-	0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x75,
-	0x6e, 0x64, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x26,
-	0x0a, 0x0f, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x65, 0x6e, 0x64, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65,
-	0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x75, 0x6e, 0x64, 0x6f, 0x45, 0x6e, 0x64,
-	0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x90, 0x01, 0x0a, 0x0a, 0x43, 0x6f, 0x69, 0x6e, 0x52,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
-	0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x65,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
-	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x73,
-	0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x63, 0x72, 0x69,
-	0x70, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0e, 0x6c, 0x6f, 0x63, 0x6b, 0x69,
-	0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x22, 0xaf, 0x01, 0x0a, 0x09, 0x55, 0x6e,
-	0x64, 0x6f, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x38, 0x0a, 0x18, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x5f, 0x68, 0x61, 0x73,
-	0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x16, 0x74, 0x72, 0x61, 0x6e, 0x73,
-	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x48, 0x61, 0x73, 0x68, 0x65,
-	0x73, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x6e, 0x64, 0x65,
-	0x78, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75,
-	0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6d, 0x6f, 0x75,
-	0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x6d, 0x6f, 0x75, 0x6e,
-	0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x63,
-	0x72, 0x69, 0x70, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0e, 0x6c, 0x6f, 0x63,
-	0x6b, 0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x22, 0x07, 0x0a, 0x05, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x22, 0x7f, 0x0a, 0x0e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x12, 0x19, 0x0a, 0x08, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x79, 0x6f, 0x75, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x59, 0x6f, 0x75, 0x12, 0x17, 0x0a, 0x07, 0x61,
-	0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x64,
-	0x64, 0x72, 0x4d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x68, 0x65, 0x69,
-	0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x62, 0x65, 0x73, 0x74, 0x48,
-	0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x51, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63,
-	0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x74, 0x6f, 0x70,
-	0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0c, 0x74, 0x6f, 0x70, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12,
-	0x17, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x06, 0x61, 0x64, 0x64, 0x72, 0x4d, 0x65, 0x22, 0x36, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a,
-	0x0c, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73,
-	0x22, 0x2f, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73,
-	0x68, 0x22, 0x2f, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f,
-	0x63, 0x6b, 0x22, 0x3a, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a,
-	0x04, 0x61, 0x64, 0x64, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x64, 0x64,
-	0x72, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x22, 0x2b,
-	0x0a, 0x09, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x05, 0x61,
-	0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x41, 0x64, 0x64,
-	0x72, 0x65, 0x73, 0x73, 0x52, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x22, 0x29, 0x0a, 0x09, 0x57,
+	0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x52, 0x0a, 0x11, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x6c, 0x6f, 0x63, 0x6b,
+	0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x22, 0xd3, 0x01, 0x0a, 0x0b, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x65, 0x67,
+	0x77, 0x69, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x65, 0x67, 0x77, 0x69,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x06, 0x69,
+	0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x54, 0x72,
+	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x06,
+	0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x07, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x77, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x65,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x77, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x6d, 0x65, 0x22,
+	0x5a, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1f, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x30, 0x0a, 0x0c, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x41, 0x0a, 0x0a, 0x54,
+	0x78, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x22, 0xb8,
+	0x03, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1f,
+	0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x07,
+	0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12,
+	0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x34, 0x0a, 0x16, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x5f, 0x6f, 0x66, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x14, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4f,
+	0x66, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d, 0x0a,
+	0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x12,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x5f, 0x65, 0x6e, 0x64, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x45, 0x6e, 0x64, 0x4f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x66, 0x69, 0x6c,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x6e, 0x64, 0x6f, 0x46, 0x69, 0x6c,
+	0x65, 0x12, 0x2a, 0x0a, 0x11, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x75, 0x6e,
+	0x64, 0x6f, 0x53, 0x74, 0x61, 0x72, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x26, 0x0a,
+	0x0f, 0x75, 0x6e, 0x64, 0x6f, 0x5f, 0x65, 0x6e, 0x64, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x75, 0x6e, 0x64, 0x6f, 0x45, 0x6e, 0x64, 0x4f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x77,
+	0x6f, 0x72, 0x6b, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x57, 0x6f, 0x72, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x0a, 0x09,
+	0x75, 0x74, 0x78, 0x6f, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x08, 0x75, 0x74, 0x78, 0x6f, 0x48, 0x61, 0x73, 0x68, 0x22, 0x5f, 0x0a, 0x08, 0x43, 0x68, 0x61,
+	0x69, 0x6e, 0x54, 0x69, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x5f,
+	0x77, 0x6f, 0x72, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x63, 0x75, 0x6d, 0x75,
+	0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x57, 0x6f, 0x72, 0x6b, 0x22, 0x90, 0x01, 0x0a, 0x0a, 0x43,
+	0x6f, 0x69, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x6e,
+	0x64, 0x65, 0x78, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0d, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x6d, 0x6f,
+	0x75, 0x6e, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x5f,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0e, 0x6c,
+	0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x22, 0xaf, 0x01,
+	0x0a, 0x09, 0x55, 0x6e, 0x64, 0x6f, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x38, 0x0a, 0x18, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x70, 0x75, 0x74,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x16, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x48,
+	0x61, 0x73, 0x68, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0d, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x61,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e,
+	0x67, 0x5f, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x0e, 0x6c, 0x6f, 0x63, 0x6b, 0x69, 0x6e, 0x67, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x73, 0x22,
+	0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0xa0, 0x01, 0x0a, 0x0e, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x79, 0x6f,
+	0x75, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x59, 0x6f, 0x75,
+	0x12, 0x17, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x61, 0x64, 0x64, 0x72, 0x4d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x65, 0x73,
+	0x74, 0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a,
+	0x62, 0x65, 0x73, 0x74, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x22, 0x51, 0x0a, 0x10, 0x47,
+	0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x24, 0x0a, 0x0e, 0x74, 0x6f, 0x70, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x74, 0x6f, 0x70, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x17, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x64, 0x64, 0x72, 0x4d, 0x65, 0x22, 0x36,
+	0x0a, 0x11, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73,
+	0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x46, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61,
+	0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x17, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61, 0x64, 0x64, 0x72, 0x4d, 0x65, 0x22, 0x37,
+	0x0a, 0x12, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x21, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x52, 0x07,
+	0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x22, 0x2f, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x61,
+	0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x22, 0x2f, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x44,
+	0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x05, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x6d, 0x0a, 0x10, 0x55, 0x74, 0x78,
+	0x6f, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x17, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x5f, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x61, 0x64, 0x64, 0x72, 0x4d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64,
+	0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x65,
+	0x6e, 0x64, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x3e, 0x0a, 0x0e, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x69,
+	0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x25, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70,
+	0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22,
+	0x31, 0x0a, 0x10, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61,
+	0x73, 0x68, 0x22, 0x6e, 0x0a, 0x0b, 0x43, 0x6f, 0x69, 0x6e, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x6f,
+	0x72, 0x12, 0x3c, 0x0a, 0x1a, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x5f, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x18, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x61, 0x73, 0x68, 0x12,
+	0x21, 0x0a, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x49, 0x6e, 0x64,
+	0x65, 0x78, 0x22, 0x5f, 0x0a, 0x09, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x43, 0x6f, 0x69, 0x6e, 0x12,
+	0x26, 0x0a, 0x07, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0c, 0x2e, 0x43, 0x6f, 0x69, 0x6e, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x07,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x2a, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x06, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x22, 0x55, 0x0a, 0x09, 0x55, 0x74, 0x78, 0x6f, 0x44, 0x65, 0x6c, 0x74, 0x61,
+	0x12, 0x22, 0x0a, 0x05, 0x73, 0x70, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x43, 0x6f, 0x69, 0x6e, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x05, 0x73,
+	0x70, 0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x43, 0x6f, 0x69,
+	0x6e, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x22, 0x3a, 0x0a, 0x07, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x64, 0x64, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x64, 0x64, 0x72, 0x12, 0x1b, 0x0a, 0x09, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x73, 0x65, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x61,
+	0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x22, 0x2b, 0x0a, 0x09, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x05, 0x61, 0x64, 0x64, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x08, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x05, 0x61, 0x64,
+	0x64, 0x72, 0x73, 0x22, 0x9a, 0x01, 0x0a, 0x08, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70,
+	0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x68, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x27, 0x0a, 0x0f,
+	0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x77, 0x6f, 0x72, 0x6b, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x69, 0x76,
+	0x65, 0x57, 0x6f, 0x72, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x21, 0x0a,
+	0x0c, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x22, 0x55, 0x0a, 0x0b, 0x42, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68,
+	0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x63,
+	0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x63,
+	0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x22, 0x7a, 0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x54, 0x72, 0x65, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x68, 0x61, 0x73,
+	0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x62, 0x65, 0x73, 0x74, 0x48, 0x61, 0x73,
+	0x68, 0x12, 0x1d, 0x0a, 0x04, 0x74, 0x69, 0x70, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70, 0x52, 0x04, 0x74, 0x69, 0x70, 0x73,
+	0x12, 0x31, 0x0a, 0x0d, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x42, 0x72, 0x61, 0x6e, 0x63, 0x68,
+	0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x0c, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x73, 0x22, 0x27, 0x0a, 0x11, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x4d, 0x0a, 0x12,
+	0x4c, 0x6f, 0x61, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x1b,
+	0x0a, 0x03, 0x74, 0x69, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70, 0x52, 0x03, 0x74, 0x69, 0x70, 0x22, 0xc7, 0x01, 0x0a, 0x0a,
+	0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x10,
+	0x0a, 0x03, 0x62, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x62, 0x69, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05,
+	0x73, 0x69, 0x6e, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x72, 0x0a, 0x0b, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x09, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x2d, 0x0a, 0x0b, 0x64, 0x65,
+	0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0b, 0x64, 0x65,
+	0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x90, 0x01, 0x0a, 0x0c, 0x53, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65,
+	0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x74, 0x78, 0x6f, 0x5f, 0x68,
+	0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x75, 0x74, 0x78, 0x6f, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x75,
+	0x6e, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x0b, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x3b, 0x0a, 0x0c,
+	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x09,
+	0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09,
+	0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x22, 0x44, 0x0a, 0x14, 0x53, 0x6e, 0x61,
+	0x70, 0x73, 0x68, 0x6f, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x22,
+	0x31, 0x0a, 0x0d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b,
+	0x12, 0x20, 0x0a, 0x05, 0x63, 0x6f, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0a, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x43, 0x6f, 0x69, 0x6e, 0x52, 0x05, 0x63, 0x6f, 0x69,
+	0x6e, 0x73, 0x22, 0x6a, 0x0a, 0x0c, 0x52, 0x65, 0x66, 0x75, 0x73, 0x65, 0x64, 0x52, 0x65, 0x6f,
+	0x72, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x69, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x74, 0x69, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x61, 0x6e, 0x63, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x70, 0x74,
+	0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x64, 0x65, 0x70, 0x74, 0x68, 0x22, 0x36,
+	0x0a, 0x0d, 0x52, 0x65, 0x66, 0x75, 0x73, 0x65, 0x64, 0x52, 0x65, 0x6f, 0x72, 0x67, 0x73, 0x12,
+	0x25, 0x0a, 0x06, 0x72, 0x65, 0x6f, 0x72, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x0d, 0x2e, 0x52, 0x65, 0x66, 0x75, 0x73, 0x65, 0x64, 0x52, 0x65, 0x6f, 0x72, 0x67, 0x52, 0x06,
+	0x72, 0x65, 0x6f, 0x72, 0x67, 0x73, 0x22, 0xa2, 0x01, 0x0a, 0x0a, 0x4e, 0x6f, 0x64, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x03, 0x74, 0x69, 0x70,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69,
+	0x70, 0x52, 0x03, 0x74, 0x69, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x12, 0x17, 0x0a, 0x07,
+	0x74, 0x69, 0x70, 0x5f, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x74,
+	0x69, 0x70, 0x41, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x22, 0x29, 0x0a, 0x09, 0x57,
 	0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x77, 0x69, 0x74, 0x6e,
 	0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x77, 0x69, 0x74,
 	0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x22, 0x21, 0x0a, 0x0d, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61,
@@ -1863,8 +4110,8 @@ var file_coin_proto_rawDesc = []byte{
 	0x14, 0x75, 0x6e, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61,
 	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72,
 	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x13, 0x75, 0x6e, 0x73, 0x69, 0x67,
-	0x6e, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xc9,
-	0x01, 0x0a, 0x12, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65,
+	0x6e, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x81,
+	0x02, 0x0a, 0x12, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65,
 	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12,
 	0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20,
@@ -1876,99 +4123,222 @@ var file_coin_proto_rawDesc = []byte{
 	0x12, 0x72, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
 	0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e,
 	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x11, 0x72, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xca, 0x01, 0x0a, 0x13, 0x4f,
-	0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65,
-	0x79, 0x12, 0x4a, 0x0a, 0x1a, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f, 0x66, 0x75, 0x6e, 0x64,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x36, 0x0a, 0x17, 0x75, 0x70,
+	0x66, 0x72, 0x6f, 0x6e, 0x74, 0x5f, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x5f, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x15, 0x75, 0x70, 0x66,
+	0x72, 0x6f, 0x6e, 0x74, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x53, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x22, 0x82, 0x02, 0x0a, 0x13, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e,
+	0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75,
+	0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x4a, 0x0a, 0x1a, 0x73, 0x69, 0x67,
+	0x6e, 0x65, 0x64, 0x5f, 0x66, 0x75, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x18, 0x73, 0x69, 0x67,
+	0x6e, 0x65, 0x64, 0x46, 0x75, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x48, 0x0a, 0x19, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f,
+	0x72, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73,
+	0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x17, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65,
+	0x66, 0x75, 0x6e, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x36, 0x0a, 0x17, 0x75, 0x70, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x5f, 0x73, 0x68, 0x75, 0x74, 0x64,
+	0x6f, 0x77, 0x6e, 0x5f, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x15, 0x75, 0x70, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77,
+	0x6e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x22, 0x6e, 0x0a, 0x13, 0x43, 0x6c, 0x6f, 0x73, 0x65,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x3d, 0x0a, 0x13, 0x63, 0x6c, 0x6f, 0x73,
 	0x69, 0x6e, 0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18,
 	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x52, 0x18, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x46, 0x75, 0x6e, 0x64, 0x69,
-	0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x48, 0x0a,
-	0x19, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x5f, 0x74,
-	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x17,
-	0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65, 0x66, 0x75, 0x6e, 0x64, 0x54, 0x72, 0x61, 0x6e,
-	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x5d, 0x0a, 0x0e, 0x50, 0x61, 0x79, 0x54, 0x6f,
-	0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72,
-	0x69, 0x70, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b,
-	0x2e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72,
-	0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69,
-	0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62,
-	0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x22, 0xf6, 0x01, 0x0a, 0x0a, 0x4d, 0x75, 0x6c, 0x74, 0x69,
-	0x50, 0x61, 0x72, 0x74, 0x79, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x5f,
-	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x63, 0x72,
-	0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54,
-	0x79, 0x70, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x79, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
-	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x79, 0x50, 0x75,
-	0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x10, 0x74, 0x68, 0x65, 0x69, 0x72,
-	0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0c, 0x48, 0x00, 0x52, 0x0e, 0x74, 0x68, 0x65, 0x69, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63,
-	0x4b, 0x65, 0x79, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d,
-	0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x2b, 0x0a,
-	0x11, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x62, 0x6c, 0x6f, 0x63,
-	0x6b, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69,
-	0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x42, 0x13, 0x0a, 0x11, 0x5f, 0x74,
-	0x68, 0x65, 0x69, 0x72, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x22,
-	0x8f, 0x02, 0x0a, 0x0e, 0x48, 0x61, 0x73, 0x68, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x4c, 0x6f,
-	0x63, 0x6b, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x5f, 0x74, 0x79, 0x70,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74,
-	0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x79, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65,
-	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x79, 0x50, 0x75, 0x62, 0x6c, 0x69,
-	0x63, 0x4b, 0x65, 0x79, 0x12, 0x28, 0x0a, 0x10, 0x74, 0x68, 0x65, 0x69, 0x72, 0x5f, 0x70, 0x75,
-	0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e,
-	0x74, 0x68, 0x65, 0x69, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x25,
-	0x0a, 0x0e, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69,
-	0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61, 0x73, 0x68, 0x5f, 0x6c, 0x6f,
-	0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x61, 0x73, 0x68, 0x4c, 0x6f,
-	0x63, 0x6b, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
-	0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x61,
-	0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12,
-	0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x66, 0x65,
-	0x65, 0x2a, 0x2b, 0x0a, 0x0a, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12,
-	0x08, 0x0a, 0x04, 0x50, 0x32, 0x50, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x4d, 0x55, 0x4c,
-	0x54, 0x49, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x54, 0x4c, 0x43, 0x10, 0x02, 0x32, 0xd6,
-	0x02, 0x0a, 0x04, 0x43, 0x6f, 0x69, 0x6e, 0x12, 0x35, 0x0a, 0x12, 0x46, 0x6f, 0x72, 0x77, 0x61,
-	0x72, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x2e,
-	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x74, 0x68, 0x41,
-	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x1e,
-	0x0a, 0x0c, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x06,
-	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x22,
-	0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0f, 0x2e, 0x56, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x12, 0x32, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12,
-	0x11, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74,
-	0x61, 0x12, 0x0f, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x10, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x53, 0x65, 0x6e, 0x64, 0x41, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x0a, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65,
-	0x73, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x47, 0x65, 0x74,
-	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74,
-	0x79, 0x1a, 0x0a, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x28, 0x0a,
-	0x0c, 0x47, 0x65, 0x74, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x0c, 0x2e,
-	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0a, 0x2e, 0x57, 0x69,
-	0x74, 0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x32, 0xf1, 0x01, 0x0a, 0x09, 0x4c, 0x69, 0x67, 0x68,
-	0x74, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x22, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
-	0x12, 0x0f, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x38, 0x0a, 0x0b, 0x4f, 0x70, 0x65,
-	0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x13, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x43,
-	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e,
-	0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
-	0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x17, 0x2e,
-	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x74, 0x68, 0x41,
-	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x14, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
-	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3d, 0x0a, 0x10,
-	0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79,
-	0x12, 0x19, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
-	0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x74, 0x68, 0x4b, 0x65, 0x79, 0x1a, 0x0e, 0x2e, 0x52, 0x65,
-	0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x42, 0x08, 0x5a, 0x06, 0x2e,
-	0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x69, 0x6f, 0x6e, 0x52, 0x12, 0x63, 0x6c, 0x6f, 0x73, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x62, 0x0a, 0x14, 0x43, 0x6c, 0x6f, 0x73, 0x65,
+	0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x4a, 0x0a, 0x1a, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x5f, 0x63, 0x6c, 0x6f, 0x73, 0x69, 0x6e,
+	0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x18, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x43, 0x6c, 0x6f, 0x73, 0x69, 0x6e, 0x67,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x63, 0x0a, 0x0c, 0x50,
+	0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x61, 0x73, 0x68,
+	0x22, 0x49, 0x0a, 0x0d, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x75, 0x66, 0x66, 0x69, 0x63, 0x69, 0x65, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x73, 0x75, 0x66, 0x66, 0x69, 0x63, 0x69, 0x65, 0x6e,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x22, 0xce, 0x01, 0x0a, 0x07,
+	0x49, 0x6e, 0x76, 0x6f, 0x69, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61, 0x79, 0x6d, 0x65,
+	0x6e, 0x74, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70,
+	0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72,
+	0x65, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72,
+	0x65, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x6d, 0x65, 0x6d, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x65,
+	0x6d, 0x6f, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x6e, 0x63, 0x6f, 0x6d, 0x69, 0x6e, 0x67, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x6e, 0x63, 0x6f, 0x6d, 0x69, 0x6e, 0x67, 0x12, 0x1d,
+	0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x73, 0x65, 0x74, 0x74, 0x6c, 0x65, 0x64, 0x41, 0x74, 0x22, 0x5d, 0x0a, 0x0e,
+	0x50, 0x61, 0x79, 0x54, 0x6f, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2c,
+	0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x22, 0xf6, 0x01, 0x0a, 0x0a,
+	0x4d, 0x75, 0x6c, 0x74, 0x69, 0x50, 0x61, 0x72, 0x74, 0x79, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x0b, 0x2e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x79, 0x5f, 0x70,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0b, 0x6d, 0x79, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x10,
+	0x74, 0x68, 0x65, 0x69, 0x72, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x48, 0x00, 0x52, 0x0e, 0x74, 0x68, 0x65, 0x69, 0x72, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x88, 0x01, 0x01, 0x12, 0x25, 0x0a, 0x0e, 0x72,
+	0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0d, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b,
+	0x65, 0x79, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
+	0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x61,
+	0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x42,
+	0x13, 0x0a, 0x11, 0x5f, 0x74, 0x68, 0x65, 0x69, 0x72, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x5f, 0x6b, 0x65, 0x79, 0x22, 0x8f, 0x02, 0x0a, 0x0e, 0x48, 0x61, 0x73, 0x68, 0x65, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x4c, 0x6f, 0x63, 0x6b, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x79, 0x5f, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x79,
+	0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x28, 0x0a, 0x10, 0x74, 0x68, 0x65,
+	0x69, 0x72, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0e, 0x74, 0x68, 0x65, 0x69, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x4b, 0x65, 0x79, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x72, 0x65, 0x76,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61,
+	0x73, 0x68, 0x5f, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68,
+	0x61, 0x73, 0x68, 0x4c, 0x6f, 0x63, 0x6b, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x64, 0x64, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x10, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x03, 0x66, 0x65, 0x65, 0x22, 0xd2, 0x01, 0x0a, 0x05, 0x56, 0x61, 0x75, 0x6c, 0x74,
+	0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x28,
+	0x0a, 0x10, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b,
+	0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0e, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2e, 0x0a, 0x13, 0x72, 0x65, 0x63, 0x6f,
+	0x76, 0x65, 0x72, 0x79, 0x5f, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x72, 0x65, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x50,
+	0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x65, 0x6c, 0x61,
+	0x79, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x75,
+	0x6e, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0a, 0x75, 0x6e, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x69, 0x6e, 0x67, 0x22, 0xdc, 0x01, 0x0a, 0x04,
+	0x53, 0x77, 0x61, 0x70, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x70, 0x75, 0x62,
+	0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x73,
+	0x65, 0x6e, 0x64, 0x65, 0x72, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x30,
+	0x0a, 0x14, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x12, 0x72, 0x65,
+	0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79,
+	0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x68, 0x61, 0x73, 0x68,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x70, 0x61, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x48,
+	0x61, 0x73, 0x68, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x46, 0x0a, 0x0a, 0x53, 0x77,
+	0x61, 0x70, 0x55, 0x6e, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x72, 0x65, 0x69, 0x6d, 0x61,
+	0x67, 0x65, 0x2a, 0x40, 0x0a, 0x0a, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x08, 0x0a, 0x04, 0x50, 0x32, 0x50, 0x4b, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x4d, 0x55,
+	0x4c, 0x54, 0x49, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x54, 0x4c, 0x43, 0x10, 0x02, 0x12,
+	0x09, 0x0a, 0x05, 0x56, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x03, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x57,
+	0x41, 0x50, 0x10, 0x04, 0x32, 0xb8, 0x07, 0x0a, 0x04, 0x43, 0x6f, 0x69, 0x6e, 0x12, 0x35, 0x0a,
+	0x12, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x17, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x57, 0x69, 0x74, 0x68, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x06, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x12, 0x1e, 0x0a, 0x0c, 0x46, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x1a, 0x06, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x12, 0x22, 0x0a, 0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x0f, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x32, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x11, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x35, 0x0a, 0x0a,
+	0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x2e, 0x47, 0x65, 0x74,
+	0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13,
+	0x2e, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x12, 0x0f,
+	0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x10, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x23, 0x0a, 0x0d, 0x53, 0x65, 0x6e, 0x64, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x12, 0x0a, 0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x1a, 0x06,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0a,
+	0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x0c, 0x47, 0x65,
+	0x74, 0x57, 0x69, 0x74, 0x6e, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x0c, 0x2e, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0a, 0x2e, 0x57, 0x69, 0x74, 0x6e, 0x65,
+	0x73, 0x73, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x54, 0x72, 0x65, 0x65, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0a, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x72, 0x65, 0x65, 0x12, 0x2d, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x55,
+	0x74, 0x78, 0x6f, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12, 0x11, 0x2e, 0x55, 0x74, 0x78, 0x6f, 0x44,
+	0x65, 0x6c, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0a, 0x2e, 0x55, 0x74,
+	0x78, 0x6f, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12, 0x33, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75,
+	0x72, 0x65, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x0f, 0x2e, 0x50, 0x72, 0x6f, 0x66,
+	0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x50, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x0f,
+	0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
+	0x11, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x09, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70, 0x12, 0x2f, 0x0a,
+	0x0f, 0x52, 0x65, 0x63, 0x6f, 0x6e, 0x73, 0x69, 0x64, 0x65, 0x72, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x12, 0x11, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70, 0x12, 0x26,
+	0x0a, 0x0e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0c, 0x2e, 0x44, 0x65, 0x70, 0x6c, 0x6f,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x35, 0x0a, 0x0a, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x6c,
+	0x6f, 0x63, 0x6b, 0x73, 0x12, 0x12, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a,
+	0x0c, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x73, 0x12, 0x06, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0d, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x4c, 0x69, 0x73, 0x74, 0x12, 0x39, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x15, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0e, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12,
+	0x24, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0b, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2a, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x66, 0x75,
+	0x73, 0x65, 0x64, 0x52, 0x65, 0x6f, 0x72, 0x67, 0x73, 0x12, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x1a, 0x0e, 0x2e, 0x52, 0x65, 0x66, 0x75, 0x73, 0x65, 0x64, 0x52, 0x65, 0x6f, 0x72, 0x67,
+	0x73, 0x12, 0x2c, 0x0a, 0x0c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x52, 0x65, 0x6f, 0x72,
+	0x67, 0x12, 0x11, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x09, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x54, 0x69, 0x70, 0x32,
+	0xe1, 0x02, 0x0a, 0x09, 0x4c, 0x69, 0x67, 0x68, 0x74, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x22, 0x0a,
+	0x07, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0f, 0x2e, 0x56, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x12, 0x38, 0x0a, 0x0b, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c,
+	0x12, 0x13, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x43, 0x68, 0x61, 0x6e,
+	0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x16, 0x47,
+	0x65, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x17, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x57, 0x69, 0x74, 0x68, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x14,
+	0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3d, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x76, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4b, 0x65, 0x79, 0x12, 0x19, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x65,
+	0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x69, 0x74, 0x68,
+	0x4b, 0x65, 0x79, 0x1a, 0x0e, 0x2e, 0x52, 0x65, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x4b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x43, 0x68, 0x61, 0x6e,
+	0x6e, 0x65, 0x6c, 0x12, 0x0d, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x3f, 0x0a, 0x10, 0x43, 0x6f, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x76,
+	0x65, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x14, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x43, 0x68,
+	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x43,
+	0x6c, 0x6f, 0x73, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1984,7 +4354,7 @@ func file_coin_proto_rawDescGZIP() []byte {
 }
 
 var file_coin_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_coin_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_coin_proto_msgTypes = make([]protoimpl.MessageInfo, 59)
 var file_coin_proto_goTypes = []interface{}{
 	(ScriptType)(0),                  // 0: ScriptType
 	(*Header)(nil),                   // 1: Header
@@ -1992,27 +4362,60 @@ var file_coin_proto_goTypes = []interface{}{
 	(*TransactionOutput)(nil),        // 3: TransactionOutput
 	(*Transaction)(nil),              // 4: Transaction
 	(*Block)(nil),                    // 5: Block
-	(*BlockRecord)(nil),              // 6: BlockRecord
-	(*CoinRecord)(nil),               // 7: CoinRecord
-	(*UndoBlock)(nil),                // 8: UndoBlock
-	(*Empty)(nil),                    // 9: Empty
-	(*VersionRequest)(nil),           // 10: VersionRequest
-	(*GetBlocksRequest)(nil),         // 11: GetBlocksRequest
-	(*GetBlocksResponse)(nil),        // 12: GetBlocksResponse
-	(*GetDataRequest)(nil),           // 13: GetDataRequest
-	(*GetDataResponse)(nil),          // 14: GetDataResponse
-	(*Address)(nil),                  // 15: Address
-	(*Addresses)(nil),                // 16: Addresses
-	(*Witnesses)(nil),                // 17: Witnesses
-	(*RevocationKey)(nil),            // 18: RevocationKey
-	(*SignedTransactionWithKey)(nil), // 19: SignedTransactionWithKey
-	(*TransactionWithAddress)(nil),   // 20: TransactionWithAddress
-	(*UpdatedTransactions)(nil),      // 21: UpdatedTransactions
-	(*OpenChannelRequest)(nil),       // 22: OpenChannelRequest
-	(*OpenChannelResponse)(nil),      // 23: OpenChannelResponse
-	(*PayToPublicKey)(nil),           // 24: PayToPublicKey
-	(*MultiParty)(nil),               // 25: MultiParty
-	(*HashedTimeLock)(nil),           // 26: HashedTimeLock
+	(*TxLocation)(nil),               // 6: TxLocation
+	(*BlockRecord)(nil),              // 7: BlockRecord
+	(*ChainTip)(nil),                 // 8: ChainTip
+	(*CoinRecord)(nil),               // 9: CoinRecord
+	(*UndoBlock)(nil),                // 10: UndoBlock
+	(*Empty)(nil),                    // 11: Empty
+	(*VersionRequest)(nil),           // 12: VersionRequest
+	(*GetBlocksRequest)(nil),         // 13: GetBlocksRequest
+	(*GetBlocksResponse)(nil),        // 14: GetBlocksResponse
+	(*GetHeadersRequest)(nil),        // 15: GetHeadersRequest
+	(*GetHeadersResponse)(nil),       // 16: GetHeadersResponse
+	(*GetDataRequest)(nil),           // 17: GetDataRequest
+	(*GetDataResponse)(nil),          // 18: GetDataResponse
+	(*UtxoDeltaRequest)(nil),         // 19: UtxoDeltaRequest
+	(*ProfileRequest)(nil),           // 20: ProfileRequest
+	(*ProfileResponse)(nil),          // 21: ProfileResponse
+	(*BlockHashRequest)(nil),         // 22: BlockHashRequest
+	(*CoinLocator)(nil),              // 23: CoinLocator
+	(*DeltaCoin)(nil),                // 24: DeltaCoin
+	(*UtxoDelta)(nil),                // 25: UtxoDelta
+	(*Address)(nil),                  // 26: Address
+	(*Addresses)(nil),                // 27: Addresses
+	(*BlockTip)(nil),                 // 28: BlockTip
+	(*BranchPoint)(nil),              // 29: BranchPoint
+	(*BlockTree)(nil),                // 30: BlockTree
+	(*LoadBlocksRequest)(nil),        // 31: LoadBlocksRequest
+	(*LoadBlocksResponse)(nil),       // 32: LoadBlocksResponse
+	(*Deployment)(nil),               // 33: Deployment
+	(*Deployments)(nil),              // 34: Deployments
+	(*SnapshotInfo)(nil),             // 35: SnapshotInfo
+	(*SnapshotList)(nil),             // 36: SnapshotList
+	(*SnapshotChunkRequest)(nil),     // 37: SnapshotChunkRequest
+	(*SnapshotChunk)(nil),            // 38: SnapshotChunk
+	(*RefusedReorg)(nil),             // 39: RefusedReorg
+	(*RefusedReorgs)(nil),            // 40: RefusedReorgs
+	(*NodeStatus)(nil),               // 41: NodeStatus
+	(*Witnesses)(nil),                // 42: Witnesses
+	(*RevocationKey)(nil),            // 43: RevocationKey
+	(*SignedTransactionWithKey)(nil), // 44: SignedTransactionWithKey
+	(*TransactionWithAddress)(nil),   // 45: TransactionWithAddress
+	(*UpdatedTransactions)(nil),      // 46: UpdatedTransactions
+	(*OpenChannelRequest)(nil),       // 47: OpenChannelRequest
+	(*OpenChannelResponse)(nil),      // 48: OpenChannelResponse
+	(*CloseChannelRequest)(nil),      // 49: CloseChannelRequest
+	(*CloseChannelResponse)(nil),     // 50: CloseChannelResponse
+	(*ProbeRequest)(nil),             // 51: ProbeRequest
+	(*ProbeResponse)(nil),            // 52: ProbeResponse
+	(*Invoice)(nil),                  // 53: Invoice
+	(*PayToPublicKey)(nil),           // 54: PayToPublicKey
+	(*MultiParty)(nil),               // 55: MultiParty
+	(*HashedTimeLock)(nil),           // 56: HashedTimeLock
+	(*Vault)(nil),                    // 57: Vault
+	(*Swap)(nil),                     // 58: Swap
+	(*SwapUnlock)(nil),               // 59: SwapUnlock
 }
 var file_coin_proto_depIdxs = []int32{
 	2,  // 0: Transaction.inputs:type_name -> TransactionInput
@@ -2020,48 +4423,95 @@ var file_coin_proto_depIdxs = []int32{
 	1,  // 2: Block.header:type_name -> Header
 	4,  // 3: Block.transactions:type_name -> Transaction
 	1,  // 4: BlockRecord.header:type_name -> Header
-	5,  // 5: GetDataResponse.block:type_name -> Block
-	15, // 6: Addresses.addrs:type_name -> Address
-	4,  // 7: SignedTransactionWithKey.signed_transaction:type_name -> Transaction
-	4,  // 8: TransactionWithAddress.transaction:type_name -> Transaction
-	4,  // 9: UpdatedTransactions.signed_transaction:type_name -> Transaction
-	4,  // 10: UpdatedTransactions.unsigned_transaction:type_name -> Transaction
-	4,  // 11: OpenChannelRequest.funding_transaction:type_name -> Transaction
-	4,  // 12: OpenChannelRequest.refund_transaction:type_name -> Transaction
-	4,  // 13: OpenChannelResponse.signed_funding_transaction:type_name -> Transaction
-	4,  // 14: OpenChannelResponse.signed_refund_transaction:type_name -> Transaction
-	0,  // 15: PayToPublicKey.script_type:type_name -> ScriptType
-	0,  // 16: MultiParty.script_type:type_name -> ScriptType
-	0,  // 17: HashedTimeLock.script_type:type_name -> ScriptType
-	20, // 18: Coin.ForwardTransaction:input_type -> TransactionWithAddress
-	5,  // 19: Coin.ForwardBlock:input_type -> Block
-	10, // 20: Coin.Version:input_type -> VersionRequest
-	11, // 21: Coin.GetBlocks:input_type -> GetBlocksRequest
-	13, // 22: Coin.GetData:input_type -> GetDataRequest
-	16, // 23: Coin.SendAddresses:input_type -> Addresses
-	9,  // 24: Coin.GetAddresses:input_type -> Empty
-	4,  // 25: Coin.GetWitnesses:input_type -> Transaction
-	10, // 26: Lightning.Version:input_type -> VersionRequest
-	22, // 27: Lightning.OpenChannel:input_type -> OpenChannelRequest
-	20, // 28: Lightning.GetUpdatedTransactions:input_type -> TransactionWithAddress
-	19, // 29: Lightning.GetRevocationKey:input_type -> SignedTransactionWithKey
-	9,  // 30: Coin.ForwardTransaction:output_type -> Empty
-	9,  // 31: Coin.ForwardBlock:output_type -> Empty
-	9,  // 32: Coin.Version:output_type -> Empty
-	12, // 33: Coin.GetBlocks:output_type -> GetBlocksResponse
-	14, // 34: Coin.GetData:output_type -> GetDataResponse
-	9,  // 35: Coin.SendAddresses:output_type -> Empty
-	16, // 36: Coin.GetAddresses:output_type -> Addresses
-	17, // 37: Coin.GetWitnesses:output_type -> Witnesses
-	9,  // 38: Lightning.Version:output_type -> Empty
-	23, // 39: Lightning.OpenChannel:output_type -> OpenChannelResponse
-	21, // 40: Lightning.GetUpdatedTransactions:output_type -> UpdatedTransactions
-	18, // 41: Lightning.GetRevocationKey:output_type -> RevocationKey
-	30, // [30:42] is the sub-list for method output_type
-	18, // [18:30] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	1,  // 5: GetHeadersResponse.headers:type_name -> Header
+	5,  // 6: GetDataResponse.block:type_name -> Block
+	23, // 7: DeltaCoin.locator:type_name -> CoinLocator
+	3,  // 8: DeltaCoin.output:type_name -> TransactionOutput
+	23, // 9: UtxoDelta.spent:type_name -> CoinLocator
+	24, // 10: UtxoDelta.created:type_name -> DeltaCoin
+	26, // 11: Addresses.addrs:type_name -> Address
+	28, // 12: BlockTree.tips:type_name -> BlockTip
+	29, // 13: BlockTree.branch_points:type_name -> BranchPoint
+	28, // 14: LoadBlocksResponse.tip:type_name -> BlockTip
+	33, // 15: Deployments.deployments:type_name -> Deployment
+	35, // 16: SnapshotList.snapshots:type_name -> SnapshotInfo
+	24, // 17: SnapshotChunk.coins:type_name -> DeltaCoin
+	39, // 18: RefusedReorgs.reorgs:type_name -> RefusedReorg
+	28, // 19: NodeStatus.tip:type_name -> BlockTip
+	4,  // 20: SignedTransactionWithKey.signed_transaction:type_name -> Transaction
+	4,  // 21: TransactionWithAddress.transaction:type_name -> Transaction
+	4,  // 22: UpdatedTransactions.signed_transaction:type_name -> Transaction
+	4,  // 23: UpdatedTransactions.unsigned_transaction:type_name -> Transaction
+	4,  // 24: OpenChannelRequest.funding_transaction:type_name -> Transaction
+	4,  // 25: OpenChannelRequest.refund_transaction:type_name -> Transaction
+	4,  // 26: OpenChannelResponse.signed_funding_transaction:type_name -> Transaction
+	4,  // 27: OpenChannelResponse.signed_refund_transaction:type_name -> Transaction
+	4,  // 28: CloseChannelRequest.closing_transaction:type_name -> Transaction
+	4,  // 29: CloseChannelResponse.signed_closing_transaction:type_name -> Transaction
+	0,  // 30: PayToPublicKey.script_type:type_name -> ScriptType
+	0,  // 31: MultiParty.script_type:type_name -> ScriptType
+	0,  // 32: HashedTimeLock.script_type:type_name -> ScriptType
+	0,  // 33: Vault.script_type:type_name -> ScriptType
+	0,  // 34: Swap.script_type:type_name -> ScriptType
+	45, // 35: Coin.ForwardTransaction:input_type -> TransactionWithAddress
+	5,  // 36: Coin.ForwardBlock:input_type -> Block
+	12, // 37: Coin.Version:input_type -> VersionRequest
+	13, // 38: Coin.GetBlocks:input_type -> GetBlocksRequest
+	15, // 39: Coin.GetHeaders:input_type -> GetHeadersRequest
+	17, // 40: Coin.GetData:input_type -> GetDataRequest
+	27, // 41: Coin.SendAddresses:input_type -> Addresses
+	11, // 42: Coin.GetAddresses:input_type -> Empty
+	4,  // 43: Coin.GetWitnesses:input_type -> Transaction
+	11, // 44: Coin.GetBlockTree:input_type -> Empty
+	19, // 45: Coin.GetUtxoDelta:input_type -> UtxoDeltaRequest
+	20, // 46: Coin.CaptureProfile:input_type -> ProfileRequest
+	22, // 47: Coin.InvalidateBlock:input_type -> BlockHashRequest
+	22, // 48: Coin.ReconsiderBlock:input_type -> BlockHashRequest
+	11, // 49: Coin.GetDeployments:input_type -> Empty
+	31, // 50: Coin.LoadBlocks:input_type -> LoadBlocksRequest
+	11, // 51: Coin.GetSnapshots:input_type -> Empty
+	37, // 52: Coin.GetSnapshotChunk:input_type -> SnapshotChunkRequest
+	11, // 53: Coin.GetNodeStatus:input_type -> Empty
+	11, // 54: Coin.GetRefusedReorgs:input_type -> Empty
+	22, // 55: Coin.ConfirmReorg:input_type -> BlockHashRequest
+	12, // 56: Lightning.Version:input_type -> VersionRequest
+	47, // 57: Lightning.OpenChannel:input_type -> OpenChannelRequest
+	45, // 58: Lightning.GetUpdatedTransactions:input_type -> TransactionWithAddress
+	44, // 59: Lightning.GetRevocationKey:input_type -> SignedTransactionWithKey
+	51, // 60: Lightning.ProbeChannel:input_type -> ProbeRequest
+	49, // 61: Lightning.CooperativeClose:input_type -> CloseChannelRequest
+	11, // 62: Coin.ForwardTransaction:output_type -> Empty
+	11, // 63: Coin.ForwardBlock:output_type -> Empty
+	11, // 64: Coin.Version:output_type -> Empty
+	14, // 65: Coin.GetBlocks:output_type -> GetBlocksResponse
+	16, // 66: Coin.GetHeaders:output_type -> GetHeadersResponse
+	18, // 67: Coin.GetData:output_type -> GetDataResponse
+	11, // 68: Coin.SendAddresses:output_type -> Empty
+	27, // 69: Coin.GetAddresses:output_type -> Addresses
+	42, // 70: Coin.GetWitnesses:output_type -> Witnesses
+	30, // 71: Coin.GetBlockTree:output_type -> BlockTree
+	25, // 72: Coin.GetUtxoDelta:output_type -> UtxoDelta
+	21, // 73: Coin.CaptureProfile:output_type -> ProfileResponse
+	28, // 74: Coin.InvalidateBlock:output_type -> BlockTip
+	28, // 75: Coin.ReconsiderBlock:output_type -> BlockTip
+	34, // 76: Coin.GetDeployments:output_type -> Deployments
+	32, // 77: Coin.LoadBlocks:output_type -> LoadBlocksResponse
+	36, // 78: Coin.GetSnapshots:output_type -> SnapshotList
+	38, // 79: Coin.GetSnapshotChunk:output_type -> SnapshotChunk
+	41, // 80: Coin.GetNodeStatus:output_type -> NodeStatus
+	40, // 81: Coin.GetRefusedReorgs:output_type -> RefusedReorgs
+	28, // 82: Coin.ConfirmReorg:output_type -> BlockTip
+	11, // 83: Lightning.Version:output_type -> Empty
+	48, // 84: Lightning.OpenChannel:output_type -> OpenChannelResponse
+	46, // 85: Lightning.GetUpdatedTransactions:output_type -> UpdatedTransactions
+	43, // 86: Lightning.GetRevocationKey:output_type -> RevocationKey
+	52, // 87: Lightning.ProbeChannel:output_type -> ProbeResponse
+	50, // 88: Lightning.CooperativeClose:output_type -> CloseChannelResponse
+	62, // [62:89] is the sub-list for method output_type
+	35, // [35:62] is the sub-list for method input_type
+	35, // [35:35] is the sub-list for extension type_name
+	35, // [35:35] is the sub-list for extension extendee
+	0,  // [0:35] is the sub-list for field type_name
 }
 
 func init() { file_coin_proto_init() }
@@ -2082,8 +4532,164 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TransactionInput); i {
+		file_coin_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransactionInput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TransactionOutput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Transaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TxLocation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChainTip); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CoinRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UndoBlock); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Empty); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VersionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlocksRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlocksResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetHeadersRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2094,8 +4700,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TransactionOutput); i {
+		file_coin_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetHeadersResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2106,8 +4712,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Transaction); i {
+		file_coin_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDataRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2118,8 +4724,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Block); i {
+		file_coin_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDataResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2130,8 +4736,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BlockRecord); i {
+		file_coin_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UtxoDeltaRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2142,8 +4748,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CoinRecord); i {
+		file_coin_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProfileRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2154,8 +4760,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UndoBlock); i {
+		file_coin_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProfileResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2166,8 +4772,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Empty); i {
+		file_coin_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockHashRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2178,8 +4784,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VersionRequest); i {
+		file_coin_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CoinLocator); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2190,8 +4796,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetBlocksRequest); i {
+		file_coin_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeltaCoin); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2202,8 +4808,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetBlocksResponse); i {
+		file_coin_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UtxoDelta); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2214,8 +4820,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetDataRequest); i {
+		file_coin_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Address); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2226,8 +4832,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetDataResponse); i {
+		file_coin_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Addresses); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2238,8 +4844,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Address); i {
+		file_coin_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockTip); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2250,8 +4856,8 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Addresses); i {
+		file_coin_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BranchPoint); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2262,7 +4868,151 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockTree); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadBlocksRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LoadBlocksResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Deployment); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Deployments); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotChunkRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RefusedReorg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RefusedReorgs); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Witnesses); i {
 			case 0:
 				return &v.state
@@ -2274,7 +5024,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RevocationKey); i {
 			case 0:
 				return &v.state
@@ -2286,7 +5036,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SignedTransactionWithKey); i {
 			case 0:
 				return &v.state
@@ -2298,7 +5048,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TransactionWithAddress); i {
 			case 0:
 				return &v.state
@@ -2310,7 +5060,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UpdatedTransactions); i {
 			case 0:
 				return &v.state
@@ -2322,7 +5072,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*OpenChannelRequest); i {
 			case 0:
 				return &v.state
@@ -2334,7 +5084,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*OpenChannelResponse); i {
 			case 0:
 				return &v.state
@@ -2346,7 +5096,67 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CloseChannelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CloseChannelResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProbeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProbeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Invoice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*PayToPublicKey); i {
 			case 0:
 				return &v.state
@@ -2358,7 +5168,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MultiParty); i {
 			case 0:
 				return &v.state
@@ -2370,7 +5180,7 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
-		file_coin_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+		file_coin_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*HashedTimeLock); i {
 			case 0:
 				return &v.state
@@ -2382,15 +5192,51 @@ func file_coin_proto_init() {
 				return nil
 			}
 		}
+		file_coin_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Vault); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Swap); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_coin_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SwapUnlock); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_coin_proto_msgTypes[24].OneofWrappers = []interface{}{}
+	file_coin_proto_msgTypes[54].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_coin_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   26,
+			NumMessages:   59,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -28,6 +28,8 @@ type CoinClient interface {
 	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*Empty, error)
 	// Gets maximum 500 blocks past block with top hash
 	GetBlocks(ctx context.Context, in *GetBlocksRequest, opts ...grpc.CallOption) (*GetBlocksResponse, error)
+	// Gets maximum 2000 headers past the first block of a locator on the main chain
+	GetHeaders(ctx context.Context, in *GetHeadersRequest, opts ...grpc.CallOption) (*GetHeadersResponse, error)
 	// Get a single block
 	GetData(ctx context.Context, in *GetDataRequest, opts ...grpc.CallOption) (*GetDataResponse, error)
 	// Sends know addresses to neighbors, forwarded from node to node
@@ -36,6 +38,30 @@ type CoinClient interface {
 	GetAddresses(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Addresses, error)
 	// Segwit protocol; added for Lightning
 	GetWitnesses(ctx context.Context, in *Transaction, opts ...grpc.CallOption) (*Witnesses, error)
+	// Gets every known tip and branch point, for rendering forks
+	GetBlockTree(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BlockTree, error)
+	// Gets the change to the unspent coins between two heights, for trusted peers
+	GetUtxoDelta(ctx context.Context, in *UtxoDeltaRequest, opts ...grpc.CallOption) (*UtxoDelta, error)
+	// Admin: captures a cpu, heap, or goroutine profile to the data directory
+	CaptureProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*ProfileResponse, error)
+	// Admin: marks a block and its descendants invalid, switching the active chain away from them
+	InvalidateBlock(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error)
+	// Admin: clears the invalid marking from a block, its ancestors, and its descendants
+	ReconsiderBlock(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error)
+	// Gets where each soft fork deployment stands for the next block
+	GetDeployments(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Deployments, error)
+	// Admin: loads the blocks in an archive or another node's block files, as if they came from peers
+	LoadBlocks(ctx context.Context, in *LoadBlocksRequest, opts ...grpc.CallOption) (*LoadBlocksResponse, error)
+	// Gets the snapshots of the unspent coins the node serves, taken at checkpoint heights
+	GetSnapshots(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SnapshotList, error)
+	// Gets a single chunk of a snapshot of the unspent coins
+	GetSnapshotChunk(ctx context.Context, in *SnapshotChunkRequest, opts ...grpc.CallOption) (*SnapshotChunk, error)
+	// Gets the node's address, version, tip, peers, and whether its tip has gone stale
+	GetNodeStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatus, error)
+	// Gets the heavier branches the node refused to reorg to, for reorging deeper than its limit
+	GetRefusedReorgs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RefusedReorgs, error)
+	// Admin: confirms a refused reorg, switching the active chain to the branch ending at the block
+	ConfirmReorg(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error)
 }
 
 type coinClient struct {
@@ -82,6 +108,15 @@ func (c *coinClient) GetBlocks(ctx context.Context, in *GetBlocksRequest, opts .
 	return out, nil
 }
 
+func (c *coinClient) GetHeaders(ctx context.Context, in *GetHeadersRequest, opts ...grpc.CallOption) (*GetHeadersResponse, error) {
+	out := new(GetHeadersResponse)
+	err := c.cc.Invoke(ctx, "/Coin/GetHeaders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *coinClient) GetData(ctx context.Context, in *GetDataRequest, opts ...grpc.CallOption) (*GetDataResponse, error) {
 	out := new(GetDataResponse)
 	err := c.cc.Invoke(ctx, "/Coin/GetData", in, out, opts...)
@@ -118,6 +153,114 @@ func (c *coinClient) GetWitnesses(ctx context.Context, in *Transaction, opts ...
 	return out, nil
 }
 
+func (c *coinClient) GetBlockTree(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BlockTree, error) {
+	out := new(BlockTree)
+	err := c.cc.Invoke(ctx, "/Coin/GetBlockTree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetUtxoDelta(ctx context.Context, in *UtxoDeltaRequest, opts ...grpc.CallOption) (*UtxoDelta, error) {
+	out := new(UtxoDelta)
+	err := c.cc.Invoke(ctx, "/Coin/GetUtxoDelta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) CaptureProfile(ctx context.Context, in *ProfileRequest, opts ...grpc.CallOption) (*ProfileResponse, error) {
+	out := new(ProfileResponse)
+	err := c.cc.Invoke(ctx, "/Coin/CaptureProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) InvalidateBlock(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error) {
+	out := new(BlockTip)
+	err := c.cc.Invoke(ctx, "/Coin/InvalidateBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) ReconsiderBlock(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error) {
+	out := new(BlockTip)
+	err := c.cc.Invoke(ctx, "/Coin/ReconsiderBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetDeployments(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Deployments, error) {
+	out := new(Deployments)
+	err := c.cc.Invoke(ctx, "/Coin/GetDeployments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) LoadBlocks(ctx context.Context, in *LoadBlocksRequest, opts ...grpc.CallOption) (*LoadBlocksResponse, error) {
+	out := new(LoadBlocksResponse)
+	err := c.cc.Invoke(ctx, "/Coin/LoadBlocks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetSnapshots(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SnapshotList, error) {
+	out := new(SnapshotList)
+	err := c.cc.Invoke(ctx, "/Coin/GetSnapshots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetSnapshotChunk(ctx context.Context, in *SnapshotChunkRequest, opts ...grpc.CallOption) (*SnapshotChunk, error) {
+	out := new(SnapshotChunk)
+	err := c.cc.Invoke(ctx, "/Coin/GetSnapshotChunk", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetNodeStatus(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeStatus, error) {
+	out := new(NodeStatus)
+	err := c.cc.Invoke(ctx, "/Coin/GetNodeStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) GetRefusedReorgs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*RefusedReorgs, error) {
+	out := new(RefusedReorgs)
+	err := c.cc.Invoke(ctx, "/Coin/GetRefusedReorgs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coinClient) ConfirmReorg(ctx context.Context, in *BlockHashRequest, opts ...grpc.CallOption) (*BlockTip, error) {
+	out := new(BlockTip)
+	err := c.cc.Invoke(ctx, "/Coin/ConfirmReorg", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CoinServer is the server API for Coin service.
 // All implementations must embed UnimplementedCoinServer
 // for forward compatibility
@@ -128,6 +271,8 @@ type CoinServer interface {
 	Version(context.Context, *VersionRequest) (*Empty, error)
 	// Gets maximum 500 blocks past block with top hash
 	GetBlocks(context.Context, *GetBlocksRequest) (*GetBlocksResponse, error)
+	// Gets maximum 2000 headers past the first block of a locator on the main chain
+	GetHeaders(context.Context, *GetHeadersRequest) (*GetHeadersResponse, error)
 	// Get a single block
 	GetData(context.Context, *GetDataRequest) (*GetDataResponse, error)
 	// Sends know addresses to neighbors, forwarded from node to node
@@ -136,6 +281,30 @@ type CoinServer interface {
 	GetAddresses(context.Context, *Empty) (*Addresses, error)
 	// Segwit protocol; added for Lightning
 	GetWitnesses(context.Context, *Transaction) (*Witnesses, error)
+	// Gets every known tip and branch point, for rendering forks
+	GetBlockTree(context.Context, *Empty) (*BlockTree, error)
+	// Gets the change to the unspent coins between two heights, for trusted peers
+	GetUtxoDelta(context.Context, *UtxoDeltaRequest) (*UtxoDelta, error)
+	// Admin: captures a cpu, heap, or goroutine profile to the data directory
+	CaptureProfile(context.Context, *ProfileRequest) (*ProfileResponse, error)
+	// Admin: marks a block and its descendants invalid, switching the active chain away from them
+	InvalidateBlock(context.Context, *BlockHashRequest) (*BlockTip, error)
+	// Admin: clears the invalid marking from a block, its ancestors, and its descendants
+	ReconsiderBlock(context.Context, *BlockHashRequest) (*BlockTip, error)
+	// Gets where each soft fork deployment stands for the next block
+	GetDeployments(context.Context, *Empty) (*Deployments, error)
+	// Admin: loads the blocks in an archive or another node's block files, as if they came from peers
+	LoadBlocks(context.Context, *LoadBlocksRequest) (*LoadBlocksResponse, error)
+	// Gets the snapshots of the unspent coins the node serves, taken at checkpoint heights
+	GetSnapshots(context.Context, *Empty) (*SnapshotList, error)
+	// Gets a single chunk of a snapshot of the unspent coins
+	GetSnapshotChunk(context.Context, *SnapshotChunkRequest) (*SnapshotChunk, error)
+	// Gets the node's address, version, tip, peers, and whether its tip has gone stale
+	GetNodeStatus(context.Context, *Empty) (*NodeStatus, error)
+	// Gets the heavier branches the node refused to reorg to, for reorging deeper than its limit
+	GetRefusedReorgs(context.Context, *Empty) (*RefusedReorgs, error)
+	// Admin: confirms a refused reorg, switching the active chain to the branch ending at the block
+	ConfirmReorg(context.Context, *BlockHashRequest) (*BlockTip, error)
 	mustEmbedUnimplementedCoinServer()
 }
 
@@ -155,6 +324,9 @@ func (UnimplementedCoinServer) Version(context.Context, *VersionRequest) (*Empty
 func (UnimplementedCoinServer) GetBlocks(context.Context, *GetBlocksRequest) (*GetBlocksResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBlocks not implemented")
 }
+func (UnimplementedCoinServer) GetHeaders(context.Context, *GetHeadersRequest) (*GetHeadersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHeaders not implemented")
+}
 func (UnimplementedCoinServer) GetData(context.Context, *GetDataRequest) (*GetDataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetData not implemented")
 }
@@ -167,6 +339,42 @@ func (UnimplementedCoinServer) GetAddresses(context.Context, *Empty) (*Addresses
 func (UnimplementedCoinServer) GetWitnesses(context.Context, *Transaction) (*Witnesses, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetWitnesses not implemented")
 }
+func (UnimplementedCoinServer) GetBlockTree(context.Context, *Empty) (*BlockTree, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockTree not implemented")
+}
+func (UnimplementedCoinServer) GetUtxoDelta(context.Context, *UtxoDeltaRequest) (*UtxoDelta, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUtxoDelta not implemented")
+}
+func (UnimplementedCoinServer) CaptureProfile(context.Context, *ProfileRequest) (*ProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CaptureProfile not implemented")
+}
+func (UnimplementedCoinServer) InvalidateBlock(context.Context, *BlockHashRequest) (*BlockTip, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InvalidateBlock not implemented")
+}
+func (UnimplementedCoinServer) ReconsiderBlock(context.Context, *BlockHashRequest) (*BlockTip, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconsiderBlock not implemented")
+}
+func (UnimplementedCoinServer) GetDeployments(context.Context, *Empty) (*Deployments, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeployments not implemented")
+}
+func (UnimplementedCoinServer) LoadBlocks(context.Context, *LoadBlocksRequest) (*LoadBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadBlocks not implemented")
+}
+func (UnimplementedCoinServer) GetSnapshots(context.Context, *Empty) (*SnapshotList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshots not implemented")
+}
+func (UnimplementedCoinServer) GetSnapshotChunk(context.Context, *SnapshotChunkRequest) (*SnapshotChunk, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshotChunk not implemented")
+}
+func (UnimplementedCoinServer) GetNodeStatus(context.Context, *Empty) (*NodeStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeStatus not implemented")
+}
+func (UnimplementedCoinServer) GetRefusedReorgs(context.Context, *Empty) (*RefusedReorgs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRefusedReorgs not implemented")
+}
+func (UnimplementedCoinServer) ConfirmReorg(context.Context, *BlockHashRequest) (*BlockTip, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmReorg not implemented")
+}
 func (UnimplementedCoinServer) mustEmbedUnimplementedCoinServer() {}
 
 // UnsafeCoinServer may be embedded to opt out of forward compatibility for this service.
@@ -252,6 +460,24 @@ func _Coin_GetBlocks_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Coin_GetHeaders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHeadersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetHeaders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetHeaders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetHeaders(ctx, req.(*GetHeadersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Coin_GetData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetDataRequest)
 	if err := dec(in); err != nil {
@@ -324,6 +550,222 @@ func _Coin_GetWitnesses_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Coin_GetBlockTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetBlockTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetBlockTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetBlockTree(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetUtxoDelta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UtxoDeltaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetUtxoDelta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetUtxoDelta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetUtxoDelta(ctx, req.(*UtxoDeltaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_CaptureProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).CaptureProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/CaptureProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).CaptureProfile(ctx, req.(*ProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_InvalidateBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).InvalidateBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/InvalidateBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).InvalidateBlock(ctx, req.(*BlockHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_ReconsiderBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).ReconsiderBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/ReconsiderBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).ReconsiderBlock(ctx, req.(*BlockHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetDeployments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetDeployments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetDeployments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetDeployments(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_LoadBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).LoadBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/LoadBlocks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).LoadBlocks(ctx, req.(*LoadBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetSnapshots(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetSnapshotChunk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotChunkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetSnapshotChunk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetSnapshotChunk",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetSnapshotChunk(ctx, req.(*SnapshotChunkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetNodeStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetNodeStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetNodeStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetNodeStatus(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_GetRefusedReorgs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).GetRefusedReorgs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/GetRefusedReorgs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).GetRefusedReorgs(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coin_ConfirmReorg_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoinServer).ConfirmReorg(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Coin/ConfirmReorg",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoinServer).ConfirmReorg(ctx, req.(*BlockHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Coin_ServiceDesc is the grpc.ServiceDesc for Coin service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -347,6 +789,10 @@ var Coin_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBlocks",
 			Handler:    _Coin_GetBlocks_Handler,
 		},
+		{
+			MethodName: "GetHeaders",
+			Handler:    _Coin_GetHeaders_Handler,
+		},
 		{
 			MethodName: "GetData",
 			Handler:    _Coin_GetData_Handler,
@@ -363,6 +809,54 @@ var Coin_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetWitnesses",
 			Handler:    _Coin_GetWitnesses_Handler,
 		},
+		{
+			MethodName: "GetBlockTree",
+			Handler:    _Coin_GetBlockTree_Handler,
+		},
+		{
+			MethodName: "GetUtxoDelta",
+			Handler:    _Coin_GetUtxoDelta_Handler,
+		},
+		{
+			MethodName: "CaptureProfile",
+			Handler:    _Coin_CaptureProfile_Handler,
+		},
+		{
+			MethodName: "InvalidateBlock",
+			Handler:    _Coin_InvalidateBlock_Handler,
+		},
+		{
+			MethodName: "ReconsiderBlock",
+			Handler:    _Coin_ReconsiderBlock_Handler,
+		},
+		{
+			MethodName: "GetDeployments",
+			Handler:    _Coin_GetDeployments_Handler,
+		},
+		{
+			MethodName: "LoadBlocks",
+			Handler:    _Coin_LoadBlocks_Handler,
+		},
+		{
+			MethodName: "GetSnapshots",
+			Handler:    _Coin_GetSnapshots_Handler,
+		},
+		{
+			MethodName: "GetSnapshotChunk",
+			Handler:    _Coin_GetSnapshotChunk_Handler,
+		},
+		{
+			MethodName: "GetNodeStatus",
+			Handler:    _Coin_GetNodeStatus_Handler,
+		},
+		{
+			MethodName: "GetRefusedReorgs",
+			Handler:    _Coin_GetRefusedReorgs_Handler,
+		},
+		{
+			MethodName: "ConfirmReorg",
+			Handler:    _Coin_ConfirmReorg_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "coin.proto",
@@ -380,6 +874,10 @@ type LightningClient interface {
 	GetUpdatedTransactions(ctx context.Context, in *TransactionWithAddress, opts ...grpc.CallOption) (*UpdatedTransactions, error)
 	// Once everyone has state n + 1, you can safely revoke state n
 	GetRevocationKey(ctx context.Context, in *SignedTransactionWithKey, opts ...grpc.CallOption) (*RevocationKey, error)
+	// Offer an HTLC that can never be fulfilled, to learn if a route can carry an amount
+	ProbeChannel(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeResponse, error)
+	// Agree on a closing transaction that pays each side to its shutdown script
+	CooperativeClose(ctx context.Context, in *CloseChannelRequest, opts ...grpc.CallOption) (*CloseChannelResponse, error)
 }
 
 type lightningClient struct {
@@ -426,6 +924,24 @@ func (c *lightningClient) GetRevocationKey(ctx context.Context, in *SignedTransa
 	return out, nil
 }
 
+func (c *lightningClient) ProbeChannel(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeResponse, error) {
+	out := new(ProbeResponse)
+	err := c.cc.Invoke(ctx, "/Lightning/ProbeChannel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) CooperativeClose(ctx context.Context, in *CloseChannelRequest, opts ...grpc.CallOption) (*CloseChannelResponse, error) {
+	out := new(CloseChannelResponse)
+	err := c.cc.Invoke(ctx, "/Lightning/CooperativeClose", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LightningServer is the server API for Lightning service.
 // All implementations must embed UnimplementedLightningServer
 // for forward compatibility
@@ -438,6 +954,10 @@ type LightningServer interface {
 	GetUpdatedTransactions(context.Context, *TransactionWithAddress) (*UpdatedTransactions, error)
 	// Once everyone has state n + 1, you can safely revoke state n
 	GetRevocationKey(context.Context, *SignedTransactionWithKey) (*RevocationKey, error)
+	// Offer an HTLC that can never be fulfilled, to learn if a route can carry an amount
+	ProbeChannel(context.Context, *ProbeRequest) (*ProbeResponse, error)
+	// Agree on a closing transaction that pays each side to its shutdown script
+	CooperativeClose(context.Context, *CloseChannelRequest) (*CloseChannelResponse, error)
 	mustEmbedUnimplementedLightningServer()
 }
 
@@ -457,6 +977,12 @@ func (UnimplementedLightningServer) GetUpdatedTransactions(context.Context, *Tra
 func (UnimplementedLightningServer) GetRevocationKey(context.Context, *SignedTransactionWithKey) (*RevocationKey, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRevocationKey not implemented")
 }
+func (UnimplementedLightningServer) ProbeChannel(context.Context, *ProbeRequest) (*ProbeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeChannel not implemented")
+}
+func (UnimplementedLightningServer) CooperativeClose(context.Context, *CloseChannelRequest) (*CloseChannelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CooperativeClose not implemented")
+}
 func (UnimplementedLightningServer) mustEmbedUnimplementedLightningServer() {}
 
 // UnsafeLightningServer may be embedded to opt out of forward compatibility for this service.
@@ -542,6 +1068,42 @@ func _Lightning_GetRevocationKey_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Lightning_ProbeChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ProbeChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Lightning/ProbeChannel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ProbeChannel(ctx, req.(*ProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_CooperativeClose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).CooperativeClose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Lightning/CooperativeClose",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).CooperativeClose(ctx, req.(*CloseChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Lightning_ServiceDesc is the grpc.ServiceDesc for Lightning service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -565,6 +1127,14 @@ var Lightning_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRevocationKey",
 			Handler:    _Lightning_GetRevocationKey_Handler,
 		},
+		{
+			MethodName: "ProbeChannel",
+			Handler:    _Lightning_ProbeChannel_Handler,
+		},
+		{
+			MethodName: "CooperativeClose",
+			Handler:    _Lightning_CooperativeClose_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "coin.proto",
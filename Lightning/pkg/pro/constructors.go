@@ -12,6 +12,7 @@ func SizeOfTransaction(t *Transaction) uint32 {
 		sz += uint32(unsafe.Sizeof(txi.ReferenceTransactionHash))
 		sz += uint32(unsafe.Sizeof(txi.UnlockingScript))
 		sz += uint32(unsafe.Sizeof(txi.OutputIndex))
+		sz += uint32(unsafe.Sizeof(txi.Sequence))
 	}
 	for _, txo := range t.Outputs {
 		sz += uint32(unsafe.Sizeof(txo.Amount))
@@ -0,0 +1,24 @@
+package profiling
+
+import "time"
+
+// Config is the Profiler's configuration options.
+// Port is the port the pprof endpoints are served on, on
+// localhost only. If it is 0, the endpoints are not served.
+// Directory is where captured profiles are written, relative
+// to the node's data directory.
+// MaxDuration is the longest a CPU profile can be captured for.
+type Config struct {
+	Port        int
+	Directory   string
+	MaxDuration time.Duration
+}
+
+// DefaultConfig returns the Profiler's default Config.
+func DefaultConfig() *Config {
+	return &Config{
+		Port:        0,
+		Directory:   "profiles",
+		MaxDuration: time.Minute,
+	}
+}
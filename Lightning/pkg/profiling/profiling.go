@@ -0,0 +1,127 @@
+package profiling
+
+import (
+	"Coin/pkg/utils"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"sync"
+	"time"
+)
+
+// The kinds of profile that can be captured.
+const (
+	CPU       = "cpu"
+	Heap      = "heap"
+	Goroutine = "goroutine"
+)
+
+// Profiler serves the pprof endpoints and captures profiles
+// to disk on demand.
+// directory is where captured profiles are written,
+// server serves the pprof endpoints, if they are enabled,
+// mutex makes sure only one profile is captured at a time.
+//
+// All methods are safe to call on a nil Profiler, which
+// serves nothing and refuses to capture.
+type Profiler struct {
+	config    *Config
+	directory string
+	server    *http.Server
+	mutex     sync.Mutex
+}
+
+// New returns a Profiler given a Config, writing profiles
+// under dataDirectory.
+func New(config *Config, dataDirectory string) *Profiler {
+	return &Profiler{
+		config:    config,
+		directory: filepath.Join(dataDirectory, config.Directory),
+	}
+}
+
+// Start serves the pprof endpoints on localhost, if the
+// Config has a Port.
+func (p *Profiler) Start() error {
+	if p == nil || p.config.Port == 0 {
+		return nil
+	}
+	lis, err := net.Listen("tcp4", fmt.Sprintf("localhost:%v", p.config.Port))
+	if err != nil {
+		return fmt.Errorf("[profiling.Start] unable to listen on port %v: %v", p.config.Port, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	p.server = &http.Server{Handler: mux}
+	go func() {
+		if err := p.server.Serve(lis); err != nil && err != http.ErrServerClosed {
+			utils.Debug.Printf("[profiling.Start] pprof server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops serving the pprof endpoints.
+func (p *Profiler) Stop() {
+	if p == nil || p.server == nil {
+		return
+	}
+	if err := p.server.Close(); err != nil {
+		utils.Debug.Printf("[profiling.Stop] %v", err)
+	}
+}
+
+// Capture writes a profile of the given kind to the Profiler's
+// directory and returns the file's path. A CPU profile samples
+// for duration, capped at the Config's MaxDuration. Heap and
+// goroutine profiles are snapshots, so they ignore duration.
+func (p *Profiler) Capture(kind string, duration time.Duration) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("[profiling.Capture] profiling is disabled")
+	}
+	if kind != CPU && kind != Heap && kind != Goroutine {
+		return "", fmt.Errorf("[profiling.Capture] unknown profile kind %q", kind)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := os.MkdirAll(p.directory, 0755); err != nil {
+		return "", fmt.Errorf("[profiling.Capture] unable to create %v: %v", p.directory, err)
+	}
+	path := filepath.Join(p.directory, fmt.Sprintf("%v-%v.pprof", kind, time.Now().Format("20060102-150405.000")))
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("[profiling.Capture] unable to create %v: %v", path, err)
+	}
+	defer f.Close()
+	switch kind {
+	case CPU:
+		if duration > p.config.MaxDuration {
+			duration = p.config.MaxDuration
+		}
+		if err = runtimepprof.StartCPUProfile(f); err != nil {
+			break
+		}
+		time.Sleep(duration)
+		runtimepprof.StopCPUProfile()
+	case Heap:
+		// collect garbage first, so the profile shows live memory
+		runtime.GC()
+		err = runtimepprof.Lookup(Heap).WriteTo(f, 0)
+	case Goroutine:
+		err = runtimepprof.Lookup(Goroutine).WriteTo(f, 0)
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("[profiling.Capture] unable to capture %v profile: %v", kind, err)
+	}
+	return path, nil
+}
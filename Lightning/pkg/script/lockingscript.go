@@ -15,6 +15,12 @@ const MULTI = 1
 // HTLC represents a HashedTimeLock script
 const HTLC = 2
 
+// VAULT represents a Vault script
+const VAULT = 3
+
+// SWAP represents a Swap script
+const SWAP = 4
+
 // PayToPublicKey is the standard locking script, when we want to pay one person
 type PayToPublicKey struct {
 	ScriptType int
@@ -44,6 +50,33 @@ type HashedTimeLock struct {
 	Fee              uint32
 }
 
+// Vault is a locking script that the RecoveryPublicKey can spend at any
+// time, but that the OwnerPublicKey can only spend slowly: first into an
+// Unvaulting Vault with the same keys, and then, DelayBlocks after that,
+// anywhere. This gives the recovery key holder time to cancel a withdrawal
+// made with a stolen owner key. Nodes enforce these rules on every
+// Transaction and Block that spends a Vault (see CheckVaultSpend).
+type Vault struct {
+	ScriptType        int
+	OwnerPublicKey    []byte
+	RecoveryPublicKey []byte
+	DelayBlocks       uint32
+	Unvaulting        bool
+}
+
+// Swap is a hash time locked script for swapping on-chain coins for a
+// lightning payment. The RecipientPublicKey can spend it by revealing
+// the preimage of PaymentHash, which settles the lightning payment. If
+// it doesn't within TimeoutBlocks, the SenderPublicKey can take the
+// coins back.
+type Swap struct {
+	ScriptType         int
+	SenderPublicKey    []byte
+	RecipientPublicKey []byte
+	PaymentHash        []byte
+	TimeoutBlocks      uint32
+}
+
 func EncodeMultiParty(multi *MultiParty) *pro.MultiParty {
 	return &pro.MultiParty{
 		ScriptType:       pro.ScriptType_MULTI,
@@ -73,6 +106,26 @@ func EncodeHashedTimeLock(htlc *HashedTimeLock) *pro.HashedTimeLock {
 	}
 }
 
+func EncodeVault(v *Vault) *pro.Vault {
+	return &pro.Vault{
+		ScriptType:        pro.ScriptType_VAULT,
+		OwnerPublicKey:    v.OwnerPublicKey,
+		RecoveryPublicKey: v.RecoveryPublicKey,
+		DelayBlocks:       v.DelayBlocks,
+		Unvaulting:        v.Unvaulting,
+	}
+}
+
+func EncodeSwap(s *Swap) *pro.Swap {
+	return &pro.Swap{
+		ScriptType:         pro.ScriptType_SWAP,
+		SenderPublicKey:    s.SenderPublicKey,
+		RecipientPublicKey: s.RecipientPublicKey,
+		PaymentHash:        s.PaymentHash,
+		TimeoutBlocks:      s.TimeoutBlocks,
+	}
+}
+
 func DecodePayToPublicKey(p2pk *pro.PayToPublicKey) *PayToPublicKey {
 	return &PayToPublicKey{PublicKey: p2pk.GetPublicKey()}
 }
@@ -97,6 +150,26 @@ func DecodeHashedTimeLock(htlc *pro.HashedTimeLock) *HashedTimeLock {
 	}
 }
 
+func DecodeVault(v *pro.Vault) *Vault {
+	return &Vault{
+		ScriptType:        VAULT,
+		OwnerPublicKey:    v.GetOwnerPublicKey(),
+		RecoveryPublicKey: v.GetRecoveryPublicKey(),
+		DelayBlocks:       v.GetDelayBlocks(),
+		Unvaulting:        v.GetUnvaulting(),
+	}
+}
+
+func DecodeSwap(s *pro.Swap) *Swap {
+	return &Swap{
+		ScriptType:         SWAP,
+		SenderPublicKey:    s.GetSenderPublicKey(),
+		RecipientPublicKey: s.GetRecipientPublicKey(),
+		PaymentHash:        s.GetPaymentHash(),
+		TimeoutBlocks:      s.GetTimeoutBlocks(),
+	}
+}
+
 func DetermineScriptType(b []byte) (int, error) {
 	// since proto will unmarshal anything, we unmarshal
 	// as a pay to public key and then we check the script type
@@ -112,7 +185,29 @@ func DetermineScriptType(b []byte) (int, error) {
 		return MULTI, nil
 	case pro.ScriptType_HTLC:
 		return HTLC, nil
+	case pro.ScriptType_VAULT:
+		return VAULT, nil
+	case pro.ScriptType_SWAP:
+		return SWAP, nil
 	default:
 		return -1, fmt.Errorf("unable to unmarshal script")
 	}
 }
+
+// SigOps returns how many signatures must be checked to spend coins
+// locked by script b. The MultiParty scripts, and the HashedTimeLock
+// scripts built on them, need both parties to sign; every other script
+// needs one signature, including scripts that can't be decoded, which
+// are counted like a PayToPublicKey.
+func SigOps(b []byte) uint32 {
+	t, err := DetermineScriptType(b)
+	if err != nil {
+		return 1
+	}
+	switch t {
+	case MULTI, HTLC:
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,74 @@
+package script
+
+import (
+	"Coin/pkg/pro"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// ParseSwap returns the Swap in a locking script, or an
+// error if the locking script is not a Swap.
+func ParseSwap(lockingScript []byte) (*Swap, error) {
+	if t, err := DetermineScriptType(lockingScript); err != nil || t != SWAP {
+		return nil, fmt.Errorf("[script.ParseSwap] locking script is not a swap")
+	}
+	s := &pro.Swap{}
+	if err := proto.Unmarshal(lockingScript, s); err != nil {
+		return nil, fmt.Errorf("[script.ParseSwap] unable to unmarshal swap: %v", err)
+	}
+	return DecodeSwap(s), nil
+}
+
+// MakeSwapUnlock returns the unlocking script that spends a Swap with
+// signature, revealing preimage. The sender refunds a Swap with no
+// preimage.
+func MakeSwapUnlock(signature []byte, preimage []byte) ([]byte, error) {
+	return proto.Marshal(&pro.SwapUnlock{Signature: signature, Preimage: preimage})
+}
+
+// SwapPreimage returns the preimage revealed by an unlocking script
+// that spends s, or nil if it doesn't reveal s's preimage.
+func SwapPreimage(s *Swap, unlockingScript []byte) []byte {
+	unlock := &pro.SwapUnlock{}
+	if err := proto.Unmarshal(unlockingScript, unlock); err != nil {
+		return nil
+	}
+	if !SettlesSwap(s, unlock.GetPreimage()) {
+		return nil
+	}
+	return unlock.GetPreimage()
+}
+
+// SettlesSwap returns whether preimage is the preimage of s's
+// PaymentHash.
+func SettlesSwap(s *Swap, preimage []byte) bool {
+	hash := sha256.Sum256(preimage)
+	return len(preimage) > 0 && bytes.Equal(hash[:], s.PaymentHash)
+}
+
+// CheckSwapSpend returns an error if the holder of signer may not spend
+// an output locked by s, revealing preimage, given how many blocks the
+// output has been confirmed for.
+//
+// The rules are:
+// (1) the recipient can spend the output by revealing the preimage of
+// its PaymentHash.
+// (2) the sender can spend the output once it has been confirmed for
+// TimeoutBlocks.
+func CheckSwapSpend(s *Swap, signer []byte, preimage []byte, confirmations uint32) error {
+	switch {
+	case bytes.Equal(signer, s.RecipientPublicKey):
+		if !SettlesSwap(s, preimage) {
+			return fmt.Errorf("[script.CheckSwapSpend] preimage doesn't match the payment hash")
+		}
+		return nil
+	case bytes.Equal(signer, s.SenderPublicKey):
+		if confirmations < s.TimeoutBlocks {
+			return fmt.Errorf("[script.CheckSwapSpend] refund needs %v more blocks", s.TimeoutBlocks-confirmations)
+		}
+		return nil
+	}
+	return fmt.Errorf("[script.CheckSwapSpend] signer is neither the sender nor the recipient")
+}
@@ -0,0 +1,58 @@
+package script
+
+import (
+	"Coin/pkg/pro"
+	"bytes"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// ParseVault returns the Vault in a locking script, or an
+// error if the locking script is not a Vault.
+func ParseVault(lockingScript []byte) (*Vault, error) {
+	if t, err := DetermineScriptType(lockingScript); err != nil || t != VAULT {
+		return nil, fmt.Errorf("[script.ParseVault] locking script is not a vault")
+	}
+	v := &pro.Vault{}
+	if err := proto.Unmarshal(lockingScript, v); err != nil {
+		return nil, fmt.Errorf("[script.ParseVault] unable to unmarshal vault: %v", err)
+	}
+	return DecodeVault(v), nil
+}
+
+// CheckVaultSpend returns an error if the holder of signer may not spend
+// an output locked by v into outputScripts, given how many blocks the
+// output has been confirmed for.
+//
+// The rules are:
+// (1) the recovery key can spend the output anywhere, at any time.
+// (2) the owner key can spend a Vault that is not Unvaulting, but only
+// into a single Unvaulting Vault with the same keys and delay.
+// (3) the owner key can spend an Unvaulting Vault anywhere, once it has
+// been confirmed for DelayBlocks.
+func CheckVaultSpend(v *Vault, signer []byte, confirmations uint32, outputScripts [][]byte) error {
+	switch {
+	case bytes.Equal(signer, v.RecoveryPublicKey):
+		return nil
+	case !bytes.Equal(signer, v.OwnerPublicKey):
+		return fmt.Errorf("[script.CheckVaultSpend] signer is neither the owner nor the recovery key")
+	case v.Unvaulting:
+		if confirmations < v.DelayBlocks {
+			return fmt.Errorf("[script.CheckVaultSpend] withdrawal needs %v more blocks", v.DelayBlocks-confirmations)
+		}
+		return nil
+	}
+	if len(outputScripts) != 1 {
+		return fmt.Errorf("[script.CheckVaultSpend] vault must be spent into exactly one output")
+	}
+	next, err := ParseVault(outputScripts[0])
+	if err != nil {
+		return err
+	}
+	if !next.Unvaulting || next.DelayBlocks != v.DelayBlocks ||
+		!bytes.Equal(next.OwnerPublicKey, v.OwnerPublicKey) ||
+		!bytes.Equal(next.RecoveryPublicKey, v.RecoveryPublicKey) {
+		return fmt.Errorf("[script.CheckVaultSpend] vault must be spent into an unvaulting copy of itself")
+	}
+	return nil
+}
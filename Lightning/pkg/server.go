@@ -3,12 +3,16 @@ package pkg
 import (
 	"Coin/pkg/address"
 	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/coindatabase"
 	"Coin/pkg/peer"
 	"Coin/pkg/pro"
 	"Coin/pkg/utils"
 	"errors"
 	"fmt"
 	"golang.org/x/net/context"
+	grpcpeer "google.golang.org/grpc/peer"
+	"net"
 	"time"
 )
 
@@ -42,16 +46,15 @@ func (n *Node) Version(ctx context.Context, in *pro.VersionRequest) (*pro.Empty,
 		return &pro.Empty{}, nil
 	}
 	newPeer := peer.New(n.AddressDB.Get(newAddr.Addr), in.Version, in.BestHeight)
+	// either side can ask to only exchange blocks
+	newPeer.Addr.BlocksOnly = in.BlocksOnly || n.wantsBlocksOnly(newAddr.Addr)
 	// Check if we are waiting for a ver in response to a ver, do not respond if this is a confirmation of peering
 	pendingVer := newPeer.Addr.SentVer != time.Time{} && newPeer.Addr.SentVer.Add(n.Config.VersionTimeout).After(time.Now())
 	if n.PeerDb.Add(newPeer) && !pendingVer {
 		newPeer.Addr.SentVer = time.Now()
-		_, err := newAddr.VersionRPC(&pro.VersionRequest{
-			Version:    uint32(n.Config.Version),
-			AddrYou:    in.AddrYou,
-			AddrMe:     n.Address,
-			BestHeight: n.BlockChain.Length,
-		})
+		request := n.makeVersionRequest(in.AddrYou)
+		request.BlocksOnly = newPeer.Addr.BlocksOnly
+		_, err := newAddr.VersionRPC(request)
 		if err != nil {
 			return &pro.Empty{}, err
 		}
@@ -62,25 +65,40 @@ func (n *Node) Version(ctx context.Context, in *pro.VersionRequest) (*pro.Empty,
 // GetBlocks Handles get blocks request (request for blocks past a certain block)
 func (n *Node) GetBlocks(ctx context.Context, in *pro.GetBlocksRequest) (*pro.GetBlocksResponse, error) {
 	blockHashes := make([]string, 0)
-	br := n.BlockChain.BlockInfoDB.GetBlockRecord(in.TopBlockHash)
-	if br == nil {
+	info, err := n.BlockChain.GetBlockInfo(in.TopBlockHash)
+	if err != nil {
 		return &pro.GetBlocksResponse{}, fmt.Errorf("[GetBlocks] did not have block")
 	}
-	if ind := br.Height; ind < n.BlockChain.Length {
+	if ind := info.Height; ind < n.BlockChain.Length {
 		upperIndex := n.BlockChain.Length
 		// Can send a maximum of 50 0 headers
 		if ind+500 < upperIndex {
 			upperIndex = ind + 500
 		}
-		for _, bn := range n.BlockChain.GetBlocks(ind+1, upperIndex) {
-			blockHashes = append(blockHashes, bn.Hash())
+		blockHashes = n.BlockChain.GetHashes(ind+1, upperIndex)
+		// a pruned node can't send the blocks it no longer has
+		if len(blockHashes) > 0 && n.BlockChain.IsPruned(blockHashes[0]) {
+			return &pro.GetBlocksResponse{}, fmt.Errorf("[GetBlocks] blocks above height %v were pruned", ind)
 		}
 	}
 	return &pro.GetBlocksResponse{BlockHashes: blockHashes}, nil
 }
 
+// GetHeaders Handles get headers request (request for the headers past
+// the first block of a locator that is on the main chain)
+func (n *Node) GetHeaders(ctx context.Context, in *pro.GetHeadersRequest) (*pro.GetHeadersResponse, error) {
+	var headers []*pro.Header
+	for _, header := range n.BlockChain.BlockInfoDB.GetHeaders(in.Locator, "") {
+		headers = append(headers, block.EncodeHeader(header))
+	}
+	return &pro.GetHeadersResponse{Headers: headers}, nil
+}
+
 // GetData Handles get data request (request for a specific block identified by its hash)
 func (n *Node) GetData(ctx context.Context, in *pro.GetDataRequest) (*pro.GetDataResponse, error) {
+	if n.BlockChain.IsPruned(in.BlockHash) {
+		return &pro.GetDataResponse{}, fmt.Errorf("[GetData] block {%v} was pruned", in.BlockHash)
+	}
 	blk := n.BlockChain.GetBlock(in.BlockHash)
 	if blk == nil {
 		utils.Debug.Printf("Node {%v} received a data req from the network for a block {%v} that could not be found locally.\n",
@@ -90,6 +108,172 @@ func (n *Node) GetData(ctx context.Context, in *pro.GetDataRequest) (*pro.GetDat
 	return &pro.GetDataResponse{Block: block.EncodeBlock(blk)}, nil
 }
 
+// GetUtxoDelta Handles a trusted peer's request for the change to
+// the unspent coins between two heights. Anyone can claim to be a
+// trusted peer, so the request must also come from its host
+func (n *Node) GetUtxoDelta(ctx context.Context, in *pro.UtxoDeltaRequest) (*pro.UtxoDelta, error) {
+	if !n.Config.TrustedPeers[in.AddrMe] || !sentFromHost(ctx, in.AddrMe) {
+		return nil, fmt.Errorf("[GetUtxoDelta] %v is not a trusted peer", in.AddrMe)
+	}
+	delta, err := n.BlockChain.GetUTXODelta(in.StartHeight, in.EndHeight)
+	if err != nil {
+		return nil, err
+	}
+	return coindatabase.EncodeUTXODelta(delta), nil
+}
+
+// sentFromHost returns whether the rpc with ctx came over a
+// connection from the host of addr, which is the only part of a
+// peer's address its connections share.
+func sentFromHost(ctx context.Context, addr string) bool {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return false
+	}
+	from, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == from {
+		return true
+	}
+	ips, err := net.LookupHost(host)
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip == from {
+			return true
+		}
+	}
+	return false
+}
+
+// CaptureProfile Handles an admin's request to capture a profile
+// of the running node to its data directory, sampling for no longer
+// than the ProfilingConfig's MaxDuration. It is only served on the
+// AdminPort
+func (n *Node) CaptureProfile(ctx context.Context, in *pro.ProfileRequest) (*pro.ProfileResponse, error) {
+	duration := time.Duration(in.Seconds) * time.Second
+	if conf := n.Config.ProfilingConfig; conf != nil && duration > conf.MaxDuration {
+		duration = conf.MaxDuration
+	}
+	path, err := n.Profiler.Capture(in.Kind, duration)
+	if err != nil {
+		return nil, err
+	}
+	return &pro.ProfileResponse{Path: path}, nil
+}
+
+// InvalidateBlock Handles an admin's request to mark a block and its
+// descendants invalid, returning the tip the active chain ends up at.
+// It is only served on the AdminPort
+func (n *Node) InvalidateBlock(ctx context.Context, in *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.BlockChain.InvalidateBlock(in.BlockHash); err != nil {
+		return nil, err
+	}
+	return n.activeTip(), nil
+}
+
+// ReconsiderBlock Handles an admin's request to clear the invalid
+// marking from a block, returning the tip the active chain ends up at.
+// It is only served on the AdminPort
+func (n *Node) ReconsiderBlock(ctx context.Context, in *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.BlockChain.ReconsiderBlock(in.BlockHash); err != nil {
+		return nil, err
+	}
+	return n.activeTip(), nil
+}
+
+// GetDeployments Handles a request for where each soft fork
+// deployment stands for the next block
+func (n *Node) GetDeployments(ctx context.Context, in *pro.Empty) (*pro.Deployments, error) {
+	period, threshold := n.BlockChain.DeploymentPeriod()
+	return blockchain.EncodeDeploymentStatuses(n.BlockChain.DeploymentStatuses(), period, threshold), nil
+}
+
+// LoadBlocks Handles an admin's request to load the blocks in an
+// archive or another node's block files on the node's disk. It is
+// only served on the AdminPort
+func (n *Node) LoadBlocks(ctx context.Context, in *pro.LoadBlocksRequest) (*pro.LoadBlocksResponse, error) {
+	accepted, err := n.LoadBlockFiles(in.Path)
+	if err != nil {
+		return nil, err
+	}
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return &pro.LoadBlocksResponse{Accepted: uint32(accepted), Tip: n.activeTip()}, nil
+}
+
+// GetSnapshots Handles a request for the snapshots of the unspent
+// coins the node serves
+func (n *Node) GetSnapshots(ctx context.Context, in *pro.Empty) (*pro.SnapshotList, error) {
+	list := &pro.SnapshotList{}
+	for _, s := range n.BlockChain.Snapshots() {
+		list.Snapshots = append(list.Snapshots, blockchain.EncodeSnapshot(s))
+	}
+	return list, nil
+}
+
+// GetSnapshotChunk Handles a request for a single chunk of a snapshot
+// of the unspent coins
+func (n *Node) GetSnapshotChunk(ctx context.Context, in *pro.SnapshotChunkRequest) (*pro.SnapshotChunk, error) {
+	return n.BlockChain.SnapshotChunk(in.Height, in.Index)
+}
+
+// GetNodeStatus Handles a request for the node's address, version,
+// tip, peers, and how long it has been since the tip changed
+func (n *Node) GetNodeStatus(ctx context.Context, in *pro.Empty) (*pro.NodeStatus, error) {
+	age, stale := n.TipAge()
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return &pro.NodeStatus{
+		Address: n.Address,
+		Version: uint32(n.Config.Version),
+		Tip:     n.activeTip(),
+		Peers:   uint32(len(n.PeerDb.List())),
+		TipAge:  uint64(age / time.Second),
+		Stale:   stale,
+	}, nil
+}
+
+// GetRefusedReorgs Handles a request for the heavier branches the
+// node refused to reorg to, for going deeper than its MaxReorgDepth
+func (n *Node) GetRefusedReorgs(ctx context.Context, in *pro.Empty) (*pro.RefusedReorgs, error) {
+	return blockchain.EncodeRefusedReorgs(n.BlockChain.RefusedReorgs()), nil
+}
+
+// ConfirmReorg Handles an admin's confirmation of a refused reorg,
+// returning the tip the active chain ends up at. It is only served
+// on the AdminPort
+func (n *Node) ConfirmReorg(ctx context.Context, in *pro.BlockHashRequest) (*pro.BlockTip, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if err := n.BlockChain.ConfirmReorg(in.BlockHash); err != nil {
+		return nil, err
+	}
+	return n.activeTip(), nil
+}
+
+// activeTip returns the tip of the active chain. The caller must hold
+// the node's mutex, since blocks are handled under it.
+func (n *Node) activeTip() *pro.BlockTip {
+	return &pro.BlockTip{
+		Hash:           n.BlockChain.LastHash,
+		Height:         n.BlockChain.Length,
+		CumulativeWork: n.BlockChain.CumulativeWork.String(),
+		Active:         true,
+	}
+}
+
 // SendAddresses Handles send addresses request (request for nodes to peer with the requesting node)
 func (n *Node) SendAddresses(ctx context.Context, in *pro.Addresses) (*pro.Empty, error) {
 	// Forward nodes to all neighbors if new nodes were found (without redundancy)
@@ -124,12 +308,7 @@ func (n *Node) SendAddresses(ctx context.Context, in *pro.Addresses) (*pro.Empty
 		}
 		// Try to connect to each new address as true peers (it is okay if this is repeated, this may be a reboot)
 		go func() {
-			_, err := newAddr.VersionRPC(&pro.VersionRequest{
-				Version:    uint32(n.Config.Version),
-				AddrYou:    newAddr.Addr,
-				AddrMe:     n.Address,
-				BestHeight: n.BlockChain.Length,
-			})
+			_, err := newAddr.VersionRPC(n.makeVersionRequest(newAddr.Addr))
 			if err != nil {
 				utils.Debug.Printf("%v recieved no response from VersionRPC to %v",
 					utils.FmtAddr(n.Address), utils.FmtAddr(addr.Addr))
@@ -160,15 +339,26 @@ func (n *Node) GetAddresses(ctx context.Context, in *pro.Empty) (*pro.Addresses,
 func (n *Node) ForwardTransaction(ctx context.Context, in *pro.TransactionWithAddress) (*pro.Empty, error) {
 	theirTx, addr := block.DecodeTransactionWithAddress(in)
 	myTx := block.DecodeTransaction(in.GetTransaction())
+	if err := block.ValidateTransaction(theirTx); err != nil {
+		utils.Debug.Printf("%v recieved malformed transaction: %v", utils.FmtAddr(n.Address), err)
+		return &pro.Empty{}, err
+	}
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
 
+	// peers that agreed to only exchange blocks shouldn't send us transactions
+	if p := n.PeerDb.Get(addr); p != nil && p.Addr.BlocksOnly {
+		return &pro.Empty{}, errors.New("transaction from blocks-only peer")
+	}
+
 	// SeenTransactions map[string]*TransactionWithCount
 	// keys are string and values are pointers to TransactionWithCount objects 
 	//TODO: handle using myTX
 
 	txs_count, ok := n.SeenTransactions[theirTx.Hash()]
 	if ok {
+		// a peer relaying one of our own transactions back shows it was accepted
+		n.Broadcaster.Echo(theirTx.Hash(), addr)
 		txs_count.Count ++
 		return &pro.Empty{}, nil // successfully complete 
 	}
@@ -215,9 +405,33 @@ func (n *Node) ForwardTransaction(ctx context.Context, in *pro.TransactionWithAd
 	return &pro.Empty{}, nil
 }
 
+// invalidBlockError returns err if it says a block a peer sent can
+// never be valid (see block.RejectError), so only a peer that sent an
+// invalid block is told it did, and nil otherwise
+func invalidBlockError(err error) error {
+	if re := block.AsRejectError(err); re != nil && re.Invalid() {
+		return err
+	}
+	return nil
+}
+
 // ForwardBlock Handles forward block request (block propagation)
 func (n *Node) ForwardBlock(ctx context.Context, in *pro.Block) (*pro.Empty, error) {
 	b := block.DecodeBlock(in)
+	if err := block.ValidateBlock(b); err != nil {
+		utils.Debug.Printf("%v recieved malformed block: %v", utils.FmtAddr(n.Address), err)
+		return &pro.Empty{}, err
+	}
+	if err := n.BlockChain.ValidateHeader(b.Header); err != nil && !blockchain.IsUnknownParent(err) {
+		utils.Debug.Printf("%v recieved %v with an invalid header: %v", utils.FmtAddr(n.Address), b.NameTag(), err)
+		return &pro.Empty{}, invalidBlockError(err)
+	}
+	// a block that can't be stored isn't marked seen, so it can be
+	// taken again once there is space for it
+	if err := n.BlockChain.ChainWriter.CheckSpace(0); err != nil {
+		utils.Debug.Printf("%v refused %v: %v", utils.FmtAddr(n.Address), b.NameTag(), err)
+		return &pro.Empty{}, errors.New("node is out of disk space")
+	}
 
 	// If we've seen this transaction more than once before, don't forward
 	n.mutex.Lock()
@@ -231,27 +445,40 @@ func (n *Node) ForwardBlock(ctx context.Context, in *pro.Block) (*pro.Empty, err
 		n.SeenBlocks[b.Hash()] = 1
 	}
 
-	if !n.CheckBlock(b) {
+	// a block can't be checked until its parent arrives, so the chain
+	// keeps it until then
+	if !n.BlockChain.HasBlock(b.Header.PreviousHash) {
+		n.BlockChain.HandleBlock(b)
+		return &pro.Empty{}, nil
+	}
+	// the coins are those of the active chain, so only a block
+	// extending its tip can be checked against them. The chain checks
+	// a side branch's blocks against its own coins if it reorganizes
+	// to it (see BlockChain.connectBranch)
+	if b.Header.PreviousHash == n.BlockChain.LastHash && !n.CheckBlock(b) {
 		utils.Debug.Printf("%v recieved invalid %v", utils.FmtAddr(n.Address), b.NameTag())
-		return &pro.Empty{}, errors.New("block is not valid")
+		// tell the peer which rule the block broke
+		return &pro.Empty{}, n.BlockChain.CoinDB.CheckBlock(b.Transactions)
 	}
 	mnChn := n.BlockChain.LastHash == b.Header.PreviousHash && n.BlockChain.CoinDB.ValidateBlock(b.Transactions)
-	n.BlockChain.HandleBlock(b)
+	// a block the chain didn't take goes no further
+	if err := n.BlockChain.HandleBlock(b); err != nil {
+		utils.Debug.Printf("%v rejected %v: %v", utils.FmtAddr(n.Address), b.NameTag(), err)
+		return &pro.Empty{}, invalidBlockError(err)
+	}
+	if mnChn {
+		n.confirmTransactions(b)
+	}
 	if n.Config.MinerConfig.HasMiner && mnChn {
 		go n.Miner.HandleBlock(b)
 	}
+	// the wallet handles the block before it's counted towards a
+	// checkpoint, which records the wallet's state version
 	if n.Config.WalletConfig.HasWallet && mnChn {
-		go n.Wallet.HandleBlock(b.Transactions)
-	}
-	for _, p := range n.PeerDb.List() {
-		go func(addr *address.Address) {
-			_, err := addr.ForwardBlockRPC(block.EncodeBlock(b))
-			if err != nil {
-				utils.Debug.Printf("%v received no response from ForwardBlockRPC to %v",
-					utils.FmtAddr(n.Address), utils.FmtAddr(p.Addr.Addr))
-			}
-		}(p.Addr)
+		n.Wallet.HandleBlock(b.Transactions)
 	}
+	n.Checkpoints.HandleBlock(n.BlockChain, n.Wallet.StateVersion())
+	n.announceBlock(b)
 	return &pro.Empty{}, nil
 }
 
@@ -276,3 +503,8 @@ func (n *Node) GetWitnesses(ctx context.Context, in *pro.Transaction) (*pro.Witn
 		Witnesses: tx.Witnesses,
 	}, nil
 }
+
+// GetBlockTree Handles get block tree request (request for every known tip and branch point)
+func (n *Node) GetBlockTree(ctx context.Context, in *pro.Empty) (*pro.BlockTree, error) {
+	return blockchain.EncodeBlockTree(n.BlockChain.GetBlockTree()), nil
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
+	"fmt"
 )
 
 // Hash returns the hash of the inputted
@@ -60,7 +61,11 @@ func Byt2PK(pkB []byte) (*ecdsa.PublicKey, error) {
 	if err != nil {
 		return nil, err
 	}
-	return pk.(*ecdsa.PublicKey), nil
+	ecPk, ok := pk.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("[utils.Byt2PK] public key is not an ECDSA key")
+	}
+	return ecPk, nil
 }
 
 // PkFromSk converts the byte form of a secret key
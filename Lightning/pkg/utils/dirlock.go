@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LockFileName is the name of the lock file LockDirectory creates in
+// a directory.
+const LockFileName = "node.lock"
+
+// DirectoryLockedError is returned when a directory is locked by
+// another process, or by another lock in this one. Path is the
+// directory.
+type DirectoryLockedError struct {
+	Path string
+}
+
+// Error returns a description of the conflict.
+func (e *DirectoryLockedError) Error() string {
+	return fmt.Sprintf("data directory {%v} is in use by another node; stop it, or give this node its own directory", e.Path)
+}
+
+// IsDirectoryLocked returns whether err is a *DirectoryLockedError.
+func IsDirectoryLocked(err error) bool {
+	_, ok := err.(*DirectoryLockedError)
+	return ok
+}
+
+// DirectoryLock is an exclusive lock on a directory, held until it is
+// released or the process exits.
+// Path is the locked directory, and file is the open lock file the
+// lock is held on.
+type DirectoryLock struct {
+	Path string
+	file *os.File
+}
+
+// LockDirectory creates dir if it doesn't exist, and takes an exclusive
+// lock on it, so that two nodes can't write to the same files or
+// levelDBs at once. If the lock is already held, it fails right away
+// with a *DirectoryLockedError rather than waiting. The lock is on
+// the file LockFileName in dir, which is left behind once the lock is
+// released; a lock file that isn't locked doesn't stop dir from being
+// locked again, so a node that crashed doesn't keep the next one out.
+func LockDirectory(dir string) (*DirectoryLock, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("[LockDirectory] Unable to create {%v}: %v", dir, err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, LockFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("[LockDirectory] Unable to open lock file in {%v}: %v", dir, err)
+	}
+	if err = lockFile(file); err != nil {
+		file.Close()
+		if err == errLocked {
+			return nil, &DirectoryLockedError{Path: dir}
+		}
+		return nil, fmt.Errorf("[LockDirectory] Unable to lock {%v}: %v", dir, err)
+	}
+	return &DirectoryLock{Path: dir, file: file}, nil
+}
+
+// Release releases the lock. Releasing it again does nothing.
+func (l *DirectoryLock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errLocked is returned by lockFile when another lock is held on the
+// file.
+var errLocked = errors.New("file is locked")
+
+// lockFile takes an exclusive flock on file without waiting for it.
+// flock locks belong to the open file, so two opens of the same lock
+// file in one process also exclude each other.
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errLocked
+	}
+	return err
+}
+
+// unlockFile releases the lock lockFile took on file.
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// CorruptionError is returned when a levelDB is found to be corrupted
+// on open. Path is the levelDB's path. Recovered is whether the levelDB
+// was recovered, in which case it can be used, but some of what was
+// written to it may have been lost, so whatever it indexes should be
+// rebuilt. Err is the corruption that was found.
+type CorruptionError struct {
+	Path      string
+	Recovered bool
+	Err       error
+}
+
+// Error returns a description of the corruption.
+func (e *CorruptionError) Error() string {
+	if e.Recovered {
+		return fmt.Sprintf("levelDB {%v} was corrupted and has been recovered, but may have lost data: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("levelDB {%v} is corrupted and could not be recovered: %v", e.Path, e.Err)
+}
+
+// IsCorruption returns whether err is a *CorruptionError.
+func IsCorruption(err error) bool {
+	_, ok := err.(*CorruptionError)
+	return ok
+}
+
+// OpenLevelDB opens the levelDB at path. If readOnly is true, the
+// levelDB is opened so that it cannot be written to. If another process
+// (such as a running node) holds the levelDB's lock, a read-only open
+// falls back to a snapshot copy of it in a temporary directory. The
+// returned string is that directory, which the caller should remove once
+// it closes the levelDB, or empty if no copy was made.
+//
+// If the levelDB is corrupted, OpenLevelDB tries to recover it, and
+// returns a *CorruptionError. If the recovery worked, the levelDB is
+// returned along with the error, and can be used.
+func OpenLevelDB(path string, readOnly bool) (*leveldb.DB, string, error) {
+	if !readOnly {
+		db, err := leveldb.OpenFile(path, nil)
+		if err != nil && errors.IsCorrupted(err) {
+			return recoverLevelDB(path, err)
+		}
+		return db, "", err
+	}
+	options := &opt.Options{ReadOnly: true, ErrorIfMissing: true}
+	db, err := leveldb.OpenFile(path, options)
+	if err == nil {
+		return db, "", nil
+	}
+	if errors.IsCorrupted(err) {
+		// a read-only levelDB can't be recovered in place
+		return nil, "", &CorruptionError{Path: path, Recovered: false, Err: err}
+	}
+	if _, statErr := os.Stat(path); statErr != nil {
+		return nil, "", err
+	}
+	// the levelDB is most likely locked by a live node, so read a copy
+	snapshotDir, err := ioutil.TempDir("", filepath.Base(path)+"-snapshot")
+	if err != nil {
+		return nil, "", err
+	}
+	if err = copyLevelDBFiles(path, snapshotDir); err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, "", err
+	}
+	db, err = leveldb.OpenFile(snapshotDir, options)
+	if err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, "", err
+	}
+	return db, snapshotDir, nil
+}
+
+// recoverLevelDB tries to recover the corrupted levelDB at path,
+// whose corruption is err, by rebuilding it from whatever tables are
+// still readable.
+func recoverLevelDB(path string, err error) (*leveldb.DB, string, error) {
+	Debug.Printf("[OpenLevelDB] levelDB {%v} is corrupted, recovering: %v", path, err)
+	db, recoverErr := leveldb.RecoverFile(path, nil)
+	if recoverErr != nil {
+		return nil, "", &CorruptionError{Path: path, Recovered: false, Err: recoverErr}
+	}
+	return db, "", &CorruptionError{Path: path, Recovered: true, Err: err}
+}
+
+// copyLevelDBFiles copies every file in a levelDB directory other than
+// its LOCK file, and the node's lock file (see LockDirectory), into
+// another directory.
+func copyLevelDBFiles(src string, dst string) error {
+	entries, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == "LOCK" || entry.Name() == LockFileName {
+			continue
+		}
+		if err = copyFile(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file at src to dst.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
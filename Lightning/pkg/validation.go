@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"Coin/pkg/block"
+	"Coin/pkg/utils"
 	"fmt"
 )
 
@@ -60,9 +61,9 @@ func (n *Node) CheckBlockConfiguration(b *block.Block) bool {
 // (ChkBlkSem), and configurally (ChkBlkConf) valid.
 // Each transaction on the block must be syntactically (ChkTxSyn),
 // semantically (ChkTxSem), and configurally (ChkTxConf) valid.
-// Each transaction on the block must reference UTXO on the same
-// chain (main or forked chain) and not be a double spend on that
-// chain.
+// Each transaction on the block must reference UTXO on the main
+// chain and not be a double spend on it, so only blocks extending
+// the main chain's tip can be checked.
 // Inputs:
 // b *block.Block the block to be checked for validity
 // Returns:
@@ -167,12 +168,19 @@ func (n *Node) CheckTransactionConfiguration(t *block.Transaction) bool {
 // and configurally (ChkTxConf). If the transaction
 // can be classified as an orphan, there are more
 // validity checks that have to be done (ChkNonOrfSem).
+// The coins it spends that are locked by vaults must
+// allow it into the next block (see
+// BlockChain.CheckVaultSpends).
 // Inputs:
 // t *block.Transaction the transaction to be checked for validity
 // Returns:
 // bool True if the transaction is syntactically valid. false
 // otherwise
 func (n *Node) CheckTransaction(tx *block.Transaction) bool {
+	if err := n.BlockChain.CheckVaultSpends(tx); err != nil {
+		utils.Debug.Printf("%v", err)
+		return false
+	}
 	return true
 	//valid := CheckTransactionSyntax(tx) && n.CheckTransactionSemantics(tx) && n.CheckTransactionConfiguration(tx)
 	//if err := n.BlockChain.CoinDB.ValidateTransaction(tx); err != nil {
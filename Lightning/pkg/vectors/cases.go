@@ -0,0 +1,129 @@
+package vectors
+
+import (
+	"Coin/pkg/block"
+	"strings"
+)
+
+// Vectors are the consensus test vectors, in no particular order.
+var Vectors = []*Vector{
+	{
+		Name:   "empty block",
+		Build:  func(funding *block.Transaction) *block.Block { return blockOf() },
+		Accept: true,
+	},
+	{
+		Name: "spends an unspent coin",
+		Build: func(funding *block.Transaction) *block.Block {
+			return blockOf(spend(funding, 0))
+		},
+		Accept: true,
+	},
+	{
+		Name: "spends a coin created earlier in the block",
+		Build: func(funding *block.Transaction) *block.Block {
+			first := spend(funding, 0)
+			return blockOf(first, spend(first, 0))
+		},
+		Accept: true,
+	},
+	{
+		Name: "spends an output index the transaction doesn't have",
+		Build: func(funding *block.Transaction) *block.Block {
+			return blockOf(spend(funding, uint32(len(funding.Outputs))))
+		},
+		Reason: "did not contain Coin",
+	},
+	{
+		Name: "spends a transaction that doesn't exist",
+		Build: func(funding *block.Transaction) *block.Block {
+			missing := &block.Transaction{Version: 99}
+			return blockOf(spend(missing, 0))
+		},
+		Reason: "coin not in leveldb",
+	},
+	{
+		Name: "spends the same coin twice in one block",
+		Build: func(funding *block.Transaction) *block.Block {
+			first := spend(funding, 0)
+			second := spend(funding, 0)
+			second.Version = 2
+			return blockOf(first, second)
+		},
+		Reason: "already spent in block",
+	},
+	{
+		Name: "spends a coin created later in the block",
+		Build: func(funding *block.Transaction) *block.Block {
+			first := spend(funding, 0)
+			return blockOf(spend(first, 0), first)
+		},
+		Reason: "coin not in leveldb",
+	},
+	{
+		Name: "has no header",
+		Build: func(funding *block.Transaction) *block.Block {
+			b := blockOf(spend(funding, 0))
+			b.Header = nil
+			return b
+		},
+		Reason: "header is missing",
+	},
+	{
+		Name: "has a malformed previous hash",
+		Build: func(funding *block.Transaction) *block.Block {
+			b := blockOf()
+			b.Header.PreviousHash = "not a hash"
+			return b
+		},
+		Reason: "bad previous hash",
+	},
+	{
+		Name: "has an input with a malformed hash",
+		Build: func(funding *block.Transaction) *block.Block {
+			tx := spend(funding, 0)
+			tx.Inputs[0].ReferenceTransactionHash = strings.Repeat("z", block.HashLength)
+			return blockOf(tx)
+		},
+		Reason: "is not hex",
+	},
+	{
+		Name: "has a missing input",
+		Build: func(funding *block.Transaction) *block.Block {
+			tx := spend(funding, 0)
+			tx.Inputs = append(tx.Inputs, nil)
+			return blockOf(tx)
+		},
+		Reason: "input 1 is missing",
+	},
+	{
+		Name: "has outputs adding up to more than MaxMoney",
+		Build: func(funding *block.Transaction) *block.Block {
+			tx := spend(funding, 0)
+			tx.Outputs = append(tx.Outputs, &block.TransactionOutput{Amount: block.MaxMoney})
+			return blockOf(tx)
+		},
+		Reason: "add up to more than",
+	},
+}
+
+// blockOf returns a Block containing txs.
+func blockOf(txs ...*block.Transaction) *block.Block {
+	return &block.Block{
+		Header:       &block.Header{Version: 0},
+		Transactions: txs,
+	}
+}
+
+// spend returns a Transaction that spends the output of tx at
+// outputIndex, paying one coin to an empty locking script.
+func spend(tx *block.Transaction, outputIndex uint32) *block.Transaction {
+	return &block.Transaction{
+		Version: 1,
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: tx.Hash(),
+			OutputIndex:              outputIndex,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{}}},
+	}
+}
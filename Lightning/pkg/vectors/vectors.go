@@ -0,0 +1,52 @@
+// Package vectors holds consensus test vectors: Blocks that the
+// validation pipeline must accept, and Blocks it must reject, along
+// with why. As consensus rules are added, vectors for them are added
+// here, so that every rule is checked against every change.
+package vectors
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/coindatabase"
+	"fmt"
+	"strings"
+)
+
+// Vector is a single consensus test case.
+// Name describes the case,
+// Build returns the Block to validate, given the funding Transaction
+// whose outputs are unspent before the Block,
+// Accept is whether the Block must be accepted,
+// Reason is part of the error the Block must be rejected with.
+type Vector struct {
+	Name   string
+	Build  func(funding *block.Transaction) *block.Block
+	Accept bool
+	Reason string
+}
+
+// Check runs a Block through the validation pipeline: first the
+// checks on its structure, then the checks against the unspent Coins
+// in coinDB. It returns why the Block was rejected, or nil if it was
+// accepted.
+func Check(coinDB *coindatabase.CoinDatabase, b *block.Block) error {
+	if err := block.ValidateBlock(b); err != nil {
+		return err
+	}
+	return coinDB.CheckBlock(b.Transactions)
+}
+
+// Run checks the Block built by v against coinDB, which must have
+// funding's outputs unspent, and returns an error if the outcome is
+// not the one v expects.
+func Run(coinDB *coindatabase.CoinDatabase, funding *block.Transaction, v *Vector) error {
+	err := Check(coinDB, v.Build(funding))
+	switch {
+	case v.Accept && err != nil:
+		return fmt.Errorf("[%v] expected the block to be accepted, but it was rejected: %v", v.Name, err)
+	case !v.Accept && err == nil:
+		return fmt.Errorf("[%v] expected the block to be rejected (%v), but it was accepted", v.Name, v.Reason)
+	case !v.Accept && !strings.Contains(err.Error(), v.Reason):
+		return fmt.Errorf("[%v] expected the block to be rejected (%v), but it was rejected for: %v", v.Name, v.Reason, err)
+	}
+	return nil
+}
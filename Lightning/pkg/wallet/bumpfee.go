@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// replacementsBuffer is how many Replacements the wallet holds for the
+// node before BumpFee refuses to make more.
+const replacementsBuffer = 16
+
+// Replacement is a transaction the wallet rebuilt to pay a higher fee.
+// Original is the hash of the transaction it replaces.
+// Transaction is the replacement, which spends the same coins.
+type Replacement struct {
+	Original    string
+	Transaction *block.Transaction
+}
+
+// BumpFee replaces a transaction the wallet requested, which hasn't
+// been seen in a block yet, with one that spends the same coins and
+// makes the same payments, but pays newFee, which has to be more than
+// it paid. The higher fee comes out of the change, or out of more
+// coins if there isn't enough change. The original is marked as
+// replaced in the History, and the node broadcasts the replacement in
+// its place, which miners take over the original since it has a higher
+// priority (see miner.TxPool.Add). The replacement is handed to the
+// node on Replacements, so no fee is bumped while that is full.
+func (w *Wallet) BumpFee(hash string, newFee uint32) (*block.Transaction, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	original, ok := w.unseenTxs[hash]
+	if !ok {
+		return nil, fmt.Errorf("[wallet.BumpFee] transaction {%v} is not one of ours waiting to be mined", hash)
+	}
+	// only BumpFee sends Replacements, and it holds the mutex, so there
+	// is still room for this one once it's made
+	if len(w.Replacements) == cap(w.Replacements) {
+		return nil, fmt.Errorf("[wallet.BumpFee] the node hasn't taken the last %v replacements yet", cap(w.Replacements))
+	}
+	coinInfos := w.UnseenSpentCoins[hash]
+	available := uint64(0)
+	for _, ci := range coinInfos {
+		available += uint64(ci.TransactionOutput.Amount)
+	}
+	if oldFee := available - uint64(original.SumOutputs()); uint64(newFee) <= oldFee {
+		return nil, fmt.Errorf("[wallet.BumpFee] fee %v is not more than the %v {%v} pays", newFee, oldFee, hash)
+	}
+	// the change gives way to the higher fee, or the whole of a
+	// consolidation
+	payments := original.Outputs
+	var changeScript []byte
+	if i, ok := w.unseenChange[hash]; ok {
+		changeScript = payments[i].LockingScript
+		payments = append(append([]*block.TransactionOutput{}, payments[:i]...), payments[i+1:]...)
+	}
+	paid := uint64(0)
+	for _, txo := range payments {
+		paid += uint64(txo.Amount)
+	}
+	need := paid + uint64(newFee)
+	// spend more coins if the ones already spent aren't worth enough
+	var extras []CoinInfo
+	if available < need {
+		var coins []CoinInfo
+		for ci := range w.CoinCollection {
+			coins = append(coins, ci)
+		}
+		if need-available > uint64(w.Balance) {
+			return nil, fmt.Errorf("[wallet.BumpFee] balance %v can't pay %v more for {%v}", w.Balance, need-available, hash)
+		}
+		if extras = w.coinSelector().SelectCoins(coins, uint32(need-available)); extras == nil {
+			return nil, fmt.Errorf("[wallet.BumpFee] coins can't pay %v more for {%v}", need-available, hash)
+		}
+		for _, ci := range extras {
+			available += uint64(ci.TransactionOutput.Amount)
+		}
+	}
+	outputs := append([]*block.TransactionOutput{}, payments...)
+	change := uint32(available - need)
+	if change != 0 {
+		if changeScript == nil {
+			var err error
+			if changeScript, err = proto.Marshal(&pro.PayToPublicKey{PublicKey: w.changeKey()}); err != nil {
+				return nil, fmt.Errorf("[wallet.BumpFee] failed to marshal script: %v", err)
+			}
+		}
+		outputs = append(outputs, &block.TransactionOutput{Amount: change, LockingScript: changeScript})
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("[wallet.BumpFee] fee %v leaves nothing of {%v}", newFee, hash)
+	}
+	tx := &block.Transaction{
+		Version:  original.Version,
+		Inputs:   append(append([]*block.TransactionInput{}, original.Inputs...), w.signInputs(extras)...),
+		Outputs:  outputs,
+		LockTime: original.LockTime,
+	}
+	w.removeUnseen(hash)
+	w.addUnseen(tx.Hash(), append(append([]CoinInfo{}, coinInfos...), extras...))
+	w.unseenTxs[tx.Hash()] = tx
+	if change != 0 {
+		w.unseenChange[tx.Hash()] = uint32(len(outputs) - 1)
+	}
+	for _, ci := range extras {
+		delete(w.CoinCollection, ci)
+		w.Balance -= ci.TransactionOutput.Amount
+	}
+	w.recordReplacement(hash, tx, uint32(paid), newFee)
+	w.Replacements <- &Replacement{Original: hash, Transaction: tx}
+	return tx, nil
+}
+
+// recordReplacement adds the transaction that replaced the one with
+// hash to the History, sending the same recipients what the original
+// sent, with the same memo. A consolidation sends what it is left with
+// after the new fee, since it sends it to the wallet itself.
+func (w *Wallet) recordReplacement(hash string, tx *block.Transaction, paid uint32, fee uint32) {
+	e := &HistoryEntry{
+		TransactionHash: tx.Hash(),
+		Timestamp:       time.Now().Unix(),
+		Amount:          paid,
+		Fee:             fee,
+		Replaces:        hash,
+	}
+	if original := w.historyEntry(hash); original != nil {
+		if original.Amount > paid {
+			e.Amount = tx.SumOutputs()
+		}
+		e.Recipient = original.Recipient
+		e.Note = original.Note
+		e.Tags = append([]string{}, original.Tags...)
+	}
+	w.appendHistory(e)
+}
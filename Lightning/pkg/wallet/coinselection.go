@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"Coin/pkg/utils"
+	"fmt"
+	"sort"
+)
+
+// The names of the CoinSelectors, which the Config's CoinSelection
+// picks between.
+const (
+	LargestFirstSelection   = "largest-first"
+	SmallestFirstSelection  = "smallest-first"
+	BranchAndBoundSelection = "branch-and-bound"
+)
+
+// defaultBranchAndBoundTries is how many branches a BranchAndBound
+// without MaxTries explores.
+const defaultBranchAndBoundTries = 100000
+
+// CoinSelector picks the coins a transaction spends.
+type CoinSelector interface {
+	// SelectCoins returns coins, out of coins, that are worth at
+	// least target in total, or nil if they can't be.
+	SelectCoins(coins []CoinInfo, target uint32) []CoinInfo
+}
+
+// LargestFirst spends the largest coins first, so a transaction has
+// as few inputs as possible.
+type LargestFirst struct{}
+
+// SmallestFirst spends the smallest coins first, so the wallet is left
+// with fewer, larger coins, as if it had consolidated.
+type SmallestFirst struct{}
+
+// BranchAndBound searches for coins worth exactly the target, so a
+// transaction needs no change output, in the manner of Bitcoin Core's
+// branch and bound. It explores including or leaving out each coin,
+// largest first, abandoning a branch once it's worth too much or can
+// no longer be worth enough.
+// MaxTries is how many branches it explores before giving up. If it is
+// zero, it explores up to 100000.
+// Fallback picks the coins when there's no exact match. If it is nil,
+// LargestFirst does.
+type BranchAndBound struct {
+	MaxTries int
+	Fallback CoinSelector
+}
+
+// NewCoinSelector returns the CoinSelector with name, one of the
+// CoinSelection names, or LargestFirst if name is empty.
+func NewCoinSelector(name string) (CoinSelector, error) {
+	switch name {
+	case LargestFirstSelection, "":
+		return LargestFirst{}, nil
+	case SmallestFirstSelection:
+		return SmallestFirst{}, nil
+	case BranchAndBoundSelection:
+		return BranchAndBound{}, nil
+	}
+	return nil, fmt.Errorf("[wallet.NewCoinSelector] unknown coin selection {%v}", name)
+}
+
+// coinSelector returns the CoinSelector picked by the Config's
+// CoinSelection, falling back to LargestFirst if it's unknown.
+func (w *Wallet) coinSelector() CoinSelector {
+	selector, err := NewCoinSelector(w.Config.CoinSelection)
+	if err != nil {
+		utils.Debug.Printf("%v", err)
+		return LargestFirst{}
+	}
+	return selector
+}
+
+// sortCoins returns a copy of coins sorted by amount, largest first if
+// descending is set, and otherwise smallest first. Coins of the same
+// amount are in a fixed order, so selection never depends on the order
+// coins come out of a map.
+func sortCoins(coins []CoinInfo, descending bool) []CoinInfo {
+	sorted := append([]CoinInfo{}, coins...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.TransactionOutput.Amount != b.TransactionOutput.Amount {
+			return (a.TransactionOutput.Amount > b.TransactionOutput.Amount) == descending
+		}
+		if a.ReferenceTransactionHash != b.ReferenceTransactionHash {
+			return a.ReferenceTransactionHash < b.ReferenceTransactionHash
+		}
+		return a.OutputIndex < b.OutputIndex
+	})
+	return sorted
+}
+
+// selectInOrder returns the shortest prefix of coins worth at least
+// target, or nil if they all aren't.
+func selectInOrder(coins []CoinInfo, target uint32) []CoinInfo {
+	total := uint64(0)
+	for i, ci := range coins {
+		if total >= uint64(target) {
+			return coins[:i]
+		}
+		total += uint64(ci.TransactionOutput.Amount)
+	}
+	if total >= uint64(target) {
+		return coins
+	}
+	return nil
+}
+
+// SelectCoins returns the fewest of the largest coins worth target.
+func (LargestFirst) SelectCoins(coins []CoinInfo, target uint32) []CoinInfo {
+	return selectInOrder(sortCoins(coins, true), target)
+}
+
+// SelectCoins returns as many of the smallest coins as it takes to be
+// worth target.
+func (SmallestFirst) SelectCoins(coins []CoinInfo, target uint32) []CoinInfo {
+	return selectInOrder(sortCoins(coins, false), target)
+}
+
+// SelectCoins returns coins worth exactly target if it finds them, and
+// otherwise what the Fallback selects.
+func (bnb BranchAndBound) SelectCoins(coins []CoinInfo, target uint32) []CoinInfo {
+	sorted := sortCoins(coins, true)
+	// remaining[i] is what sorted[i:] are worth together
+	remaining := make([]uint64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + uint64(sorted[i].TransactionOutput.Amount)
+	}
+	tries := bnb.MaxTries
+	if tries <= 0 {
+		tries = defaultBranchAndBoundTries
+	}
+	var chosen []CoinInfo
+	var search func(i int, total uint64) bool
+	search = func(i int, total uint64) bool {
+		if total == uint64(target) {
+			return true
+		}
+		if tries <= 0 || i == len(sorted) || total > uint64(target) || total+remaining[i] < uint64(target) {
+			return false
+		}
+		tries--
+		chosen = append(chosen, sorted[i])
+		if search(i+1, total+uint64(sorted[i].TransactionOutput.Amount)) {
+			return true
+		}
+		chosen = chosen[:len(chosen)-1]
+		return search(i+1, total)
+	}
+	if target > 0 && search(0, 0) {
+		return chosen
+	}
+	fallback := bnb.Fallback
+	if fallback == nil {
+		fallback = LargestFirst{}
+	}
+	return fallback.SelectCoins(coins, target)
+}
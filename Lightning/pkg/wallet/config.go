@@ -1,5 +1,7 @@
 package wallet
 
+import "time"
+
 // Config represents the configuration (settings)
 // for the wallet.
 // HasWt (HasWallet) defines whether the wallet
@@ -16,6 +18,39 @@ package wallet
 // software version of the node.
 // DefLckTm (DefaultLockTime) is the default lock
 // time (when the utxo can be spent)
+// AutoConsolidate defines whether the
+// wallet should periodically merge its coins together.
+// ConsolidationInterval is how often the wallet checks
+// whether it should consolidate.
+// QuietHoursStart and QuietHoursEnd are the local hours
+// (0-23) between which the wallet may consolidate.
+// ConsolidationMaxFee is the highest fee estimate the
+// wallet will consolidate at.
+// ConsolidationTarget is how many coins the wallet
+// should be left with after consolidating.
+// UnseenExpiry is how many blocks a transaction the
+// wallet requested may go without being seen in a block
+// before it is abandoned, and the coins it spent are
+// spendable again. Zero means it is never abandoned.
+// HistoryPath is the file the wallet's
+// transaction history is persisted to. If it is empty,
+// the history is only kept in memory.
+// StatePath is the file the wallet's coins, the coins it's
+// waiting on, and its vaults and swaps are persisted to,
+// rewritten whenever they change, so they survive a restart.
+// If it is empty, they are only kept in memory.
+// Seed is what the wallet's keys are derived from. If it is
+// nil, the seed persisted at StatePath is used, or a new one
+// is generated.
+// KeyLookahead is how many keys past the last one used on
+// each chain the wallet watches for payments to.
+// CoinSelection names the CoinSelector that picks the coins
+// the wallet spends, unless a request picks its own.
+// FeeHistoryBlocks is how many recent blocks the wallet
+// estimates fees from.
+// FeeTarget is how many blocks the wallet expects the
+// transactions it makes on its own, such as consolidations,
+// to take to be mined, when estimating their fees.
 type Config struct {
 	HasWallet                  bool
 	TransactionReplayThreshold uint32
@@ -23,6 +58,21 @@ type Config struct {
 	TransactionVersion         uint32
 	DefaultLockTime            uint32
 	DefaultFee                 uint32
+	UnseenExpiry               uint32
+	HistoryPath                string
+	StatePath                  string
+	Seed                       []byte
+	KeyLookahead               uint32
+	CoinSelection              string
+	FeeHistoryBlocks           uint32
+	FeeTarget                  uint32
+
+	AutoConsolidate       bool
+	ConsolidationInterval time.Duration
+	QuietHoursStart       int
+	QuietHoursEnd         int
+	ConsolidationMaxFee   uint32
+	ConsolidationTarget   int
 }
 
 // DefaultConfig returns the standard/basic
@@ -35,5 +85,19 @@ func DefaultConfig() *Config {
 		TransactionVersion:         0,
 		DefaultLockTime:            0,
 		DefaultFee:                 5,
+		UnseenExpiry:               100,
+		HistoryPath:                "",
+		StatePath:                  "",
+		Seed:                       nil,
+		KeyLookahead:               20,
+		CoinSelection:              BranchAndBoundSelection,
+		FeeHistoryBlocks:           20,
+		FeeTarget:                  6,
+		AutoConsolidate:            false,
+		ConsolidationInterval:      10 * time.Minute,
+		QuietHoursStart:            1,
+		QuietHoursEnd:              5,
+		ConsolidationMaxFee:        5,
+		ConsolidationTarget:        5,
 	}
 }
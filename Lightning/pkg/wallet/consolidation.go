@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"time"
+)
+
+// StartConsolidationScheduler starts a go routine that, every
+// ConsolidationInterval, sends the current time on ConsolidationDue.
+// The node answers by calling MaybeConsolidate, so that the wallet is
+// only ever changed from the node's main loop.
+func (w *Wallet) StartConsolidationScheduler() {
+	if !w.Config.AutoConsolidate || w.Config.ConsolidationInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(w.Config.ConsolidationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case t := <-ticker.C:
+				w.ConsolidationDue <- t
+			case <-w.stopConsolidation:
+				return
+			}
+		}
+	}()
+}
+
+// StopConsolidationScheduler stops the go routine started by
+// StartConsolidationScheduler.
+func (w *Wallet) StopConsolidationScheduler() {
+	if !w.Config.AutoConsolidate || w.Config.ConsolidationInterval <= 0 {
+		return
+	}
+	w.stopConsolidation <- true
+}
+
+// MaybeConsolidate merges the wallet's smallest coins into one, leaving
+// it with ConsolidationTarget coins, if now is within the quiet hours, the
+// fee estimate is at most ConsolidationMaxFee, and the wallet has more
+// coins than its target. It returns the consolidating transaction, or nil
+// if it did not consolidate.
+func (w *Wallet) MaybeConsolidate(now time.Time) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	if !inQuietHours(now.Hour(), w.Config.QuietHoursStart, w.Config.QuietHoursEnd) {
+		return nil
+	}
+	fee := w.estimateFee()
+	if fee > w.Config.ConsolidationMaxFee {
+		return nil
+	}
+	target := w.Config.ConsolidationTarget
+	if target < 1 {
+		target = 1
+	}
+	if len(w.CoinCollection) <= target {
+		return nil
+	}
+	// merging n coins into one removes n - 1 of them
+	n := len(w.CoinCollection) - target + 1
+	return w.consolidate(n, fee)
+}
+
+// consolidate merges the wallet's n smallest coins into one coin, paying fee.
+func (w *Wallet) consolidate(n int, fee uint32) *block.Transaction {
+	var coinInfos []CoinInfo
+	for ci := range w.CoinCollection {
+		coinInfos = append(coinInfos, ci)
+	}
+	sort.Slice(coinInfos, func(i, j int) bool {
+		return coinInfos[i].TransactionOutput.Amount < coinInfos[j].TransactionOutput.Amount
+	})
+	coinInfos = coinInfos[:n]
+	total := uint32(0)
+	var inputs []*block.TransactionInput
+	for _, ci := range coinInfos {
+		unlockingScript, err := ci.TransactionOutput.MakeSignature(w.signer(ci.TransactionOutput))
+		if err != nil {
+			utils.Debug.Printf("[wallet.consolidate] Failed to create unlockingScript")
+			return nil
+		}
+		inputs = append(inputs, &block.TransactionInput{
+			ReferenceTransactionHash: ci.ReferenceTransactionHash,
+			OutputIndex:              ci.OutputIndex,
+			UnlockingScript:          unlockingScript,
+		})
+		total += ci.TransactionOutput.Amount
+	}
+	if total <= fee {
+		utils.Debug.Printf("[wallet.consolidate] coins are worth less than the fee of %v", fee)
+		return nil
+	}
+	changeKey := w.changeKey()
+	myScript, err := proto.Marshal(&pro.PayToPublicKey{PublicKey: changeKey})
+	if err != nil {
+		utils.Debug.Printf("[wallet.consolidate] Failed to marshal script")
+		return nil
+	}
+	tx := &block.Transaction{
+		Version:  w.Config.TransactionVersion,
+		Inputs:   inputs,
+		Outputs:  []*block.TransactionOutput{{Amount: total - fee, LockingScript: myScript}},
+		LockTime: w.Config.DefaultLockTime,
+	}
+	w.addUnseen(tx.Hash(), coinInfos)
+	w.unseenTxs[tx.Hash()] = tx
+	w.unseenChange[tx.Hash()] = 0
+	for _, ci := range coinInfos {
+		delete(w.CoinCollection, ci)
+	}
+	w.Balance -= total
+	w.recordHistory(tx, total-fee, fee, [][]byte{changeKey}, &Memo{
+		Note: fmt.Sprintf("auto-consolidated %v coins worth %v into one", n, total),
+		Tags: []string{"consolidation"},
+	})
+	w.broadcast(tx)
+	return tx
+}
+
+// inQuietHours returns whether hour is in [start, end), where the
+// quiet hours may wrap around midnight.
+func inQuietHours(hour int, start int, end int) bool {
+	if start <= end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
@@ -0,0 +1,179 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"math"
+	"sort"
+	"sync"
+)
+
+// feeConfidence is how likely a transaction paying the estimated
+// feerate should be to be mined within its target.
+const feeConfidence = 0.95
+
+// maxFeeRounds is how many times feeWithin re-selects coins for a
+// larger fee before settling on it.
+const maxFeeRounds = 5
+
+// FeeEstimator recommends the feerate a transaction should pay to be
+// mined within a number of blocks, from the feerates of the
+// transactions in recent blocks and in the mempool. Feerates are fees
+// per 100 units of transaction size, which is the priority the miner
+// orders transactions by (see miner.CalculatePriority).
+// Mempool returns the feerates of the transactions waiting to be
+// mined. If it is nil, only recent blocks are considered.
+// history is how many blocks are remembered, and blocks the sorted
+// feerates of the transactions in each, oldest first, not counting
+// coinbases or blocks without other transactions.
+// mutex guards blocks.
+type FeeEstimator struct {
+	Mempool func() []uint32
+
+	history int
+	mutex   sync.Mutex
+	blocks  [][]uint32
+}
+
+// NewFeeEstimator returns a FeeEstimator that remembers the last
+// history blocks.
+func NewFeeEstimator(history uint32) *FeeEstimator {
+	if history == 0 {
+		history = 1
+	}
+	return &FeeEstimator{history: int(history)}
+}
+
+// ObserveBlock records the feerates of the transactions in a block
+// the main chain gained, not counting its coinbase.
+func (fe *FeeEstimator) ObserveBlock(feerates []uint32) {
+	if len(feerates) == 0 {
+		return
+	}
+	sorted := append([]uint32{}, feerates...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	fe.blocks = append(fe.blocks, sorted)
+	if len(fe.blocks) > fe.history {
+		fe.blocks = fe.blocks[len(fe.blocks)-fe.history:]
+	}
+}
+
+// EstimateFeerate returns the feerate a transaction should pay to be
+// mined within target blocks, or false if there's nothing to go on. It
+// pays at least:
+// (1) the lowest feerate mined in enough recent blocks that a
+// transaction paying it is mined within target blocks with
+// feeConfidence, if each block is as likely to take it as recent
+// blocks were
+// (2) one more than the feerate of the last transaction in the mempool
+// that target blocks would take, if the mempool is fuller than that,
+// supposing blocks take the highest feerates first, as many as recent
+// blocks held on average.
+func (fe *FeeEstimator) EstimateFeerate(target uint32) (uint32, bool) {
+	if target == 0 {
+		target = 1
+	}
+	fe.mutex.Lock()
+	var minima []uint32
+	mined := 0
+	for _, rates := range fe.blocks {
+		minima = append(minima, rates[0])
+		mined += len(rates)
+	}
+	fe.mutex.Unlock()
+	var mempool []uint32
+	if fe.Mempool != nil {
+		mempool = fe.Mempool()
+	}
+	if len(minima) == 0 && len(mempool) == 0 {
+		return 0, false
+	}
+	// the miner never gives a transaction a priority below 1
+	rate := uint32(1)
+	// (1) a block with a lower minimum would have taken the transaction
+	if len(minima) > 0 {
+		sort.Slice(minima, func(i, j int) bool { return minima[i] < minima[j] })
+		perBlock := 1 - math.Pow(1-feeConfidence, 1/float64(target))
+		i := int(math.Ceil(perBlock*float64(len(minima)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		if minima[i] > rate {
+			rate = minima[i]
+		}
+	}
+	// (2) outbid everything target blocks can't take
+	perBlock := 1
+	if len(minima) > 0 && mined/len(minima) > 1 {
+		perBlock = mined / len(minima)
+	}
+	if capacity := perBlock * int(target); len(mempool) >= capacity {
+		sort.Slice(mempool, func(i, j int) bool { return mempool[i] > mempool[j] })
+		if mempool[capacity-1]+1 > rate {
+			rate = mempool[capacity-1] + 1
+		}
+	}
+	return rate, true
+}
+
+// feeForSize returns the smallest fee that gives a transaction of size
+// a feerate of at least rate.
+func feeForSize(rate uint32, size uint32) uint32 {
+	return uint32((uint64(rate)*uint64(size) + 99) / 100)
+}
+
+// txSize returns the size of a transaction with inputs inputs and
+// outputs outputs, which doesn't depend on what's in them (see
+// block.Transaction.Size).
+func txSize(inputs int, outputs int) uint32 {
+	tx := &block.Transaction{}
+	for i := 0; i < inputs; i++ {
+		tx.Inputs = append(tx.Inputs, &block.TransactionInput{})
+	}
+	for i := 0; i < outputs; i++ {
+		tx.Outputs = append(tx.Outputs, &block.TransactionOutput{})
+	}
+	return tx.Size()
+}
+
+// feeWithin returns the fee a transaction sending amount, with the
+// coins selector picks and change, should pay to be mined within
+// blocks blocks, or the Config's DefaultFee if the wallet's Fees have
+// nothing to go on. The coins depend on the fee, and the fee on how
+// many coins there are, so it goes back and forth until they agree.
+func (w *Wallet) feeWithin(amount uint32, blocks uint32, selector CoinSelector) uint32 {
+	rate, ok := w.Fees.EstimateFeerate(blocks)
+	if !ok {
+		return w.Config.DefaultFee
+	}
+	var coins []CoinInfo
+	for ci := range w.CoinCollection {
+		coins = append(coins, ci)
+	}
+	fee := feeForSize(rate, txSize(1, 2))
+	for i := 0; i < maxFeeRounds; i++ {
+		selected := selector.SelectCoins(coins, amount+fee)
+		if selected == nil {
+			// the request reports that the coins aren't worth enough
+			break
+		}
+		need := feeForSize(rate, txSize(len(selected), 2))
+		if need <= fee {
+			break
+		}
+		fee = need
+	}
+	return fee
+}
+
+// RequestTransactionWithin is RequestTransaction, paying the fee that
+// should get the transaction mined within blocks blocks (see
+// FeeEstimator), rather than a fee of the caller's choosing.
+func (w *Wallet) RequestTransactionWithin(amount uint32, blocks uint32, recipientPK []byte, memo *Memo) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	selector := w.coinSelector()
+	payments := []Payment{{RecipientPK: recipientPK, Amount: amount}}
+	return w.requestPayments(payments, w.feeWithin(amount, blocks, selector), memo, selector)
+}
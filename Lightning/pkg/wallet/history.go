@@ -0,0 +1,182 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Memo is local information a user attaches to a transaction
+// they request, recording why the payment was made. It is
+// never broadcast to the network.
+// Note is a free-form description of the payment.
+// Tags are labels for grouping payments, such as "rent".
+type Memo struct {
+	Note string
+	Tags []string
+}
+
+// HistoryEntry is a transaction the wallet has requested.
+// TransactionHash is the hash of the transaction.
+// Timestamp is when the transaction was requested, in Unix seconds.
+// Amount and Fee are what was sent and paid to send it.
+// Recipient is the hex-encoded public key the amount was sent to, or
+// the keys separated by commas, if it was split between several.
+// Note and Tags come from the transaction's Memo, if it had one.
+// Replaces is the hash of the transaction this one replaced to pay a
+// higher fee, and ReplacedBy the hash of the one that replaced it, if
+// either was (see BumpFee).
+type HistoryEntry struct {
+	TransactionHash string   `json:"transaction_hash"`
+	Timestamp       int64    `json:"timestamp"`
+	Amount          uint32   `json:"amount"`
+	Fee             uint32   `json:"fee"`
+	Recipient       string   `json:"recipient"`
+	Note            string   `json:"note,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Replaces        string   `json:"replaces,omitempty"`
+	ReplacedBy      string   `json:"replaced_by,omitempty"`
+}
+
+// GetHistoryEntry returns the HistoryEntry for a transaction hash,
+// or nil if the wallet did not request that transaction.
+func (w *Wallet) GetHistoryEntry(hash string) *HistoryEntry {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.historyEntry(hash)
+}
+
+// historyEntry is GetHistoryEntry. The caller must hold the mutex.
+func (w *Wallet) historyEntry(hash string) *HistoryEntry {
+	for _, e := range w.History {
+		if e.TransactionHash == hash {
+			return e
+		}
+	}
+	return nil
+}
+
+// HistoryWithTag returns every HistoryEntry that has the given tag,
+// oldest first.
+func (w *Wallet) HistoryWithTag(tag string) []*HistoryEntry {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var entries []*HistoryEntry
+	for _, e := range w.History {
+		for _, t := range e.Tags {
+			if t == tag {
+				entries = append(entries, e)
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// ExportHistory writes the wallet's History, memos included,
+// to out as a JSON array.
+func (w *Wallet) ExportHistory(out io.Writer) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	history := w.History
+	if history == nil {
+		history = []*HistoryEntry{}
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(history)
+}
+
+// recordHistory adds a requested transaction to the History,
+// persisting it if the wallet has a HistoryPath.
+func (w *Wallet) recordHistory(tx *block.Transaction, amount uint32, fee uint32, recipientPKs [][]byte, memo *Memo) {
+	var recipients []string
+	for _, pk := range recipientPKs {
+		recipients = append(recipients, hex.EncodeToString(pk))
+	}
+	e := &HistoryEntry{
+		TransactionHash: tx.Hash(),
+		Timestamp:       time.Now().Unix(),
+		Amount:          amount,
+		Fee:             fee,
+		Recipient:       strings.Join(recipients, ","),
+	}
+	if memo != nil {
+		e.Note = memo.Note
+		e.Tags = append([]string{}, memo.Tags...)
+	}
+	w.appendHistory(e)
+}
+
+// appendHistory adds e to the History, marking the entry it replaces
+// as replaced, and persists it if the wallet has a HistoryPath.
+func (w *Wallet) appendHistory(e *HistoryEntry) {
+	linkReplacement(w.History, e)
+	w.History = append(w.History, e)
+	if w.Config.HistoryPath == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		utils.Debug.Printf("[wallet.appendHistory] Unable to marshal history entry {%v}: %v", e.TransactionHash, err)
+		return
+	}
+	file, err := os.OpenFile(w.Config.HistoryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		utils.Debug.Printf("[wallet.appendHistory] Unable to open {%v}: %v", w.Config.HistoryPath, err)
+		return
+	}
+	defer file.Close()
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		utils.Debug.Printf("[wallet.appendHistory] Unable to write to {%v}: %v", w.Config.HistoryPath, err)
+	}
+}
+
+// loadHistory returns the History persisted at path, one JSON
+// HistoryEntry per line. It returns nil if there is no history.
+func loadHistory(path string) []*HistoryEntry {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.Debug.Printf("[wallet.loadHistory] Unable to open {%v}: %v", path, err)
+		}
+		return nil
+	}
+	defer file.Close()
+	var history []*HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		e := &HistoryEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			utils.Debug.Printf("[wallet.loadHistory] Skipping malformed entry in {%v}: %v", path, err)
+			continue
+		}
+		linkReplacement(history, e)
+		history = append(history, e)
+	}
+	return history
+}
+
+// linkReplacement sets the ReplacedBy of the entry in history that e
+// replaces, if it replaces one. The file at HistoryPath only ever
+// grows, so that's never written to it, and is worked out again as the
+// History is loaded.
+func linkReplacement(history []*HistoryEntry, e *HistoryEntry) {
+	if e.Replaces == "" {
+		return
+	}
+	for _, original := range history {
+		if original.TransactionHash == e.Replaces {
+			original.ReplacedBy = e.TransactionHash
+		}
+	}
+}
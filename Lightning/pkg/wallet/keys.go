@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/id"
+	"Coin/pkg/pro"
+	"Coin/pkg/utils"
+	"crypto/rand"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// The chains of keys the wallet derives from its seed, below its
+// account key, m/0'.
+const (
+	// ReceiveChain is the chain of keys the wallet hands out to be
+	// paid to.
+	ReceiveChain uint32 = 0
+	// ChangeChain is the chain of keys the wallet pays its own change
+	// to.
+	ChangeChain uint32 = 1
+)
+
+// seedLength is how many bytes of seed the wallet generates.
+const seedLength = 32
+
+// ownedKey is a key the wallet can spend the coins of.
+// ID is the key.
+// Chain and Index are where the key was derived, if Derived is set.
+// Otherwise, it's the wallet's Id.
+type ownedKey struct {
+	ID      id.ID
+	Chain   uint32
+	Index   uint32
+	Derived bool
+}
+
+// newSeed returns a random seed for a wallet's keys.
+func newSeed() ([]byte, error) {
+	seed := make([]byte, seedLength)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("[wallet.newSeed] failed to generate seed: %v", err)
+	}
+	return seed, nil
+}
+
+// initKeys starts deriving the wallet's keys from seed, from the start
+// of each chain. The wallet's Id stays one of its keys, since coins
+// are paid to it by the node's miner.
+func (w *Wallet) initKeys(seed []byte) error {
+	master, err := id.NewMasterKey(seed)
+	if err != nil {
+		return err
+	}
+	account, err := master.Child(0)
+	if err != nil {
+		return err
+	}
+	var chains [2]*id.ExtendedKey
+	for c := range chains {
+		if chains[c], err = account.Child(uint32(c)); err != nil {
+			return err
+		}
+	}
+	w.seed = seed
+	w.chains = chains
+	w.nextIndexes = [2]uint32{}
+	w.chainKeys = [2][]id.ID{}
+	w.keys = map[string]*ownedKey{string(w.Id.GetPublicKeyBytes()): {ID: w.Id}}
+	w.deriveLookahead()
+	return nil
+}
+
+// deriveLookahead derives the Config's KeyLookahead keys past the
+// next unused key of each chain, so payments to keys the wallet has
+// handed out, or that were handed out before it was restored, are
+// recognized.
+func (w *Wallet) deriveLookahead() {
+	for c, chain := range w.chains {
+		if chain == nil {
+			continue
+		}
+		for uint32(len(w.chainKeys[c])) <= w.nextIndexes[c]+w.Config.KeyLookahead {
+			index := uint32(len(w.chainKeys[c]))
+			key, err := deriveKey(chain, index)
+			// an index that derives no key is skipped
+			w.chainKeys[c] = append(w.chainKeys[c], key)
+			if err != nil {
+				utils.Debug.Printf("[wallet.deriveLookahead] %v", err)
+				continue
+			}
+			w.keys[string(key.GetPublicKeyBytes())] = &ownedKey{ID: key, Chain: uint32(c), Index: index, Derived: true}
+		}
+	}
+}
+
+// nextKey returns the next unused key of a chain, and marks it used,
+// so it's never handed out again. It returns the wallet's Id if the
+// wallet has no seed.
+func (w *Wallet) nextKey(chain uint32) id.ID {
+	if w.chains[chain] == nil {
+		return w.Id
+	}
+	for {
+		index := w.nextIndexes[chain]
+		w.nextIndexes[chain]++
+		w.deriveLookahead()
+		if key := w.chainKeys[chain][index]; key != nil {
+			return key
+		}
+	}
+}
+
+// deriveKey returns the key at index of a chain.
+func deriveKey(chain *id.ExtendedKey, index uint32) (id.ID, error) {
+	child, err := chain.Child(index)
+	if err != nil {
+		return nil, err
+	}
+	key, err := child.ID()
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// NewReceiveKey returns the public key of a fresh key for the wallet
+// to be paid to, which it has never handed out before.
+func (w *Wallet) NewReceiveKey() []byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	return w.nextKey(ReceiveChain).GetPublicKeyBytes()
+}
+
+// changeKey returns the public key of a fresh key for the wallet to
+// pay its change to.
+func (w *Wallet) changeKey() []byte {
+	return w.nextKey(ChangeChain).GetPublicKeyBytes()
+}
+
+// NextKeyIndexes returns the index of the next unused key on the
+// ReceiveChain and on the ChangeChain.
+func (w *Wallet) NextKeyIndexes() (uint32, uint32) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.nextIndexes[ReceiveChain], w.nextIndexes[ChangeChain]
+}
+
+// ownsKey returns whether publicKey is one of the wallet's keys. A
+// derived key at or past the next unused key of its chain has been
+// used elsewhere, by a copy of the wallet or before it was restored,
+// so the chain moves on past it.
+func (w *Wallet) ownsKey(publicKey []byte) bool {
+	k, ok := w.keys[string(publicKey)]
+	if !ok {
+		return false
+	}
+	if k.Derived && k.Index >= w.nextIndexes[k.Chain] {
+		w.nextIndexes[k.Chain] = k.Index + 1
+		w.deriveLookahead()
+	}
+	return true
+}
+
+// signer returns the key that can spend a coin the wallet owns, which
+// is the wallet's Id unless the coin is paid to a derived key.
+func (w *Wallet) signer(txo *block.TransactionOutput) id.ID {
+	pK := &pro.PayToPublicKey{}
+	if err := proto.Unmarshal(txo.LockingScript, pK); err != nil {
+		return w.Id
+	}
+	if k, ok := w.keys[string(pK.GetPublicKey())]; ok {
+		return k.ID
+	}
+	return w.Id
+}
@@ -0,0 +1,91 @@
+package wallet
+
+import "sort"
+
+// CoinReport describes one of the wallet's coins, to help decide
+// which coins to merge, and which aren't worth spending at all.
+// Amount is the coin's value.
+// Age is how many blocks the wallet has handled since the one the
+// coin was received in, counting that one. It is 0 if the wallet
+// doesn't know, such as for coins restored from a Snapshot.
+// Confirmed is whether the coin has enough confirmations to be spent.
+// Dust is whether the coin is worth no more than the fee of a
+// transaction spending it alone, at the current fee estimate.
+type CoinReport struct {
+	CoinInfo
+	Amount    uint32
+	Age       uint32
+	Confirmed bool
+	Dust      bool
+}
+
+// estimateFee returns the fee the wallet expects a transaction, with
+// one input and change, to pay right now to be mined within the
+// Config's FeeTarget blocks.
+func (w *Wallet) estimateFee() uint32 {
+	if w.FeeEstimator != nil {
+		return w.FeeEstimator()
+	}
+	if rate, ok := w.Fees.EstimateFeerate(w.Config.FeeTarget); ok {
+		return feeForSize(rate, txSize(1, 2))
+	}
+	return w.Config.DefaultFee
+}
+
+// CoinReports returns a CoinReport for each coin the wallet has, or is
+// waiting on confirmations for, that isn't being spent. Coins are
+// ordered from the least valuable to the most, and oldest first among
+// coins of the same value.
+func (w *Wallet) CoinReports() []*CoinReport {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.coinReports()
+}
+
+// coinReports is CoinReports. The caller must hold the mutex.
+func (w *Wallet) coinReports() []*CoinReport {
+	fee := w.estimateFee()
+	var reports []*CoinReport
+	add := func(ci CoinInfo, confirmed bool) {
+		r := &CoinReport{
+			CoinInfo:  ci,
+			Amount:    ci.TransactionOutput.Amount,
+			Confirmed: confirmed,
+			Dust:      ci.TransactionOutput.Amount <= fee,
+		}
+		if at, ok := w.receivedAt[ci]; ok {
+			r.Age = w.blocksSeen - at
+		}
+		reports = append(reports, r)
+	}
+	for ci := range w.CoinCollection {
+		if _, spent := w.UnconfirmedSpentCoins[ci]; !spent {
+			add(ci, true)
+		}
+	}
+	for ci := range w.UnconfirmedReceivedCoins {
+		add(ci, false)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Amount != reports[j].Amount {
+			return reports[i].Amount < reports[j].Amount
+		}
+		return reports[i].Age > reports[j].Age
+	})
+	return reports
+}
+
+// DustCoins returns the CoinReports of the confirmed coins that are
+// dust at the current fee estimate. They can only be spent alongside
+// more valuable coins, such as by consolidating.
+func (w *Wallet) DustCoins() []*CoinReport {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var dust []*CoinReport
+	for _, r := range w.coinReports() {
+		if r.Confirmed && r.Dust {
+			dust = append(dust, r)
+		}
+	}
+	return dust
+}
@@ -0,0 +1,71 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/mnemonic"
+	"fmt"
+)
+
+// Mnemonic returns the wallet's seed written as a mnemonic, which is
+// all it takes to get the wallet's keys back if its data is lost (see
+// RestoreFromMnemonic).
+func (w *Wallet) Mnemonic() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.seed == nil {
+		return "", fmt.Errorf("[wallet.Mnemonic] the wallet has no seed")
+	}
+	return mnemonic.Encode(w.seed)
+}
+
+// RestoreFromMnemonic replaces the wallet's seed with the seed a
+// mnemonic was written from, and forgets every coin the wallet knew
+// of, along with how many blocks it had handled. Handling every block
+// of the active chain again then finds the coins of the restored keys
+// (see Node.RestoreWallet). The History is kept.
+func (w *Wallet) RestoreFromMnemonic(words string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	seed, err := mnemonic.Decode(words)
+	if err != nil {
+		return err
+	}
+	if err = w.initKeys(seed); err != nil {
+		return fmt.Errorf("[wallet.RestoreFromMnemonic] %v", err)
+	}
+	history := w.History
+	w.restore(&Snapshot{})
+	w.History = history
+	w.blocksSeen = 0
+	return nil
+}
+
+// handleSpentElsewhere removes the coins a transaction spends that the
+// wallet didn't spend itself, as a copy of the wallet may have, or as
+// the wallet did before it was restored. Coins spent by the wallet's
+// own transactions are out of the CoinCollection already.
+func (w *Wallet) handleSpentElsewhere(tx *block.Transaction) {
+	if len(tx.Inputs) == 0 {
+		return
+	}
+	owned := make(map[partialInput]CoinInfo)
+	for ci := range w.CoinCollection {
+		owned[partialInput{ci.ReferenceTransactionHash, ci.OutputIndex}] = ci
+	}
+	for ci := range w.UnconfirmedReceivedCoins {
+		owned[partialInput{ci.ReferenceTransactionHash, ci.OutputIndex}] = ci
+	}
+	for _, txi := range tx.Inputs {
+		ci, ok := owned[partialInput{txi.ReferenceTransactionHash, txi.OutputIndex}]
+		if !ok {
+			continue
+		}
+		if w.CoinCollection[ci] {
+			delete(w.CoinCollection, ci)
+			w.Balance -= ci.TransactionOutput.Amount
+		}
+		delete(w.UnconfirmedReceivedCoins, ci)
+		delete(w.receivedAt, ci)
+	}
+}
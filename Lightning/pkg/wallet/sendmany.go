@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+)
+
+// Payment is an amount to send to a public key, one of the outputs of
+// a transaction the wallet requests.
+// RecipientPK is the public key the amount is paid to.
+// Amount is what it's paid.
+type Payment struct {
+	RecipientPK []byte
+	Amount      uint32
+}
+
+// RequestTransactionMulti is RequestTransaction, making every one of
+// payments with a single transaction, with an output for each, in
+// order, plus change. That pays one fee rather than one for each
+// payment. The History records the transaction once, with the total
+// amount sent.
+func (w *Wallet) RequestTransactionMulti(payments []Payment, fee uint32, memo *Memo) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.requestPayments(payments, fee, memo, w.coinSelector())
+}
+
+// requestPayments makes payments with a single transaction, spending
+// the coins selector picks, and asks the node to propagate it.
+func (w *Wallet) requestPayments(payments []Payment, fee uint32, memo *Memo, selector CoinSelector) *block.Transaction {
+	defer w.save()
+	if len(payments) == 0 {
+		utils.Debug.Printf("[wallet.RequestTransaction] no payments to make")
+		return nil
+	}
+	total := uint64(0)
+	var recipientPKs [][]byte
+	for _, p := range payments {
+		if len(p.RecipientPK) == 0 {
+			utils.Debug.Printf("[wallet.RequestTransaction] receiver's public key is invalid")
+			return nil
+		}
+		total += uint64(p.Amount)
+		recipientPKs = append(recipientPKs, p.RecipientPK)
+	}
+	// have to ensure that we have enough money to actually make this transaction
+	if uint64(w.Balance) < total+uint64(fee) {
+		utils.Debug.Printf("%v did not have a large enough balance to make the requested transaction\n"+
+			"Balance: %v\nTransaction cost: %v", utils.FmtAddr(w.Address), w.Balance, total+uint64(fee))
+		return nil
+	}
+	amount := uint32(total)
+	change, inputs, coinInfos := w.generateTransactionInputs(amount, fee, selector)
+	if coinInfos == nil {
+		utils.Debug.Printf("[wallet.RequestTransaction] coinInfos were nil")
+		return nil
+	}
+	tx := &block.Transaction{
+		Version:  0,
+		Inputs:   inputs,
+		Outputs:  w.generateTransactionOutputs(payments, change),
+		LockTime: 0,
+	}
+	// now that we have the transaction, we can add the coinInfos to our UnseenSpentCoins
+	// and temporarily remove from the CoinCollection
+	w.addUnseen(tx.Hash(), coinInfos)
+	w.unseenTxs[tx.Hash()] = tx
+	if change != 0 {
+		w.unseenChange[tx.Hash()] = uint32(len(payments))
+	}
+	for _, ci := range coinInfos {
+		delete(w.CoinCollection, ci)
+	}
+	w.recordHistory(tx, amount, fee, recipientPKs, memo)
+	// if we want to broadcast, send to the channel that the node monitors
+	go func() {
+		w.TransactionRequests <- tx
+	}()
+	// have to make sure that the balance is decremented so that the wallet owner can't keep spamming their coin
+	w.Balance -= amount + fee + change
+	return tx
+}
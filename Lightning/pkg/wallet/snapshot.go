@@ -0,0 +1,108 @@
+package wallet
+
+import "Coin/pkg/block"
+
+// PendingCoin is a coin along with how many confirmations
+// the wallet has seen for it so far.
+type PendingCoin struct {
+	CoinInfo
+	Confirmations uint32
+}
+
+// Snapshot is a copy of the wallet's state that can be
+// serialized, for backing the wallet up.
+// Balance is the wallet's Balance.
+// Coins are the coins in the CoinCollection.
+// UnseenSpentCoins, UnconfirmedSpentCoins,
+// UnconfirmedReceivedCoins, Vaults, Swaps, and History are
+// copies of the wallet's fields of the same names.
+type Snapshot struct {
+	Balance                  uint32
+	Coins                    []CoinInfo
+	UnseenSpentCoins         map[string][]CoinInfo
+	UnconfirmedSpentCoins    []PendingCoin
+	UnconfirmedReceivedCoins []PendingCoin
+	Vaults                   map[string]*VaultCoin
+	Swaps                    map[string]*SwapCoin
+	History                  []*HistoryEntry
+}
+
+// Snapshot returns a Snapshot of the wallet's current state.
+func (w *Wallet) Snapshot() *Snapshot {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.snapshot()
+}
+
+// snapshot is Snapshot. The caller must hold the mutex.
+func (w *Wallet) snapshot() *Snapshot {
+	s := &Snapshot{
+		Balance:          w.Balance,
+		UnseenSpentCoins: make(map[string][]CoinInfo),
+		Vaults:           make(map[string]*VaultCoin),
+		Swaps:            make(map[string]*SwapCoin),
+		History:          append([]*HistoryEntry{}, w.History...),
+	}
+	for c := range w.CoinCollection {
+		s.Coins = append(s.Coins, c)
+	}
+	for hash, coins := range w.UnseenSpentCoins {
+		s.UnseenSpentCoins[hash] = append([]CoinInfo{}, coins...)
+	}
+	for c, n := range w.UnconfirmedSpentCoins {
+		s.UnconfirmedSpentCoins = append(s.UnconfirmedSpentCoins, PendingCoin{c, n})
+	}
+	for c, n := range w.UnconfirmedReceivedCoins {
+		s.UnconfirmedReceivedCoins = append(s.UnconfirmedReceivedCoins, PendingCoin{c, n})
+	}
+	for key, v := range w.Vaults {
+		s.Vaults[key] = v
+	}
+	for key, sc := range w.Swaps {
+		s.Swaps[key] = sc
+	}
+	return s
+}
+
+// Restore replaces the wallet's state with the state in s.
+func (w *Wallet) Restore(s *Snapshot) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.restore(s)
+	w.save()
+}
+
+// restore is Restore, without saving the wallet's state.
+func (w *Wallet) restore(s *Snapshot) {
+	w.Balance = s.Balance
+	w.CoinCollection = make(map[CoinInfo]bool)
+	for _, c := range s.Coins {
+		w.CoinCollection[c] = true
+	}
+	w.UnseenSpentCoins = make(map[string][]CoinInfo)
+	w.unseenSince = make(map[string]uint32)
+	w.unseenTxs = make(map[string]*block.Transaction)
+	w.unseenChange = make(map[string]uint32)
+	for hash, coins := range s.UnseenSpentCoins {
+		w.addUnseen(hash, append([]CoinInfo{}, coins...))
+	}
+	w.UnconfirmedSpentCoins = make(map[CoinInfo]uint32)
+	for _, p := range s.UnconfirmedSpentCoins {
+		w.UnconfirmedSpentCoins[p.CoinInfo] = p.Confirmations
+	}
+	// when restored coins were received isn't known
+	w.receivedAt = make(map[CoinInfo]uint32)
+	w.UnconfirmedReceivedCoins = make(map[CoinInfo]uint32)
+	for _, p := range s.UnconfirmedReceivedCoins {
+		w.UnconfirmedReceivedCoins[p.CoinInfo] = p.Confirmations
+	}
+	w.Vaults = make(map[string]*VaultCoin)
+	for key, v := range s.Vaults {
+		w.Vaults[key] = v
+	}
+	w.Swaps = make(map[string]*SwapCoin)
+	for key, sc := range s.Swaps {
+		w.Swaps[key] = sc
+	}
+	w.History = append([]*HistoryEntry{}, s.History...)
+}
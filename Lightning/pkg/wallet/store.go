@@ -0,0 +1,180 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// stateVersion is the version of the format the wallet's state is
+// persisted in.
+const stateVersion = 1
+
+// storedState is how the wallet's state is laid out in the file at
+// the Config's StatePath.
+// Version is the version of the format.
+// PublicKey is the public key of the wallet's Id, so a wallet never
+// takes on the coins of another key.
+// Seed is the seed the wallet's keys are derived from, and
+// NextIndexes the index of the next unused key on each chain.
+// Snapshot is the wallet's balance, coins, pending coins, vaults,
+// swaps and history.
+// BlocksSeen, UnseenSince, UnseenTransactions, UnseenChange and
+// ReceivedAt are the wallet's blocksSeen, unseenSince, unseenTxs,
+// unseenChange and receivedAt.
+type storedState struct {
+	Version     int               `json:"version"`
+	PublicKey   []byte            `json:"public_key"`
+	Seed        []byte            `json:"seed"`
+	NextIndexes [2]uint32         `json:"next_indexes"`
+	Snapshot    *Snapshot         `json:"snapshot"`
+	BlocksSeen  uint32            `json:"blocks_seen"`
+	UnseenSince map[string]uint32 `json:"unseen_since"`
+	ReceivedAt  []receivedCoin    `json:"received_at"`
+
+	UnseenTransactions map[string]*block.Transaction `json:"unseen_transactions,omitempty"`
+	UnseenChange       map[string]uint32             `json:"unseen_change,omitempty"`
+}
+
+// receivedCoin is a coin along with how many blocks the wallet had
+// handled when it was received.
+type receivedCoin struct {
+	CoinInfo
+	BlocksSeen uint32
+}
+
+// save writes the wallet's state to the file at the Config's
+// StatePath, if it has one, replacing the state already there. It
+// writes to a temporary file first, so that a crash never leaves a
+// partially written state in place of a good one. It's called after
+// everything that changes the wallet's coins, which must hold the
+// mutex until it returns.
+func (w *Wallet) save() {
+	if w.Config.StatePath == "" {
+		return
+	}
+	s := &storedState{
+		Version:     stateVersion,
+		PublicKey:   w.Id.GetPublicKeyBytes(),
+		Seed:        w.seed,
+		NextIndexes: w.nextIndexes,
+		Snapshot:    w.snapshot(),
+		BlocksSeen:  w.blocksSeen,
+		UnseenSince: w.unseenSince,
+
+		UnseenTransactions: w.unseenTxs,
+		UnseenChange:       w.unseenChange,
+	}
+	for c, n := range w.receivedAt {
+		s.ReceivedAt = append(s.ReceivedAt, receivedCoin{c, n})
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		utils.Debug.Printf("[wallet.save] Unable to encode wallet state: %v", err)
+		return
+	}
+	tmp := w.Config.StatePath + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0600); err != nil {
+		utils.Debug.Printf("[wallet.save] Unable to write {%v}: %v", tmp, err)
+		return
+	}
+	if err = os.Rename(tmp, w.Config.StatePath); err != nil {
+		utils.Debug.Printf("[wallet.save] Unable to replace {%v}: %v", w.Config.StatePath, err)
+	}
+}
+
+// load replaces the wallet's state with the state persisted at the
+// Config's StatePath, if there is one. The History is left as it is
+// if the wallet has a HistoryPath, since that's persisted as it grows.
+func (w *Wallet) load() error {
+	if w.Config.StatePath == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(w.Config.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("[wallet.load] failed to read {%v}: %v", w.Config.StatePath, err)
+	}
+	s := &storedState{}
+	if err = json.Unmarshal(data, s); err != nil || s.Snapshot == nil {
+		return fmt.Errorf("[wallet.load] state {%v} is corrupted: %v", w.Config.StatePath, err)
+	}
+	if s.Version != stateVersion {
+		return fmt.Errorf("[wallet.load] state {%v} has unknown version %v", w.Config.StatePath, s.Version)
+	}
+	if !bytes.Equal(s.PublicKey, w.Id.GetPublicKeyBytes()) {
+		return fmt.Errorf("[wallet.load] state {%v} belongs to another key", w.Config.StatePath)
+	}
+	if w.Config.Seed != nil && !bytes.Equal(s.Seed, w.Config.Seed) {
+		return fmt.Errorf("[wallet.load] state {%v} belongs to another seed", w.Config.StatePath)
+	}
+	if err = w.initKeys(s.Seed); err != nil {
+		return fmt.Errorf("[wallet.load] state {%v} has an unusable seed: %v", w.Config.StatePath, err)
+	}
+	w.nextIndexes = s.NextIndexes
+	w.deriveLookahead()
+	s.shareOutputs()
+	history := w.History
+	w.restore(s.Snapshot)
+	if w.Config.HistoryPath != "" {
+		w.History = history
+	}
+	w.blocksSeen = s.BlocksSeen
+	for hash, n := range s.UnseenSince {
+		if _, ok := w.UnseenSpentCoins[hash]; ok {
+			w.unseenSince[hash] = n
+		}
+	}
+	for hash, tx := range s.UnseenTransactions {
+		if _, ok := w.UnseenSpentCoins[hash]; ok {
+			w.unseenTxs[hash] = tx
+		}
+	}
+	for hash, i := range s.UnseenChange {
+		if tx, ok := w.unseenTxs[hash]; ok && int(i) < len(tx.Outputs) {
+			w.unseenChange[hash] = i
+		}
+	}
+	for _, rc := range s.ReceivedAt {
+		w.receivedAt[rc.CoinInfo] = rc.BlocksSeen
+	}
+	return nil
+}
+
+// shareOutputs makes every CoinInfo in the storedState for the same
+// output share one TransactionOutput. CoinInfos are map keys, which
+// compare TransactionOutputs by pointer, and decoding gives each copy
+// of a CoinInfo a TransactionOutput of its own.
+func (s *storedState) shareOutputs() {
+	outputs := make(map[partialInput]*block.TransactionOutput)
+	share := func(ci *CoinInfo) {
+		pi := partialInput{ci.ReferenceTransactionHash, ci.OutputIndex}
+		if txo, ok := outputs[pi]; ok {
+			ci.TransactionOutput = txo
+		} else {
+			outputs[pi] = ci.TransactionOutput
+		}
+	}
+	for i := range s.Snapshot.Coins {
+		share(&s.Snapshot.Coins[i])
+	}
+	for _, coins := range s.Snapshot.UnseenSpentCoins {
+		for i := range coins {
+			share(&coins[i])
+		}
+	}
+	for i := range s.Snapshot.UnconfirmedSpentCoins {
+		share(&s.Snapshot.UnconfirmedSpentCoins[i].CoinInfo)
+	}
+	for i := range s.Snapshot.UnconfirmedReceivedCoins {
+		share(&s.Snapshot.UnconfirmedReceivedCoins[i].CoinInfo)
+	}
+	for i := range s.ReceivedAt {
+		share(&s.ReceivedAt[i].CoinInfo)
+	}
+}
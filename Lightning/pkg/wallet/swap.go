@@ -0,0 +1,245 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/pro"
+	"Coin/pkg/script"
+	"Coin/pkg/utils"
+	"bytes"
+	"google.golang.org/protobuf/proto"
+)
+
+// SwapCoin is an output locked by a Swap script that the wallet is the
+// sender or the recipient of.
+// Swap is the output's locking script.
+// Seen is whether the wallet has seen the output in a block.
+// Confirmations is how many blocks have been seen on top of that block.
+type SwapCoin struct {
+	CoinInfo
+	Swap          *script.Swap
+	Seen          bool
+	Confirmations uint32
+}
+
+// Sending returns whether the wallet locked the SwapCoin, and so can
+// refund it, rather than claim it.
+func (sc *SwapCoin) Sending(w *Wallet) bool {
+	return bytes.Equal(sc.Swap.SenderPublicKey, w.Id.GetPublicKeyBytes())
+}
+
+// GetSwapCoin returns the wallet's SwapCoin for an output,
+// or nil if the wallet is not part of a swap there.
+func (w *Wallet) GetSwapCoin(hash string, index uint32) *SwapCoin {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.swapCoin(hash, index)
+}
+
+// swapCoin is GetSwapCoin. The caller must hold the mutex.
+func (w *Wallet) swapCoin(hash string, index uint32) *SwapCoin {
+	return w.Swaps[vaultKey(hash, index)]
+}
+
+// LockSwap locks amount of the wallet's coins into a Swap, which
+// recipientPK can claim by revealing the preimage of paymentHash, and
+// which the wallet takes back if it isn't claimed within timeout blocks.
+func (w *Wallet) LockSwap(amount uint32, fee uint32, recipientPK []byte, paymentHash []byte, timeout uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	if w.Balance < amount+fee {
+		utils.Debug.Printf("%v did not have a large enough balance to lock the swap\n"+
+			"Balance: %v\nSwap cost: %v", utils.FmtAddr(w.Address), w.Balance, amount+fee)
+		return nil
+	}
+	change, inputs, coinInfos := w.generateTransactionInputs(amount, fee, w.coinSelector())
+	if coinInfos == nil {
+		utils.Debug.Printf("[wallet.LockSwap] coinInfos were nil")
+		return nil
+	}
+	s := &script.Swap{
+		ScriptType:         script.SWAP,
+		SenderPublicKey:    w.Id.GetPublicKeyBytes(),
+		RecipientPublicKey: recipientPK,
+		PaymentHash:        paymentHash,
+		TimeoutBlocks:      timeout,
+	}
+	swapScript, err := proto.Marshal(script.EncodeSwap(s))
+	if err != nil {
+		utils.Debug.Printf("[wallet.LockSwap] Failed to marshal swap script")
+		return nil
+	}
+	outputs := []*block.TransactionOutput{{Amount: amount, LockingScript: swapScript}}
+	if change != 0 {
+		myScript, err2 := proto.Marshal(&pro.PayToPublicKey{PublicKey: w.changeKey()})
+		if err2 != nil {
+			utils.Debug.Printf("[wallet.LockSwap] Failed to marshal change script")
+			return nil
+		}
+		outputs = append(outputs, &block.TransactionOutput{Amount: change, LockingScript: myScript})
+	}
+	tx := &block.Transaction{
+		Version:  w.Config.TransactionVersion,
+		Inputs:   inputs,
+		Outputs:  outputs,
+		LockTime: w.Config.DefaultLockTime,
+	}
+	w.addUnseen(tx.Hash(), coinInfos)
+	for _, ci := range coinInfos {
+		delete(w.CoinCollection, ci)
+	}
+	w.Balance -= amount + fee + change
+	w.addSwapCoin(tx.Hash(), 0, tx.Outputs[0], s)
+	w.broadcast(tx)
+	return tx
+}
+
+// ClaimSwap claims a swap the wallet is the recipient of, revealing
+// preimage, which settles the lightning payment the swap is for.
+func (w *Wallet) ClaimSwap(hash string, index uint32, preimage []byte, fee uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	return w.claimSwap(hash, index, preimage, fee)
+}
+
+// claimSwap is ClaimSwap, without saving the wallet's state. The
+// caller must hold the mutex.
+func (w *Wallet) claimSwap(hash string, index uint32, preimage []byte, fee uint32) *block.Transaction {
+	sc := w.swapCoin(hash, index)
+	if sc == nil || sc.Sending(w) || !sc.Seen {
+		utils.Debug.Printf("[wallet.ClaimSwap] no confirmed incoming swap at {%v}", vaultKey(hash, index))
+		return nil
+	}
+	tx := w.spendSwapCoin(sc, preimage, fee)
+	if tx != nil && w.OnSwapPreimage != nil {
+		w.OnSwapPreimage(sc.Swap.PaymentHash, preimage)
+	}
+	return tx
+}
+
+// RefundSwap takes back the coins of a swap the wallet locked, once its
+// timeout has passed without the recipient claiming it.
+func (w *Wallet) RefundSwap(hash string, index uint32, fee uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	return w.refundSwap(hash, index, fee)
+}
+
+// refundSwap is RefundSwap, without saving the wallet's state. The
+// caller must hold the mutex.
+func (w *Wallet) refundSwap(hash string, index uint32, fee uint32) *block.Transaction {
+	sc := w.swapCoin(hash, index)
+	if sc == nil || !sc.Sending(w) {
+		utils.Debug.Printf("[wallet.RefundSwap] no outgoing swap at {%v}", vaultKey(hash, index))
+		return nil
+	}
+	return w.spendSwapCoin(sc, nil, fee)
+}
+
+// spendSwapCoin creates and broadcasts a Transaction that spends a
+// SwapCoin back to the wallet, revealing preimage if it is claiming
+// the swap. It returns nil if the swap does not allow the spend.
+func (w *Wallet) spendSwapCoin(sc *SwapCoin, preimage []byte, fee uint32) *block.Transaction {
+	if sc.TransactionOutput.Amount <= fee {
+		utils.Debug.Printf("[wallet.spendSwapCoin] swap is too small to pay a fee of %v", fee)
+		return nil
+	}
+	err := script.CheckSwapSpend(sc.Swap, w.Id.GetPublicKeyBytes(), preimage, sc.Confirmations)
+	if err != nil {
+		utils.Debug.Printf("%v", err)
+		return nil
+	}
+	sig, err := sc.TransactionOutput.MakeSignature(w.Id)
+	if err != nil {
+		utils.Debug.Printf("[wallet.spendSwapCoin] Failed to create signature")
+		return nil
+	}
+	unlockingScript, err := script.MakeSwapUnlock(sig, preimage)
+	if err != nil {
+		utils.Debug.Printf("[wallet.spendSwapCoin] Failed to create unlockingScript")
+		return nil
+	}
+	myScript, err := proto.Marshal(&pro.PayToPublicKey{PublicKey: w.Id.GetPublicKeyBytes()})
+	if err != nil {
+		utils.Debug.Printf("[wallet.spendSwapCoin] Failed to marshal script")
+		return nil
+	}
+	tx := &block.Transaction{
+		Version: w.Config.TransactionVersion,
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: sc.ReferenceTransactionHash,
+			OutputIndex:              sc.OutputIndex,
+			UnlockingScript:          unlockingScript,
+		}},
+		Outputs:  []*block.TransactionOutput{{Amount: sc.TransactionOutput.Amount - fee, LockingScript: myScript}},
+		LockTime: w.Config.DefaultLockTime,
+	}
+	delete(w.Swaps, vaultKey(sc.ReferenceTransactionHash, sc.OutputIndex))
+	w.broadcast(tx)
+	return tx
+}
+
+// addSwapCoin starts tracking an output locked by s as one of the
+// wallet's swaps.
+func (w *Wallet) addSwapCoin(hash string, index uint32, txo *block.TransactionOutput, s *script.Swap) {
+	w.Swaps[vaultKey(hash, index)] = &SwapCoin{
+		CoinInfo: CoinInfo{
+			ReferenceTransactionHash: hash,
+			OutputIndex:              index,
+			TransactionOutput:        txo,
+		},
+		Swap: s,
+	}
+}
+
+// updateSwaps tracks the swaps that tx locks the wallet into, and
+// learns the preimages of the wallet's swaps that tx claims.
+func (w *Wallet) updateSwaps(tx *block.Transaction) {
+	for _, txi := range tx.Inputs {
+		key := vaultKey(txi.ReferenceTransactionHash, txi.OutputIndex)
+		sc, ok := w.Swaps[key]
+		if !ok {
+			continue
+		}
+		delete(w.Swaps, key)
+		preimage := script.SwapPreimage(sc.Swap, txi.UnlockingScript)
+		if preimage != nil && w.OnSwapPreimage != nil {
+			w.OnSwapPreimage(sc.Swap.PaymentHash, preimage)
+		}
+	}
+	hash := tx.Hash()
+	me := w.Id.GetPublicKeyBytes()
+	for i, txo := range tx.Outputs {
+		s, err := script.ParseSwap(txo.LockingScript)
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(s.SenderPublicKey, me) && !bytes.Equal(s.RecipientPublicKey, me) {
+			continue
+		}
+		if w.swapCoin(hash, uint32(i)) == nil {
+			w.addSwapCoin(hash, uint32(i), txo, s)
+		}
+		w.swapCoin(hash, uint32(i)).Seen = true
+	}
+}
+
+// settleSwaps claims the wallet's incoming swaps whose preimages it
+// knows, and refunds its outgoing swaps that have timed out.
+func (w *Wallet) settleSwaps() {
+	for _, sc := range w.Swaps {
+		if !sc.Seen {
+			continue
+		}
+		switch {
+		case sc.Sending(w) && sc.Confirmations >= sc.Swap.TimeoutBlocks:
+			w.refundSwap(sc.ReferenceTransactionHash, sc.OutputIndex, w.estimateFee())
+		case !sc.Sending(w) && w.LookupPreimage != nil:
+			if preimage := w.LookupPreimage(sc.Swap.PaymentHash); preimage != nil {
+				w.claimSwap(sc.ReferenceTransactionHash, sc.OutputIndex, preimage, w.estimateFee())
+			}
+		}
+	}
+}
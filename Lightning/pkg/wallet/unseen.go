@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/utils"
+	"fmt"
+	"sort"
+)
+
+// StuckTransaction is a transaction the wallet requested that
+// hasn't been seen in a block yet.
+// TransactionHash is the hash of the transaction.
+// Coins are the wallet's coins that the transaction spends.
+// Amount is what those coins are worth.
+// Age is how many blocks the wallet has seen since it requested
+// the transaction.
+type StuckTransaction struct {
+	TransactionHash string
+	Coins           []CoinInfo
+	Amount          uint32
+	Age             uint32
+}
+
+// addUnseen records that the transaction with hash spends coinInfos,
+// and hasn't been seen in a block yet.
+func (w *Wallet) addUnseen(hash string, coinInfos []CoinInfo) {
+	w.UnseenSpentCoins[hash] = coinInfos
+	if w.unseenSince == nil {
+		w.unseenSince = make(map[string]uint32)
+	}
+	if _, ok := w.unseenSince[hash]; !ok {
+		w.unseenSince[hash] = w.blocksSeen
+	}
+}
+
+// removeUnseen forgets the transaction with hash, returning the
+// coins it spent.
+func (w *Wallet) removeUnseen(hash string) []CoinInfo {
+	coinInfos := w.UnseenSpentCoins[hash]
+	delete(w.UnseenSpentCoins, hash)
+	delete(w.unseenSince, hash)
+	delete(w.unseenTxs, hash)
+	delete(w.unseenChange, hash)
+	return coinInfos
+}
+
+// StuckTransactions returns every transaction in UnseenSpentCoins
+// that has gone unseen for at least minAge blocks, oldest first.
+func (w *Wallet) StuckTransactions(minAge uint32) []*StuckTransaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.stuckTransactions(minAge)
+}
+
+// stuckTransactions is StuckTransactions. The caller must hold the
+// mutex.
+func (w *Wallet) stuckTransactions(minAge uint32) []*StuckTransaction {
+	var stuck []*StuckTransaction
+	for hash, coinInfos := range w.UnseenSpentCoins {
+		age := w.blocksSeen - w.unseenSince[hash]
+		if age < minAge {
+			continue
+		}
+		st := &StuckTransaction{TransactionHash: hash, Coins: coinInfos, Age: age}
+		for _, ci := range coinInfos {
+			st.Amount += ci.TransactionOutput.Amount
+		}
+		stuck = append(stuck, st)
+	}
+	sort.Slice(stuck, func(i, j int) bool {
+		if stuck[i].Age != stuck[j].Age {
+			return stuck[i].Age > stuck[j].Age
+		}
+		return stuck[i].TransactionHash < stuck[j].TransactionHash
+	})
+	return stuck
+}
+
+// AbandonTransaction gives up on a transaction the wallet requested
+// that hasn't been seen in a block, making the coins it spent
+// spendable again. If the transaction is mined after all, the
+// coins will be double counted until they are spent again, so it
+// should only be abandoned once it can't be mined.
+func (w *Wallet) AbandonTransaction(hash string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	if _, ok := w.UnseenSpentCoins[hash]; !ok {
+		return fmt.Errorf("[AbandonTransaction] transaction %v is not waiting to be seen", hash)
+	}
+	w.releaseCoins(w.removeUnseen(hash))
+	return nil
+}
+
+// releaseCoins puts coins that an unseen transaction spent back in
+// the CoinCollection.
+func (w *Wallet) releaseCoins(coinInfos []CoinInfo) {
+	for _, ci := range coinInfos {
+		w.CoinCollection[ci] = true
+		w.Balance += ci.TransactionOutput.Amount
+	}
+}
+
+// handleConflicts looks for transactions in a block that spend the
+// same coins as a transaction in UnseenSpentCoins, such as a
+// malleated copy of it or a double spend. The unseen transaction can
+// never be mined, so the coins the block spent are treated as spent,
+// and the rest are released.
+func (w *Wallet) handleConflicts(tx *block.Transaction) {
+	if len(w.UnseenSpentCoins) == 0 {
+		return
+	}
+	spentBy := make(map[partialInput]string)
+	for hash, coinInfos := range w.UnseenSpentCoins {
+		for _, ci := range coinInfos {
+			spentBy[partialInput{ci.ReferenceTransactionHash, ci.OutputIndex}] = hash
+		}
+	}
+	conflicts := make(map[string]map[partialInput]bool)
+	for _, txi := range tx.Inputs {
+		pi := partialInput{txi.ReferenceTransactionHash, txi.OutputIndex}
+		if hash, ok := spentBy[pi]; ok {
+			if conflicts[hash] == nil {
+				conflicts[hash] = make(map[partialInput]bool)
+			}
+			conflicts[hash][pi] = true
+		}
+	}
+	for hash, spent := range conflicts {
+		utils.Debug.Printf("[wallet.handleConflicts] %v conflicts with unseen transaction %v", tx.Hash(), hash)
+		var released []CoinInfo
+		for _, ci := range w.removeUnseen(hash) {
+			if spent[partialInput{ci.ReferenceTransactionHash, ci.OutputIndex}] {
+				w.UnconfirmedSpentCoins[ci] = 0
+			} else {
+				released = append(released, ci)
+			}
+		}
+		w.releaseCoins(released)
+	}
+}
+
+// expireUnseen abandons every transaction that has gone unseen for
+// the Config's UnseenExpiry blocks.
+func (w *Wallet) expireUnseen() {
+	if w.Config.UnseenExpiry == 0 {
+		return
+	}
+	for _, st := range w.stuckTransactions(w.Config.UnseenExpiry) {
+		utils.Debug.Printf("[wallet.expireUnseen] abandoning %v after %v blocks unseen", st.TransactionHash, st.Age)
+		w.releaseCoins(w.removeUnseen(st.TransactionHash))
+	}
+}
+
+// HandleBroadcastFailure is called when the node gives up on
+// broadcasting a transaction the wallet requested. If it still hasn't
+// been seen in a block, it is abandoned, so its coins can be spent again.
+func (w *Wallet) HandleBroadcastFailure(tx *block.Transaction) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	if _, ok := w.UnseenSpentCoins[tx.Hash()]; !ok {
+		return
+	}
+	utils.Debug.Printf("[wallet.HandleBroadcastFailure] abandoning %v, which could not be broadcast", tx.NameTag())
+	w.releaseCoins(w.removeUnseen(tx.Hash()))
+}
@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/id"
+	"Coin/pkg/pro"
+	"Coin/pkg/script"
+	"Coin/pkg/utils"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// VaultCoin is an output locked by a Vault script that the wallet owns.
+// Vault is the output's locking script.
+// Seen is whether the wallet has seen the output in a block.
+// Confirmations is how many blocks have been seen on top of that block.
+type VaultCoin struct {
+	CoinInfo
+	Vault         *script.Vault
+	Seen          bool
+	Confirmations uint32
+}
+
+// vaultKey returns the key of a VaultCoin in the wallet's Vaults.
+func vaultKey(hash string, index uint32) string {
+	return fmt.Sprintf("%v:%v", hash, index)
+}
+
+// GetVaultCoin returns the wallet's VaultCoin for an output,
+// or nil if the wallet does not have a vault there.
+func (w *Wallet) GetVaultCoin(hash string, index uint32) *VaultCoin {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.vaultCoin(hash, index)
+}
+
+// vaultCoin is GetVaultCoin. The caller must hold the mutex.
+func (w *Wallet) vaultCoin(hash string, index uint32) *VaultCoin {
+	return w.Vaults[vaultKey(hash, index)]
+}
+
+// CreateVaultDeposit moves amount of the wallet's coins into a vault,
+// which recoveryPK can sweep at any time, but which the wallet can only
+// withdraw from delay blocks after starting a withdrawal.
+func (w *Wallet) CreateVaultDeposit(amount uint32, fee uint32, recoveryPK []byte, delay uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	if w.Balance < amount+fee {
+		utils.Debug.Printf("%v did not have a large enough balance to make the vault deposit\n"+
+			"Balance: %v\nDeposit cost: %v", utils.FmtAddr(w.Address), w.Balance, amount+fee)
+		return nil
+	}
+	change, inputs, coinInfos := w.generateTransactionInputs(amount, fee, w.coinSelector())
+	if coinInfos == nil {
+		utils.Debug.Printf("[wallet.CreateVaultDeposit] coinInfos were nil")
+		return nil
+	}
+	v := &script.Vault{
+		ScriptType:        script.VAULT,
+		OwnerPublicKey:    w.Id.GetPublicKeyBytes(),
+		RecoveryPublicKey: recoveryPK,
+		DelayBlocks:       delay,
+	}
+	vaultScript, err := proto.Marshal(script.EncodeVault(v))
+	if err != nil {
+		utils.Debug.Printf("[wallet.CreateVaultDeposit] Failed to marshal vault script")
+		return nil
+	}
+	outputs := []*block.TransactionOutput{{Amount: amount, LockingScript: vaultScript}}
+	if change != 0 {
+		myScript, err2 := proto.Marshal(&pro.PayToPublicKey{PublicKey: w.changeKey()})
+		if err2 != nil {
+			utils.Debug.Printf("[wallet.CreateVaultDeposit] Failed to marshal change script")
+			return nil
+		}
+		outputs = append(outputs, &block.TransactionOutput{Amount: change, LockingScript: myScript})
+	}
+	tx := &block.Transaction{
+		Version:  w.Config.TransactionVersion,
+		Inputs:   inputs,
+		Outputs:  outputs,
+		LockTime: w.Config.DefaultLockTime,
+	}
+	w.addUnseen(tx.Hash(), coinInfos)
+	for _, ci := range coinInfos {
+		delete(w.CoinCollection, ci)
+	}
+	w.Balance -= amount + fee + change
+	w.addVaultCoin(tx, v)
+	w.broadcast(tx)
+	return tx
+}
+
+// InitiateVaultWithdrawal starts withdrawing from a vault by moving it
+// into an unvaulting vault. The withdrawal can be completed with
+// CompleteVaultWithdrawal once the vault's delay has passed, or stopped
+// by the recovery key with CancelVaultWithdrawal before then.
+func (w *Wallet) InitiateVaultWithdrawal(hash string, index uint32, fee uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	vc := w.vaultCoin(hash, index)
+	if vc == nil || vc.Vault.Unvaulting || !vc.Seen {
+		utils.Debug.Printf("[wallet.InitiateVaultWithdrawal] no confirmed vault at {%v}", vaultKey(hash, index))
+		return nil
+	}
+	next := *vc.Vault
+	next.Unvaulting = true
+	nextScript, err := proto.Marshal(script.EncodeVault(&next))
+	if err != nil {
+		utils.Debug.Printf("[wallet.InitiateVaultWithdrawal] Failed to marshal vault script")
+		return nil
+	}
+	tx := w.spendVaultCoin(vc, w.Id, fee, nextScript)
+	if tx != nil {
+		w.addVaultCoin(tx, &next)
+	}
+	return tx
+}
+
+// CompleteVaultWithdrawal sends the funds of an unvaulting vault back to
+// the wallet, once the vault's delay has passed.
+func (w *Wallet) CompleteVaultWithdrawal(hash string, index uint32, fee uint32) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	vc := w.vaultCoin(hash, index)
+	if vc == nil || !vc.Vault.Unvaulting {
+		utils.Debug.Printf("[wallet.CompleteVaultWithdrawal] no withdrawal in progress at {%v}", vaultKey(hash, index))
+		return nil
+	}
+	myScript, err := proto.Marshal(&pro.PayToPublicKey{PublicKey: w.Id.GetPublicKeyBytes()})
+	if err != nil {
+		utils.Debug.Printf("[wallet.CompleteVaultWithdrawal] Failed to marshal script")
+		return nil
+	}
+	return w.spendVaultCoin(vc, w.Id, fee, myScript)
+}
+
+// CancelVaultWithdrawal uses the recovery key to sweep a vault, whether
+// or not a withdrawal is in progress, to the recovery key's own address.
+func (w *Wallet) CancelVaultWithdrawal(hash string, index uint32, fee uint32, recovery id.ID) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
+	vc := w.vaultCoin(hash, index)
+	if vc == nil {
+		utils.Debug.Printf("[wallet.CancelVaultWithdrawal] no vault at {%v}", vaultKey(hash, index))
+		return nil
+	}
+	recoveryScript, err := proto.Marshal(&pro.PayToPublicKey{PublicKey: recovery.GetPublicKeyBytes()})
+	if err != nil {
+		utils.Debug.Printf("[wallet.CancelVaultWithdrawal] Failed to marshal script")
+		return nil
+	}
+	return w.spendVaultCoin(vc, recovery, fee, recoveryScript)
+}
+
+// spendVaultCoin creates and broadcasts a Transaction that spends a
+// VaultCoin, signed by signer, into a single output locked by
+// lockingScript. It returns nil if the vault does not allow the spend.
+func (w *Wallet) spendVaultCoin(vc *VaultCoin, signer id.ID, fee uint32, lockingScript []byte) *block.Transaction {
+	if vc.TransactionOutput.Amount <= fee {
+		utils.Debug.Printf("[wallet.spendVaultCoin] vault is too small to pay a fee of %v", fee)
+		return nil
+	}
+	err := script.CheckVaultSpend(vc.Vault, signer.GetPublicKeyBytes(), vc.Confirmations, [][]byte{lockingScript})
+	if err != nil {
+		utils.Debug.Printf("%v", err)
+		return nil
+	}
+	unlockingScript, err := vc.TransactionOutput.MakeSignature(signer)
+	if err != nil {
+		utils.Debug.Printf("[wallet.spendVaultCoin] Failed to create unlockingScript")
+		return nil
+	}
+	tx := &block.Transaction{
+		Version: w.Config.TransactionVersion,
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: vc.ReferenceTransactionHash,
+			OutputIndex:              vc.OutputIndex,
+			UnlockingScript:          unlockingScript,
+		}},
+		Outputs:  []*block.TransactionOutput{{Amount: vc.TransactionOutput.Amount - fee, LockingScript: lockingScript}},
+		LockTime: w.Config.DefaultLockTime,
+	}
+	delete(w.Vaults, vaultKey(vc.ReferenceTransactionHash, vc.OutputIndex))
+	w.broadcast(tx)
+	return tx
+}
+
+// addVaultCoin starts tracking the first output of tx, which is
+// locked by v, as one of the wallet's vaults.
+func (w *Wallet) addVaultCoin(tx *block.Transaction, v *script.Vault) {
+	hash := tx.Hash()
+	w.Vaults[vaultKey(hash, 0)] = &VaultCoin{
+		CoinInfo: CoinInfo{
+			ReferenceTransactionHash: hash,
+			OutputIndex:              0,
+			TransactionOutput:        tx.Outputs[0],
+		},
+		Vault: v,
+	}
+}
+
+// updateVaults marks vaults created by tx as seen.
+func (w *Wallet) updateVaults(tx *block.Transaction) {
+	hash := tx.Hash()
+	for i := range tx.Outputs {
+		if vc, ok := w.Vaults[vaultKey(hash, uint32(i))]; ok {
+			vc.Seen = true
+		}
+	}
+}
+
+// broadcast sends a Transaction to the node, which
+// propagates it along the P2P network.
+func (w *Wallet) broadcast(tx *block.Transaction) {
+	go func() {
+		w.TransactionRequests <- tx
+	}()
+}
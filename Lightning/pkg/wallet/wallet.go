@@ -9,6 +9,8 @@ import (
 	"bytes"
 	"fmt"
 	"google.golang.org/protobuf/proto"
+	"sync"
+	"time"
 )
 
 // CoinInfo holds the information about a TransactionOutput
@@ -39,6 +41,59 @@ type CoinInfo struct {
 // UnconfirmedReceivedCoins is a mapping of CoinInfos to number of confirmations
 // (which are integers). We can't confirm we've received a Coin until
 // we've seen enough POW on top the block containing our received transaction.
+//
+// History is every transaction the wallet has requested, oldest first,
+// along with any memo attached to it.
+//
+// Vaults are the outputs locked by Vault scripts that the wallet owns,
+// keyed by "hash:index". They are not part of the Balance.
+//
+// Swaps are the outputs locked by Swap scripts that the wallet is the
+// sender or the recipient of, keyed by "hash:index". They are not part
+// of the Balance either.
+//
+// seed is what the wallet's keys are derived from, and chains are the
+// ReceiveChain and ChangeChain keys they are derived below (see
+// initKeys). chainKeys are the keys derived on each chain so far, by
+// index, and nextIndexes the index of the next unused key on each.
+// keys are every key the wallet can spend the coins of, derived or
+// not, by public key.
+//
+// blocksSeen is how many blocks the wallet has handled, and
+// unseenSince is how many it had handled when each transaction in
+// UnseenSpentCoins was requested, so stuck ones can be expired.
+// unseenTxs are the transactions in UnseenSpentCoins that pay for
+// requests or consolidations, so their fees can be bumped.
+// unseenChange is the index of the output of each of unseenTxs that
+// pays the wallet its change, if it has one, so a higher fee comes
+// out of the change rather than a payment to one of the wallet's
+// own keys.
+// receivedAt is how many it had handled when each coin was received,
+// so the age of coins can be reported.
+//
+// mutex guards the wallet's state, and its saves, since the node calls
+// the wallet from more than one go routine. Exported methods take it,
+// so they never call one another.
+//
+// Replacements receives the transactions the wallet rebuilds to pay a
+// higher fee (see BumpFee), for the node to broadcast in place of the
+// transactions they replace. The node's main loop consumes it. It
+// holds up to replacementsBuffer of them, and BumpFee refuses to bump
+// a fee while it's full.
+//
+// ConsolidationDue receives the time whenever the wallet's consolidation
+// scheduler thinks it may be time to consolidate.
+// FeeEstimator returns the fee the wallet should expect to pay. If it is
+// nil, the wallet asks Fees, or assumes the Config's DefaultFee if Fees
+// have nothing to go on.
+// Fees estimates the feerates that get transactions mined in time, from
+// the blocks and mempool the node shows it.
+// LookupPreimage returns the preimage of a payment hash, if the wallet
+// should claim swaps locked to it. If it is nil, swaps are only claimed
+// with ClaimSwap.
+// OnSwapPreimage is called with a swap's payment hash and preimage
+// when the swap is claimed, whether by the wallet or by the recipient
+// of a swap the wallet locked.
 type Wallet struct {
 	Config              *Config
 	Id                  id.ID
@@ -55,6 +110,34 @@ type Wallet struct {
 	// Seen but not confirmed
 	UnconfirmedSpentCoins    map[CoinInfo]uint32
 	UnconfirmedReceivedCoins map[CoinInfo]uint32
+
+	History []*HistoryEntry
+
+	Vaults map[string]*VaultCoin
+	Swaps  map[string]*SwapCoin
+
+	Replacements chan *Replacement
+
+	ConsolidationDue  chan time.Time
+	FeeEstimator      func() uint32
+	Fees              *FeeEstimator
+	LookupPreimage    func(paymentHash []byte) []byte
+	OnSwapPreimage    func(paymentHash []byte, preimage []byte)
+	stopConsolidation chan bool
+
+	seed        []byte
+	chains      [2]*id.ExtendedKey
+	chainKeys   [2][]id.ID
+	nextIndexes [2]uint32
+	keys        map[string]*ownedKey
+
+	blocksSeen   uint32
+	unseenSince  map[string]uint32
+	unseenTxs    map[string]*block.Transaction
+	unseenChange map[string]uint32
+	receivedAt   map[CoinInfo]uint32
+
+	mutex sync.Mutex
 }
 
 // SetAddress sets the address
@@ -63,12 +146,26 @@ func (w *Wallet) SetAddress(a string) {
 	w.Address = a
 }
 
-// New creates a wallet object
+// StateVersion returns how many blocks the wallet has handled. It
+// changes whenever the wallet's view of the chain does, so it tells
+// which blocks a saved copy of the wallet's state has seen.
+func (w *Wallet) StateVersion() uint32 {
+	if w == nil {
+		return 0
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.blocksSeen
+}
+
+// New creates a wallet object, deriving its keys from the Config's
+// Seed, or from a new seed, and picking up the state persisted at the
+// Config's StatePath, if there is one.
 func New(config *Config, id id.ID) *Wallet {
 	if !config.HasWallet {
 		return nil
 	}
-	return &Wallet{
+	w := &Wallet{
 		Config:                   config,
 		Id:                       id,
 		TransactionRequests:      make(chan *block.Transaction),
@@ -77,28 +174,67 @@ func New(config *Config, id id.ID) *Wallet {
 		UnseenSpentCoins:         make(map[string][]CoinInfo),
 		UnconfirmedSpentCoins:    make(map[CoinInfo]uint32),
 		UnconfirmedReceivedCoins: make(map[CoinInfo]uint32),
+		History:                  loadHistory(config.HistoryPath),
+		Vaults:                   make(map[string]*VaultCoin),
+		Swaps:                    make(map[string]*SwapCoin),
+		Replacements:             make(chan *Replacement, replacementsBuffer),
+		ConsolidationDue:         make(chan time.Time),
+		Fees:                     NewFeeEstimator(config.FeeHistoryBlocks),
+		stopConsolidation:        make(chan bool),
+		unseenSince:              make(map[string]uint32),
+		unseenTxs:                make(map[string]*block.Transaction),
+		unseenChange:             make(map[string]uint32),
+		receivedAt:               make(map[CoinInfo]uint32),
+		keys:                     map[string]*ownedKey{string(id.GetPublicKeyBytes()): {ID: id}},
+	}
+	seed := config.Seed
+	var err error
+	if seed == nil {
+		seed, err = newSeed()
+	}
+	if err == nil {
+		err = w.initKeys(seed)
+	}
+	if err != nil {
+		utils.Debug.Printf("%v", err)
 	}
+	if err := w.load(); err != nil {
+		utils.Debug.Printf("%v", err)
+	}
+	return w
 }
 
-// generateTransactionInputs creates the transaction inputs required to make a transaction.
-// In addition to the inputs, it returns the amount of change the wallet holder should
-// return to themselves, and the coinInfos used
-func (w *Wallet) generateTransactionInputs(amount uint32, fee uint32) (uint32, []*block.TransactionInput, []CoinInfo) {
-	// the inputs that we will eventually be returning
-	var inputs []*block.TransactionInput
+// generateTransactionInputs creates the transaction inputs required to make a transaction,
+// spending the coins selector picks. In addition to the inputs, it returns the amount of
+// change the wallet holder should return to themselves, and the coinInfos used, which are
+// nil if the coins aren't worth enough.
+func (w *Wallet) generateTransactionInputs(amount uint32, fee uint32, selector CoinSelector) (uint32, []*block.TransactionInput, []CoinInfo) {
+	var coins []CoinInfo
+	for coinInfo := range w.CoinCollection {
+		coins = append(coins, coinInfo)
+	}
 	// the coinInfos that we're using
-	var coinInfos []CoinInfo
-	// the total amount of the coins that we've used so far for our inputs
+	coinInfos := selector.SelectCoins(coins, amount+fee)
+	if len(coinInfos) == 0 {
+		return 0, nil, nil
+	}
+	// the total amount of the coins that we've used for our inputs
 	total := uint32(0)
-	// Now that we know our balance is enough, we can loop through our coins until we've reached
-	// a large enough total to meet our amount and fee
-	for coinInfo, _ := range w.CoinCollection {
-		if total >= amount+fee {
-			break
-		}
+	for _, coinInfo := range coinInfos {
+		total += coinInfo.TransactionOutput.Amount
+	}
+	change := total - (amount + fee)
+	return change, w.signInputs(coinInfos), coinInfos
+}
+
+// signInputs generates the transaction inputs that spend coinInfos.
+func (w *Wallet) signInputs(coinInfos []CoinInfo) []*block.TransactionInput {
+	// the inputs that we will eventually be returning
+	var inputs []*block.TransactionInput
+	for _, coinInfo := range coinInfos {
 		// have to generate the unlockingScripts so that we can prove we have the ability to spend
 		// this coin
-		unlockingScript, err := coinInfo.TransactionOutput.MakeSignature(w.Id)
+		unlockingScript, err := coinInfo.TransactionOutput.MakeSignature(w.signer(coinInfo.TransactionOutput))
 		if err != nil {
 			utils.Debug.Printf("[generateTransactionInputs] Error: failed to create unlockingScript\n")
 		}
@@ -108,44 +244,41 @@ func (w *Wallet) generateTransactionInputs(amount uint32, fee uint32) (uint32, [
 			OutputIndex:              coinInfo.OutputIndex,
 			UnlockingScript:          unlockingScript,
 		}
-		coinInfos = append(coinInfos, coinInfo)
 		inputs = append(inputs, txi)
-		total += coinInfo.TransactionOutput.Amount
 	}
-	change := total - (amount + fee)
-	return change, inputs, coinInfos
+	return inputs
 }
 
-// generateTransactionOutputs generates the transaction outputs required to create a transaction.
-func (w *Wallet) generateTransactionOutputs(
-	amount uint32,
-	receiverPK []byte,
-	change uint32,
-) []*block.TransactionOutput {
-	// make sure that the public key we're sending our amount to is valid
-	if receiverPK == nil || len(receiverPK) == 0 {
-		utils.Debug.Printf("[generateTransactionOutputs] Error: receiver's public key is invalid")
-		return nil
-	}
+// generateTransactionOutputs generates the transaction outputs required to create a transaction,
+// one for each payment, in order, followed by the change, if there is any.
+func (w *Wallet) generateTransactionOutputs(payments []Payment, change uint32) []*block.TransactionOutput {
 	// the outputs that we will eventually return
 	var outputs []*block.TransactionOutput
-	// the output for the person we're sending this transaction output to
-	myScript := &pro.PayToPublicKey{PublicKey: w.Id.GetPublicKeyBytes()}
-	myScriptB, err := proto.Marshal(myScript)
-	if err != nil {
-		myScriptB = []byte{}
-		fmt.Printf("[wallet.generateTransactionOutputs] Failed to marshal script")
-	}
-	theirScript := &pro.PayToPublicKey{PublicKey: receiverPK}
-	theirScriptB, err2 := proto.Marshal(theirScript)
-	if err2 != nil {
-		theirScriptB = []byte{}
-		fmt.Printf("[wallet.generateTransactionOutputs] Failed to marshal script")
-	}
-	txoSending := &block.TransactionOutput{Amount: amount, LockingScript: theirScriptB}
-	outputs = append(outputs, txoSending)
-	// if there's change, we should send that back to ourselves.
+	for _, p := range payments {
+		// make sure that the public key we're sending our amount to is valid
+		if len(p.RecipientPK) == 0 {
+			utils.Debug.Printf("[generateTransactionOutputs] Error: receiver's public key is invalid")
+			return nil
+		}
+		// the output for the person we're sending this transaction output to
+		theirScript := &pro.PayToPublicKey{PublicKey: p.RecipientPK}
+		theirScriptB, err2 := proto.Marshal(theirScript)
+		if err2 != nil {
+			theirScriptB = []byte{}
+			fmt.Printf("[wallet.generateTransactionOutputs] Failed to marshal script")
+		}
+		txoSending := &block.TransactionOutput{Amount: p.Amount, LockingScript: theirScriptB}
+		outputs = append(outputs, txoSending)
+	}
+	// if there's change, we should send that back to ourselves, to a
+	// key we've never used before
 	if change != 0 {
+		myScript := &pro.PayToPublicKey{PublicKey: w.changeKey()}
+		myScriptB, err := proto.Marshal(myScript)
+		if err != nil {
+			myScriptB = []byte{}
+			fmt.Printf("[wallet.generateTransactionOutputs] Failed to marshal script")
+		}
 		txoChange := &block.TransactionOutput{Amount: change, LockingScript: myScriptB}
 		outputs = append(outputs, txoChange)
 	}
@@ -153,55 +286,45 @@ func (w *Wallet) generateTransactionOutputs(
 }
 
 // RequestTransaction allows the wallet to send a transaction to the node,
-// which will propagate the transaction along the P2P network.
-func (w *Wallet) RequestTransaction(amount uint32, fee uint32, recipientPK []byte) *block.Transaction {
-	// have to ensure that we have enough money to actually make this transaction
-	if w.Balance < amount+fee {
-		utils.Debug.Printf("%v did not have a large enough balance to make the requested transaction\n"+
-			"Balance: %v\nTransaction cost: %v", utils.FmtAddr(w.Address), w.Balance, amount+fee)
-		return nil
-	}
-	change, inputs, coinInfos := w.generateTransactionInputs(amount, fee)
-	if coinInfos == nil {
-		utils.Debug.Printf("[wallet.RequestTransaction] coinInfos were nil")
-		return nil
-	}
-	outputs := w.generateTransactionOutputs(amount, recipientPK, change)
-	tx := &block.Transaction{
-		Version:  0,
-		Inputs:   inputs,
-		Outputs:  outputs,
-		LockTime: 0,
-	}
-	// now that we have the transaction, we can add the coinInfos to our UnseenSpentCoins
-	// and temporarily remove from the CoinCollection
-	w.UnseenSpentCoins[tx.Hash()] = coinInfos
-	for _, ci := range coinInfos {
-		delete(w.CoinCollection, ci)
-	}
-	// if we want to broadcast, send to the channel that the node monitors
-	go func() {
-		w.TransactionRequests <- tx
-	}()
-	// we do this here in case generateTransactionInputs doesn't work
-	// have to make sure that the balance is decremented so that the wallet owner can't keep spamming their coin
-	coinTotals := amount + fee + change
-	w.Balance -= coinTotals
-	return tx
+// which will propagate the transaction along the P2P network. The memo,
+// which may be nil, is recorded alongside the transaction in the History.
+// The coins it spends are picked by the Config's CoinSelection.
+func (w *Wallet) RequestTransaction(amount uint32, fee uint32, recipientPK []byte, memo *Memo) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.requestPayments([]Payment{{RecipientPK: recipientPK, Amount: amount}}, fee, memo, w.coinSelector())
+}
+
+// RequestTransactionWithSelector is RequestTransaction, spending the
+// coins selector picks.
+func (w *Wallet) RequestTransactionWithSelector(amount uint32, fee uint32, recipientPK []byte, memo *Memo,
+	selector CoinSelector) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.requestPayments([]Payment{{RecipientPK: recipientPK, Amount: amount}}, fee, memo, selector)
 }
 
 // HandleBlock handles the transactions of a new block. It:
-// (1) sees if any of the inputs are ones that we've spent
+// (1) sees if any of the inputs are ones that we've spent, or that
+// spend our coins without our knowing
 // (2) sees if any of the incoming outputs on the block are ours
 // (3) updates our unconfirmed coins, since we've just gotten
 // another confirmation!
 func (w *Wallet) HandleBlock(txs []*block.Transaction) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
 	// most of the time, we will just be handling the transactions
 	for _, tx := range txs {
 		// see if this is a transaction we've spent a coin on
 		if _, ok := w.UnseenSpentCoins[tx.Hash()]; ok {
 			w.handleSeenCoins(tx.Hash())
+		} else {
+			w.handleConflicts(tx)
+			w.handleSpentElsewhere(tx)
 		}
+		w.updateVaults(tx)
+		w.updateSwaps(tx)
 		// check outputs to see if they contain any coins for us
 		for i, txo := range tx.Outputs {
 			pK := &pro.PayToPublicKey{}
@@ -210,12 +333,19 @@ func (w *Wallet) HandleBlock(txs []*block.Transaction) {
 				fmt.Printf("[wallet.HandleBlock] Failed to unmarshal")
 				continue
 			}
-			if bytes.Equal(pK.GetPublicKey(), w.Id.GetPublicKeyBytes()) {
+			// vaults and swaps are tracked separately, since they can't be spent freely
+			if pK.GetScriptType() == pro.ScriptType_VAULT || pK.GetScriptType() == pro.ScriptType_SWAP {
+				continue
+			}
+			if w.ownsKey(pK.GetPublicKey()) {
 				w.addCoin(tx.Hash(), uint32(i), txo)
 			}
 		}
 	}
 	w.updateConfirmations()
+	w.settleSwaps()
+	w.blocksSeen++
+	w.expireUnseen()
 }
 
 // addCoin adds a received coin to our UnconfirmedReceivedCoins
@@ -226,9 +356,22 @@ func (w *Wallet) addCoin(hash string, index uint32, output *block.TransactionOut
 		TransactionOutput:        output,
 	}
 	w.UnconfirmedReceivedCoins[coinInfo] = 0
+	w.receivedAt[coinInfo] = w.blocksSeen
 }
 
 func (w *Wallet) updateConfirmations() {
+	// update vaults, whose withdrawals are timed in confirmations
+	for _, vc := range w.Vaults {
+		if vc.Seen {
+			vc.Confirmations++
+		}
+	}
+	// and swaps, whose refunds are too
+	for _, sc := range w.Swaps {
+		if sc.Seen {
+			sc.Confirmations++
+		}
+	}
 	// update unconfirmed spent coins
 	for coinInfo, numConfirmations := range w.UnconfirmedSpentCoins {
 		if numConfirmations == w.Config.SafeBlockAmount {
@@ -236,6 +379,7 @@ func (w *Wallet) updateConfirmations() {
 			// coin from our coin collection. It's been spent!
 			delete(w.CoinCollection, coinInfo)
 			delete(w.UnconfirmedSpentCoins, coinInfo)
+			delete(w.receivedAt, coinInfo)
 		} else {
 			// otherwise, we still have to wait :(
 			w.UnconfirmedSpentCoins[coinInfo] = numConfirmations + 1
@@ -260,10 +404,9 @@ func (w *Wallet) updateConfirmations() {
 // handleSeenCoins moves coins from UnseenSpentCoins to
 // UnconfirmedSpentCoins
 func (w *Wallet) handleSeenCoins(hash string) {
-	seenCoins, _ := w.UnseenSpentCoins[hash]
 	// remove from unseen, since we've now seen our
 	// transaction in a block
-	delete(w.UnseenSpentCoins, hash)
+	seenCoins := w.removeUnseen(hash)
 	// move the seen coins over to unconfirmed
 	for _, coinInfo := range seenCoins {
 		w.UnconfirmedSpentCoins[coinInfo] = 0
@@ -278,6 +421,9 @@ type partialInput struct {
 
 // HandleFork handles a fork, updating the wallet's relevant fields.
 func (w *Wallet) HandleFork(blocks []*block.Block) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
 	// get the coins that we need to check
 	txis := map[partialInput]CoinInfo{}
 	// fill txis with partial inputs
@@ -312,7 +458,7 @@ func (w *Wallet) HandleFork(blocks []*block.Block) {
 			}
 			// actually add them back to the wallet's map
 			for key, val := range unseen {
-				w.UnseenSpentCoins[key] = val
+				w.addUnseen(key, val)
 			}
 			for _, txo := range tx.Outputs {
 				pK := &pro.PayToPublicKey{}
@@ -320,8 +466,8 @@ func (w *Wallet) HandleFork(blocks []*block.Block) {
 				if err != nil {
 					fmt.Printf("[wallet.HandleFork] Failed to unmarshal")
 				}
-				if bytes.Equal(pK.GetPublicKey(), w.Id.GetPublicKeyBytes()) {
-					w.RemoveFromUnconfirmed(txo)
+				if w.ownsKey(pK.GetPublicKey()) {
+					w.removeFromUnconfirmed(txo)
 
 				}
 			}
@@ -329,7 +475,17 @@ func (w *Wallet) HandleFork(blocks []*block.Block) {
 	}
 }
 
+// RemoveFromUnconfirmed forgets the coin txo is the output of, if the
+// wallet is waiting on confirmations to receive it.
 func (w *Wallet) RemoveFromUnconfirmed(txo *block.TransactionOutput) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.removeFromUnconfirmed(txo)
+}
+
+// removeFromUnconfirmed is RemoveFromUnconfirmed. The caller must hold
+// the mutex.
+func (w *Wallet) removeFromUnconfirmed(txo *block.TransactionOutput) {
 	for ci, pri := range w.UnconfirmedReceivedCoins {
 		if txo == ci.TransactionOutput && pri < w.Config.SafeBlockAmount {
 			delete(w.UnconfirmedReceivedCoins, ci)
@@ -391,8 +547,11 @@ func (w *Wallet) HandleRevokedOutput(hash string, txo *block.TransactionOutput,
 // GenerateFundingTransaction is very similar to RequestTransaction, except it does NOT broadcast to the node.
 // Also, the outputs are slightly different.
 func (w *Wallet) GenerateFundingTransaction(amount uint32, fee uint32, counterparty []byte) *block.Transaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer w.save()
 	total := amount + fee
-	change, inputs, coinInfos := w.generateTransactionInputs(total, fee)
+	change, inputs, coinInfos := w.generateTransactionInputs(total, fee, w.coinSelector())
 	tmp := []*block.TransactionOutput{}
 
 	multi := &pro.MultiParty{
@@ -438,7 +597,7 @@ func (w *Wallet) GenerateFundingTransaction(amount uint32, fee uint32, counterpa
 		delete(w.CoinCollection, c)
 		tx := trans.Hash()
 		// UnseenSpentCoins map[string][]CoinInfo
-		w.UnseenSpentCoins[tx] = append(w.UnseenSpentCoins[tx], c) // add coin c to the UnseenSpentCoins 
+		w.addUnseen(tx, append(w.UnseenSpentCoins[tx], c)) // add coin c to the UnseenSpentCoins
 		if w.Balance < c.TransactionOutput.Amount{
 			w.Balance = 0
 		} else {
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"Coin/pkg/address"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/pro"
+	"Coin/pkg/profiling"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAdminRPCs(t *testing.T) {
+	n := NewGenesisNode()
+	n.Config.AdminPort = GetFreePort()
+	n.Config.ProfilingConfig.MaxDuration = 50 * time.Millisecond
+	defer CleanUp([]*blockchain.BlockChain{n.BlockChain})
+	n.Start()
+	defer n.Kill()
+	peer := address.New(n.Address, 0)
+	admin := address.New(fmt.Sprintf("127.0.0.1:%v", n.Config.AdminPort), 0)
+
+	// peers are refused, and the operator reaches the chain
+	_, err := peer.ConfirmReorgRPC(&pro.BlockHashRequest{BlockHash: "missing"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a peer to be refused ConfirmReorg, got %v", err)
+	}
+	_, err = admin.ConfirmReorgRPC(&pro.BlockHashRequest{BlockHash: "missing"})
+	if err == nil || status.Code(err) == codes.PermissionDenied {
+		t.Errorf("Expected the operator's ConfirmReorg to reach the chain, got %v", err)
+	}
+	for _, rpc := range []func(*pro.BlockHashRequest) (*pro.BlockTip, error){peer.InvalidateBlockRPC, peer.ReconsiderBlockRPC} {
+		if _, err = rpc(&pro.BlockHashRequest{BlockHash: n.BlockChain.LastHash}); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected a peer to be refused invalidating or reconsidering blocks, got %v", err)
+		}
+	}
+	_, err = admin.InvalidateBlockRPC(&pro.BlockHashRequest{BlockHash: "missing"})
+	if err == nil || status.Code(err) == codes.PermissionDenied {
+		t.Errorf("Expected the operator's InvalidateBlock to reach the chain, got %v", err)
+	}
+	if _, err = peer.LoadBlocksRPC(&pro.LoadBlocksRequest{Path: "/"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a peer to be refused loading blocks, got %v", err)
+	}
+	if _, err = peer.CaptureProfileRPC(&pro.ProfileRequest{Kind: profiling.Heap}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a peer to be refused profiling, got %v", err)
+	}
+	// a long capture is cut short
+	start := time.Now()
+	if _, err = admin.CaptureProfileRPC(&pro.ProfileRequest{Kind: profiling.CPU, Seconds: 3600}); err != nil {
+		t.Errorf("Expected the operator to capture a profile, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the capture to stop at the MaxDuration, took %v", time.Since(start))
+	}
+	// peers still reach everything else
+	if _, err = peer.GetNodeStatusRPC(&pro.Empty{}); err != nil {
+		t.Errorf("Expected a peer to get the node status, got %v", err)
+	}
+}
+
+func TestUtxoDeltaOnlyForTrustedHosts(t *testing.T) {
+	n := NewGenesisNode()
+	defer CleanUp([]*blockchain.BlockChain{n.BlockChain})
+	n.Config.TrustedPeers = map[string]bool{"10.0.0.1:8000": true, "127.0.0.1:8000": true}
+	n.Start()
+	defer n.Kill()
+	peer := address.New(n.Address, 0)
+
+	// a peer claiming a trusted address from another host is refused
+	if _, err := peer.GetUtxoDeltaRPC(&pro.UtxoDeltaRequest{AddrMe: "10.0.0.1:8000", StartHeight: 0, EndHeight: 1}); err == nil {
+		t.Errorf("Expected a request from another host than the trusted peer's to be refused")
+	}
+	if _, err := peer.GetUtxoDeltaRPC(&pro.UtxoDeltaRequest{AddrMe: "127.0.0.1:8000", StartHeight: 0, EndHeight: 1}); err != nil {
+		t.Errorf("Expected the trusted peer's host to get the delta, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package test
+
+import (
+	"Coin/pkg/backup"
+	"Coin/pkg/blockchain"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBackupAllAndRotate(t *testing.T) {
+	node := NewGenesisNode()
+	defer CleanUp([]*blockchain.BlockChain{node.BlockChain})
+	node.Config.BackupConfig.Iterations = 1000
+	FillWalletWithCoins(node.Wallet, 3, 10)
+	path := node.Config.BackupConfig.Path
+
+	if err := node.BackupAll("first passphrase"); err != nil {
+		t.Fatalf("failed to back up the node: %v", err)
+	}
+	a, err := backup.Open(path, "first passphrase")
+	if err != nil {
+		t.Fatalf("failed to open the backup: %v", err)
+	}
+	if !bytes.Equal(a.NodeKeys.PrivateKey, node.Id.GetPrivateKeyBytes()) {
+		t.Errorf("backup has the wrong node private key")
+	}
+	if !bytes.Equal(a.LightningKeys.PublicKey, node.LightningNode.Id.GetPublicKeyBytes()) {
+		t.Errorf("backup has the wrong lightning public key")
+	}
+	AssertSize(t, 3, len(a.Wallet.Coins))
+	if a.Wallet.Balance != node.Wallet.Balance {
+		t.Errorf("expected a balance of %v in the backup, got %v", node.Wallet.Balance, a.Wallet.Balance)
+	}
+
+	if _, err = backup.Open(path, "wrong passphrase"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected opening with the wrong passphrase to fail, got %v", err)
+	}
+
+	if err = backup.Rotate(path, "wrong passphrase", "second passphrase", 1000); err == nil {
+		t.Errorf("expected rotating with the wrong passphrase to fail")
+	}
+	if err = backup.Rotate(path, "first passphrase", "second passphrase", 1000); err != nil {
+		t.Fatalf("failed to rotate the backup: %v", err)
+	}
+	if _, err = backup.Open(path, "first passphrase"); err == nil {
+		t.Errorf("expected the old passphrase to stop working after rotating")
+	}
+	rotated, err := backup.Open(path, "second passphrase")
+	if err != nil {
+		t.Fatalf("failed to open the rotated backup: %v", err)
+	}
+	if rotated.CreatedAt != a.CreatedAt || len(rotated.Wallet.Coins) != len(a.Wallet.Coins) {
+		t.Errorf("rotating changed the backup's contents")
+	}
+
+	wallet := CreateMockedWallet()
+	wallet.Restore(rotated.Wallet)
+	AssertBalance(t, wallet, node.Wallet.Balance)
+	AssertSize(t, 3, len(wallet.CoinCollection))
+}
@@ -0,0 +1,2817 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/blockinfodatabase"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/id"
+	"Coin/pkg/script"
+	"Coin/pkg/utils"
+	"bytes"
+	"fmt"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testConfig returns the default BlockChain Config with the
+// testDifficultyTarget, whose databases live in paths that CleanUp
+// removes when the chain is passed at index i.
+func testConfig(i int) *blockchain.Config {
+	config := blockchain.DefaultConfig()
+	config.BlockInfoDBPath = "blockinfodata" + strconv.Itoa(i)
+	config.CoinDBPath = "coindata" + strconv.Itoa(i)
+	config.ChainWriterDBPath = "data" + strconv.Itoa(i)
+	config.DifficultyTarget = testDifficultyTarget
+	return config
+}
+
+// newTestBlockChain returns a BlockChain whose databases live in paths
+// that CleanUp removes when the chain is passed at index 0.
+func newTestBlockChain() *blockchain.BlockChain {
+	return openBlockChain(testConfig(0))
+}
+
+// openBlockChain returns the BlockChain for config, exiting if it
+// can't be opened.
+func openBlockChain(config *blockchain.Config) *blockchain.BlockChain {
+	bc, err := blockchain.New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return bc
+}
+
+// storeBlock stores a Block with the ChainWriter, failing the test if
+// it can't be stored.
+func storeBlock(t *testing.T, cw *chainwriter.ChainWriter, b *block.Block, ub *chainwriter.UndoBlock, height uint32) *blockinfodatabase.BlockRecord {
+	br, err := cw.StoreBlock(b, ub, height)
+	if err != nil {
+		t.Fatalf("Failed to store block: %v", err)
+	}
+	return br
+}
+
+// mine sets a Block's Nonce to one whose hash meets its
+// DifficultyTarget, and returns the Block.
+func mine(b *block.Block) *block.Block {
+	for block.ValidateProofOfWork(b.Header) != nil {
+		b.Header.Nonce++
+	}
+	return b
+}
+
+// emptyChild returns a Block without Transactions that builds on prev,
+// timestamped a second after it, and mined to the testDifficultyTarget.
+// Mining starts from nonce << 16, so children given different nonces
+// are different Blocks.
+func emptyChild(prev *block.Block, nonce uint32) *block.Block {
+	return mine(&block.Block{
+		Header: &block.Header{
+			PreviousHash:     prev.Hash(),
+			DifficultyTarget: testDifficultyTarget,
+			Nonce:            nonce << 16,
+			Timestamp:        prev.Header.Timestamp + 1,
+		},
+	})
+}
+
+func TestGetBlockTree(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genBlock := bc.LastBlock
+	b1 := emptyChild(genBlock, 1)
+	b2 := emptyChild(b1, 1)
+	fork := emptyChild(genBlock, 2)
+	for _, b := range []*block.Block{b1, b2, fork} {
+		bc.HandleBlock(b)
+	}
+
+	tree := bc.GetBlockTree()
+	if tree.BestHash != b2.Hash() {
+		t.Errorf("Expected best hash %v, got %v", b2.Hash(), tree.BestHash)
+	}
+	AssertSize(t, len(tree.Tips), 2)
+	AssertSize(t, len(tree.BranchPoints), 1)
+	if tree.Tips[0].Hash != b2.Hash() || !tree.Tips[0].Active || tree.Tips[0].Height != 3 {
+		t.Errorf("Expected the active tip at height 3 first, got %+v", tree.Tips[0])
+	}
+	if tree.Tips[1].Hash != fork.Hash() || tree.Tips[1].Active || tree.Tips[1].BranchPoint != genBlock.Hash() {
+		t.Errorf("Expected the fork tip to branch from genesis, got %+v", tree.Tips[1])
+	}
+	if tree.Tips[0].CumulativeWork.Cmp(tree.Tips[1].CumulativeWork) <= 0 {
+		t.Errorf("Expected the active tip to have more work than the fork")
+	}
+	if tree.BranchPoints[0].Hash != genBlock.Hash() {
+		t.Errorf("Expected genesis to be the branch point")
+	}
+	AssertSize(t, len(tree.BranchPoints[0].Children), 2)
+}
+
+func TestHandleBlockTwiceIsNoOp(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	b1 := emptyChild(bc.LastBlock, 1)
+	bc.HandleBlock(b1)
+	br := bc.BlockInfoDB.GetBlockRecord(b1.Hash())
+	offset := bc.ChainWriter.CurrentBlockOffset
+
+	bc.HandleBlock(b1)
+	if bc.Length != 2 {
+		t.Errorf("Expected length 2 after handling the same block twice, got %v", bc.Length)
+	}
+	if bc.ChainWriter.CurrentBlockOffset != offset {
+		t.Errorf("Expected the block to only be written once")
+	}
+	if again := storeBlock(t, bc.ChainWriter, b1, &chainwriter.UndoBlock{}, 2); again.BlockStartOffset != br.BlockStartOffset {
+		t.Errorf("Expected storing a stored block to return its original record")
+	}
+}
+
+func TestGetBlockRecordByHeightFollowsMainChain(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genBlock := bc.LastBlock
+	b1 := emptyChild(genBlock, 1)
+	b2 := emptyChild(b1, 1)
+	f2 := emptyChild(b1, 2)
+	f3 := emptyChild(f2, 2)
+	for _, b := range []*block.Block{b1, b2, f2} {
+		bc.HandleBlock(b)
+	}
+	if br := bc.BlockInfoDB.GetBlockRecordByHeight(2); br == nil || br.Header.Nonce != b1.Header.Nonce {
+		t.Fatalf("Expected b1 at height 2")
+	}
+	if bc.BlockInfoDB.GetHashByHeight(3) != b2.Hash() {
+		t.Errorf("Expected b2 at height 3")
+	}
+	bc.HandleBlock(f3)
+	if bc.BlockInfoDB.GetHashByHeight(3) != f2.Hash() || bc.BlockInfoDB.GetHashByHeight(4) != f3.Hash() {
+		t.Errorf("Expected the index to follow the new main chain after a reorg")
+	}
+	if b := bc.GetBlockByHeight(4); b == nil || b.Hash() != f3.Hash() {
+		t.Errorf("Expected GetBlockByHeight to return f3")
+	}
+	AssertSize(t, len(bc.BlockInfoDB.GetAllBlockRecords()), 5)
+}
+
+func TestGetRecordsInRange(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	prev := bc.LastBlock
+	for i := 0; i < 4; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	records := bc.BlockInfoDB.GetRecordsInRange(2, 4)
+	AssertSize(t, len(records), 3)
+	for i, br := range records {
+		if br.Height != uint32(2+i) {
+			t.Errorf("Expected record %v to be at height %v, got %v", i, 2+i, br.Height)
+		}
+	}
+	// the chain is only 5 blocks long
+	AssertSize(t, len(bc.BlockInfoDB.GetRecordsInRange(4, 100)), 2)
+	AssertSize(t, len(bc.BlockInfoDB.GetRecordsInRange(6, 100)), 0)
+	AssertSize(t, len(bc.BlockInfoDB.GetRecordsInRange(3, 2)), 0)
+}
+
+func TestBlockChainResumesFromTip(t *testing.T) {
+	bc := newTestBlockChain()
+	prev := bc.LastBlock
+	for i := 0; i < 3; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	work := bc.CumulativeWork
+	bc.Close()
+
+	resumed := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{resumed})
+	if resumed.Length != 4 || resumed.LastHash != prev.Hash() {
+		t.Fatalf("Expected to resume at height 4, got %v", resumed.Length)
+	}
+	if resumed.CumulativeWork.Cmp(work) != 0 {
+		t.Errorf("Expected cumulative work %v, got %v", work, resumed.CumulativeWork)
+	}
+	if resumed.LastBlock == nil || resumed.LastBlock.Hash() != prev.Hash() {
+		t.Errorf("Expected the last block to be read back from disk")
+	}
+	AssertSize(t, len(resumed.UnsafeHashes), 4)
+}
+
+func TestStoreBlockRecordsInOneBatch(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	var hashes []string
+	var records []*blockinfodatabase.BlockRecord
+	for i := 0; i < 100; i++ {
+		br := MockedBlockRecord()
+		br.Height = uint32(i)
+		br.Header.Nonce = uint32(i)
+		hashes = append(hashes, strconv.Itoa(i))
+		records = append(records, br)
+	}
+	if err := bc.BlockInfoDB.StoreBlockRecords(hashes, records[:99]); err == nil {
+		t.Errorf("Expected mismatched hashes and records to fail")
+	}
+	if err := bc.BlockInfoDB.StoreBlockRecords(hashes, records); err != nil {
+		t.Fatalf("Failed to store block records: %v", err)
+	}
+	for i, hash := range hashes {
+		if br := bc.BlockInfoDB.GetBlockRecord(hash); br.Height != uint32(i) {
+			t.Errorf("Expected record %v to have height %v, got %v", hash, i, br.Height)
+		}
+	}
+}
+
+func TestStoreBlockRecordAndSetTip(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	hash := strings.Repeat("d", block.HashLength)
+	br := MockedBlockRecord()
+	br.Height = 7
+	tip := &blockinfodatabase.ChainTip{Hash: hash, Height: br.Height, CumulativeWork: big.NewInt(7)}
+	if err := bc.BlockInfoDB.StoreBlockRecordAndSetTip("not the tip", br, tip, nil); err == nil {
+		t.Errorf("Expected storing a record that isn't the tip to fail")
+	}
+	if bc.BlockInfoDB.HasBlockRecord("not the tip") {
+		t.Errorf("Expected nothing to be stored when the tip doesn't match")
+	}
+	if err := bc.BlockInfoDB.StoreBlockRecordAndSetTip(hash, br, tip, nil); err != nil {
+		t.Fatalf("Failed to store block record and tip: %v", err)
+	}
+	if got := bc.BlockInfoDB.GetTip(); got == nil || got.Hash != hash || got.Height != 7 {
+		t.Errorf("Expected the tip to be {%v} at height 7, got %v", hash, got)
+	}
+	if bc.BlockInfoDB.GetHashByHeight(7) != hash || bc.BlockInfoDB.GetBlockRecord(hash).Height != 7 {
+		t.Errorf("Expected the record to be stored and indexed along with the tip")
+	}
+}
+
+func TestPruneOrphanedBranches(t *testing.T) {
+	config := testConfig(0)
+	config.OrphanPruneDepth = 2
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+
+	// a branch that is buried 3 deep, and one that is only buried 1 deep
+	b1 := emptyChild(bc.LastBlock, 100)
+	b2 := emptyChild(b1, 101)
+	prev := bc.LastBlock
+	var c1 *block.Block
+	for i := 0; i < 5; i++ {
+		if i == 3 {
+			c1 = emptyChild(prev, 200)
+		}
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	bc.HandleBlock(c1)
+	bc.HandleBlock(b1)
+	bc.HandleBlock(b2)
+	AssertSize(t, int(bc.Length), 6)
+
+	marked := bc.MarkOrphanedBranches()
+	AssertSize(t, len(marked), 2)
+	for _, hash := range marked {
+		if hash != b1.Hash() && hash != b2.Hash() {
+			t.Errorf("Expected only the deeply buried branch to be marked, got {%v}", hash)
+		}
+	}
+	pruned, err := bc.PruneOrphanedRecords()
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+	AssertSize(t, pruned, 2)
+	if bc.BlockInfoDB.HasBlockRecord(b1.Hash()) || bc.BlockInfoDB.HasBlockRecord(b2.Hash()) {
+		t.Errorf("Expected the buried branch's records to be deleted")
+	}
+	AssertSize(t, len(bc.BlockInfoDB.GetAllBlockRecords()), 7)
+	AssertSize(t, len(bc.BlockInfoDB.GetOrphaned()), 0)
+	if bc.GetBlockByHeight(6) == nil {
+		t.Errorf("Expected the main chain to be intact")
+	}
+}
+
+func TestBlockRecordCache(t *testing.T) {
+	config := blockinfodatabase.DefaultConfig()
+	config.DatabasePath = "blockinfodata_cache_test"
+	config.CacheSize = 2
+	blockInfoDB := blockinfodatabase.New(config)
+	defer os.RemoveAll(config.DatabasePath)
+	defer blockInfoDB.Close()
+
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		br := MockedBlockRecord()
+		br.Height = uint32(i + 1)
+		hashes = append(hashes, strconv.Itoa(i))
+		blockInfoDB.StoreBlockRecord(hashes[i], br)
+	}
+	// the first record was evicted when the third was stored
+	first := blockInfoDB.GetBlockRecord(hashes[0])
+	if first == nil || first.Height != 1 {
+		t.Fatalf("Expected an evicted record to be read back from the db")
+	}
+	if blockInfoDB.GetBlockRecord(hashes[0]) != first {
+		t.Errorf("Expected a recently used record to come from the cache")
+	}
+
+	updated := MockedBlockRecord()
+	updated.Height = 10
+	blockInfoDB.StoreBlockRecord(hashes[0], updated)
+	if br := blockInfoDB.GetBlockRecord(hashes[0]); br.Height != 10 {
+		t.Errorf("Expected the cache to see the updated record, got height %v", br.Height)
+	}
+
+	if err := blockInfoDB.DeleteBlockRecords(hashes[:1]); err != nil {
+		t.Fatalf("Failed to delete record: %v", err)
+	}
+	if br := blockInfoDB.GetBlockRecord(hashes[0]); blockInfoDB.HasBlockRecord(hashes[0]) || (br != nil && br.Height == 10) {
+		t.Errorf("Expected a deleted record to be gone from the cache")
+	}
+}
+
+func TestHeaviestChainWins(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+	prev := genesis
+	for i := 0; i < 3; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	AssertSize(t, int(bc.Length), 4)
+	longest := prev.Hash()
+	if br := bc.BlockInfoDB.GetBlockRecord(longest); br.ChainWork.Cmp(bc.CumulativeWork) != 0 {
+		t.Errorf("Expected the tip's record to have the chain's work %v, got %v", bc.CumulativeWork, br.ChainWork)
+	}
+
+	// every block carries the same target, so a longer fork has more work
+	var fork []*block.Block
+	prev = genesis
+	for i := 0; i < 4; i++ {
+		prev = emptyChild(prev, uint32(100+i))
+		fork = append(fork, prev)
+		bc.HandleBlock(prev)
+	}
+	heavy := fork[len(fork)-1]
+	if bc.LastHash != heavy.Hash() || bc.Length != 5 {
+		t.Fatalf("Expected the heavier chain to become active")
+	}
+	heavyBr := bc.BlockInfoDB.GetBlockRecord(heavy.Hash())
+	if blockchain.CompareChainWork(heavyBr, bc.BlockInfoDB.GetBlockRecord(longest)) <= 0 {
+		t.Errorf("Expected the fork's tip to have more work")
+	}
+	if bc.CumulativeWork.Cmp(heavyBr.ChainWork) != 0 {
+		t.Errorf("Expected the chain's work to be the fork tip's chain work")
+	}
+	if bc.GetBlockByHeight(2).Hash() != fork[0].Hash() || bc.GetBlockByHeight(5).Hash() != heavy.Hash() {
+		t.Errorf("Expected the height index to follow the heavier chain")
+	}
+}
+
+func TestTxIndex(t *testing.T) {
+	config := testConfig(0)
+	config.TxIndex = true
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+
+	var txs []*block.Transaction
+	prev := genesis
+	for i := 0; i < 3; i++ {
+		tx := &block.Transaction{
+			Version:  uint32(i + 1),
+			Outputs:  []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{}}},
+			LockTime: block.CoinbaseLockTime(uint32(i + 2)),
+		}
+		txs = append(txs, tx)
+		prev = emptyChild(prev, uint32(i))
+		prev.Transactions = []*block.Transaction{tx}
+		bc.HandleBlock(prev)
+	}
+	if tx, height := bc.GetTransaction(txs[1].Hash()); tx == nil || tx.Hash() != txs[1].Hash() || height != 3 {
+		t.Fatalf("Expected to find the transaction at height 3, got height %v", height)
+	}
+	if c := bc.GetConfirmations(txs[0].Hash()); c != 3 {
+		t.Errorf("Expected 3 confirmations, got %v", c)
+	}
+	if tx, _ := bc.GetTransaction(genesis.Transactions[0].Hash()); tx == nil {
+		t.Errorf("Expected the genesis transaction to be indexed")
+	}
+
+	// once a heavier chain replaces the blocks, their transactions are unconfirmed
+	prev = genesis
+	for i := 0; i < 4; i++ {
+		prev = emptyChild(prev, uint32(100+i))
+		bc.HandleBlock(prev)
+	}
+	if c := bc.GetConfirmations(txs[0].Hash()); c != 0 {
+		t.Errorf("Expected a disconnected transaction to be unconfirmed, got %v", c)
+	}
+}
+
+func TestBlockInfoDBSchemaMigration(t *testing.T) {
+	path := "blockinfodata0"
+	defer os.RemoveAll(path)
+
+	// write a database the way it was written before the schema was versioned
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	genesisHash, childHash := strings.Repeat("a", block.HashLength), strings.Repeat("b", block.HashLength)
+	genesis := MockedBlockRecord()
+	child := MockedBlockRecord()
+	child.Header.PreviousHash = genesisHash
+	child.Header.DifficultyTarget = "00ff"
+	child.Height = genesis.Height + 1
+	for hash, br := range map[string]*blockinfodatabase.BlockRecord{genesisHash: genesis, childHash: child} {
+		data, _ := proto.Marshal(blockinfodatabase.EncodeBlockRecord(br))
+		if err = db.Put([]byte(hash), data, nil); err != nil {
+			t.Fatalf("failed to store block record: %v", err)
+		}
+	}
+	db.Close()
+
+	config := blockinfodatabase.DefaultConfig()
+	config.DatabasePath = path
+	blockInfoDB := blockinfodatabase.New(config)
+	if version, err := blockInfoDB.GetSchemaVersion(); err != nil || version != blockinfodatabase.SchemaVersion {
+		t.Errorf("Expected schema version %v after migrating, got %v (%v)", blockinfodatabase.SchemaVersion, version, err)
+	}
+	want := new(big.Int).Add(block.Work(genesis.Header), block.Work(child.Header))
+	if work := blockInfoDB.GetBlockRecord(childHash).ChainWork; work == nil || work.Cmp(want) != 0 {
+		t.Errorf("Expected the migration to back-fill chain work %v, got %v", want, work)
+	}
+	AssertSize(t, 2, len(blockInfoDB.GetAllBlockRecords()))
+	blockInfoDB.Close()
+
+	// a new database starts at the current version
+	os.RemoveAll(path)
+	blockInfoDB = blockinfodatabase.New(config)
+	defer blockInfoDB.Close()
+	if version, _ := blockInfoDB.GetSchemaVersion(); version != blockinfodatabase.SchemaVersion {
+		t.Errorf("Expected a new database to have schema version %v, got %v", blockinfodatabase.SchemaVersion, version)
+	}
+}
+
+func TestRecoverCorruptedDatabase(t *testing.T) {
+	bc := newTestBlockChain()
+	b1 := emptyChild(bc.LastBlock, 1)
+	bc.HandleBlock(b1)
+	bc.Close()
+
+	manifests, _ := filepath.Glob(filepath.Join("blockinfodata0", "MANIFEST-*"))
+	if len(manifests) == 0 {
+		t.Fatalf("expected the block info database to have a manifest")
+	}
+	if err := ioutil.WriteFile(manifests[0], []byte("not a manifest"), 0644); err != nil {
+		t.Fatalf("failed to corrupt the manifest: %v", err)
+	}
+
+	bc = newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	err := bc.BlockInfoDB.Err()
+	if !utils.IsCorruption(err) || !err.(*utils.CorruptionError).Recovered {
+		t.Fatalf("Expected the corruption to be detected and recovered from, got %v", err)
+	}
+	if !bc.NeedsReindex() {
+		t.Errorf("Expected the chain to need reindexing")
+	}
+	if bc.LastHash != b1.Hash() {
+		t.Errorf("Expected the recovered chain to resume from its tip")
+	}
+}
+
+func TestGetHeaders(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	blocks := []*block.Block{bc.LastBlock}
+	for i := 0; i < 5; i++ {
+		b := emptyChild(blocks[len(blocks)-1], uint32(i))
+		bc.HandleBlock(b)
+		blocks = append(blocks, b)
+	}
+	fork := emptyChild(blocks[1], 100)
+	bc.HandleBlock(fork)
+
+	headers := bc.BlockInfoDB.GetHeaders([]string{blocks[2].Hash(), blocks[0].Hash()}, "")
+	AssertSize(t, 3, len(headers))
+	if headers[0].PreviousHash != blocks[2].Hash() {
+		t.Errorf("Expected the headers to start right after the locator's first block")
+	}
+
+	headers = bc.BlockInfoDB.GetHeaders([]string{blocks[2].Hash()}, blocks[4].Hash())
+	AssertSize(t, 2, len(headers))
+
+	// blocks off the main chain are skipped when finding the common block
+	headers = bc.BlockInfoDB.GetHeaders([]string{fork.Hash(), blocks[1].Hash()}, "")
+	AssertSize(t, 4, len(headers))
+
+	// with nothing in common, headers start after the genesis block
+	headers = bc.BlockInfoDB.GetHeaders([]string{strings.Repeat("c", block.HashLength)}, "")
+	AssertSize(t, 5, len(headers))
+	if headers[0].PreviousHash != blocks[0].Hash() {
+		t.Errorf("Expected the headers to start right after the genesis block")
+	}
+}
+
+func TestBlockValidationStatus(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genBlock := bc.LastBlock
+	b1 := emptyChild(genBlock, 1)
+	b2 := emptyChild(b1, 1)
+	bc.HandleBlock(b1)
+	bc.HandleBlock(b2)
+	// spends a coin that doesn't exist
+	bad := emptyChild(b2, 1)
+	bad.Transactions = []*block.Transaction{{
+		Version: 1,
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: strings.Repeat("c", block.HashLength),
+			UnlockingScript:          []byte{},
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{}}},
+	}}
+	badChild := emptyChild(bad, 1)
+	fork := emptyChild(genBlock, 2)
+	forkChild := emptyChild(fork, 2)
+	for _, b := range []*block.Block{bad, badChild, fork, bad} {
+		bc.HandleBlock(b)
+	}
+
+	status := func(b *block.Block) blockinfodatabase.Status {
+		return bc.BlockInfoDB.GetBlockRecord(b.Hash()).Status
+	}
+	for _, b := range []*block.Block{genBlock, b1, b2} {
+		if status(b) != blockinfodatabase.StatusFullyValid {
+			t.Errorf("Expected %v to be fully valid, got %v", b.NameTag(), status(b))
+		}
+	}
+	for _, b := range []*block.Block{bad, badChild} {
+		if !status(b).Has(blockinfodatabase.StatusFailed) {
+			t.Errorf("Expected %v to have failed, got %v", b.NameTag(), status(b))
+		}
+	}
+	if bc.LastHash != b2.Hash() {
+		t.Errorf("Expected the chain to stay on the last valid block")
+	}
+	if !status(fork).IsValid(blockinfodatabase.StatusTreeValid) || status(fork).Has(blockinfodatabase.StatusScriptsValid) {
+		t.Errorf("Expected a block off the main chain to be only partially validated, got %v", status(fork))
+	}
+
+	bc.HandleBlock(forkChild)
+	marked, err := bc.BlockInfoDB.MarkFailed(fork.Hash())
+	if err != nil {
+		t.Fatalf("Failed to mark block as failed: %v", err)
+	}
+	AssertSize(t, 2, len(marked))
+	if !status(forkChild).Has(blockinfodatabase.StatusFailed) {
+		t.Errorf("Expected marking a block as failed to mark its descendants")
+	}
+}
+
+func TestBlockInfoDBBackupAndRestore(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	backupPath := "blockinfobackup0"
+	defer os.RemoveAll(backupPath)
+	b1 := emptyChild(bc.LastBlock, 1)
+	bc.HandleBlock(b1)
+
+	n, err := bc.BlockInfoDB.Backup(backupPath)
+	if err != nil || n == 0 {
+		t.Fatalf("Failed to back up block info database: %v", err)
+	}
+	if _, err = bc.BlockInfoDB.Backup(backupPath); err == nil {
+		t.Errorf("Expected backing up over an existing backup to fail")
+	}
+	b2 := emptyChild(b1, 1)
+	bc.HandleBlock(b2)
+
+	restored, err := bc.BlockInfoDB.Restore(backupPath)
+	if err != nil || restored != n {
+		t.Fatalf("Expected to restore %v entries, restored %v (%v)", n, restored, err)
+	}
+	if tip := bc.BlockInfoDB.GetTip(); tip == nil || tip.Hash != b1.Hash() {
+		t.Errorf("Expected the tip to be restored to %v, got %v", b1.NameTag(), tip)
+	}
+	if bc.BlockInfoDB.HasBlockRecord(b2.Hash()) || bc.BlockInfoDB.GetHashByHeight(3) != "" {
+		t.Errorf("Expected what was written after the backup to be gone")
+	}
+	if !bc.BlockInfoDB.HasBlockRecord(b1.Hash()) {
+		t.Errorf("Expected the backed up record to be restored")
+	}
+	if _, err = bc.BlockInfoDB.Restore("no such backup"); err == nil {
+		t.Errorf("Expected restoring a missing backup to fail")
+	}
+}
+
+func TestBlockInfoDBMetrics(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	prev := bc.LastBlock
+	for i := 0; i < 3; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	bc.BlockInfoDB.HasBlockRecord(strings.Repeat("e", block.HashLength))
+
+	m := bc.BlockInfoDB.Metrics()
+	AssertSize(t, m.Records, 4)
+	if m.DiskSize <= 0 {
+		t.Errorf("Expected the database to take up space on disk")
+	}
+	if m.Writes.Count == 0 || m.Reads.Count == 0 || m.CacheHits == 0 || m.CacheMisses == 0 {
+		t.Errorf("Expected reads, writes, and cache lookups to be counted, got %+v", m)
+	}
+	total := uint64(0)
+	for _, n := range m.Writes.Counts {
+		total += n
+	}
+	if total != m.Writes.Count {
+		t.Errorf("Expected the buckets to add up to %v writes, got %v", m.Writes.Count, total)
+	}
+	if median := m.Writes.Quantile(0.5); median <= 0 || median > m.Writes.Quantile(1) {
+		t.Errorf("Expected a positive median write latency no higher than the slowest, got %v", median)
+	}
+}
+
+func TestFramedBlockFiles(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 1 << 20
+	cw := chainwriter.New(config)
+	b1 := emptyChild(MockedBlock(), 1)
+	b2 := emptyChild(b1, 1)
+	br1 := storeBlock(t, cw, b1, &chainwriter.UndoBlock{}, 1)
+	br2 := storeBlock(t, cw, b2, &chainwriter.UndoBlock{}, 2)
+	fi1 := &chainwriter.FileInfo{FileName: br1.BlockFile, StartOffset: br1.BlockStartOffset, EndOffset: br1.BlockEndOffset}
+	fi2 := &chainwriter.FileInfo{FileName: br2.BlockFile, StartOffset: br2.BlockStartOffset, EndOffset: br2.BlockEndOffset}
+	if b, err := cw.ReadBlock(fi1); err != nil || b.Hash() != b1.Hash() {
+		t.Fatalf("Expected to read back the first block")
+	}
+
+	// corrupt a byte in the middle of the first block
+	data, _ := ioutil.ReadFile(br1.BlockFile)
+	data[(br1.BlockStartOffset+br1.BlockEndOffset)/2] ^= 0xff
+	ioutil.WriteFile(br1.BlockFile, data, 0644)
+	if _, err := cw.ReadBlock(fi1); err == nil {
+		t.Errorf("Expected the corrupted block to be rejected")
+	}
+	if b, err := cw.ReadBlock(fi2); err != nil || b.Hash() != b2.Hash() {
+		t.Errorf("Expected the block after the corrupted one to still be readable")
+	}
+	infos, err := cw.ScanFile(br1.BlockFile)
+	if err != nil {
+		t.Fatalf("Failed to scan block file: %v", err)
+	}
+	if len(infos) != 1 || *infos[0] != *fi2 {
+		t.Errorf("Expected scanning to find only the intact block, got %v", infos)
+	}
+
+	other := chainwriter.New(config)
+	other.Magic = chainwriter.DefaultMagic + 1
+	if _, err := other.ReadBlock(fi2); err == nil {
+		t.Errorf("Expected a block with another network's magic to be rejected")
+	}
+
+	past := &chainwriter.FileInfo{FileName: fi2.FileName, StartOffset: fi2.StartOffset, EndOffset: fi2.EndOffset + 100}
+	if _, err := cw.ReadBlock(past); err == nil {
+		t.Errorf("Expected a block that ends past the end of its file to be rejected")
+	}
+	missing := &chainwriter.FileInfo{FileName: filepath.Join(dir, "missing"), StartOffset: 0, EndOffset: 10}
+	if _, err := cw.ReadUndoBlock(missing); err == nil {
+		t.Errorf("Expected reading from a missing file to fail")
+	}
+}
+
+func TestCompressedRecordsCoexist(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 1 << 20
+	config.MaxUndoFileSize = 1 << 20
+	plain := chainwriter.New(config)
+	ub := &chainwriter.UndoBlock{}
+	for i := 0; i < 20; i++ {
+		ub.TransactionInputHashes = append(ub.TransactionInputHashes, MockedBlock().Hash())
+		ub.OutputIndexes = append(ub.OutputIndexes, 0)
+		ub.Amounts = append(ub.Amounts, 10)
+		ub.LockingScripts = append(ub.LockingScripts, []byte("locking script"))
+	}
+	b1 := emptyChild(MockedBlock(), 1)
+	br1 := storeBlock(t, plain, b1, ub, 1)
+
+	config.Compression = chainwriter.CompressionSnappy
+	compressed := chainwriter.New(config)
+	b2 := emptyChild(b1, 1)
+	br2 := storeBlock(t, compressed, b2, ub, 2)
+	if br2.UndoFile != br1.UndoFile {
+		t.Fatalf("Expected both undo blocks in the same file")
+	}
+	if br2.UndoEndOffset-br2.UndoStartOffset >= br1.UndoEndOffset-br1.UndoStartOffset {
+		t.Errorf("Expected the compressed undo block to take up less space")
+	}
+
+	for _, cw := range []*chainwriter.ChainWriter{plain, compressed} {
+		for i, br := range []*blockinfodatabase.BlockRecord{br1, br2} {
+			fi := &chainwriter.FileInfo{FileName: br.UndoFile, StartOffset: br.UndoStartOffset, EndOffset: br.UndoEndOffset}
+			if read, err := cw.ReadUndoBlock(fi); err != nil || len(read.TransactionInputHashes) != 20 ||
+				string(read.LockingScripts[19]) != "locking script" {
+				t.Errorf("Expected to read back undo block %v", i+1)
+			}
+		}
+		fi := &chainwriter.FileInfo{FileName: br2.BlockFile, StartOffset: br2.BlockStartOffset, EndOffset: br2.BlockEndOffset}
+		if read, err := cw.ReadBlock(fi); err != nil || read.Hash() != b2.Hash() {
+			t.Errorf("Expected to read back the second block")
+		}
+	}
+	infos, err := compressed.ScanFile(br1.UndoFile)
+	if err != nil || len(infos) != 2 {
+		t.Errorf("Expected scanning to find both undo blocks, got %v (%v)", infos, err)
+	}
+}
+
+func TestConcurrentPooledReads(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 200
+	config.MaxOpenFiles = 2
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	b := MockedBlock()
+	var blocks []*block.Block
+	var infos []*chainwriter.FileInfo
+	for i := 0; i < 8; i++ {
+		b = emptyChild(b, 1)
+		br := storeBlock(t, cw, b, &chainwriter.UndoBlock{}, uint32(i+1))
+		blocks = append(blocks, b)
+		infos = append(infos, &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset})
+	}
+	if cw.CurrentBlockFileNumber < 2 {
+		t.Fatalf("Expected the blocks to span more files than are kept open")
+	}
+
+	var wg sync.WaitGroup
+	failed := make(chan int, 8*len(blocks))
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := range blocks {
+				j := (i + g) % len(blocks)
+				if read, err := cw.ReadBlock(infos[j]); err != nil || read.Hash() != blocks[j].Hash() {
+					failed <- j
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(failed)
+	for j := range failed {
+		t.Errorf("Expected to read back block %v", j)
+	}
+	if n := cw.OpenFiles(); n == 0 || n > 2 {
+		t.Errorf("Expected between 1 and 2 open files, got %v", n)
+	}
+	cw.Close()
+	AssertSize(t, cw.OpenFiles(), 0)
+	if read, err := cw.ReadBlock(infos[0]); err != nil || read.Hash() != blocks[0].Hash() {
+		t.Errorf("Expected to read a block again after closing the open files")
+	}
+}
+
+func TestConcurrentStoreBlock(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	var blocks []*block.Block
+	for i := 0; i < 16; i++ {
+		blocks = append(blocks, emptyChild(MockedBlock(), uint32(i+1)))
+	}
+
+	// every block is stored twice, by different goroutines
+	records := make([]*blockinfodatabase.BlockRecord, 2*len(blocks))
+	var wg sync.WaitGroup
+	for i := range records {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			br, err := cw.StoreBlock(blocks[i%len(blocks)], &chainwriter.UndoBlock{}, 1)
+			if err != nil {
+				t.Errorf("Failed to store block: %v", err)
+			}
+			records[i] = br
+		}(i)
+	}
+	wg.Wait()
+
+	spans := make(map[string][]*blockinfodatabase.BlockRecord)
+	for i, br := range records[:len(blocks)] {
+		if records[i+len(blocks)] != br {
+			t.Errorf("Expected block %v to be written once", i)
+		}
+		fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+		if read, err := cw.ReadBlock(fi); err != nil || read.Hash() != blocks[i].Hash() {
+			t.Errorf("Expected to read back block %v", i)
+		}
+		spans[br.BlockFile] = append(spans[br.BlockFile], br)
+	}
+	total := 0
+	for file, brs := range spans {
+		infos, err := cw.ScanFile(file)
+		if err != nil {
+			t.Fatalf("Failed to scan block file: %v", err)
+		}
+		AssertSize(t, len(infos), len(brs))
+		total += len(infos)
+	}
+	AssertSize(t, total, len(blocks))
+}
+
+func TestIterateBlocks(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	// every block gets a file of its own
+	config.MaxBlockFileSize = 1
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	b := MockedBlock()
+	var hashes []string
+	var records []*blockinfodatabase.BlockRecord
+	for i := 0; i < 12; i++ {
+		b = emptyChild(b, 1)
+		records = append(records, storeBlock(t, cw, b, &chainwriter.UndoBlock{}, uint32(i+1)))
+		hashes = append(hashes, b.Hash())
+	}
+	if cw.CurrentBlockFileNumber < 10 {
+		// files are visited by number, not by name, so block_10 comes last
+		t.Fatalf("Expected the blocks to span more than ten files")
+	}
+	var seen []string
+	err := cw.IterateBlocks(func(b *block.Block, fi *chainwriter.FileInfo) error {
+		seen = append(seen, b.Hash())
+		return nil
+	})
+	if err != nil || strings.Join(seen, ",") != strings.Join(hashes, ",") {
+		t.Errorf("Expected to iterate over the blocks in the order they were written (%v)", err)
+	}
+
+	// corrupt the third block
+	br := records[2]
+	data, _ := ioutil.ReadFile(br.BlockFile)
+	data[(br.BlockStartOffset+br.BlockEndOffset)/2] ^= 0xff
+	ioutil.WriteFile(br.BlockFile, data, 0644)
+	stop := fmt.Errorf("stop")
+	seen = nil
+	err = cw.IterateBlocks(func(b *block.Block, fi *chainwriter.FileInfo) error {
+		seen = append(seen, b.Hash())
+		if len(seen) == 4 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Errorf("Expected the error that stopped iterating, got %v", err)
+	}
+	if strings.Join(seen, ",") != strings.Join([]string{hashes[0], hashes[1], hashes[3], hashes[4]}, ",") {
+		t.Errorf("Expected to skip the corrupted block and stop after four blocks")
+	}
+}
+
+func TestSyncStoredBlocks(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 1
+	config.SyncInterval = 2
+	cw := chainwriter.New(config)
+	b := MockedBlock()
+	var last *blockinfodatabase.BlockRecord
+	for i := 0; i < 5; i++ {
+		b = emptyChild(b, 1)
+		last = storeBlock(t, cw, b, &chainwriter.UndoBlock{Amounts: []uint32{1}, TransactionInputHashes: []string{"h"},
+			OutputIndexes: []uint32{0}, LockingScripts: [][]byte{{1}}}, uint32(i+1))
+	}
+	if err := cw.Sync(); err != nil {
+		t.Errorf("Failed to sync: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Errorf("Failed to close: %v", err)
+	}
+	restarted := chainwriter.New(config)
+	defer restarted.Close()
+	if *restarted.State() != *cw.State() {
+		t.Errorf("Expected to resume where the synced writer stopped")
+	}
+	fi := &chainwriter.FileInfo{FileName: last.BlockFile, StartOffset: last.BlockStartOffset, EndOffset: last.BlockEndOffset}
+	if read, err := restarted.ReadBlock(fi); err != nil || read.Hash() != b.Hash() {
+		t.Errorf("Expected to read back the last block")
+	}
+}
+
+func TestStoreBlockWhenDiskFull(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	state := *bc.ChainWriter.State()
+	// no disk has this much space free
+	bc.ChainWriter.MinFreeSpace = 1 << 62
+	b1 := emptyChild(bc.LastBlock, 1)
+	if _, err := bc.ChainWriter.StoreBlock(b1, &chainwriter.UndoBlock{}, 2); !chainwriter.IsDiskFull(err) {
+		t.Errorf("Expected the disk to be full, got %v", err)
+	}
+	bc.HandleBlock(b1)
+	if bc.Length != 1 || bc.BlockInfoDB.HasBlockRecord(b1.Hash()) {
+		t.Errorf("Expected a block that can't be stored not to be recorded")
+	}
+	if *bc.ChainWriter.State() != state {
+		t.Errorf("Expected a block that can't be stored to leave the files as they were")
+	}
+
+	bc.ChainWriter.MinFreeSpace = 0
+	bc.HandleBlock(b1)
+	if bc.Length != 2 || bc.GetBlock(b1.Hash()) == nil {
+		t.Errorf("Expected the block to be stored once there is space for it")
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 1 << 20
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	b1 := emptyChild(MockedBlock(), 1)
+	// a header too long to be read with the first read
+	b2 := emptyChild(b1, 2)
+	b2.Header.DifficultyTarget = strings.Repeat("f", 300)
+	config.Compression = chainwriter.CompressionSnappy
+	config.DataDirectory = filepath.Join(dir, "compressed")
+	compressed := chainwriter.New(config)
+	defer compressed.Close()
+
+	for i, b := range []*block.Block{b1, b2} {
+		for _, w := range []*chainwriter.ChainWriter{cw, compressed} {
+			br := storeBlock(t, w, b, &chainwriter.UndoBlock{}, uint32(i+1))
+			fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+			h, err := w.ReadHeader(fi)
+			if err != nil || h.PreviousHash != b.Header.PreviousHash || h.Nonce != b.Header.Nonce ||
+				h.DifficultyTarget != b.Header.DifficultyTarget {
+				t.Errorf("Expected to read back the header of block %v (%v)", i+1, err)
+			}
+		}
+	}
+
+	br := storeBlock(t, cw, emptyChild(b2, 3), &chainwriter.UndoBlock{}, 3)
+	other := chainwriter.New(config)
+	other.Magic = chainwriter.DefaultMagic + 1
+	fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+	if _, err := other.ReadHeader(fi); err == nil {
+		t.Errorf("Expected a header with another network's magic to be rejected")
+	}
+	fi.EndOffset += 100
+	if _, err := cw.ReadHeader(fi); err == nil {
+		t.Errorf("Expected a header past the end of its file to be rejected")
+	}
+}
+
+func TestChainWriterResumesAfterRestart(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 400
+	cw := chainwriter.New(config)
+	b := emptyChild(MockedBlock(), 1)
+	var last *blockinfodatabase.BlockRecord
+	for i := 0; i < 5; i++ {
+		b = emptyChild(b, 1)
+		last = storeBlock(t, cw, b, &chainwriter.UndoBlock{}, uint32(i+1))
+	}
+	if cw.CurrentBlockFileNumber == 0 {
+		t.Fatalf("Expected the blocks to span several files")
+	}
+	// a crash partway through writing leaves a torn record behind
+	f, _ := os.OpenFile(last.BlockFile, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte{0xc0, 0x1d, 0xb1})
+	f.Close()
+
+	restarted := chainwriter.New(config)
+	if restarted.CurrentBlockFileNumber != cw.CurrentBlockFileNumber || restarted.CurrentBlockOffset != cw.CurrentBlockOffset {
+		t.Errorf("Expected to resume at file %v offset %v, got file %v offset %v", cw.CurrentBlockFileNumber,
+			cw.CurrentBlockOffset, restarted.CurrentBlockFileNumber, restarted.CurrentBlockOffset)
+	}
+	if info, _ := os.Stat(last.BlockFile); info.Size() != int64(last.BlockEndOffset) {
+		t.Errorf("Expected the torn record to be dropped")
+	}
+	next := emptyChild(b, 1)
+	br := storeBlock(t, restarted, next, &chainwriter.UndoBlock{}, 6)
+	fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+	if read, err := restarted.ReadBlock(fi); err != nil || read.Hash() != next.Hash() {
+		t.Errorf("Expected to read back a block written after restarting")
+	}
+	fi = &chainwriter.FileInfo{FileName: last.BlockFile, StartOffset: last.BlockStartOffset, EndOffset: last.BlockEndOffset}
+	if read, err := restarted.ReadBlock(fi); err != nil || read.Hash() != b.Hash() {
+		t.Errorf("Expected blocks written before restarting to be intact")
+	}
+}
+
+func TestChainWriterStateStoredWithBlockRecords(t *testing.T) {
+	bc := newTestBlockChain()
+	prev := bc.LastBlock
+	for i := 0; i < 3; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	recorded := bc.ChainWriter.State()
+	if state := bc.BlockInfoDB.GetWriterState(); state == nil || *state != *recorded {
+		t.Fatalf("Expected the writer state %v to be stored with the last block record, got %v", recorded, state)
+	}
+	// a crash after writing a block, but before recording it
+	unrecorded := storeBlock(t, bc.ChainWriter, emptyChild(prev, 9), &chainwriter.UndoBlock{}, 5)
+	bc.Close()
+
+	resumed := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{resumed})
+	if state := resumed.ChainWriter.State(); *state != *recorded {
+		t.Errorf("Expected to resume writing at %v, got %v", recorded, state)
+	}
+	if info, err := os.Stat(unrecorded.BlockFile); err == nil && info.Size() > int64(unrecorded.BlockStartOffset) {
+		t.Errorf("Expected the unrecorded block to be dropped")
+	}
+	next := emptyChild(prev, 10)
+	resumed.HandleBlock(next)
+	if resumed.LastHash != next.Hash() || resumed.GetBlock(next.Hash()) == nil {
+		t.Errorf("Expected a block handled after resuming to be stored and read back")
+	}
+	if resumed.GetBlock(prev.Hash()) == nil {
+		t.Errorf("Expected blocks recorded before the crash to be intact")
+	}
+}
+
+func TestPruneBlockFiles(t *testing.T) {
+	config := testConfig(0)
+	config.PruneDepth = 10
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastHash
+	genesisFile := bc.BlockInfoDB.GetBlockRecord(genesis).BlockFile
+	prev := bc.LastBlock
+	for i := 0; i < 40; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	AssertSize(t, int(bc.Length), 41)
+	if br := bc.BlockInfoDB.GetBlockRecord(genesis); !br.Status.Has(blockinfodatabase.StatusPruned) {
+		t.Fatalf("Expected the genesis block to be pruned, got status %v", br.Status)
+	}
+	if _, err := os.Stat(genesisFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the genesis block's file to be deleted")
+	}
+	if bc.GetBlock(genesis) != nil {
+		t.Errorf("Expected a pruned block not to be readable")
+	}
+	for height := bc.Length - 10; height <= bc.Length; height++ {
+		br := bc.BlockInfoDB.GetBlockRecordByHeight(height)
+		if br.Status.Has(blockinfodatabase.StatusPruned) || bc.GetBlockByHeight(height) == nil {
+			t.Errorf("Expected the block at height %v to be kept", height)
+		}
+	}
+	if blocks := bc.GetBlocks(1, bc.Length); len(blocks) == 0 || len(blocks) >= int(bc.Length) {
+		t.Errorf("Expected only the blocks that weren't pruned, got %v", len(blocks))
+	}
+	AssertSize(t, len(bc.GetHashes(1, bc.Length)), int(bc.Length))
+}
+
+func TestPruneToTarget(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+	prev := genesis
+	for i := 0; i < 40; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	usage := bc.ChainWriter.DiskUsage()
+	if usage == 0 {
+		t.Fatalf("Expected the block and undo files to take up space")
+	}
+
+	// nothing is pruned while the files fit
+	if hashes := bc.ChainWriter.PruneToTarget(usage, bc.Length, bc.BlockInfoDB.GetAllBlockRecords()); len(hashes) != 0 {
+		t.Errorf("Expected nothing to be pruned under the target, got %v blocks", len(hashes))
+	}
+	// the oldest files go first, until the rest fit
+	hashes := bc.ChainWriter.PruneToTarget(usage/2, bc.Length, bc.BlockInfoDB.GetAllBlockRecords())
+	if err := bc.BlockInfoDB.MarkPruned(hashes); err != nil {
+		t.Fatalf("Failed to mark blocks pruned: %v", err)
+	}
+	if len(hashes) == 0 || bc.ChainWriter.DiskUsage() > usage/2 || !bc.IsPruned(genesis.Hash()) {
+		t.Errorf("Expected the oldest files to be pruned down to %v bytes, got %v", usage/2, bc.ChainWriter.DiskUsage())
+	}
+	// but recent blocks are kept, even over the target
+	keep := bc.Length - 6
+	hashes = bc.ChainWriter.PruneToTarget(0, keep, bc.BlockInfoDB.GetAllBlockRecords())
+	if err := bc.BlockInfoDB.MarkPruned(hashes); err != nil {
+		t.Fatalf("Failed to mark blocks pruned: %v", err)
+	}
+	for height := keep; height <= bc.Length; height++ {
+		if bc.IsPruned(bc.BlockInfoDB.GetHashByHeight(height)) || bc.GetBlockByHeight(height) == nil {
+			t.Errorf("Expected the block at height %v to be kept", height)
+		}
+	}
+
+	// pruned blocks are refused with a clear error
+	if _, err := bc.FindTransaction(genesis.Transactions[0].Hash(), genesis.Hash()); err == nil || !strings.Contains(err.Error(), "pruned") {
+		t.Errorf("Expected looking in a pruned block to say it was pruned, got %v", err)
+	}
+	if _, err := bc.GetUTXODelta(1, 3); err == nil || !strings.Contains(err.Error(), "pruned") {
+		t.Errorf("Expected a delta over pruned blocks to say they were pruned, got %v", err)
+	}
+}
+
+func TestPruneUndoFiles(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 1 << 20
+	// every undo block gets a file of its own
+	config.MaxUndoFileSize = 1
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	ub := &chainwriter.UndoBlock{Amounts: []uint32{1}, TransactionInputHashes: []string{"h"},
+		OutputIndexes: []uint32{0}, LockingScripts: [][]byte{{1}}}
+	records := make(map[string]*blockinfodatabase.BlockRecord)
+	var hashes []string
+	b := MockedBlock()
+	for height := uint32(1); height <= 10; height++ {
+		b = emptyChild(b, height)
+		records[b.Hash()] = storeBlock(t, cw, b, ub, height)
+		hashes = append(hashes, b.Hash())
+	}
+
+	pruned := cw.PruneUndoFiles(5, records)
+	AssertSize(t, len(pruned), 4)
+	for i, hash := range hashes {
+		br := records[hash]
+		_, err := os.Stat(br.UndoFile)
+		if i < 4 != os.IsNotExist(err) {
+			t.Errorf("Expected only the undo files below height 5 to be deleted, got %v at height %v", err, br.Height)
+		}
+		fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+		if _, err = cw.ReadBlock(fi); err != nil {
+			t.Errorf("Expected every block to be kept, got %v", err)
+		}
+	}
+	for _, hash := range pruned {
+		records[hash].Status |= blockinfodatabase.StatusUndoPruned
+	}
+	AssertSize(t, len(cw.PruneUndoFiles(5, records)), 0)
+	// the current undo file is never deleted
+	AssertSize(t, len(cw.PruneUndoFiles(100, records)), 5)
+}
+
+func TestStoreBlocksPipeline(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = dir
+	config.MaxBlockFileSize = 400
+	config.MaxUndoFileSize = 400
+	cw := chainwriter.New(config)
+	defer cw.Close()
+	ub := &chainwriter.UndoBlock{Amounts: []uint32{1}, TransactionInputHashes: []string{MockedBlock().Hash()},
+		OutputIndexes: []uint32{0}, LockingScripts: [][]byte{{1}}}
+	var blocks []*block.Block
+	b := MockedBlock()
+	for i := 0; i < 20; i++ {
+		b = emptyChild(b, uint32(i))
+		blocks = append(blocks, b)
+	}
+
+	// a block stored on its own, and one that appears twice in the batch
+	stored := storeBlock(t, cw, blocks[0], ub, 1)
+	writes := []*chainwriter.BlockWrite{{Block: blocks[0], UndoBlock: ub, Height: 1}}
+	for i := 1; i < 10; i++ {
+		writes = append(writes, &chainwriter.BlockWrite{Block: blocks[i], UndoBlock: ub, Height: uint32(i + 1)})
+	}
+	writes = append(writes, writes[5])
+	records, err := cw.StoreBlocks(writes)
+	if err != nil {
+		t.Fatalf("Failed to store blocks: %v", err)
+	}
+	AssertSize(t, len(records), len(writes))
+	if records[0] != stored || records[10] != records[5] {
+		t.Errorf("Expected blocks that were already stored to be written once")
+	}
+
+	p := chainwriter.NewPipeline(cw, 4)
+	var order []int
+	for i := 10; i < 20; i++ {
+		i := i
+		p.Submit(&chainwriter.BlockWrite{Block: blocks[i], UndoBlock: ub, Height: uint32(i + 1)},
+			func(br *blockinfodatabase.BlockRecord, err error) {
+				if err != nil {
+					t.Errorf("Failed to store block %v: %v", i, err)
+					return
+				}
+				order = append(order, i)
+				records = append(records, br)
+			})
+	}
+	p.Close()
+	AssertSize(t, len(order), 10)
+	for j, i := range order {
+		if i != 10+j {
+			t.Errorf("Expected callbacks in the order the blocks were submitted, got %v", order)
+			break
+		}
+	}
+
+	// every block and undo block can be read back, and nothing else
+	// was written
+	seen := 0
+	cw.IterateBlocks(func(*block.Block, *chainwriter.FileInfo) error {
+		seen++
+		return nil
+	})
+	AssertSize(t, seen, len(blocks))
+	for _, br := range records {
+		hash := br.Header.PreviousHash
+		fi := &chainwriter.FileInfo{FileName: br.UndoFile, StartOffset: br.UndoStartOffset, EndOffset: br.UndoEndOffset}
+		if _, err = cw.ReadUndoBlock(fi); err != nil {
+			t.Errorf("Expected to read back the undo block of the child of {%v}: %v", hash, err)
+		}
+	}
+	restarted := chainwriter.New(config)
+	defer restarted.Close()
+	if *restarted.State() != *cw.State() {
+		t.Errorf("Expected to resume after the last batch")
+	}
+}
+
+func TestDataDirectoryLocked(t *testing.T) {
+	bc := newTestBlockChain()
+	for _, dir := range []string{"data0", "blockinfodata0", "coindata0"} {
+		if _, err := utils.LockDirectory(dir); !utils.IsDirectoryLocked(err) {
+			t.Errorf("Expected {%v} to be locked by the running chain, got %v", dir, err)
+		}
+	}
+	config := testConfig(0)
+	if second, err := blockchain.New(config); second != nil || !utils.IsDirectoryLocked(err) {
+		t.Errorf("Expected a second chain in the same directories to be refused, got %v", err)
+	}
+	bc.Close()
+
+	lock, err := utils.LockDirectory("data0")
+	if err != nil {
+		t.Fatalf("Expected the lock to be released when the chain was closed: %v", err)
+	}
+	if err = lock.Release(); err != nil {
+		t.Errorf("Failed to release lock: %v", err)
+	}
+	resumed := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{resumed})
+	if resumed.LastHash != bc.LastHash {
+		t.Errorf("Expected a new chain to open the released directories")
+	}
+}
+
+func TestExportImportChain(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chain.archive")
+	bc := newTestBlockChain()
+	config := testConfig(1)
+	fresh := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc, fresh})
+	prev := bc.LastBlock
+	for i := 0; i < 5; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+
+	if _, err := bc.ExportChain(path, 3, 2); err == nil {
+		t.Errorf("Expected an empty height range to be refused")
+	}
+	n, err := bc.ExportChain(path, 1, 100)
+	if err != nil {
+		t.Fatalf("Failed to export chain: %v", err)
+	}
+	AssertSize(t, n, 6)
+	added, err := fresh.ImportChain(path)
+	if err != nil {
+		t.Fatalf("Failed to import chain: %v", err)
+	}
+	// the genesis block is already there
+	AssertSize(t, added, 5)
+	if fresh.LastHash != bc.LastHash || fresh.Length != bc.Length {
+		t.Errorf("Expected the imported chain to reach the exported tip")
+	}
+
+	// a corrupted archive is refused at the bad record
+	data, _ := ioutil.ReadFile(path)
+	data[len(data)-10] ^= 0xff
+	corrupted := filepath.Join(dir, "corrupted.archive")
+	ioutil.WriteFile(corrupted, data, 0644)
+	count, err := bc.ChainWriter.ImportChain(corrupted, func(*block.Block) error { return nil })
+	if err == nil || count != 5 {
+		t.Errorf("Expected the corrupted last block to be refused after 5 blocks, got %v (%v)", count, err)
+	}
+	// as is one from another network
+	other := chainwriter.DefaultConfig()
+	other.DataDirectory = filepath.Join(dir, "other")
+	other.Magic = chainwriter.DefaultMagic + 1
+	if _, err = chainwriter.New(other).ImportChain(path, func(*block.Block) error { return nil }); err == nil {
+		t.Errorf("Expected an archive with another magic to be refused")
+	}
+}
+
+func TestLoadBlocks(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "loadblocks")
+	defer os.RemoveAll(dir)
+	bc := newTestBlockChain()
+	var chains []*blockchain.BlockChain
+	for i := 1; i <= 2; i++ {
+		config := testConfig(i)
+		chains = append(chains, openBlockChain(config))
+	}
+	defer CleanUp(append([]*blockchain.BlockChain{bc}, chains...))
+	genesis := bc.LastBlock
+	prev := genesis
+	for i := 0; i < 4; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	fork := emptyChild(genesis, 10)
+	invalid := emptyChild(genesis, 11)
+	invalid.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(5),
+	}}
+	bc.HandleBlock(fork)
+	bc.HandleBlock(invalid)
+
+	// another node's block directory holds its side branches and the
+	// invalid blocks it was sent too
+	accepted, err := chains[0].LoadBlocks(bc.ChainWriter.BlockDirectory, nil)
+	if err != nil {
+		t.Fatalf("Failed to load block directory: %v", err)
+	}
+	AssertSize(t, accepted, 5)
+	if chains[0].LastHash != bc.LastHash || !chains[0].BlockInfoDB.HasBlockRecord(fork.Hash()) {
+		t.Errorf("Expected the loaded chain to reach the same tip and keep the fork")
+	}
+	if !chains[0].BlockInfoDB.GetBlockRecord(invalid.Hash()).Status.Has(blockinfodatabase.StatusFailed) {
+		t.Errorf("Expected the invalid block to be loaded as failed")
+	}
+
+	// an archive is recognized as one
+	path := filepath.Join(dir, "chain.archive")
+	if _, err = bc.ExportChain(path, 1, bc.Length); err != nil {
+		t.Fatalf("Failed to export chain: %v", err)
+	}
+	handled := 0
+	accepted, err = chains[1].LoadBlocks(path, func(*block.Block) { handled++ })
+	if err != nil || accepted != 4 || handled != 4 || chains[1].LastHash != bc.LastHash {
+		t.Errorf("Expected the archive's 4 new blocks to be loaded, got %v (%v)", accepted, err)
+	}
+	if _, err = chains[1].LoadBlocks(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Errorf("Expected a missing path to be refused")
+	}
+}
+
+func TestSeparateShardedDirectories(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "chainwriter")
+	defer os.RemoveAll(dir)
+	config := chainwriter.DefaultConfig()
+	config.DataDirectory = filepath.Join(dir, "data")
+	config.BlockDirectory = filepath.Join(dir, "blocks")
+	config.UndoDirectory = filepath.Join(dir, "undo")
+	config.FilesPerDirectory = 2
+	config.MaxBlockFileSize = 1
+	config.MaxUndoFileSize = 1
+	config.SyncInterval = 1
+	cw := chainwriter.New(config)
+	ub := &chainwriter.UndoBlock{Amounts: []uint32{1}, TransactionInputHashes: []string{MockedBlock().Hash()},
+		OutputIndexes: []uint32{0}, LockingScripts: [][]byte{{1}}}
+	var records []*blockinfodatabase.BlockRecord
+	b := MockedBlock()
+	for i := 0; i < 5; i++ {
+		b = emptyChild(b, uint32(i))
+		records = append(records, storeBlock(t, cw, b, ub, uint32(i+1)))
+	}
+	// every record has its own file, two files to a shard
+	last := records[len(records)-1]
+	if expected := filepath.Join(dir, "blocks", "2", "block_5.txt"); filepath.Clean(last.BlockFile) != expected {
+		t.Errorf("Expected the last block in {%v}, got {%v}", expected, last.BlockFile)
+	}
+	if expected := filepath.Join(dir, "undo", "2", "undo_5.txt"); filepath.Clean(last.UndoFile) != expected {
+		t.Errorf("Expected the last undo block in {%v}, got {%v}", expected, last.UndoFile)
+	}
+	if files, _ := ioutil.ReadDir(config.DataDirectory); len(files) != 0 {
+		t.Errorf("Expected nothing to be written to the data directory, got %v files", len(files))
+	}
+	for _, br := range records {
+		fi := &chainwriter.FileInfo{FileName: br.BlockFile, StartOffset: br.BlockStartOffset, EndOffset: br.BlockEndOffset}
+		if _, err := cw.ReadBlock(fi); err != nil {
+			t.Errorf("Failed to read back block: %v", err)
+		}
+	}
+	cw.Close()
+
+	restarted := chainwriter.New(config)
+	defer restarted.Close()
+	if *restarted.State() != *cw.State() {
+		t.Errorf("Expected to resume at %v, got %v", cw.State(), restarted.State())
+	}
+	seen := 0
+	restarted.IterateBlocks(func(*block.Block, *chainwriter.FileInfo) error {
+		seen++
+		return nil
+	})
+	AssertSize(t, seen, len(records))
+}
+
+func TestRejectCodes(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+	coinbase := func(height uint32, amount uint32) *block.Transaction {
+		return &block.Transaction{
+			Outputs:  []*block.TransactionOutput{{Amount: amount, LockingScript: []byte{1}}},
+			LockTime: block.CoinbaseLockTime(height),
+		}
+	}
+	subsidy := blockchain.DefaultConfig().BlockSubsidy
+
+	base := emptyChild(genesis, 1)
+	base.Transactions = []*block.Transaction{coinbase(2, subsidy)}
+	if err := bc.HandleBlock(base); err != nil {
+		t.Fatalf("Expected a valid block to be accepted: %v", err)
+	}
+	if err := bc.HandleBlock(emptyChild(emptyChild(genesis, 50), 1)); err != nil {
+		t.Errorf("Expected an orphan to be kept, not rejected: %v", err)
+	}
+
+	unmined := emptyChild(base, 1)
+	unmined.Header.DifficultyTarget = "1"
+	future := emptyChild(base, 2)
+	future.Header.Timestamp = uint32(time.Now().Unix() + blockchain.MaxFutureBlockTime + 60)
+	mine(future)
+	past := emptyChild(base, 3)
+	past.Header.Timestamp = genesis.Header.Timestamp
+	mine(past)
+	wrongHeight := emptyChild(base, 4)
+	wrongHeight.Transactions = []*block.Transaction{coinbase(4, subsidy)}
+	greedy := emptyChild(base, 5)
+	greedy.Transactions = []*block.Transaction{coinbase(3, subsidy+1)}
+	missing := emptyChild(base, 6)
+	missing.Transactions = []*block.Transaction{coinbase(3, subsidy), spend(&block.Transaction{Version: 9}, 0, 1)}
+	overpaying := spend(base.Transactions[0], 0, 1)
+	overpaying.Outputs[0].Amount = subsidy + 1
+	belowOut := emptyChild(base, 7)
+	belowOut.Transactions = []*block.Transaction{coinbase(3, subsidy), overpaying}
+	for _, c := range []struct {
+		b    *block.Block
+		code block.RejectCode
+	}{
+		{base, block.RejectDuplicate},
+		{unmined, block.RejectBadPoW},
+		{future, block.RejectTimeTooNew},
+		{past, block.RejectTimeTooOld},
+		{wrongHeight, block.RejectCoinbaseHeight},
+		{greedy, block.RejectCoinbaseAmount},
+		{missing, block.RejectInputsMissing},
+		{belowOut, block.RejectInBelowOut},
+		{emptyChild(greedy, 1), block.RejectPrevInvalid},
+	} {
+		err := bc.HandleBlock(c.b)
+		if code := block.RejectCodeOf(err); code != c.code {
+			t.Errorf("Expected rejection %v, got %v (%v)", c.code, code, err)
+		}
+		if re := block.AsRejectError(err); re != nil && re.Invalid() == (c.code == block.RejectDuplicate || c.code == block.RejectTimeTooNew) {
+			t.Errorf("Expected %v to be invalid only if the block can never be valid", c.code)
+		}
+	}
+	if bc.LastHash != base.Hash() {
+		t.Errorf("Expected no rejected block to be connected")
+	}
+}
+
+func TestValidateHeader(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+
+	if err := bc.ValidateHeader(emptyChild(genesis, 0).Header); err != nil {
+		t.Errorf("Expected a mined header to be valid: %v", err)
+	}
+	unmined := emptyChild(genesis, 0)
+	unmined.Header.DifficultyTarget = "1"
+	// a header mined to an easier target than the chain's has too
+	// little work, however well it meets its own
+	weak := emptyChild(genesis, 3)
+	weak.Header.DifficultyTarget = strings.Repeat("f", block.HashLength)
+	empty := emptyChild(genesis, 4)
+	empty.Header.DifficultyTarget = ""
+	future := emptyChild(genesis, 1)
+	future.Header.Timestamp = uint32(time.Now().Unix() + blockchain.MaxFutureBlockTime + 60)
+	mine(future)
+	orphan := emptyChild(emptyChild(genesis, 50), 2)
+	for name, b := range map[string]*block.Block{"unmined": unmined, "weak": weak, "empty": empty, "future": future, "orphan": orphan} {
+		err := bc.ValidateHeader(b.Header)
+		if err == nil {
+			t.Errorf("Expected the %v header to be rejected", name)
+		} else if name == "weak" || name == "empty" {
+			if code := block.RejectCodeOf(err); code != block.RejectBadPoW {
+				t.Errorf("Expected the %v header to be rejected as %v, got %v", name, block.RejectBadPoW, code)
+			}
+		}
+		bc.HandleBlock(b)
+		if bc.BlockInfoDB.HasBlockRecord(b.Hash()) {
+			t.Errorf("Expected the %v block not to be stored", name)
+		}
+	}
+	AssertSize(t, int(bc.Length), 1)
+}
+
+func TestMedianTimePast(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	// timestamps that jump back and forth, as miners' clocks do
+	prev := bc.LastBlock
+	for i, ts := range []uint32{100, 120, 110, 130, 115, 140, 125, 150, 135, 160, 145, 170} {
+		prev = emptyChild(prev, uint32(i))
+		prev.Header.Timestamp = ts
+		bc.HandleBlock(mine(prev))
+	}
+	AssertSize(t, int(bc.Length), 13)
+	// the median of the last 11: 110 115 120 125 130 135 140 145 150 160 170
+	mtp := bc.MedianTimePast(bc.LastHash)
+	AssertSize(t, int(mtp), 135)
+
+	early := emptyChild(prev, 100)
+	early.Header.Timestamp = mtp
+	if err := bc.ValidateHeader(mine(early).Header); err == nil {
+		t.Errorf("Expected a timestamp at the median time past to be rejected")
+	}
+	late := emptyChild(prev, 101)
+	late.Header.Timestamp = mtp + 1
+	if err := bc.ValidateHeader(mine(late).Header); err != nil {
+		t.Errorf("Expected a timestamp after the median time past to be valid: %v", err)
+	}
+
+	// height locks are reached by the next block, time locks by the
+	// median time past rather than the tip's own timestamp
+	heightLocked := &block.Transaction{LockTime: 14}
+	timeLocked := &block.Transaction{LockTime: block.LockTimeThreshold + 1}
+	if !bc.IsFinal(&block.Transaction{}) || bc.IsFinal(heightLocked) || bc.IsFinal(timeLocked) {
+		t.Errorf("Expected only the unlocked transaction to be final")
+	}
+	if !block.IsFinal(heightLocked, 15, 0) || !block.IsFinal(timeLocked, 1, block.LockTimeThreshold+2) {
+		t.Errorf("Expected the locks to be reached past their height and time")
+	}
+}
+
+func TestHeadersFirstSync(t *testing.T) {
+	bc := newTestBlockChain()
+	config := testConfig(1)
+	fresh := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc, fresh})
+	var blocks []*block.Block
+	prev := bc.LastBlock
+	for i := 0; i < 15; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+		blocks = append(blocks, prev)
+	}
+	// fresh shares the first 3 blocks, and then forks off
+	for _, b := range blocks[:3] {
+		fresh.HandleBlock(b)
+	}
+	fresh.HandleBlock(emptyChild(blocks[2], 100))
+	AssertSize(t, int(fresh.Length), 5)
+
+	// headers are sent past the fork, a page at a time
+	lastHash := fresh.LastHash
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("Expected the headers to run out")
+		}
+		headers := bc.BlockInfoDB.GetHeaders(fresh.Headers.Locator(lastHash), "")
+		for _, header := range headers {
+			if err := fresh.Headers.Add(header); err != nil {
+				t.Fatalf("Expected a valid header to be added: %v", err)
+			}
+			lastHash = (&block.Block{Header: header}).Hash()
+		}
+		if len(headers) < blockinfodatabase.MaxHeaders {
+			break
+		}
+	}
+	AssertSize(t, fresh.Headers.Len(), 12)
+	missing := fresh.Headers.Missing()
+	AssertSize(t, len(missing), 12)
+	for i, hash := range missing {
+		if hash != blocks[i+3].Hash() {
+			t.Fatalf("Expected the missing blocks to be the source chain's past the fork, in order")
+		}
+	}
+
+	// bad headers are rejected
+	unmined := emptyChild(prev, 200)
+	unmined.Header.DifficultyTarget = "1"
+	if err := fresh.Headers.Add(unmined.Header); err == nil {
+		t.Errorf("Expected a header without proof of work to be rejected")
+	}
+	if err := fresh.Headers.Add(emptyChild(emptyChild(prev, 201), 202).Header); err == nil {
+		t.Errorf("Expected a header with an unknown parent to be rejected")
+	} else if _, ok := err.(*blockchain.UnknownParentError); !ok {
+		t.Errorf("Expected an UnknownParentError, got %v", err)
+	}
+
+	// once the bodies arrive, fresh switches to the source chain
+	for _, hash := range missing {
+		fresh.HandleBlock(bc.GetBlock(hash))
+	}
+	if fresh.LastHash != bc.LastHash || fresh.Length != bc.Length {
+		t.Errorf("Expected the synced chain to reach the source's tip")
+	}
+	fresh.Headers.Prune()
+	AssertSize(t, fresh.Headers.Len(), 0)
+	AssertSize(t, len(fresh.Headers.Missing()), 0)
+}
+
+// unspent returns whether the output at index of tx is an unspent Coin.
+func unspent(bc *blockchain.BlockChain, tx *block.Transaction, index uint32) bool {
+	coin := bc.CoinDB.GetCoin(coindatabase.CoinLocator{ReferenceTransactionHash: tx.Hash(), OutputIndex: index})
+	return coin != nil && !coin.IsSpent
+}
+
+// spend returns a Transaction that spends the output at index of tx.
+func spend(tx *block.Transaction, index uint32, version uint32) *block.Transaction {
+	return &block.Transaction{
+		Version: version,
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: tx.Hash(), OutputIndex: index}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{1}}},
+	}
+}
+
+func TestCoinbaseCommitsToHeight(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	coinbase := func(height uint32) *block.Transaction {
+		return &block.Transaction{
+			Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}},
+			LockTime: block.CoinbaseLockTime(height),
+		}
+	}
+
+	// a coinbase committing to another height is invalid
+	wrong := emptyChild(bc.LastBlock, 1)
+	wrong.Transactions = []*block.Transaction{coinbase(3)}
+	bc.HandleBlock(wrong)
+	if bc.LastHash == wrong.Hash() || !bc.BlockInfoDB.GetBlockRecord(wrong.Hash()).Status.Has(blockinfodatabase.StatusFailed) {
+		t.Errorf("Expected a coinbase committing to the wrong height to be rejected")
+	}
+
+	// so identical coinbases at different heights have different hashes
+	b2 := emptyChild(bc.LastBlock, 2)
+	b2.Transactions = []*block.Transaction{coinbase(2)}
+	b3 := emptyChild(b2, 1)
+	b3.Transactions = []*block.Transaction{coinbase(3)}
+	bc.HandleBlock(b2)
+	bc.HandleBlock(b3)
+	if bc.LastHash != b3.Hash() || !unspent(bc, b2.Transactions[0], 0) || !unspent(bc, b3.Transactions[0], 0) {
+		t.Errorf("Expected both coinbases to be connected with their own coins")
+	}
+}
+
+func TestBlocksRespectLockTimes(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}, {Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	bc.HandleBlock(base)
+
+	// a transaction locked until height 3 can't be in the block at height 3
+	heightLocked := spend(base.Transactions[0], 0, 1)
+	heightLocked.LockTime = 3
+	early := emptyChild(base, 1)
+	early.Transactions = []*block.Transaction{heightLocked}
+	bc.HandleBlock(early)
+	if bc.LastHash == early.Hash() || !bc.BlockInfoDB.GetBlockRecord(early.Hash()).Status.Has(blockinfodatabase.StatusFailed) {
+		t.Errorf("Expected a block with a transaction before its lock height to be rejected")
+	}
+
+	// a time lock is judged by the parent's median time past, not the
+	// block's own timestamp
+	timeLocked := spend(base.Transactions[0], 1, 1)
+	timeLocked.LockTime = block.LockTimeThreshold
+	ahead := emptyChild(base, 2)
+	ahead.Header.Timestamp = block.LockTimeThreshold + 1
+	ahead.Transactions = []*block.Transaction{timeLocked}
+	bc.HandleBlock(mine(ahead))
+	if bc.LastHash == ahead.Hash() {
+		t.Errorf("Expected a block with a transaction before its lock time to be rejected")
+	}
+
+	heightLocked.LockTime = 2
+	final := emptyChild(base, 3)
+	final.Transactions = []*block.Transaction{heightLocked}
+	bc.HandleBlock(final)
+	if bc.LastHash != final.Hash() {
+		t.Errorf("Expected a block with a transaction past its lock height to be valid")
+	}
+}
+
+func TestSequenceLocks(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}, {Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	bc.HandleBlock(base)
+
+	// a coin locked for 2 blocks can't be spent in the next block
+	locked := spend(base.Transactions[0], 0, 1)
+	locked.Inputs[0].Sequence = 2
+	early := emptyChild(base, 1)
+	early.Transactions = []*block.Transaction{locked}
+	bc.HandleBlock(early)
+	if bc.LastHash == early.Hash() || bc.IsFinal(locked) {
+		t.Errorf("Expected a coin to be locked for 2 blocks")
+	}
+
+	// nor can a coin created in the same block, however short the lock
+	unlocked := spend(base.Transactions[0], 0, 2)
+	same := emptyChild(base, 2)
+	same.Transactions = []*block.Transaction{unlocked, spend(unlocked, 0, 1)}
+	same.Transactions[1].Inputs[0].Sequence = 1
+	bc.HandleBlock(same)
+	if bc.LastHash == same.Hash() {
+		t.Errorf("Expected a coin from the same block to be locked")
+	}
+
+	// one block later, the lock is reached, but a time lock isn't,
+	// since the median time past has barely moved
+	b3 := emptyChild(base, 3)
+	bc.HandleBlock(b3)
+	timeLocked := spend(base.Transactions[0], 1, 1)
+	timeLocked.Inputs[0].Sequence = block.SequenceLockByTime | 1
+	if !bc.IsFinal(locked) || bc.IsFinal(timeLocked) {
+		t.Errorf("Expected only the height lock to be reached")
+	}
+	b4 := emptyChild(b3, 1)
+	b4.Transactions = []*block.Transaction{locked}
+	bc.HandleBlock(b4)
+	if bc.LastHash != b4.Hash() {
+		t.Errorf("Expected a block spending a coin after its relative lock to be valid")
+	}
+
+	// a disabled lock doesn't lock at all
+	timeLocked.Inputs[0].Sequence |= block.SequenceLockDisabled
+	if !bc.IsFinal(timeLocked) {
+		t.Errorf("Expected a disabled relative lock to be ignored")
+	}
+}
+
+func TestSequenceLocksOnPrunedBlocks(t *testing.T) {
+	config := testConfig(0)
+	config.PruneDepth = 10
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}, {Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	bc.HandleBlock(base)
+	prev := base
+	for i := 0; i < 40; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+	}
+	if bc.GetBlock(base.Hash()) != nil {
+		t.Fatalf("Expected the block with the coins to be pruned")
+	}
+
+	// the coins' age is still known without the pruned block's body
+	locked := spend(base.Transactions[0], 0, 1)
+	locked.Inputs[0].Sequence = 50
+	early := emptyChild(prev, 1)
+	early.Transactions = []*block.Transaction{locked}
+	if code := block.RejectCodeOf(bc.HandleBlock(early)); code != block.RejectNonFinal {
+		t.Errorf("Expected a coin from a pruned block to still be locked, got %v", code)
+	}
+	unlocked := spend(base.Transactions[0], 1, 1)
+	unlocked.Inputs[0].Sequence = 30
+	late := emptyChild(prev, 2)
+	late.Transactions = []*block.Transaction{unlocked}
+	if err := bc.HandleBlock(late); err != nil || bc.LastHash != late.Hash() {
+		t.Errorf("Expected a coin from a pruned block to be spendable after its lock: %v", err)
+	}
+}
+
+func TestVaultSpendsAreEnforced(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	owner, _ := id.CreateSimpleID()
+	recovery, _ := id.CreateSimpleID()
+	vault := &script.Vault{OwnerPublicKey: owner.GetPublicKeyBytes(), RecoveryPublicKey: recovery.GetPublicKeyBytes(), DelayBlocks: 2}
+	vaultScript, _ := proto.Marshal(script.EncodeVault(vault))
+	vault.Unvaulting = true
+	unvaultingScript, _ := proto.Marshal(script.EncodeVault(vault))
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: vaultScript}, {Amount: 5, LockingScript: vaultScript}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	bc.HandleBlock(base)
+	// spendVault spends output index of tx, signed by signer, into a
+	// single output locked by lockingScript
+	spendVault := func(tx *block.Transaction, index uint32, signer id.ID, lockingScript []byte) *block.Transaction {
+		sig, _ := tx.Outputs[index].MakeSignature(signer)
+		return &block.Transaction{
+			Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: tx.Hash(), OutputIndex: index, UnlockingScript: sig}},
+			Outputs: []*block.TransactionOutput{{Amount: 4, LockingScript: lockingScript}},
+		}
+	}
+
+	// the owner can't take the coins straight out, nor can anyone else
+	for _, theft := range []*block.Transaction{
+		spendVault(base.Transactions[0], 0, owner, []byte{1}),
+		spendVault(base.Transactions[0], 0, owner, vaultScript),
+		{Inputs: []*block.TransactionInput{{ReferenceTransactionHash: base.Transactions[0].Hash()}}, Outputs: []*block.TransactionOutput{{Amount: 4, LockingScript: unvaultingScript}}},
+	} {
+		if re := block.AsRejectError(bc.CheckVaultSpends(theft)); re == nil || re.Code != block.RejectVaultSpend {
+			t.Errorf("Expected a vault spend breaking its rules to be rejected")
+		}
+		b := emptyChild(base, 1)
+		b.Transactions = []*block.Transaction{theft}
+		bc.HandleBlock(b)
+		if bc.LastHash == b.Hash() {
+			t.Errorf("Expected a block breaking a vault's rules to be rejected")
+		}
+	}
+
+	// the owner starts a withdrawal, and the recovery key sweeps the
+	// other vault at once
+	unvault := spendVault(base.Transactions[0], 0, owner, unvaultingScript)
+	sweep := spendVault(base.Transactions[0], 1, recovery, []byte{1})
+	b3 := emptyChild(base, 2)
+	b3.Transactions = []*block.Transaction{unvault, sweep}
+	bc.HandleBlock(b3)
+	if bc.LastHash != b3.Hash() {
+		t.Fatalf("Expected starting a withdrawal and sweeping with the recovery key to be valid")
+	}
+
+	// the withdrawal waits for the delay
+	withdrawal := spendVault(unvault, 0, owner, []byte{1})
+	if bc.CheckVaultSpends(withdrawal) == nil {
+		t.Errorf("Expected a withdrawal to wait for its delay")
+	}
+	early := emptyChild(b3, 1)
+	early.Transactions = []*block.Transaction{withdrawal}
+	bc.HandleBlock(early)
+	if bc.LastHash == early.Hash() {
+		t.Errorf("Expected a block with an early withdrawal to be rejected")
+	}
+	b4 := emptyChild(b3, 2)
+	bc.HandleBlock(b4)
+	if err := bc.CheckVaultSpends(withdrawal); err != nil {
+		t.Errorf("Expected the withdrawal to be allowed after its delay, got %v", err)
+	}
+	b5 := emptyChild(b4, 1)
+	b5.Transactions = []*block.Transaction{withdrawal}
+	bc.HandleBlock(b5)
+	if bc.LastHash != b5.Hash() {
+		t.Errorf("Expected a block completing a withdrawal after its delay to be valid")
+	}
+}
+
+func TestCoinbaseValue(t *testing.T) {
+	if blockchain.Subsidy(9, 50, 10, 2) != 50 || blockchain.Subsidy(10, 50, 10, 2) != 25 || blockchain.Subsidy(20, 50, 10, 2) != 0 {
+		t.Errorf("Expected the subsidy to halve every 10 blocks, twice")
+	}
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	coinbase := func(height uint32, amount uint32) *block.Transaction {
+		return &block.Transaction{
+			Outputs:  []*block.TransactionOutput{{Amount: amount, LockingScript: []byte{1}}},
+			LockTime: block.CoinbaseLockTime(height),
+		}
+	}
+	subsidy := blockchain.DefaultConfig().BlockSubsidy
+
+	// the coinbase may claim the subsidy, but no more
+	greedy := emptyChild(bc.LastBlock, 1)
+	greedy.Transactions = []*block.Transaction{coinbase(2, subsidy+1)}
+	bc.HandleBlock(greedy)
+	if bc.LastHash == greedy.Hash() {
+		t.Errorf("Expected a coinbase paying more than the subsidy to be rejected")
+	}
+	base := emptyChild(bc.LastBlock, 2)
+	base.Transactions = []*block.Transaction{coinbase(2, subsidy)}
+	bc.HandleBlock(base)
+	if bc.LastHash != base.Hash() {
+		t.Fatalf("Expected a coinbase paying the subsidy to be valid")
+	}
+
+	// along with the fees of the block's other transactions, which
+	// can't pay out more than they spend
+	paying := spend(base.Transactions[0], 0, 1)
+	paying.Outputs[0].Amount = subsidy - 4
+	overpaying := spend(base.Transactions[0], 0, 2)
+	overpaying.Outputs[0].Amount = subsidy + 1
+	for i, txs := range [][]*block.Transaction{
+		{coinbase(3, subsidy+5), paying},
+		{coinbase(3, subsidy), overpaying},
+	} {
+		invalid := emptyChild(base, uint32(i))
+		invalid.Transactions = txs
+		bc.HandleBlock(invalid)
+		if bc.LastHash == invalid.Hash() {
+			t.Errorf("Expected block %v to be rejected", i)
+		}
+	}
+	valid := emptyChild(base, 3)
+	valid.Transactions = []*block.Transaction{coinbase(3, subsidy+4), paying}
+	bc.HandleBlock(valid)
+	if bc.LastHash != valid.Hash() {
+		t.Errorf("Expected a coinbase claiming the subsidy and fees to be valid")
+	}
+}
+
+func TestReorgToHeavierBranch(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	var reorgs []*blockchain.Reorg
+	bc.OnReorg(func(r *blockchain.Reorg) { reorgs = append(reorgs, r) })
+
+	// both branches build on a block with two coins
+	coinbase := &block.Transaction{
+		Version:  1,
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}, {Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{coinbase}
+	bc.HandleBlock(base)
+
+	// the active branch spends both coins
+	spendA, keepA := spend(coinbase, 0, 2), spend(coinbase, 1, 3)
+	a1 := emptyChild(base, 2)
+	a1.Transactions = []*block.Transaction{spendA, keepA}
+	a2 := emptyChild(a1, 3)
+	bc.HandleBlock(a1)
+	bc.HandleBlock(a2)
+	AssertSize(t, int(bc.Length), 4)
+
+	// a heavier branch spends the first coin differently
+	spendB := spend(coinbase, 0, 4)
+	b1 := emptyChild(base, 4)
+	b1.Transactions = []*block.Transaction{spendB}
+	b2 := emptyChild(b1, 5)
+	b3 := emptyChild(b2, 9)
+	bc.HandleBlock(b1)
+	bc.HandleBlock(b2)
+	if bc.LastHash != a2.Hash() || len(reorgs) != 0 {
+		t.Fatalf("Expected the branch without more work not to become active")
+	}
+	bc.HandleBlock(b3)
+	if bc.LastHash != b3.Hash() || bc.Length != 5 || len(reorgs) != 1 {
+		t.Fatalf("Expected the heavier branch to become active")
+	}
+	r := reorgs[0]
+	if r.Ancestor != base.Hash() || r.Tip() != b3.Hash() || r.Length != 5 {
+		t.Errorf("Expected a reorg from %v to %v, got one from %v to %v", base.Hash(), b3.Hash(), r.Ancestor, r.Tip())
+	}
+	AssertSize(t, len(r.Disconnected), 2)
+	AssertSize(t, len(r.Connected), 3)
+	if r.Disconnected[0].Hash() != a2.Hash() || r.Connected[0].Hash() != b1.Hash() {
+		t.Errorf("Expected blocks to be disconnected from the old tip back, and connected from the ancestor up")
+	}
+	// spendA conflicts with spendB, but keepA can still be mined
+	if len(r.Returned) != 1 || r.Returned[0].Hash() != keepA.Hash() {
+		t.Errorf("Expected only the non-conflicting transaction to be returned, got %v", r.Returned)
+	}
+	if !unspent(bc, spendB, 0) || unspent(bc, spendA, 0) || !unspent(bc, coinbase, 1) || unspent(bc, coinbase, 0) {
+		t.Errorf("Expected the coins to follow the new branch")
+	}
+	if br := bc.BlockInfoDB.GetBlockRecord(b1.Hash()); !br.Status.IsValid(blockinfodatabase.StatusFullyValid) || br.UndoFile == "" {
+		t.Errorf("Expected the connected block to be fully valid with an undo block, got %v", br.Status)
+	}
+	if bc.GetBlockByHeight(3).Hash() != b1.Hash() {
+		t.Errorf("Expected the height index to follow the new branch")
+	}
+
+	// the undo blocks made while connecting let the branch be disconnected again
+	a3 := emptyChild(a2, 6)
+	a4 := emptyChild(a3, 10)
+	bc.HandleBlock(a3)
+	bc.HandleBlock(a4)
+	if bc.LastHash != a4.Hash() || len(reorgs) != 2 {
+		t.Fatalf("Expected the original branch to become active again")
+	}
+	if unspent(bc, spendB, 0) || !unspent(bc, spendA, 0) || !unspent(bc, keepA, 0) || unspent(bc, coinbase, 0) {
+		t.Errorf("Expected the coins to follow the original branch")
+	}
+
+	// a heavier branch with an invalid block is marked failed, and the
+	// active chain is left as it was
+	bad := emptyChild(a3, 7)
+	bad.Transactions = []*block.Transaction{spend(MockedBlock().Transactions[0], 9, 5)}
+	badChild := emptyChild(bad, 8)
+	bc.HandleBlock(bad)
+	bc.HandleBlock(badChild)
+	if bc.LastHash != a4.Hash() || len(reorgs) != 2 {
+		t.Fatalf("Expected the branch with an invalid block not to become active")
+	}
+	if !bc.BlockInfoDB.GetBlockRecord(badChild.Hash()).Status.Has(blockinfodatabase.StatusFailed) {
+		t.Errorf("Expected the invalid block's descendants to be marked failed")
+	}
+	if !unspent(bc, spendA, 0) || !unspent(bc, keepA, 0) || unspent(bc, coinbase, 0) {
+		t.Errorf("Expected the coins to be restored after the failed reorg")
+	}
+}
+
+func TestTipSubscription(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	sub := bc.Subscribe(10)
+	slow := bc.Subscribe(1)
+	next := func() *blockchain.TipEvent {
+		select {
+		case e := <-sub.Events:
+			return e
+		default:
+			t.Fatalf("Expected another tip event")
+			return nil
+		}
+	}
+
+	a1 := emptyChild(bc.LastBlock, 1)
+	a2 := emptyChild(a1, 2)
+	bc.HandleBlock(a1)
+	bc.HandleBlock(a2)
+	for i, b := range []*block.Block{a1, a2} {
+		if e := next(); e.Kind != blockchain.TipConnected || e.Hash != b.Hash() || e.Height != uint32(i)+2 {
+			t.Errorf("Expected block %v to be connected at height %v, got %v %v", b.Hash(), i+2, e.Kind, e.Hash)
+		}
+	}
+
+	// a heavier branch disconnects the old tip, newest first, before
+	// connecting the new one
+	b2 := emptyChild(a1, 3)
+	b3 := emptyChild(b2, 4)
+	bc.HandleBlock(b2)
+	bc.HandleBlock(b3)
+	if e := next(); e.Kind != blockchain.TipDisconnected || e.Hash != a2.Hash() || e.Height != 3 {
+		t.Errorf("Expected the old tip to be disconnected, got %v %v", e.Kind, e.Hash)
+	}
+	for i, b := range []*block.Block{b2, b3} {
+		if e := next(); e.Kind != blockchain.TipConnected || e.Hash != b.Hash() || e.Height != uint32(i)+3 {
+			t.Errorf("Expected block %v to be connected at height %v, got %v %v", b.Hash(), i+3, e.Kind, e.Hash)
+		}
+	}
+	if e := next(); e.Kind != blockchain.ReorgCompleted || e.Hash != b3.Hash() || e.Reorg == nil || e.Reorg.Ancestor != a1.Hash() {
+		t.Errorf("Expected the reorg to complete at the new tip, got %v %v", e.Kind, e.Hash)
+	}
+
+	// a subscriber that falls behind is dropped rather than waited for
+	if !slow.Overflowed() || sub.Overflowed() {
+		t.Errorf("Expected only the slow subscriber to overflow")
+	}
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	bc.HandleBlock(emptyChild(b3, 5))
+	if _, ok := <-sub.Events; ok {
+		t.Errorf("Expected no events after unsubscribing")
+	}
+}
+
+func TestInvalidateAndReconsiderBlock(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+	b1 := emptyChild(genesis, 1)
+	b2 := emptyChild(b1, 2)
+	b3 := emptyChild(b2, 3)
+	b4 := emptyChild(b3, 4)
+	f2 := emptyChild(b1, 20)
+	f3 := emptyChild(f2, 30)
+	for _, b := range []*block.Block{b1, b2, b3, b4, f2, f3} {
+		bc.HandleBlock(b)
+	}
+	var reorgs []*blockchain.Reorg
+	bc.OnReorg(func(r *blockchain.Reorg) { reorgs = append(reorgs, r) })
+	failed := func(b *block.Block) bool {
+		return bc.BlockInfoDB.GetBlockRecord(b.Hash()).Status.Has(blockinfodatabase.StatusFailed)
+	}
+
+	if err := bc.InvalidateBlock(genesis.Hash()); err == nil {
+		t.Errorf("Expected the genesis block not to be invalidated")
+	}
+	if err := bc.InvalidateBlock(emptyChild(b4, 99).Hash()); err == nil {
+		t.Errorf("Expected an unknown block not to be invalidated")
+	}
+
+	// the active chain switches to the best valid chain, even though
+	// it has less work
+	if err := bc.InvalidateBlock(b3.Hash()); err != nil {
+		t.Fatalf("Failed to invalidate block: %v", err)
+	}
+	if bc.LastHash != f3.Hash() || bc.Length != 4 {
+		t.Fatalf("Expected the active chain to switch to the fork")
+	}
+	if !failed(b3) || !failed(b4) || failed(b2) {
+		t.Errorf("Expected only the block and its descendants to be invalid")
+	}
+	AssertSize(t, len(reorgs), 1)
+	AssertSize(t, len(reorgs[0].Disconnected), 3)
+	// blocks building on an invalidated block are invalid too
+	b5 := emptyChild(b4, 5)
+	bc.HandleBlock(b5)
+	if !failed(b5) || bc.LastHash != f3.Hash() {
+		t.Errorf("Expected a block descending from an invalidated block to be invalid")
+	}
+
+	// invalidating the fork goes back to what is left of the old chain
+	if err := bc.InvalidateBlock(f2.Hash()); err != nil {
+		t.Fatalf("Failed to invalidate block: %v", err)
+	}
+	if bc.LastHash != b2.Hash() || bc.Length != 3 {
+		t.Fatalf("Expected the active chain to switch back to the old chain")
+	}
+
+	// reconsidering a block brings back its descendants, but not other
+	// invalidated branches
+	if err := bc.ReconsiderBlock(b3.Hash()); err != nil {
+		t.Fatalf("Failed to reconsider block: %v", err)
+	}
+	if bc.LastHash != b5.Hash() || bc.Length != 6 || failed(b5) || !failed(f2) {
+		t.Fatalf("Expected the active chain to return to the reconsidered chain")
+	}
+
+	// invalidating the tip only disconnects it
+	if err := bc.InvalidateBlock(b5.Hash()); err != nil {
+		t.Fatalf("Failed to invalidate block: %v", err)
+	}
+	if bc.LastHash != b4.Hash() || bc.Length != 5 || bc.LastBlock.Hash() != b4.Hash() {
+		t.Fatalf("Expected the active chain to end at the tip's parent")
+	}
+	if r := reorgs[len(reorgs)-1]; r.Tip() != b4.Hash() || len(r.Connected) != 0 {
+		t.Errorf("Expected the reorg to only disconnect the tip")
+	}
+	if err := bc.ReconsiderBlock(b5.Hash()); err != nil || bc.LastHash != b5.Hash() {
+		t.Errorf("Expected the tip to be connected again: %v", err)
+	}
+}
+
+func TestChainQueries(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genesis := bc.LastBlock
+	tx := &block.Transaction{
+		Outputs:  []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}
+	b2 := emptyChild(genesis, 2)
+	b2.Transactions = []*block.Transaction{tx}
+	b3 := emptyChild(b2, 3)
+	fork := emptyChild(genesis, 4)
+	for _, b := range []*block.Block{b2, b3, fork} {
+		bc.HandleBlock(b)
+	}
+
+	info, err := bc.GetBlockInfoByHeight(2)
+	if err != nil || info.Hash != b2.Hash() || info.Block.Hash() != b2.Hash() || info.Confirmations != 2 {
+		t.Fatalf("Expected the block at height 2 with 2 confirmations: %v", err)
+	}
+	if info, err = bc.GetBlockInfo(fork.Hash()); err != nil || info.Height != 2 || info.Confirmations != 0 {
+		t.Errorf("Expected a block off the main chain to be unconfirmed: %v", err)
+	}
+	if !info.Status.Has(blockinfodatabase.StatusTreeValid) || info.ChainWork.Sign() <= 0 {
+		t.Errorf("Expected the block's status and chain work")
+	}
+	if _, err = bc.GetBlockInfo(emptyChild(b3, 5).Hash()); err == nil || bc.HasBlock(emptyChild(b3, 5).Hash()) {
+		t.Errorf("Expected an unknown block not to be found")
+	}
+	if _, err = bc.GetBlockInfoByHeight(4); err == nil {
+		t.Errorf("Expected no block above the tip")
+	}
+
+	// without a transaction index, transactions are found by block
+	txInfo, err := bc.FindTransaction(tx.Hash(), b2.Hash())
+	if err != nil || txInfo.Height != 2 || txInfo.Index != 0 || txInfo.Confirmations != 2 {
+		t.Fatalf("Expected to find the transaction in its block: %v", err)
+	}
+	if _, err = bc.FindTransaction(tx.Hash(), ""); err == nil {
+		t.Errorf("Expected a transaction not to be found without an index or a block")
+	}
+	if _, err = bc.FindTransaction(tx.Hash(), b3.Hash()); err == nil {
+		t.Errorf("Expected a transaction not to be found in another block")
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	coinbase := func(height uint32) *block.Transaction {
+		return &block.Transaction{
+			Outputs:  []*block.TransactionOutput{{Amount: 50, LockingScript: []byte{1}}},
+			LockTime: block.CoinbaseLockTime(height),
+		}
+	}
+	b2 := emptyChild(bc.LastBlock, 2)
+	b2.Transactions = []*block.Transaction{coinbase(2)}
+	b3 := emptyChild(b2, 3)
+	b3.Transactions = []*block.Transaction{coinbase(3), spend(b2.Transactions[0], 0, 1)}
+	b4 := emptyChild(b3, 4)
+	b4.Transactions = []*block.Transaction{coinbase(4)}
+	for _, b := range []*block.Block{b2, b3, b4} {
+		bc.HandleBlock(b)
+	}
+	AssertSize(t, int(bc.Length), 4)
+	if errs := bc.VerifyChain(0, blockchain.VerifyCoins); len(errs) != 0 {
+		t.Fatalf("Expected a consistent chain to verify, got %v", errs[0])
+	}
+
+	// spending the tip's coinbase behind the chain's back leaves the
+	// coins inconsistent with it
+	bc.CoinDB.StoreBlock([]*block.Transaction{spend(b4.Transactions[0], 0, 2)})
+	errs := bc.VerifyChain(0, blockchain.VerifyCoins)
+	AssertSize(t, len(errs), 1)
+	if errs[0].Hash != b4.Hash() || errs[0].Height != 4 {
+		t.Errorf("Expected the tip to be reported, got %v", errs[0])
+	}
+	// which only checking the coins finds
+	AssertSize(t, len(bc.VerifyChain(0, blockchain.VerifyUndo)), 0)
+	// and only if the tip is checked
+	AssertSize(t, len(bc.VerifyChain(1, blockchain.VerifyCoins)), 1)
+	bc.CoinDB.StoreBlock([]*block.Transaction{spend(b3.Transactions[0], 0, 3)})
+	AssertSize(t, len(bc.VerifyChain(1, blockchain.VerifyCoins)), 1)
+	AssertSize(t, len(bc.VerifyChain(2, blockchain.VerifyCoins)), 2)
+}
+
+func TestUTXOCommitment(t *testing.T) {
+	config := testConfig(0)
+	config.UTXOCommitmentHeight = 3
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	coinbase := func(height uint32, version uint32, digest []byte) *block.Transaction {
+		tx := &block.Transaction{
+			Version:  version,
+			Outputs:  []*block.TransactionOutput{{Amount: 50, LockingScript: []byte{1}}},
+			LockTime: block.CoinbaseLockTime(height),
+		}
+		if digest != nil {
+			tx.Outputs = append(tx.Outputs, &block.TransactionOutput{LockingScript: block.UTXOCommitmentScript(digest)})
+		}
+		return tx
+	}
+	tipState := func() []byte {
+		state, err := coindatabase.DecodeUTXOHash(bc.BlockInfoDB.GetBlockRecord(bc.LastHash).UTXOHash)
+		if err != nil {
+			t.Fatalf("Expected the tip to have a utxo hash: %v", err)
+		}
+		return state.Digest()
+	}
+
+	// blocks below the height needn't commit
+	b2 := emptyChild(bc.LastBlock, 2)
+	b2.Transactions = []*block.Transaction{coinbase(2, 0, nil)}
+	bc.HandleBlock(b2)
+	AssertSize(t, int(bc.Length), 2)
+	digest := bc.UTXOCommitment()
+	if !bytes.Equal(digest, tipState()) {
+		t.Fatalf("Expected the next block to commit to the tip's utxo hash")
+	}
+
+	// but from it on, they must commit to the coins their parent leaves
+	missing := emptyChild(b2, 3)
+	missing.Transactions = []*block.Transaction{coinbase(3, 1, nil)}
+	wrong := emptyChild(b2, 4)
+	wrong.Transactions = []*block.Transaction{coinbase(3, 2, make([]byte, 32))}
+	for _, b := range []*block.Block{missing, wrong} {
+		bc.HandleBlock(b)
+		if bc.LastHash == b.Hash() || !bc.BlockInfoDB.GetBlockRecord(b.Hash()).Status.Has(blockinfodatabase.StatusFailed) {
+			t.Errorf("Expected a block without the right commitment to be rejected")
+		}
+	}
+	b3 := emptyChild(b2, 5)
+	b3.Transactions = []*block.Transaction{coinbase(3, 3, digest), spend(b2.Transactions[0], 0, 1)}
+	bc.HandleBlock(b3)
+	if bc.LastHash != b3.Hash() {
+		t.Fatalf("Expected a block with the right commitment to be connected")
+	}
+	state, err := bc.CoinDB.UTXOHash()
+	if err != nil || !bytes.Equal(state.Digest(), tipState()) {
+		t.Errorf("Expected the tracked utxo hash to match the coins, got %v", err)
+	}
+
+	// a heavier branch is checked as it's connected
+	f3 := emptyChild(b2, 6)
+	f3.Transactions = []*block.Transaction{coinbase(3, 4, digest)}
+	forkState, err := coindatabase.DecodeUTXOHash(bc.BlockInfoDB.GetBlockRecord(b2.Hash()).UTXOHash)
+	if err != nil {
+		t.Fatalf("Expected the fork point to have a utxo hash: %v", err)
+	}
+	forkState.ConnectBlock(f3, &chainwriter.UndoBlock{})
+	f4 := emptyChild(f3, 7)
+	f4.Transactions = []*block.Transaction{coinbase(4, 5, forkState.Digest())}
+	bc.HandleBlock(f3)
+	bc.HandleBlock(f4)
+	if bc.LastHash != f4.Hash() {
+		t.Fatalf("Expected the heavier branch to become active")
+	}
+	if state, err = bc.CoinDB.UTXOHash(); err != nil || !bytes.Equal(state.Digest(), tipState()) {
+		t.Errorf("Expected the tracked utxo hash to follow the reorg, got %v", err)
+	}
+}
+
+func TestDeployments(t *testing.T) {
+	config := testConfig(0)
+	config.DeploymentPeriod = 4
+	config.DeploymentThreshold = 3
+	config.Deployments = []blockchain.Deployment{
+		{Name: "signaled", Bit: 1, StartTime: 0, Timeout: 1000},
+		{Name: "ignored", Bit: 2, StartTime: 0, Timeout: 6},
+	}
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	extend := func(n int, version uint32) {
+		for i := 0; i < n; i++ {
+			b := emptyChild(bc.LastBlock, uint32(bc.Length))
+			b.Header.Version = version
+			bc.HandleBlock(mine(b))
+		}
+	}
+	states := func() []blockchain.DeploymentState {
+		var states []blockchain.DeploymentState
+		for _, s := range bc.DeploymentStatuses() {
+			states = append(states, s.State)
+		}
+		return states
+	}
+
+	// nothing is signaled for in the first period
+	if bc.BlockVersion() != blockchain.VersionBitsTopBits || states()[0] != blockchain.DeploymentDefined {
+		t.Errorf("Expected no deployment to have started in the first period")
+	}
+	extend(3, 0)
+	AssertSize(t, int(bc.Length), 4)
+	if bc.BlockVersion() != blockchain.VersionBitsTopBits|1<<1|1<<2 {
+		t.Errorf("Expected blocks to signal for both started deployments, got %x", bc.BlockVersion())
+	}
+
+	// three of the second period's four blocks signal for the first
+	extend(3, blockchain.VersionBitsTopBits|1<<1)
+	if status := bc.DeploymentStatuses()[0]; status.State != blockchain.DeploymentStarted || status.Count != 3 || status.Elapsed != 3 || status.Since != 5 {
+		t.Errorf("Expected 3 of 3 blocks to signal since height 5, got %+v", status)
+	}
+	extend(1, 1<<1)
+	if s := states(); s[0] != blockchain.DeploymentLockedIn || s[1] != blockchain.DeploymentStarted {
+		t.Fatalf("Expected only the signaled deployment to lock in, got %v", s)
+	}
+	extend(4, 0)
+	if s := states(); s[0] != blockchain.DeploymentActive || s[1] != blockchain.DeploymentFailed {
+		t.Errorf("Expected the signaled deployment to activate and the other to time out, got %v", s)
+	}
+	if !bc.IsDeploymentActive("signaled", bc.LastHash) || bc.IsDeploymentActive("signaled", bc.BlockInfoDB.GetHashByHeight(8)) {
+		t.Errorf("Expected the deployment to be active only from height 13")
+	}
+	if bc.BlockVersion() != blockchain.VersionBitsTopBits {
+		t.Errorf("Expected blocks to stop signaling, got %x", bc.BlockVersion())
+	}
+}
+
+func TestOrphanPool(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	var connected []string
+	bc.OnOrphanConnected(func(b *block.Block) { connected = append(connected, b.Hash()) })
+	b1 := emptyChild(bc.LastBlock, 1)
+	b2 := emptyChild(b1, 2)
+	b3 := emptyChild(b2, 3)
+
+	// blocks that arrive before their parents are kept, not stored
+	bc.HandleBlock(b3)
+	bc.HandleBlock(b2)
+	AssertSize(t, bc.Orphans.Len(), 2)
+	if bc.BlockInfoDB.HasBlockRecord(b2.Hash()) || bc.Length != 1 {
+		t.Fatalf("Expected orphans not to be stored")
+	}
+
+	// once the parent arrives, every orphan waiting on it is handled
+	bc.HandleBlock(b1)
+	AssertSize(t, bc.Orphans.Len(), 0)
+	if bc.LastHash != b3.Hash() || bc.Length != 4 {
+		t.Fatalf("Expected the orphans to extend the chain")
+	}
+	if len(connected) != 2 || connected[0] != b2.Hash() || connected[1] != b3.Hash() {
+		t.Errorf("Expected both orphans to be reported in order, got %v", connected)
+	}
+
+	// a full pool evicts its oldest block
+	pool := blockchain.NewOrphanPool(2, time.Hour)
+	for i := uint32(0); i < 3; i++ {
+		pool.Add(emptyChild(b3, 10+i))
+	}
+	AssertSize(t, pool.Len(), 2)
+	if pool.Has(emptyChild(b3, 10).Hash()) || !pool.Has(emptyChild(b3, 12).Hash()) {
+		t.Errorf("Expected the oldest orphan to be evicted")
+	}
+	if pool.Add(emptyChild(b3, 12)) {
+		t.Errorf("Expected an orphan already in the pool not to be added again")
+	}
+
+	// and orphans are evicted once they expire
+	pool = blockchain.NewOrphanPool(2, time.Millisecond)
+	pool.Add(emptyChild(b3, 20))
+	time.Sleep(5 * time.Millisecond)
+	if evicted := pool.Expire(); evicted != 1 || pool.Len() != 0 {
+		t.Errorf("Expected the expired orphan to be evicted, evicted %v", evicted)
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	config := testConfig(0)
+	genesis := blockchain.GenesisBlock(config)
+	owner, _ := id.CreateSimpleID()
+	thief, _ := id.CreateSimpleID()
+	vault := &script.Vault{OwnerPublicKey: owner.GetPublicKeyBytes(), RecoveryPublicKey: owner.GetPublicKeyBytes()}
+	vaultScript, _ := proto.Marshal(script.EncodeVault(vault))
+	base := emptyChild(genesis, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: vaultScript}, {Amount: 5, LockingScript: vaultScript}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	// steal spends output index of base with a signature the vault doesn't accept
+	steal := func(index uint32) *block.Transaction {
+		sig, _ := base.Transactions[0].Outputs[index].MakeSignature(thief)
+		return &block.Transaction{
+			Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: base.Transactions[0].Hash(), OutputIndex: index, UnlockingScript: sig}},
+			Outputs: []*block.TransactionOutput{{Amount: 4, LockingScript: []byte{1}}},
+		}
+	}
+	// b1 is signed badly, which only a checkpoint can get it past
+	b1 := emptyChild(base, 1)
+	b1.Transactions = []*block.Transaction{steal(0)}
+	b2 := emptyChild(b1, 2)
+	config.Checkpoints = []blockchain.Checkpoint{{Height: 4, Hash: b2.Hash()}}
+	bc := openBlockChain(config)
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	if cp := bc.LastCheckpoint(); cp == nil || cp.Height != 4 {
+		t.Fatalf("Expected the last checkpoint to be at height 4")
+	}
+
+	// a checkpoint doesn't get a block past spending a coin that
+	// doesn't exist
+	bc.HandleBlock(base)
+	missing := emptyChild(base, 3)
+	missing.Transactions = []*block.Transaction{spend(MockedTransaction(), 9, 1)}
+	bc.HandleBlock(missing)
+	if bc.LastHash != base.Hash() {
+		t.Fatalf("Expected a block below the checkpoint spending a missing coin to be rejected")
+	}
+
+	// a block that conflicts with a checkpoint is rejected
+	conflicting := emptyChild(b1, 3)
+	bc.HandleBlock(b1)
+	if err := bc.ValidateHeader(conflicting.Header); err == nil {
+		t.Errorf("Expected a block conflicting with a checkpoint to be rejected")
+	}
+	if bc.LastHash != b1.Hash() {
+		t.Fatalf("Expected the block below the checkpoint to be connected without checking its signatures")
+	}
+
+	// before the checkpoint is reached, other branches are allowed
+	if err := bc.ValidateHeader(emptyChild(genesis, 4).Header); err != nil {
+		t.Errorf("Expected a fork before the checkpoint is reached to be allowed: %v", err)
+	}
+	bc.HandleBlock(b2)
+	if bc.LastHash != b2.Hash() {
+		t.Fatalf("Expected the checkpointed block to be connected")
+	}
+	// but once it is, a branch forking below it can never include it
+	if err := bc.ValidateHeader(emptyChild(genesis, 4).Header); err == nil {
+		t.Errorf("Expected a fork below a reached checkpoint to be rejected")
+	}
+	// blocks above the last checkpoint are checked as usual
+	b3 := emptyChild(b2, 5)
+	b3.Transactions = []*block.Transaction{steal(1)}
+	bc.HandleBlock(b3)
+	if bc.LastHash != b2.Hash() {
+		t.Errorf("Expected a badly signed block above the checkpoint to be rejected")
+	}
+}
+
+func TestUTXOSnapshot(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "snapshots")
+	defer os.RemoveAll(dir)
+	var configs []*blockchain.Config
+	for i := 0; i <= 1; i++ {
+		config := testConfig(i)
+		configs = append(configs, config)
+	}
+	genesis := blockchain.GenesisBlock(configs[0])
+	b1 := emptyChild(genesis, 1)
+	b1.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 30, LockingScript: []byte{1}}, {Amount: 20, LockingScript: []byte{2}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	b2 := emptyChild(b1, 2)
+
+	// the serving node writes a snapshot when it reaches a checkpoint
+	configs[0].Checkpoints = []blockchain.Checkpoint{{Height: 3, Hash: b2.Hash()}}
+	configs[0].SnapshotDirectory = dir
+	configs[0].SnapshotChunkSize = 1
+	server := openBlockChain(configs[0])
+	server.HandleBlock(b1)
+	server.HandleBlock(b2)
+	snapshots := server.Snapshots()
+	if len(snapshots) != 1 || snapshots[0].Height != 3 || snapshots[0].Hash != b2.Hash() {
+		t.Fatalf("Expected a snapshot at the checkpoint")
+	}
+	snapshot := snapshots[0]
+	AssertSize(t, len(snapshot.ChunkHashes), int(snapshot.Coins))
+	var coins []*coindatabase.DeltaCoin
+	for i, chunkHash := range snapshot.ChunkHashes {
+		pchunk, err := server.SnapshotChunk(3, uint32(i))
+		if err != nil {
+			t.Fatalf("Failed to read chunk %v: %v", i, err)
+		}
+		if hash, _ := coindatabase.HashSnapshotChunk(pchunk); !bytes.Equal(hash, chunkHash) {
+			t.Errorf("Expected chunk %v to match its advertised hash", i)
+		}
+		coins = append(coins, coindatabase.DecodeSnapshotChunk(pchunk)...)
+	}
+	if _, err := server.SnapshotChunk(3, snapshot.Coins); err == nil {
+		t.Errorf("Expected a chunk past the end to be refused")
+	}
+
+	// a new node loads it at a checkpoint that commits to its coins
+	digest, err := snapshot.Digest()
+	if err != nil {
+		t.Fatalf("Failed to decode the snapshot's hash: %v", err)
+	}
+	configs[1].Checkpoints = []blockchain.Checkpoint{{Height: 3, Hash: b2.Hash(), UTXOHash: digest}}
+	bc := openBlockChain(configs[1])
+	defer CleanUp([]*blockchain.BlockChain{server, bc})
+	if !snapshot.Matches(bc.SnapshotCheckpoint()) {
+		t.Fatalf("Expected the snapshot to match the checkpoint")
+	}
+	if err = bc.Headers.Add(b1.Header); err != nil {
+		t.Fatalf("Failed to add header: %v", err)
+	}
+	if err = bc.LoadSnapshot(b2, coins[1:]); err == nil {
+		t.Errorf("Expected coins that don't match the checkpoint to be refused")
+	}
+	if err = bc.LoadSnapshot(b2, coins); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if bc.Length != 3 || bc.LastHash != b2.Hash() || bc.CumulativeWork.Cmp(server.CumulativeWork) != 0 {
+		t.Errorf("Expected the chain to start at the checkpoint")
+	}
+	if !bc.IsPruned(b1.Hash()) || bc.BlockInfoDB.GetHashByHeight(2) != b1.Hash() {
+		t.Errorf("Expected the blocks below the snapshot to be recorded as pruned")
+	}
+	cl := coindatabase.CoinLocator{ReferenceTransactionHash: b1.Transactions[0].Hash(), OutputIndex: 1}
+	if coin := bc.CoinDB.GetCoin(cl); coin == nil || coin.TransactionOutput.Amount != 20 {
+		t.Errorf("Expected the snapshot's coins to be loaded")
+	}
+	// blocks above the snapshot are validated as usual
+	b3 := emptyChild(b2, 3)
+	b3.Transactions = []*block.Transaction{spend(b1.Transactions[0], 0, 1)}
+	b3.Transactions[0].Outputs[0].Amount = 30
+	if err = bc.HandleBlock(b3); err != nil || bc.LastHash != b3.Hash() {
+		t.Errorf("Expected a block spending a snapshot coin to be connected: %v", err)
+	}
+	if err = bc.LoadSnapshot(b2, coins); err == nil {
+		t.Errorf("Expected a snapshot to be refused once the chain has moved on")
+	}
+}
+
+func TestIntegrityChecker(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	b1 := emptyChild(bc.LastBlock, 1)
+	b1.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 30, LockingScript: []byte{1}}, {Amount: 20, LockingScript: []byte{2}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	b2 := emptyChild(b1, 2)
+	b2.Transactions = []*block.Transaction{spend(b1.Transactions[0], 0, 1)}
+	bc.HandleBlock(b1)
+	bc.HandleBlock(b2)
+	if bc.LastHash != b2.Hash() {
+		t.Fatalf("Expected both blocks to be connected")
+	}
+
+	// a consistent chain raises nothing, and a pass ends at the genesis
+	// block
+	ic := bc.NewIntegrityChecker(&sync.Mutex{})
+	if errs := ic.Step(); len(errs) != 0 {
+		t.Errorf("Expected no inconsistencies, got %v", errs)
+	}
+	AssertSize(t, int(ic.Passes()), 1)
+
+	// a coin a block spent showing up again is caught
+	spent := &coindatabase.DeltaCoin{
+		Locator:           coindatabase.CoinLocator{ReferenceTransactionHash: b1.Transactions[0].Hash(), OutputIndex: 0},
+		TransactionOutput: b1.Transactions[0].Outputs[0],
+	}
+	if err := bc.CoinDB.ApplyUTXODelta(&coindatabase.UTXODelta{Created: []*coindatabase.DeltaCoin{spent}}); err != nil {
+		t.Fatalf("Failed to corrupt the coins: %v", err)
+	}
+	errs := ic.Step()
+	if len(errs) != 1 || errs[0].Hash != b2.Hash() || errs[0].Height != 3 {
+		t.Fatalf("Expected the block that spent the coin to be reported, got %v", errs)
+	}
+	select {
+	case alert := <-ic.Alerts:
+		if alert.Hash != b2.Hash() {
+			t.Errorf("Expected the alert to be for the block that spent the coin")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected an alert")
+	}
+}
+
+func TestMaxReorgDepth(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	genBlock := bc.LastBlock
+	main := genBlock
+	for i := uint32(0); i < 7; i++ {
+		main = emptyChild(main, 1)
+		bc.HandleBlock(main)
+	}
+	var branch []*block.Block
+	prev := genBlock
+	for i := uint32(0); i < 8; i++ {
+		prev = emptyChild(prev, 2)
+		branch = append(branch, prev)
+		bc.HandleBlock(prev)
+	}
+
+	// the branch has more work, but would disconnect all 7 blocks
+	if bc.LastHash != main.Hash() {
+		t.Fatalf("Expected a reorg deeper than the limit to be refused")
+	}
+	refused := bc.RefusedReorgs()
+	AssertSize(t, len(refused), 1)
+	if refused[0].Tip != prev.Hash() || refused[0].Ancestor != genBlock.Hash() || refused[0].Depth != 7 {
+		t.Errorf("Expected the refused reorg to end at the branch's tip, got %+v", refused[0])
+	}
+
+	// only a refused reorg can be confirmed
+	if err := bc.ConfirmReorg(branch[6].Hash()); err == nil {
+		t.Errorf("Expected confirming a block that isn't a refused tip to fail")
+	}
+	if err := bc.ConfirmReorg(prev.Hash()); err != nil {
+		t.Fatalf("Failed to confirm the reorg: %v", err)
+	}
+	if bc.LastHash != prev.Hash() || bc.Length != 9 {
+		t.Errorf("Expected the confirmed branch to be the active chain")
+	}
+	AssertSize(t, len(bc.RefusedReorgs()), 0)
+}
+
+func TestBlockInputSums(t *testing.T) {
+	bc := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	base := emptyChild(bc.LastBlock, 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}, {Amount: 7, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	bc.HandleBlock(base)
+
+	// the coins the block spends are gone once it's connected, as is
+	// the one it creates and spends itself
+	parent := spend(base.Transactions[0], 0, 1)
+	b := emptyChild(base, 1)
+	b.Transactions = []*block.Transaction{parent, spend(parent, 0, 1), spend(base.Transactions[0], 1, 1)}
+	bc.HandleBlock(b)
+	if bc.LastHash != b.Hash() {
+		t.Fatalf("Expected the block to be connected")
+	}
+	sums, err := bc.BlockInputSums(b)
+	if err != nil {
+		t.Fatalf("Failed to get the block's input sums: %v", err)
+	}
+	if fmt.Sprint(sums) != "[5 1 7]" {
+		t.Errorf("Expected input sums of [5 1 7], got %v", sums)
+	}
+}
+
+func TestAssumeValidSkipsScriptChecks(t *testing.T) {
+	openWith := func(assumeValid string) *blockchain.BlockChain {
+		config := testConfig(0)
+		config.AssumeValid = assumeValid
+		return openBlockChain(config)
+	}
+	owner, _ := id.CreateSimpleID()
+	thief, _ := id.CreateSimpleID()
+	vault := &script.Vault{OwnerPublicKey: owner.GetPublicKeyBytes(), RecoveryPublicKey: owner.GetPublicKeyBytes()}
+	vaultScript, _ := proto.Marshal(script.EncodeVault(vault))
+	base := emptyChild(blockchain.GenesisBlock(blockchain.DefaultConfig()), 1)
+	base.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: vaultScript}, {Amount: 5, LockingScript: vaultScript}},
+		LockTime: block.CoinbaseLockTime(2),
+	}}
+	// steal spends output index of base with a signature the vault doesn't accept
+	steal := func(index uint32) *block.Transaction {
+		sig, _ := base.Transactions[0].Outputs[index].MakeSignature(thief)
+		return &block.Transaction{
+			Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: base.Transactions[0].Hash(), OutputIndex: index, UnlockingScript: sig}},
+			Outputs: []*block.TransactionOutput{{Amount: 4, LockingScript: []byte{1}}},
+		}
+	}
+	signedBadly := emptyChild(base, 1)
+	signedBadly.Transactions = []*block.Transaction{steal(0)}
+	trusted := emptyChild(signedBadly, 1)
+	above := emptyChild(trusted, 1)
+	above.Transactions = []*block.Transaction{steal(1)}
+	handle := func(bc *blockchain.BlockChain, blocks ...*block.Block) {
+		for _, b := range blocks {
+			bc.HandleBlock(b)
+		}
+	}
+
+	// without the trusted block's header, nothing is assumed valid
+	bc := openWith(trusted.Hash())
+	handle(bc, base, signedBadly)
+	if bc.LastHash != base.Hash() {
+		t.Errorf("Expected a badly signed block to be checked before the trusted block's header arrives")
+	}
+	CleanUp([]*blockchain.BlockChain{bc})
+
+	// with it, its ancestors' signatures aren't checked, but the
+	// blocks above it are
+	bc = openWith(trusted.Hash())
+	for _, b := range []*block.Block{base, signedBadly, trusted} {
+		if err := bc.Headers.Add(b.Header); err != nil {
+			t.Fatalf("Failed to add the header of %v: %v", b.NameTag(), err)
+		}
+	}
+	handle(bc, base, signedBadly, trusted, above)
+	if bc.LastHash != trusted.Hash() {
+		t.Errorf("Expected the trusted block's ancestors to be assumed valid, and nothing above it")
+	}
+	CleanUp([]*blockchain.BlockChain{bc})
+
+	// everything else about its ancestors is still checked
+	overpaid := emptyChild(base, 1)
+	overpaid.Transactions = []*block.Transaction{{
+		Outputs:  []*block.TransactionOutput{{Amount: blockchain.DefaultConfig().BlockSubsidy + 1, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(3),
+	}}
+	bc = openWith(overpaid.Hash())
+	defer CleanUp([]*blockchain.BlockChain{bc})
+	bc.Headers.Add(base.Header)
+	bc.Headers.Add(overpaid.Header)
+	handle(bc, base, overpaid)
+	if bc.LastHash != base.Hash() {
+		t.Errorf("Expected an assumed valid block's coinbase to still be checked")
+	}
+}
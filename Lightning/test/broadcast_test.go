@@ -0,0 +1,114 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/broadcast"
+	"Coin/pkg/id"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sentLog records the peers a Broadcaster sends to.
+type sentLog struct {
+	mutex sync.Mutex
+	sends chan string
+	peers map[string]int
+}
+
+func newSentLog() *sentLog {
+	return &sentLog{sends: make(chan string, 100), peers: make(map[string]int)}
+}
+
+func (s *sentLog) send(addr string, tx *block.Transaction) error {
+	s.mutex.Lock()
+	s.peers[addr]++
+	s.mutex.Unlock()
+	s.sends <- addr
+	return nil
+}
+
+// wait waits for n sends, and returns the peers they went to.
+func (s *sentLog) wait(t *testing.T, n int) map[string]bool {
+	peers := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		select {
+		case addr := <-s.sends:
+			peers[addr] = true
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %v sends, got %v", n, i)
+		}
+	}
+	return peers
+}
+
+func TestBroadcasterRetriesAcrossPeers(t *testing.T) {
+	config := broadcast.DefaultConfig()
+	config.PeersPerAttempt = 2
+	config.MaxAttempts = 3
+	log := newSentLog()
+	peers := []string{"a", "b", "c", "d"}
+	b := broadcast.New(config, log.send, func() []string { return peers })
+	tx := MockedTransaction()
+
+	b.Track(tx)
+	first := log.wait(t, 2)
+	// not due yet
+	b.Tick(time.Now())
+	// the second attempt goes to the peers that haven't been tried
+	b.Tick(time.Now().Add(config.RebroadcastInterval))
+	second := log.wait(t, 2)
+	for addr := range second {
+		if first[addr] {
+			t.Errorf("Expected the retry to go to new peers, but %v was sent to twice", addr)
+		}
+	}
+	status := b.GetStatus(tx.Hash())
+	if status.Attempts != 2 || status.SentTo != 4 || status.Accepted {
+		t.Errorf("Unexpected status %+v", status)
+	}
+
+	if b.Echo("not a transaction", "a") {
+		t.Errorf("Expected an echo of an untracked transaction to be ignored")
+	}
+	b.Echo(tx.Hash(), "a")
+	if !b.GetStatus(tx.Hash()).Accepted {
+		t.Errorf("Expected the transaction to be accepted once echoed")
+	}
+	b.Confirm(tx.Hash())
+	if b.GetStatus(tx.Hash()) != nil {
+		t.Errorf("Expected a confirmed transaction to no longer be tracked")
+	}
+}
+
+func TestBroadcasterGivesUp(t *testing.T) {
+	config := broadcast.DefaultConfig()
+	config.MaxAttempts = 2
+	log := newSentLog()
+	b := broadcast.New(config, log.send, func() []string { return []string{"a"} })
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 1, 100)
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+	AssertBalance(t, w, 0)
+
+	b.Track(tx)
+	now := time.Now()
+	for i := 1; i <= 2; i++ {
+		b.Tick(now.Add(time.Duration(i) * config.RebroadcastInterval))
+	}
+	log.wait(t, 2)
+	select {
+	case failed := <-b.Failed:
+		if failed.Hash() != tx.Hash() {
+			t.Fatalf("Expected %v to fail, got %v", tx.NameTag(), failed.NameTag())
+		}
+		w.HandleBroadcastFailure(failed)
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the broadcast to fail")
+	}
+	if b.GetStatus(tx.Hash()) != nil {
+		t.Errorf("Expected a failed transaction to no longer be tracked")
+	}
+	AssertBalance(t, w, 100)
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/capture"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCaptureAndReplay(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "capture")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture.log")
+
+	genesis := NewGenesisNode()
+	conf := setNodeConfig(pkg.DefaultConfig(GetFreePort()), 1)
+	conf.CaptureConfig.Path = path
+	captured := NewNode(conf)
+	fresh := NewNode(setNodeConfig(pkg.DefaultConfig(GetFreePort()), 2))
+	defer CleanUp([]*blockchain.BlockChain{genesis.BlockChain, captured.BlockChain, fresh.BlockChain})
+	cluster := []*pkg.Node{genesis, captured}
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+
+	b := emptyChild(genesis.BlockChain.LastBlock, 1)
+	genesis.HandleMinerBlock(b)
+	for i := 0; i < 40 && captured.TipHash() != b.Hash(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if captured.TipHash() != b.Hash() {
+		t.Fatalf("Expected the capturing node to receive the block")
+	}
+	captured.Kill()
+
+	records, err := capture.Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read capture: %v", err)
+	}
+	inbound := map[string]bool{}
+	outbound := 0
+	for _, r := range records {
+		if r.Direction == capture.Inbound {
+			inbound[r.Method] = true
+		} else {
+			outbound++
+		}
+	}
+	if !inbound["/Coin/ForwardBlock"] || !inbound["/Coin/Version"] {
+		t.Errorf("Expected the received version and block to be captured, got %v", inbound)
+	}
+	if outbound == 0 {
+		t.Errorf("Expected sent messages to be captured")
+	}
+
+	fresh.Start()
+	defer fresh.Kill()
+	for _, r := range capture.Replay(records, fresh, fresh.LightningNode) {
+		if r.Record.Method == "/Coin/ForwardBlock" && r.Err != nil {
+			t.Errorf("Expected replaying the block to succeed: %v", r.Err)
+		}
+	}
+	if fresh.TipHash() != b.Hash() {
+		t.Errorf("Expected replaying the capture to give the fresh node the block")
+	}
+	genesis.Kill()
+}
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/chainparams"
+	"Coin/pkg/launch"
+	"bytes"
+	"testing"
+)
+
+func TestChainParams(t *testing.T) {
+	AssertSize(t, len(chainparams.Names()), 3)
+	if _, err := chainparams.Get("nonet"); err == nil {
+		t.Errorf("Expected an unknown network to be rejected")
+	}
+	genesisHashes := make(map[string]bool)
+	magics := make(map[uint32]bool)
+	for _, name := range chainparams.Names() {
+		params, err := chainparams.Get(name)
+		if err != nil {
+			t.Fatalf("Failed to get %v: %v", name, err)
+		}
+		genesisHashes[params.GenesisBlock().Hash()] = true
+		magics[params.Magic] = true
+	}
+	if len(genesisHashes) != 3 || len(magics) != 3 {
+		t.Errorf("Expected every network to have its own genesis block and magic")
+	}
+	// the main network keeps the defaults
+	if chainparams.Main.GenesisBlock().Hash() != blockchain.GenesisBlock(blockchain.DefaultConfig()).Hash() ||
+		chainparams.Main.Magic != chainwriter.DefaultMagic {
+		t.Errorf("Expected the main network to use the default genesis block and magic")
+	}
+
+	c := pkg.DefaultConfig(0)
+	c.UseParams(chainparams.RegTest)
+	if c.Port != chainparams.RegTest.DefaultPort || c.ChainConfig.Magic != chainparams.RegTest.Magic ||
+		c.MinerConfig.SubsidyHalvingRate != chainparams.RegTest.SubsidyHalvingRate {
+		t.Errorf("Expected the config to take the network's settings")
+	}
+	if c = pkg.DefaultConfig(41000); c.Port != 41000 {
+		t.Errorf("Expected a given port to be kept, got %v", c.Port)
+	}
+
+	address := chainparams.TestNet.FormatAddress([]byte{1, 2, 3})
+	if pk, err := chainparams.TestNet.ParseAddress(address); err != nil || !bytes.Equal(pk, []byte{1, 2, 3}) {
+		t.Errorf("Expected an address to parse back to its public key: %v", err)
+	}
+	if _, err := chainparams.Main.ParseAddress(address); err == nil {
+		t.Errorf("Expected an address for another network to be rejected")
+	}
+
+	if err := (&launch.Topology{Name: "net", Miners: 1, Network: "nonet"}).Validate(); err == nil {
+		t.Errorf("Expected a topology on an unknown network to be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/checkpoint"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayBlockAfterCrash(t *testing.T) {
+	config := coindatabase.DefaultConfig()
+	config.DatabasePath = "coindata_test"
+	defer os.RemoveAll(config.DatabasePath)
+	coinDB := coindatabase.New(config)
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	if err := coinDB.Flush(genBlock.Hash()); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	parent := genBlock.Transactions[0]
+	child := &block.Transaction{
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	coinDB.StoreBlock([]*block.Transaction{child})
+	// crash without flushing the spent coin out of the main cache
+	coinDB.Close()
+
+	coinDB = coindatabase.New(config)
+	defer coinDB.Close()
+	if coinDB.FlushMarker() != genBlock.Hash() {
+		t.Errorf("Expected the flush marker to be {%v}, got {%v}", genBlock.Hash(), coinDB.FlushMarker())
+	}
+	spent := coindatabase.CoinLocator{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}
+	if coinDB.GetCoin(spent) == nil {
+		t.Fatalf("Expected the spent coin to come back after the crash")
+	}
+	for i := 0; i < 2; i++ {
+		coinDB.ReplayBlock([]*block.Transaction{child})
+		if coinDB.GetCoin(spent) != nil {
+			t.Errorf("Expected replaying to remove the spent coin")
+		}
+		created := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}
+		if coinDB.GetCoin(created) == nil {
+			t.Errorf("Expected replaying to keep the created coin")
+		}
+	}
+	if _, err := coinDB.PruneSpent(); err != nil || coinDB.FlushMarker() != genBlock.Hash() {
+		t.Errorf("Expected pruning to leave the flush marker alone (%v)", err)
+	}
+}
+
+func TestCheckpointRecover(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "checkpoint")
+	defer os.RemoveAll(dir)
+	config := &checkpoint.Config{Path: filepath.Join(dir, "checkpoint.json"), Interval: 3}
+	bc := newTestBlockChain()
+	m := checkpoint.New(config)
+	if m.Last != nil {
+		t.Fatalf("Expected no checkpoint yet")
+	}
+	prev := bc.LastBlock
+	for i := 0; i < 7; i++ {
+		prev = emptyChild(prev, uint32(i))
+		bc.HandleBlock(prev)
+		m.HandleBlock(bc, uint32(i+1))
+	}
+	// checkpoints were made after the 3rd and 6th blocks
+	cp, err := checkpoint.Read(config.Path)
+	if err != nil {
+		t.Fatalf("Failed to read checkpoint: %v", err)
+	}
+	if cp.Height != 7 || cp.WalletVersion != 6 || cp.Hash != bc.BlockInfoDB.GetHashByHeight(7) {
+		t.Errorf("Expected a checkpoint at height 7 with wallet version 6, got %+v", cp)
+	}
+	bc.Close()
+
+	resumed := newTestBlockChain()
+	defer CleanUp([]*blockchain.BlockChain{resumed})
+	if _, err = checkpoint.New(config).Recover(resumed, 5); err == nil {
+		t.Errorf("Expected a wallet behind the checkpoint to be rejected")
+	}
+	replayed, err := checkpoint.New(config).Recover(resumed, 7)
+	if err != nil || replayed != 1 {
+		t.Errorf("Expected to replay the 1 block after the checkpoint, replayed %v (%v)", replayed, err)
+	}
+	if err = resumed.CoinDB.Flush("not the checkpoint"); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	if _, err = checkpoint.New(config).Recover(resumed, 7); err == nil {
+		t.Errorf("Expected a checkpoint that disagrees with the coin database to be rejected")
+	}
+}
+
+func TestCheckpointFollowsWallet(t *testing.T) {
+	conf := setNodeConfig(GenesisConfig(GetFreePort()), 0)
+	conf.CheckpointConfig.Interval = 1
+	node := NewNode(conf)
+	defer CleanUp([]*blockchain.BlockChain{node.BlockChain})
+
+	b := emptyChild(node.BlockChain.LastBlock, 1)
+	if _, err := node.ForwardBlock(context.Background(), block.EncodeBlock(b)); err != nil {
+		t.Fatalf("Failed to forward the block: %v", err)
+	}
+	cp := node.Checkpoints.Last
+	if cp == nil || cp.Hash != b.Hash() {
+		t.Fatalf("Expected a checkpoint at the forwarded block, got %+v", cp)
+	}
+	if cp.WalletVersion != 1 || node.Wallet.StateVersion() != 1 {
+		t.Errorf("Expected the checkpoint to record the wallet after it handled the block, got wallet version %v", cp.WalletVersion)
+	}
+}
@@ -0,0 +1,421 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain/chainwriter"
+	"Coin/pkg/blockchain/coindatabase"
+	"Coin/pkg/pro"
+	"github.com/syndtr/goleveldb/leveldb"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTestCoinDB returns a CoinDatabase backed by a fresh directory,
+// along with a function that closes and removes it.
+func newTestCoinDB(t *testing.T) (*coindatabase.CoinDatabase, func()) {
+	t.Helper()
+	config := coindatabase.DefaultConfig()
+	config.DatabasePath = "coindata_test"
+	coinDB := coindatabase.New(config)
+	return coinDB, func() {
+		coinDB.Close()
+		if err := os.RemoveAll(config.DatabasePath); err != nil {
+			t.Errorf("could not remove %v", config.DatabasePath)
+		}
+	}
+}
+
+func TestStoreBlockSpendsOutputFromSameBlock(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+
+	parent := genBlock.Transactions[0]
+	child := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: parent.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	grandchild := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: child.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{}}},
+	}
+	txs := []*block.Transaction{child, grandchild}
+	if !coinDB.ValidateBlock(txs) {
+		t.Fatalf("block spending an output created earlier in the block should be valid")
+	}
+	if coinDB.ValidateBlock([]*block.Transaction{grandchild, child}) {
+		t.Errorf("block spending an output created later in the block should be invalid")
+	}
+	coinDB.StoreBlock(txs)
+	spent := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(spent); coin != nil && !coin.IsSpent {
+		t.Errorf("coin spent within the block should not be unspent")
+	}
+	created := coindatabase.CoinLocator{ReferenceTransactionHash: grandchild.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(created); coin == nil || coin.IsSpent {
+		t.Errorf("coin created by the last transaction should be unspent")
+	}
+}
+
+func TestStoreBlockTwiceIsNoOp(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	child := &block.Transaction{
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: genBlock.Transactions[0].Hash()}},
+		Outputs: []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{}}, {Amount: 5, LockingScript: []byte{}}},
+	}
+	grandchild := &block.Transaction{
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: child.Hash()}},
+		Outputs: []*block.TransactionOutput{{Amount: 4, LockingScript: []byte{}}},
+	}
+	coinDB.StoreBlock([]*block.Transaction{child})
+	coinDB.StoreBlock([]*block.Transaction{grandchild})
+	coinDB.FlushMainCache()
+
+	// storing the child's block again mustn't bring back the coin its
+	// grandchild spent
+	coinDB.StoreBlock([]*block.Transaction{child})
+	spent := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(spent); coin != nil && !coin.IsSpent {
+		t.Errorf("Expected storing a stored block not to restore a spent coin")
+	}
+	kept := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 1}
+	if coin := coinDB.GetCoin(kept); coin == nil || coin.IsSpent {
+		t.Errorf("Expected the unspent coin to be kept")
+	}
+}
+
+func TestValidateBlockRejectsDoubleSpendInBlock(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	txs := GenerateTransactions(genBlock.Transactions)
+	double := GenerateTransactions(genBlock.Transactions)[0]
+	double.Version = 1
+	if coinDB.ValidateBlock(append(txs, double)) {
+		t.Errorf("block spending the same coin twice should be invalid")
+	}
+}
+
+func TestValidateBlockRejectsDuplicateTransactions(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	parent := genBlock.Transactions[0]
+	if coinDB.ValidateBlock([]*block.Transaction{parent}) {
+		t.Errorf("transaction with the same hash as one with unspent coins should be invalid")
+	}
+	fresh := &block.Transaction{Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{}}}, LockTime: 1}
+	if coinDB.ValidateBlock([]*block.Transaction{fresh, fresh}) {
+		t.Errorf("block with the same transaction twice should be invalid")
+	}
+
+	// once all of its coins are spent, the hash may be used again
+	child := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: parent.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	coinDB.StoreBlock([]*block.Transaction{child})
+	if !coinDB.ValidateBlock([]*block.Transaction{parent}) {
+		t.Errorf("transaction with the same hash as one with only spent coins should be valid")
+	}
+	coinDB.FlushMainCache()
+	if !coinDB.ValidateBlock([]*block.Transaction{parent}) {
+		t.Errorf("transaction with the same hash as one with only spent coins should be valid after a flush")
+	}
+}
+
+func TestPruneSpentRemovesSpentCoins(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	parent := genBlock.Transactions[0]
+	child := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: parent.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	coinDB.StoreBlock([]*block.Transaction{child})
+	spent := coindatabase.CoinLocator{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(spent); coin == nil || !coin.IsSpent {
+		t.Fatalf("spent coin should still be in the main cache before pruning")
+	}
+	if _, err := coinDB.PruneSpent(); err != nil {
+		t.Fatalf("PruneSpent failed: %v", err)
+	}
+	if coin := coinDB.GetCoin(spent); coin != nil {
+		t.Errorf("spent coin should be gone after pruning")
+	}
+	created := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(created); coin == nil || coin.IsSpent {
+		t.Errorf("unspent coin should survive pruning")
+	}
+}
+
+func TestUndoCoinsRejectsMismatchedUndoBlock(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	parent := genBlock.Transactions[0]
+	child := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: parent.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	b := &block.Block{Header: genBlock.Header, Transactions: []*block.Transaction{child}}
+	coinDB.StoreBlock(b.Transactions)
+
+	bad := &chainwriter.UndoBlock{
+		TransactionInputHashes: []string{parent.Hash()},
+		OutputIndexes:          []uint32{1},
+		Amounts:                []uint32{parent.Outputs[0].Amount},
+		LockingScripts:         [][]byte{parent.Outputs[0].LockingScript},
+	}
+	if err := coinDB.UndoCoins([]*block.Block{b}, []*chainwriter.UndoBlock{bad}); err == nil {
+		t.Fatalf("undo block with the wrong output index should be rejected")
+	}
+	created := coindatabase.CoinLocator{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(created); coin == nil {
+		t.Fatalf("rejected undo should leave the coinDB untouched")
+	}
+
+	good := &chainwriter.UndoBlock{
+		TransactionInputHashes: []string{parent.Hash()},
+		OutputIndexes:          []uint32{0},
+		Amounts:                []uint32{parent.Outputs[0].Amount},
+		LockingScripts:         [][]byte{parent.Outputs[0].LockingScript},
+	}
+	if err := coinDB.UndoCoins([]*block.Block{b}, []*chainwriter.UndoBlock{good}); err != nil {
+		t.Fatalf("matching undo block should be accepted: %v", err)
+	}
+	if coin := coinDB.GetCoin(created); coin != nil {
+		t.Errorf("coin created by the undone block should be gone")
+	}
+	restored := coindatabase.CoinLocator{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(restored); coin == nil || coin.IsSpent {
+		t.Errorf("coin spent by the undone block should be unspent again")
+	}
+}
+
+func TestMigrateCoinRecordFromVersion0(t *testing.T) {
+	old := &coindatabase.CoinRecord{
+		Version:        0,
+		OutputIndexes:  []uint32{0, 1, 0},
+		Amounts:        []uint32{10, 20, 10},
+		LockingScripts: [][]byte{{0}, {1}, {0}},
+	}
+	cr, err := coindatabase.MigrateCoinRecord(old)
+	if err != nil {
+		t.Fatalf("Unable to migrate version 0 record: %v", err)
+	}
+	if cr.Version != coindatabase.CoinRecordVersion {
+		t.Errorf("Expected version %v, got %v", coindatabase.CoinRecordVersion, cr.Version)
+	}
+	AssertSize(t, len(cr.OutputIndexes), 2)
+	AssertSize(t, len(cr.Amounts), 2)
+	if _, err = coindatabase.MigrateCoinRecord(&coindatabase.CoinRecord{Version: coindatabase.CoinRecordVersion + 1}); err == nil {
+		t.Errorf("Expected an error migrating a record from a newer version")
+	}
+}
+
+func TestGetBalanceForPublicKey(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	parent := genBlock.Transactions[0]
+	watched := []byte{1, 2, 3}
+	lockingScript, _ := proto.Marshal(&pro.PayToPublicKey{PublicKey: watched})
+	child := &block.Transaction{
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: parent.Hash(),
+			OutputIndex:              0,
+		}},
+		Outputs: []*block.TransactionOutput{
+			{Amount: 10, LockingScript: lockingScript},
+			{Amount: 5, LockingScript: lockingScript},
+		},
+	}
+	coinDB.StoreBlock([]*block.Transaction{child})
+	if balance := coinDB.GetBalanceForPublicKey(watched); balance != 15 {
+		t.Errorf("Expected a balance of 15, got %v", balance)
+	}
+	if balance := coinDB.GetBalanceForPublicKey(parent.Outputs[0].LockingScript); balance != 0 {
+		t.Errorf("Expected spent coins not to count, got %v", balance)
+	}
+}
+
+func TestGetBalanceForPublicKeyMigratesOldRecords(t *testing.T) {
+	path := "coindata_test"
+	defer os.RemoveAll(path)
+
+	// write a version 0 record, which could repeat an output index
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	watched := []byte{1, 2, 3}
+	data, _ := proto.Marshal(&pro.CoinRecord{
+		Version:        0,
+		OutputIndexes:  []uint32{0, 0},
+		Amounts:        []uint32{10, 10},
+		LockingScripts: [][]byte{watched, watched},
+	})
+	if err = db.Put([]byte(strings.Repeat("a", block.HashLength)), data, nil); err != nil {
+		t.Fatalf("failed to store coin record: %v", err)
+	}
+	db.Close()
+
+	config := coindatabase.DefaultConfig()
+	config.DatabasePath = path
+	config.MigrateOnOpen = false
+	coinDB := coindatabase.New(config)
+	defer coinDB.Close()
+	if balance := coinDB.GetBalanceForPublicKey(watched); balance != 10 {
+		t.Errorf("Expected the repeated output to count once, got a balance of %v", balance)
+	}
+}
+
+func TestMainCacheStaysWithinByteBudget(t *testing.T) {
+	config := coindatabase.DefaultConfig()
+	config.DatabasePath = "coindata_test"
+	config.MainCacheCapacity = 1000
+	config.MainCacheByteBudget = 2000
+	coinDB := coindatabase.New(config)
+	defer func() {
+		coinDB.Close()
+		os.RemoveAll(config.DatabasePath)
+	}()
+	var outputs []*block.TransactionOutput
+	for i := 0; i < 20; i++ {
+		outputs = append(outputs, &block.TransactionOutput{Amount: 1, LockingScript: make([]byte, 300)})
+	}
+	tx := &block.Transaction{Outputs: outputs}
+	coinDB.StoreBlock([]*block.Transaction{tx})
+	if coinDB.MainCacheBytes() > config.MainCacheByteBudget {
+		t.Errorf("Expected the main cache to use at most %v bytes, used %v", config.MainCacheByteBudget, coinDB.MainCacheBytes())
+	}
+	for i := range outputs {
+		if coinDB.GetCoin(coindatabase.CoinLocator{ReferenceTransactionHash: tx.Hash(), OutputIndex: uint32(i)}) == nil {
+			t.Errorf("Expected coin %v to still be in the db", i)
+		}
+	}
+}
+
+func TestReadOnlyCoinDBWhileLocked(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+	coinDB.FlushMainCache()
+
+	config := coindatabase.DefaultConfig()
+	config.DatabasePath = "coindata_test"
+	config.ReadOnly = true
+	reader := coindatabase.New(config)
+	defer reader.Close()
+	cl := coindatabase.CoinLocator{ReferenceTransactionHash: genBlock.Transactions[0].Hash(), OutputIndex: 0}
+	if reader.GetCoin(cl) == nil {
+		t.Errorf("Expected the read-only coin db to see the genesis coin")
+	}
+	if _, err := reader.PruneSpent(); err == nil {
+		t.Errorf("Expected pruning a read-only coin db to fail")
+	}
+}
+
+func TestApplyUTXODeltaIsAllOrNothing(t *testing.T) {
+	coinDB, cleanUp := newTestCoinDB(t)
+	defer cleanUp()
+	genBlock := GenesisBlock()
+	coinDB.StoreBlock(genBlock.Transactions)
+
+	parent := genBlock.Transactions[0]
+	child := &block.Transaction{
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}},
+		Outputs: []*block.TransactionOutput{{Amount: 10, LockingScript: []byte{}}},
+	}
+	grandchild := &block.Transaction{
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: child.Hash(), OutputIndex: 0}},
+		Outputs: []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{}}},
+	}
+	b1 := &block.Block{Header: MockedHeader(), Transactions: []*block.Transaction{child}}
+	b2 := &block.Block{Header: MockedHeader(), Transactions: []*block.Transaction{grandchild}}
+
+	delta := coindatabase.MakeUTXODelta([]*block.Block{b1, b2})
+	AssertSize(t, len(delta.Spent), 1)
+	AssertSize(t, len(delta.Created), 1)
+	delta = coindatabase.DecodeUTXODelta(coindatabase.EncodeUTXODelta(delta))
+	if err := coinDB.ApplyUTXODelta(delta); err != nil {
+		t.Fatalf("Failed to apply delta: %v", err)
+	}
+	genesisCoin := coindatabase.CoinLocator{ReferenceTransactionHash: parent.Hash(), OutputIndex: 0}
+	if coinDB.GetCoin(genesisCoin) != nil {
+		t.Errorf("Expected the genesis coin to be spent")
+	}
+	created := coindatabase.CoinLocator{ReferenceTransactionHash: grandchild.Hash(), OutputIndex: 0}
+	if coin := coinDB.GetCoin(created); coin == nil || coin.TransactionOutput.Amount != 5 {
+		t.Fatalf("Expected the grandchild's coin to be unspent")
+	}
+
+	bad := &coindatabase.UTXODelta{Spent: []coindatabase.CoinLocator{created, genesisCoin}}
+	if err := coinDB.ApplyUTXODelta(bad); err == nil {
+		t.Errorf("Expected a delta spending a missing coin to fail")
+	}
+	if coinDB.GetCoin(created) == nil {
+		t.Errorf("Expected a failed delta to change nothing")
+	}
+}
+
+func TestValidationRejectsMalformedData(t *testing.T) {
+	if err := chainwriter.ValidateUndoBlock(MockedUndoBlock()); err == nil {
+		t.Errorf("Expected an undo block with mismatched lengths to be rejected")
+	}
+	b := &block.Block{Header: MockedHeader(), Transactions: []*block.Transaction{MockedTransaction()}}
+	if err := block.ValidateBlock(b); err == nil {
+		t.Errorf("Expected a block with an empty input hash to be rejected")
+	}
+	b.Transactions = GenesisBlock().Transactions
+	if err := block.ValidateBlock(b); err != nil {
+		t.Errorf("Expected a well formed block to be accepted: %v", err)
+	}
+
+	pcr := &pro.CoinRecord{
+		Version:        coindatabase.CoinRecordVersion,
+		OutputIndexes:  []uint32{0, 0},
+		Amounts:        []uint32{1, 2},
+		LockingScripts: [][]byte{{}, {}},
+	}
+	if err := coindatabase.ValidateCoinRecord(pcr); err == nil {
+		t.Errorf("Expected a coin record with a repeated output index to be rejected")
+	}
+	pcr.OutputIndexes = []uint32{0}
+	if err := coindatabase.ValidateCoinRecord(pcr); err == nil {
+		t.Errorf("Expected a coin record with mismatched lengths to be rejected")
+	}
+	pcr.Amounts, pcr.LockingScripts = []uint32{1}, [][]byte{{}}
+	if err := coindatabase.ValidateCoinRecord(pcr); err != nil {
+		t.Errorf("Expected a well formed coin record to be accepted: %v", err)
+	}
+}
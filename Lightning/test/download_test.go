@@ -0,0 +1,103 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/download"
+	"fmt"
+	"go.uber.org/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadReassignsStalledRanges(t *testing.T) {
+	var hashes []string
+	byHash := make(map[string]*block.Block)
+	prev := GenesisBlock()
+	for i := 0; i < 40; i++ {
+		prev = emptyChild(prev, uint32(i))
+		hashes = append(hashes, prev.Hash())
+		byHash[prev.Hash()] = prev
+	}
+	config := download.DefaultConfig()
+	config.RangeSize = 4
+	config.StallTimeout = time.Millisecond * 50
+	d := download.New(config, func(addr string, hash string) (*block.Block, error) {
+		switch addr {
+		case "slow":
+			time.Sleep(time.Second)
+		case "broken":
+			return nil, fmt.Errorf("connection refused")
+		case "fast":
+			time.Sleep(time.Millisecond)
+		}
+		return byHash[hash], nil
+	})
+
+	blocks, err := d.Download([]string{"slow", "broken", "fast"}, hashes)
+	if err != nil {
+		t.Fatalf("Failed to download: %v", err)
+	}
+	AssertSize(t, len(blocks), len(hashes))
+	for i, b := range blocks {
+		if b.Hash() != hashes[i] {
+			t.Fatalf("Expected block %v to be {%v}, got {%v}", i, hashes[i], b.Hash())
+		}
+	}
+	stats := d.Stats()
+	if !stats["slow"].Stalled || !stats["broken"].Stalled {
+		t.Errorf("Expected the slow and broken peers to be dropped")
+	}
+	if stats["fast"].Stalled || stats["fast"].Blocks != len(hashes) {
+		t.Errorf("Expected the fast peer to send every block, sent %v", stats["fast"].Blocks)
+	}
+
+	if _, err = d.Download([]string{"broken"}, hashes); err == nil {
+		t.Errorf("Expected a download with no working peers to fail")
+	}
+}
+
+func TestStreamIsWindowed(t *testing.T) {
+	var hashes []string
+	byHash := make(map[string]*block.Block)
+	prev := GenesisBlock()
+	for i := 0; i < 40; i++ {
+		prev = emptyChild(prev, uint32(i))
+		hashes = append(hashes, prev.Hash())
+		byHash[prev.Hash()] = prev
+	}
+	config := download.DefaultConfig()
+	config.RangeSize = 4
+	config.Window = 8
+	fetches := atomic.NewInt32(0)
+	d := download.New(config, func(addr string, hash string) (*block.Block, error) {
+		fetches.Inc()
+		return byHash[hash], nil
+	})
+
+	out := make(chan *block.Block)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- d.Stream([]string{"a", "b", "c"}, hashes, out)
+	}()
+	first := <-out
+	// while the first Block is being handled, only the window past it
+	// is downloaded
+	time.Sleep(time.Millisecond * 100)
+	if n := int(fetches.Load()); n > config.Window+config.RangeSize {
+		t.Errorf("Expected at most %v blocks to be downloaded ahead, got %v", config.Window+config.RangeSize, n)
+	}
+	blocks := []*block.Block{first}
+	for b := range out {
+		blocks = append(blocks, b)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("Failed to stream: %v", err)
+	}
+	AssertSize(t, len(blocks), len(hashes))
+	for i, b := range blocks {
+		if b.Hash() != hashes[i] {
+			t.Fatalf("Expected block %v to be {%v}, got {%v}", i, hashes[i], b.Hash())
+		}
+	}
+	AssertSize(t, int(fetches.Load()), len(hashes))
+}
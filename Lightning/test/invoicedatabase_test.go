@@ -0,0 +1,72 @@
+package test
+
+import (
+	"Coin/pkg/lightning/invoicedatabase"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestInvoiceDatabase(t *testing.T) {
+	config := &invoicedatabase.Config{DatabasePath: "invoicedata0"}
+	defer os.RemoveAll(config.DatabasePath)
+	invoiceDB := invoicedatabase.New(config)
+
+	incoming, err := invoiceDB.AddInvoice(50, "coffee")
+	if err != nil {
+		t.Fatalf("failed to add invoice: %v", err)
+	}
+	unpaid, _ := invoiceDB.AddInvoice(20, "tea")
+	preimage := []byte("a preimage only the payee knows")
+	paymentHash := sha256.Sum256(preimage)
+	if _, err = invoiceDB.AddPayment(paymentHash[:], 30, "rent"); err != nil {
+		t.Fatalf("failed to add payment: %v", err)
+	}
+	if _, err = invoiceDB.AddPayment(paymentHash[:], 30, "rent"); err == nil {
+		t.Errorf("expected adding the same payment twice to fail")
+	}
+
+	if _, err = invoiceDB.Settle(paymentHash[:], []byte("the wrong preimage")); err == nil {
+		t.Errorf("expected settling with the wrong preimage to fail")
+	}
+	if _, err = invoiceDB.Settle(paymentHash[:], preimage); err != nil {
+		t.Fatalf("failed to settle payment: %v", err)
+	}
+	if _, err = invoiceDB.Settle(incoming.PaymentHash, incoming.Preimage); err != nil {
+		t.Fatalf("failed to settle invoice: %v", err)
+	}
+
+	// the invoices survive a restart
+	invoiceDB.Close()
+	invoiceDB = invoicedatabase.New(config)
+	defer invoiceDB.Close()
+	paid := invoiceDB.GetInvoice(paymentHash[:])
+	if paid == nil || !paid.Settled() || !bytes.Equal(paid.Preimage, preimage) {
+		t.Fatalf("expected the payment to be settled with its preimage after reopening, got %+v", paid)
+	}
+	AssertSize(t, 3, len(invoiceDB.ListInvoices(nil)))
+	AssertSize(t, 2, len(invoiceDB.ListInvoices(&invoicedatabase.Query{SettledOnly: true})))
+	AssertSize(t, 2, len(invoiceDB.ListInvoices(&invoicedatabase.Query{IncomingOnly: true})))
+	AssertSize(t, 1, len(invoiceDB.ListInvoices(&invoicedatabase.Query{OutgoingOnly: true})))
+
+	var out bytes.Buffer
+	if err = invoiceDB.Export(&out, nil); err != nil {
+		t.Fatalf("failed to export invoices: %v", err)
+	}
+	var proofs []*invoicedatabase.SettlementProof
+	if err = json.Unmarshal(out.Bytes(), &proofs); err != nil {
+		t.Fatalf("failed to decode exported invoices: %v", err)
+	}
+	AssertSize(t, 3, len(proofs))
+	for _, proof := range proofs {
+		settled := proof.SettledAt != 0
+		if proof.Verify() != settled {
+			t.Errorf("expected the proof for %v to verify only if it was settled", proof.Memo)
+		}
+		if proof.Memo == unpaid.Memo && proof.Preimage != "" {
+			t.Errorf("expected the export not to reveal the preimage of an unpaid invoice")
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"Coin/pkg/journal"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestJournalKeepsMostRecentEventsAcrossRestart(t *testing.T) {
+	config := journal.DefaultConfig()
+	config.Path = "journal_test"
+	config.Capacity = 3
+	defer os.Remove(config.Path)
+
+	j := journal.New(config)
+	for i := 0; i < 10; i++ {
+		j.Record(journal.BlockConnected, strconv.Itoa(i), "")
+	}
+	events := j.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %v", len(events))
+	}
+
+	reloaded := journal.New(config).Events()
+	if len(reloaded) != 3 {
+		t.Fatalf("Expected 3 events after reload, got %v", len(reloaded))
+	}
+	for i, e := range reloaded {
+		if e.Hash != strconv.Itoa(7+i) {
+			t.Errorf("Expected event %v to have hash %v, got %v", i, 7+i, e.Hash)
+		}
+	}
+}
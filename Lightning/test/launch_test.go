@@ -0,0 +1,90 @@
+package test
+
+import (
+	"Coin/pkg/launch"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTopologyEdgesAndPlan(t *testing.T) {
+	topology, err := launch.Load("ring")
+	if err != nil {
+		t.Fatalf("Failed to load the ring profile: %v", err)
+	}
+	AssertSize(t, len(topology.Edges()), topology.Size())
+	topology.Layout = launch.LayoutStar
+	AssertSize(t, len(topology.Edges()), topology.Size()-1)
+	topology.Layout = ""
+	AssertSize(t, len(topology.Edges()), topology.Size()*(topology.Size()-1)/2)
+
+	if _, err = launch.Load("no such profile or file"); err == nil {
+		t.Errorf("Expected loading an unknown topology to fail")
+	}
+	bad := &launch.Topology{Name: "bad", Miners: 2, Links: [][2]int{{0, 2}}}
+	if err = bad.Validate(); err == nil {
+		t.Errorf("Expected a link to a missing node to be rejected")
+	}
+
+	dir, _ := ioutil.TempDir("", "launch")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "topology.json")
+	ioutil.WriteFile(path, []byte(`{"name": "mine", "miners": 1, "wallets": 1, "lightning": 1, "links": [[0, 1], [1, 2]], "base_port": 41000, "announce_delay_ms": 250}`), 0644)
+	topology, err = launch.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load topology file: %v", err)
+	}
+	p, err := topology.Plan()
+	if err != nil {
+		t.Fatalf("Failed to plan topology: %v", err)
+	}
+	AssertSize(t, len(p.Nodes), 3)
+	roles := []string{launch.RoleMiner, launch.RoleWallet, launch.RoleLightning}
+	for i, spec := range p.Nodes {
+		if spec.Role != roles[i] || spec.Port != 41000+i {
+			t.Errorf("Expected node %v to be a %v on port %v, got a %v on port %v", i, roles[i], 41000+i, spec.Role, spec.Port)
+		}
+	}
+	AssertSize(t, len(p.Nodes[1].Peers), 2)
+	if c := p.Nodes[1].Config(); c.MinerConfig.HasMiner || c.HasCustomId {
+		t.Errorf("Expected node 1 not to mine, and not to be the genesis node")
+	}
+	if c := p.Nodes[0].Config(); !c.MinerConfig.HasMiner || !c.HasCustomId {
+		t.Errorf("Expected node 0 to be the genesis miner")
+	}
+	if c := p.Nodes[2].Config(); c.BlockAnnounceDelay != 250*time.Millisecond {
+		t.Errorf("Expected blocks to be announced after 250ms, got %v", c.BlockAnnounceDelay)
+	}
+
+	planPath := filepath.Join(dir, "plan.json")
+	if err = p.Write(planPath); err != nil {
+		t.Fatalf("Failed to write plan: %v", err)
+	}
+	read, err := launch.ReadPlan(planPath)
+	if err != nil || len(read.Nodes) != 3 || read.Nodes[2].DataDir != p.Nodes[2].DataDir {
+		t.Errorf("Expected to read back the plan that was written, got %v (%v)", read, err)
+	}
+}
+
+func TestLaunchInProcess(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "launch")
+	defer os.RemoveAll(dir)
+	topology := &launch.Topology{Name: "pair", Miners: 1, Wallets: 1, DataDir: dir}
+	network, err := launch.Launch(topology, "")
+	if err != nil {
+		t.Fatalf("Failed to launch network: %v", err)
+	}
+	defer network.Kill()
+	AssertSize(t, len(network.Nodes), 2)
+	for i, n := range network.Nodes {
+		AssertSize(t, len(n.PeerDb.List()), 1)
+		if n.Config.MinerConfig.HasMiner != (i == 0) {
+			t.Errorf("Expected only node 0 to mine")
+		}
+	}
+	if _, err = os.Stat(launch.PlanPath(topology)); err != nil {
+		t.Errorf("Expected the plan to be written: %v", err)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"Coin/pkg/script"
 	"Coin/pkg/utils"
 	"bytes"
+	"context"
+	"strings"
 	"testing"
 
 	"google.golang.org/protobuf/proto"
@@ -61,6 +63,8 @@ func TestForwardTransaction(t *testing.T) {
 	ConnectCluster(cluster)
 	peer := cluster[0].PeerDb.Get(cluster[1].Address)
 	tx := MockedTransaction()
+	// forwarded transactions have to reference what they spend by its hash
+	tx.Inputs[0].ReferenceTransactionHash = MockedTransaction().Hash()
 	ptx := block.EncodeTransaction(tx)
 	sig, _ := utils.Sign(cluster[1].Id.GetPrivateKey(), []byte(tx.Hash()))
 	tx.Witnesses = [][]byte{sig}
@@ -474,6 +478,93 @@ func TestUpdateState(t *testing.T) {
 	}
 }
 
+func TestProbe(t *testing.T) {
+	cluster := NewCluster(2)
+	chains := []*blockchain.BlockChain{cluster[0].BlockChain, cluster[1].BlockChain}
+	defer CleanUp(chains)
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	FillWalletWithCoins(cluster[0].Wallet, 100, 100)
+	lightning0 := cluster[0].LightningNode
+	lightning1 := cluster[1].LightningNode
+	peer1 := lightning0.PeerDb.Get(lightning1.Address)
+	lightning0.CreateChannel(peer1, lightning1.Id.GetPublicKeyBytes(), 100, 10)
+	lightning0.UpdateState(peer1, MakeUpdatedTransaction(t, lightning0, peer1, 20, true))
+
+	// Alice has 80 on her side of the channel, and Bob has 20
+	for _, c := range []struct {
+		from       *lightning.LightningNode
+		to         *lightning.LightningNode
+		amount     uint32
+		sufficient bool
+	}{
+		{lightning0, lightning1, 80, true},
+		{lightning0, lightning1, 81, false},
+		{lightning1, lightning0, 20, true},
+		{lightning1, lightning0, 21, false},
+	} {
+		result, err := c.from.Probe(c.to.Address, c.amount)
+		if err != nil {
+			t.Fatalf("Probe failed: %v", err)
+		}
+		if result.Sufficient != c.sufficient {
+			t.Errorf("Probing %v: expected sufficient to be %v, got %v (%v)",
+				c.amount, c.sufficient, result.Sufficient, result.Failure)
+		}
+		if c.from.Router.Get(c.to.Address, c.amount) != result {
+			t.Errorf("Probing %v: expected the result to be cached", c.amount)
+		}
+	}
+	if _, err := lightning0.Probe("nowhere", 1); err == nil {
+		t.Errorf("Expected probing an unknown destination to fail")
+	}
+}
+
+func TestUpfrontShutdownScript(t *testing.T) {
+	cluster := NewCluster(2)
+	chains := []*blockchain.BlockChain{cluster[0].BlockChain, cluster[1].BlockChain}
+	defer CleanUp(chains)
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	FillWalletWithCoins(cluster[0].Wallet, 100, 100)
+	lightning0 := cluster[0].LightningNode
+	lightning1 := cluster[1].LightningNode
+	aliceScript := MakeLockingScript([]byte{1})
+	bobScript := MakeLockingScript([]byte{2})
+	lightning0.Config.UpfrontShutdownScript = aliceScript
+	lightning1.Config.UpfrontShutdownScript = bobScript
+	peer1 := lightning0.PeerDb.Get(lightning1.Address)
+	peer0 := lightning1.PeerDb.Get(lightning0.Address)
+	lightning0.CreateChannel(peer1, lightning1.Id.GetPublicKeyBytes(), 100, 10)
+	lightning0.UpdateState(peer1, MakeUpdatedTransaction(t, lightning0, peer1, 20, true))
+	if !bytes.Equal(lightning0.Channels[peer1].TheirShutdownScript, bobScript) {
+		t.Errorf("Expected the funder to know the fundee's shutdown script")
+	}
+	if !bytes.Equal(lightning1.Channels[peer0].TheirShutdownScript, aliceScript) {
+		t.Errorf("Expected the fundee to know the funder's shutdown script")
+	}
+
+	// a compromised funder tries to send its closing funds elsewhere
+	redirected, err := lightning0.Channels[peer1].ClosingTransaction(10, MakeLockingScript([]byte{3}), bobScript)
+	if err != nil {
+		t.Fatalf("Failed to make closing transaction: %v", err)
+	}
+	_, err = lightning1.CooperativeClose(context.Background(), &pro.CloseChannelRequest{
+		Address:            lightning0.Address,
+		ClosingTransaction: block.EncodeTransaction(redirected),
+	})
+	if err == nil {
+		t.Errorf("Expected a close to a different script to be refused")
+	}
+	AssertSize(t, 1, len(lightning1.Channels))
+
+	if err = lightning0.CloseChannel(peer1, 10); err != nil {
+		t.Fatalf("Failed to close channel: %v", err)
+	}
+	AssertSize(t, 0, len(lightning0.Channels))
+	AssertSize(t, 0, len(lightning1.Channels))
+}
+
 func TestWatchTowerHandleBlock(t *testing.T) {
 	i, _ := id.New(id.DefaultConfig())
 	wt := &lightning.WatchTower{
@@ -496,3 +587,47 @@ func TestWatchTowerHandleBlock(t *testing.T) {
 		t.Errorf("Block should NOT have caught this transaction")
 	}
 }
+
+func TestExportChannelHistory(t *testing.T) {
+	cluster := NewCluster(2)
+	chains := []*blockchain.BlockChain{cluster[0].BlockChain, cluster[1].BlockChain}
+	defer CleanUp(chains)
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	FillWalletWithCoins(cluster[0].Wallet, 100, 100)
+	lightning0 := cluster[0].LightningNode
+	lightning1 := cluster[1].LightningNode
+	peer1 := lightning0.PeerDb.Get(lightning1.Address)
+	lightning0.CreateChannel(peer1, lightning1.Id.GetPublicKeyBytes(), 100, 10)
+	lightning0.UpdateState(peer1, MakeUpdatedTransaction(t, lightning0, peer1, 20, true))
+	lightning0.UpdateState(peer1, MakeUpdatedTransaction(t, lightning0, peer1, 15, false))
+
+	channel := lightning0.Channels[peer1]
+	if _, err := lightning0.ExportChannelHistory("no such channel"); err == nil {
+		t.Errorf("Expected exporting an unknown channel to fail")
+	}
+	history, err := lightning0.ExportChannelHistory(channel.ChannelID())
+	if err != nil {
+		t.Fatalf("Failed to export channel history: %v", err)
+	}
+	AssertSize(t, len(history.Commitments), 3)
+	if !history.Funder || history.State != 2 || history.Peer != lightning1.Address {
+		t.Errorf("Expected a funded channel with %v at state 2, got %+v", lightning1.Address, history)
+	}
+	for i, r := range history.Commitments {
+		if r.State != i || r.MyTransaction != channel.MyTransactions[i].Hash() || r.TheirTransaction != channel.TheirTransactions[i].Hash() {
+			t.Errorf("Expected state %v to record the channel's transactions for it", i)
+		}
+		if r.Revoked != (i < 2) {
+			t.Errorf("Expected only the states before the current one to be revoked, got %v for state %v", r.Revoked, i)
+		}
+	}
+	last := history.Commitments[2]
+	mine, theirs := channel.Balances()
+	if last.MyBalance != mine || last.TheirBalance != theirs {
+		t.Errorf("Expected the current state to have balances %v and %v, got %v and %v", mine, theirs, last.MyBalance, last.TheirBalance)
+	}
+	if s := history.String(); !strings.Contains(s, channel.ChannelID()) || strings.Count(s, "\n") != 5 {
+		t.Errorf("Expected a header and a line per state, got:\n%v", s)
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/id"
+	"Coin/pkg/miner"
+	"testing"
+)
+
+// spending returns a Transaction spending the output of
+// referenceHash at outputIndex. version tells apart
+// Transactions that spend the same output.
+func spending(referenceHash string, outputIndex uint32, version uint32) *block.Transaction {
+	return &block.Transaction{
+		Version: version,
+		Inputs: []*block.TransactionInput{{
+			ReferenceTransactionHash: referenceHash,
+			OutputIndex:              outputIndex,
+			UnlockingScript:          []byte{},
+		}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{}}},
+	}
+}
+
+func TestTxPoolEvictsConflictsAndDescendants(t *testing.T) {
+	tp := miner.NewTxPool(miner.DefaultConfig(0))
+	funding := MockedTransaction()
+	parent := spending(funding.Hash(), 0, 1)
+	child := spending(parent.Hash(), 0, 1)
+	grandchild := spending(child.Hash(), 0, 1)
+	unrelated := spending(funding.Hash(), 1, 1)
+	for _, tx := range []*block.Transaction{parent, child, grandchild, unrelated} {
+		tp.Add(tx, 10)
+	}
+	AssertSize(t, int(tp.Length()), 4)
+
+	// a block confirms a different spend of parent's input
+	conflict := spending(funding.Hash(), 0, 2)
+	tp.CheckTransactions([]*block.Transaction{conflict})
+	AssertSize(t, int(tp.Length()), 1)
+	for _, tx := range []*block.Transaction{parent, child, grandchild} {
+		if tp.TxQ.Has(tx) {
+			t.Errorf("Expected %v to be evicted", tx.NameTag())
+		}
+	}
+	if !tp.TxQ.Has(unrelated) {
+		t.Errorf("Expected the unrelated transaction to stay in the pool")
+	}
+
+	// a block confirming the transaction itself evicts nothing else
+	tp.CheckTransactions([]*block.Transaction{unrelated})
+	AssertSize(t, int(tp.Length()), 0)
+	if tp.CurrentPriority.Load() != 0 {
+		t.Errorf("Expected no priority to be left in the pool, got %v", tp.CurrentPriority.Load())
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	i, _ := id.CreateSimpleID()
+	m := miner.New(miner.DefaultConfig(0), i)
+	funding := MockedTransaction()
+	txs := []*block.Transaction{spending(funding.Hash(), 0, 1), spending(funding.Hash(), 1, 1)}
+	for _, tx := range txs {
+		m.TxPool.Add(tx, 10)
+	}
+	// answer the miner's request for input sums, as the node would
+	go func() {
+		requested := <-m.GetInputSums
+		sums := make([]uint32, len(requested))
+		for j := range sums {
+			sums[j] = 10
+		}
+		m.InputSums <- sums
+	}()
+
+	template := m.PreviewTemplate()
+	AssertSize(t, len(template.Transactions), 2)
+	// each transaction spends 10 and pays out 1
+	if template.Fees != 18 || template.Subsidy != m.CalculateMintingReward() {
+		t.Errorf("Expected fees of 18 and a subsidy of %v, got %v and %v", m.CalculateMintingReward(), template.Fees, template.Subsidy)
+	}
+	if template.Reward() != template.Fees+template.Subsidy || template.Size == 0 || !template.PriorityMet {
+		t.Errorf("Expected a sized template that pays fees and subsidy, got %+v", template)
+	}
+	if m.Mining.Load() || len(m.MiningPool) != 0 || m.TxPool.Length() != 2 {
+		t.Errorf("Expected previewing not to start mining or change the pools")
+	}
+}
+
+func TestBlockLimits(t *testing.T) {
+	// a block's transactions may need at most MaxBlockSigOps signatures
+	// checked, counting each input and each output's locking script
+	manyOutputs := spending(MockedTransaction().Hash(), 0, 1)
+	for uint32(len(manyOutputs.Outputs)) < block.MaxBlockSigOps {
+		manyOutputs.Outputs = append(manyOutputs.Outputs, MockedTransactionOutput())
+	}
+	if block.SigOps(manyOutputs) != block.MaxBlockSigOps+1 {
+		t.Errorf("Expected %v sigops, got %v", block.MaxBlockSigOps+1, block.SigOps(manyOutputs))
+	}
+	b := MockedBlock()
+	b.Transactions = []*block.Transaction{manyOutputs}
+	if block.ValidateBlock(b) == nil {
+		t.Errorf("Expected a block with too many sigops to be rejected")
+	}
+	manyOutputs.Outputs = manyOutputs.Outputs[1:]
+	if err := block.ValidateBlock(b); err != nil {
+		t.Errorf("Expected a block with %v sigops to be valid, got %v", block.MaxBlockSigOps, err)
+	}
+
+	// and may be at most MaxBlockSize bytes
+	large := spending(MockedTransaction().Hash(), 0, 2)
+	large.Outputs[0].LockingScript = make([]byte, block.MaxBlockSize)
+	b.Transactions = []*block.Transaction{large}
+	if block.ValidateBlock(b) == nil {
+		t.Errorf("Expected a block larger than %v bytes to be rejected", block.MaxBlockSize)
+	}
+
+	// the miner leaves out transactions that would break either limit
+	i, _ := id.CreateSimpleID()
+	c := miner.DefaultConfig(0)
+	c.BlockSize = 2 * block.MaxBlockSize
+	m := miner.New(c, i)
+	small := spending(MockedTransaction().Hash(), 1, 1)
+	m.TxPool.Add(small, 100)
+	m.TxPool.Add(large, 10)
+	pool := m.NewMiningPool()
+	if len(pool) != 1 || pool[0] != small {
+		t.Errorf("Expected only the small transaction to be mined, got %v transactions", len(pool))
+	}
+}
+
+func TestMiningPoolSkipsLockedTransactions(t *testing.T) {
+	i, _ := id.CreateSimpleID()
+	m := miner.New(miner.DefaultConfig(0), i)
+	locked := spending(MockedTransaction().Hash(), 0, 1)
+	locked.LockTime = 100
+	final := spending(MockedTransaction().Hash(), 1, 1)
+	m.TxPool.Add(locked, 100)
+	m.TxPool.Add(final, 10)
+	m.IsFinal = func(tx *block.Transaction) bool { return tx.LockTime == 0 }
+	pool := m.NewMiningPool()
+	if len(pool) != 1 || pool[0] != final {
+		t.Errorf("Expected only the final transaction to be mined, got %v transactions", len(pool))
+	}
+	if !m.TxPool.TxQ.Has(locked) {
+		t.Errorf("Expected the locked transaction to stay in the pool")
+	}
+}
@@ -0,0 +1,28 @@
+package test
+
+import (
+	"Coin/pkg/profiling"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCaptureProfiles(t *testing.T) {
+	config := profiling.DefaultConfig()
+	config.MaxDuration = 50 * time.Millisecond
+	p := profiling.New(config, "profiling_test")
+	defer os.RemoveAll("profiling_test")
+
+	for _, kind := range []string{profiling.CPU, profiling.Heap, profiling.Goroutine} {
+		path, err := p.Capture(kind, time.Hour)
+		if err != nil {
+			t.Fatalf("Failed to capture %v profile: %v", kind, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Expected a %v profile at %v", kind, path)
+		}
+	}
+	if _, err := p.Capture("disk", time.Second); err == nil {
+		t.Errorf("Expected an unknown profile kind to fail")
+	}
+}
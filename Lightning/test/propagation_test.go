@@ -0,0 +1,252 @@
+package test
+
+import (
+	"Coin/pkg"
+	"Coin/pkg/address"
+	"Coin/pkg/block"
+	"Coin/pkg/blockchain"
+	"Coin/pkg/journal"
+	"Coin/pkg/pro"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBlockAnnounceDelay(t *testing.T) {
+	genesis := NewGenesisNode()
+	genesis.Config.BlockAnnounceDelay = 500 * time.Millisecond
+	peer := NewNode(setNodeConfig(pkg.DefaultConfig(GetFreePort()), 1))
+	cluster := []*pkg.Node{genesis, peer}
+	defer CleanUp([]*blockchain.BlockChain{genesis.BlockChain, peer.BlockChain})
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	defer genesis.Kill()
+	defer peer.Kill()
+
+	b := emptyChild(genesis.BlockChain.LastBlock, 1)
+	genesis.HandleMinerBlock(b)
+	time.Sleep(200 * time.Millisecond)
+	if peer.TipHash() == b.Hash() {
+		t.Errorf("Expected the block's announcement to be delayed")
+	}
+	for i := 0; i < 40 && peer.TipHash() != b.Hash(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if peer.TipHash() != b.Hash() {
+		t.Errorf("Expected the block to be announced after the delay")
+	}
+}
+
+func TestStaleTip(t *testing.T) {
+	genesis := NewGenesisNode()
+	conf := setNodeConfig(pkg.DefaultConfig(GetFreePort()), 1)
+	conf.TargetBlockInterval = 100 * time.Millisecond
+	conf.StaleTipMultiple = 2
+	stale := NewNode(conf)
+	defer CleanUp([]*blockchain.BlockChain{genesis.BlockChain, stale.BlockChain})
+	StartCluster([]*pkg.Node{genesis, stale})
+	defer genesis.Kill()
+	defer stale.Kill()
+
+	// the stale node knows of the genesis node, but isn't its peer, so
+	// it only hears of the block once it goes looking
+	b := emptyChild(genesis.BlockChain.LastBlock, 1)
+	genesis.HandleMinerBlock(b)
+	if err := stale.AddressDB.Add(address.New(genesis.Address, 0)); err != nil {
+		t.Fatalf("Failed to add the genesis node's address: %v", err)
+	}
+	if _, isStale := stale.TipAge(); isStale {
+		t.Errorf("Expected a new tip not to be stale")
+	}
+	for i := 0; i < 40 && stale.TipHash() != b.Hash(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if stale.TipHash() != b.Hash() {
+		t.Fatalf("Expected the stale node to fetch the block from a new peer")
+	}
+	warned := false
+	for _, e := range stale.Journal.Events() {
+		warned = warned || e.Kind == journal.StaleTip
+	}
+	if !warned {
+		t.Errorf("Expected the stale tip to be recorded")
+	}
+
+	status, err := address.New(stale.Address, 0).GetNodeStatusRPC(&pro.Empty{})
+	if err != nil {
+		t.Fatalf("Failed to get the node status: %v", err)
+	}
+	if status.Stale || status.Tip.Hash != b.Hash() || status.Peers != 1 {
+		t.Errorf("Expected a fresh tip at the block with one peer, got %v", status)
+	}
+}
+
+func TestForwardDoubleSpendingBranch(t *testing.T) {
+	node := NewGenesisNode()
+	defer CleanUp([]*blockchain.BlockChain{node.BlockChain})
+	node.Start()
+	defer node.Kill()
+	forward := func(b *block.Block) error {
+		_, err := address.New(node.Address, 0).ForwardBlockRPC(block.EncodeBlock(b))
+		return err
+	}
+
+	coinbase := &block.Transaction{
+		Version:  1,
+		Outputs:  []*block.TransactionOutput{{Amount: 5, LockingScript: []byte{1}}},
+		LockTime: block.CoinbaseLockTime(2),
+	}
+	base := emptyChild(node.BlockChain.LastBlock, 1)
+	base.Transactions = []*block.Transaction{coinbase}
+	a1 := emptyChild(base, 2)
+	a1.Transactions = []*block.Transaction{spend(coinbase, 0, 2)}
+	for _, b := range []*block.Block{base, a1} {
+		if err := forward(b); err != nil {
+			t.Fatalf("Failed to forward %v: %v", b.NameTag(), err)
+		}
+	}
+
+	// a side branch spends the coin the active chain already spent,
+	// which is only a double spend on the active chain
+	b1 := emptyChild(base, 3)
+	b1.Transactions = []*block.Transaction{spend(coinbase, 0, 3)}
+	b2 := emptyChild(b1, 4)
+	if err := forward(b1); err != nil {
+		t.Fatalf("Expected a block spending coins on its own branch to be taken: %v", err)
+	}
+	if err := forward(b2); err != nil {
+		t.Fatalf("Failed to forward %v: %v", b2.NameTag(), err)
+	}
+	if node.TipHash() != b2.Hash() {
+		t.Errorf("Expected the node to reorg to the heavier branch")
+	}
+
+	// a block extending the tip is still checked against its coins
+	twice := emptyChild(b2, 5)
+	twice.Transactions = []*block.Transaction{spend(coinbase, 0, 4)}
+	if err := forward(twice); err == nil || node.TipHash() != b2.Hash() {
+		t.Errorf("Expected a block double spending on the active chain to be rejected")
+	}
+}
+
+func TestForwardBlockNotTakenIsNotRelayed(t *testing.T) {
+	cluster := NewCluster(2)
+	node, other := cluster[0], cluster[1]
+	defer CleanUp([]*blockchain.BlockChain{node.BlockChain, other.BlockChain})
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	defer node.Kill()
+	defer other.Kill()
+	forward := func(b *block.Block) error {
+		_, err := address.New(node.Address, 0).ForwardBlockRPC(block.EncodeBlock(b))
+		return err
+	}
+
+	// a block the node already has isn't relayed again, and the peer
+	// that sent it isn't blamed for it
+	stored := emptyChild(node.BlockChain.LastBlock, 1)
+	node.BlockChain.HandleBlock(stored)
+	if err := forward(stored); err != nil {
+		t.Errorf("Expected a duplicate block not to be treated as invalid, got %v", err)
+	}
+	// nor is one from too far in the future
+	future := emptyChild(node.BlockChain.LastBlock, 2)
+	future.Header.Timestamp = uint32(time.Now().Unix() + blockchain.MaxFutureBlockTime + 60)
+	if err := forward(mine(future)); err != nil {
+		t.Errorf("Expected a block from the future not to be treated as invalid, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if other.BlockChain.HasBlock(stored.Hash()) || other.BlockChain.HasBlock(future.Hash()) {
+		t.Errorf("Expected blocks the node didn't take not to be relayed")
+	}
+
+	// an invalid block is blamed on the peer
+	unmined := emptyChild(node.BlockChain.LastBlock, 3)
+	unmined.Header.DifficultyTarget = "1"
+	if err := forward(unmined); err == nil {
+		t.Errorf("Expected an invalid block to be rejected")
+	}
+}
+
+func TestBlocksOnlyPeerGetsBlocksButNotTransactions(t *testing.T) {
+	genesis := NewGenesisNode()
+	conf := setNodeConfig(pkg.DefaultConfig(GetFreePort()), 1)
+	conf.BlocksOnly = true
+	blocksOnly := NewNode(conf)
+	relay := NewNode(setNodeConfig(pkg.DefaultConfig(GetFreePort()), 2))
+	cluster := []*pkg.Node{genesis, blocksOnly, relay}
+	defer CleanUp([]*blockchain.BlockChain{genesis.BlockChain, blocksOnly.BlockChain, relay.BlockChain})
+	StartCluster(cluster)
+	ConnectCluster(cluster)
+	defer genesis.Kill()
+	defer relay.Kill()
+	for _, n := range []*pkg.Node{genesis, relay} {
+		if p := n.PeerDb.Get(blocksOnly.Address); p == nil || !p.Addr.BlocksOnly {
+			t.Fatalf("Expected the peers to have negotiated blocks-only relay")
+		}
+	}
+	if p := genesis.PeerDb.Get(relay.Address); p == nil || p.Addr.BlocksOnly {
+		t.Fatalf("Expected the other peer to relay transactions")
+	}
+	// the blocks-only peer would take a transaction it was sent,
+	// so only its peers keep transactions from it
+	for _, p := range blocksOnly.PeerDb.List() {
+		p.Addr.BlocksOnly = false
+	}
+
+	// the genesis node relays a transaction to every peer but the blocks-only one
+	tx := &block.Transaction{
+		Version: 1,
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: genesis.BlockChain.LastBlock.Transactions[0].Hash()}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{1}}},
+	}
+	_, err := address.New(genesis.Address, 0).ForwardTransactionRPC(block.EncodeTransactionWithAddress(tx, relay.Address))
+	if err != nil {
+		t.Fatalf("Failed to forward %v: %v", tx.NameTag(), err)
+	}
+	b := emptyChild(genesis.BlockChain.LastBlock, 1)
+	genesis.HandleMinerBlock(b)
+	for i := 0; i < 40 && blocksOnly.TipHash() != b.Hash(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if blocksOnly.TipHash() != b.Hash() {
+		t.Errorf("Expected the blocks-only peer to receive the block")
+	}
+	blocksOnly.Kill()
+	CheckTransactionSeen(t, []*pkg.Node{genesis, relay}, tx)
+	if _, ok := blocksOnly.SeenTransactions[tx.Hash()]; ok {
+		t.Errorf("Expected the blocks-only peer not to be sent transactions")
+	}
+}
+
+func TestForwardMalformedTransaction(t *testing.T) {
+	node := NewGenesisNode()
+	defer CleanUp([]*blockchain.BlockChain{node.BlockChain})
+	forward := func(tx *block.Transaction) error {
+		_, err := node.ForwardTransaction(context.Background(), block.EncodeTransactionWithAddress(tx, "127.0.0.1:1"))
+		return err
+	}
+
+	coinbase := node.BlockChain.LastBlock.Transactions[0]
+	badHash := &block.Transaction{
+		Version: 1,
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: "not a hash"}},
+		Outputs: []*block.TransactionOutput{{Amount: 1, LockingScript: []byte{1}}},
+	}
+	tooMuch := &block.Transaction{
+		Version: 2,
+		Inputs:  []*block.TransactionInput{{ReferenceTransactionHash: coinbase.Hash()}},
+		Outputs: []*block.TransactionOutput{
+			{Amount: block.MaxMoney, LockingScript: []byte{1}},
+			{Amount: 1, LockingScript: []byte{1}},
+		},
+	}
+	for _, tx := range []*block.Transaction{badHash, tooMuch} {
+		if err := forward(tx); err == nil {
+			t.Errorf("Expected malformed %v to be rejected", tx.NameTag())
+		}
+		if _, ok := node.SeenTransactions[tx.Hash()]; ok {
+			t.Errorf("Expected malformed %v not to be seen", tx.NameTag())
+		}
+	}
+}
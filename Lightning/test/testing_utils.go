@@ -8,7 +8,6 @@ import (
 	"Coin/pkg/pro"
 	"Coin/pkg/utils"
 	"Coin/pkg/wallet"
-	"fmt"
 	"github.com/phayes/freeport"
 	"log"
 	"os"
@@ -21,23 +20,27 @@ func setNodeConfig(conf *pkg.Config, i int) *pkg.Config {
 	conf.ChainConfig.BlockInfoDBPath = "blockinfodata" + strconv.Itoa(i)
 	conf.ChainConfig.CoinDBPath = "coindata" + strconv.Itoa(i)
 	conf.ChainConfig.ChainWriterDBPath = "data" + strconv.Itoa(i)
+	conf.ChainConfig.DifficultyTarget = testDifficultyTarget
+	conf.MinerConfig.InitialPOWDifficulty = []byte(testDifficultyTarget)
+	conf.JournalConfig.Path = "journal" + strconv.Itoa(i)
+	conf.BackupConfig.Path = "backup" + strconv.Itoa(i)
+	conf.CheckpointConfig.Path = "checkpoint" + strconv.Itoa(i)
 	return conf
 }
 
 // CleanUp is used to clean up testing side effects, where num is
 // the number of blockchains (which create directories)
 func CleanUp(chains []*blockchain.BlockChain) {
-	paths := []string{"coindata", "blockinfodata", "data"}
+	paths := []string{"coindata", "blockinfodata", "data", "journal", "backup", "checkpoint"}
 	for i, chain := range chains {
-		// manually close the levelDBs
-		chain.BlockInfoDB.Close()
-		chain.CoinDB.Close()
+		// close the levelDBs and release the directories
+		chain.Close()
 		// erase the paths
 		for _, path := range paths {
 			path += strconv.Itoa(i)
 			if _, err := os.Stat(path); !os.IsNotExist(err) {
 				if err2 := os.RemoveAll(path); err2 != nil {
-					fmt.Errorf("coudld not remove %v", path)
+					utils.Debug.Printf("could not remove %v", path)
 				}
 			}
 		}
@@ -65,7 +68,7 @@ func NewCluster(n int) []*pkg.Node {
 	cluster := []*pkg.Node{NewGenesisNode()}
 	for i := 1; i < n; i++ {
 		conf := setNodeConfig(pkg.DefaultConfig(GetFreePort()), i)
-		cluster = append(cluster, pkg.New(conf))
+		cluster = append(cluster, NewNode(conf))
 	}
 	return cluster
 }
@@ -95,7 +98,16 @@ func ConnectCluster(c []*pkg.Node) {
 }
 
 func NewGenesisNode() *pkg.Node {
-	return pkg.New(setNodeConfig(GenesisConfig(GetFreePort()), 0))
+	return NewNode(setNodeConfig(GenesisConfig(GetFreePort()), 0))
+}
+
+// NewNode returns the node for conf, exiting if it can't be made.
+func NewNode(conf *pkg.Config) *pkg.Node {
+	n, err := pkg.New(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return n
 }
 
 func CheckMainChains(t *testing.T, nodes []*pkg.Node) {
@@ -186,6 +198,10 @@ func CreateDifficultyTarget(numZeros int) []byte {
 	return utils.CalcPOWD(numZeros)
 }
 
+// testDifficultyTarget is the proof of work target test BlockChains
+// and miners use, easy enough that a Block is mined in a few tries.
+var testDifficultyTarget = string(CreateDifficultyTarget(0))
+
 func CreateHardestDifficultyTarget() []byte {
 	ret := ""
 	for i := 0; i < 64; i++ {
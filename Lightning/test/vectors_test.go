@@ -0,0 +1,20 @@
+package test
+
+import (
+	"Coin/pkg/vectors"
+	"testing"
+)
+
+func TestConsensusVectors(t *testing.T) {
+	for _, v := range vectors.Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			coinDB, cleanUp := newTestCoinDB(t)
+			defer cleanUp()
+			genBlock := GenesisBlock()
+			coinDB.StoreBlock(genBlock.Transactions)
+			if err := vectors.Run(coinDB, genBlock.Transactions[0], v); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
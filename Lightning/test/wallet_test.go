@@ -0,0 +1,767 @@
+package test
+
+import (
+	"Coin/pkg/block"
+	"Coin/pkg/id"
+	"Coin/pkg/lightning"
+	"Coin/pkg/miner"
+	"Coin/pkg/mnemonic"
+	"Coin/pkg/pro"
+	"Coin/pkg/wallet"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVaultWithdrawalWaitsForDelay(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 2, 100)
+	recovery, _ := id.CreateSimpleID()
+
+	deposit := w.CreateVaultDeposit(150, 5, recovery.GetPublicKeyBytes(), 2)
+	if deposit == nil {
+		t.Fatalf("Expected the vault deposit to be created")
+	}
+	AssertBalance(t, w, 0)
+	if w.InitiateVaultWithdrawal(deposit.Hash(), 0, 5) != nil {
+		t.Errorf("Expected a withdrawal from an unconfirmed vault to fail")
+	}
+	w.HandleBlock([]*block.Transaction{deposit})
+
+	unvault := w.InitiateVaultWithdrawal(deposit.Hash(), 0, 5)
+	if unvault == nil {
+		t.Fatalf("Expected the withdrawal to start")
+	}
+	w.HandleBlock([]*block.Transaction{unvault})
+	if w.CompleteVaultWithdrawal(unvault.Hash(), 0, 5) != nil {
+		t.Errorf("Expected the withdrawal to wait for the delay")
+	}
+	w.HandleBlock(MockedBlock().Transactions)
+	if w.CompleteVaultWithdrawal(unvault.Hash(), 0, 5) == nil {
+		t.Errorf("Expected the withdrawal to complete after the delay")
+	}
+}
+
+func TestVaultRecoveryCancelsWithdrawal(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 1, 100)
+	recovery, _ := id.CreateSimpleID()
+
+	deposit := w.CreateVaultDeposit(90, 5, recovery.GetPublicKeyBytes(), 10)
+	w.HandleBlock([]*block.Transaction{deposit})
+	unvault := w.InitiateVaultWithdrawal(deposit.Hash(), 0, 5)
+	w.HandleBlock([]*block.Transaction{unvault})
+
+	thief, _ := id.CreateSimpleID()
+	if w.CancelVaultWithdrawal(unvault.Hash(), 0, 5, thief) != nil {
+		t.Errorf("Expected only the recovery key to be able to cancel")
+	}
+	cancel := w.CancelVaultWithdrawal(unvault.Hash(), 0, 5, recovery)
+	if cancel == nil {
+		t.Fatalf("Expected the recovery key to cancel the withdrawal")
+	}
+	if cancel.Outputs[0].Amount != 80 {
+		t.Errorf("Expected 80 to be recovered, got %v", cancel.Outputs[0].Amount)
+	}
+	if w.GetVaultCoin(unvault.Hash(), 0) != nil {
+		t.Errorf("Expected the cancelled vault to be forgotten")
+	}
+}
+
+// swapWallet returns a wallet that settles swaps with the invoices of a
+// lightning node, the way a node wires them together.
+func swapWallet() (*wallet.Wallet, *lightning.LightningNode) {
+	w := CreateMockedWallet()
+	ln := lightning.New(lightning.DefaultConfig(GetFreePort()))
+	w.LookupPreimage = ln.SwapPreimage
+	w.OnSwapPreimage = ln.SettleSwap
+	return w, ln
+}
+
+// spendsSwap waits for w to broadcast a Transaction spending an output
+// of lock, and returns it, or nil if w doesn't.
+func spendsSwap(w *wallet.Wallet, lock *block.Transaction) *block.Transaction {
+	for {
+		select {
+		case tx := <-w.TransactionRequests:
+			if len(tx.Inputs) == 1 && tx.Inputs[0].ReferenceTransactionHash == lock.Hash() {
+				return tx
+			}
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+}
+
+func TestSwapClaimSettlesInvoices(t *testing.T) {
+	sender, senderLN := swapWallet()
+	FillWalletWithCoins(sender, 1, 100)
+	recipient, recipientLN := swapWallet()
+	lookup := recipient.LookupPreimage
+	recipient.LookupPreimage = nil
+
+	paymentHash, err := recipientLN.RequestSwap(50, "swap")
+	if err != nil {
+		t.Fatalf("Failed to request swap: %v", err)
+	}
+	if err = senderLN.PayWithSwap(paymentHash, 50, "swap"); err != nil {
+		t.Fatalf("Failed to record payment: %v", err)
+	}
+	lock := sender.LockSwap(50, 5, recipient.Id.GetPublicKeyBytes(), paymentHash, 10)
+	if lock == nil {
+		t.Fatalf("Expected the swap to be locked")
+	}
+	AssertBalance(t, sender, 0)
+	sender.HandleBlock([]*block.Transaction{lock})
+	recipient.HandleBlock([]*block.Transaction{lock})
+	if recipient.GetSwapCoin(lock.Hash(), 0) == nil {
+		t.Fatalf("Expected the recipient to track the swap")
+	}
+	if recipient.ClaimSwap(lock.Hash(), 0, []byte("the wrong preimage"), 5) != nil {
+		t.Errorf("Expected claiming with the wrong preimage to fail")
+	}
+	if sender.RefundSwap(lock.Hash(), 0, 5) != nil {
+		t.Errorf("Expected the refund to wait for the timeout")
+	}
+
+	// once the recipient can look up the preimage, it claims the swap
+	recipient.LookupPreimage = lookup
+	recipient.HandleBlock(MockedBlock().Transactions)
+	claim := spendsSwap(recipient, lock)
+	if claim == nil {
+		t.Fatalf("Expected the recipient to claim the swap")
+	}
+	if !recipientLN.Invoices.GetInvoice(paymentHash).Settled() {
+		t.Errorf("Expected claiming the swap to settle the recipient's invoice")
+	}
+	sender.HandleBlock([]*block.Transaction{claim})
+	if !senderLN.Invoices.GetInvoice(paymentHash).Settled() {
+		t.Errorf("Expected the claim to reveal the preimage to the sender")
+	}
+	if sender.GetSwapCoin(lock.Hash(), 0) != nil {
+		t.Errorf("Expected the claimed swap to be forgotten")
+	}
+}
+
+func TestSwapRefundsAfterTimeout(t *testing.T) {
+	sender, senderLN := swapWallet()
+	FillWalletWithCoins(sender, 1, 100)
+	recipient, _ := id.CreateSimpleID()
+	paymentHash := make([]byte, 32)
+	senderLN.PayWithSwap(paymentHash, 50, "swap")
+
+	lock := sender.LockSwap(50, 5, recipient.GetPublicKeyBytes(), paymentHash, 2)
+	sender.HandleBlock([]*block.Transaction{lock})
+	sender.HandleBlock(MockedBlock().Transactions)
+	refund := spendsSwap(sender, lock)
+	if refund == nil {
+		t.Fatalf("Expected the swap to be refunded after the timeout")
+	}
+	if refund.Outputs[0].Amount != 45 {
+		t.Errorf("Expected 45 to be refunded, got %v", refund.Outputs[0].Amount)
+	}
+	if senderLN.Invoices.GetInvoice(paymentHash).Settled() {
+		t.Errorf("Expected a refunded payment not to be settled")
+	}
+}
+
+func TestMaybeConsolidateDuringQuietHours(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 8, 10)
+	w.Config.ConsolidationTarget = 5
+	w.Config.QuietHoursStart = 23
+	w.Config.QuietHoursEnd = 2
+
+	busy := time.Date(2021, 1, 1, 12, 0, 0, 0, time.Local)
+	if w.MaybeConsolidate(busy) != nil {
+		t.Errorf("Expected no consolidation outside of quiet hours")
+	}
+	w.FeeEstimator = func() uint32 { return w.Config.ConsolidationMaxFee + 1 }
+	quiet := time.Date(2021, 1, 1, 1, 0, 0, 0, time.Local)
+	if w.MaybeConsolidate(quiet) != nil {
+		t.Errorf("Expected no consolidation while fees are high")
+	}
+	w.FeeEstimator = nil
+	tx := w.MaybeConsolidate(quiet)
+	if tx == nil {
+		t.Fatalf("Expected the wallet to consolidate")
+	}
+	AssertSize(t, len(tx.Inputs), 4)
+	AssertSize(t, len(w.CoinCollection), 4)
+	AssertSize(t, len(w.HistoryWithTag("consolidation")), 1)
+}
+
+func TestUnseenSpentCoinsExpire(t *testing.T) {
+	w := CreateMockedWallet()
+	w.Config.UnseenExpiry = 3
+	FillWalletWithCoins(w, 2, 100)
+	recipient, _ := id.CreateSimpleID()
+
+	tx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+	AssertBalance(t, w, 100)
+	for i := 0; i < 2; i++ {
+		w.HandleBlock(MockedBlock().Transactions)
+	}
+	stuck := w.StuckTransactions(2)
+	if len(stuck) != 1 || stuck[0].TransactionHash != tx.Hash() || stuck[0].Amount != 100 {
+		t.Fatalf("Expected the transaction to be stuck, got %v", stuck)
+	}
+	w.HandleBlock(MockedBlock().Transactions)
+	AssertSize(t, 0, len(w.UnseenSpentCoins))
+	AssertBalance(t, w, 200)
+}
+
+func TestUnseenSpentCoinsConflictAndAbandon(t *testing.T) {
+	w := CreateMockedWallet()
+	// the coins must come from one transaction, so that they have different outpoints
+	funding := MockedBlockWithNCoins(w, 1, 100).Transactions[0]
+	funding.Outputs = append(funding.Outputs, &block.TransactionOutput{Amount: 100, LockingScript: funding.Outputs[0].LockingScript})
+	w.HandleBlock([]*block.Transaction{funding})
+	for i := 0; i < 6; i++ {
+		w.HandleBlock(MockedBlock().Transactions)
+	}
+	recipient, _ := id.CreateSimpleID()
+
+	tx := w.RequestTransaction(150, 5, recipient.GetPublicKeyBytes(), nil)
+	AssertBalance(t, w, 0)
+	// a block spends one of the transaction's coins in a different transaction
+	conflict := &block.Transaction{
+		Version: tx.Version + 1,
+		Inputs:  tx.Inputs[:1],
+		Outputs: []*block.TransactionOutput{{Amount: 90, LockingScript: []byte{}}},
+	}
+	w.HandleBlock([]*block.Transaction{conflict})
+	if _, ok := w.UnseenSpentCoins[tx.Hash()]; ok {
+		t.Errorf("Expected the conflicted transaction to be dropped")
+	}
+	AssertSize(t, 1, len(w.UnconfirmedSpentCoins))
+	AssertBalance(t, w, 100)
+
+	stuckTx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+	if err := w.AbandonTransaction("not a transaction"); err == nil {
+		t.Errorf("Expected abandoning an unknown transaction to fail")
+	}
+	if err := w.AbandonTransaction(stuckTx.Hash()); err != nil {
+		t.Fatalf("Failed to abandon transaction: %v", err)
+	}
+	AssertBalance(t, w, 100)
+	AssertSize(t, 0, len(w.StuckTransactions(0)))
+}
+
+func TestCoinReportsAgeAndDust(t *testing.T) {
+	w := CreateMockedWallet()
+	w.Config.DefaultFee = 5
+	FillWalletWithCoins(w, 1, 100)
+	w.HandleBlock(MockedBlockWithNCoins(w, 1, 5).Transactions)
+	w.HandleBlock(MockedBlockWithNCoins(w, 1, 3).Transactions)
+
+	reports := w.CoinReports()
+	AssertSize(t, len(reports), 3)
+	expected := []struct {
+		amount    uint32
+		age       uint32
+		confirmed bool
+		dust      bool
+	}{{3, 1, false, true}, {5, 2, false, true}, {100, 9, true, false}}
+	for i, e := range expected {
+		r := reports[i]
+		if r.Amount != e.amount || r.Age != e.age || r.Confirmed != e.confirmed || r.Dust != e.dust {
+			t.Errorf("Expected coin %v to be %+v, got %+v", i, e, r)
+		}
+	}
+
+	for i := 0; i < 6; i++ {
+		w.HandleBlock(MockedBlock().Transactions)
+	}
+	AssertSize(t, len(w.DustCoins()), 2)
+	// cheaper fees make coins worth spending again
+	w.FeeEstimator = func() uint32 { return 4 }
+	AssertSize(t, len(w.DustCoins()), 1)
+}
+
+func TestWalletStatePersists(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "wallet")
+	defer os.RemoveAll(dir)
+	config := wallet.DefaultConfig()
+	config.StatePath = filepath.Join(dir, "wallet.json")
+	i, _ := id.CreateSimpleID()
+	w := wallet.New(config, i)
+	FillWalletWithCoins(w, 2, 100)
+	w.HandleBlock(MockedBlockWithNCoins(w, 1, 30).Transactions)
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+
+	// a restarted wallet picks up where it left off
+	restarted := wallet.New(config, i)
+	AssertBalance(t, restarted, w.Balance)
+	AssertSize(t, len(restarted.CoinCollection), len(w.CoinCollection))
+	AssertSize(t, len(restarted.UnconfirmedReceivedCoins), 1)
+	if _, ok := restarted.UnseenSpentCoins[tx.Hash()]; !ok {
+		t.Fatalf("Expected the requested transaction to still be unseen")
+	}
+	if restarted.StateVersion() != w.StateVersion() {
+		t.Errorf("Expected the restarted wallet to have seen %v blocks, got %v", w.StateVersion(), restarted.StateVersion())
+	}
+
+	// and carries on handling blocks the same way, getting its change
+	// and the coin it was waiting on
+	restarted.HandleBlock([]*block.Transaction{tx})
+	for j := 0; j < 6; j++ {
+		restarted.HandleBlock(MockedBlock().Transactions)
+	}
+	AssertSize(t, len(restarted.UnconfirmedSpentCoins), 0)
+	AssertSize(t, len(restarted.UnconfirmedReceivedCoins), 0)
+	AssertBalance(t, restarted, 175)
+
+	// the state of one key is never loaded for another
+	other, _ := id.CreateSimpleID()
+	AssertBalance(t, wallet.New(config, other), 0)
+}
+
+func TestHDWalletKeys(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, 32)
+	master, err := id.NewMasterKey(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive master key: %v", err)
+	}
+	again, _ := id.NewMasterKey(seed)
+	child, _ := master.Derive(0, 1, 2)
+	same, _ := again.Derive(0, 1, 2)
+	other, _ := again.Derive(0, 1, 3)
+	if child.Key.D.Cmp(same.Key.D) != 0 || child.Key.D.Cmp(other.Key.D) == 0 {
+		t.Fatalf("Expected derivation to depend only on the seed and path")
+	}
+
+	config := wallet.DefaultConfig()
+	config.Seed = seed
+	i, _ := id.CreateSimpleID()
+	w := wallet.New(config, i)
+	first, second := w.NewReceiveKey(), w.NewReceiveKey()
+	if bytes.Equal(first, second) || bytes.Equal(first, i.GetPublicKeyBytes()) {
+		t.Fatalf("Expected every receive key to be fresh")
+	}
+
+	// payments to derived keys are the wallet's, and spendable
+	payment := MockedBlockWithNCoins(w, 1, 100).Transactions[0]
+	payment.Outputs[0].LockingScript, _ = proto.Marshal(&pro.PayToPublicKey{PublicKey: second})
+	w.HandleBlock([]*block.Transaction{payment})
+	for j := 0; j < 6; j++ {
+		w.HandleBlock(MockedBlock().Transactions)
+	}
+	AssertBalance(t, w, 100)
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+	if tx == nil || len(tx.Outputs) != 2 {
+		t.Fatalf("Expected a transaction with change")
+	}
+	change := &pro.PayToPublicKey{}
+	_ = proto.Unmarshal(tx.Outputs[1].LockingScript, change)
+	if bytes.Equal(change.PublicKey, i.GetPublicKeyBytes()) || bytes.Equal(change.PublicKey, second) {
+		t.Errorf("Expected the change to go to a fresh key")
+	}
+	if receive, changeIndex := w.NextKeyIndexes(); receive != 2 || changeIndex != 1 {
+		t.Errorf("Expected 2 receive keys and 1 change key to be used, got %v and %v", receive, changeIndex)
+	}
+
+	// a copy of the wallet recognizes keys it hasn't handed out yet
+	copied := wallet.New(config, i)
+	copied.HandleBlock([]*block.Transaction{payment})
+	AssertSize(t, len(copied.UnconfirmedReceivedCoins), 1)
+	if receive, _ := copied.NextKeyIndexes(); receive != 2 {
+		t.Errorf("Expected the copy to move past the key it was paid to, got %v", receive)
+	}
+}
+
+func TestMnemonicRestore(t *testing.T) {
+	words, err := mnemonic.Encode(make([]byte, 16))
+	if err != nil || words != strings.Repeat("abandon ", 11)+"about" {
+		t.Fatalf("Expected the BIP39 mnemonic of a zero seed, got {%v}: %v", words, err)
+	}
+	if _, err = mnemonic.Decode(strings.Repeat("abandon ", 12)); err == nil {
+		t.Errorf("Expected a mnemonic with a bad checksum to be rejected")
+	}
+
+	config := wallet.DefaultConfig()
+	config.Seed = bytes.Repeat([]byte{9}, 32)
+	i, _ := id.CreateSimpleID()
+	w := wallet.New(config, i)
+	var blocks [][]*block.Transaction
+	handle := func(txs []*block.Transaction) {
+		blocks = append(blocks, txs)
+		w.HandleBlock(txs)
+	}
+	confirm := func() {
+		for j := 0; j < 6; j++ {
+			handle(MockedBlock().Transactions)
+		}
+	}
+	payment := MockedBlockWithNCoins(w, 1, 100).Transactions[0]
+	payment.Outputs[0].LockingScript, _ = proto.Marshal(&pro.PayToPublicKey{PublicKey: w.NewReceiveKey()})
+	handle([]*block.Transaction{payment})
+	confirm()
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), nil)
+	handle([]*block.Transaction{tx})
+	confirm()
+	AssertBalance(t, w, 45)
+
+	// the data is lost, and the wallet comes back from its mnemonic
+	words, err = w.Mnemonic()
+	if err != nil || len(strings.Fields(words)) != 24 {
+		t.Fatalf("Expected a 24 word mnemonic, got {%v}: %v", words, err)
+	}
+	restored := wallet.New(wallet.DefaultConfig(), i)
+	if err = restored.RestoreFromMnemonic(strings.ToUpper(words)); err != nil {
+		t.Fatalf("Failed to restore from mnemonic: %v", err)
+	}
+	for _, txs := range blocks {
+		restored.HandleBlock(txs)
+	}
+	AssertBalance(t, restored, 45)
+	AssertSize(t, len(restored.CoinCollection), 1)
+	if receive, change := restored.NextKeyIndexes(); receive != 1 || change != 1 {
+		t.Errorf("Expected the restored wallet to move past its used keys, got %v and %v", receive, change)
+	}
+}
+
+func TestCoinSelection(t *testing.T) {
+	var coins []wallet.CoinInfo
+	for i, amt := range []uint32{10, 20, 50, 70} {
+		coins = append(coins, wallet.CoinInfo{
+			ReferenceTransactionHash: string(rune('a' + i)),
+			TransactionOutput:        &block.TransactionOutput{Amount: amt},
+		})
+	}
+	amounts := func(selected []wallet.CoinInfo) []uint32 {
+		var amts []uint32
+		for _, ci := range selected {
+			amts = append(amts, ci.TransactionOutput.Amount)
+		}
+		return amts
+	}
+	assertAmounts := func(name string, selected []wallet.CoinInfo, expected ...uint32) {
+		if got := amounts(selected); len(got) != len(expected) || fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Expected %v to select %v, got %v", name, expected, got)
+		}
+	}
+	assertAmounts("largest-first", wallet.LargestFirst{}.SelectCoins(coins, 60), 70)
+	assertAmounts("smallest-first", wallet.SmallestFirst{}.SelectCoins(coins, 60), 10, 20, 50)
+	assertAmounts("branch-and-bound", wallet.BranchAndBound{}.SelectCoins(coins, 80), 70, 10)
+	assertAmounts("branch-and-bound without a match", wallet.BranchAndBound{}.SelectCoins(coins, 75), 70, 50)
+	if (wallet.LargestFirst{}).SelectCoins(coins, 151) != nil {
+		t.Errorf("Expected no selection when the coins aren't worth enough")
+	}
+	if _, err := wallet.NewCoinSelector("random"); err == nil {
+		t.Errorf("Expected an unknown coin selection to be rejected")
+	}
+
+	// an exact match needs no change
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 3, 100)
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransactionWithSelector(195, 5, recipient.GetPublicKeyBytes(), nil, wallet.BranchAndBound{})
+	if tx == nil || len(tx.Inputs) != 2 || len(tx.Outputs) != 1 {
+		t.Fatalf("Expected an exact match spending 2 coins without change")
+	}
+	AssertBalance(t, w, 100)
+}
+
+func TestFeeEstimation(t *testing.T) {
+	fe := wallet.NewFeeEstimator(10)
+	if _, ok := fe.EstimateFeerate(1); ok {
+		t.Fatalf("Expected no estimate without any blocks")
+	}
+	// blocks of two transactions, the cheapest paying 1 to 10
+	for rate := uint32(1); rate <= 10; rate++ {
+		fe.ObserveBlock([]uint32{rate + 5, rate})
+	}
+	soon, _ := fe.EstimateFeerate(1)
+	later, _ := fe.EstimateFeerate(6)
+	if soon != 10 || later != 4 {
+		t.Errorf("Expected feerates of 10 within 1 block and 4 within 6, got %v and %v", soon, later)
+	}
+	// a full mempool must be outbid
+	fe.Mempool = func() []uint32 { return []uint32{20, 40, 30} }
+	soon, _ = fe.EstimateFeerate(1)
+	later, _ = fe.EstimateFeerate(6)
+	if soon != 31 || later != 4 {
+		t.Errorf("Expected feerates of 31 within 1 block and 4 within 6, got %v and %v", soon, later)
+	}
+
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 3, 100)
+	w.Fees = fe
+	recipient, _ := id.CreateSimpleID()
+	tx := w.RequestTransactionWithin(50, 1, recipient.GetPublicKeyBytes(), nil)
+	if tx == nil {
+		t.Fatalf("Expected the transaction to be requested")
+	}
+	fee := uint32(100*len(tx.Inputs)) - tx.SumOutputs()
+	if priority := miner.CalculatePriority(tx, 100*uint32(len(tx.Inputs))); priority < 31 {
+		t.Errorf("Expected a feerate of at least 31, got %v from a fee of %v", priority, fee)
+	}
+	AssertBalance(t, w, 300-50-fee-tx.Outputs[1].Amount)
+}
+
+func TestBumpFee(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 3, 100)
+	recipient, _ := id.CreateSimpleID()
+	original := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), &wallet.Memo{Note: "rent"})
+	if original == nil {
+		t.Fatalf("Expected the transaction to be requested")
+	}
+	if _, err := w.BumpFee(original.Hash(), 5); err == nil {
+		t.Errorf("Expected a fee that isn't higher to be refused")
+	}
+
+	// the change pays the higher fee
+	bumped, err := w.BumpFee(original.Hash(), 20)
+	if err != nil {
+		t.Fatalf("Expected the fee to be bumped, got %v", err)
+	}
+	if len(bumped.Inputs) != 1 || bumped.Outputs[0].Amount != 50 || bumped.Outputs[1].Amount != 30 {
+		t.Errorf("Expected the same input to pay 50 with 30 change")
+	}
+	select {
+	case r := <-w.Replacements:
+		if r.Original != original.Hash() || r.Transaction != bumped {
+			t.Errorf("Expected the node to be handed the replacement")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the node to be handed the replacement")
+	}
+	AssertBalance(t, w, 200)
+	if _, err = w.BumpFee(original.Hash(), 30); err == nil {
+		t.Errorf("Expected a replaced transaction not to be bumped again")
+	}
+
+	// the miner takes the replacement over the original, but not back
+	tp := miner.NewTxPool(miner.DefaultConfig(0))
+	tp.Add(original, 100)
+	tp.Add(bumped, 100)
+	tp.Add(original, 100)
+	if tp.Length() != 1 || !tp.TxQ.Has(bumped) {
+		t.Errorf("Expected the pool to hold only the replacement")
+	}
+
+	// more coins pay a fee the change can't
+	again, err := w.BumpFee(bumped.Hash(), 120)
+	if err != nil {
+		t.Fatalf("Expected the fee to be bumped, got %v", err)
+	}
+	if len(again.Inputs) != 2 || again.Outputs[0].Amount != 50 || again.Outputs[1].Amount != 30 {
+		t.Errorf("Expected another coin to pay 50 with 30 change")
+	}
+	<-w.Replacements
+	AssertBalance(t, w, 100)
+	if _, ok := w.UnseenSpentCoins[again.Hash()]; !ok || len(w.UnseenSpentCoins) != 1 {
+		t.Errorf("Expected only the last replacement to be waiting to be mined")
+	}
+	e := w.GetHistoryEntry(original.Hash())
+	if e.ReplacedBy != bumped.Hash() || w.GetHistoryEntry(bumped.Hash()).ReplacedBy != again.Hash() {
+		t.Errorf("Expected the History to record each replacement")
+	}
+	if last := w.GetHistoryEntry(again.Hash()); last.Replaces != bumped.Hash() || last.Fee != 120 || last.Amount != 50 || last.Note != "rent" {
+		t.Errorf("Expected the History to record the replacement's fee, with the original's amount and memo")
+	}
+}
+
+func TestBumpFeeKeepsPaymentsToOurselves(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 2, 100)
+	// a payment to one of the wallet's own keys, with no change
+	original := w.RequestTransaction(95, 5, w.Id.GetPublicKeyBytes(), nil)
+	if original == nil || len(original.Outputs) != 1 {
+		t.Fatalf("Expected a transaction with a single output to be requested")
+	}
+	bumped, err := w.BumpFee(original.Hash(), 10)
+	if err != nil {
+		t.Fatalf("Expected the fee to be bumped, got %v", err)
+	}
+	<-w.Replacements
+	if len(bumped.Inputs) != 2 || bumped.Outputs[0].Amount != 95 || bumped.Outputs[1].Amount != 95 {
+		t.Errorf("Expected another coin to pay 95 with 95 change, not the payment to give way")
+	}
+}
+func TestHistoryRecordsMemos(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 3, 100)
+	recipient, _ := id.CreateSimpleID()
+	memo := &wallet.Memo{Note: "march rent", Tags: []string{"rent", "home"}}
+	rent := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), memo)
+	plain := w.RequestTransaction(20, 5, recipient.GetPublicKeyBytes(), nil)
+	memo.Tags[0] = "changed"
+
+	e := w.GetHistoryEntry(rent.Hash())
+	if e == nil || e.Amount != 50 || e.Fee != 5 || e.Note != "march rent" {
+		t.Fatalf("Expected the History to record the payment and its memo, got %+v", e)
+	}
+	if e.Recipient != fmt.Sprintf("%x", recipient.GetPublicKeyBytes()) {
+		t.Errorf("Expected the History to record the recipient")
+	}
+	if len(e.Tags) != 2 || e.Tags[0] != "rent" {
+		t.Errorf("Expected the History to keep its own copy of the memo's tags, got %v", e.Tags)
+	}
+	if e = w.GetHistoryEntry(plain.Hash()); e == nil || e.Note != "" || len(e.Tags) != 0 {
+		t.Errorf("Expected a payment without a memo to be recorded without one")
+	}
+	if w.GetHistoryEntry(MockedTransaction().Hash()) != nil {
+		t.Errorf("Expected no entry for a transaction the wallet didn't request")
+	}
+	if tagged := w.HistoryWithTag("home"); len(tagged) != 1 || tagged[0].TransactionHash != rent.Hash() {
+		t.Errorf("Expected only the rent to be tagged home")
+	}
+
+	// the export reads back as the History, memos included
+	var out bytes.Buffer
+	if err := w.ExportHistory(&out); err != nil {
+		t.Fatalf("Failed to export the History: %v", err)
+	}
+	var exported []*wallet.HistoryEntry
+	if err := json.Unmarshal(out.Bytes(), &exported); err != nil {
+		t.Fatalf("Failed to read the exported History: %v", err)
+	}
+	AssertSize(t, len(exported), 2)
+	for i, e := range exported {
+		if !reflect.DeepEqual(e, w.History[i]) {
+			t.Errorf("Expected exported entry %v to be %+v, got %+v", i, w.History[i], e)
+		}
+	}
+	out.Reset()
+	if err := CreateMockedWallet().ExportHistory(&out); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("Expected an empty History to export as an empty array, got %q", out.String())
+	}
+}
+
+func TestHistoryReloads(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "history")
+	defer os.RemoveAll(dir)
+	config := wallet.DefaultConfig()
+	config.HistoryPath = filepath.Join(dir, "history.jsonl")
+	i, _ := id.CreateSimpleID()
+	w := wallet.New(config, i)
+	FillWalletWithCoins(w, 3, 100)
+	recipient, _ := id.CreateSimpleID()
+	rent := w.RequestTransaction(50, 5, recipient.GetPublicKeyBytes(), &wallet.Memo{Note: "rent", Tags: []string{"home"}})
+	bumped, err := w.BumpFee(rent.Hash(), 20)
+	if err != nil {
+		t.Fatalf("Expected the fee to be bumped, got %v", err)
+	}
+	<-w.Replacements
+
+	// a restarted wallet reads its History back, working out what
+	// was replaced
+	reloaded := wallet.New(config, i)
+	AssertSize(t, len(reloaded.History), 2)
+	if e := reloaded.GetHistoryEntry(rent.Hash()); e == nil || e.Note != "rent" || e.ReplacedBy != bumped.Hash() {
+		t.Errorf("Expected the reloaded History to have the memo and the replacement, got %+v", e)
+	}
+	if e := reloaded.GetHistoryEntry(bumped.Hash()); e == nil || e.Replaces != rent.Hash() || e.Fee != 20 {
+		t.Errorf("Expected the reloaded History to have the replacement, got %+v", e)
+	}
+	if tagged := reloaded.HistoryWithTag("home"); len(tagged) != 2 {
+		t.Errorf("Expected both entries to keep their tags, got %v", len(tagged))
+	}
+
+	// a line cut short by a crash is skipped, and the rest is kept
+	file, err := os.OpenFile(config.HistoryPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open the History: %v", err)
+	}
+	file.WriteString(`{"transaction_hash":"abc","amou`)
+	file.Close()
+	truncated := wallet.New(config, i)
+	AssertSize(t, len(truncated.History), 2)
+	if truncated.History[1].TransactionHash != bumped.Hash() {
+		t.Errorf("Expected the entries before the cut to be kept")
+	}
+
+	// without a HistoryPath nothing is read back
+	config.HistoryPath = ""
+	AssertSize(t, len(wallet.New(config, i).History), 0)
+}
+
+func TestRequestTransactionMulti(t *testing.T) {
+	w := CreateMockedWallet()
+	FillWalletWithCoins(w, 3, 100)
+	var payments []wallet.Payment
+	var recipients []string
+	for _, amount := range []uint32{30, 50, 20} {
+		recipient, _ := id.CreateSimpleID()
+		payments = append(payments, wallet.Payment{RecipientPK: recipient.GetPublicKeyBytes(), Amount: amount})
+		recipients = append(recipients, fmt.Sprintf("%x", recipient.GetPublicKeyBytes()))
+	}
+
+	// nothing to pay, or nobody to pay it to, spends nothing
+	if w.RequestTransactionMulti(nil, 10, nil) != nil {
+		t.Errorf("Expected a request without payments to be refused")
+	}
+	bad := append([]wallet.Payment{}, payments...)
+	bad[1] = wallet.Payment{RecipientPK: []byte{}, Amount: 50}
+	if w.RequestTransactionMulti(bad, 10, nil) != nil {
+		t.Errorf("Expected a payment without a recipient to be refused")
+	}
+	AssertBalance(t, w, 300)
+	AssertSize(t, len(w.CoinCollection), 3)
+	AssertSize(t, len(w.History), 0)
+
+	tx := w.RequestTransactionMulti(payments, 10, &wallet.Memo{Note: "split"})
+	if tx == nil {
+		t.Fatalf("Expected the payments to be requested")
+	}
+	// an output for each payment, in order, then the change
+	AssertSize(t, len(tx.Outputs), len(payments)+1)
+	for i, p := range payments {
+		pK := &pro.PayToPublicKey{}
+		if err := proto.Unmarshal(tx.Outputs[i].LockingScript, pK); err != nil || !bytes.Equal(pK.GetPublicKey(), p.RecipientPK) {
+			t.Errorf("Expected output %v to pay payment %v's recipient", i, i)
+		}
+		if tx.Outputs[i].Amount != p.Amount {
+			t.Errorf("Expected output %v to pay %v, got %v", i, p.Amount, tx.Outputs[i].Amount)
+		}
+	}
+	// the coins spent pay the payments and a single fee
+	spent := uint32(0)
+	for _, ci := range w.UnseenSpentCoins[tx.Hash()] {
+		spent += ci.TransactionOutput.Amount
+	}
+	AssertSize(t, len(tx.Inputs), len(w.UnseenSpentCoins[tx.Hash()]))
+	if change := tx.Outputs[len(payments)].Amount; spent != 100+10+change {
+		t.Errorf("Expected %v spent to pay 100 and a fee of 10, with %v change", spent, change)
+	}
+	AssertBalance(t, w, 300-spent)
+	select {
+	case requested := <-w.TransactionRequests:
+		if requested != tx {
+			t.Errorf("Expected the transaction to be handed to the node")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the transaction to be handed to the node")
+	}
+
+	// the History records it once, with the total and every recipient
+	AssertSize(t, len(w.History), 1)
+	e := w.GetHistoryEntry(tx.Hash())
+	if e == nil || e.Amount != 100 || e.Fee != 10 || e.Note != "split" {
+		t.Fatalf("Expected the History to record the total and the fee, got %+v", e)
+	}
+	if e.Recipient != strings.Join(recipients, ",") {
+		t.Errorf("Expected the History to record every recipient, in order, got %v", e.Recipient)
+	}
+
+	// the change comes back once the transaction is confirmed
+	w.HandleBlock([]*block.Transaction{tx})
+	for i := 0; i < 6; i++ {
+		w.HandleBlock(MockedBlock().Transactions)
+	}
+	AssertBalance(t, w, 300-110)
+}